@@ -5,13 +5,16 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"net/url"
 	"os"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/newrelic/newrelic-diagnostics-cli/helpers/httpHelper"
 	"github.com/newrelic/newrelic-diagnostics-cli/output/color"
@@ -52,6 +55,12 @@ type AttachDeps struct{}
 const awsUploadTimeoutSeconds = 7200
 const defaultAttachmentEndpoint = "http://localhost:3000/attachments"
 
+// makeRequest sends the upload requests, tests replace it to simulate a flaky connection
+var makeRequest = httpHelper.MakeHTTPRequest
+
+// uploadBackoff is the wait after the first failed upload attempt, doubled after each following one
+var uploadBackoff = 5 * time.Second
+
 // Upload - takes the license key from ValidateLicenseKey
 // and uploads the output to account
 func Upload(endpoint string, identifyingKey string, timestamp string, dependencies IAttachDeps) {
@@ -90,7 +99,7 @@ func Upload(endpoint string, identifyingKey string, timestamp string, dependenci
 }
 
 func getFilesForUpload(identifyingKey string, timestamp string, filetype string, deps IAttachDeps) UploadFiles {
-	thisFileName := "nrdiag-output." + filetype
+	thisFileName := config.OutputFileName("." + filetype)
 	thisFile := UploadFiles{Path: config.Flags.OutputPath, Filename: thisFileName}
 	thisFile.Filesize = deps.GetFileSize(thisFile.Path + "/" + thisFile.Filename)
 	extension := filepath.Ext(thisFileName)
@@ -125,10 +134,11 @@ func uploadFile(endpoint string, files UploadFiles, attachmentKey string, deps I
 	wrapper := deps.GetWrapper(endpoint, reader, files.Filesize, files.NewFilename, attachmentKey)
 
 	log.Debug("Starting upload")
-	res, err := makeRequest(wrapper)
+	res, err := uploadWithRetries(wrapper)
 
 	if err != nil {
 		log.Info("Error uploading file", err)
+		printRetryCommand(files, wrapper)
 		return nil, err
 	}
 	if res.StatusCode != 200 {
@@ -136,6 +146,7 @@ func uploadFile(endpoint string, files UploadFiles, attachmentKey string, deps I
 		body, _ := ioutil.ReadAll(res.Body)
 		log.Debug("Body was", string(body))
 		log.Debug("headers were", res.Header)
+		printRetryCommand(files, wrapper)
 		return nil, errors.New(res.Status)
 	}
 	log.Debug("Upload finished with status:  ", res.Status)
@@ -156,8 +167,76 @@ func getAttachmentsEndpoint() string {
 	return defaultAttachmentEndpoint
 }
 
-func makeRequest(wrapper httpHelper.RequestWrapper) (*http.Response, error) {
-	return httpHelper.MakeHTTPRequest(wrapper)
+// uploadWithRetries makes an upload request up to -upload-attempts times, backing off exponentially between attempts.
+// Connection errors and 5xx or 429 responses are retried, any other response is returned as is. The payload is
+// rewound before each attempt, so it must be an io.Seeker for the request to be retried
+func uploadWithRetries(wrapper httpHelper.RequestWrapper) (*http.Response, error) {
+	attempts := config.Flags.UploadAttempts
+	if attempts < 1 {
+		attempts = 1
+	}
+	seeker, rewindable := wrapper.Payload.(io.Seeker)
+	if wrapper.Payload != nil && !rewindable {
+		attempts = 1
+	}
+
+	backoff := uploadBackoff
+	var res *http.Response
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if rewindable {
+			if _, seekErr := seeker.Seek(0, io.SeekStart); seekErr != nil {
+				return nil, seekErr
+			}
+		}
+		res, err = makeRequest(wrapper)
+		if !retryableUpload(res, err) || attempt == attempts {
+			break
+		}
+		if err != nil {
+			log.Infof("Upload attempt %d of %d failed: %s. Retrying in %s\n", attempt, attempts, err.Error(), backoff)
+		} else {
+			log.Infof("Upload attempt %d of %d failed with status %s. Retrying in %s\n", attempt, attempts, res.Status, backoff)
+			_, _ = io.Copy(ioutil.Discard, res.Body)
+			res.Body.Close()
+		}
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+	return res, err
+}
+
+func retryableUpload(res *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	return res.StatusCode >= 500 || res.StatusCode == http.StatusTooManyRequests
+}
+
+// printRetryCommand tells the user where the file that could not be uploaded is, and how to upload it without running nrdiag again
+func printRetryCommand(files UploadFiles, wrapper httpHelper.RequestWrapper) {
+	localPath := filepath.Join(files.Path, files.Filename)
+	if absPath, err := filepath.Abs(localPath); err == nil {
+		localPath = absPath
+	}
+	log.Info("The file that failed to upload is available at " + localPath)
+	log.Info("To retry the upload later, run:\n\t" + retryCommand(localPath, wrapper))
+}
+
+// retryCommand returns the curl command making the same upload request as the wrapper with the file at localPath
+func retryCommand(localPath string, wrapper httpHelper.RequestWrapper) string {
+	command := []string{"curl", "-X", wrapper.Method}
+
+	headers := make([]string, 0, len(wrapper.Headers))
+	for header := range wrapper.Headers {
+		headers = append(headers, header)
+	}
+	sort.Strings(headers)
+	for _, header := range headers {
+		command = append(command, "-H", strconv.Quote(header+": "+wrapper.Headers[header]))
+	}
+	command = append(command, "--data-binary", strconv.Quote("@"+localPath), strconv.Quote(wrapper.URL))
+	return strings.Join(command, " ")
 }
 
 func (a AttachDeps) GetFileSize(file string) int64 {
@@ -225,7 +304,7 @@ func UploadLegacy(identifyingKey string, timestamp string) {
 }
 
 func getS3UploadFiles(identifyingKey string, timestamp string, filetype string) UploadFiles {
-	thisFileName := "nrdiag-output." + filetype
+	thisFileName := config.OutputFileName("." + filetype)
 	thisFile := UploadFiles{Path: config.Flags.OutputPath, Filename: thisFileName}
 	thisFile.Path = config.Flags.OutputPath
 	thisFile.Filename = thisFileName
@@ -282,10 +361,11 @@ func uploadAWS(filesToUpload []UploadFiles, attachmentKey string) error {
 			TimeoutSeconds: awsUploadTimeoutSeconds,
 		}
 
-		res, err := httpHelper.MakeHTTPRequest(wrapper)
+		res, err := uploadWithRetries(wrapper)
 
 		if err != nil {
 			log.Info("Error uploading file", err)
+			printRetryCommand(files, wrapper)
 			return err
 		}
 		if res.StatusCode != 200 {
@@ -293,6 +373,7 @@ func uploadAWS(filesToUpload []UploadFiles, attachmentKey string) error {
 			body, _ := ioutil.ReadAll(res.Body)
 			log.Debug("Body was", string(body))
 			log.Debug("headers were", res.Header)
+			printRetryCommand(files, wrapper)
 			return errors.New("error uploading, status code was " + res.Status)
 		}
 		log.Debug(res.Status, "was status code to AWS upload")
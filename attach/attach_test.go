@@ -584,3 +584,116 @@ func FailureGetRequest(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusNotFound)
 	w.Header().Set("Content-Type", "application/json")
 }
+
+func Test_uploadAWSThroughProxy(t *testing.T) {
+	for _, key := range []string{"HTTP_PROXY", "http_proxy", "HTTPS_PROXY", "https_proxy", "NO_PROXY", "no_proxy", "ALL_PROXY", "all_proxy"} {
+		t.Setenv(key, "")
+	}
+
+	var proxiedMethod, proxiedURL, proxiedBody string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// a proxy receives the absolute URL of the upload instead of a path
+		proxiedMethod = r.Method
+		proxiedURL = r.URL.String()
+		body, _ := ioutil.ReadAll(r.Body)
+		proxiedBody = string(body)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer proxy.Close()
+
+	originalFlags := config.Flags
+	defer func() { config.Flags = originalFlags }()
+	config.Flags.Proxy = proxy.URL
+
+	dir := t.TempDir()
+	err := ioutil.WriteFile(dir+"/nrdiag-output.zip", []byte("zipped results"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	uploadURL := "http://upload.nrdiag.invalid/tickets/543210/nrdiag-output.zip"
+	files := []UploadFiles{{
+		Path:     dir,
+		Filename: "nrdiag-output.zip",
+		Filesize: int64(len("zipped results")),
+		URL:      uploadURL,
+	}}
+
+	err = uploadAWS(files, "attachmentkey")
+
+	assert.Nil(t, err)
+	assert.Equal(t, "PUT", proxiedMethod)
+	assert.Equal(t, uploadURL, proxiedURL)
+	assert.Equal(t, "zipped results", proxiedBody)
+}
+
+func Test_uploadWithRetries(t *testing.T) {
+	originalMakeRequest, originalBackoff, originalFlags := makeRequest, uploadBackoff, config.Flags
+	defer func() { makeRequest, uploadBackoff, config.Flags = originalMakeRequest, originalBackoff, originalFlags }()
+	uploadBackoff = 0
+
+	tests := []struct {
+		name       string
+		attempts   int
+		failures   int
+		failWith   int
+		wantCalls  int
+		wantErr    bool
+		wantStatus int
+	}{
+		{name: "succeeds on the first attempt", attempts: 3, failures: 0, wantCalls: 1, wantStatus: 200},
+		{name: "retries connection errors", attempts: 3, failures: 2, wantCalls: 3, wantStatus: 200},
+		{name: "retries server errors", attempts: 3, failures: 2, failWith: 503, wantCalls: 3, wantStatus: 200},
+		{name: "gives up after the last attempt", attempts: 3, failures: 5, wantCalls: 3, wantErr: true},
+		{name: "returns the last server error", attempts: 2, failures: 5, failWith: 500, wantCalls: 2, wantStatus: 500},
+		{name: "does not retry client errors", attempts: 3, failures: 5, failWith: 403, wantCalls: 1, wantStatus: 403},
+		{name: "makes a single attempt when the flag is unset", attempts: 0, failures: 5, wantCalls: 1, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config.Flags.UploadAttempts = tt.attempts
+			calls := 0
+			makeRequest = func(wrapper httpHelper.RequestWrapper) (*http.Response, error) {
+				calls++
+				// every attempt has to send the whole file
+				body, _ := ioutil.ReadAll(wrapper.Payload)
+				if string(body) != "mock" {
+					t.Errorf("attempt %d sent %q, want %q", calls, body, "mock")
+				}
+				if calls <= tt.failures {
+					if tt.failWith == 0 {
+						return nil, errors.New("connection reset by peer")
+					}
+					return &http.Response{StatusCode: tt.failWith, Status: http.StatusText(tt.failWith), Body: ioutil.NopCloser(strings.NewReader(""))}, nil
+				}
+				return &http.Response{StatusCode: 200, Status: "200 OK", Body: ioutil.NopCloser(strings.NewReader(""))}, nil
+			}
+
+			res, err := uploadWithRetries(httpHelper.RequestWrapper{
+				Method:  "PUT",
+				URL:     "https://upload.example.com/nrdiag-output.zip",
+				Payload: bytes.NewReader([]byte("mock")),
+			})
+
+			assert.Equal(t, tt.wantCalls, calls)
+			if tt.wantErr {
+				assert.NotNil(t, err)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+		})
+	}
+}
+
+func Test_retryCommand(t *testing.T) {
+	wrapper := httpHelper.RequestWrapper{
+		Method:  "POST",
+		URL:     "https://diag.example.com/attachments/upload_api?filename=nrdiag-output-2021-04-29T05:15:00Z.zip",
+		Headers: map[string]string{"Attachment-Key": "testKey", "Accept": "*/*"},
+	}
+
+	observed := retryCommand("/tmp/nrdiag output/nrdiag-output.zip", wrapper)
+
+	expected := `curl -X POST -H "Accept: */*" -H "Attachment-Key: testKey" --data-binary "@/tmp/nrdiag output/nrdiag-output.zip" "https://diag.example.com/attachments/upload_api?filename=nrdiag-output-2021-04-29T05:15:00Z.zip"`
+	assert.Equal(t, expected, observed)
+}
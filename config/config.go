@@ -2,22 +2,61 @@ package config
 
 import (
 	"encoding/json"
+	"errors"
 	"flag"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
+	"sync"
 )
 
 // Verbosity is the current log level
 type Verbosity int
 
 const (
+	//Error only logs errors
+	Error Verbosity = iota - 2
+	//Warn logs warnings and errors
+	Warn
 	//Info indicates normal logging level
-	Info Verbosity = iota
+	Info
 	//Verbose indicates additional logging (for troubleshooting the app)
 	Verbose
 )
 
+// logLevels are the accepted values of the -log-level flag
+var logLevels = map[string]Verbosity{
+	"error": Error,
+	"warn":  Warn,
+	"info":  Info,
+	"debug": Verbose,
+}
+
+// ParseLogLevel returns the verbosity of a -log-level value: error, warn, info or debug
+func ParseLogLevel(level string) (Verbosity, error) {
+	verbosity, ok := logLevels[strings.ToLower(strings.TrimSpace(level))]
+	if !ok {
+		return Info, errors.New("unsupported log level '" + level + "'. Accepted values: error, warn, info, debug")
+	}
+	return verbosity, nil
+}
+
+// Accepted values of the -output-format flag
+const (
+	JSONOutputFormat  = "json"
+	JUnitOutputFormat = "junit"
+	HTMLOutputFormat  = "html"
+	SARIFOutputFormat = "sarif"
+	YAMLOutputFormat  = "yaml"
+)
+
+// Accepted values of the -log-format flag
+const (
+	TextLogFormat = "text"
+	JSONLogFormat = "json"
+)
+
 type Region string
 
 const (
@@ -37,26 +76,88 @@ type userFlags struct {
 	SkipVersionCheck   bool
 	YesToAll           bool
 	ShowOverrideHelp   bool
+	ListTasks          bool
+	ShowCatalog        bool
+	Stream             bool
+	NoRedact           bool
+	Anonymize          bool
+	AnonymizeMap       bool
+	Offline            bool
+	SkipPreflight      bool
+	CollectOnly        bool
 	AutoAttach         bool
 	UsageOptOut        bool
 	Proxy              string
 	ProxyUser          string
 	ProxyPassword      string
+	PACURL             string
 	Tasks              string
+	TaskFile           string
+	Single             string
+	Profile            string
+	Exclude            string
 	ConfigFile         string
+	ValidateConfig     string
+	Diff               string
+	Resume             string
+	Verify             string
 	Override           string
 	OutputPath         string
+	OutputName         string
+	OutputFormat       string
+	LogFormat          string
+	LogLevel           string
+	MinStatus          string
+	FailOn             string
 	Filter             string
 	BrowserURL         string
+	CollectorHost      string
+	HTTPTimeout        int
+	UploadAttempts     int
+	Deadline           int
+	Concurrency        int
+	Timings            bool
+	Pprof              string
+	PprofProfiles      bool
+	LogAge             int
+	LogMaxSize         int
+	PayloadMaxSize     int
+	EnvAllow           string
+	EnvDeny            string
+	CABundle           string
+	ClientCert         string
+	ClientKey          string
 	AttachmentEndpoint string
 	Suites             string
 	Include            string
+	IncludePaths       []string
 	APIKey             string
 	Region             string
 	LegacyAttach       bool
 	InNewRelicCLI      bool
 }
 
+// pathList - the value of a flag that can be repeated, e.g. -include-path a -include-path b. A comma separated value
+// is split, as lists are joined with commas in a -profile file
+type pathList []string
+
+func (l *pathList) String() string {
+	if l == nil {
+		return ""
+	}
+	return strings.Join(*l, ",")
+}
+
+// Set - adds the paths of one use of the flag
+func (l *pathList) Set(value string) error {
+	for _, path := range strings.Split(value, ",") {
+		if path = strings.TrimSpace(path); path != "" {
+			*l = append(*l, path)
+		}
+	}
+	return nil
+}
+
 type ConfigFlag struct {
 	Name  string      `json:"name"`
 	Value interface{} `json:"value"`
@@ -75,18 +176,59 @@ func (f userFlags) MarshalJSON() ([]byte, error) {
 		VeryQuiet        bool
 		YesToAll         bool
 		ShowOverrideHelp bool
+		ListTasks        bool
+		ShowCatalog      bool
+		Stream           bool
+		NoRedact         bool
+		Anonymize        bool
+		AnonymizeMap     bool
+		Offline          bool
+		SkipPreflight    bool
+		CollectOnly      bool
 		AutoAttach       bool
 		ProxySpecified   bool
+		PACURL           string
 		SkipVersionCheck bool
 		Tasks            string
+		TaskFile         string
+		Single           string
+		Profile          string
+		Exclude          string
 		ConfigFile       string
+		ValidateConfig   string
+		Diff             string
+		Resume           string
+		Verify           string
 		Override         string
 		OutputPath       string
+		OutputName       string
+		OutputFormat     string
+		LogFormat        string
+		LogLevel         string
+		MinStatus        string
+		FailOn           string
 		Filter           string
 		BrowserURL       string
+		CollectorHost    string
+		HTTPTimeout      int
+		UploadAttempts   int
+		Deadline         int
+		Concurrency      int
+		Timings          bool
+		Pprof            string
+		PprofProfiles    bool
+		LogAge           int
+		LogMaxSize       int
+		PayloadMaxSize   int
+		EnvAllow         string
+		EnvDeny          string
+		CABundle         string
+		ClientCert       string
+		ClientKey        string
 		Suites           string
 		APIKey           string
 		Include          string
+		IncludePaths     []string
 		Region           string
 	}{
 		Verbose:          f.Verbose,
@@ -94,22 +236,72 @@ func (f userFlags) MarshalJSON() ([]byte, error) {
 		VeryQuiet:        f.VeryQuiet,
 		YesToAll:         f.YesToAll,
 		ShowOverrideHelp: f.ShowOverrideHelp,
+		ListTasks:        f.ListTasks,
+		ShowCatalog:      f.ShowCatalog,
+		Stream:           f.Stream,
+		NoRedact:         f.NoRedact,
+		Anonymize:        f.Anonymize,
+		AnonymizeMap:     f.AnonymizeMap,
+		Offline:          f.Offline,
+		SkipPreflight:    f.SkipPreflight,
+		CollectOnly:      f.CollectOnly,
 		AutoAttach:       f.AutoAttach,
 		ProxySpecified:   proxySpecified,
+		PACURL:           f.PACURL,
 		SkipVersionCheck: f.SkipVersionCheck,
 		Tasks:            f.Tasks,
+		TaskFile:         f.TaskFile,
+		Single:           f.Single,
+		Profile:          f.Profile,
+		Exclude:          f.Exclude,
 		ConfigFile:       f.ConfigFile,
+		ValidateConfig:   f.ValidateConfig,
+		Diff:             f.Diff,
+		Resume:           f.Resume,
+		Verify:           f.Verify,
 		Override:         f.Override,
 		OutputPath:       f.OutputPath,
+		OutputName:       f.OutputName,
+		OutputFormat:     f.OutputFormat,
+		LogFormat:        f.LogFormat,
+		LogLevel:         f.LogLevel,
+		MinStatus:        f.MinStatus,
+		FailOn:           f.FailOn,
 		Filter:           f.Filter,
 		BrowserURL:       f.BrowserURL,
+		CollectorHost:    f.CollectorHost,
+		HTTPTimeout:      f.HTTPTimeout,
+		UploadAttempts:   f.UploadAttempts,
+		Deadline:         f.Deadline,
+		Concurrency:      f.Concurrency,
+		Timings:          f.Timings,
+		Pprof:            f.Pprof,
+		PprofProfiles:    f.PprofProfiles,
+		LogAge:           f.LogAge,
+		LogMaxSize:       f.LogMaxSize,
+		PayloadMaxSize:   f.PayloadMaxSize,
+		EnvAllow:         f.EnvAllow,
+		EnvDeny:          f.EnvDeny,
+		CABundle:         f.CABundle,
+		ClientCert:       f.ClientCert,
+		ClientKey:        f.ClientKey,
 		Suites:           f.Suites,
 		Include:          f.Include,
+		IncludePaths:     f.IncludePaths,
 		APIKey:           f.APIKey,
 		Region:           f.Region,
 	})
 }
 
+// DefaultHTTPTimeoutSeconds is the default value of the -http-timeout flag
+const DefaultHTTPTimeoutSeconds = 30
+
+// DefaultUploadAttempts is the default value of the -upload-attempts flag
+const DefaultUploadAttempts = 3
+
+// DefaultConcurrency is the default value of the -concurrency flag
+const DefaultConcurrency = 4
+
 // LogLevel is the current log level for output to the screen
 var LogLevel Verbosity
 
@@ -166,7 +358,21 @@ func ParseFlags() {
 	flag.BoolVar(&Flags.SkipVersionCheck, "skip-version-check", false, "Skips the automatic check for a newer version of the application.")
 
 	flag.StringVar(&Flags.Tasks, "t", defaultString, "alias for -tasks")
-	flag.StringVar(&Flags.Tasks, "tasks", defaultString, "Specific {name of task} - could be comma separated list and/or contain a wildcard (*)")
+	flag.StringVar(&Flags.Tasks, "tasks", defaultString, "Specific {name of task} - could be comma separated list and/or contain a wildcard (*), e.g. 'Base/Collector/*' or '*/Config/*'. Matching is case-insensitive. Tasks matching -exclude are skipped even when listed here")
+	flag.StringVar(&Flags.TaskFile, "task-file", defaultString, "Path to a file listing task identifiers to run, one per line, in the same format as -tasks. Lines starting with '#' are comments. Merged with -tasks")
+	flag.StringVar(&Flags.Single, "single", defaultString, "Run a single task, given by its exact identifier, and its dependencies, then print its result to stdout as JSON. No output file or zip is written. With -v the results of the dependencies are printed too")
+	flag.StringVar(&Flags.Profile, "profile", defaultString, "Path to a JSON or YAML file setting command line options, keyed by their name without the dash, e.g. 'tasks', 'exclude', 'proxy', 'http-timeout' or 'output-format'. Options given on the command line take precedence over the file")
+
+	flag.StringVar(&Flags.Exclude, "exclude", defaultString, "Skip tasks - comma separated list of task identifiers or category prefixes, e.g. 'Base/Collector'. Takes precedence over -t and -suites: an excluded task is reported as skipped even if it was selected")
+	flag.BoolVar(&Flags.ListTasks, "list-tasks", false, "Dry run: print the tasks that would run, in order, for the given -t, -exclude and -suites selection and exit without running them")
+	flag.BoolVar(&Flags.ShowCatalog, "show-catalog", false, "Print a JSON array of every registered task with its explain text and dependencies, sorted by identifier, and exit without running any task")
+	flag.BoolVar(&Flags.Stream, "stream", false, "Write each result to stdout as a JSON object per line (NDJSON) as soon as its task completes. The rest of the screen output moves to stderr. nrdiag-output.json is still written at the end of the run")
+	flag.BoolVar(&Flags.NoRedact, "no-redact", false, "Keep license keys, API keys and proxy passwords in the results written to the output files. By default they are replaced with _REDACTED_. Intended for internal debugging only")
+	flag.BoolVar(&Flags.Anonymize, "anonymize", false, "Replace the hostnames and IP addresses in the results, the run log and the collected text files with stable pseudonyms, such as host-1 and ipv4-1. The mapping back to the actual names is written next to the zip file, not in it")
+	flag.BoolVar(&Flags.AnonymizeMap, "anonymize-map", false, "Add the mapping of the -anonymize pseudonyms to the actual hostnames and IP addresses to the zip file")
+	flag.BoolVar(&Flags.Offline, "offline", false, "Air-gapped mode: skip the tasks making outbound network requests, such as the collector connection checks, and report them as skipped. Config, log and environment collection still run. Also skips the version check, the usage data and the upload of the results")
+	flag.BoolVar(&Flags.CollectOnly, "collect-only", false, "Only run the tasks collecting the config files, logs and environment details for a support ticket, and their dependencies. The checks and the tasks making network requests are not run")
+	flag.BoolVar(&Flags.SkipPreflight, "skip-preflight", false, "Skip the connectivity check made before the network tasks run. When it can't reach the New Relic collector of the region through the proxy, nrdiag reports it and offers to skip the tasks making network requests rather than wait for each of them to time out")
 
 	flag.StringVar(&Flags.Suites, "s", defaultString, "alias for -suites")
 	flag.StringVar(&Flags.Suites, "suites", defaultString, "Specific {name of task suite} - could be comma separated list. If you do '-h suites' it will list all diagnostic task suites that can be run.")
@@ -181,20 +387,32 @@ func ParseFlags() {
 
 	flag.StringVar(&Flags.ConfigFile, "c", defaultString, "alias for -config-file")
 	flag.StringVar(&Flags.ConfigFile, "config-file", defaultString, "Override default config file location. Can be used to specify either a folder to search in addition to the default folders or a specific config file")
+	flag.StringVar(&Flags.ValidateConfig, "validate-config", defaultString, "Only parse and validate the given agent config file, reporting the agent it is for and the line of any syntax error. No network or environment tasks are run")
+	flag.StringVar(&Flags.Diff, "diff", defaultString, "Compare the results of two nrdiag-output.json files and print the tasks whose status changed: -diff old.json new.json. No task is run. Other flags go before -diff")
+	flag.StringVar(&Flags.Verify, "verify", defaultString, "Check that the files of an nrdiag zip file match the sizes and SHA-256 checksums of its manifest.json, e.g. after a transfer: -verify nrdiag-output.zip. No task is run")
+	flag.StringVar(&Flags.Resume, "resume", defaultString, "Run again the tasks selected with -t or -task-file only, and carry over the other results of an earlier nrdiag-output.json into the new output file, each with the RunDate of the run it comes from: -resume nrdiag-output.json -t Base/Collector/*. The tasks the selected ones depend on run again too")
 
 	flag.StringVar(&Flags.Proxy, "p", defaultString, "alias for -proxy")
-	flag.StringVar(&Flags.Proxy, "proxy", defaultString, "Proxy should be in the format http(s)://proxyIp:proxyPort Not necessary in most cases… will override config file if used)")
+	flag.StringVar(&Flags.Proxy, "proxy", defaultString, "Proxy should be in the format http(s)://proxyIp:proxyPort or socks5://proxyIp:proxyPort Not necessary in most cases… will override config file if used)")
 
 	flag.StringVar(&Flags.ProxyUser, "proxy-user", defaultString, "Proxy username, if necessary")
 	flag.StringVar(&Flags.ProxyPassword, "proxy-pw", defaultString, "Proxy pasword, if necessary")
+	flag.StringVar(&Flags.PACURL, "pac-url", defaultString, "URL or path of a proxy auto-configuration (PAC) file, evaluated for each request to pick its proxy. Ignored when -proxy is used")
 
 	flag.StringVar(&Flags.Override, "o", defaultString, "alias for -override")
 	flag.StringVar(&Flags.Override, "override", defaultString, "Specify overrides for detected values. Format <Identifier>.<property>=<value> - example '-o Base/Config/Validate.agentLanguage=PHP'")
 
 	flag.StringVar(&Flags.OutputPath, "output-path", filepath.FromSlash("./"), "Output directory for results. Files will be named 'nrdiag-output.json and nrdiag-output.zip.")
+	flag.StringVar(&Flags.OutputName, "output-name", defaultString, "Name of the output files, without their extension, in place of nrdiag-output. {host} is replaced with the hostname and {ts} with the UTC start time of the run, e.g. 'nrdiag-{host}-{ts}'. Path separators and other unsafe characters are replaced with '_' so the files stay in -output-path")
+	flag.StringVar(&Flags.OutputFormat, "output-format", JSONOutputFormat, "Format of the results file. Accepted values: json, junit, html, sarif, yaml. The other formats are written alongside nrdiag-output.json: junit as a JUnit XML report, nrdiag-output.xml; html as a self-contained report, nrdiag-output.html; sarif as a SARIF 2.1.0 log of the non-successful results, nrdiag-output.sarif; yaml as the same results as the JSON file, nrdiag-output.yaml")
+	flag.StringVar(&Flags.LogFormat, "log-format", TextLogFormat, "Format of the messages nrdiag logs to the screen. Accepted values: text, json. json prints one JSON object per message with its level, timestamp and message, for log pipelines")
+	flag.StringVar(&Flags.LogLevel, "log-level", defaultString, "Minimum level of the messages nrdiag logs to the screen. Accepted values: error, warn, info, debug. Takes precedence over -v, which is the same as debug. Defaults to info")
+	flag.StringVar(&Flags.MinStatus, "min-status", defaultString, "Only write results at or above this severity to nrdiag-output.json. From least to most severe: None, Success, Info, Warning, Failure, Error. The screen output and the zip file keep every result")
+	flag.StringVar(&Flags.FailOn, "fail-on", "failure", "Exit with code 4 when any result is at or above this severity. Accepted values: warning, failure (also fails on error), error. Success, None and Info results never change the exit code")
 
 	flag.BoolVar(&Flags.YesToAll, "y", false, "alias for -yes")
-	flag.BoolVar(&Flags.YesToAll, "yes", false, "Say 'yes' to any prompt that comes up while running.")
+	flag.BoolVar(&Flags.YesToAll, "yes", false, "Say 'yes' to any prompt that comes up while running, e.g. to collect a file that may contain secure information or to upload the results. Needed to run nrdiag unattended")
+	flag.BoolVar(&Flags.YesToAll, "assume-yes", false, "alias for -yes")
 
 	flag.StringVar(&Flags.Filter, "filter", "success,warning,failure,error,info", "Filter results based on status. Accepted values: Success, Warning, Failure, Error, None or Info. Multiple values can be provided in commma separated list. e.g: \"Success,Warning,Failure\"")
 
@@ -203,9 +421,32 @@ func ParseFlags() {
 
 	flag.StringVar(&Flags.BrowserURL, "browser-url", defaultString, "Specify a URL to check for the presence of a New Relic Browser agent")
 
+	flag.StringVar(&Flags.CollectorHost, "collector-host", defaultString, "Override the collector host used by the Base/Collector/Connect* tasks, e.g. when collector traffic goes through an internal relay. Format: host or host:port")
+
+	flag.IntVar(&Flags.HTTPTimeout, "http-timeout", DefaultHTTPTimeoutSeconds, "Timeout in seconds of the HTTP requests made by tasks that do not set their own timeout")
+	flag.IntVar(&Flags.UploadAttempts, "upload-attempts", DefaultUploadAttempts, "Number of attempts made to upload each file with -attach or -api-key before giving up, waiting twice as long after each failed attempt")
+	flag.IntVar(&Flags.Deadline, "deadline", 0, "Deadline in seconds for running all tasks. Tasks still running or not yet started when it is reached are reported as errors. Per-task timeouts such as -http-timeout still apply within the deadline. 0 means no deadline")
+	flag.IntVar(&Flags.Concurrency, "concurrency", DefaultConcurrency, "Maximum number of tasks run at the same time. A task only starts once the tasks it depends on are done. 1 runs the tasks one after the other")
+	flag.BoolVar(&Flags.Timings, "timings", false, "Print how long each task took to stderr at the end of the run, slowest first, and include the timings in nrdiag-output.json")
+	flag.StringVar(&Flags.Pprof, "pprof", defaultString, "Serve the Go pprof profiles of nrdiag itself on this local address, e.g. localhost:6060, while the tasks run. For troubleshooting nrdiag's own memory and CPU usage")
+	flag.BoolVar(&Flags.PprofProfiles, "pprof-profiles", false, "Write heap and goroutine profiles of nrdiag itself to -output-path at the end of the run, next to the zip file")
+
+	flag.IntVar(&Flags.LogAge, "log-age", 0, "Only collect the log lines of the last given number of days with Base/Log/Copy and Base/Log/Collect. Log files, and their newest rotation, last modified before that are skipped. 0 collects every line")
+	flag.IntVar(&Flags.PayloadMaxSize, "payload-max-size", 0, "Maximum size in KB of a task payload kept inline in nrdiag-output.json. Larger payloads, such as whole config dumps, are written to their own file in the nrdiag-output-payloads directory, next to nrdiag-output.json and in the zip file, and the result references it by path. 0 keeps every payload inline")
+	flag.IntVar(&Flags.LogMaxSize, "log-max-size", 0, "Maximum size in MB of each log file collected by Base/Log/Copy and Base/Log/Collect. Larger files are truncated from the front so their most recent lines are kept. 0 means no limit")
+
+	flag.StringVar(&Flags.EnvAllow, "env-allow", defaultString, "Comma separated list of additional environment variables collected by Base/Env/CollectEnvVars, a '*' matches any sequence of characters, e.g. 'MY_APP_*'. By default only the New Relic, profiler and proxy variables and a few host settings such as PATH are collected")
+	flag.StringVar(&Flags.EnvDeny, "env-deny", defaultString, "Comma separated list of environment variables not to collect with Base/Env/CollectEnvVars, in the same format as -env-allow. Takes precedence over -env-allow and the default list")
+
+	flag.StringVar(&Flags.CABundle, "ca-bundle", defaultString, "Path to a PEM file of CA certificates to trust in addition to the system roots, e.g. the root certificate of a TLS-inspecting proxy")
+
+	flag.StringVar(&Flags.ClientCert, "client-cert", defaultString, "Path to a PEM encoded client certificate presented to servers and proxies requiring mutual TLS. Requires -client-key")
+	flag.StringVar(&Flags.ClientKey, "client-key", defaultString, "Path to the PEM encoded private key of the -client-cert certificate")
+
 	flag.BoolVar(&Flags.UsageOptOut, "usage-opt-out", false, "Decline to send anonymous New Relic Diagnostic tool usage data to New Relic for this run")
 
 	flag.StringVar(&Flags.Include, "include", defaultString, "Include a file or directory (including subdirectories) in the nrdiag-output.zip. Limit 4GB. To upload the results to New Relic also use the '-a' flag.")
+	flag.Var((*pathList)(&Flags.IncludePaths), "include-path", "A directory to search for agent config and log files in addition to the default locations, e.g. a non-standard install directory. Can be repeated")
 
 	flag.StringVar(&Flags.Region, "r", defaultString, "alias for -region")
 	flag.StringVar(&Flags.Region, "region", defaultString, "The region your New Relic account is in. Accepted values: EU or US. Case insensitive. (Default: US)")
@@ -217,6 +458,11 @@ func ParseFlags() {
 
 	flag.Parse()
 
+	// the profile fills in the options not given on the command line, before the ones derived from them are set
+	if Flags.Profile != "" {
+		ProfileWarnings, ProfileError = applyProfile(flag.CommandLine, Flags.Profile)
+	}
+
 	if Flags.VeryQuiet {
 		Flags.Quiet = true
 
@@ -230,10 +476,34 @@ func ParseFlags() {
 	} else {
 		LogLevel = Info
 	}
+	// an unsupported -log-level is reported once the logger is set up, with the other invalid flags
+	if level, err := ParseLogLevel(Flags.LogLevel); err == nil {
+		LogLevel = level
+	}
+
+	// -validate-config is a local lint: the given file is the only one collected and nothing is sent to New Relic
+	if Flags.ValidateConfig != "" {
+		Flags.ConfigFile = Flags.ValidateConfig
+		Flags.UsageOptOut = true
+		Flags.SkipVersionCheck = true
+	}
+
+	// -single prints the result to stdout, anything else written there would break the JSON. It is a quick check, not a
+	// run worth reporting on
+	if Flags.Single != "" {
+		Flags.UsageOptOut = true
+		Flags.SkipVersionCheck = true
+	}
+
+	// -offline makes no outbound request, the version check and the usage data included
+	if Flags.Offline {
+		Flags.UsageOptOut = true
+		Flags.SkipVersionCheck = true
+	}
 
 	if Flags.BrowserURL != "" {
-		Flags.Override = "Browser/Agent/GetSource.url=" + Flags.BrowserURL + "," + Flags.Override
-		Flags.Tasks = "Browser/Agent/Detect," + Flags.Tasks
+		Flags.Override = "Browser/Agent/GetSource.url=" + Flags.BrowserURL + ",Browser/Agent/Snippet.url=" + Flags.BrowserURL + "," + Flags.Override
+		Flags.Tasks = "Browser/Agent/Detect,Browser/Agent/Snippet," + Flags.Tasks
 	}
 
 	// Set the endpoints based on region
@@ -271,19 +541,60 @@ func (f userFlags) UsagePayload() []ConfigFlag {
 		{Name: "version", Value: f.Version},
 		{Name: "yesToAll", Value: f.YesToAll},
 		{Name: "showOverrideHelp", Value: f.ShowOverrideHelp},
+		{Name: "listTasks", Value: f.ListTasks},
+		{Name: "showCatalog", Value: f.ShowCatalog},
+		{Name: "stream", Value: f.Stream},
+		{Name: "noRedact", Value: f.NoRedact},
+		{Name: "anonymize", Value: f.Anonymize},
+		{Name: "anonymizeMap", Value: f.AnonymizeMap},
+		{Name: "offline", Value: f.Offline},
+		{Name: "skipPreflight", Value: f.SkipPreflight},
+		{Name: "collectOnly", Value: f.CollectOnly},
 		{Name: "autoAttach", Value: f.AutoAttach},
 		{Name: "proxy", Value: boolifyFlag(f.Proxy)},
 		{Name: "proxyUser", Value: boolifyFlag(f.ProxyUser)},
 		{Name: "proxyPassword", Value: boolifyFlag(f.ProxyPassword)},
+		{Name: "pacURL", Value: boolifyFlag(f.PACURL)},
 		{Name: "tasks", Value: f.Tasks},
+		{Name: "taskFile", Value: boolifyFlag(f.TaskFile)},
+		{Name: "single", Value: boolifyFlag(f.Single)},
+		{Name: "profile", Value: boolifyFlag(f.Profile)},
+		{Name: "exclude", Value: f.Exclude},
 		{Name: "configFile", Value: boolifyFlag(f.ConfigFile)},
+		{Name: "validateConfig", Value: boolifyFlag(f.ValidateConfig)},
+		{Name: "diff", Value: boolifyFlag(f.Diff)},
+		{Name: "resume", Value: boolifyFlag(f.Resume)},
+		{Name: "verify", Value: boolifyFlag(f.Verify)},
 		{Name: "override", Value: boolifyFlag(f.Override)},
 		{Name: "outputPath", Value: boolifyFlag(f.OutputPath)},
+		{Name: "outputName", Value: boolifyFlag(f.OutputName)},
+		{Name: "outputFormat", Value: f.OutputFormat},
+		{Name: "logFormat", Value: f.LogFormat},
+		{Name: "logLevel", Value: f.LogLevel},
+		{Name: "minStatus", Value: f.MinStatus},
+		{Name: "failOn", Value: f.FailOn},
 		{Name: "filter", Value: f.Filter},
 		{Name: "browserURL", Value: boolifyFlag(f.BrowserURL)},
+		{Name: "collectorHost", Value: boolifyFlag(f.CollectorHost)},
+		{Name: "httpTimeout", Value: f.HTTPTimeout},
+		{Name: "uploadAttempts", Value: f.UploadAttempts},
+		{Name: "deadline", Value: f.Deadline},
+		{Name: "concurrency", Value: f.Concurrency},
+		{Name: "timings", Value: f.Timings},
+		{Name: "pprof", Value: boolifyFlag(f.Pprof)},
+		{Name: "pprofProfiles", Value: f.PprofProfiles},
+		{Name: "logAge", Value: f.LogAge},
+		{Name: "logMaxSize", Value: f.LogMaxSize},
+		{Name: "payloadMaxSize", Value: f.PayloadMaxSize},
+		{Name: "envAllow", Value: boolifyFlag(f.EnvAllow)},
+		{Name: "envDeny", Value: boolifyFlag(f.EnvDeny)},
+		{Name: "caBundle", Value: boolifyFlag(f.CABundle)},
+		{Name: "clientCert", Value: boolifyFlag(f.ClientCert)},
+		{Name: "clientKey", Value: boolifyFlag(f.ClientKey)},
 		{Name: "attachmentEndpoint", Value: boolifyFlag(f.AttachmentEndpoint)},
 		{Name: "suites", Value: f.Suites},
 		{Name: "include", Value: f.Include},
+		{Name: "includePath", Value: len(f.IncludePaths) > 0},
 		{Name: "apiKey", Value: f.APIKey},
 		{Name: "region", Value: f.Region},
 	}
@@ -295,12 +606,67 @@ func boolifyFlag(inputFlag string) bool {
 }
 
 // IsForcedTask returns true if the supplied task (identifier) was supplied in the
-// -t command line argument.
+// -t command line argument, or is the task run with -single.
 func (f userFlags) IsForcedTask(identifier string) bool {
-	identifiers := strings.Split(f.Tasks, ",")
-	for _, ident := range identifiers {
+	if f.Single != "" && strings.EqualFold(strings.TrimSpace(f.Single), identifier) {
+		return true
+	}
+	for _, pattern := range forcedTaskPatterns(f.Tasks) {
+		if pattern.MatchString(identifier) {
+			return true
+		}
+	}
+	return false
+}
+
+// forcedTasks caches the compiled -t patterns, IsForcedTask is called by every task that checks it
+var forcedTasks struct {
+	sync.Mutex
+	source   string
+	patterns []*regexp.Regexp
+}
+
+func forcedTaskPatterns(tasksFlag string) []*regexp.Regexp {
+	forcedTasks.Lock()
+	defer forcedTasks.Unlock()
+	if forcedTasks.patterns != nil && forcedTasks.source == tasksFlag {
+		return forcedTasks.patterns
+	}
+
+	patterns := []*regexp.Regexp{}
+	for _, ident := range strings.Split(tasksFlag, ",") {
 		trimmedIdentifer := strings.TrimSpace(ident)
-		if strings.EqualFold(identifier, trimmedIdentifer) {
+		if trimmedIdentifer == "" {
+			continue
+		}
+		patterns = append(patterns, CompileTaskPattern(trimmedIdentifer))
+	}
+	forcedTasks.source = tasksFlag
+	forcedTasks.patterns = patterns
+	return patterns
+}
+
+// CompileTaskPattern returns a case-insensitive matcher for a task identifier supplied with -t.
+// A '*' matches any sequence of characters, e.g. 'Base/Collector/*' or '*/Config/*'. Without a '*' the
+// identifier has to match exactly.
+func CompileTaskPattern(pattern string) *regexp.Regexp {
+	parts := strings.Split(pattern, "*")
+	for i, part := range parts {
+		parts[i] = regexp.QuoteMeta(part)
+	}
+	return regexp.MustCompile("(?i)^" + strings.Join(parts, ".*") + "$")
+}
+
+// IsExcludedTask returns true if the supplied task (identifier) matches an identifier or
+// category prefix supplied in the -exclude command line argument.
+func (f userFlags) IsExcludedTask(identifier string) bool {
+	lowerIdentifier := strings.ToLower(identifier)
+	for _, exclude := range strings.Split(f.Exclude, ",") {
+		prefix := strings.ToLower(strings.TrimSuffix(strings.TrimSpace(exclude), "/*"))
+		if prefix == "" {
+			continue
+		}
+		if lowerIdentifier == prefix || strings.HasPrefix(lowerIdentifier, strings.TrimSuffix(prefix, "/")+"/") {
 			return true
 		}
 	}
@@ -336,6 +702,11 @@ func parseRegionFlagAndEnv(regionFromFlag string, regionFromEnv string) Region {
 	return USRegion
 }
 
+// SelectedRegion - the region of the account, from -region or NEW_RELIC_REGION, US by default
+func SelectedRegion() Region {
+	return parseRegionFlagAndEnv(Flags.Region, os.Getenv("NEW_RELIC_REGION"))
+}
+
 // stringToRegion - converts region string to Region type
 func stringToRegion(region string) Region {
 	r := strings.TrimSpace(strings.ToLower(region))
@@ -1,8 +1,10 @@
 package config
 
 import (
+	"flag"
 	"reflect"
 	"testing"
+	"time"
 )
 
 func Test_userFlags_UsagePayload(t *testing.T) {
@@ -16,20 +18,61 @@ func Test_userFlags_UsagePayload(t *testing.T) {
 		Version            bool
 		YesToAll           bool
 		ShowOverrideHelp   bool
+		ListTasks          bool
+		ShowCatalog        bool
+		Stream             bool
+		NoRedact           bool
+		Anonymize          bool
+		AnonymizeMap       bool
+		Offline            bool
+		SkipPreflight      bool
+		CollectOnly        bool
 		AutoAttach         bool
 		UsageOptOut        bool
 		Proxy              string
 		ProxyUser          string
 		ProxyPassword      string
+		PACURL             string
 		Tasks              string
+		TaskFile           string
+		Single             string
+		Profile            string
+		Exclude            string
 		ConfigFile         string
+		ValidateConfig     string
+		Diff               string
+		Resume             string
+		Verify             string
 		Override           string
 		OutputPath         string
+		OutputName         string
+		OutputFormat       string
+		LogFormat          string
+		LogLevel           string
+		MinStatus          string
+		FailOn             string
 		Filter             string
 		BrowserURL         string
+		CollectorHost      string
+		HTTPTimeout        int
+		UploadAttempts     int
+		Deadline           int
+		Concurrency        int
+		Timings            bool
+		Pprof              string
+		PprofProfiles      bool
+		LogAge             int
+		LogMaxSize         int
+		PayloadMaxSize     int
+		EnvAllow           string
+		EnvDeny            string
+		CABundle           string
+		ClientCert         string
+		ClientKey          string
 		AttachmentEndpoint string
 		Suites             string
 		Include            string
+		IncludePaths       []string
 		APIKey             string
 		Region             string
 	}
@@ -43,19 +86,60 @@ func Test_userFlags_UsagePayload(t *testing.T) {
 		Version:            true,
 		YesToAll:           false,
 		ShowOverrideHelp:   true,
+		ListTasks:          false,
+		ShowCatalog:        false,
+		Stream:             true,
+		NoRedact:           false,
+		Anonymize:          false,
+		AnonymizeMap:       false,
+		Offline:            true,
+		SkipPreflight:      true,
+		CollectOnly:        true,
 		AutoAttach:         true,
 		Proxy:              "string",
 		ProxyUser:          "string",
 		ProxyPassword:      "",
+		PACURL:             "http://wpad/proxy.pac",
 		Tasks:              "string",
+		TaskFile:           "",
+		Single:             "Base/Env/CollectEnvVars",
+		Profile:            "",
+		Exclude:            "string",
 		ConfigFile:         "string",
+		ValidateConfig:     "",
+		Diff:               "old.json",
+		Resume:             "",
+		Verify:             "",
 		Override:           "",
 		OutputPath:         "",
+		OutputName:         "nrdiag-{host}-{ts}",
+		OutputFormat:       "junit",
+		LogFormat:          "json",
+		LogLevel:           "warn",
+		MinStatus:          "warning",
+		FailOn:             "failure",
 		Filter:             "string",
 		BrowserURL:         "string",
+		CollectorHost:      "",
+		HTTPTimeout:        30,
+		UploadAttempts:     3,
+		Deadline:           0,
+		Concurrency:        4,
+		Timings:            false,
+		Pprof:              "localhost:6060",
+		PprofProfiles:      true,
+		LogAge:             0,
+		LogMaxSize:         0,
+		PayloadMaxSize:     0,
+		EnvAllow:           "MY_APP_*",
+		EnvDeny:            "",
+		CABundle:           "string",
+		ClientCert:         "string",
+		ClientKey:          "",
 		AttachmentEndpoint: "string",
 		Suites:             "string",
 		Include:            "string",
+		IncludePaths:       []string{"/opt/app"},
 		APIKey:             "string",
 		Region:             "string",
 	}
@@ -69,19 +153,60 @@ func Test_userFlags_UsagePayload(t *testing.T) {
 		{Name: "version", Value: true},
 		{Name: "yesToAll", Value: false},
 		{Name: "showOverrideHelp", Value: true},
+		{Name: "listTasks", Value: false},
+		{Name: "showCatalog", Value: false},
+		{Name: "stream", Value: true},
+		{Name: "noRedact", Value: false},
+		{Name: "anonymize", Value: false},
+		{Name: "anonymizeMap", Value: false},
+		{Name: "offline", Value: true},
+		{Name: "skipPreflight", Value: true},
+		{Name: "collectOnly", Value: true},
 		{Name: "autoAttach", Value: true},
 		{Name: "proxy", Value: true},
 		{Name: "proxyUser", Value: true},
 		{Name: "proxyPassword", Value: false},
+		{Name: "pacURL", Value: true},
 		{Name: "tasks", Value: "string"},
+		{Name: "taskFile", Value: false},
+		{Name: "single", Value: true},
+		{Name: "profile", Value: false},
+		{Name: "exclude", Value: "string"},
 		{Name: "configFile", Value: true},
+		{Name: "validateConfig", Value: false},
+		{Name: "diff", Value: true},
+		{Name: "resume", Value: false},
+		{Name: "verify", Value: false},
 		{Name: "override", Value: false},
 		{Name: "outputPath", Value: false},
+		{Name: "outputName", Value: true},
+		{Name: "outputFormat", Value: "junit"},
+		{Name: "logFormat", Value: "json"},
+		{Name: "logLevel", Value: "warn"},
+		{Name: "minStatus", Value: "warning"},
+		{Name: "failOn", Value: "failure"},
 		{Name: "filter", Value: "string"},
 		{Name: "browserURL", Value: true},
+		{Name: "collectorHost", Value: false},
+		{Name: "httpTimeout", Value: 30},
+		{Name: "uploadAttempts", Value: 3},
+		{Name: "deadline", Value: 0},
+		{Name: "concurrency", Value: 4},
+		{Name: "timings", Value: false},
+		{Name: "pprof", Value: true},
+		{Name: "pprofProfiles", Value: true},
+		{Name: "logAge", Value: 0},
+		{Name: "logMaxSize", Value: 0},
+		{Name: "payloadMaxSize", Value: 0},
+		{Name: "envAllow", Value: true},
+		{Name: "envDeny", Value: false},
+		{Name: "caBundle", Value: true},
+		{Name: "clientCert", Value: true},
+		{Name: "clientKey", Value: false},
 		{Name: "attachmentEndpoint", Value: true},
 		{Name: "suites", Value: "string"},
 		{Name: "include", Value: "string"},
+		{Name: "includePath", Value: true},
 		{Name: "apiKey", Value: "string"},
 		{Name: "region", Value: "string"},
 	}
@@ -108,20 +233,61 @@ func Test_userFlags_UsagePayload(t *testing.T) {
 				Version:            tt.fields.Version,
 				YesToAll:           tt.fields.YesToAll,
 				ShowOverrideHelp:   tt.fields.ShowOverrideHelp,
+				ListTasks:          tt.fields.ListTasks,
+				ShowCatalog:        tt.fields.ShowCatalog,
+				Stream:             tt.fields.Stream,
+				NoRedact:           tt.fields.NoRedact,
+				Anonymize:          tt.fields.Anonymize,
+				AnonymizeMap:       tt.fields.AnonymizeMap,
+				Offline:            tt.fields.Offline,
+				SkipPreflight:      tt.fields.SkipPreflight,
+				CollectOnly:        tt.fields.CollectOnly,
 				AutoAttach:         tt.fields.AutoAttach,
 				UsageOptOut:        tt.fields.UsageOptOut,
 				Proxy:              tt.fields.Proxy,
 				ProxyUser:          tt.fields.ProxyUser,
 				ProxyPassword:      tt.fields.ProxyPassword,
+				PACURL:             tt.fields.PACURL,
 				Tasks:              tt.fields.Tasks,
+				TaskFile:           tt.fields.TaskFile,
+				Single:             tt.fields.Single,
+				Profile:            tt.fields.Profile,
+				Exclude:            tt.fields.Exclude,
 				ConfigFile:         tt.fields.ConfigFile,
+				ValidateConfig:     tt.fields.ValidateConfig,
+				Diff:               tt.fields.Diff,
+				Resume:             tt.fields.Resume,
+				Verify:             tt.fields.Verify,
 				Override:           tt.fields.Override,
 				OutputPath:         tt.fields.OutputPath,
+				OutputName:         tt.fields.OutputName,
+				OutputFormat:       tt.fields.OutputFormat,
+				LogFormat:          tt.fields.LogFormat,
+				LogLevel:           tt.fields.LogLevel,
+				MinStatus:          tt.fields.MinStatus,
+				FailOn:             tt.fields.FailOn,
 				Filter:             tt.fields.Filter,
 				BrowserURL:         tt.fields.BrowserURL,
+				CollectorHost:      tt.fields.CollectorHost,
+				HTTPTimeout:        tt.fields.HTTPTimeout,
+				UploadAttempts:     tt.fields.UploadAttempts,
+				Deadline:           tt.fields.Deadline,
+				Concurrency:        tt.fields.Concurrency,
+				Timings:            tt.fields.Timings,
+				Pprof:              tt.fields.Pprof,
+				PprofProfiles:      tt.fields.PprofProfiles,
+				LogAge:             tt.fields.LogAge,
+				LogMaxSize:         tt.fields.LogMaxSize,
+				PayloadMaxSize:     tt.fields.PayloadMaxSize,
+				EnvAllow:           tt.fields.EnvAllow,
+				EnvDeny:            tt.fields.EnvDeny,
+				CABundle:           tt.fields.CABundle,
+				ClientCert:         tt.fields.ClientCert,
+				ClientKey:          tt.fields.ClientKey,
 				AttachmentEndpoint: tt.fields.AttachmentEndpoint,
 				Suites:             tt.fields.Suites,
 				Include:            tt.fields.Include,
+				IncludePaths:       tt.fields.IncludePaths,
 				APIKey:             tt.fields.APIKey,
 				Region:             tt.fields.Region,
 			}
@@ -161,6 +327,67 @@ func Test_boolifyFlag(t *testing.T) {
 	}
 }
 
+func Test_userFlags_IsForcedTask(t *testing.T) {
+	tests := []struct {
+		name       string
+		tasks      string
+		single     string
+		identifier string
+		want       bool
+	}{
+		{name: "no tasks", tasks: "", identifier: "Base/Collector/ConnectUS", want: false},
+		{name: "exact identifier", tasks: "Base/Collector/ConnectUS", identifier: "Base/Collector/ConnectUS", want: true},
+		{name: "exact identifier in a different case", tasks: "base/collector/connectus", identifier: "Base/Collector/ConnectUS", want: true},
+		{name: "literal identifier does not match a longer one", tasks: "Base/Collector/Connect", identifier: "Base/Collector/ConnectUS", want: false},
+		{name: "literal identifier does not match a shorter one", tasks: "Base/Collector/ConnectUS", identifier: "Base/Collector/Connect", want: false},
+		{name: "prefix wildcard", tasks: "*/ConnectUS", identifier: "Base/Collector/ConnectUS", want: true},
+		{name: "suffix wildcard", tasks: "Base/Collector/*", identifier: "Base/Collector/ConnectUS", want: true},
+		{name: "suffix wildcard in a different case", tasks: "base/COLLECTOR/*", identifier: "Base/Collector/ConnectUS", want: true},
+		{name: "suffix wildcard for another subcategory", tasks: "Base/Config/*", identifier: "Base/Collector/ConnectUS", want: false},
+		{name: "middle wildcard", tasks: "*/Config/*", identifier: "Java/Config/Agent", want: true},
+		{name: "middle wildcard for another subcategory", tasks: "*/Config/*", identifier: "Java/Env/Version", want: false},
+		{name: "wildcard inside a name", tasks: "Base/Collector/Connect*", identifier: "Base/Collector/ConnectEU", want: true},
+		{name: "one of several tasks", tasks: "Java/Agent/Version, Base/Collector/*", identifier: "Base/Collector/ConnectUS", want: true},
+		{name: "regex characters are matched literally", tasks: "Base/Collector/Connect.S", identifier: "Base/Collector/ConnectUS", want: false},
+		{name: "single task", single: "base/collector/traceroute", identifier: "Base/Collector/Traceroute", want: true},
+		{name: "single task does not force its dependencies", single: "Base/Collector/Traceroute", identifier: "Base/Config/Validate", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := userFlags{Tasks: tt.tasks, Single: tt.single}
+			if got := f.IsForcedTask(tt.identifier); got != tt.want {
+				t.Errorf("IsForcedTask() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_userFlags_IsExcludedTask(t *testing.T) {
+	tests := []struct {
+		name       string
+		exclude    string
+		identifier string
+		want       bool
+	}{
+		{name: "no exclusions", exclude: "", identifier: "Base/Collector/ConnectUS", want: false},
+		{name: "exact identifier", exclude: "Base/Collector/ConnectUS", identifier: "Base/Collector/ConnectUS", want: true},
+		{name: "exact identifier in a different case", exclude: "base/collector/connectus", identifier: "Base/Collector/ConnectUS", want: true},
+		{name: "category prefix", exclude: "Base/Collector", identifier: "Base/Collector/ConnectUS", want: true},
+		{name: "category prefix with trailing wildcard", exclude: "Base/Collector/*", identifier: "Base/Collector/ConnectUS", want: true},
+		{name: "one of several exclusions", exclude: "Java/Agent, Base/Collector", identifier: "Base/Collector/ConnectUS", want: true},
+		{name: "partial name is not a prefix", exclude: "Base/Coll", identifier: "Base/Collector/ConnectUS", want: false},
+		{name: "other category", exclude: "Base/Config", identifier: "Base/Collector/ConnectUS", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := userFlags{Exclude: tt.exclude}
+			if got := f.IsExcludedTask(tt.identifier); got != tt.want {
+				t.Errorf("IsExcludedTask() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func Test_stringToRegion(t *testing.T) {
 	type args struct {
 		region string
@@ -297,3 +524,72 @@ func Test_parseRegionFlagAndEnv(t *testing.T) {
 		})
 	}
 }
+
+func Test_ParseLogLevel(t *testing.T) {
+	tests := []struct {
+		level   string
+		want    Verbosity
+		wantErr bool
+	}{
+		{level: "error", want: Error},
+		{level: "Warn", want: Warn},
+		{level: " info ", want: Info},
+		{level: "DEBUG", want: Verbose},
+		{level: "trace", want: Info, wantErr: true},
+		{level: "", want: Info, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.level, func(t *testing.T) {
+			got, err := ParseLogLevel(tt.level)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseLogLevel() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseLogLevel() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_expandOutputName(t *testing.T) {
+	now := time.Date(2024, time.March, 5, 14, 30, 15, 0, time.FixedZone("PST", -8*3600))
+	tests := []struct {
+		template string
+		host     string
+		want     string
+	}{
+		{template: "", host: "web-01", want: "nrdiag-output"},
+		{template: "nrdiag-{host}-{ts}", host: "web-01", want: "nrdiag-web-01-20240305T223015Z"},
+		{template: "nrdiag-{host}-{ts}.zip", host: "web-01", want: "nrdiag-web-01-20240305T223015Z"},
+		{template: "results.JSON", host: "web-01", want: "results"},
+		{template: "{host}", host: "web 01.example.com", want: "web_01.example.com"},
+		{template: "../../etc/{host}", host: "web-01", want: "_.._etc_web-01"},
+		{template: `..\..\{host}`, host: "web-01", want: "_.._web-01"},
+		{template: "/tmp/out", host: "web-01", want: "_tmp_out"},
+		{template: "..", host: "web-01", want: "nrdiag-output"},
+		{template: "{unknown}", host: "web-01", want: "_unknown_"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.template, func(t *testing.T) {
+			if got := expandOutputName(tt.template, tt.host, now); got != tt.want {
+				t.Errorf("expandOutputName() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_pathList(t *testing.T) {
+	var paths []string
+	flags := flag.NewFlagSet("nrdiag", flag.ContinueOnError)
+	flags.Var((*pathList)(&paths), "include-path", "")
+	if err := flags.Parse([]string{"-include-path", "/opt/app", "-include-path", "/srv/one, /srv/two,"}); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"/opt/app", "/srv/one", "/srv/two"}
+	if !reflect.DeepEqual(paths, want) {
+		t.Errorf("-include-path = %q, want %q", paths, want)
+	}
+	if got := flags.Lookup("include-path").Value.String(); got != "/opt/app,/srv/one,/srv/two" {
+		t.Errorf("String() = %q", got)
+	}
+}
@@ -0,0 +1,57 @@
+package config
+
+import (
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultOutputName is the name of the output files, before their extension, when -output-name is not set
+const DefaultOutputName = "nrdiag-output"
+
+// outputNameTimestamp is the {ts} format, without the colons Windows does not allow in file names
+const outputNameTimestamp = "20060102T150405Z"
+
+var unsafeOutputNameChars = regexp.MustCompile(`[^A-Za-z0-9._-]`)
+
+var (
+	outputNameOnce sync.Once
+	outputName     string
+)
+
+// OutputFileName returns the name of the output file with the given extension, e.g. OutputFileName(".zip"). The
+// -output-name template is expanded once, so every file of the run shares the same hostname and timestamp
+func OutputFileName(extension string) string {
+	outputNameOnce.Do(func() {
+		host, err := os.Hostname()
+		if err != nil {
+			host = "unknown-host"
+		}
+		outputName = expandOutputName(Flags.OutputName, host, time.Now())
+	})
+	return outputName + extension
+}
+
+// expandOutputName replaces {host} and {ts} in the template and sanitizes the result so it stays a plain file name
+// within the output directory: path separators and any other unsafe character become '_' and leading dots are dropped
+func expandOutputName(template string, host string, now time.Time) string {
+	name := strings.TrimSpace(template)
+	// the same name is used for the zip and the JSON file, so an extension given with the template is not kept
+	for _, extension := range []string{".zip", ".json"} {
+		if strings.HasSuffix(strings.ToLower(name), extension) {
+			name = name[:len(name)-len(extension)]
+		}
+	}
+	name = strings.NewReplacer(
+		"{host}", host,
+		"{ts}", now.UTC().Format(outputNameTimestamp),
+	).Replace(name)
+	name = unsafeOutputNameChars.ReplaceAllString(name, "_")
+	name = strings.TrimLeft(name, ".")
+	if name == "" {
+		return DefaultOutputName
+	}
+	return name
+}
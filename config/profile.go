@@ -0,0 +1,87 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ProfileWarnings are the entries of the -profile file that were ignored, reported once the logger is set up
+var ProfileWarnings []string
+
+// ProfileError is set when the -profile file could not be used
+var ProfileError error
+
+// applyProfile sets the options listed in a JSON or YAML profile file, keyed by their flag name, e.g. 'http-timeout'.
+// Options given on the command line, under their name or an alias, are left as they are. Unknown keys are returned as
+// warnings rather than failing the run; a value an option can't take is an error
+func applyProfile(flags *flag.FlagSet, path string) ([]string, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	// JSON is valid YAML, a single decoder reads both
+	var profile map[string]interface{}
+	if err := yaml.Unmarshal(content, &profile); err != nil {
+		return nil, errors.New("unable to parse " + path + ": " + err.Error())
+	}
+
+	// an alias and its option share the same value, setting either on the command line takes precedence
+	fromCommandLine := map[flag.Value]bool{}
+	flags.Visit(func(f *flag.Flag) {
+		fromCommandLine[f.Value] = true
+	})
+
+	keys := make([]string, 0, len(profile))
+	for key := range profile {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var warnings []string
+	for _, key := range keys {
+		option := flags.Lookup(strings.TrimLeft(key, "-"))
+		if option == nil || option.Name == "profile" {
+			warnings = append(warnings, fmt.Sprintf("unknown option '%s' in %s is ignored", key, path))
+			continue
+		}
+		// an empty entry, e.g. 'proxy:', leaves the option unset
+		if fromCommandLine[option.Value] || profile[key] == nil {
+			continue
+		}
+		value, ok := profileValue(profile[key])
+		if !ok {
+			warnings = append(warnings, fmt.Sprintf("option '%s' in %s is not a value or a list of values, it is ignored", key, path))
+			continue
+		}
+		if err := flags.Set(option.Name, value); err != nil {
+			return warnings, fmt.Errorf("invalid value for '%s' in %s: %s", key, path, err.Error())
+		}
+	}
+	return warnings, nil
+}
+
+// profileValue - the command line form of a profile value. Lists, e.g. of tasks, are comma separated
+func profileValue(value interface{}) (string, bool) {
+	switch v := value.(type) {
+	case []interface{}:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			itemValue, ok := profileValue(item)
+			if !ok || strings.Contains(itemValue, ",") {
+				return "", false
+			}
+			items = append(items, itemValue)
+		}
+		return strings.Join(items, ","), true
+	case map[string]interface{}:
+		return "", false
+	default:
+		return fmt.Sprint(v), true
+	}
+}
@@ -0,0 +1,148 @@
+package config
+
+import (
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type profileFlags struct {
+	tasks        string
+	exclude      string
+	proxy        string
+	httpTimeout  int
+	outputFormat string
+	yesToAll     bool
+}
+
+func newProfileFlagSet(f *profileFlags) *flag.FlagSet {
+	flags := flag.NewFlagSet("nrdiag", flag.ContinueOnError)
+	flags.StringVar(&f.tasks, "t", "", "alias for -tasks")
+	flags.StringVar(&f.tasks, "tasks", "", "")
+	flags.StringVar(&f.exclude, "exclude", "", "")
+	flags.StringVar(&f.proxy, "p", "", "alias for -proxy")
+	flags.StringVar(&f.proxy, "proxy", "", "")
+	flags.IntVar(&f.httpTimeout, "http-timeout", 30, "")
+	flags.StringVar(&f.outputFormat, "output-format", "", "")
+	flags.BoolVar(&f.yesToAll, "y", false, "")
+	flags.String("profile", "", "")
+	return flags
+}
+
+func writeProfile(t *testing.T, name string, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func Test_applyProfile(t *testing.T) {
+	tests := []struct {
+		name         string
+		file         string
+		content      string
+		args         []string
+		want         profileFlags
+		wantWarnings []string
+	}{
+		{
+			name: "YAML profile",
+			file: "profile.yml",
+			content: `tasks:
+  - Base/Collector/*
+  - Base/Config/Validate
+exclude: Base/Collector/Traceroute
+proxy: http://proxy.example.com:8080
+http-timeout: 10
+output-format: junit
+y: true
+`,
+			want: profileFlags{
+				tasks:        "Base/Collector/*,Base/Config/Validate",
+				exclude:      "Base/Collector/Traceroute",
+				proxy:        "http://proxy.example.com:8080",
+				httpTimeout:  10,
+				outputFormat: "junit",
+				yesToAll:     true,
+			},
+		},
+		{
+			name:    "JSON profile",
+			file:    "profile.json",
+			content: `{"tasks": "Base/Env/*", "http-timeout": 5}`,
+			want:    profileFlags{tasks: "Base/Env/*", httpTimeout: 5},
+		},
+		{
+			name:    "command line flags take precedence, aliases included",
+			file:    "profile.yml",
+			content: "tasks: Base/Env/*\nproxy: http://proxy.example.com:8080\nhttp-timeout: 10\n",
+			args:    []string{"-t", "Base/Config/*", "-http-timeout", "60"},
+			want:    profileFlags{tasks: "Base/Config/*", proxy: "http://proxy.example.com:8080", httpTimeout: 60},
+		},
+		{
+			name:    "unknown and unusable keys are ignored with a warning",
+			file:    "profile.yml",
+			content: "taks: Base/Env/*\nprofile: other.yml\noutput-format:\n  type: junit\nexclude:\n",
+			want:    profileFlags{httpTimeout: 30},
+			wantWarnings: []string{
+				"option 'output-format' in %s is not a value or a list of values, it is ignored",
+				"unknown option 'profile' in %s is ignored",
+				"unknown option 'taks' in %s is ignored",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeProfile(t, tt.file, tt.content)
+			var got profileFlags
+			flags := newProfileFlagSet(&got)
+			if err := flags.Parse(tt.args); err != nil {
+				t.Fatal(err)
+			}
+
+			warnings, err := applyProfile(flags, path)
+			if err != nil {
+				t.Fatal("Unexpected error:", err)
+			}
+			if got != tt.want {
+				t.Errorf("applyProfile() set %+v, want %+v", got, tt.want)
+			}
+			var wantWarnings []string
+			for _, warning := range tt.wantWarnings {
+				wantWarnings = append(wantWarnings, strings.Replace(warning, "%s", path, 1))
+			}
+			if !reflect.DeepEqual(warnings, wantWarnings) {
+				t.Errorf("applyProfile() warnings = %q, want %q", warnings, wantWarnings)
+			}
+		})
+	}
+}
+
+func Test_applyProfile_errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{name: "invalid value", content: "http-timeout: soon\n", want: "invalid value for 'http-timeout'"},
+		{name: "not a profile", content: "- tasks\n", want: "unable to parse"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var f profileFlags
+			_, err := applyProfile(newProfileFlagSet(&f), writeProfile(t, "profile.yml", tt.content))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("applyProfile() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+
+	var f profileFlags
+	if _, err := applyProfile(newProfileFlagSet(&f), filepath.Join(t.TempDir(), "missing.yml")); err == nil {
+		t.Error("Expected an error for a missing profile file")
+	}
+}
@@ -1,13 +1,17 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"os"
 	"sync"
 
 	"github.com/newrelic/newrelic-diagnostics-cli/config"
+	"github.com/newrelic/newrelic-diagnostics-cli/helpers/httpHelper"
 	"github.com/newrelic/newrelic-diagnostics-cli/internal/haberdasher"
 	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
 	"github.com/newrelic/newrelic-diagnostics-cli/output"
+	"github.com/newrelic/newrelic-diagnostics-cli/registration"
 	"github.com/newrelic/newrelic-diagnostics-cli/usage"
 	"github.com/newrelic/newrelic-diagnostics-cli/version"
 )
@@ -18,12 +22,135 @@ func main() {
 	log.Debug("---------------------------------------------------------------------------------------------")
 	log.Debugf("Running nrdiag with version: %s and build timestamp %s\n", config.Version, config.BuildTimestamp)
 	log.Debugf("Run ID: %s\n", runID)
-	log.Debug("nrdiag was run with options", os.Args)
+	log.Debug("nrdiag was run with options", config.MaskedArgs(os.Args))
+
+	// the catalog is meant for tooling, print it before anything else writes to stdout
+	if config.Flags.ShowCatalog {
+		err := printCatalog()
+		if err != nil {
+			log.Error("Unable to build the task catalog. \nError: " + err.Error())
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
+	err := processLogFormat()
+	if err != nil {
+		log.Error("Invalid -log-format. \nError: " + err.Error() + "\nExiting program.")
+		os.Exit(3)
+	}
+
+	err = processLogLevel()
+	if err != nil {
+		log.Error("Invalid -log-level. \nError: " + err.Error() + "\nExiting program.")
+		os.Exit(3)
+	}
+
+	err = processProfile()
+	if err != nil {
+		log.Error("Unable to use the options of -profile. \nError: " + err.Error() + "\nExiting program.")
+		os.Exit(3)
+	}
+
+	// -diff only reads two earlier output files: no task is run and nothing is written
+	if config.Flags.Diff != "" {
+		newPath, err := processDiff()
+		if err != nil {
+			log.Error("Invalid -diff. \nError: " + err.Error() + "\nExiting program.")
+			os.Exit(3)
+		}
+		err = processFailOn()
+		if err != nil {
+			log.Error("Invalid -fail-on. \nError: " + err.Error() + "\nExiting program.")
+			os.Exit(3)
+		}
+		os.Exit(runDiff(config.Flags.Diff, newPath))
+	}
+
+	// -verify only reads an earlier zip file: no task is run and nothing is written
+	if config.Flags.Verify != "" {
+		os.Exit(runVerify(config.Flags.Verify))
+	}
+
+	// a task depending on itself, directly or not, would hang the run while the queue is built
+	err = registration.CheckDependencyCycles()
+	if err != nil {
+		log.Error("Unable to order the tasks to run. \nError: " + err.Error() + "\nExiting program.")
+		os.Exit(1)
+	}
 
 	//Error setting proxy and they specifically included one so let's break out of the program before we attempt any non-proxied calls.
-	_, err := processHTTPProxy()
+	_, err = processHTTPProxy()
+	if err != nil {
+		log.Error("Proxy configuration found, but unable to use. \nError: " + err.Error() + "\nExiting program.")
+		os.Exit(3)
+	}
+
+	// A CA bundle that can't be used would otherwise surface as certificate errors on every collector check
+	err = processCABundle()
+	if err != nil {
+		log.Error("Unable to use the CA bundle provided with -ca-bundle. \nError: " + err.Error() + "\nExiting program.")
+		os.Exit(3)
+	}
+
+	err = processClientCertificate()
 	if err != nil {
-		log.Info("Proxy configuration found, but unable to use. \nError: " + err.Error() + "\nExiting program.")
+		log.Error("Unable to use the client certificate provided with -client-cert. \nError: " + err.Error() + "\nExiting program.")
+		os.Exit(3)
+	}
+
+	// Without the PAC file requests would silently go through the proxy of the environment, or none
+	err = processPAC()
+	if err != nil {
+		log.Error("Unable to use the PAC file provided with -pac-url. \nError: " + err.Error() + "\nExiting program.")
+		os.Exit(3)
+	}
+
+	err = processIncludePaths()
+	if err != nil {
+		log.Error("Invalid -include-path. \nError: " + err.Error() + "\nExiting program.")
+		os.Exit(3)
+	}
+
+	err = processOutputFormat()
+	if err != nil {
+		log.Error("Invalid -output-format. \nError: " + err.Error() + "\nExiting program.")
+		os.Exit(3)
+	}
+
+	err = processFailOn()
+	if err != nil {
+		log.Error("Invalid -fail-on. \nError: " + err.Error() + "\nExiting program.")
+		os.Exit(3)
+	}
+
+	err = processMinStatus()
+	if err != nil {
+		log.Error("Invalid -min-status. \nError: " + err.Error() + "\nExiting program.")
+		os.Exit(3)
+	}
+
+	err = processTaskFile()
+	if err != nil {
+		log.Error("Unable to read the tasks listed in -task-file. \nError: " + err.Error() + "\nExiting program.")
+		os.Exit(3)
+	}
+
+	err = processSingle()
+	if err != nil {
+		log.Error("Invalid -single. \nError: " + err.Error() + "\nExiting program.")
+		os.Exit(3)
+	}
+
+	err = processCollectOnly()
+	if err != nil {
+		log.Error("Invalid -collect-only. \nError: " + err.Error() + "\nExiting program.")
+		os.Exit(3)
+	}
+
+	err = processResume()
+	if err != nil {
+		log.Error("Unable to resume the results of -resume. \nError: " + err.Error() + "\nExiting program.")
 		os.Exit(3)
 	}
 
@@ -33,6 +160,7 @@ func main() {
 	haberdasher.InitializeDefaultClient()
 	haberdasher.DefaultClient.SetRunID(runID)
 	haberdasher.DefaultClient.SetUserAgent("Nrdiag_/" + config.Version)
+	haberdasher.DefaultClient.SetHTTPClient(httpHelper.Client())
 
 	if config.HaberdasherURL == "" {
 		log.Info("No Haberdasher base URL set. Defaulting to localhost")
@@ -52,13 +180,26 @@ func main() {
 
 	go processTasksToRun()
 
+	// only a run writing the zip file writes the run log, the others would hold every message in memory for nothing
+	if config.Flags.Help || config.Flags.Version || config.Flags.ListTasks || config.Flags.Interactive || config.Flags.Single != "" {
+		log.DiscardRunLog()
+	}
+
 	// if statments for doing stuff with args
 	if config.Flags.Help {
 		processHelp()
 	} else if config.Flags.Version {
 		version.ProcessVersion(promptUser)
+	} else if config.Flags.ListTasks {
+		// dry run: no task is executed and no output files are written
+		processListTasks()
 	} else if config.Flags.Interactive {
 		// do interactive stuff
+	} else if config.Flags.Single != "" {
+		// prints the result to stdout, no output file, zip or upload
+		if exitCode := runSingle(options, overrides); exitCode != 0 {
+			os.Exit(exitCode)
+		}
 	} else {
 		// the wait group is way of tracking open threads
 		// anytime you spawn an async function, increment and pass it in
@@ -71,17 +212,32 @@ func main() {
 		// create the filelist file, exit if it can't be created
 		flErr := output.CreateFileList()
 		if flErr != nil {
-			log.Info("Error creating filelist", err)
+			log.Error("Error creating filelist", err)
 			os.Exit(3)
 		}
 
+		// every message from here on, and those logged so far, goes to nrdiag-output.log whatever the -log-level
+		output.OpenRunLog()
+
 		// check if any files/directories should be included in the zip
 		if config.Flags.Include != "" {
 			output.HandleIncludeFlag(zipfile, config.Flags.Include)
 		}
 
+		// serves the profiles of nrdiag itself while the tasks run
+		stopPprof, err := processPprof()
+		if err != nil {
+			log.Error("Unable to start the -pprof server. \nError: " + err.Error() + "\nExiting program.")
+			os.Exit(3)
+		}
+
+		// -deadline and Ctrl-C bound the tasks and the requests they make, not writing the output
+		ctx, cancel := runContext(config.Flags.Deadline)
+		stopInterrupts := cancelOnInterrupt(cancel)
+		httpHelper.SetRunContext(ctx)
+
 		wg.Add(1) // run the tasks in goroutine
-		go processTasks(options, overrides, &wg)
+		go processTasks(ctx, options, overrides, &wg)
 
 		wg.Add(1) // collect files the tasks produce and add them to the zip file
 		go output.ProcessFilesChannel(zipfile, &wg)
@@ -90,6 +246,11 @@ func main() {
 		// does not need the wait group since it blocks
 		outputResults := output.WriteLineResults()
 
+		// -resume carries over the earlier results of the tasks that didn't run again. Their payloads are read back as
+		// raw JSON, the usage data only covers the tasks run
+		ranResults := outputResults
+		outputResults = output.MergeResumedResults(outputResults, resumedResults)
+
 		if !config.Flags.Quiet {
 			// writes to the screen
 			output.WriteSummary(outputResults)
@@ -97,6 +258,22 @@ func main() {
 
 		// block on wait group so program does not exit prematurely
 		wg.Wait()
+		interrupted := errors.Is(ctx.Err(), context.Canceled)
+		stopInterrupts()
+		cancel()
+		httpHelper.SetRunContext(context.Background())
+		stopPprof()
+
+		if config.Flags.PprofProfiles {
+			for _, path := range writePprofProfiles(config.Flags.OutputPath) {
+				log.Info("Wrote the profile " + path)
+			}
+		}
+
+		if config.Flags.Timings {
+			// writes to stderr
+			output.WriteTimings(outputResults)
+		}
 
 		// creates the output file
 		output.WriteOutputFile(outputResults)
@@ -104,24 +281,35 @@ func main() {
 		// copy our output file(s) to the zip file
 		output.CopyOutputToZip(zipfile)
 
+		// the run log is added before the file list so it's listed there
+		output.CopyRunLogToZip(zipfile)
+
+		// the -anonymize mapping stays next to the zip file unless -anonymize-map
+		output.WriteAnonymizeMap(zipfile)
+
 		// copy the file list to the zip file last to ensure it's up to date
 		output.CopyFileListToZip(zipfile)
 
 		// ...and close it out
 		output.CloseZip(zipfile)
 
+		// an interrupted run only writes what it found so far
+		if interrupted {
+			os.Exit(exitCodeInterrupted)
+		}
+
 		// upload any files (zip and json)
 		processUploads()
 
 		// deal with haberdasher data
 		if !config.Flags.UsageOptOut {
-			usage.SendUsageData(outputResults, runID)
+			usage.SendUsageData(ranResults, runID)
 		}
 		if !config.Flags.SkipVersionCheck {
 			version.ProcessAutoVersionCheck()
 		}
 
-		if config.Flags.Suites == "" && config.Flags.Tasks == "" {
+		if config.Flags.Suites == "" && config.Flags.Tasks == "" && config.Flags.ValidateConfig == "" && !config.Flags.CollectOnly {
 			var command, option string
 			if config.Flags.InNewRelicCLI {
 				command = "newrelic diagnose run"
@@ -132,5 +320,10 @@ func main() {
 			}
 			log.Infof("\n\nFor better results, run Diagnostics CLI with the 'suites' option to target a New Relic product. To learn how to use this option, run: '%s %s'\n\n", command, option)
 		}
+
+		// the exit codes are documented in the README, scripts rely on them
+		if exitCode := exitCodeForResults(outputResults, config.Flags.FailOn); exitCode != 0 {
+			os.Exit(exitCode)
+		}
 	}
 }
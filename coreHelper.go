@@ -1,18 +1,21 @@
 package main
 
 import (
-	"bufio"
-	"fmt"
+	"context"
 	"net"
 	"net/http"
 	"os"
+	"os/signal"
 	"time"
 
 	"strings"
 
 	"github.com/google/uuid"
 	"github.com/newrelic/newrelic-diagnostics-cli/config"
+	"github.com/newrelic/newrelic-diagnostics-cli/helpers/httpHelper"
 	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
+	"github.com/newrelic/newrelic-diagnostics-cli/output"
+	"github.com/newrelic/newrelic-diagnostics-cli/registration"
 	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
 )
 
@@ -54,48 +57,115 @@ func parseOverrides(overrides string) []override {
 func ProxyParseNSet() (set bool) {
 	//This sets the default
 	var DefaultDialer = &net.Dialer{Timeout: 1000 * time.Millisecond}
-	http.DefaultTransport = &http.Transport{Dial: DefaultDialer.Dial, Proxy: http.ProxyFromEnvironment}
+	// ProxyFromConfig rather than http.ProxyFromEnvironment, which caches the environment on first use and ignores
+	// HTTP_PROXY for https requests
+	http.DefaultTransport = &http.Transport{Dial: DefaultDialer.Dial, Proxy: httpHelper.ProxyFromConfig}
 	return true
 
 }
 
-//similar to tasks - This takes the input string as the query to the end users and waits for a response
+// promptUser - asks the user a question, the same way tasks do. -y answers yes without asking
 func promptUser(msg string) bool {
 	if config.Flags.YesToAll {
 		return true
 	}
+	return tasks.AskUser(msg)
+}
 
-	prompt := "Choose 'y' or 'n', then press enter: "
-	yesResponses := []string{"y", "yes"}
-	noResponses := []string{"n", "no"}
+func generateRunID() string {
+	uuid, err := uuid.NewUUID()
+	if err != nil {
+		log.Debug("Error generating UUID", err)
+		return ""
+	}
 
-	scanner := bufio.NewScanner(os.Stdin)
-	fmt.Println(msg)
-	fmt.Print(prompt)
+	return uuid.String()
+}
 
-	for scanner.Scan() {
-		userInput := strings.ToLower(scanner.Text())
+// exitCodeFailOn - the exit code of a run that produced a result at or above the -fail-on severity
+const exitCodeFailOn = 4
 
-		if tasks.ContainsString(noResponses, userInput) {
-			return false
-		}
+// exitCodeInterrupted - the exit code of a run stopped with Ctrl-C, the one shells use for a process killed by SIGINT
+const exitCodeInterrupted = 130
 
-		if tasks.ContainsString(yesResponses, userInput) {
-			return true
+// exitCodeForResults - returns exitCodeFailOn if any result is at or above the failOn status, 0 otherwise
+func exitCodeForResults(results []registration.TaskResult, failOn string) int {
+	failOnStatus, err := tasks.StatusFromString(failOn)
+	if err != nil || !failOnStatus.IsAtLeast(tasks.Warning) {
+		return 0
+	}
+	for _, taskResult := range results {
+		if taskResult.Result.Status.IsAtLeast(failOnStatus) {
+			log.Debug(taskResult.Task.Identifier(), "returned", taskResult.Result.Status.StatusToString(), "- exiting with code", exitCodeFailOn)
+			return exitCodeFailOn
 		}
+	}
+	return 0
+}
+
+// runDiff - prints the results that changed status between two output files, returns exitCodeFailOn if a task reached
+// the -fail-on severity since the old run
+func runDiff(oldPath string, newPath string) int {
+	diff, err := output.DiffOutputFiles(oldPath, newPath)
+	if err != nil {
+		log.Error("Unable to compare the output files. \nError: " + err.Error())
+		return 3
+	}
+	output.WriteDiff(diff)
 
-		//Repeat prompt if invalid input is provided
-		fmt.Printf("%s", prompt)
+	failOnStatus, err := tasks.StatusFromString(config.Flags.FailOn)
+	if err != nil || !failOnStatus.IsAtLeast(tasks.Warning) {
+		return 0
+	}
+	if regressions := diff.Regressions(failOnStatus); len(regressions) > 0 {
+		log.Debug(regressions[0].Identifier, "changed to", regressions[0].New.StatusToString(), "- exiting with code", exitCodeFailOn)
+		return exitCodeFailOn
 	}
-	return false
+	return 0
 }
 
-func generateRunID() string {
-	uuid, err := uuid.NewUUID()
+// exitCodeNotIntact - the exit code of -verify for a zip file that doesn't match its manifest
+const exitCodeNotIntact = 1
+
+// runVerify - prints whether a zip file matches its manifest, returns exitCodeNotIntact if it doesn't
+func runVerify(path string) int {
+	verification, err := output.VerifyBundle(path)
 	if err != nil {
-		log.Debug("Error generating UUID", err)
-		return ""
+		log.Error("Unable to verify the zip file. \nError: " + err.Error())
+		return 3
+	}
+	output.WriteVerification(path, verification)
+	if !verification.Intact() {
+		return exitCodeNotIntact
 	}
+	return 0
+}
 
-	return uuid.String()
+// cancelOnInterrupt - cancels the run on the first Ctrl-C, so the tasks and requests in flight are stopped and the results
+// found so far written. A second Ctrl-C exits immediately, as does any Ctrl-C once the returned stop function is called
+func cancelOnInterrupt(cancel context.CancelFunc) (stop func()) {
+	interrupts := make(chan os.Signal, 1)
+	signal.Notify(interrupts, os.Interrupt)
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-interrupts:
+			signal.Stop(interrupts)
+			log.Info("\nInterrupted, stopping the tasks still running. Press Ctrl-C again to exit immediately")
+			cancel()
+		case <-done:
+		}
+	}()
+	return func() {
+		signal.Stop(interrupts)
+		close(done)
+	}
+}
+
+// runContext - returns the context the tasks run in. It is cancelled once deadlineSeconds have passed, or never when it is 0
+func runContext(deadlineSeconds int) (context.Context, context.CancelFunc) {
+	if deadlineSeconds <= 0 {
+		return context.WithCancel(context.Background())
+	}
+	return context.WithTimeout(context.Background(), time.Duration(deadlineSeconds)*time.Second)
 }
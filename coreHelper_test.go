@@ -1,9 +1,17 @@
 package main
 
 import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
 	"testing"
+	"time"
 
+	"github.com/newrelic/newrelic-diagnostics-cli/config"
+	"github.com/newrelic/newrelic-diagnostics-cli/registration"
 	tasks "github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	baseConfig "github.com/newrelic/newrelic-diagnostics-cli/tasks/base/config"
 )
 
 func Test_parseOverrides(t *testing.T) {
@@ -59,3 +67,227 @@ func testOverridesEqual(a, b []override) bool {
 
 	return true
 }
+
+func Test_exitCodeForResults(t *testing.T) {
+	resultsWith := func(statuses ...tasks.Status) []registration.TaskResult {
+		var results []registration.TaskResult
+		for _, status := range statuses {
+			results = append(results, registration.TaskResult{
+				Task:   listedTask("Base/Config/Validate"),
+				Result: tasks.Result{Status: status},
+			})
+		}
+		return results
+	}
+
+	tests := []struct {
+		name    string
+		results []registration.TaskResult
+		failOn  string
+		want    int
+	}{
+		{"no results", nil, "failure", 0},
+		{"success, none and info never fail", resultsWith(tasks.Success, tasks.None, tasks.Info), "warning", 0},
+		{"warning below the default", resultsWith(tasks.Success, tasks.Warning), "failure", 0},
+		{"failure at the default", resultsWith(tasks.Success, tasks.Failure), "failure", exitCodeFailOn},
+		{"error at the default", resultsWith(tasks.Error), "failure", exitCodeFailOn},
+		{"warning when failing on warnings", resultsWith(tasks.Warning), "Warning", exitCodeFailOn},
+		{"failure when failing on errors only", resultsWith(tasks.Failure), "error", 0},
+		{"error when failing on errors only", resultsWith(tasks.Error), "error", exitCodeFailOn},
+		{"info is not an accepted threshold", resultsWith(tasks.Info), "info", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := exitCodeForResults(tt.results, tt.failOn); got != tt.want {
+				t.Errorf("exitCodeForResults() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_runDiff(t *testing.T) {
+	dir := t.TempDir()
+	writeOutput := func(name string, status string) string {
+		path := filepath.Join(dir, name)
+		content := `{"RunDate": "2024-01-02T10:00:00Z", "Results": [{"Identifier": {"Category": "Base", "Subcategory": "Collector", "Name": "ConnectUS"}, "Result": {"Status": "` + status + `"}}]}`
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	success, warning, failure := writeOutput("success.json", "Success"), writeOutput("warning.json", "Warning"), writeOutput("failure.json", "Failure")
+	defer func() { config.Flags.FailOn = "" }()
+
+	tests := []struct {
+		name    string
+		oldPath string
+		newPath string
+		failOn  string
+		want    int
+	}{
+		{name: "regression to failure", oldPath: success, newPath: failure, failOn: "failure", want: exitCodeFailOn},
+		{name: "already failing", oldPath: failure, newPath: failure, failOn: "failure", want: 0},
+		{name: "fixed", oldPath: failure, newPath: success, failOn: "failure", want: 0},
+		{name: "below fail-on", oldPath: success, newPath: warning, failOn: "failure", want: 0},
+		{name: "regression to warning", oldPath: success, newPath: warning, failOn: "warning", want: exitCodeFailOn},
+		{name: "missing file", oldPath: filepath.Join(dir, "missing.json"), newPath: success, failOn: "failure", want: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config.Flags.FailOn = tt.failOn
+			if got := runDiff(tt.oldPath, tt.newPath); got != tt.want {
+				t.Errorf("runDiff() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_runContext(t *testing.T) {
+	ctx, cancel := runContext(0)
+	defer cancel()
+	if _, ok := ctx.Deadline(); ok {
+		t.Error("Expected no deadline when -deadline is not set")
+	}
+
+	ctx, cancel = runContext(30)
+	defer cancel()
+	deadline, ok := ctx.Deadline()
+	if !ok || time.Until(deadline) > 30*time.Second || time.Until(deadline) < 29*time.Second {
+		t.Error("Expected a deadline 30 seconds away, got", deadline)
+	}
+}
+
+func Test_processTaskFile(t *testing.T) {
+	defer func() { config.Flags.Tasks, config.Flags.TaskFile = "", "" }()
+	taskFile := filepath.Join(t.TempDir(), "tasks.txt")
+
+	tests := []struct {
+		name    string
+		tasks   string
+		content string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "identifiers, globs and comments",
+			content: "# collector checks\nBase/Collector/*\n\n  Base/Config/Validate  # the config file\r\n#Java/Env/Version\n",
+			want:    "Base/Collector/*,Base/Config/Validate",
+		},
+		{
+			name:    "merged with -t",
+			tasks:   "Base/Env/CollectEnvVars",
+			content: "Base/Config/Validate\n",
+			want:    "Base/Env/CollectEnvVars,Base/Config/Validate",
+		},
+		{
+			name:    "only comments",
+			tasks:   "Base/Env/CollectEnvVars",
+			content: "# nothing to run\n\n",
+			want:    "Base/Env/CollectEnvVars",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ioutil.WriteFile(taskFile, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			config.Flags.Tasks, config.Flags.TaskFile = tt.tasks, taskFile
+
+			err := processTaskFile()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("processTaskFile() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if config.Flags.Tasks != tt.want {
+				t.Errorf("processTaskFile() set -t to %q, want %q", config.Flags.Tasks, tt.want)
+			}
+		})
+	}
+
+	config.Flags.TaskFile = filepath.Join(t.TempDir(), "missing.txt")
+	if err := processTaskFile(); err == nil {
+		t.Error("Expected an error for a missing -task-file")
+	}
+}
+
+func Test_processIncludePaths(t *testing.T) {
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	installDir := filepath.Join(dir, "install")
+	if err := os.Mkdir(installDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	link := filepath.Join(dir, "link")
+	if err := os.Symlink(installDir, link); err != nil {
+		t.Skip("symlinks are not supported:", err)
+	}
+	file := filepath.Join(dir, "newrelic.yml")
+	if err := ioutil.WriteFile(file, []byte("license_key: abc\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	loop := filepath.Join(dir, "loop")
+	if err := os.Symlink(loop, loop); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { config.Flags.IncludePaths = nil }()
+
+	config.Flags.IncludePaths = []string{installDir, link, installDir + string(filepath.Separator)}
+	if err := processIncludePaths(); err != nil {
+		t.Fatalf("processIncludePaths() error = %v", err)
+	}
+	if !reflect.DeepEqual(config.Flags.IncludePaths, []string{installDir}) {
+		t.Errorf("processIncludePaths() resolved %q, want %q", config.Flags.IncludePaths, []string{installDir})
+	}
+
+	for _, invalid := range []string{file, loop, filepath.Join(dir, "missing")} {
+		config.Flags.IncludePaths = []string{invalid}
+		if err := processIncludePaths(); err == nil {
+			t.Errorf("processIncludePaths() accepted %s", invalid)
+		}
+	}
+}
+
+func Test_promptUser_assumeYes(t *testing.T) {
+	askUser := tasks.AskUser
+	tasks.AskUser = func(msg string) bool {
+		t.Errorf("prompted with -y: %s", msg)
+		return false
+	}
+	defer func() { tasks.AskUser = askUser }()
+	config.Flags.YesToAll = true
+	defer func() { config.Flags.YesToAll = false }()
+
+	// a file that may contain secure information, collected only once the user agreed
+	dir := t.TempDir()
+	if err := ioutil.WriteFile(filepath.Join(dir, "app.config"), []byte("<configuration/>"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	workingDir, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(workingDir)
+
+	options, _ := processOverrides()
+	if !promptUser("Do you want to upload these to your New Relic account?") {
+		t.Error("promptUser() = false with -y")
+	}
+	// tasks given options without YesToAll still honor -y
+	if !tasks.PromptUser("Include this file in nrdiag-output.zip?", tasks.Options{}) {
+		t.Error("tasks.PromptUser() = false with -y")
+	}
+
+	result := baseConfig.BaseConfigCollect{}.Execute(options, map[string]tasks.Result{})
+	collected := false
+	for _, file := range result.FilesToCopy {
+		collected = collected || filepath.Base(file.Path) == "app.config"
+	}
+	if !collected {
+		t.Errorf("Base/Config/Collect did not collect app.config with -y: %s", result.Summary)
+	}
+}
@@ -1,15 +1,19 @@
 package httpHelper
 
 import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"errors"
+	"fmt"
 	"io"
-	"net"
+	"math"
 	"net/http"
+	"sync"
 	"time"
 
 	"github.com/newrelic/newrelic-diagnostics-cli/config"
 	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
-	pb "gopkg.in/cheggaaa/pb.v1"
 )
 
 //RequestWrapper is a basic wrapper to streamline the use of http requests within the project
@@ -17,10 +21,90 @@ type RequestWrapper struct {
 	Method         string
 	URL            string
 	Headers        map[string]string
+	// Context cancels the request when done. Defaults to the run context, see SetRunContext
+	Context        context.Context
 	Payload        io.Reader
 	Length         int64
+	// TimeoutSeconds overrides the -http-timeout flag for this request when set
 	TimeoutSeconds int16
 	BypassProxy    bool
+	// RootCAs replaces the trusted roots of a request bypassing the proxy, e.g. with the cluster CA of the Kubernetes API server
+	RootCAs *x509.CertPool
+	// Network forces the IP version of a request bypassing the proxy, "tcp4" or "tcp6". The default "tcp" tries both
+	Network string
+	// RetryCount is the number of additional attempts made when the request fails to complete. Only requests without a Payload are retried
+	RetryCount int
+	// RetryBackoffSeconds is the wait before the first retry, doubled on each following retry
+	RetryBackoffSeconds int
+}
+
+//RetryError is returned when a request configured with a RetryCount failed on every attempt. It wraps the error of the last attempt
+type RetryError struct {
+	Attempts int
+	Err      error
+}
+
+func (e RetryError) Error() string {
+	return e.Err.Error()
+}
+
+func (e RetryError) Unwrap() error {
+	return e.Err
+}
+
+// runContext is done when the run is interrupted or reaches its -deadline, which cancels the requests made with it.
+// Requests can be made by tasks abandoned at the deadline while it is replaced, hence the lock
+var runContext = struct {
+	sync.Mutex
+	ctx context.Context
+}{ctx: context.Background()}
+
+//SetRunContext - sets the context of the run, used by the requests that do not set their own Context
+func SetRunContext(ctx context.Context) {
+	runContext.Lock()
+	defer runContext.Unlock()
+	runContext.ctx = ctx
+}
+
+//RunContext - returns the context of the run, to derive the contexts of requests from
+func RunContext() context.Context {
+	runContext.Lock()
+	defer runContext.Unlock()
+	return runContext.ctx
+}
+
+// injectedClient is the http.Client set by SetClient, nil for the proxy aware default of Client
+var injectedClient = struct {
+	sync.Mutex
+	client *http.Client
+}{}
+
+//SetClient - sets the client MakeHTTPRequest makes every request with, e.g. to add instrumentation or a test double when
+//embedding nrdiag. nil restores the default, the proxy aware client returned by Client
+func SetClient(httpClient *http.Client) {
+	injectedClient.Lock()
+	defer injectedClient.Unlock()
+	injectedClient.client = httpClient
+}
+
+// currentClient - the client set by SetClient, nil when there is none
+func currentClient() *http.Client {
+	injectedClient.Lock()
+	defer injectedClient.Unlock()
+	return injectedClient.client
+}
+
+// requestClient - a copy of the current client with the timeout of a request, unless the client sets its own
+func requestClient(timeoutSeconds int16) *http.Client {
+	current := currentClient()
+	if current == nil {
+		current = Client()
+	}
+	requestClient := *current
+	if requestClient.Timeout == 0 {
+		requestClient.Timeout = time.Duration(timeoutSeconds) * time.Second
+	}
+	return &requestClient
 }
 
 //NewHTTPRequestWrapper - returns a new request wrapper for creating an http request
@@ -31,7 +115,18 @@ func NewHTTPRequestWrapper() RequestWrapper {
 }
 
 //Default request timeout of 30 seconds if no timeout value is passed to helper.
-const defaultTimeoutSeconds = 30
+const defaultTimeoutSeconds = config.DefaultHTTPTimeoutSeconds
+
+//DefaultTimeoutSeconds - returns the timeout used for a request that does not set TimeoutSeconds: the -http-timeout flag if set, otherwise 30 seconds
+func DefaultTimeoutSeconds() int16 {
+	if config.Flags.HTTPTimeout > math.MaxInt16 {
+		return math.MaxInt16
+	}
+	if config.Flags.HTTPTimeout > 0 {
+		return int16(config.Flags.HTTPTimeout)
+	}
+	return defaultTimeoutSeconds
+}
 
 //MakeHTTPRequest -  takes the basics of a request and makes it
 func MakeHTTPRequest(wrapper RequestWrapper) (*http.Response, error) {
@@ -41,16 +136,60 @@ func MakeHTTPRequest(wrapper RequestWrapper) (*http.Response, error) {
 		return nil, errors.New("error: URL or method are not set")
 	}
 	reader := wrapper.Payload
-	// set up a progress bar if length is set
-	if wrapper.Length != 0 {
-		bar := pb.New(int(wrapper.Length)).SetUnits(pb.U_BYTES)
-		bar.Start()
-		defer bar.Finish()
-		reader = bar.NewProxyReader(wrapper.Payload)
+	// report the upload progress if length is set
+	if wrapper.Length != 0 && wrapper.Payload != nil {
+		progress := newProgressReader(wrapper.Payload, wrapper.Length)
+		defer progress.finish()
+		reader = progress
+	}
+
+	//If no request timeout is provided, use the -http-timeout flag, falling back to the default value.
+	if wrapper.TimeoutSeconds == 0 {
+		wrapper.TimeoutSeconds = DefaultTimeoutSeconds()
+	}
+
+	client := requestClient(wrapper.TimeoutSeconds)
+
+	resp, err := client.Do(newRequest(wrapper, reader, 1))
+	if err == nil || wrapper.RetryCount == 0 || wrapper.Payload != nil {
+		return resp, err
 	}
 
+	ctx := requestContext(wrapper)
+	attempts := 1
+	backoff := time.Duration(wrapper.RetryBackoffSeconds) * time.Second
+	for ; err != nil && attempts <= wrapper.RetryCount && ctx.Err() == nil; attempts++ {
+		log.Debugf("Request to %s failed: %s. Retrying in %s\n", wrapper.URL, err.Error(), backoff)
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			// the cancelled request below returns the context error
+		}
+		backoff *= 2
+		resp, err = client.Do(newRequest(wrapper, nil, attempts+1))
+	}
+	if err != nil {
+		return resp, RetryError{Attempts: attempts, Err: err}
+	}
+
+	return resp, nil
+
+}
+
+func requestContext(wrapper RequestWrapper) context.Context {
+	if wrapper.Context != nil {
+		return wrapper.Context
+	}
+	return RunContext()
+}
+
+func newRequest(wrapper RequestWrapper, body io.Reader, attempt int) *http.Request {
+	ctx := context.WithValue(requestContext(wrapper), requestAttemptKey{}, RequestAttempt{Number: attempt, Start: time.Now()})
+	if wrapper.BypassProxy {
+		ctx = context.WithValue(ctx, bypassProxyKey{}, bypassProxy{rootCAs: wrapper.RootCAs, network: wrapper.Network})
+	}
 	//Now create our request object
-	req, _ := http.NewRequest(wrapper.Method, wrapper.URL, reader)
+	req, _ := http.NewRequestWithContext(ctx, wrapper.Method, wrapper.URL, body)
 
 	// Setting the content length header if supplied
 	if wrapper.Length != 0 {
@@ -62,44 +201,81 @@ func MakeHTTPRequest(wrapper RequestWrapper) (*http.Response, error) {
 		req.Header.Set(k, v)
 	}
 
-	//If no request timeout is provided, set timeout to default value.
-	if wrapper.TimeoutSeconds == 0 {
-		wrapper.TimeoutSeconds = defaultTimeoutSeconds
-	}
+	req.Header.Set("User-Agent", "Nrdiag_/"+config.Version)
+	return req
+}
 
-	var transport http.RoundTripper
+// requestAttemptKey - the context key of the RequestAttempt of a request
+type requestAttemptKey struct{}
 
-	//All HTTP requests use the same http transport.
-	//However, when bypassing the detected system proxy, the request will be using its own unique http transport.
-	if wrapper.BypassProxy {
-		//These are the http.DefaultTransport values minus the Proxy
-		transport = &http.Transport{
-			DialContext: (&net.Dialer{
-				Timeout:   30 * time.Second,
-				KeepAlive: 30 * time.Second,
-				DualStack: true,
-			}).DialContext,
-			MaxIdleConns:          100,
-			IdleConnTimeout:       90 * time.Second,
-			TLSHandshakeTimeout:   10 * time.Second,
-			ExpectContinueTimeout: 1 * time.Second,
-		}
-	} else {
-		transport = http.DefaultTransport
-	}
+// RequestAttempt - the attempt of a request that returned its response, see RetryCount
+type RequestAttempt struct {
+	// Number is 1 for the first attempt
+	Number int
+	Start  time.Time
+}
 
-	client := &http.Client{
-		Transport: transport,
-		Timeout:   time.Duration(wrapper.TimeoutSeconds) * time.Second,
+//GetRequestAttempt - returns the attempt of the request that returned a response made by MakeHTTPRequest. Returns nil
+//if the response was not made by it
+func GetRequestAttempt(resp *http.Response) *RequestAttempt {
+	if resp == nil || resp.Request == nil {
+		return nil
 	}
+	attempt, ok := resp.Request.Context().Value(requestAttemptKey{}).(RequestAttempt)
+	if !ok {
+		return nil
+	}
+	return &attempt
+}
 
-	req.Header.Set("User-Agent", "Nrdiag_/"+config.Version)
+// TLSConnectionInfo - negotiated TLS details of a completed request
+type TLSConnectionInfo struct {
+	Version     string
+	CipherSuite string
+}
 
-	resp, err := client.Do(req)
-	if err != nil {
-		return resp, err
+var tlsVersionNames = map[uint16]string{
+	tls.VersionTLS10: "TLS 1.0",
+	tls.VersionTLS11: "TLS 1.1",
+	tls.VersionTLS12: "TLS 1.2",
+	tls.VersionTLS13: "TLS 1.3",
+}
+
+//GetTLSConnectionInfo - returns the TLS version and cipher suite negotiated for a response. Returns nil if the response was not made over TLS
+func GetTLSConnectionInfo(resp *http.Response) *TLSConnectionInfo {
+	if resp == nil || resp.TLS == nil {
+		return nil
+	}
+	return &TLSConnectionInfo{
+		Version:     TLSVersionName(resp.TLS.Version),
+		CipherSuite: tls.CipherSuiteName(resp.TLS.CipherSuite),
 	}
+}
 
-	return resp, nil
+//TLSVersionName - returns a readable name for a TLS version constant such as tls.VersionTLS12
+func TLSVersionName(version uint16) string {
+	if name, ok := tlsVersionNames[version]; ok {
+		return name
+	}
+	return fmt.Sprintf("unknown (0x%04X)", version)
+}
 
+// CertificateInfo - details of the leaf certificate presented by the server of a completed request
+type CertificateInfo struct {
+	Subject  string
+	Issuer   string
+	NotAfter time.Time
+}
+
+//GetLeafCertificateInfo - returns the subject, issuer and expiry of the server's leaf certificate. Returns nil if the response was not made over TLS
+func GetLeafCertificateInfo(resp *http.Response) *CertificateInfo {
+	if resp == nil || resp.TLS == nil || len(resp.TLS.PeerCertificates) == 0 {
+		return nil
+	}
+	leaf := resp.TLS.PeerCertificates[0]
+	return &CertificateInfo{
+		Subject:  leaf.Subject.String(),
+		Issuer:   leaf.Issuer.String(),
+		NotAfter: leaf.NotAfter,
+	}
 }
@@ -0,0 +1,217 @@
+package httpHelper
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/config"
+)
+
+// newFlakyServer - returns a test server that drops the connection for the first `failures` requests. The handler runs
+// on the goroutines of the server, the count of requests is read with Load
+func newFlakyServer(t *testing.T, failures int) (*httptest.Server, *atomic.Int32) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if int(requests.Add(1)) <= failures {
+			conn, _, err := w.(http.Hijacker).Hijack()
+			if err != nil {
+				t.Fatalf("unable to hijack connection: %s", err)
+			}
+			conn.Close()
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	return server, &requests
+}
+
+func TestMakeHTTPRequest_Retry(t *testing.T) {
+	tests := []struct {
+		name         string
+		failures     int
+		retryCount   int
+		wantErr      bool
+		wantAttempts int
+		wantRequests int
+	}{
+		{
+			name:         "should not retry by default",
+			failures:     1,
+			retryCount:   0,
+			wantErr:      true,
+			wantRequests: 1,
+		},
+		{
+			name:         "should succeed when a retry completes",
+			failures:     2,
+			retryCount:   2,
+			wantErr:      false,
+			wantRequests: 3,
+		},
+		{
+			name:         "should return a RetryError when every attempt fails",
+			failures:     5,
+			retryCount:   2,
+			wantErr:      true,
+			wantAttempts: 3,
+			wantRequests: 3,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, requests := newFlakyServer(t, tt.failures)
+			defer server.Close()
+
+			resp, err := MakeHTTPRequest(RequestWrapper{
+				Method:     "GET",
+				URL:        server.URL,
+				RetryCount: tt.retryCount,
+			})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("MakeHTTPRequest() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil {
+				resp.Body.Close()
+				if attempt := GetRequestAttempt(resp); attempt == nil || attempt.Number != tt.wantRequests {
+					t.Errorf("GetRequestAttempt() = %+v, want attempt %d", attempt, tt.wantRequests)
+				}
+			}
+			if int(requests.Load()) != tt.wantRequests {
+				t.Errorf("MakeHTTPRequest() made %d requests, want %d", requests.Load(), tt.wantRequests)
+			}
+
+			var retryErr RetryError
+			if errors.As(err, &retryErr) != (tt.wantAttempts > 0) {
+				t.Fatalf("MakeHTTPRequest() error = %#v, want RetryError: %v", err, tt.wantAttempts > 0)
+			}
+			if retryErr.Attempts != tt.wantAttempts {
+				t.Errorf("RetryError.Attempts = %d, want %d", retryErr.Attempts, tt.wantAttempts)
+			}
+		})
+	}
+}
+
+func TestDefaultTimeoutSeconds(t *testing.T) {
+	defer func() { config.Flags.HTTPTimeout = 0 }()
+
+	tests := []struct {
+		flag int
+		want int16
+	}{
+		{flag: 0, want: 30},
+		{flag: -5, want: 30},
+		{flag: 5, want: 5},
+		{flag: 100000, want: math.MaxInt16},
+	}
+	for _, tt := range tests {
+		config.Flags.HTTPTimeout = tt.flag
+		if got := DefaultTimeoutSeconds(); got != tt.want {
+			t.Errorf("DefaultTimeoutSeconds() with -http-timeout %d = %d, want %d", tt.flag, got, tt.want)
+		}
+	}
+}
+
+func TestMakeHTTPRequest_RequestContextCancelled(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	SetRunContext(ctx)
+	defer SetRunContext(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	start := time.Now()
+	_, err := MakeHTTPRequest(RequestWrapper{Method: "GET", URL: server.URL, TimeoutSeconds: 30})
+
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected the in-flight request to be cancelled, got %v", err)
+	}
+	if time.Since(start) > 5*time.Second {
+		t.Error("Expected the request to return when the context was cancelled, took", time.Since(start))
+	}
+}
+
+func TestMakeHTTPRequest_WrapperContextStopsRetries(t *testing.T) {
+	server, requests := newFlakyServer(t, 5)
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	start := time.Now()
+	_, err := MakeHTTPRequest(RequestWrapper{Method: "GET", URL: server.URL, Context: ctx, RetryCount: 3, RetryBackoffSeconds: 10})
+
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected the cancelled context error, got %v", err)
+	}
+	if time.Since(start) > 5*time.Second {
+		t.Error("Expected the retries to stop when the context was cancelled, took", time.Since(start))
+	}
+	if requests.Load() != 1 {
+		t.Errorf("Expected a single request before the cancellation, got %d", requests.Load())
+	}
+}
+
+// recordingTransport - answers every request itself, recording its URL
+type recordingTransport struct {
+	urls []string
+}
+
+func (r *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	r.urls = append(r.urls, req.URL.String())
+	return &http.Response{StatusCode: http.StatusTeapot, Body: http.NoBody, Request: req}, nil
+}
+
+func TestSetClient(t *testing.T) {
+	transport := &recordingTransport{}
+	SetClient(&http.Client{Transport: transport})
+	defer SetClient(nil)
+
+	for _, wrapper := range []RequestWrapper{
+		{Method: "GET", URL: "https://collector.newrelic.com/status/mongrel"},
+		{Method: "GET", URL: "https://kubernetes.default.svc/api", BypassProxy: true},
+	} {
+		resp, err := MakeHTTPRequest(wrapper)
+		if err != nil || resp.StatusCode != http.StatusTeapot {
+			t.Errorf("MakeHTTPRequest(%s) = %v, %v, expected the response of the client set", wrapper.URL, resp, err)
+		}
+	}
+	if _, err := Client().Get("https://insights-collector.newrelic.com"); err != nil {
+		t.Error("Client() request error:", err)
+	}
+	if len(transport.urls) != 3 {
+		t.Errorf("Expected the 3 requests to go through the client set, got %v", transport.urls)
+	}
+
+	SetClient(nil)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	resp, err := MakeHTTPRequest(RequestWrapper{Method: "GET", URL: server.URL, BypassProxy: true})
+	if err != nil || resp.StatusCode != http.StatusOK {
+		t.Errorf("MakeHTTPRequest() = %v, %v, expected the default client once reset", resp, err)
+	}
+}
+
+func TestMakeHTTPRequest_Network(t *testing.T) {
+	// httptest listens on 127.0.0.1, which only an IPv4 connection reaches
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	resp, err := MakeHTTPRequest(RequestWrapper{Method: "GET", URL: server.URL, BypassProxy: true, Network: "tcp4"})
+	if err != nil || resp.StatusCode != http.StatusOK {
+		t.Errorf("MakeHTTPRequest() over tcp4 = %v, %v, expected a response", resp, err)
+	}
+	if _, err := MakeHTTPRequest(RequestWrapper{Method: "GET", URL: server.URL, BypassProxy: true, Network: "tcp6"}); err == nil {
+		t.Error("MakeHTTPRequest() over tcp6 reached an IPv4 address")
+	}
+}
@@ -0,0 +1,87 @@
+package httpHelper
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/config"
+)
+
+// progressInterval is how often the progress of an upload is printed
+const progressInterval = 500 * time.Millisecond
+
+// progressOutput is where upload progress is printed. It is kept off stdout so it can't end up in piped results
+var progressOutput io.Writer = os.Stderr
+
+// stdoutIsTerminal - returns true when stdout is a terminal rather than a pipe or a file
+var stdoutIsTerminal = func() bool {
+	info, err := os.Stdout.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// progressReader counts the bytes read from a request body. In a terminal it periodically rewrites a line with the
+// percentage and bytes sent; under -q or when stdout is redirected only the final line is printed
+type progressReader struct {
+	reader      io.Reader
+	total       int64
+	sent        int64
+	interactive bool
+	out         io.Writer
+	lastPrint   time.Time
+	now         func() time.Time
+}
+
+func newProgressReader(reader io.Reader, total int64) *progressReader {
+	return &progressReader{
+		reader:      reader,
+		total:       total,
+		interactive: !config.Flags.Quiet && stdoutIsTerminal(),
+		out:         progressOutput,
+		now:         time.Now,
+	}
+}
+
+func (p *progressReader) Read(buf []byte) (int, error) {
+	n, err := p.reader.Read(buf)
+	p.sent += int64(n)
+	if n > 0 && p.interactive && p.now().Sub(p.lastPrint) >= progressInterval {
+		p.lastPrint = p.now()
+		fmt.Fprint(p.out, "\r"+p.progressLine())
+	}
+	return n, err
+}
+
+// finish prints the final state of the upload, ending the line rewritten in a terminal
+func (p *progressReader) finish() {
+	if p.interactive {
+		fmt.Fprint(p.out, "\r")
+	}
+	fmt.Fprintln(p.out, p.progressLine())
+}
+
+func (p *progressReader) progressLine() string {
+	percent := 100
+	if p.total > 0 && p.sent < p.total {
+		percent = int(p.sent * 100 / p.total)
+	}
+	return fmt.Sprintf("Uploading: %3d%% (%s of %s)", percent, formatBytes(p.sent), formatBytes(p.total))
+}
+
+// formatBytes - returns a byte count in the largest unit it reaches, e.g. 1.5 MB
+func formatBytes(count int64) string {
+	const unit = 1024
+	if count < unit {
+		return fmt.Sprintf("%d B", count)
+	}
+	div, exp := int64(unit), 0
+	for n := count / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(count)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,111 @@
+package httpHelper
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/config"
+)
+
+func TestProgressReader(t *testing.T) {
+	tests := []struct {
+		name     string
+		terminal bool
+		quiet    bool
+		want     string
+	}{
+		{
+			name:     "terminal",
+			terminal: true,
+			want:     "\rUploading:  40% (4 B of 10 B)\rUploading:  80% (8 B of 10 B)\rUploading: 100% (10 B of 10 B)\rUploading: 100% (10 B of 10 B)\n",
+		},
+		{
+			name:     "redirected stdout",
+			terminal: false,
+			want:     "Uploading: 100% (10 B of 10 B)\n",
+		},
+		{
+			name:     "quiet",
+			terminal: true,
+			quiet:    true,
+			want:     "Uploading: 100% (10 B of 10 B)\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			originalTerminal, originalQuiet := stdoutIsTerminal, config.Flags.Quiet
+			defer func() { stdoutIsTerminal, config.Flags.Quiet = originalTerminal, originalQuiet }()
+			stdoutIsTerminal = func() bool { return tt.terminal }
+			config.Flags.Quiet = tt.quiet
+
+			var out bytes.Buffer
+			clock := time.Unix(0, 0)
+			progress := newProgressReader(strings.NewReader("0123456789"), 10)
+			progress.out = &out
+			progress.now = func() time.Time {
+				clock = clock.Add(progressInterval)
+				return clock
+			}
+
+			buf := make([]byte, 4)
+			for {
+				if _, err := progress.Read(buf); err != nil {
+					break
+				}
+			}
+			progress.finish()
+
+			if out.String() != tt.want {
+				t.Errorf("progress output = %q, want %q", out.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestMakeHTTPRequest_ProgressOnStderr(t *testing.T) {
+	originalOutput, originalTerminal := progressOutput, stdoutIsTerminal
+	defer func() { progressOutput, stdoutIsTerminal = originalOutput, originalTerminal }()
+	var out bytes.Buffer
+	progressOutput = &out
+	stdoutIsTerminal = func() bool { return false }
+
+	var received string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		received = string(body)
+	}))
+	defer server.Close()
+
+	payload := strings.Repeat("x", 2048)
+	_, err := MakeHTTPRequest(RequestWrapper{Method: "PUT", URL: server.URL, Payload: strings.NewReader(payload), Length: int64(len(payload))})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if received != payload {
+		t.Errorf("server received %d bytes, want %d", len(received), len(payload))
+	}
+	if out.String() != "Uploading: 100% (2.0 KB of 2.0 KB)\n" {
+		t.Errorf("progress output = %q", out.String())
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	tests := map[int64]string{
+		0:                 "0 B",
+		1023:              "1023 B",
+		1536:              "1.5 KB",
+		350 * 1024 * 1024: "350.0 MB",
+		3 << 30:           "3.0 GB",
+	}
+	for count, want := range tests {
+		if got := formatBytes(count); got != want {
+			t.Errorf("formatBytes(%d) = %q, want %q", count, got, want)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package httpHelper
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io/ioutil"
+)
+
+// rootCAs - trusted roots set by LoadCABundle, nil to use the system roots
+var rootCAs *x509.CertPool
+
+// clientCertificates - certificates set by LoadClientCertificate, presented to servers or proxies that request one
+var clientCertificates []tls.Certificate
+
+// LoadCABundle - adds the certificates of a PEM file to the roots trusted by every request, e.g. the root of a TLS-inspecting corporate proxy.
+// The system roots stay trusted when they can be loaded. Returns an error if the file can't be read or holds no certificate
+func LoadCABundle(path string) error {
+	pem, err := ioutil.ReadFile(path)
+	if err != nil {
+		return errors.New("unable to read CA bundle " + path + ": " + err.Error())
+	}
+
+	pool, err := x509.SystemCertPool()
+	if err != nil || pool == nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(pem) {
+		return errors.New("no PEM encoded certificate could be parsed from CA bundle " + path)
+	}
+
+	transportMu.Lock()
+	defer transportMu.Unlock()
+	rootCAs = pool
+	// rebuild the shared transport with the new roots
+	proxyTransport = nil
+	return nil
+}
+
+// LoadClientCertificate - loads a PEM encoded certificate and private key presented by every request to servers requiring mutual TLS.
+// Both files must be provided
+func LoadClientCertificate(certFile, keyFile string) error {
+	if certFile == "" || keyFile == "" {
+		return errors.New("a client certificate requires both -client-cert and -client-key")
+	}
+	certificate, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return errors.New("unable to load client certificate " + certFile + ": " + err.Error())
+	}
+
+	transportMu.Lock()
+	defer transportMu.Unlock()
+	clientCertificates = []tls.Certificate{certificate}
+	// rebuild the shared transport with the new certificate
+	proxyTransport = nil
+	return nil
+}
+
+// tlsClientConfig - TLS settings applied to every transport built by this package
+func tlsClientConfig() *tls.Config {
+	return &tls.Config{
+		RootCAs:      rootCAs,
+		Certificates: clientCertificates,
+	}
+}
@@ -0,0 +1,174 @@
+package httpHelper
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// resetTLS - restores the system roots once a test has loaded its own
+func resetTLS(t *testing.T) {
+	t.Cleanup(func() {
+		transportMu.Lock()
+		defer transportMu.Unlock()
+		rootCAs = nil
+		clientCertificates = nil
+		proxyTransport = nil
+	})
+}
+
+func TestLoadCABundle(t *testing.T) {
+	clearProxyEnv(t)
+	resetTLS(t)
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	_, err := MakeHTTPRequest(RequestWrapper{Method: "GET", URL: server.URL})
+	if err == nil {
+		t.Fatal("MakeHTTPRequest() to a server signed by an unknown authority should fail")
+	}
+
+	bundle := filepath.Join(t.TempDir(), "bundle.pem")
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := ioutil.WriteFile(bundle, certPEM, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := LoadCABundle(bundle); err != nil {
+		t.Fatalf("LoadCABundle() error = %v", err)
+	}
+
+	resp, err := MakeHTTPRequest(RequestWrapper{Method: "GET", URL: server.URL})
+	if err != nil {
+		t.Fatalf("MakeHTTPRequest() with the CA bundle loaded error = %v", err)
+	}
+	resp.Body.Close()
+}
+
+func TestLoadCABundle_invalid(t *testing.T) {
+	resetTLS(t)
+	dir := t.TempDir()
+	notPEM := filepath.Join(dir, "bundle.pem")
+	if err := ioutil.WriteFile(notPEM, []byte("not a certificate"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		path string
+	}{
+		{name: "missing file", path: filepath.Join(dir, "missing.pem")},
+		{name: "file without certificates", path: notPEM},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := LoadCABundle(tt.path); err == nil {
+				t.Errorf("LoadCABundle() error = nil, want an error")
+			}
+			if rootCAs != nil {
+				t.Errorf("LoadCABundle() replaced the trusted roots on error")
+			}
+		})
+	}
+}
+
+// writeClientCertificate - writes a self-signed client certificate and its key as PEM files, returning their paths
+func writeClientCertificate(t *testing.T) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "nrdiag-test-client"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "client.crt")
+	keyFile := filepath.Join(dir, "client.key")
+	if err := ioutil.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return certFile, keyFile
+}
+
+func TestLoadClientCertificate(t *testing.T) {
+	clearProxyEnv(t)
+	resetTLS(t)
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+	server.StartTLS()
+	defer server.Close()
+
+	bundle := filepath.Join(t.TempDir(), "bundle.pem")
+	if err := ioutil.WriteFile(bundle, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := LoadCABundle(bundle); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := MakeHTTPRequest(RequestWrapper{Method: "GET", URL: server.URL}); err == nil {
+		t.Fatal("MakeHTTPRequest() without a client certificate should fail")
+	}
+
+	certFile, keyFile := writeClientCertificate(t)
+	if err := LoadClientCertificate(certFile, keyFile); err != nil {
+		t.Fatalf("LoadClientCertificate() error = %v", err)
+	}
+	resp, err := MakeHTTPRequest(RequestWrapper{Method: "GET", URL: server.URL})
+	if err != nil {
+		t.Fatalf("MakeHTTPRequest() with a client certificate error = %v", err)
+	}
+	resp.Body.Close()
+}
+
+func TestLoadClientCertificate_invalid(t *testing.T) {
+	resetTLS(t)
+	certFile, keyFile := writeClientCertificate(t)
+
+	tests := []struct {
+		name     string
+		certFile string
+		keyFile  string
+	}{
+		{name: "missing key", certFile: certFile},
+		{name: "missing certificate", keyFile: keyFile},
+		{name: "key provided as certificate", certFile: keyFile, keyFile: keyFile},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := LoadClientCertificate(tt.certFile, tt.keyFile); err == nil {
+				t.Errorf("LoadClientCertificate() error = nil, want an error")
+			}
+		})
+	}
+}
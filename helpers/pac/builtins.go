@@ -0,0 +1,425 @@
+package pac
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var errUnsupportedTime = errors.New("the date and time functions of PAC files are not supported")
+
+// builtins - the PAC helper functions, the global scope of each PAC file
+func builtins() map[string]interface{} {
+	return map[string]interface{}{
+		"isPlainHostName": builtin(func(args []interface{}) (interface{}, error) {
+			return !strings.Contains(stringArg(args, 0), "."), nil
+		}),
+		"dnsDomainIs": builtin(func(args []interface{}) (interface{}, error) {
+			return strings.HasSuffix(strings.ToLower(stringArg(args, 0)), strings.ToLower(stringArg(args, 1))), nil
+		}),
+		"localHostOrDomainIs": builtin(func(args []interface{}) (interface{}, error) {
+			host, hostdom := strings.ToLower(stringArg(args, 0)), strings.ToLower(stringArg(args, 1))
+			if strings.Contains(host, ".") {
+				return host == hostdom, nil
+			}
+			return host == firstLabel(hostdom), nil
+		}),
+		"dnsDomainLevels": builtin(func(args []interface{}) (interface{}, error) {
+			return float64(strings.Count(stringArg(args, 0), ".")), nil
+		}),
+		"shExpMatch": builtin(func(args []interface{}) (interface{}, error) {
+			return shExpMatch(stringArg(args, 0), stringArg(args, 1)), nil
+		}),
+		"isResolvable": builtin(func(args []interface{}) (interface{}, error) {
+			return resolveIPv4(stringArg(args, 0)) != nil, nil
+		}),
+		"dnsResolve": builtin(func(args []interface{}) (interface{}, error) {
+			if ip := resolveIPv4(stringArg(args, 0)); ip != nil {
+				return ip.String(), nil
+			}
+			return nil, nil
+		}),
+		"isInNet": builtin(func(args []interface{}) (interface{}, error) {
+			return isInNet(stringArg(args, 0), stringArg(args, 1), stringArg(args, 2)), nil
+		}),
+		"myIpAddress": builtin(func(args []interface{}) (interface{}, error) {
+			return localAddress(), nil
+		}),
+		"convert_addr": builtin(func(args []interface{}) (interface{}, error) {
+			ip := net.ParseIP(stringArg(args, 0)).To4()
+			if ip == nil {
+				return float64(0), nil
+			}
+			return float64(uint32(ip[0])<<24 | uint32(ip[1])<<16 | uint32(ip[2])<<8 | uint32(ip[3])), nil
+		}),
+		"weekdayRange": builtin(func(args []interface{}) (interface{}, error) {
+			return nil, errUnsupportedTime
+		}),
+		"dateRange": builtin(func(args []interface{}) (interface{}, error) {
+			return nil, errUnsupportedTime
+		}),
+		"timeRange": builtin(func(args []interface{}) (interface{}, error) {
+			return nil, errUnsupportedTime
+		}),
+		// alert only logs in the browsers
+		"alert": builtin(func(args []interface{}) (interface{}, error) {
+			return nil, nil
+		}),
+	}
+}
+
+// stringMethod - the String.prototype methods PAC files use on the URL and the host
+func stringMethod(str string, name string, args []interface{}) (interface{}, error) {
+	switch name {
+	case "toLowerCase":
+		return strings.ToLower(str), nil
+	case "toUpperCase":
+		return strings.ToUpper(str), nil
+	case "indexOf":
+		return float64(strings.Index(str, stringArg(args, 0))), nil
+	case "lastIndexOf":
+		return float64(strings.LastIndex(str, stringArg(args, 0))), nil
+	case "startsWith":
+		return strings.HasPrefix(str, stringArg(args, 0)), nil
+	case "endsWith":
+		return strings.HasSuffix(str, stringArg(args, 0)), nil
+	case "includes":
+		return strings.Contains(str, stringArg(args, 0)), nil
+	case "charAt":
+		index := indexArg(args, 0, 0, len(str))
+		if index >= len(str) {
+			return "", nil
+		}
+		return str[index : index+1], nil
+	case "substring":
+		start, end := indexArg(args, 0, 0, len(str)), indexArg(args, 1, len(str), len(str))
+		if start > end {
+			start, end = end, start
+		}
+		return str[start:end], nil
+	case "split":
+		var parts []string
+		if len(args) == 0 || args[0] == nil {
+			parts = []string{str}
+		} else if re, ok := args[0].(*regexp.Regexp); ok {
+			parts = re.Split(str, -1)
+		} else {
+			parts = strings.Split(str, toString(args[0]))
+		}
+		elements := make([]interface{}, len(parts))
+		for n, part := range parts {
+			elements[n] = part
+		}
+		return &array{elements: elements}, nil
+	case "match":
+		re, ok := regexArg(args, 0)
+		if !ok {
+			return nil, fmt.Errorf("unsupported argument of match, a regular expression is expected")
+		}
+		return regexMethod(re, "exec", []interface{}{str})
+	case "search":
+		re, ok := regexArg(args, 0)
+		if !ok {
+			return nil, fmt.Errorf("unsupported argument of search, a regular expression is expected")
+		}
+		if loc := re.FindStringIndex(str); loc != nil {
+			return float64(loc[0]), nil
+		}
+		return float64(-1), nil
+	case "replace":
+		// the first match only, as without the g flag. $ has no special meaning in the replacement
+		replacement := stringArg(args, 1)
+		if re, ok := regexArg(args, 0); ok {
+			if loc := re.FindStringIndex(str); loc != nil {
+				return str[:loc[0]] + replacement + str[loc[1]:], nil
+			}
+			return str, nil
+		}
+		return strings.Replace(str, stringArg(args, 0), replacement, 1), nil
+	case "trim":
+		return strings.TrimSpace(str), nil
+	}
+	return nil, fmt.Errorf("unsupported string method %s", name)
+}
+
+// arrayMethod - the Array.prototype methods PAC files use on their lists of hosts and networks
+func arrayMethod(arr *array, name string, args []interface{}) (interface{}, error) {
+	switch name {
+	case "push":
+		arr.elements = append(arr.elements, args...)
+		return float64(len(arr.elements)), nil
+	case "indexOf":
+		var search interface{}
+		if len(args) > 0 {
+			search = args[0]
+		}
+		for n, element := range arr.elements {
+			if strictEquals(element, search) {
+				return float64(n), nil
+			}
+		}
+		return float64(-1), nil
+	case "join":
+		separator := ","
+		if len(args) > 0 && args[0] != nil {
+			separator = toString(args[0])
+		}
+		return joinElements(arr, separator), nil
+	}
+	return nil, fmt.Errorf("unsupported array method %s", name)
+}
+
+// regexMethod - the RegExp.prototype methods, exec returns the match and its groups or null
+func regexMethod(re *regexp.Regexp, name string, args []interface{}) (interface{}, error) {
+	switch name {
+	case "test":
+		return re.MatchString(stringArg(args, 0)), nil
+	case "exec":
+		match := re.FindStringSubmatch(stringArg(args, 0))
+		if match == nil {
+			return nil, nil
+		}
+		elements := make([]interface{}, len(match))
+		for n, group := range match {
+			elements[n] = group
+		}
+		return &array{elements: elements}, nil
+	}
+	return nil, fmt.Errorf("unsupported regular expression method %s", name)
+}
+
+func regexArg(args []interface{}, n int) (*regexp.Regexp, bool) {
+	if n >= len(args) {
+		return nil, false
+	}
+	re, ok := args[n].(*regexp.Regexp)
+	return re, ok
+}
+
+func joinElements(arr *array, separator string) string {
+	parts := make([]string, len(arr.elements))
+	for n, element := range arr.elements {
+		// null and undefined elements are joined as empty strings
+		if element != nil {
+			parts[n] = toString(element)
+		}
+	}
+	return strings.Join(parts, separator)
+}
+
+func stringArg(args []interface{}, n int) string {
+	if n >= len(args) {
+		return ""
+	}
+	return toString(args[n])
+}
+
+// indexArg - an index argument clamped to the string, or the default when it is missing
+func indexArg(args []interface{}, n int, defaultIndex int, length int) int {
+	if n >= len(args) || args[n] == nil {
+		return defaultIndex
+	}
+	index := toNumber(args[n])
+	if math.IsNaN(index) || index < 0 {
+		return 0
+	}
+	if index > float64(length) {
+		return length
+	}
+	return int(index)
+}
+
+func firstLabel(host string) string {
+	return strings.SplitN(host, ".", 2)[0]
+}
+
+// shExpMatch - matches a shell expression, where * is any string and ? any character
+func shExpMatch(str string, shexp string) bool {
+	var pattern strings.Builder
+	pattern.WriteString("^")
+	for _, r := range shexp {
+		switch r {
+		case '*':
+			pattern.WriteString(".*")
+		case '?':
+			pattern.WriteString(".")
+		default:
+			pattern.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	pattern.WriteString("$")
+	matched, err := regexp.MatchString(pattern.String(), str)
+	return err == nil && matched
+}
+
+// resolveIPv4 - the IPv4 address of the host, itself when it already is one. PAC files compare IPv4 addresses only
+func resolveIPv4(host string) net.IP {
+	if ip := net.ParseIP(host); ip != nil {
+		return ip.To4()
+	}
+	addresses, err := lookupHost(host)
+	if err != nil {
+		return nil
+	}
+	for _, address := range addresses {
+		if ip := net.ParseIP(address).To4(); ip != nil {
+			return ip
+		}
+	}
+	return nil
+}
+
+func isInNet(host string, pattern string, mask string) bool {
+	ip := resolveIPv4(host)
+	patternIP := net.ParseIP(pattern).To4()
+	maskIP := net.ParseIP(mask).To4()
+	if ip == nil || patternIP == nil || maskIP == nil {
+		return false
+	}
+	netMask := net.IPMask(maskIP)
+	return ip.Mask(netMask).Equal(patternIP.Mask(netMask))
+}
+
+// myIPAddress - the address of the interface routing to the internet. Dialing UDP sends no packet, it only picks the route
+func myIPAddress() string {
+	conn, err := net.Dial("udp", "198.51.100.1:80")
+	if err != nil {
+		return "127.0.0.1"
+	}
+	defer conn.Close()
+	if addr, ok := conn.LocalAddr().(*net.UDPAddr); ok && addr.IP.To4() != nil {
+		return addr.IP.String()
+	}
+	return "127.0.0.1"
+}
+
+// The JavaScript conversions of the values of the interpreter: string, float64, bool, nil for null and undefined,
+// *array, *regexp.Regexp and functions
+
+func truthy(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return false
+	case bool:
+		return v
+	case float64:
+		return v != 0 && !math.IsNaN(v)
+	case string:
+		return v != ""
+	}
+	return true
+}
+
+func toString(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return strconv.FormatBool(v)
+	case float64:
+		if math.IsNaN(v) {
+			return "NaN"
+		}
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case string:
+		return v
+	case function:
+		return "function " + v.name
+	case *array:
+		return joinElements(v, ",")
+	case *regexp.Regexp:
+		return "/" + v.String() + "/"
+	}
+	return "function"
+}
+
+// typeOf - the result of the typeof operator
+func typeOf(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "undefined"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case *array, *regexp.Regexp:
+		return "object"
+	}
+	return "function"
+}
+
+func toNumber(value interface{}) float64 {
+	switch v := value.(type) {
+	case nil:
+		return 0
+	case bool:
+		if v {
+			return 1
+		}
+		return 0
+	case float64:
+		return v
+	case string:
+		v = strings.TrimSpace(v)
+		if v == "" {
+			return 0
+		}
+		if number, err := strconv.ParseFloat(v, 64); err == nil {
+			return number
+		}
+	}
+	return math.NaN()
+}
+
+func strictEquals(left, right interface{}) bool {
+	switch l := left.(type) {
+	case nil:
+		return right == nil
+	case bool:
+		r, ok := right.(bool)
+		return ok && l == r
+	case float64:
+		r, ok := right.(float64)
+		return ok && l == r
+	case string:
+		r, ok := right.(string)
+		return ok && l == r
+	case *array:
+		r, ok := right.(*array)
+		return ok && l == r
+	case *regexp.Regexp:
+		r, ok := right.(*regexp.Regexp)
+		return ok && l == r
+	}
+	// functions are only equal to themselves, which PAC files don't compare
+	return false
+}
+
+func looseEquals(left, right interface{}) bool {
+	if left == nil || right == nil {
+		return left == nil && right == nil
+	}
+	_, leftIsString := left.(string)
+	_, rightIsString := right.(string)
+	if leftIsString && rightIsString {
+		return strictEquals(left, right)
+	}
+	if isFunction(left) || isFunction(right) {
+		return false
+	}
+	return toNumber(left) == toNumber(right)
+}
+
+func isFunction(value interface{}) bool {
+	switch value.(type) {
+	case function, builtin:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,207 @@
+package pac
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenString
+	tokenNumber
+	tokenPunct
+	tokenRegex
+)
+
+type token struct {
+	kind  tokenKind
+	text  string
+	value interface{} // the string, float64 or *regexp.Regexp of a literal
+	line  int
+}
+
+// punctuators are matched longest first
+var punctuators = []string{
+	"===", "!==",
+	"==", "!=", "<=", ">=", "&&", "||", "++", "--", "+=", "-=", "*=", "/=", "%=",
+	"(", ")", "{", "}", "[", "]", ";", ",", ".", "!", "=", "<", ">", "+", "-", "*", "/", "%", "?", ":",
+}
+
+// tokenize - splits a PAC file into tokens, dropping the whitespace and the comments
+func tokenize(script string) ([]token, error) {
+	var tokens []token
+	line := 1
+	for i := 0; i < len(script); {
+		c := script[i]
+		switch {
+		case c == '\n':
+			line++
+			i++
+		case c == ' ' || c == '\t' || c == '\r':
+			i++
+		case strings.HasPrefix(script[i:], "//"):
+			end := strings.IndexByte(script[i:], '\n')
+			if end == -1 {
+				i = len(script)
+			} else {
+				i += end
+			}
+		case strings.HasPrefix(script[i:], "/*"):
+			end := strings.Index(script[i+2:], "*/")
+			if end == -1 {
+				return nil, fmt.Errorf("line %d: unterminated comment", line)
+			}
+			line += strings.Count(script[i:i+2+end], "\n")
+			i += end + 4
+		case c == '"' || c == '\'':
+			value, length, err := readString(script[i:])
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %s", line, err.Error())
+			}
+			tokens = append(tokens, token{kind: tokenString, text: script[i : i+length], value: value, line: line})
+			i += length
+		case c == '/' && regexAllowed(tokens):
+			re, length, err := readRegex(script[i:])
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %s", line, err.Error())
+			}
+			tokens = append(tokens, token{kind: tokenRegex, text: script[i : i+length], value: re, line: line})
+			i += length
+		case c >= '0' && c <= '9':
+			end := i
+			for end < len(script) && (script[end] >= '0' && script[end] <= '9' || script[end] == '.') {
+				end++
+			}
+			number, err := strconv.ParseFloat(script[i:end], 64)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid number %s", line, script[i:end])
+			}
+			tokens = append(tokens, token{kind: tokenNumber, text: script[i:end], value: number, line: line})
+			i = end
+		case isIdentStart(rune(c)):
+			end := i
+			for end < len(script) && isIdentPart(rune(script[end])) {
+				end++
+			}
+			tokens = append(tokens, token{kind: tokenIdent, text: script[i:end], line: line})
+			i = end
+		default:
+			matched := false
+			for _, punct := range punctuators {
+				if strings.HasPrefix(script[i:], punct) {
+					tokens = append(tokens, token{kind: tokenPunct, text: punct, line: line})
+					i += len(punct)
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return nil, fmt.Errorf("line %d: unexpected character %q", line, c)
+			}
+		}
+	}
+	return append(tokens, token{kind: tokenEOF, line: line}), nil
+}
+
+// readString - the value and length of the string literal starting the text, quotes included
+func readString(text string) (string, int, error) {
+	quote := text[0]
+	var value strings.Builder
+	for i := 1; i < len(text); i++ {
+		switch c := text[i]; c {
+		case quote:
+			return value.String(), i + 1, nil
+		case '\n':
+			return "", 0, fmt.Errorf("unterminated string")
+		case '\\':
+			i++
+			if i == len(text) {
+				return "", 0, fmt.Errorf("unterminated string")
+			}
+			switch escaped := text[i]; escaped {
+			case 'n':
+				value.WriteByte('\n')
+			case 't':
+				value.WriteByte('\t')
+			default:
+				value.WriteByte(escaped)
+			}
+		default:
+			value.WriteByte(c)
+		}
+	}
+	return "", 0, fmt.Errorf("unterminated string")
+}
+
+// regexAllowed - whether a / starts a regular expression literal rather than a division, which only follows a value
+func regexAllowed(tokens []token) bool {
+	if len(tokens) == 0 {
+		return true
+	}
+	previous := tokens[len(tokens)-1]
+	switch previous.kind {
+	case tokenNumber, tokenString, tokenRegex:
+		return false
+	case tokenIdent:
+		return previous.text == "return" || previous.text == "typeof" || previous.text == "case"
+	}
+	return previous.text != ")" && previous.text != "]"
+}
+
+// readRegex - the regular expression and length of the literal starting the text, slashes and flags included. Go's
+// RE2 syntax covers the expressions PAC files match hosts with, those using backreferences or lookarounds are refused
+func readRegex(text string) (*regexp.Regexp, int, error) {
+	inClass := false
+	for i := 1; i < len(text); i++ {
+		switch c := text[i]; {
+		case c == '\\':
+			i++
+		case c == '\n':
+			return nil, 0, fmt.Errorf("unterminated regular expression")
+		case c == '[':
+			inClass = true
+		case c == ']':
+			inClass = false
+		case c == '/' && !inClass:
+			end := i + 1
+			for end < len(text) && isIdentPart(rune(text[end])) {
+				end++
+			}
+			prefix := ""
+			for _, flag := range text[i+1 : end] {
+				switch flag {
+				case 'i', 'm', 's':
+					prefix += string(flag)
+				case 'g':
+					// test and match only look for the first match
+				default:
+					return nil, 0, fmt.Errorf("unsupported regular expression flag %c", flag)
+				}
+			}
+			source := text[1:i]
+			if prefix != "" {
+				source = "(?" + prefix + ")" + source
+			}
+			re, err := regexp.Compile(source)
+			if err != nil {
+				return nil, 0, fmt.Errorf("unsupported regular expression %s: %s", text[:end], err.Error())
+			}
+			return re, end, nil
+		}
+	}
+	return nil, 0, fmt.Errorf("unterminated regular expression")
+}
+
+func isIdentStart(r rune) bool {
+	return r == '_' || r == '$' || unicode.IsLetter(r)
+}
+
+func isIdentPart(r rune) bool {
+	return isIdentStart(r) || unicode.IsDigit(r)
+}
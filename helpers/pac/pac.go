@@ -0,0 +1,590 @@
+// Package pac evaluates proxy auto-configuration (PAC) files, to pick the proxy nrdiag connects through the way the
+// browsers and the agents configured with the same file would. PAC files are JavaScript, of which only a fixed subset
+// is supported: function declarations, var, assignments, if/else, for loops with break and continue, and return
+// statements, made of string, number, boolean, array and regular expression literals, the operators, the string, array
+// and regular expression methods and the PAC helper functions other than the date and time ones. Parse rejects anything
+// else, such as object literals, switch or while statements and for/in loops, with an error naming the construct
+package pac
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// maxCallDepth - stops a recursive function of a PAC file before it exhausts the stack
+const maxCallDepth = 100
+
+// maxSteps - the statements and expressions an evaluation of a PAC file may run, a loop that never ends fails rather
+// than hang the request picking its proxy. PAC files usually run a few hundred
+const maxSteps = 1000000
+
+// lookupHost and localAddress resolve the hosts of dnsResolve, isResolvable, isInNet and myIpAddress, replaced in the tests
+var (
+	lookupHost   = net.LookupHost
+	localAddress = myIPAddress
+)
+
+// Script - a parsed PAC file
+type Script struct {
+	globals *scope
+}
+
+type function struct {
+	name    string
+	params  []string
+	body    blockStmt
+	closure *scope
+}
+
+type builtin func(args []interface{}) (interface{}, error)
+
+// array - a JavaScript array, shared by the variables it is assigned to
+type array struct {
+	elements []interface{}
+}
+
+// completion - how a statement ended: normally, or with a return, break or continue the enclosing statements handle
+type completion int
+
+const (
+	completionNormal completion = iota
+	completionReturn
+	completionBreak
+	completionContinue
+)
+
+type scope struct {
+	values map[string]interface{}
+	parent *scope
+}
+
+func (s *scope) lookup(name string) (interface{}, bool) {
+	for current := s; current != nil; current = current.parent {
+		if value, ok := current.values[name]; ok {
+			return value, true
+		}
+	}
+	return nil, false
+}
+
+func (s *scope) assign(name string, value interface{}) {
+	for current := s; current != nil; current = current.parent {
+		if _, ok := current.values[name]; ok {
+			current.values[name] = value
+			return
+		}
+	}
+	// an undeclared variable is a global one
+	global := s
+	for global.parent != nil {
+		global = global.parent
+	}
+	global.values[name] = value
+}
+
+// Parse - parses a PAC file and runs its top level statements. It has to declare FindProxyForURL
+func Parse(script string) (*Script, error) {
+	program, err := parse(script)
+	if err != nil {
+		return nil, err
+	}
+	s := &Script{globals: &scope{values: builtins()}}
+	i := &interpreter{}
+	// function declarations are hoisted, they can be called before they are declared
+	for _, statement := range program {
+		if decl, ok := statement.(functionDecl); ok {
+			s.globals.values[decl.name] = function{name: decl.name, params: decl.params, body: decl.body, closure: s.globals}
+		}
+	}
+	for _, statement := range program {
+		if _, _, err := i.exec(statement, s.globals); err != nil {
+			return nil, err
+		}
+	}
+	if _, ok := s.globals.values["FindProxyForURL"].(function); !ok {
+		return nil, errors.New("the PAC file does not declare a FindProxyForURL function")
+	}
+	return s, nil
+}
+
+// FindProxyForURL - calls the FindProxyForURL function of the PAC file, returning its result such as "PROXY host:port; DIRECT"
+func (s *Script) FindProxyForURL(rawURL string, host string) (string, error) {
+	i := &interpreter{}
+	result, err := i.call(s.globals.values["FindProxyForURL"], []interface{}{rawURL, host})
+	if err != nil {
+		return "", err
+	}
+	value, ok := result.(string)
+	if !ok {
+		return "", fmt.Errorf("FindProxyForURL returned %s rather than a string", toString(result))
+	}
+	return value, nil
+}
+
+// ProxyForURL - the proxy the PAC file picks for the URL, nil to connect directly
+func (s *Script) ProxyForURL(target *url.URL) (*url.URL, error) {
+	result, err := s.FindProxyForURL(target.String(), target.Hostname())
+	if err != nil {
+		return nil, err
+	}
+	return ParseResult(result)
+}
+
+// ParseResult - the proxy of the first entry of a FindProxyForURL result, nil for DIRECT. nrdiag doesn't fall back to
+// the next entries when the first proxy is down, as its checks are there to report that
+func ParseResult(result string) (*url.URL, error) {
+	entry := strings.TrimSpace(strings.Split(result, ";")[0])
+	if entry == "" {
+		return nil, nil
+	}
+	fields := strings.Fields(entry)
+	kind := strings.ToUpper(fields[0])
+	if kind == "DIRECT" {
+		return nil, nil
+	}
+	if len(fields) != 2 {
+		return nil, fmt.Errorf("invalid PAC result entry %q", entry)
+	}
+	var scheme string
+	switch kind {
+	case "PROXY", "HTTP":
+		scheme = "http"
+	case "HTTPS":
+		scheme = "https"
+	case "SOCKS", "SOCKS5":
+		scheme = "socks5"
+	default:
+		return nil, fmt.Errorf("unsupported PAC result entry %q", entry)
+	}
+	return url.Parse(scheme + "://" + fields[1])
+}
+
+type interpreter struct {
+	depth int
+	steps int
+}
+
+// step - counts a statement or an expression against maxSteps
+func (i *interpreter) step() error {
+	i.steps++
+	if i.steps > maxSteps {
+		return fmt.Errorf("the PAC file ran more than %d steps, it may loop forever", maxSteps)
+	}
+	return nil
+}
+
+// exec - runs a statement, returning how it ended and the value of a return statement
+func (i *interpreter) exec(statement stmt, s *scope) (completion, interface{}, error) {
+	if err := i.step(); err != nil {
+		return completionNormal, nil, err
+	}
+	switch st := statement.(type) {
+	case blockStmt:
+		return i.execList(st.body, s)
+	case functionDecl:
+		s.values[st.name] = function{name: st.name, params: st.params, body: st.body, closure: s}
+	case ifStmt:
+		condition, err := i.eval(st.condition, s)
+		if err != nil {
+			return completionNormal, nil, err
+		}
+		if truthy(condition) {
+			return i.exec(st.then, s)
+		} else if st.otherwise != nil {
+			return i.exec(st.otherwise, s)
+		}
+	case returnStmt:
+		if st.value == nil {
+			return completionReturn, nil, nil
+		}
+		value, err := i.eval(st.value, s)
+		return completionReturn, value, err
+	case varStmt:
+		for n, name := range st.names {
+			value, err := i.eval(st.values[n], s)
+			if err != nil {
+				return completionNormal, nil, err
+			}
+			s.values[name] = value
+		}
+	case exprStmt:
+		_, err := i.eval(st.value, s)
+		return completionNormal, nil, err
+	case forStmt:
+		if st.init != nil {
+			if _, _, err := i.exec(st.init, s); err != nil {
+				return completionNormal, nil, err
+			}
+		}
+		return i.loop(st.condition, st.body, st.update, s)
+	case breakStmt:
+		return completionBreak, nil, nil
+	case continueStmt:
+		return completionContinue, nil, nil
+	}
+	return completionNormal, nil, nil
+}
+
+// execList - runs statements until one of them returns, breaks or continues
+func (i *interpreter) execList(statements []stmt, s *scope) (completion, interface{}, error) {
+	for _, inner := range statements {
+		if ended, value, err := i.exec(inner, s); ended != completionNormal || err != nil {
+			return ended, value, err
+		}
+	}
+	return completionNormal, nil, nil
+}
+
+// loop - runs the body while the condition holds, a nil condition always does. update runs after each iteration
+func (i *interpreter) loop(condition expr, body stmt, update expr, s *scope) (completion, interface{}, error) {
+	for {
+		if condition != nil {
+			value, err := i.eval(condition, s)
+			if err != nil {
+				return completionNormal, nil, err
+			}
+			if !truthy(value) {
+				return completionNormal, nil, nil
+			}
+		}
+		ended, value, err := i.exec(body, s)
+		if err != nil || ended == completionReturn {
+			return ended, value, err
+		}
+		if ended == completionBreak {
+			return completionNormal, nil, nil
+		}
+		if update != nil {
+			if _, err := i.eval(update, s); err != nil {
+				return completionNormal, nil, err
+			}
+		}
+	}
+}
+
+func (i *interpreter) eval(expression expr, s *scope) (interface{}, error) {
+	if err := i.step(); err != nil {
+		return nil, err
+	}
+	switch e := expression.(type) {
+	case literalExpr:
+		return e.value, nil
+	case identExpr:
+		value, ok := s.lookup(e.name)
+		if !ok {
+			return nil, fmt.Errorf("%s is not defined", e.name)
+		}
+		return value, nil
+	case memberExpr:
+		object, err := i.eval(e.object, s)
+		if err != nil {
+			return nil, err
+		}
+		if e.name == "length" {
+			switch o := object.(type) {
+			case string:
+				return float64(len(o)), nil
+			case *array:
+				return float64(len(o.elements)), nil
+			}
+		}
+		return nil, fmt.Errorf("unsupported property %s of %s", e.name, toString(object))
+	case indexExpr:
+		object, index, err := i.evalIndex(e, s)
+		if err != nil {
+			return nil, err
+		}
+		switch o := object.(type) {
+		case *array:
+			if index >= 0 && index < len(o.elements) {
+				return o.elements[index], nil
+			}
+		case string:
+			if index >= 0 && index < len(o) {
+				return o[index : index+1], nil
+			}
+		}
+		return nil, nil
+	case arrayExpr:
+		elements := make([]interface{}, 0, len(e.elements))
+		for _, element := range e.elements {
+			value, err := i.eval(element, s)
+			if err != nil {
+				return nil, err
+			}
+			elements = append(elements, value)
+		}
+		return &array{elements: elements}, nil
+	case assignExpr:
+		return i.evalAssign(e, s)
+	case updateExpr:
+		old, err := i.eval(e.target, s)
+		if err != nil {
+			return nil, err
+		}
+		updated := toNumber(old) + 1
+		if e.op == "--" {
+			updated = toNumber(old) - 1
+		}
+		if err := i.store(e.target, updated, s); err != nil {
+			return nil, err
+		}
+		if e.prefix {
+			return updated, nil
+		}
+		return toNumber(old), nil
+	case callExpr:
+		return i.evalCall(e, s)
+	case unaryExpr:
+		if e.op == "typeof" {
+			// typeof is the one way to use an undeclared variable
+			if ident, ok := e.operand.(identExpr); ok {
+				if _, declared := s.lookup(ident.name); !declared {
+					return "undefined", nil
+				}
+			}
+		}
+		operand, err := i.eval(e.operand, s)
+		if err != nil {
+			return nil, err
+		}
+		switch e.op {
+		case "!":
+			return !truthy(operand), nil
+		case "typeof":
+			return typeOf(operand), nil
+		}
+		return -toNumber(operand), nil
+	case conditionalExpr:
+		condition, err := i.eval(e.condition, s)
+		if err != nil {
+			return nil, err
+		}
+		if truthy(condition) {
+			return i.eval(e.then, s)
+		}
+		return i.eval(e.otherwise, s)
+	case binaryExpr:
+		return i.evalBinary(e, s)
+	}
+	return nil, fmt.Errorf("unsupported expression %T", expression)
+}
+
+// evalIndex - the array or string and the index of an element, -1 for an index that is not a whole number
+func (i *interpreter) evalIndex(e indexExpr, s *scope) (interface{}, int, error) {
+	object, err := i.eval(e.object, s)
+	if err != nil {
+		return nil, 0, err
+	}
+	value, err := i.eval(e.index, s)
+	if err != nil {
+		return nil, 0, err
+	}
+	index := toNumber(value)
+	if math.IsNaN(index) || index != math.Trunc(index) || index > math.MaxInt32 {
+		return object, -1, nil
+	}
+	return object, int(index), nil
+}
+
+func (i *interpreter) evalAssign(e assignExpr, s *scope) (interface{}, error) {
+	value, err := i.eval(e.value, s)
+	if err != nil {
+		return nil, err
+	}
+	if e.op != "=" {
+		current, err := i.eval(e.target, s)
+		if err != nil {
+			return nil, err
+		}
+		// a compound assignment is the binary operator followed by the assignment, e.g. a += b is a = a + b
+		if value, err = binaryOperation(strings.TrimSuffix(e.op, "="), current, value); err != nil {
+			return nil, err
+		}
+	}
+	return value, i.store(e.target, value, s)
+}
+
+// store - assigns a value to a variable or an array element, growing the array when the index is past its end
+func (i *interpreter) store(target expr, value interface{}, s *scope) error {
+	switch t := target.(type) {
+	case identExpr:
+		s.assign(t.name, value)
+		return nil
+	case indexExpr:
+		object, index, err := i.evalIndex(t, s)
+		if err != nil {
+			return err
+		}
+		arr, ok := object.(*array)
+		if !ok || index < 0 {
+			return fmt.Errorf("unsupported assignment to an element of %s", toString(object))
+		}
+		if index > maxSteps {
+			return fmt.Errorf("unsupported array index %d", index)
+		}
+		for len(arr.elements) <= index {
+			arr.elements = append(arr.elements, nil)
+		}
+		arr.elements[index] = value
+		return nil
+	}
+	return fmt.Errorf("invalid assignment target")
+}
+
+func (i *interpreter) evalBinary(e binaryExpr, s *scope) (interface{}, error) {
+	left, err := i.eval(e.left, s)
+	if err != nil {
+		return nil, err
+	}
+	// the logical operators short-circuit and return one of their operands
+	switch e.op {
+	case "||":
+		if truthy(left) {
+			return left, nil
+		}
+		return i.eval(e.right, s)
+	case "&&":
+		if !truthy(left) {
+			return left, nil
+		}
+		return i.eval(e.right, s)
+	}
+	right, err := i.eval(e.right, s)
+	if err != nil {
+		return nil, err
+	}
+	return binaryOperation(e.op, left, right)
+}
+
+// binaryOperation - applies an operator other than the logical ones to its evaluated operands
+func binaryOperation(op string, left, right interface{}) (interface{}, error) {
+	switch op {
+	case "==":
+		return looseEquals(left, right), nil
+	case "!=":
+		return !looseEquals(left, right), nil
+	case "===":
+		return strictEquals(left, right), nil
+	case "!==":
+		return !strictEquals(left, right), nil
+	case "+":
+		leftString, leftIsString := left.(string)
+		rightString, rightIsString := right.(string)
+		if leftIsString || rightIsString {
+			if !leftIsString {
+				leftString = toString(left)
+			}
+			if !rightIsString {
+				rightString = toString(right)
+			}
+			return leftString + rightString, nil
+		}
+		return toNumber(left) + toNumber(right), nil
+	case "-":
+		return toNumber(left) - toNumber(right), nil
+	case "*":
+		return toNumber(left) * toNumber(right), nil
+	case "/":
+		return toNumber(left) / toNumber(right), nil
+	case "%":
+		return math.Mod(toNumber(left), toNumber(right)), nil
+	}
+	// relational operators compare strings with each other, anything else as numbers
+	leftString, leftIsString := left.(string)
+	rightString, rightIsString := right.(string)
+	if leftIsString && rightIsString {
+		return compare(op, float64(strings.Compare(leftString, rightString)), 0), nil
+	}
+	leftNumber, rightNumber := toNumber(left), toNumber(right)
+	if math.IsNaN(leftNumber) || math.IsNaN(rightNumber) {
+		return false, nil
+	}
+	return compare(op, leftNumber, rightNumber), nil
+}
+
+func compare(op string, l, r float64) bool {
+	switch op {
+	case "<":
+		return l < r
+	case ">":
+		return l > r
+	case "<=":
+		return l <= r
+	}
+	return l >= r
+}
+
+func (i *interpreter) evalCall(e callExpr, s *scope) (interface{}, error) {
+	var args []interface{}
+	evalArgs := func() error {
+		for _, argument := range e.args {
+			value, err := i.eval(argument, s)
+			if err != nil {
+				return err
+			}
+			args = append(args, value)
+		}
+		return nil
+	}
+
+	if member, ok := e.callee.(memberExpr); ok {
+		object, err := i.eval(member.object, s)
+		if err != nil {
+			return nil, err
+		}
+		if err := evalArgs(); err != nil {
+			return nil, err
+		}
+		switch o := object.(type) {
+		case string:
+			return stringMethod(o, member.name, args)
+		case *array:
+			return arrayMethod(o, member.name, args)
+		case *regexp.Regexp:
+			return regexMethod(o, member.name, args)
+		}
+		return nil, fmt.Errorf("unsupported method %s of %s", member.name, toString(object))
+	}
+
+	callee, err := i.eval(e.callee, s)
+	if err != nil {
+		return nil, err
+	}
+	if err := evalArgs(); err != nil {
+		return nil, err
+	}
+	return i.call(callee, args)
+}
+
+func (i *interpreter) call(callee interface{}, args []interface{}) (interface{}, error) {
+	switch fn := callee.(type) {
+	case builtin:
+		return fn(args)
+	case function:
+		if i.depth >= maxCallDepth {
+			return nil, fmt.Errorf("%s recursed more than %d times", fn.name, maxCallDepth)
+		}
+		i.depth++
+		defer func() { i.depth-- }()
+		local := &scope{values: map[string]interface{}{}, parent: fn.closure}
+		for n, param := range fn.params {
+			var value interface{}
+			if n < len(args) {
+				value = args[n]
+			}
+			local.values[param] = value
+		}
+		ended, value, err := i.exec(fn.body, local)
+		if ended != completionReturn {
+			value = nil
+		}
+		return value, err
+	}
+	return nil, fmt.Errorf("%s is not a function", toString(callee))
+}
@@ -0,0 +1,304 @@
+package pac
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+const corporatePAC = `
+// proxies the public hosts, except New Relic EU, through the corporate proxy
+function FindProxyForURL(url, host) {
+	host = host.toLowerCase();
+	if (isPlainHostName(host) || dnsDomainIs(host, ".corp.example.com")) {
+		return "DIRECT";
+	}
+	if (isInNet(dnsResolve(host), "10.0.0.0", "255.0.0.0")) {
+		return "DIRECT";
+	}
+	/* the EU datacenter is reached through its own proxy */
+	if (shExpMatch(host, "*.eu01.nr-data.net") || host == "collector.eu.newrelic.com")
+		return "PROXY eu-proxy.example.com:3128; DIRECT";
+	var scheme = url.substring(0, url.indexOf(":"));
+	return scheme === "https" ? proxyFor(host) : "SOCKS5 socks.example.com:1080";
+}
+
+function proxyFor(host) {
+	return "PROXY " + defaultProxy;
+}
+
+var defaultProxy = "proxy.example.com:8080";
+`
+
+func stubLookupHost(t *testing.T, hosts map[string]string) {
+	original := lookupHost
+	lookupHost = func(host string) ([]string, error) {
+		if address, ok := hosts[host]; ok {
+			return []string{address}, nil
+		}
+		return nil, errors.New("no such host")
+	}
+	t.Cleanup(func() { lookupHost = original })
+}
+
+func TestScript_FindProxyForURL(t *testing.T) {
+	stubLookupHost(t, map[string]string{
+		"collector.newrelic.com": "162.247.241.2",
+		"internal.example.com":   "10.1.2.3",
+	})
+	script, err := Parse(corporatePAC)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	tests := []struct {
+		url  string
+		host string
+		want string
+	}{
+		{url: "https://collector.newrelic.com/status/mongrel", host: "collector.newrelic.com", want: "PROXY proxy.example.com:8080"},
+		{url: "http://collector.newrelic.com/status/mongrel", host: "collector.newrelic.com", want: "SOCKS5 socks.example.com:1080"},
+		{url: "https://Collector.EU.newrelic.com/", host: "Collector.EU.newrelic.com", want: "PROXY eu-proxy.example.com:3128; DIRECT"},
+		{url: "https://bam.eu01.nr-data.net/1/", host: "bam.eu01.nr-data.net", want: "PROXY eu-proxy.example.com:3128; DIRECT"},
+		{url: "https://wiki.corp.example.com/", host: "wiki.corp.example.com", want: "DIRECT"},
+		{url: "http://intranet/", host: "intranet", want: "DIRECT"},
+		{url: "https://internal.example.com/", host: "internal.example.com", want: "DIRECT"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			got, err := script.FindProxyForURL(tt.url, tt.host)
+			if err != nil {
+				t.Fatalf("FindProxyForURL() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("FindProxyForURL() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScript_ProxyForURL(t *testing.T) {
+	script, err := Parse(`function FindProxyForURL(url, host) { return host == "direct.example.com" ? "DIRECT" : "PROXY proxy.example.com:8080"; }`)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	target, _ := url.Parse("https://collector.newrelic.com/status/mongrel")
+	proxyURL, err := script.ProxyForURL(target)
+	if err != nil || proxyURL == nil || proxyURL.String() != "http://proxy.example.com:8080" {
+		t.Errorf("ProxyForURL() = %v, %v, want http://proxy.example.com:8080", proxyURL, err)
+	}
+	target, _ = url.Parse("https://direct.example.com/")
+	if proxyURL, err = script.ProxyForURL(target); err != nil || proxyURL != nil {
+		t.Errorf("ProxyForURL() = %v, %v, want a direct connection", proxyURL, err)
+	}
+}
+
+func TestParseResult(t *testing.T) {
+	tests := []struct {
+		result  string
+		want    string
+		wantErr bool
+	}{
+		{result: "DIRECT", want: ""},
+		{result: "", want: ""},
+		{result: "PROXY proxy.example.com:8080; DIRECT", want: "http://proxy.example.com:8080"},
+		{result: "HTTPS secure.example.com:443", want: "https://secure.example.com:443"},
+		{result: " socks 127.0.0.1:1080 ", want: "socks5://127.0.0.1:1080"},
+		{result: "PROXY", wantErr: true},
+		{result: "QUIC example.com:443", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.result, func(t *testing.T) {
+			got, err := ParseResult(tt.result)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseResult() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			gotString := ""
+			if got != nil {
+				gotString = got.String()
+			}
+			if gotString != tt.want {
+				t.Errorf("ParseResult() = %q, want %q", gotString, tt.want)
+			}
+		})
+	}
+}
+
+func TestParse_errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		script  string
+		wantErr string
+	}{
+		{name: "no FindProxyForURL", script: `function findProxy(url, host) { return "DIRECT"; }`, wantErr: "does not declare a FindProxyForURL"},
+		{name: "syntax error", script: "function FindProxyForURL(url, host) {\n\treturn \"DIRECT\";", wantErr: "line 2: expected '}'"},
+		{name: "unterminated string", script: `function FindProxyForURL(url, host) { return "DIRECT; }`, wantErr: "unterminated string"},
+		{name: "try", script: `function FindProxyForURL(url, host) { try { return "DIRECT"; } catch (e) {} }`, wantErr: "line 1: try statements are not supported"},
+		{name: "object literal", script: "var m = {\"newrelic.com\": \"PROXY o:1\"};\nfunction FindProxyForURL(url, host) { for (var k in m) return m[k]; }", wantErr: "line 1: object literals are not supported"},
+		{name: "for in", script: "var hosts = [\"a\"];\nfunction FindProxyForURL(url, host) {\n\tfor (var k in hosts) return \"DIRECT\";\n}", wantErr: "line 3: for/in loops are not supported"},
+		{name: "switch", script: `function FindProxyForURL(url, host) { switch (host) { default: return "DIRECT"; } }`, wantErr: "switch statements are not supported"},
+		{name: "while", script: `function FindProxyForURL(url, host) { while (true) {} }`, wantErr: "while statements are not supported"},
+		{name: "do while", script: `function FindProxyForURL(url, host) { do {} while (false); return "DIRECT"; }`, wantErr: "do statements are not supported"},
+		{name: "function expression", script: `var pick = function() { return "DIRECT"; }; function FindProxyForURL(url, host) { return pick(); }`, wantErr: "function expressions are not supported"},
+		{name: "new", script: `function FindProxyForURL(url, host) { var d = new Date(); return "DIRECT"; }`, wantErr: "'new' expressions are not supported"},
+		{name: "backreference", script: `function FindProxyForURL(url, host) { return /(a)\1/.test(host) ? "DIRECT" : "PROXY p:1"; }`, wantErr: "unsupported regular expression"},
+		{name: "assignment to a call", script: `function FindProxyForURL(url, host) { host.toLowerCase() = "a"; }`, wantErr: "invalid assignment target"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.script)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Parse() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestScript_FindProxyForURL_errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		script  string
+		wantErr string
+	}{
+		{name: "time range", script: `function FindProxyForURL(url, host) { if (timeRange(8, 18)) return "DIRECT"; return "PROXY p:1"; }`, wantErr: "date and time"},
+		{name: "undefined", script: `function FindProxyForURL(url, host) { return proxy; }`, wantErr: "proxy is not defined"},
+		{name: "recursion", script: `function FindProxyForURL(url, host) { return FindProxyForURL(url, host); }`, wantErr: "recursed"},
+		{name: "endless loop", script: `function FindProxyForURL(url, host) { for (;;) {} }`, wantErr: "may loop forever"},
+		{name: "endless for loop", script: `function FindProxyForURL(url, host) { for (var i = 0; ; i++) { continue; } }`, wantErr: "may loop forever"},
+		{name: "not a string", script: `function FindProxyForURL(url, host) { return 1; }`, wantErr: "rather than a string"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			script, err := Parse(tt.script)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			_, err = script.FindProxyForURL("https://collector.newrelic.com/", "collector.newrelic.com")
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("FindProxyForURL() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// listsPAC - arrays of hosts and networks walked with for loops and regular expressions, as the PAC files generated by
+// proxy appliances are written
+const listsPAC = `
+const directHosts = ["localhost", ".corp.example.com", ".internal", ];
+var directNets = [["10.0.0.0", "255.0.0.0"], ["192.168.0.0", "255.255.0.0"]];
+let weights = [];
+for (var n = 0; n < 3; n++) weights.push(n * 2);
+
+function FindProxyForURL(url, host) {
+	for (var i = 0; i < directHosts.length; i++) {
+		if (dnsDomainIs(host, directHosts[i])) return "DIRECT";
+	}
+	var address = dnsResolve(host);
+	for (var k = 0; k < directNets.length; k++) {
+		if (!address) break;
+		if (isInNet(address, directNets[k][0], directNets[k][1])) {
+			return "DIRECT";
+		}
+	}
+	if (/^(bam|js-agent)\.(eu01\.)?nr-data\.net$/i.test(host)) {
+		return "PROXY browser-proxy.example.com:" + (weights[2] * 1000 + 80);
+	}
+	var scheme = url.match(/^([a-z]+):/)[1];
+	if (scheme == "ftp") {
+		return "DIRECT";
+	}
+	var proxy = scheme == "http" || scheme == "https" ? "PROXY proxy.example.com:8080" : "SOCKS5 socks.example.com:1080";
+	var tries = 0;
+	for (; tries < 3; tries += 1) {
+		if (isResolvable(host)) break;
+	}
+	return proxy + (tries == 3 ? "; DIRECT" : "");
+}
+`
+
+func TestScript_FindProxyForURL_lists(t *testing.T) {
+	stubLookupHost(t, map[string]string{
+		"collector.newrelic.com": "162.247.241.2",
+		"nas.example.com":        "192.168.1.5",
+	})
+	script, err := Parse(listsPAC)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	tests := []struct {
+		url  string
+		host string
+		want string
+	}{
+		{url: "https://wiki.corp.example.com/", host: "wiki.corp.example.com", want: "DIRECT"},
+		{url: "https://nas.example.com/", host: "nas.example.com", want: "DIRECT"},
+		{url: "https://BAM.eu01.nr-data.net/1/", host: "BAM.eu01.nr-data.net", want: "PROXY browser-proxy.example.com:4080"},
+		{url: "https://collector.newrelic.com/", host: "collector.newrelic.com", want: "PROXY proxy.example.com:8080"},
+		{url: "ws://collector.newrelic.com/", host: "collector.newrelic.com", want: "SOCKS5 socks.example.com:1080"},
+		{url: "ftp://files.example.org/", host: "files.example.org", want: "DIRECT"},
+		{url: "https://unknown.example.org/", host: "unknown.example.org", want: "PROXY proxy.example.com:8080; DIRECT"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			got, err := script.FindProxyForURL(tt.url, tt.host)
+			if err != nil {
+				t.Fatalf("FindProxyForURL() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("FindProxyForURL() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_builtins(t *testing.T) {
+	stubLookupHost(t, map[string]string{"collector.newrelic.com": "162.247.241.2"})
+	original := localAddress
+	localAddress = func() string { return "192.168.1.20" }
+	defer func() { localAddress = original }()
+
+	tests := []struct {
+		expression string
+		want       interface{}
+	}{
+		{expression: `isPlainHostName("www")`, want: true},
+		{expression: `isPlainHostName("www.example.com")`, want: false},
+		{expression: `localHostOrDomainIs("www", "www.example.com")`, want: true},
+		{expression: `localHostOrDomainIs("www.example.org", "www.example.com")`, want: false},
+		{expression: `dnsDomainLevels("www.example.com")`, want: float64(2)},
+		{expression: `shExpMatch("http://example.com/a?b", "*/a?b")`, want: true},
+		{expression: `shExpMatch("example.com", "*.example.com")`, want: false},
+		{expression: `isResolvable("collector.newrelic.com")`, want: true},
+		{expression: `isResolvable("unknown.example.com")`, want: false},
+		{expression: `dnsResolve("unknown.example.com")`, want: nil},
+		{expression: `isInNet(myIpAddress(), "192.168.0.0", "255.255.0.0")`, want: true},
+		{expression: `isInNet("collector.newrelic.com", "10.0.0.0", "255.0.0.0")`, want: false},
+		{expression: `convert_addr("10.0.0.1")`, want: float64(167772161)},
+		{expression: `"example.com".length > 5 && !("a" != "a")`, want: true},
+		{expression: `"1" == 1 && "1" !== 1 && null == undefined`, want: true},
+		{expression: `"" || "fallback"`, want: "fallback"},
+		{expression: `"Example".toUpperCase().charAt(1) + -1`, want: "X-1"},
+		{expression: `7 * 6 / 2 % 4 + 1`, want: float64(2)},
+		{expression: `["a", "b", "c"].join("-") + ["x"].indexOf("x") + [1, 2].length`, want: "a-b-c02"},
+		{expression: `"a.b.c".split(".")[1] + "host"[0]`, want: "bh"},
+		{expression: `/^collector\.(eu\.)?newrelic\.com$/.test("collector.eu.newrelic.com")`, want: true},
+		{expression: `"www.Example.com".replace(/example/i, "x") + "a".search(/b/)`, want: "www.x.com-1"},
+		{expression: `typeof missing + typeof "" + typeof 1`, want: "undefinedstringnumber"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			script, err := Parse("var result = " + tt.expression + ";\nfunction FindProxyForURL(url, host) { return \"DIRECT\"; }")
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if got := script.globals.values["result"]; got != tt.want {
+				t.Errorf("%s = %v (%T), want %v (%T)", tt.expression, got, got, tt.want, tt.want)
+			}
+		})
+	}
+}
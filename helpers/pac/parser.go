@@ -0,0 +1,560 @@
+package pac
+
+import (
+	"fmt"
+)
+
+// The syntax tree of the JavaScript subset PAC files are written in: function declarations, var, assignments, if/else,
+// for loops and return statements, and expressions made of literals, arrays, regular expressions, variables, calls,
+// string, array and regular expression methods and the usual operators. The parser rejects the rest of JavaScript with
+// an error naming the construct, see unsupported
+
+type expr interface{}
+
+type (
+	literalExpr struct{ value interface{} }
+	identExpr   struct{ name string }
+	callExpr    struct {
+		callee expr
+		args   []expr
+	}
+	memberExpr struct {
+		object expr
+		name   string
+	}
+	indexExpr struct{ object, index expr }
+	arrayExpr struct{ elements []expr }
+	// assignExpr assigns to a variable or an array element, op is =, += or another compound assignment
+	assignExpr struct {
+		op            string
+		target, value expr
+	}
+	// updateExpr is ++ or -- on a variable or an array element, prefix when it returns the updated value
+	updateExpr struct {
+		op     string
+		prefix bool
+		target expr
+	}
+	unaryExpr struct {
+		op      string
+		operand expr
+	}
+	binaryExpr struct {
+		op          string
+		left, right expr
+	}
+	conditionalExpr struct{ condition, then, otherwise expr }
+)
+
+type stmt interface{}
+
+type (
+	blockStmt struct{ body []stmt }
+	ifStmt    struct {
+		condition expr
+		then      stmt
+		otherwise stmt
+	}
+	returnStmt struct{ value expr }
+	varStmt    struct {
+		names  []string
+		values []expr
+	}
+	exprStmt struct{ value expr }
+	// forStmt is a for loop, init, condition and update may be nil
+	forStmt struct {
+		init      stmt
+		condition expr
+		update    expr
+		body      stmt
+	}
+	breakStmt    struct{}
+	continueStmt struct{}
+	functionDecl struct {
+		name   string
+		params []string
+		body   blockStmt
+	}
+)
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+// parse - the statements of a PAC file
+func parse(script string) ([]stmt, error) {
+	tokens, err := tokenize(script)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	var program []stmt
+	for p.peek().kind != tokenEOF {
+		statement, err := p.statement()
+		if err != nil {
+			return nil, err
+		}
+		program = append(program, statement)
+	}
+	return program, nil
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+// is - whether the next token is the given punctuator or keyword
+func (p *parser) is(text string) bool {
+	t := p.peek()
+	return (t.kind == tokenPunct || t.kind == tokenIdent) && t.text == text
+}
+
+func (p *parser) accept(text string) bool {
+	if p.is(text) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) expect(text string) error {
+	if !p.accept(text) {
+		return p.errorf("expected '%s'", text)
+	}
+	return nil
+}
+
+// unsupported - the error of a JavaScript construct outside the subset of the package documentation, at the next token
+func (p *parser) unsupported(construct string) error {
+	return fmt.Errorf("line %d: %s are not supported in PAC files by nrdiag", p.peek().line, construct)
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	t := p.peek()
+	found := t.text
+	if t.kind == tokenEOF {
+		found = "end of file"
+	}
+	return fmt.Errorf("line %d: %s, found '%s'", t.line, fmt.Sprintf(format, args...), found)
+}
+
+func (p *parser) identifier() (string, error) {
+	t := p.peek()
+	if t.kind != tokenIdent {
+		return "", p.errorf("expected a name")
+	}
+	p.pos++
+	return t.text, nil
+}
+
+func (p *parser) statement() (stmt, error) {
+	switch {
+	case p.accept(";"):
+		return blockStmt{}, nil
+	case p.is("{"):
+		return p.block()
+	case p.accept("function"):
+		return p.function()
+	case p.accept("if"):
+		return p.ifStatement()
+	case p.accept("return"):
+		var value expr
+		if !p.is(";") && !p.is("}") {
+			var err error
+			if value, err = p.expression(); err != nil {
+				return nil, err
+			}
+		}
+		p.accept(";")
+		return returnStmt{value: value}, nil
+	case p.accept("var"), p.accept("let"), p.accept("const"):
+		statement, err := p.varStatement()
+		p.accept(";")
+		return statement, err
+	case p.accept("for"):
+		return p.forStatement()
+	case p.accept("break"):
+		p.accept(";")
+		return breakStmt{}, nil
+	case p.accept("continue"):
+		p.accept(";")
+		return continueStmt{}, nil
+	case p.is("while"), p.is("do"), p.is("switch"), p.is("try"), p.is("throw"), p.is("with"):
+		return nil, p.unsupported(p.peek().text + " statements")
+	}
+
+	// an expression such as an assignment or a call to alert()
+	value, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+	p.accept(";")
+	return exprStmt{value: value}, nil
+}
+
+func (p *parser) block() (blockStmt, error) {
+	if err := p.expect("{"); err != nil {
+		return blockStmt{}, err
+	}
+	var body []stmt
+	for !p.accept("}") {
+		if p.peek().kind == tokenEOF {
+			return blockStmt{}, p.errorf("expected '}'")
+		}
+		statement, err := p.statement()
+		if err != nil {
+			return blockStmt{}, err
+		}
+		body = append(body, statement)
+	}
+	return blockStmt{body: body}, nil
+}
+
+func (p *parser) function() (stmt, error) {
+	name, err := p.identifier()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+	var params []string
+	for !p.accept(")") {
+		if len(params) > 0 {
+			if err := p.expect(","); err != nil {
+				return nil, err
+			}
+		}
+		param, err := p.identifier()
+		if err != nil {
+			return nil, err
+		}
+		params = append(params, param)
+	}
+	body, err := p.block()
+	if err != nil {
+		return nil, err
+	}
+	return functionDecl{name: name, params: params, body: body}, nil
+}
+
+func (p *parser) ifStatement() (stmt, error) {
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+	condition, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect(")"); err != nil {
+		return nil, err
+	}
+	then, err := p.statement()
+	if err != nil {
+		return nil, err
+	}
+	statement := ifStmt{condition: condition, then: then}
+	if p.accept("else") {
+		if statement.otherwise, err = p.statement(); err != nil {
+			return nil, err
+		}
+	}
+	return statement, nil
+}
+
+func (p *parser) varStatement() (stmt, error) {
+	var statement varStmt
+	for {
+		name, err := p.identifier()
+		if err != nil {
+			return nil, err
+		}
+		var value expr = literalExpr{}
+		if p.accept("=") {
+			if value, err = p.expression(); err != nil {
+				return nil, err
+			}
+		}
+		statement.names = append(statement.names, name)
+		statement.values = append(statement.values, value)
+		if !p.accept(",") {
+			break
+		}
+	}
+	return statement, nil
+}
+
+func (p *parser) forStatement() (stmt, error) {
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+	// for (var name in object) and for (name in object)
+	ahead := p.pos
+	if p.is("var") || p.is("let") || p.is("const") {
+		ahead++
+	}
+	if p.tokens[ahead].kind == tokenIdent && p.tokens[ahead+1].kind == tokenIdent && p.tokens[ahead+1].text == "in" {
+		return nil, p.unsupported("for/in loops")
+	}
+
+	var statement forStmt
+	var err error
+	switch {
+	case p.is(";"):
+	case p.accept("var"), p.accept("let"), p.accept("const"):
+		if statement.init, err = p.varStatement(); err != nil {
+			return nil, err
+		}
+	default:
+		value, err := p.expression()
+		if err != nil {
+			return nil, err
+		}
+		statement.init = exprStmt{value: value}
+	}
+	if err := p.expect(";"); err != nil {
+		return nil, err
+	}
+	if !p.is(";") {
+		if statement.condition, err = p.expression(); err != nil {
+			return nil, err
+		}
+	}
+	if err := p.expect(";"); err != nil {
+		return nil, err
+	}
+	if !p.is(")") {
+		if statement.update, err = p.expression(); err != nil {
+			return nil, err
+		}
+	}
+	if err := p.expect(")"); err != nil {
+		return nil, err
+	}
+	if statement.body, err = p.statement(); err != nil {
+		return nil, err
+	}
+	return statement, nil
+}
+
+// binaryPrecedence - the operators of each precedence level, loosest first
+var binaryPrecedence = [][]string{
+	{"||"},
+	{"&&"},
+	{"==", "!=", "===", "!=="},
+	{"<", ">", "<=", ">="},
+	{"+", "-"},
+	{"*", "/", "%"},
+}
+
+// assignmentOperators - the operators of assignExpr
+var assignmentOperators = []string{"=", "+=", "-=", "*=", "/=", "%="}
+
+func (p *parser) expression() (expr, error) {
+	target, err := p.conditional()
+	if err != nil {
+		return nil, err
+	}
+	for _, op := range assignmentOperators {
+		if p.peek().kind == tokenPunct && p.peek().text == op {
+			if !isAssignable(target) {
+				return nil, p.errorf("invalid assignment target")
+			}
+			p.next()
+			value, err := p.expression()
+			if err != nil {
+				return nil, err
+			}
+			return assignExpr{op: op, target: target, value: value}, nil
+		}
+	}
+	return target, nil
+}
+
+// isAssignable - whether an expression is a variable or an array element, which assignExpr and updateExpr change
+func isAssignable(target expr) bool {
+	switch target.(type) {
+	case identExpr, indexExpr:
+		return true
+	}
+	return false
+}
+
+func (p *parser) conditional() (expr, error) {
+	condition, err := p.binary(0)
+	if err != nil || !p.accept("?") {
+		return condition, err
+	}
+	then, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect(":"); err != nil {
+		return nil, err
+	}
+	otherwise, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+	return conditionalExpr{condition: condition, then: then, otherwise: otherwise}, nil
+}
+
+func (p *parser) binary(level int) (expr, error) {
+	if level == len(binaryPrecedence) {
+		return p.unary()
+	}
+	left, err := p.binary(level + 1)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op := ""
+		for _, candidate := range binaryPrecedence[level] {
+			if p.peek().kind == tokenPunct && p.peek().text == candidate {
+				op = candidate
+			}
+		}
+		if op == "" {
+			return left, nil
+		}
+		p.next()
+		right, err := p.binary(level + 1)
+		if err != nil {
+			return nil, err
+		}
+		left = binaryExpr{op: op, left: left, right: right}
+	}
+}
+
+func (p *parser) unary() (expr, error) {
+	if p.is("++") || p.is("--") {
+		op := p.next().text
+		target, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		if !isAssignable(target) {
+			return nil, p.errorf("invalid %s target", op)
+		}
+		return updateExpr{op: op, prefix: true, target: target}, nil
+	}
+	if p.is("!") || p.is("-") || p.is("typeof") {
+		op := p.next().text
+		operand, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return unaryExpr{op: op, operand: operand}, nil
+	}
+	return p.postfix()
+}
+
+func (p *parser) postfix() (expr, error) {
+	value, err := p.primary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		switch {
+		case p.accept("."):
+			name, err := p.identifier()
+			if err != nil {
+				return nil, err
+			}
+			value = memberExpr{object: value, name: name}
+		case p.accept("["):
+			index, err := p.expression()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect("]"); err != nil {
+				return nil, err
+			}
+			value = indexExpr{object: value, index: index}
+		case (p.is("++") || p.is("--")) && isAssignable(value):
+			value = updateExpr{op: p.next().text, target: value}
+		case p.accept("("):
+			var args []expr
+			for !p.accept(")") {
+				if len(args) > 0 {
+					if err := p.expect(","); err != nil {
+						return nil, err
+					}
+				}
+				arg, err := p.expression()
+				if err != nil {
+					return nil, err
+				}
+				args = append(args, arg)
+			}
+			value = callExpr{callee: value, args: args}
+		default:
+			return value, nil
+		}
+	}
+}
+
+func (p *parser) primary() (expr, error) {
+	switch {
+	case p.is("{"):
+		return nil, p.unsupported("object literals")
+	case p.is("function"):
+		return nil, p.unsupported("function expressions")
+	case p.is("new"), p.is("this"), p.is("delete"), p.is("void"):
+		return nil, p.unsupported("'" + p.peek().text + "' expressions")
+	}
+	t := p.peek()
+	switch t.kind {
+	case tokenString, tokenNumber, tokenRegex:
+		p.next()
+		return literalExpr{value: t.value}, nil
+	case tokenIdent:
+		p.next()
+		switch t.text {
+		case "true":
+			return literalExpr{value: true}, nil
+		case "false":
+			return literalExpr{value: false}, nil
+		case "null", "undefined":
+			return literalExpr{}, nil
+		}
+		return identExpr{name: t.text}, nil
+	}
+	if p.accept("(") {
+		value, err := p.expression()
+		if err != nil {
+			return nil, err
+		}
+		return value, p.expect(")")
+	}
+	if p.accept("[") {
+		var array arrayExpr
+		for !p.accept("]") {
+			if len(array.elements) > 0 {
+				if err := p.expect(","); err != nil {
+					return nil, err
+				}
+				// a trailing comma
+				if p.accept("]") {
+					break
+				}
+			}
+			element, err := p.expression()
+			if err != nil {
+				return nil, err
+			}
+			array.elements = append(array.elements, element)
+		}
+		return array, nil
+	}
+	return nil, p.errorf("unexpected token")
+}
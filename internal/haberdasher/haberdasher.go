@@ -188,6 +188,11 @@ func (c *Client) SetUserAgent(userAgent string) {
 	c.UserAgent = userAgent
 }
 
+// SetHTTPClient sets the http.Client a client sends its requests with
+func (c *Client) SetHTTPClient(httpClient *http.Client) {
+	c.httpClient = httpClient
+}
+
 // generateInsertKey takes a string, and returns a unique deterministic hash
 // it generates a SHA512 digest based on every other char of the input, reversed
 func generateInsertKey(runID string) string {
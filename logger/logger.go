@@ -1,8 +1,13 @@
 package logger
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
 	"log"
+	"os"
+	"regexp"
+	"strings"
 	"time"
 
 	"github.com/newrelic/newrelic-diagnostics-cli/config"
@@ -20,13 +25,68 @@ type API interface {
 
 type packageMethods struct{}
 
+// Output - where the messages are printed: stdout, unless -stream or -single reserved it for the results. Whatever else
+// nrdiag shows on the terminal, such as the prompts of the tasks, goes there too
+func Output() io.Writer {
+	if config.Flags.Stream || config.Flags.Single != "" {
+		return os.Stderr
+	}
+	return os.Stdout
+}
+
+// logLine is a message printed with -log-format json
+type logLine struct {
+	Level     string `json:"level"`
+	Timestamp string `json:"timestamp"`
+	Message   string `json:"message"`
+}
+
+// colorCodes matches the ANSI escape sequences of the output/color package, they are noise in structured logs
+var colorCodes = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+
+// enabled - true when -log-level or -v let messages of the given level through
+func enabled(level config.Verbosity) bool {
+	return config.LogLevel >= level
+}
+
+// structured - true when -log-format json asked for one JSON object per message
+func structured() bool {
+	return config.Flags.LogFormat == config.JSONLogFormat
+}
+
+// writeStructured prints a message as a single JSON line. Messages that are only whitespace, like the blank lines spacing
+// out the text output, are dropped
+func writeStructured(level string, message string) {
+	message = strings.TrimSpace(colorCodes.ReplaceAllString(message, ""))
+	if message == "" {
+		return
+	}
+	line, _ := json.Marshal(logLine{Level: level, Timestamp: getTimestamp(), Message: message})
+	fmt.Fprintln(Output(), string(line))
+}
+
+// emit - writes a message to the run log and, when its level is enabled, to the screen. text is the message as printed in
+// text format, message the same without the timestamp and level prefix of debug messages
+func emit(level config.Verbosity, levelName string, text string, message string) {
+	writeRunLog(text)
+	if !enabled(level) {
+		return
+	}
+	if structured() {
+		writeStructured(levelName, message)
+		return
+	}
+	fmt.Fprint(Output(), text)
+}
+
 //Log is a struct that exposes all the public functions of the logger package
 var Log = packageMethods{}
 
 // FixedPrefix is for printing a line with a fixed width prefix followed by other text
 func FixedPrefix(length int, prefix string, text string) {
 	format := fmt.Sprintf("%%-%ds%%s\n", length) //produces something like "%-10s%s\n"
-	fmt.Printf(format, prefix, text)
+	line := fmt.Sprintf(format, prefix, text)
+	emit(config.Info, "info", line, line)
 }
 
 // FixedPrefix - alias via an empty struct to the original implementation
@@ -34,9 +94,10 @@ func (l packageMethods) FixedPrefix(length int, prefix string, text string) {
 	FixedPrefix(length, prefix, text)
 }
 
-//Info is wrapper for Println. No verbosity check -- it always logs.
+//Info is wrapper for Println. Logs unless -log-level is warn or error
 func Info(s ...interface{}) {
-	fmt.Println(s...)
+	message := fmt.Sprintln(s...)
+	emit(config.Info, "info", message, message)
 }
 
 // Info - alias via an empty struct to the original implementation
@@ -44,19 +105,74 @@ func (l packageMethods) Info(s ...interface{}) {
 	Info(s...)
 }
 
-//Infof is wrapper for Printf. No verbosity check -- it always logs.
+//Infof is wrapper for Printf. Logs unless -log-level is warn or error
 func Infof(format string, s ...interface{}) {
-	fmt.Printf(format, s...)
+	message := fmt.Sprintf(format, s...)
+	emit(config.Info, "info", message, message)
+}
+
+//Warn is wrapper for Println. Logs unless -log-level is error
+func Warn(s ...interface{}) {
+	message := fmt.Sprintln(s...)
+	emit(config.Warn, "warn", message, message)
+}
+
+// Warn - alias via an empty struct to the original implementation
+func (l packageMethods) Warn(s ...interface{}) {
+	Warn(s...)
+}
+
+//Warnf is wrapper for Printf. Logs unless -log-level is error
+func Warnf(format string, s ...interface{}) {
+	message := fmt.Sprintf(format, s...)
+	emit(config.Warn, "warn", message, message)
+}
+
+// Warnf - alias via an empty struct to the original implementation
+func (l packageMethods) Warnf(format string, s ...interface{}) {
+	Warnf(format, s...)
+}
+
+//Error is wrapper for Println. No verbosity check -- it always logs.
+func Error(s ...interface{}) {
+	message := fmt.Sprintln(s...)
+	emit(config.Error, "error", message, message)
+}
+
+// Error - alias via an empty struct to the original implementation
+func (l packageMethods) Error(s ...interface{}) {
+	Error(s...)
+}
+
+//Errorf is wrapper for Printf. No verbosity check -- it always logs.
+func Errorf(format string, s ...interface{}) {
+	message := fmt.Sprintf(format, s...)
+	emit(config.Error, "error", message, message)
+}
+
+// Errorf - alias via an empty struct to the original implementation
+func (l packageMethods) Errorf(format string, s ...interface{}) {
+	Errorf(format, s...)
 }
 
 //Fatal is wrapper for log.Fatal(). Prints message followed by a call to os.Exit(1).
 func Fatal(s ...interface{}) {
 	// log.Fatal("Exception occured!") or log.Fatal(err)
+	writeRunLog(fmt.Sprintln(s...))
+	if structured() {
+		writeStructured("fatal", fmt.Sprint(s...))
+		os.Exit(1)
+	}
 	log.Fatal(s...)
 }
 
 //Fatalf is wrapper for log.Fatalf. No verbosity check --it always logs followed by a call to os.Exit(1).
 func Fatalf(format string, s ...interface{}) {
+	writeRunLog(fmt.Sprintf(format, s...) + "\n")
+	if structured() {
+		writeStructured("fatal", fmt.Sprintf(format, s...))
+		os.Exit(1)
+	}
 	log.Fatalf(format, s...)
 }
 
@@ -65,10 +181,11 @@ func (l packageMethods) Infof(format string, s ...interface{}) {
 	Infof(format, s...)
 }
 
-//Dump is wrapper for Printf with a preset formatting to display variable types. No verbosity check -- it always logs.
+//Dump is wrapper for Printf with a preset formatting to display variable types. Logs unless -log-level is warn or error
 func Dump(s ...interface{}) {
 	for _, d := range s {
-		fmt.Printf("%#v", d)
+		message := fmt.Sprintf("%#v", d)
+		emit(config.Info, "info", message, message)
 	}
 }
 
@@ -77,14 +194,13 @@ func (l packageMethods) Dump(s ...interface{}) {
 	Dump(s)
 }
 
-//Debug is wrapper for Println. Only logs if LogLevel is set to Debug verbosity
+//Debug is wrapper for Println. Only printed on screen with -v or -log-level debug, always written to the run log
 func Debug(s ...interface{}) {
-	if config.LogLevel == 1 {
-		// This adds the timestamp and DEBUG statement to the log message
-		// The single line format defines a slice of empty interfaces defined a new interface with the timestamp and [DEBUG] string as the first element,
-		// then expands s into a single interface to add to the slice of interfaces and then finally expands the inline slice to a single interface :)
-		fmt.Println(append([]interface{}{interface{}(getTimestamp() + " [DEBUG]")}, s...)...)
-	}
+	// This adds the timestamp and DEBUG statement to the log message
+	// The single line format defines a slice of empty interfaces defined a new interface with the timestamp and [DEBUG] string as the first element,
+	// then expands s into a single interface to add to the slice of interfaces and then finally expands the inline slice to a single interface :)
+	text := fmt.Sprintln(append([]interface{}{interface{}(getTimestamp() + " [DEBUG]")}, s...)...)
+	emit(config.Verbose, "debug", text, fmt.Sprintln(s...))
 }
 
 //Debug - alias via an empty struct to the original implementation
@@ -92,11 +208,10 @@ func (l packageMethods) Debug(s ...interface{}) {
 	Debug(s...)
 }
 
-//Debugf is wrapper for Printf. Only logs if LogLevel is set to Debug verbosity
+//Debugf is wrapper for Printf. Only printed on screen with -v or -log-level debug, always written to the run log
 func Debugf(format string, s ...interface{}) {
-	if config.LogLevel == 1 {
-		fmt.Printf(getTimestamp()+" [DEBUG] "+format, s...)
-	}
+	message := fmt.Sprintf(format, s...)
+	emit(config.Verbose, "debug", getTimestamp()+" [DEBUG] "+message, message)
 }
 
 //Debugf - alias via an empty struct to the original implementation
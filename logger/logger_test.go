@@ -0,0 +1,114 @@
+package logger
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/config"
+)
+
+// captureOutput returns what log calls printed to stdout
+func captureOutput(t *testing.T, log func()) string {
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = writer
+	log()
+	os.Stdout = stdout
+	writer.Close()
+	printed, _ := ioutil.ReadAll(reader)
+	return string(printed)
+}
+
+func TestStructuredLogs(t *testing.T) {
+	originalFormat, originalLevel := config.Flags.LogFormat, config.LogLevel
+	defer func() { config.Flags.LogFormat, config.LogLevel = originalFormat, originalLevel }()
+	config.Flags.LogFormat = config.JSONLogFormat
+	config.LogLevel = config.Verbose
+
+	printed := captureOutput(t, func() {
+		Info("\nChecking", "the collector")
+		Infof("\x1b[37;1m%d tasks\x1b[0m would run:\n", 3)
+		Info("")
+		Debug("dependency for processing:", "Base/Config/Collect")
+		Debugf("override %s\n", "Status")
+		FixedPrefix(9, "Success", "Base/Env/CollectEnvVars")
+	})
+
+	want := []logLine{
+		{Level: "info", Message: "Checking the collector"},
+		{Level: "info", Message: "3 tasks would run:"},
+		{Level: "debug", Message: "dependency for processing: Base/Config/Collect"},
+		{Level: "debug", Message: "override Status"},
+		{Level: "info", Message: "Success  Base/Env/CollectEnvVars"},
+	}
+	lines := strings.Split(strings.TrimSuffix(printed, "\n"), "\n")
+	if len(lines) != len(want) {
+		t.Fatalf("Expected %d JSON lines, got %d:\n%s", len(want), len(lines), printed)
+	}
+	for i, line := range lines {
+		var got logLine
+		if err := json.Unmarshal([]byte(line), &got); err != nil {
+			t.Fatalf("line %d is not JSON: %s", i, line)
+		}
+		if got.Timestamp == "" {
+			t.Errorf("line %d has no timestamp: %s", i, line)
+		}
+		got.Timestamp = ""
+		if got != want[i] {
+			t.Errorf("line %d = %+v, want %+v", i, got, want[i])
+		}
+	}
+}
+
+func TestTextLogsByDefault(t *testing.T) {
+	originalFormat := config.Flags.LogFormat
+	defer func() { config.Flags.LogFormat = originalFormat }()
+	config.Flags.LogFormat = config.TextLogFormat
+
+	printed := captureOutput(t, func() {
+		Info("Checking", "the collector")
+		Infof("%d tasks\n", 3)
+	})
+
+	if printed != "Checking the collector\n3 tasks\n" {
+		t.Errorf("Expected unchanged text output, got %q", printed)
+	}
+}
+
+func TestLogLevels(t *testing.T) {
+	originalFormat, originalLevel := config.Flags.LogFormat, config.LogLevel
+	defer func() { config.Flags.LogFormat, config.LogLevel = originalFormat, originalLevel }()
+	config.Flags.LogFormat = config.TextLogFormat
+
+	tests := []struct {
+		level config.Verbosity
+		want  string
+	}{
+		{level: config.Error, want: "error\n"},
+		{level: config.Warn, want: "warn\nerror\n"},
+		{level: config.Info, want: "info\nwarn\nerror\n"},
+		{level: config.Verbose, want: "[DEBUG] debug\ninfo\nwarn\nerror\n"},
+	}
+	for _, tt := range tests {
+		config.LogLevel = tt.level
+		printed := captureOutput(t, func() {
+			Debug("debug")
+			Info("info")
+			Warn("warn")
+			Error("error")
+		})
+		// drop the timestamp of the debug line
+		if i := strings.Index(printed, "[DEBUG]"); i >= 0 {
+			printed = printed[i:]
+		}
+		if printed != tt.want {
+			t.Errorf("log level %d printed %q, want %q", tt.level, printed, tt.want)
+		}
+	}
+}
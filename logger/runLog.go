@@ -0,0 +1,98 @@
+package logger
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"sync"
+)
+
+// RunLogFileExtension is the extension of the file in the output path, and in the zip file, that every message logged
+// during the run is written to, debug messages included whatever the -log-level
+const RunLogFileExtension = ".log"
+
+// runLogPendingMaxBytes caps the messages held in memory before the run log file is opened. The messages logged past
+// it are dropped from the run log, they still show on screen
+const runLogPendingMaxBytes = 1 << 20
+
+// runLogTruncated is written to the run log in place of the messages dropped once runLogPendingMaxBytes is reached
+const runLogTruncated = "[run log truncated: too many messages were logged before the file was opened]\n"
+
+// runLog holds the messages logged before the run log file is opened, then writes them to it
+var runLog struct {
+	sync.Mutex
+	pending   bytes.Buffer
+	truncated bool
+	discarded bool
+	file      io.WriteCloser
+	redact    func(string) string
+}
+
+// writeRunLog - adds a message, as printed in text format and without colors, to the run log
+func writeRunLog(text string) {
+	text = colorCodes.ReplaceAllString(text, "")
+	runLog.Lock()
+	defer runLog.Unlock()
+	if runLog.discarded {
+		return
+	}
+	if runLog.file == nil {
+		if runLog.pending.Len()+len(text) > runLogPendingMaxBytes {
+			if !runLog.truncated {
+				runLog.pending.WriteString(runLogTruncated)
+				runLog.truncated = true
+			}
+			return
+		}
+		runLog.pending.WriteString(text)
+		return
+	}
+	_, _ = io.WriteString(runLog.file, runLog.redact(text))
+}
+
+// OpenRunLog - creates the run log file at path and writes to it the messages logged so far. Every message is passed
+// through redact, if any, before it is written to the file
+func OpenRunLog(path string, redact func(string) string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if redact == nil {
+		redact = func(text string) string { return text }
+	}
+
+	runLog.Lock()
+	defer runLog.Unlock()
+	if runLog.file != nil {
+		_ = runLog.file.Close()
+	}
+	runLog.file = file
+	runLog.redact = redact
+	runLog.discarded = false
+	_, err = io.WriteString(runLog.file, runLog.redact(runLog.pending.String()))
+	runLog.pending.Reset()
+	runLog.truncated = false
+	return err
+}
+
+// DiscardRunLog - drops the messages held for the run log and stops holding new ones, for the runs that write no run
+// log file, such as -single and -list-tasks. A later OpenRunLog starts the run log afresh
+func DiscardRunLog() {
+	runLog.Lock()
+	defer runLog.Unlock()
+	runLog.discarded = true
+	runLog.pending.Reset()
+	runLog.truncated = false
+}
+
+// CloseRunLog - closes the run log file. The messages logged afterwards are held in memory, until the next OpenRunLog
+func CloseRunLog() error {
+	runLog.Lock()
+	defer runLog.Unlock()
+	if runLog.file == nil {
+		return nil
+	}
+	err := runLog.file.Close()
+	runLog.file = nil
+	return err
+}
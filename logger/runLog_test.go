@@ -0,0 +1,114 @@
+package logger
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/config"
+)
+
+func TestRunLog(t *testing.T) {
+	originalLevel := config.LogLevel
+	defer func() { config.LogLevel = originalLevel }()
+	config.LogLevel = config.Warn
+	path := filepath.Join(t.TempDir(), "nrdiag-output"+RunLogFileExtension)
+	// drop what the other tests logged
+	runLog.pending.Reset()
+
+	printed := captureOutput(t, func() {
+		Info("before the file is opened")
+		if err := OpenRunLog(path, func(text string) string { return strings.Replace(text, "secret", "_REDACTED_", -1) }); err != nil {
+			t.Fatal(err)
+		}
+		Debug("license_key: secret")
+		Infof("\x1b[32mSuccess\x1b[0m %s\n", "Base/Env/CollectEnvVars")
+		Error("unable to connect")
+		if err := CloseRunLog(); err != nil {
+			t.Fatal(err)
+		}
+		Info("after the file is closed")
+	})
+
+	if printed != "unable to connect\n" {
+		t.Errorf("Expected only the error on screen at -log-level warn, got %q", printed)
+	}
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(string(content), "\n")
+	if len(lines) != 5 || lines[4] != "" {
+		t.Fatalf("Expected 4 lines in the run log, got %q", content)
+	}
+	if lines[0] != "before the file is opened" || !strings.HasSuffix(lines[1], " [DEBUG] license_key: _REDACTED_") ||
+		lines[2] != "Success Base/Env/CollectEnvVars" || lines[3] != "unable to connect" {
+		t.Errorf("Unexpected run log:\n%s", content)
+	}
+}
+
+func TestRunLog_pendingLimit(t *testing.T) {
+	originalLevel := config.LogLevel
+	defer func() { config.LogLevel = originalLevel }()
+	config.LogLevel = config.Error
+	path := filepath.Join(t.TempDir(), "nrdiag-output"+RunLogFileExtension)
+	runLog.pending.Reset()
+
+	captureOutput(t, func() {
+		line := strings.Repeat("x", 1023)
+		for i := 0; i < 2*runLogPendingMaxBytes/1024; i++ {
+			Info(line)
+		}
+		if runLog.pending.Len() > runLogPendingMaxBytes+len(runLogTruncated) {
+			t.Errorf("Expected at most %d bytes held before the run log is opened, got %d", runLogPendingMaxBytes, runLog.pending.Len())
+		}
+		if err := OpenRunLog(path, nil); err != nil {
+			t.Fatal(err)
+		}
+		Info("after the file is opened")
+		if err := CloseRunLog(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(content), runLogTruncated) || !strings.HasSuffix(string(content), "after the file is opened\n") {
+		t.Errorf("Expected the truncation note then the messages logged once the file is opened, got %d bytes ending with %q", len(content), content[len(content)-64:])
+	}
+}
+
+func TestDiscardRunLog(t *testing.T) {
+	originalLevel := config.LogLevel
+	defer func() { config.LogLevel = originalLevel }()
+	config.LogLevel = config.Error
+	path := filepath.Join(t.TempDir(), "nrdiag-output"+RunLogFileExtension)
+	runLog.pending.Reset()
+
+	captureOutput(t, func() {
+		Info("before the run log is discarded")
+		DiscardRunLog()
+		Info("after the run log is discarded")
+		if runLog.pending.Len() != 0 {
+			t.Errorf("Expected no message held once the run log is discarded, got %q", runLog.pending.String())
+		}
+		if err := OpenRunLog(path, nil); err != nil {
+			t.Fatal(err)
+		}
+		Info("after the file is opened")
+		if err := CloseRunLog(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "after the file is opened\n" {
+		t.Errorf("Expected only the message logged once the file is opened, got %q", content)
+	}
+}
@@ -0,0 +1,48 @@
+package mocks
+
+import (
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+)
+
+// FakeTask is a task with the given identifier and dependencies, for the tests of the task queue and runner. Execute
+// returns Result, or the result of ExecuteFunc when it is set
+type FakeTask struct {
+	ID          string
+	Deps        []string
+	Result      tasks.Result
+	ExecuteFunc func(options tasks.Options, upstream map[string]tasks.Result) tasks.Result
+}
+
+// Identifier - the identifier given as ID
+func (t FakeTask) Identifier() tasks.Identifier {
+	return tasks.IdentifierFromString(t.ID)
+}
+
+// Explain - "Explain " followed by the identifier
+func (t FakeTask) Explain() string {
+	return "Explain " + t.ID
+}
+
+// Dependencies - the dependencies given as Deps, never nil
+func (t FakeTask) Dependencies() []string {
+	if t.Deps == nil {
+		return []string{}
+	}
+	return t.Deps
+}
+
+// Execute - returns Result, or calls ExecuteFunc
+func (t FakeTask) Execute(options tasks.Options, upstream map[string]tasks.Result) tasks.Result {
+	if t.ExecuteFunc != nil {
+		return t.ExecuteFunc(options, upstream)
+	}
+	return t.Result
+}
+
+// FakeNetworkTask is a FakeTask making outbound network requests, see tasks.NetworkDependent
+type FakeNetworkTask struct {
+	FakeTask
+}
+
+// RequiresNetwork - marks the task as making outbound network requests
+func (t FakeNetworkTask) RequiresNetwork() {}
@@ -0,0 +1,155 @@
+// Package nrdiag runs the Diagnostics CLI tasks from another Go program, with the task registry and runner of the
+// nrdiag command but none of its flag parsing, prompts, output files or uploads: the questions the tasks would ask on
+// the terminal are answered yes, as with -y. See docs/Embedding.md
+package nrdiag
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/config"
+	"github.com/newrelic/newrelic-diagnostics-cli/helpers/httpHelper"
+	"github.com/newrelic/newrelic-diagnostics-cli/registration"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+)
+
+// offlineSummary is the summary of the network tasks not run with Options.Offline, the same as with -offline
+const offlineSummary = "skipped: offline mode"
+
+// elevationSummary is the summary of the tasks not run for lack of the privileges they need
+const elevationSummary = "This check requires elevated privileges: run it as root, or as Administrator on Windows."
+
+// Task describes a registered task
+type Task struct {
+	Identifier   string
+	Explain      string
+	Dependencies []string
+}
+
+// Options selects the tasks Run runs and how, in place of the command line flags
+type Options struct {
+	// Tasks are the identifiers of the tasks to run, e.g. "Base/Config/Validate" or "Java/*", which run with the tasks
+	// they depend on. A '*' only matches the tasks run by default. No identifier runs every task run by default. The
+	// tasks only run when requested with -t, e.g. Base/Collector/Traceroute, run when an identifier matches them
+	Tasks []string
+	// TaskOptions are the options of the tasks by identifier, like -o Identifier.key=value does. A Status or Payload
+	// option sets the result of the task instead of running it
+	TaskOptions map[string]map[string]string
+	// Concurrency is the maximum number of tasks run at the same time, 0 for the -concurrency default
+	Concurrency int
+	// Offline leaves out the tasks that make network requests, they return a None result. The other tasks skip their
+	// own network lookups, as with -offline
+	Offline bool
+	// HTTPClient is the client the tasks make their HTTP requests with, e.g. with instrumentation or a test double, nil
+	// for the proxy aware default client of the nrdiag command
+	HTTPClient *http.Client
+}
+
+// Result is the result of a task run by Run
+type Result struct {
+	Identifier string
+	tasks.Result
+	// Duration is how long the task ran, zero for the tasks that didn't run
+	Duration time.Duration
+}
+
+// runOptions - the options every task gets. A program embedding nrdiag may have no terminal to prompt on, the tasks
+// asking whether to collect a file with secure information collect it, as with -y
+func runOptions(options Options) tasks.Options {
+	taskOptions := map[string]string{"YesToAll": "true"}
+	if options.Offline {
+		taskOptions[tasks.OfflineOption] = "true"
+	}
+	return tasks.Options{Options: taskOptions}
+}
+
+// forcedTasks - whether a task is one of the Options.Tasks, as with -t: the tasks only run when asked for run then
+func forcedTasks(identifiers []string) func(task tasks.Task) bool {
+	var patterns []*regexp.Regexp
+	for _, identifier := range identifiers {
+		patterns = append(patterns, config.CompileTaskPattern(strings.TrimSpace(identifier)))
+	}
+	return func(task tasks.Task) bool {
+		for _, pattern := range patterns {
+			if pattern.MatchString(task.Identifier().String()) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Tasks returns every task registered on this OS, including the ones only run when asked for, sorted by identifier
+func Tasks() []Task {
+	var registered []Task
+	for _, entry := range registration.Catalog() {
+		registered = append(registered, Task{
+			Identifier:   entry.Identifier,
+			Explain:      entry.Explain,
+			Dependencies: entry.Dependencies,
+		})
+	}
+	return registered
+}
+
+// Run runs the selected tasks and returns their results, each one after the results of the tasks it depends on. It
+// returns an error, without running anything, when an identifier matches no task. A task still running when ctx is
+// done is abandoned and reported with an Error result, its HTTP requests cancelled.
+//
+// The tasks share process wide state, such as the HTTP client settings, so calls of Run must not overlap
+func Run(ctx context.Context, options Options) ([]Result, error) {
+	queue, err := registration.TaskQueue(options.Tasks)
+	if err != nil {
+		return nil, err
+	}
+	if len(queue) == 0 {
+		return nil, errors.New("no task to run")
+	}
+	concurrency := options.Concurrency
+	if concurrency == 0 {
+		concurrency = config.DefaultConcurrency
+	}
+
+	httpHelper.SetRunContext(ctx)
+	defer httpHelper.SetRunContext(context.Background())
+
+	work := make(chan tasks.Task, len(queue))
+	for _, task := range queue {
+		work <- task
+	}
+	close(work)
+
+	runner := registration.Runner{
+		Options:     runOptions(options),
+		TaskOptions: options.TaskOptions,
+		Concurrency: concurrency,
+		HTTPClient:  options.HTTPClient,
+		Forced:      forcedTasks(options.Tasks),
+		Skip: func(task tasks.Task, taskOptions tasks.Options) (tasks.Result, bool) {
+			if taskOptions.IsOffline() && tasks.IsNetworkDependent(task) {
+				return tasks.Result{Status: tasks.None, Summary: offlineSummary}, true
+			}
+			if tasks.RequiresElevation(task, taskOptions) && !tasks.IsElevated() {
+				return tasks.Result{Status: tasks.Warning, Summary: elevationSummary}, true
+			}
+			return tasks.Result{}, false
+		},
+	}
+	runner.Run(ctx, work, func(registration.TaskResult) {})
+
+	// in queue order rather than completion order, which varies from one run to the next
+	results := make([]Result, 0, len(queue))
+	for _, task := range queue {
+		taskResult := runner.Result(task.Identifier().String())
+		results = append(results, Result{
+			Identifier: task.Identifier().String(),
+			Result:     taskResult.Result,
+			Duration:   taskResult.Duration,
+		})
+	}
+	return results, nil
+}
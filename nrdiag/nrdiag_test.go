@@ -0,0 +1,136 @@
+package nrdiag
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+)
+
+func TestTasks(t *testing.T) {
+	for _, task := range Tasks() {
+		if task.Identifier == "Base/Config/Validate" {
+			if len(task.Dependencies) == 0 || task.Explain == "" {
+				t.Errorf("Tasks() = %+v, expected the explanation and dependencies of Base/Config/Validate", task)
+			}
+			return
+		}
+	}
+	t.Error("Tasks() is missing Base/Config/Validate")
+}
+
+func TestRun(t *testing.T) {
+	results, err := Run(context.Background(), Options{
+		Tasks: []string{"Base/Env/CollectEnvVars", "Base/Collector/ConnectUS"},
+		TaskOptions: map[string]map[string]string{
+			"Base/Env/CollectEnvVars": {"Status": "info"},
+		},
+		Offline: true,
+	})
+	if err != nil {
+		t.Fatal("Run() error:", err)
+	}
+	byIdentifier := make(map[string]Result)
+	for _, result := range results {
+		byIdentifier[result.Identifier] = result
+	}
+	if result := byIdentifier["Base/Env/CollectEnvVars"]; result.Status != tasks.Info {
+		t.Errorf("Run() result = %+v, expected the status set by the task options", result)
+	}
+	if result := byIdentifier["Base/Collector/ConnectUS"]; result.Status != tasks.None || result.Summary != offlineSummary {
+		t.Errorf("Run() result = %+v, expected the network task to be skipped", result)
+	}
+	if results[len(results)-1].Identifier != "Base/Collector/ConnectUS" {
+		t.Errorf("Run() returned the result of %s last, expected it after its dependencies", results[len(results)-1].Identifier)
+	}
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestRun_optInTask(t *testing.T) {
+	var requested []string
+	client := &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		requested = append(requested, req.URL.String())
+		return &http.Response{
+			StatusCode: http.StatusForbidden,
+			Status:     "403 Forbidden",
+			Body:       io.NopCloser(strings.NewReader("")),
+			Request:    req,
+		}, nil
+	})}
+	results, err := Run(context.Background(), Options{
+		Tasks:      []string{"Synthetics/Minion/Connect"},
+		HTTPClient: client,
+	})
+	if err != nil {
+		t.Fatal("Run() error:", err)
+	}
+	result := results[len(results)-1]
+	if result.Identifier != "Synthetics/Minion/Connect" || result.Status != tasks.Success {
+		t.Errorf("Run() result = %+v, expected the task asked for to run", result)
+	}
+	if len(requested) == 0 {
+		t.Error("Run() expected the task to request the private location endpoints")
+	}
+}
+
+func TestRun_offlineInfraAgentVersion(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake newrelic-infra is a shell script")
+	}
+	bin := t.TempDir()
+	script := "#!/bin/sh\necho 'New Relic Infrastructure Agent version: 1.13.0'\n"
+	if err := os.WriteFile(filepath.Join(bin, "newrelic-infra"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	client := &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		t.Errorf("Expected no request in offline mode, requested %s", req.URL)
+		return nil, http.ErrHandlerTimeout
+	})}
+	results, err := Run(context.Background(), Options{
+		Tasks: []string{"Infra/Agent/Version"},
+		TaskOptions: map[string]map[string]string{
+			"Infra/Config/Agent": {"Status": "success"},
+		},
+		Offline:    true,
+		HTTPClient: client,
+	})
+	if err != nil {
+		t.Fatal("Run() error:", err)
+	}
+	result := results[len(results)-1]
+	if result.Identifier != "Infra/Agent/Version" || result.Status != tasks.Info || result.Summary != "1.13.0" {
+		t.Errorf("Run() result = %+v, expected the installed version without its release date", result)
+	}
+}
+
+func TestRun_unknownTask(t *testing.T) {
+	if _, err := Run(context.Background(), Options{Tasks: []string{"Not/A/Task"}}); err == nil {
+		t.Error("Run() expected an error for an identifier matching no task")
+	}
+}
+
+func Test_runOptions(t *testing.T) {
+	original := tasks.AskUser
+	tasks.AskUser = func(question string) bool {
+		t.Errorf("Expected no prompt, asked %q", question)
+		return false
+	}
+	defer func() { tasks.AskUser = original }()
+
+	if !tasks.PromptUser("Collect the file?", runOptions(Options{})) {
+		t.Error("Expected the prompts of the tasks to be answered yes")
+	}
+}
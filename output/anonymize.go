@@ -0,0 +1,417 @@
+package output
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/config"
+	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
+	"github.com/newrelic/newrelic-diagnostics-cli/output/color"
+	"github.com/newrelic/newrelic-diagnostics-cli/registration"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+)
+
+// The kinds of names -anonymize replaces, which are also the prefixes of their pseudonyms, e.g. host-1
+const (
+	anonymizedHostname = "host"
+	anonymizedIPv4     = "ipv4"
+	anonymizedIPv6     = "ipv6"
+)
+
+// anonymizeMapExtension is appended to the output name for the file mapping the pseudonyms back to the actual names
+const anonymizeMapExtension = "-anonymize-map.json"
+
+var ipv4Pattern = regexp.MustCompile(`\b(?:\d{1,3}\.){3}\d{1,3}\b`)
+
+// ipv6Pattern only finds the candidates, they are checked with net.ParseIP as timestamps and MAC addresses match it too
+var ipv6Pattern = regexp.MustCompile(`(?i)(?:[0-9a-f]{0,4}:){2,7}[0-9a-f]{0,4}`)
+
+// hostnamePatterns find the hostnames to anonymize, in their first group: the hosts of URLs, the values of the
+// settings naming a host, and the names in the domains used for private networks
+var hostnamePatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)\b[a-z][a-z0-9+.-]*://(?:[^@/\s"']+@)?([a-z0-9_-]+(?:\.[a-z0-9_-]+)*)`),
+	regexp.MustCompile(`(?i)\b(?:host|hostname|host_name|proxy_host|proxyhost|collector_host|override_hostname|fqdn)['"]?[ \t]*[:=][ \t]*['"]?([a-z0-9][a-z0-9_-]*(?:\.[a-z0-9_-]+)*)`),
+	regexp.MustCompile(`(?i)\b([a-z0-9][a-z0-9-]*(?:\.[a-z0-9][a-z0-9-]*)*\.(?:internal|local|localdomain|lan|corp|intranet|home\.arpa))\b`),
+}
+
+// publicDomains are kept as is: the New Relic endpoints are the same for every customer and support needs them
+var publicDomains = []string{"newrelic.com", "nr-data.net", "nr-assets.net"}
+
+// versionPrefix - an IPv4 looking number following it is a version, e.g. of the .NET agent, not an address
+var versionPrefix = regexp.MustCompile(`(?i)(?:version|v)["':= ]*$`)
+
+// versionPrefixMaxLength - how much of the text before a number versionPrefix is matched against
+const versionPrefixMaxLength = 16
+
+// AnonymizedName is an actual hostname or IP address replaced by -anonymize, and the pseudonym replacing it
+type AnonymizedName struct {
+	Pseudonym string
+	Original  string
+	Kind      string
+}
+
+// anonymizer replaces the hostnames and IP addresses it finds with pseudonyms. The same name always gets the same
+// pseudonym, whatever its case, so the results, the run log and every output file of a run can still be correlated
+type anonymizer struct {
+	sync.Mutex
+	pseudonyms map[string]string
+	taken      map[string]bool
+	names      []AnonymizedName
+	counts     map[string]int
+	// hostnames is the pattern matching every hostname found so far, the longest first, nil when it is out of date
+	hostnames *regexp.Regexp
+}
+
+// newAnonymizer - an anonymizer already knowing the hostnames given, so they are replaced wherever they appear
+func newAnonymizer(hostnames ...string) *anonymizer {
+	a := &anonymizer{
+		pseudonyms: make(map[string]string),
+		taken:      make(map[string]bool),
+		counts:     make(map[string]int),
+	}
+	for _, hostname := range hostnames {
+		a.addHostname(hostname)
+		// the hostname of the OS may be the FQDN, while the agents report the short name
+		if label := strings.SplitN(hostname, ".", 2)[0]; label != hostname {
+			a.addHostname(label)
+		}
+	}
+	return a
+}
+
+var (
+	runAnonymizerOnce sync.Once
+	runAnonymizer     *anonymizer
+)
+
+// currentAnonymizer - the anonymizer of the run, shared by the output files and the run log
+func currentAnonymizer() *anonymizer {
+	runAnonymizerOnce.Do(func() {
+		hostname, _ := os.Hostname()
+		runAnonymizer = newAnonymizer(hostname)
+	})
+	return runAnonymizer
+}
+
+func (a *anonymizer) pseudonym(original string, kind string) string {
+	key := strings.ToLower(original)
+	if pseudonym, ok := a.pseudonyms[key]; ok {
+		return pseudonym
+	}
+	a.counts[kind]++
+	pseudonym := kind + "-" + strconv.Itoa(a.counts[kind])
+	a.pseudonyms[key] = pseudonym
+	a.taken[pseudonym] = true
+	a.names = append(a.names, AnonymizedName{Pseudonym: pseudonym, Original: original, Kind: kind})
+	if kind == anonymizedHostname {
+		a.hostnames = nil
+	}
+	return pseudonym
+}
+
+func (a *anonymizer) addHostname(hostname string) {
+	hostname = strings.TrimSuffix(strings.TrimSpace(hostname), ".")
+	lower := strings.ToLower(hostname)
+	if len(hostname) < 2 || lower == "localhost" || a.taken[lower] || net.ParseIP(hostname) != nil || strings.IndexFunc(hostname, isLetter) == -1 {
+		return
+	}
+	for _, domain := range publicDomains {
+		if lower == domain || strings.HasSuffix(lower, "."+domain) {
+			return
+		}
+	}
+	a.pseudonym(hostname, anonymizedHostname)
+}
+
+// anonymizeString - replaces the IP addresses and the hostnames of a string, the ones found in it included
+func (a *anonymizer) anonymizeString(s string) string {
+	a.Lock()
+	defer a.Unlock()
+	a.findHostnames(s)
+	s = a.replaceIPs(s)
+	return a.replaceHostnames(s)
+}
+
+// discover - adds the hostnames of a string without replacing them, so the strings anonymized before the one a
+// hostname is first found in are anonymized too
+func (a *anonymizer) discover(s string) {
+	a.Lock()
+	defer a.Unlock()
+	a.findHostnames(s)
+}
+
+func (a *anonymizer) findHostnames(s string) {
+	for _, pattern := range hostnamePatterns {
+		for _, match := range pattern.FindAllStringSubmatch(s, -1) {
+			a.addHostname(match[1])
+		}
+	}
+	// the FQDNs of the hostnames found, e.g. web-01.example.com once web-01 is known
+	for _, name := range a.names {
+		if name.Kind != anonymizedHostname || strings.Contains(name.Original, ".") || !strings.Contains(strings.ToLower(s), strings.ToLower(name.Original)+".") {
+			continue
+		}
+		fqdn := regexp.MustCompile(`(?i)\b` + regexp.QuoteMeta(name.Original) + `(?:\.[a-z0-9][a-z0-9-]*)+`)
+		for _, match := range fqdn.FindAllString(s, -1) {
+			a.addHostname(match)
+		}
+	}
+}
+
+func (a *anonymizer) replaceIPs(s string) string {
+	s = replaceMatches(s, ipv6Pattern, func(match string, before string, after string) (string, bool) {
+		ip := net.ParseIP(match)
+		if ip == nil || ip.To4() != nil || ip.IsLoopback() || ip.IsUnspecified() || isNameByte(lastByte(before)) || isNameByte(firstByte(after)) {
+			return "", false
+		}
+		return a.pseudonym(ip.String(), anonymizedIPv6), true
+	})
+	return replaceMatches(s, ipv4Pattern, func(match string, before string, after string) (string, bool) {
+		ip := net.ParseIP(match)
+		if ip == nil || ip.IsLoopback() || ip.IsUnspecified() || versionPrefix.MatchString(suffix(before, versionPrefixMaxLength)) {
+			return "", false
+		}
+		// more numbers around it, e.g. 1.2.3.4.5, make it something else than an address
+		if (lastByte(before) == '.' && isDigit(lastByte(strings.TrimSuffix(before, ".")))) || (firstByte(after) == '.' && len(after) > 1 && isDigit(after[1])) {
+			return "", false
+		}
+		return a.pseudonym(match, anonymizedIPv4), true
+	})
+}
+
+func (a *anonymizer) replaceHostnames(s string) string {
+	if a.hostnames == nil {
+		var originals []string
+		for _, name := range a.names {
+			if name.Kind == anonymizedHostname {
+				originals = append(originals, regexp.QuoteMeta(name.Original))
+			}
+		}
+		if len(originals) == 0 {
+			return s
+		}
+		// the alternation picks the first name matching, an FQDN must be tried before its hostname
+		sort.SliceStable(originals, func(i, j int) bool { return len(originals[i]) > len(originals[j]) })
+		a.hostnames = regexp.MustCompile(`(?i)` + strings.Join(originals, "|"))
+	}
+	return replaceMatches(s, a.hostnames, func(match string, before string, after string) (string, bool) {
+		// only whole names: not web-01 in web-012 or in the web-01.example.com of another host
+		if isNameByte(lastByte(before)) || lastByte(before) == '.' || isNameByte(firstByte(after)) || (firstByte(after) == '.' && len(after) > 1 && isNameByte(after[1])) {
+			return "", false
+		}
+		return a.pseudonyms[strings.ToLower(match)], true
+	})
+}
+
+// replaceMatches - replaces the matches of the pattern for which replace returns true with what it returns, given the
+// text before and after them
+func replaceMatches(s string, pattern *regexp.Regexp, replace func(match string, before string, after string) (string, bool)) string {
+	var output strings.Builder
+	last := 0
+	for _, location := range pattern.FindAllStringIndex(s, -1) {
+		replacement, ok := replace(s[location[0]:location[1]], s[:location[0]], s[location[1]:])
+		if !ok {
+			continue
+		}
+		output.WriteString(s[last:location[0]])
+		output.WriteString(replacement)
+		last = location[1]
+	}
+	output.WriteString(s[last:])
+	return output.String()
+}
+
+func isLetter(r rune) bool {
+	return (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
+}
+
+func isDigit(b byte) bool {
+	return b >= '0' && b <= '9'
+}
+
+// isNameByte - whether the byte can be part of a hostname label or of an identifier the name would be part of
+func isNameByte(b byte) bool {
+	return isLetter(rune(b)) || isDigit(b) || b == '-' || b == '_'
+}
+
+func firstByte(s string) byte {
+	if s == "" {
+		return 0
+	}
+	return s[0]
+}
+
+func suffix(s string, length int) string {
+	if len(s) <= length {
+		return s
+	}
+	return s[len(s)-length:]
+}
+
+func lastByte(s string) byte {
+	if s == "" {
+		return 0
+	}
+	return s[len(s)-1]
+}
+
+// anonymizeResults returns a copy of the results with the hostnames and IP addresses of their summaries and payloads
+// replaced. Every result is read before any is rewritten, so a name is replaced in all of them once found in one
+func anonymizeResults(data []registration.TaskResult) []registration.TaskResult {
+	a := currentAnonymizer()
+	payloads := make([][]byte, len(data))
+	for i, taskResult := range data {
+		a.discover(taskResult.Result.Summary)
+		if taskResult.Result.Payload == nil {
+			continue
+		}
+		marshaled, err := json.Marshal(taskResult.Result.Payload)
+		if err != nil {
+			log.Debug("Unable to anonymize the payload of", taskResult.Task.Identifier().String(), ":", err)
+			continue
+		}
+		payloads[i] = marshaled
+		_, _ = rewriteJSON(marshaled, func(value string, sensitive bool) string {
+			a.discover(value)
+			return value
+		})
+	}
+
+	anonymized := make([]registration.TaskResult, len(data))
+	for i, taskResult := range data {
+		anonymized[i] = taskResult
+		anonymized[i].Result.Summary = a.anonymizeString(taskResult.Result.Summary)
+		if taskResult.Result.Payload == nil {
+			continue
+		}
+		if payloads[i] == nil {
+			anonymized[i].Result.Payload = redactedValue
+			continue
+		}
+		payload, err := anonymizeJSON(a, payloads[i])
+		if err != nil {
+			// better to leave the payload out than to write the names it holds
+			anonymized[i].Result.Payload = redactedValue
+			continue
+		}
+		anonymized[i].Result.Payload = payload
+	}
+	return anonymized
+}
+
+// anonymizePayload marshals a payload the way the output file would and anonymizes every string in it
+func anonymizePayload(payload interface{}) (json.RawMessage, error) {
+	marshaled, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	return anonymizeJSON(currentAnonymizer(), marshaled)
+}
+
+func anonymizeJSON(a *anonymizer, document []byte) (json.RawMessage, error) {
+	return rewriteJSON(document, func(value string, sensitive bool) string {
+		return a.anonymizeString(value)
+	})
+}
+
+// anonymizeRunLog - adds -anonymize to the redaction of the run log, which is nil with -no-redact
+func anonymizeRunLog(redact func(string) string) func(string) string {
+	return func(text string) string {
+		if redact != nil {
+			text = redact(text)
+		}
+		return currentAnonymizer().anonymizeString(text)
+	}
+}
+
+// anonymizeFile - anonymizes a file written while the run went on again, for the names found after some of it was
+// written, e.g. in the results
+func anonymizeFile(path string) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		log.Debug("Unable to anonymize", path, ":", err)
+		return
+	}
+	if err := os.WriteFile(path, []byte(currentAnonymizer().anonymizeString(string(content))), 0644); err != nil {
+		log.Debug("Unable to anonymize", path, ":", err)
+	}
+}
+
+// binarySniffLength - a file with a NUL byte in its first bytes is binary, as git tells them apart
+const binarySniffLength = 8000
+
+// anonymizeCopy - copies a file collected by a task with its hostnames and IP addresses replaced, line by line. A
+// binary file can't be anonymized, it is copied as it is with a warning
+func anonymizeCopy(dst io.Writer, src io.Reader, path string) error {
+	reader := bufio.NewReader(src)
+	head, _ := reader.Peek(binarySniffLength)
+	if bytes.IndexByte(head, 0) != -1 {
+		log.Info(color.ColorString(color.Yellow, "Warning: "+path+" is a binary file, it is added to the zip file without -anonymize replacing its hostnames and IP addresses"))
+		_, err := io.Copy(dst, reader)
+		return err
+	}
+	a := currentAnonymizer()
+	for {
+		line, err := reader.ReadString('\n')
+		if line != "" {
+			if _, writeErr := io.WriteString(dst, a.anonymizeString(line)); writeErr != nil {
+				return writeErr
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// anonymizedNames - the names replaced so far, by kind then in the order they were found
+func (a *anonymizer) anonymizedNames() []AnonymizedName {
+	a.Lock()
+	defer a.Unlock()
+	names := append([]AnonymizedName{}, a.names...)
+	sort.SliceStable(names, func(i, j int) bool { return names[i].Kind < names[j].Kind })
+	return names
+}
+
+func anonymizeMapPath() string {
+	return filepath.Clean(config.Flags.OutputPath + "/" + config.OutputFileName(anonymizeMapExtension))
+}
+
+// WriteAnonymizeMap - writes the pseudonyms of -anonymize and the actual names they replace to the output path, next
+// to the zip file. The mapping is only added to the zip file with -anonymize-map, as it would defeat -anonymize
+func WriteAnonymizeMap(zipfile *zip.Writer) {
+	if !config.Flags.Anonymize {
+		return
+	}
+	marshaled, err := json.MarshalIndent(currentAnonymizer().anonymizedNames(), "", "	")
+	if err != nil {
+		log.Info("Error creating the -anonymize mapping: ", err)
+		return
+	}
+	path := anonymizeMapPath()
+	if err := os.WriteFile(path, marshaled, 0600); err != nil {
+		log.Info("Error writing the -anonymize mapping: ", err)
+		log.Info(permissionsError)
+		return
+	}
+	if config.Flags.AnonymizeMap {
+		copyFilesToZip(zipfile, []tasks.FileCopyEnvelope{{Path: path}})
+		log.Info("The mapping of the -anonymize pseudonyms to the actual names is in " + path + " and in the zip file")
+		return
+	}
+	log.Info("The mapping of the -anonymize pseudonyms to the actual names is in " + path + ", it is not in the zip file")
+}
@@ -0,0 +1,157 @@
+package output
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/output/color"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+)
+
+// diffOutput is where -diff prints the changed results
+var diffOutput io.Writer = os.Stdout
+
+// diffFile is the part of nrdiag-output.json compared by -diff. Everything that changes from one run to the next
+// whatever the results, such as the timings, the paths and the payloads, is left out
+type diffFile struct {
+	RunDate string
+	Results []struct {
+		Identifier tasks.Identifier
+		Result     struct {
+			Status string
+		}
+	}
+}
+
+// StatusChange is a task whose status differs between the two runs. A task that only ran in one of them has the None
+// status and is marked as not run in the other
+type StatusChange struct {
+	Identifier string
+	Old        tasks.Status
+	New        tasks.Status
+	OldRan     bool
+	NewRan     bool
+}
+
+// ResultsDiff is the comparison of the results of two runs, by task identifier
+type ResultsDiff struct {
+	OldRunDate string
+	NewRunDate string
+	Changed    []StatusChange
+	Unchanged  int
+}
+
+// Regressions returns the changes that brought a task to the failOn status or above from a less severe one, or from
+// not running at all
+func (d ResultsDiff) Regressions(failOn tasks.Status) []StatusChange {
+	var regressions []StatusChange
+	for _, change := range d.Changed {
+		if change.NewRan && change.New.IsAtLeast(failOn) && (!change.OldRan || !change.Old.IsAtLeast(failOn)) {
+			regressions = append(regressions, change)
+		}
+	}
+	return regressions
+}
+
+// DiffOutputFiles compares the task results of two nrdiag-output.json files
+func DiffOutputFiles(oldPath string, newPath string) (ResultsDiff, error) {
+	oldRun, err := readDiffFile(oldPath)
+	if err != nil {
+		return ResultsDiff{}, err
+	}
+	newRun, err := readDiffFile(newPath)
+	if err != nil {
+		return ResultsDiff{}, err
+	}
+	return diffResults(oldRun, newRun)
+}
+
+func readDiffFile(path string) (diffFile, error) {
+	var run diffFile
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return run, err
+	}
+	if err := json.Unmarshal(content, &run); err != nil {
+		return run, fmt.Errorf("%s is not an nrdiag output file: %s", path, err.Error())
+	}
+	if run.Results == nil {
+		return run, errors.New(path + " has no Results, it is not an nrdiag output file")
+	}
+	return run, nil
+}
+
+// statusesByIdentifier - the status of each task result of a run
+func (f diffFile) statusesByIdentifier() (map[string]tasks.Status, error) {
+	statuses := make(map[string]tasks.Status, len(f.Results))
+	for _, result := range f.Results {
+		status, err := tasks.StatusFromString(result.Result.Status)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", result.Identifier.String(), err.Error())
+		}
+		statuses[result.Identifier.String()] = status
+	}
+	return statuses, nil
+}
+
+func diffResults(oldRun diffFile, newRun diffFile) (ResultsDiff, error) {
+	diff := ResultsDiff{OldRunDate: oldRun.RunDate, NewRunDate: newRun.RunDate, Changed: []StatusChange{}}
+	oldStatuses, err := oldRun.statusesByIdentifier()
+	if err != nil {
+		return diff, err
+	}
+	newStatuses, err := newRun.statusesByIdentifier()
+	if err != nil {
+		return diff, err
+	}
+
+	identifiers := make(map[string]bool, len(oldStatuses)+len(newStatuses))
+	for identifier := range oldStatuses {
+		identifiers[identifier] = true
+	}
+	for identifier := range newStatuses {
+		identifiers[identifier] = true
+	}
+	for identifier := range identifiers {
+		oldStatus, oldRan := oldStatuses[identifier]
+		newStatus, newRan := newStatuses[identifier]
+		if oldRan == newRan && oldStatus == newStatus {
+			diff.Unchanged++
+			continue
+		}
+		diff.Changed = append(diff.Changed, StatusChange{
+			Identifier: identifier,
+			Old:        oldStatus,
+			New:        newStatus,
+			OldRan:     oldRan,
+			NewRan:     newRan,
+		})
+	}
+	sort.Slice(diff.Changed, func(i, j int) bool {
+		return diff.Changed[i].Identifier < diff.Changed[j].Identifier
+	})
+	return diff, nil
+}
+
+// WriteDiff prints the changed results as <old status> -> <new status> - <taskIdentifier>, each status in its color
+func WriteDiff(diff ResultsDiff) {
+	fmt.Fprintln(diffOutput, color.ColorString(color.White, fmt.Sprintf("\nChanged Results (%s -> %s)\n-------------------------------------------------", diff.OldRunDate, diff.NewRunDate)))
+	for _, change := range diff.Changed {
+		fmt.Fprintf(diffOutput, "%s -> %s - %s\n", changeStatusString(change.Old, change.OldRan), changeStatusString(change.New, change.NewRan), change.Identifier)
+	}
+	if len(diff.Changed) == 0 {
+		fmt.Fprintln(diffOutput, "No task changed status")
+	}
+	fmt.Fprintf(diffOutput, "\n%d changed, %d unchanged\n", len(diff.Changed), diff.Unchanged)
+}
+
+func changeStatusString(status tasks.Status, ran bool) string {
+	if !ran {
+		return "Not run"
+	}
+	return color.ColorString(status, status.StatusToString())
+}
@@ -0,0 +1,121 @@
+package output
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+)
+
+const oldDiffOutput = `{
+	"RunDate": "2024-01-02T10:00:00Z",
+	"Results": [
+		{"Identifier": {"Category": "Base", "Subcategory": "Config", "Name": "Collect"}, "Result": {"Status": "Success", "Summary": "2 config files(s) found"}},
+		{"Identifier": {"Category": "Base", "Subcategory": "Collector", "Name": "ConnectUS"}, "Result": {"Status": "Success"}},
+		{"Identifier": {"Category": "Java", "Subcategory": "Config", "Name": "Agent"}, "Result": {"Status": "Warning"}},
+		{"Identifier": {"Category": "Base", "Subcategory": "Log", "Name": "Copy"}, "Result": {"Status": "Info"}}
+	]
+}`
+
+const newDiffOutput = `{
+	"RunDate": "2024-01-03T10:00:00Z",
+	"Results": [
+		{"Identifier": {"Category": "Base", "Subcategory": "Config", "Name": "Collect"}, "Result": {"Status": "Success", "Summary": "3 config files(s) found"}},
+		{"Identifier": {"Category": "Base", "Subcategory": "Collector", "Name": "ConnectUS"}, "Result": {"Status": "Failure"}},
+		{"Identifier": {"Category": "Java", "Subcategory": "Config", "Name": "Agent"}, "Result": {"Status": "Success"}},
+		{"Identifier": {"Category": "Base", "Subcategory": "Proxy", "Name": "Detect"}, "Result": {"Status": "Error"}}
+	]
+}`
+
+func writeDiffFiles(t *testing.T, oldContent string, newContent string) (string, string) {
+	dir := t.TempDir()
+	oldPath, newPath := filepath.Join(dir, "old.json"), filepath.Join(dir, "new.json")
+	if err := os.WriteFile(oldPath, []byte(oldContent), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(newPath, []byte(newContent), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return oldPath, newPath
+}
+
+func Test_DiffOutputFiles(t *testing.T) {
+	diff, err := DiffOutputFiles(writeDiffFiles(t, oldDiffOutput, newDiffOutput))
+	if err != nil {
+		t.Fatalf("DiffOutputFiles() error = %v", err)
+	}
+
+	expected := ResultsDiff{
+		OldRunDate: "2024-01-02T10:00:00Z",
+		NewRunDate: "2024-01-03T10:00:00Z",
+		Changed: []StatusChange{
+			{Identifier: "Base/Collector/ConnectUS", Old: tasks.Success, New: tasks.Failure, OldRan: true, NewRan: true},
+			{Identifier: "Base/Log/Copy", Old: tasks.Info, New: tasks.None, OldRan: true},
+			{Identifier: "Base/Proxy/Detect", Old: tasks.None, New: tasks.Error, NewRan: true},
+			{Identifier: "Java/Config/Agent", Old: tasks.Warning, New: tasks.Success, OldRan: true, NewRan: true},
+		},
+		Unchanged: 1,
+	}
+	if !reflect.DeepEqual(diff, expected) {
+		t.Errorf("DiffOutputFiles() = %+v, want %+v", diff, expected)
+	}
+
+	regressions := diff.Regressions(tasks.Failure)
+	if len(regressions) != 2 || regressions[0].Identifier != "Base/Collector/ConnectUS" || regressions[1].Identifier != "Base/Proxy/Detect" {
+		t.Errorf("Regressions(Failure) = %+v, want Base/Collector/ConnectUS and Base/Proxy/Detect", regressions)
+	}
+	if regressions = diff.Regressions(tasks.Error); len(regressions) != 1 {
+		t.Errorf("Regressions(Error) = %+v, want Base/Proxy/Detect only", regressions)
+	}
+}
+
+func Test_DiffOutputFiles_errors(t *testing.T) {
+	tests := []struct {
+		name       string
+		newContent string
+		wantErr    string
+	}{
+		{name: "not JSON", newContent: "Check Results", wantErr: "is not an nrdiag output file"},
+		{name: "no results", newContent: `{"RunDate": "2024-01-03T10:00:00Z"}`, wantErr: "has no Results"},
+		{name: "unknown status", newContent: `{"Results": [{"Identifier": {"Category": "Base", "Subcategory": "Env", "Name": "Shell"}, "Result": {"Status": "Passed"}}]}`, wantErr: "Base/Env/Shell: unknown status 'Passed'"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := DiffOutputFiles(writeDiffFiles(t, oldDiffOutput, tt.newContent))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("DiffOutputFiles() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	if _, err := DiffOutputFiles(filepath.Join(t.TempDir(), "missing.json"), "new.json"); err == nil {
+		t.Error("DiffOutputFiles() of a missing file should return an error")
+	}
+}
+
+func Test_WriteDiff(t *testing.T) {
+	var printed bytes.Buffer
+	diffOutput = &printed
+	defer func() { diffOutput = os.Stdout }()
+
+	WriteDiff(ResultsDiff{
+		OldRunDate: "2024-01-02T10:00:00Z",
+		NewRunDate: "2024-01-03T10:00:00Z",
+		Changed: []StatusChange{
+			{Identifier: "Base/Collector/ConnectUS", Old: tasks.Success, New: tasks.Failure, OldRan: true, NewRan: true},
+			{Identifier: "Base/Log/Copy", Old: tasks.Info, OldRan: true},
+		},
+		Unchanged: 3,
+	})
+
+	output := printed.String()
+	for _, expected := range []string{"2024-01-02T10:00:00Z -> 2024-01-03T10:00:00Z", " -> ", "Base/Collector/ConnectUS", "Info", "Not run - Base/Log/Copy", "2 changed, 3 unchanged"} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("WriteDiff() printed %q, want it to contain %q", output, expected)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package output
+
+import (
+	"bytes"
+	"html/template"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/config"
+	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
+	"github.com/newrelic/newrelic-diagnostics-cli/registration"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+)
+
+const htmlFileExtension = ".html"
+
+type htmlReport struct {
+	NRDiagVersion   string
+	Recommendations []recommendation
+	Categories      []htmlCategory
+}
+
+type htmlCategory struct {
+	Name    string
+	Results []htmlResult
+}
+
+type htmlResult struct {
+	Identifier  string
+	Status      string
+	Summary     string
+	URL         string
+	Remediation *tasks.Remediation
+}
+
+// The report is opened by customers without network access, so the styles are inlined and nothing is loaded from elsewhere.
+// It deliberately has no run date: identical runs should produce identical files
+var htmlReportTemplate = template.Must(template.New("report").Funcs(template.FuncMap{"lower": strings.ToLower}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>New Relic Diagnostics results</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; margin: 2em; color: #1d252c; }
+h1 { font-size: 1.5em; }
+h2 { font-size: 1.2em; margin-top: 1.5em; border-bottom: 1px solid #d5d7d7; }
+table { border-collapse: collapse; width: 100%; }
+th, td { text-align: left; vertical-align: top; padding: 0.4em 0.6em; border-bottom: 1px solid #e8e8e8; }
+td.summary { white-space: pre-wrap; font-family: Menlo, Consolas, monospace; font-size: 0.9em; }
+.status { font-weight: bold; white-space: nowrap; }
+.status-success { color: #117a29; }
+.status-warning { color: #a66c00; }
+.status-failure, .status-error { color: #c4161c; }
+.status-info { color: #0b6acb; }
+.status-none { color: #6b7070; }
+ol.recommendations li { margin-bottom: 0.6em; }
+ol.recommendations .tasks { color: #6b7070; font-size: 0.9em; }
+.remediation { color: #6b7070; }
+</style>
+</head>
+<body>
+<h1>New Relic Diagnostics results</h1>
+<p>nrdiag version {{.NRDiagVersion}}</p>
+{{if .Recommendations}}
+<h2>Recommended actions</h2>
+<ol class="recommendations">
+{{range .Recommendations}}<li><span class="status status-{{.Status.StatusToString | lower}}">{{.Action}}</span><br>
+{{.Reason}}{{if .URL}} <a href="{{.URL}}">{{.URL}}</a>{{end}}<br>
+<span class="tasks">{{range $i, $task := .Tasks}}{{if $i}}, {{end}}{{$task}}{{end}}</span></li>
+{{end}}</ol>
+{{end}}
+{{range .Categories}}
+<h2>{{.Name}}</h2>
+<table>
+<tr><th>Task</th><th>Status</th><th>Summary</th></tr>
+{{range .Results}}<tr>
+<td>{{.Identifier}}</td>
+<td class="status status-{{.Status | lower}}">{{.Status}}</td>
+<td class="summary">{{.Summary}}{{if .URL}}
+<a href="{{.URL}}">{{.URL}}</a>{{end}}{{with .Remediation}}
+<span class="remediation">Remediation: {{.}}</span>{{end}}</td>
+</tr>
+{{end}}</table>
+{{end}}
+</body>
+</html>
+`))
+
+// getResultsHTML renders the results of the run into a self-contained HTML page grouped by task category, after the
+// recommended actions.
+// Categories and the tasks within them are sorted by identifier so the page doesn't depend on the order tasks ran in.
+func getResultsHTML(data []registration.TaskResult) string {
+	byCategory := make(map[string][]htmlResult)
+	for _, taskResult := range data {
+		identifier := taskResult.Task.Identifier()
+		byCategory[identifier.Category] = append(byCategory[identifier.Category], htmlResult{
+			Identifier:  identifier.String(),
+			Status:      taskResult.Result.Status.StatusToString(),
+			Summary:     taskResult.Result.Summary,
+			URL:         taskResult.Result.URL,
+			Remediation: taskResult.Result.Remediation,
+		})
+	}
+
+	report := htmlReport{NRDiagVersion: config.Version, Recommendations: recommendActions(data)}
+	for category, results := range byCategory {
+		sort.Slice(results, func(i, j int) bool {
+			return results[i].Identifier < results[j].Identifier
+		})
+		report.Categories = append(report.Categories, htmlCategory{Name: category, Results: results})
+	}
+	sort.Slice(report.Categories, func(i, j int) bool {
+		return report.Categories[i].Name < report.Categories[j].Name
+	})
+
+	var output bytes.Buffer
+	err := htmlReportTemplate.Execute(&output, report)
+	if err != nil {
+		log.Info("Couldn't save HTML output: ", err)
+	}
+	return output.String()
+}
+
+func outputHTML(report string) {
+	htmlFile := filepath.Clean(config.Flags.OutputPath + "/" + config.OutputFileName(htmlFileExtension))
+	log.Debug("Creating HTML file:", htmlFile)
+	err := os.MkdirAll(config.Flags.OutputPath, 0777)
+	if err != nil {
+		log.Info("Error creating directory", err)
+		log.Info(permissionsError)
+	}
+	_ = ioutil.WriteFile(htmlFile, []byte(report), 0644)
+}
@@ -0,0 +1,82 @@
+package output
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/registration"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+)
+
+func Test_getResultsHTML(t *testing.T) {
+	results := generateJUnitResults()
+	results[0].Result.Summary = "<script>alert(1)</script>"
+
+	observed := getResultsHTML(results)
+
+	reversed := make([]registration.TaskResult, len(results))
+	for i, result := range results {
+		reversed[len(results)-1-i] = result
+	}
+	if getResultsHTML(reversed) != observed {
+		t.Error("Expected the same HTML regardless of the order tasks ran in")
+	}
+
+	expectedInOrder := []string{
+		"<h2>Recommended actions</h2>",
+		"<span class=\"status status-warning\">Fix the syntax errors of the agent config file</span>",
+		"<span class=\"status status-failure\">Allow the outbound HTTPS connections to the New Relic endpoints through the firewall</span>",
+		"<h2>Base</h2>",
+		"<td>Base/Collector/ConnectUS</td>\n<td class=\"status status-failure\">Failure</td>",
+		"<a href=\"https://docs.newrelic.com/docs/new-relic-solutions/get-started/networks/\">",
+		"<td>Base/Config/Collect</td>\n<td class=\"status status-success\">Success</td>",
+		"<td>Base/Config/Validate</td>\n<td class=\"status status-warning\">Warning</td>",
+		"<td>Base/Env/CollectEnvVars</td>\n<td class=\"status status-error\">Error</td>",
+	}
+	remaining := observed
+	for _, expected := range expectedInOrder {
+		index := strings.Index(remaining, expected)
+		if index == -1 {
+			t.Fatal("Expected to find", expected, "after the previous rows in:\n", observed)
+		}
+		remaining = remaining[index+len(expected):]
+	}
+
+	if strings.Contains(observed, "<script>") {
+		t.Error("Expected task summaries to be escaped:\n", observed)
+	}
+	if strings.Contains(observed, "<link") || strings.Contains(observed, "src=") {
+		t.Error("Expected a self-contained page without external resources:\n", observed)
+	}
+}
+
+func Test_getResultsHTMLGroupsByCategory(t *testing.T) {
+	results := []registration.TaskResult{
+		{Task: registration.TasksForIdentifierString("Java/Config/Agent")[0], Result: tasks.Result{Status: tasks.Success}},
+		{Task: registration.TasksForIdentifierString("Base/Config/Collect")[0], Result: tasks.Result{Status: tasks.Info}},
+	}
+
+	observed := getResultsHTML(results)
+
+	base := strings.Index(observed, "<h2>Base</h2>")
+	java := strings.Index(observed, "<h2>Java</h2>")
+	if base == -1 || java == -1 || base > java {
+		t.Error("Expected a Base section followed by a Java section:\n", observed)
+	}
+	if !strings.Contains(observed, "status-info") {
+		t.Error("Expected the Info status to be styled:\n", observed)
+	}
+}
+
+func Test_getResultsHTMLRemediation(t *testing.T) {
+	results := []registration.TaskResult{
+		{Task: registration.TasksForIdentifierString("Base/Collector/ConnectUS")[0], Result: tasks.Result{Status: tasks.Failure, Remediation: tasks.ConfigChangeRemediation()}},
+		{Task: registration.TasksForIdentifierString("Base/Config/Collect")[0], Result: tasks.Result{Status: tasks.Info}},
+	}
+
+	observed := getResultsHTML(results)
+
+	if strings.Count(observed, `<span class="remediation">`) != 1 || !strings.Contains(observed, "Remediation: config change, customer-serviceable</span>") {
+		t.Error("Expected the remediation of the failure only:\n", observed)
+	}
+}
@@ -0,0 +1,112 @@
+package output
+
+import (
+	"encoding/xml"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/config"
+	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
+	"github.com/newrelic/newrelic-diagnostics-cli/registration"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+)
+
+const junitFileExtension = ".xml"
+
+// junitTestSuites is the root element of a JUnit XML report
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Errors    int             `xml:"errors,attr"`
+	Skipped   int             `xml:"skipped,attr"`
+	Time      string          `xml:"time,attr"`
+	Timestamp string          `xml:"timestamp,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Content string `xml:",chardata"`
+}
+
+// getResultsJUnit converts the results of the run into a JUnit XML report with one test case per task.
+// Failure and Error results are reported as failed test cases; every other status passes.
+func getResultsJUnit(data []registration.TaskResult) string {
+	suite := junitTestSuite{
+		Name:      "nrdiag",
+		Time:      "0",
+		Timestamp: OutputNow().UTC().Format("2006-01-02T15:04:05"),
+	}
+
+	for _, taskResult := range data {
+		identifier := taskResult.Task.Identifier()
+		testCase := junitTestCase{
+			Name:      identifier.String(),
+			ClassName: identifier.Category + "." + identifier.Subcategory,
+			Time:      "0",
+		}
+
+		switch taskResult.Result.Status {
+		case tasks.Failure, tasks.Error:
+			content := taskResult.Result.Summary
+			if taskResult.Result.URL != "" {
+				content += "\n" + taskResult.Result.URL
+			}
+			testCase.Failure = &junitFailure{
+				Message: taskResult.Result.Summary,
+				Type:    taskResult.Result.Status.StatusToString(),
+				Content: content,
+			}
+			suite.Failures++
+		case tasks.Warning:
+			// warnings don't fail the pipeline but are kept in the report
+			testCase.SystemOut = "Warning: " + taskResult.Result.Summary
+		default:
+			testCase.SystemOut = taskResult.Result.Summary
+		}
+
+		suite.TestCases = append(suite.TestCases, testCase)
+	}
+	suite.Tests = len(suite.TestCases)
+
+	report := junitTestSuites{
+		Tests:    suite.Tests,
+		Failures: suite.Failures,
+		Suites:   []junitTestSuite{suite},
+	}
+
+	output, err := xml.MarshalIndent(report, "", "	")
+	if err != nil {
+		log.Info("Couldn't save JUnit output: ", err)
+	}
+	return xml.Header + string(output)
+}
+
+func outputJUnit(report string) {
+	junitFile := filepath.Clean(config.Flags.OutputPath + "/" + config.OutputFileName(junitFileExtension))
+	log.Debug("Creating JUnit file:", junitFile)
+	err := os.MkdirAll(config.Flags.OutputPath, 0777)
+	if err != nil {
+		log.Info("Error creating directory", err)
+		log.Info(permissionsError)
+	}
+	_ = ioutil.WriteFile(junitFile, []byte(report), 0644)
+}
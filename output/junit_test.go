@@ -0,0 +1,135 @@
+package output
+
+import (
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/registration"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+)
+
+// junitSchemaElement - the constraints the JUnit XSD (as consumed by Jenkins, GitLab and most CI report UIs)
+// places on an element: the attributes it must carry and the child elements it may contain
+type junitSchemaElement struct {
+	requiredAttrs []string
+	children      []string
+}
+
+var junitSchema = map[string]junitSchemaElement{
+	"testsuites": {children: []string{"testsuite"}},
+	"testsuite":  {requiredAttrs: []string{"name", "tests", "failures", "errors", "skipped", "time", "timestamp"}, children: []string{"properties", "testcase", "system-out", "system-err"}},
+	"testcase":   {requiredAttrs: []string{"name", "classname", "time"}, children: []string{"skipped", "error", "failure", "system-out", "system-err"}},
+	"failure":    {requiredAttrs: []string{"type"}},
+	"system-out": {},
+}
+
+// validateJUnitSchema walks the report and returns the first violation of junitSchema
+func validateJUnitSchema(report string) string {
+	decoder := xml.NewDecoder(strings.NewReader(report))
+	var parents []string
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "report is not well-formed XML: " + err.Error()
+		}
+		switch element := token.(type) {
+		case xml.StartElement:
+			name := element.Name.Local
+			schema, ok := junitSchema[name]
+			if !ok {
+				return "unknown element <" + name + ">"
+			}
+			if len(parents) == 0 && name != "testsuites" {
+				return "root element is <" + name + ">, expected <testsuites>"
+			}
+			if len(parents) > 0 && !tasks.StringInSlice(name, junitSchema[parents[len(parents)-1]].children) {
+				return "<" + name + "> is not allowed in <" + parents[len(parents)-1] + ">"
+			}
+			for _, required := range schema.requiredAttrs {
+				found := false
+				for _, attr := range element.Attr {
+					found = found || attr.Name.Local == required
+				}
+				if !found {
+					return "<" + name + "> is missing the " + required + " attribute"
+				}
+			}
+			parents = append(parents, name)
+		case xml.EndElement:
+			parents = parents[:len(parents)-1]
+		}
+	}
+	return ""
+}
+
+func generateJUnitResults() []registration.TaskResult {
+	results := generateResultArray()
+	results[1].Result = tasks.Result{
+		Status:  tasks.Warning,
+		Summary: "Multiple config files found",
+	}
+	results[2].Result = tasks.Result{
+		Status:  tasks.Failure,
+		Summary: "There was an error connecting to collector.newrelic.com",
+		URL:     "https://docs.newrelic.com/docs/new-relic-solutions/get-started/networks/",
+	}
+	return append(results, registration.TaskResult{
+		Task: registration.TasksForIdentifierString("Base/Env/CollectEnvVars")[0],
+		Result: tasks.Result{
+			Status:  tasks.Error,
+			Summary: "Unable to read the environment",
+		},
+	})
+}
+
+func Test_getResultsJUnit(t *testing.T) {
+	OutputNow = func() time.Time {
+		return time.Date(2000, 12, 15, 17, 8, 00, 0, time.UTC)
+	}
+
+	observed := getResultsJUnit(generateJUnitResults())
+
+	if violation := validateJUnitSchema(observed); violation != "" {
+		t.Fatal("JUnit report does not match the schema:", violation, "\n", observed)
+	}
+
+	var report junitTestSuites
+	if err := xml.Unmarshal([]byte(observed), &report); err != nil {
+		t.Fatal("Unable to parse JUnit report:", err)
+	}
+	if report.Tests != 4 || report.Failures != 2 {
+		t.Errorf("Expected 4 tests and 2 failures, got %d tests and %d failures", report.Tests, report.Failures)
+	}
+	if len(report.Suites) != 1 || report.Suites[0].Timestamp != "2000-12-15T17:08:00" {
+		t.Fatal("Expected a single test suite with the run date as timestamp:", observed)
+	}
+
+	expected := []junitTestCase{
+		{Name: "Base/Config/Collect", ClassName: "Base.Config", Time: "0", SystemOut: "4 config files(s) found"},
+		{Name: "Base/Config/Validate", ClassName: "Base.Config", Time: "0", SystemOut: "Warning: Multiple config files found"},
+		{Name: "Base/Collector/ConnectUS", ClassName: "Base.Collector", Time: "0", Failure: &junitFailure{
+			Message: "There was an error connecting to collector.newrelic.com",
+			Type:    "Failure",
+			Content: "There was an error connecting to collector.newrelic.com\nhttps://docs.newrelic.com/docs/new-relic-solutions/get-started/networks/",
+		}},
+		{Name: "Base/Env/CollectEnvVars", ClassName: "Base.Env", Time: "0", Failure: &junitFailure{
+			Message: "Unable to read the environment",
+			Type:    "Error",
+			Content: "Unable to read the environment",
+		}},
+	}
+	for i, testCase := range report.Suites[0].TestCases {
+		if testCase.Name != expected[i].Name || testCase.ClassName != expected[i].ClassName || testCase.SystemOut != expected[i].SystemOut {
+			t.Errorf("Test case %d: expected %+v, got %+v", i, expected[i], testCase)
+		}
+		if (testCase.Failure == nil) != (expected[i].Failure == nil) || (testCase.Failure != nil && *testCase.Failure != *expected[i].Failure) {
+			t.Errorf("Test case %s: expected failure %+v, got %+v", testCase.Name, expected[i].Failure, testCase.Failure)
+		}
+	}
+}
@@ -0,0 +1,241 @@
+package output
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/output/color"
+
+	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
+)
+
+// manifestName is the path of the manifest in the zip file, next to the files it lists
+const manifestName = "nrdiag-output/manifest.json"
+
+// BundleManifest is the manifest.json of the zip file, listing every other file in it to tell whether the zip file
+// was corrupted or altered since nrdiag wrote it
+type BundleManifest struct {
+	Files []ManifestFile
+	// Checksum is the SHA-256 checksum of Files marshaled as JSON, changing when the manifest itself is edited
+	Checksum string
+}
+
+// ManifestFile is a file of the zip file as nrdiag wrote it
+type ManifestFile struct {
+	Name   string
+	Size   int64
+	SHA256 string
+}
+
+// manifestEntry is a file being written to the zip file, hashed as it is written
+type manifestEntry struct {
+	name string
+	size int64
+	hash hash.Hash
+}
+
+func (e *manifestEntry) Write(p []byte) (int, error) {
+	e.size += int64(len(p))
+	return e.hash.Write(p)
+}
+
+// manifestEntries are the files written to the zip file since the last call of CreateZip
+var manifestEntries = struct {
+	sync.Mutex
+	entries []*manifestEntry
+}{}
+
+func resetManifest() {
+	manifestEntries.Lock()
+	defer manifestEntries.Unlock()
+	manifestEntries.entries = nil
+}
+
+// createZipEntry - adds a file to the zip file like zip.Writer.CreateHeader, and to its manifest
+func createZipEntry(zipfile *zip.Writer, header *zip.FileHeader) (io.Writer, error) {
+	writer, err := zipfile.CreateHeader(header)
+	if err != nil {
+		return nil, err
+	}
+	entry := &manifestEntry{name: header.Name, hash: sha256.New()}
+	manifestEntries.Lock()
+	manifestEntries.entries = append(manifestEntries.entries, entry)
+	manifestEntries.Unlock()
+	return io.MultiWriter(writer, entry), nil
+}
+
+// newManifest - the manifest of the files written so far, in the order they were written
+func newManifest() BundleManifest {
+	manifestEntries.Lock()
+	defer manifestEntries.Unlock()
+	manifest := BundleManifest{Files: []ManifestFile{}}
+	for _, entry := range manifestEntries.entries {
+		manifest.Files = append(manifest.Files, ManifestFile{
+			Name:   entry.name,
+			Size:   entry.size,
+			SHA256: hex.EncodeToString(entry.hash.Sum(nil)),
+		})
+	}
+	manifest.Checksum = manifestChecksum(manifest.Files)
+	return manifest
+}
+
+func manifestChecksum(files []ManifestFile) string {
+	marshaled, _ := json.Marshal(files)
+	sum := sha256.Sum256(marshaled)
+	return hex.EncodeToString(sum[:])
+}
+
+// writeManifest - adds manifest.json to the zip file, once every other file is in it
+func writeManifest(zipfile *zip.Writer) {
+	manifest := newManifest()
+	marshaled, err := json.MarshalIndent(manifest, "", "	")
+	if err != nil {
+		log.Info("Error creating the manifest of the zip file: ", err)
+		return
+	}
+	writer, err := zipfile.CreateHeader(&zip.FileHeader{Name: manifestName, Method: zip.Deflate, Modified: time.Now()})
+	if err != nil {
+		log.Info("Error writing the manifest to the zip file: ", err)
+		return
+	}
+	if _, err = writer.Write(marshaled); err != nil {
+		log.Info("Error writing the manifest to the zip file: ", err)
+		return
+	}
+	log.Info("Zip file manifest checksum: " + manifest.Checksum)
+}
+
+// verifyOutput is where -verify prints the outcome
+var verifyOutput io.Writer = os.Stdout
+
+// BundleVerification is the comparison of the files of a zip file with its manifest
+type BundleVerification struct {
+	Checksum string
+	// ChecksumMatches is false when the manifest was edited after nrdiag wrote it
+	ChecksumMatches bool
+	Verified        int
+	// Modified are the files whose size or checksum differ from the manifest, Missing the files of the manifest not in
+	// the zip file and Added the files of the zip file not in the manifest
+	Modified []string
+	Missing  []string
+	Added    []string
+}
+
+// Intact - whether the zip file is the one nrdiag wrote
+func (v BundleVerification) Intact() bool {
+	return v.ChecksumMatches && len(v.Modified) == 0 && len(v.Missing) == 0 && len(v.Added) == 0
+}
+
+// VerifyBundle - recomputes the size and checksum of every file of an nrdiag zip file and compares them with its
+// manifest.json. It returns an error when the zip file can't be read or has no manifest
+func VerifyBundle(path string) (BundleVerification, error) {
+	reader, err := zip.OpenReader(path)
+	if err != nil {
+		return BundleVerification{}, err
+	}
+	defer reader.Close()
+
+	var manifest BundleManifest
+	var found bool
+	for _, file := range reader.File {
+		if file.Name != manifestName {
+			continue
+		}
+		found = true
+		if err := readManifest(file, &manifest); err != nil {
+			return BundleVerification{}, fmt.Errorf("unable to read %s: %w", manifestName, err)
+		}
+	}
+	if !found {
+		return BundleVerification{}, errors.New(path + " has no " + manifestName + ", it was not written by this version of nrdiag or the manifest was removed")
+	}
+
+	verification := BundleVerification{
+		Checksum:        manifest.Checksum,
+		ChecksumMatches: manifestChecksum(manifest.Files) == manifest.Checksum,
+	}
+	expected := make(map[string]ManifestFile)
+	for _, file := range manifest.Files {
+		expected[file.Name] = file
+	}
+	for _, file := range reader.File {
+		if file.Name == manifestName {
+			continue
+		}
+		want, ok := expected[file.Name]
+		if !ok {
+			verification.Added = append(verification.Added, file.Name)
+			continue
+		}
+		delete(expected, file.Name)
+		got, err := hashZipFile(file)
+		if err != nil || got != want {
+			verification.Modified = append(verification.Modified, file.Name)
+			continue
+		}
+		verification.Verified++
+	}
+	for name := range expected {
+		verification.Missing = append(verification.Missing, name)
+	}
+	sort.Strings(verification.Missing)
+	return verification, nil
+}
+
+func readManifest(file *zip.File, manifest *BundleManifest) error {
+	content, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer content.Close()
+	return json.NewDecoder(content).Decode(manifest)
+}
+
+// hashZipFile - the size and checksum of a file of the zip file. A corrupted file fails the CRC-32 check of the zip
+// format while it is read
+func hashZipFile(file *zip.File) (ManifestFile, error) {
+	content, err := file.Open()
+	if err != nil {
+		return ManifestFile{}, err
+	}
+	defer content.Close()
+	hash := sha256.New()
+	size, err := io.Copy(hash, content)
+	if err != nil {
+		return ManifestFile{}, err
+	}
+	return ManifestFile{Name: file.Name, Size: size, SHA256: hex.EncodeToString(hash.Sum(nil))}, nil
+}
+
+// WriteVerification prints the files of the zip file that don't match its manifest, then whether it is intact
+func WriteVerification(path string, verification BundleVerification) {
+	if !verification.ChecksumMatches {
+		fmt.Fprintln(verifyOutput, color.ColorString(color.LightRed, "manifest.json was modified: its checksum doesn't match the files it lists"))
+	}
+	for _, name := range verification.Modified {
+		fmt.Fprintf(verifyOutput, "%s - %s\n", color.ColorString(color.LightRed, "Modified"), name)
+	}
+	for _, name := range verification.Missing {
+		fmt.Fprintf(verifyOutput, "%s - %s\n", color.ColorString(color.LightRed, "Missing"), name)
+	}
+	for _, name := range verification.Added {
+		fmt.Fprintf(verifyOutput, "%s - %s\n", color.ColorString(color.LightRed, "Not in manifest"), name)
+	}
+	if verification.Intact() {
+		fmt.Fprintln(verifyOutput, color.ColorString(color.Green, fmt.Sprintf("%s is intact: its %d files match manifest.json", path, verification.Verified)))
+	} else {
+		fmt.Fprintln(verifyOutput, color.ColorString(color.LightRed, path+" doesn't match its manifest.json, it was corrupted or altered after nrdiag wrote it"))
+	}
+	fmt.Fprintln(verifyOutput, "Manifest checksum: "+verification.Checksum)
+}
@@ -0,0 +1,173 @@
+package output
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// writeTestBundle - writes a zip file of the files the way nrdiag does, with its manifest
+func writeTestBundle(t *testing.T, path string, files map[string]string) {
+	t.Helper()
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	zipfile := zip.NewWriter(file)
+	resetManifest()
+	for _, name := range []string{"nrdiag-output/nrdiag-output.json", "nrdiag-output/Include/app.log"} {
+		writer, err := createZipEntry(zipfile, &zip.FileHeader{Name: name, Method: zip.Deflate})
+		if err != nil {
+			t.Fatal(err)
+		}
+		io.WriteString(writer, files[name])
+	}
+	writeManifest(zipfile)
+	if err := zipfile.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// rewriteBundle - copies a zip file, passing the content of each file through edit, which drops the files it returns false for
+func rewriteBundle(t *testing.T, from string, to string, edit func(name string, content []byte) ([]byte, bool)) {
+	t.Helper()
+	reader, err := zip.OpenReader(from)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+	file, err := os.Create(to)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	zipfile := zip.NewWriter(file)
+	for _, entry := range reader.File {
+		content, _ := entry.Open()
+		original, _ := io.ReadAll(content)
+		content.Close()
+		edited, keep := edit(entry.Name, original)
+		if !keep {
+			continue
+		}
+		writer, _ := zipfile.Create(entry.Name)
+		writer.Write(edited)
+	}
+	if err := zipfile.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestVerifyBundle(t *testing.T) {
+	dir := t.TempDir()
+	bundle := filepath.Join(dir, "nrdiag-output.zip")
+	writeTestBundle(t, bundle, map[string]string{
+		"nrdiag-output/nrdiag-output.json": `{"RunDate": "2026-10-14T08:00:00Z"}`,
+		"nrdiag-output/Include/app.log":    "started\nlistening on :8080\n",
+	})
+
+	tests := []struct {
+		name string
+		edit func(name string, content []byte) ([]byte, bool)
+		want BundleVerification
+	}{
+		{
+			name: "should verify an unchanged zip file",
+			edit: func(name string, content []byte) ([]byte, bool) { return content, true },
+			want: BundleVerification{ChecksumMatches: true, Verified: 2},
+		},
+		{
+			name: "should report a modified file",
+			edit: func(name string, content []byte) ([]byte, bool) {
+				if name == "nrdiag-output/Include/app.log" {
+					return bytes.ToUpper(content), true
+				}
+				return content, true
+			},
+			want: BundleVerification{ChecksumMatches: true, Verified: 1, Modified: []string{"nrdiag-output/Include/app.log"}},
+		},
+		{
+			name: "should report a missing file",
+			edit: func(name string, content []byte) ([]byte, bool) {
+				return content, name != "nrdiag-output/nrdiag-output.json"
+			},
+			want: BundleVerification{ChecksumMatches: true, Verified: 1, Missing: []string{"nrdiag-output/nrdiag-output.json"}},
+		},
+		{
+			name: "should report an edited manifest",
+			edit: func(name string, content []byte) ([]byte, bool) {
+				if name == manifestName {
+					return bytes.Replace(content, []byte(`"Size": 27`), []byte(`"Size": 28`), 1), true
+				}
+				return content, true
+			},
+			want: BundleVerification{Verified: 1, Modified: []string{"nrdiag-output/Include/app.log"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			edited := filepath.Join(dir, "edited.zip")
+			rewriteBundle(t, bundle, edited, tt.edit)
+			got, err := VerifyBundle(edited)
+			if err != nil {
+				t.Fatal("VerifyBundle() error:", err)
+			}
+			got.Checksum = ""
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("VerifyBundle() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifyBundle_Added(t *testing.T) {
+	dir := t.TempDir()
+	bundle := filepath.Join(dir, "nrdiag-output.zip")
+	writeTestBundle(t, bundle, map[string]string{})
+	reader, _ := zip.OpenReader(bundle)
+	edited, _ := os.Create(filepath.Join(dir, "edited.zip"))
+	zipfile := zip.NewWriter(edited)
+	for _, entry := range reader.File {
+		zipfile.Copy(entry)
+	}
+	writer, _ := zipfile.Create("nrdiag-output/extra.sh")
+	io.WriteString(writer, "#!/bin/sh\n")
+	zipfile.Close()
+	edited.Close()
+	reader.Close()
+
+	got, err := VerifyBundle(filepath.Join(dir, "edited.zip"))
+	if err != nil {
+		t.Fatal("VerifyBundle() error:", err)
+	}
+	if got.Intact() || !reflect.DeepEqual(got.Added, []string{"nrdiag-output/extra.sh"}) {
+		t.Errorf("VerifyBundle() = %+v, want nrdiag-output/extra.sh added", got)
+	}
+
+	var printed bytes.Buffer
+	verifyOutput = &printed
+	defer func() { verifyOutput = os.Stdout }()
+	WriteVerification("edited.zip", got)
+	if !strings.Contains(printed.String(), "nrdiag-output/extra.sh") || !strings.Contains(printed.String(), "doesn't match its manifest.json") {
+		t.Errorf("WriteVerification() printed %q", printed.String())
+	}
+}
+
+func TestVerifyBundle_NoManifest(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "old.zip")
+	file, _ := os.Create(path)
+	zipfile := zip.NewWriter(file)
+	zipfile.Create("nrdiag-output/nrdiag-output.json")
+	zipfile.Close()
+	file.Close()
+
+	if _, err := VerifyBundle(path); err == nil || !strings.Contains(err.Error(), "has no nrdiag-output/manifest.json") {
+		t.Errorf("VerifyBundle() error = %v, want the manifest reported missing", err)
+	}
+}
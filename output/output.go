@@ -7,6 +7,7 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strconv"
 	"sync"
 
@@ -32,6 +33,10 @@ func WriteSummary(data []registration.TaskResult) {
 		}
 	}
 
+	if config.Flags.CollectOnly {
+		log.Info(color.ColorString(color.White, "\nCollect-only mode: the config files, logs and environment details were collected, no checks were run"))
+	}
+
 	if len(failures) == 0 {
 		log.Info(color.ColorString(color.White, "\nNo Issues Found\n"))
 	} else {
@@ -64,9 +69,47 @@ func WriteSummary(data []registration.TaskResult) {
 	}
 }
 
-//WriteOutputFile will output a JSON file with the results of the run
+//WriteOutputFile will output a JSON file with the results of the run, and a JUnit XML, HTML, SARIF or YAML file when requested with -output-format
 func WriteOutputFile(data []registration.TaskResult) {
-	outputJSON(getResultsJSON(data))
+	data = sortResultsByIdentifier(data)
+	if !config.Flags.NoRedact {
+		data = redactResults(data)
+	}
+	if config.Flags.Anonymize {
+		data = anonymizeResults(data)
+	}
+	summary := summarizeResults(data)
+	recommendations := recommendActions(data)
+	filteredData := filterResultsByMinStatus(data)
+	// only nrdiag-output.json references the payload files, the other formats are read by tools expecting them inline
+	jsonData := externalizePayloads(data, config.Flags.PayloadMaxSize)
+	filteredJSONData := filterResultsByMinStatus(jsonData)
+	if len(filteredData) != len(data) {
+		// the zip file keeps every result, only the file next to it is trimmed down
+		unfilteredResultsJSON = getResultsJSON(jsonData, summary, recommendations)
+	}
+	outputJSON(getResultsJSON(filteredJSONData, summary, recommendations))
+	switch config.Flags.OutputFormat {
+	case config.JUnitOutputFormat:
+		outputJUnit(getResultsJUnit(data))
+	case config.HTMLOutputFormat:
+		outputHTML(getResultsHTML(data))
+	case config.SARIFOutputFormat:
+		outputSARIF(getResultsSARIF(data))
+	case config.YAMLOutputFormat:
+		outputYAML(getResultsYAML(filteredData, summary, recommendations))
+	}
+}
+
+// sortResultsByIdentifier returns a copy of the results sorted by task identifier. Tasks run in parallel finish in no
+// particular order, sorting keeps the output files the same from one run to the next
+func sortResultsByIdentifier(data []registration.TaskResult) []registration.TaskResult {
+	sorted := make([]registration.TaskResult, len(data))
+	copy(sorted, data)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Task.Identifier().String() < sorted[j].Task.Identifier().String()
+	})
+	return sorted
 }
 
 // ProcessFilesChannel - reads from the channels for files to copy and deals with them
@@ -106,7 +149,7 @@ func ProcessFilesChannel(zipfile *zip.Writer, wg *sync.WaitGroup) {
 		}
 
 	}
-	copyFilesToZip(zipfile, taskFiles)
+	copyCollectedFilesToZip(zipfile, taskFiles)
 
 	log.Debug("Files channel closed")
 	log.Debug("Decrementing wait group in processFilesChannel.")
@@ -130,12 +173,36 @@ func CopySingleFileToZip(zipfile *zip.Writer, filename string) {
 	copyFilesToZip(zipfile, filelist)
 }
 
-// CopyOutputToZip - takes the nrdiag-output.json and adds it to the zip file
+// CopyOutputToZip - takes the nrdiag-output.json and the payload files it references, and the JUnit, HTML, SARIF or YAML report if one was requested, and adds them to the zip file
 func CopyOutputToZip(zipfile *zip.Writer) {
-	CopySingleFileToZip(zipfile, "nrdiag-output.json")
+	if unfilteredResultsJSON != "" {
+		stream := make(chan string, 1)
+		stream <- unfilteredResultsJSON
+		close(stream)
+		copyFilesToZip(zipfile, []tasks.FileCopyEnvelope{
+			{Path: config.Flags.OutputPath + config.OutputFileName(".json"), Stream: stream},
+		})
+	} else {
+		CopySingleFileToZip(zipfile, config.OutputFileName(".json"))
+	}
+	copyPayloadFilesToZip(zipfile)
+	switch config.Flags.OutputFormat {
+	case config.JUnitOutputFormat:
+		CopySingleFileToZip(zipfile, config.OutputFileName(junitFileExtension))
+	case config.HTMLOutputFormat:
+		CopySingleFileToZip(zipfile, config.OutputFileName(htmlFileExtension))
+	case config.SARIFOutputFormat:
+		CopySingleFileToZip(zipfile, config.OutputFileName(sarifFileExtension))
+	case config.YAMLOutputFormat:
+		CopySingleFileToZip(zipfile, config.OutputFileName(yamlFileExtension))
+	}
 }
 
 func CopyFileListToZip(zipfile *zip.Writer) {
+	// the original paths of the collected files may hold hostnames
+	if config.Flags.Anonymize {
+		anonymizeFile(config.Flags.OutputPath + "/nrdiag-filelist.txt")
+	}
 	CopySingleFileToZip(zipfile, "nrdiag-filelist.txt")
 }
 
@@ -195,6 +262,9 @@ func WriteLineResults() []registration.TaskResult {
 	var outputResults []registration.TaskResult
 
 	for result := range registration.Work.ResultsChannel {
+		if config.Flags.Stream {
+			streamResult(result)
+		}
 		if filteredResult(result.Result.StatusToString()) {
 			payload := ""
 			if result.Result.Status == tasks.Info {
@@ -233,7 +303,7 @@ func WriteLineResults() []registration.TaskResult {
 		filteredOutput := color.ColorString(color.Gray, strconv.Itoa(filteredCounter)+partialMessage+filteredToString(filtered))
 		log.Info(filteredOutput)
 	}
-	log.Info("See nrdiag-output.json for full results.")
+	log.Info("See " + config.OutputFileName(".json") + " for full results.")
 	log.Debug("Done with writeLineResults")
 	return outputResults
 }
@@ -22,10 +22,14 @@ import (
 const permissionsError = "\n------Error creating output files.------\nEnsure you have rights for creating files in the local directory or specify a different output directory with -output-path\nA 'permission denied' error may be solved by re-running this program prefixed by the command 'sudo -E'. The '-E' option will help preserve the environment variables needed for running this program."
 
 type resultsOutput struct {
-	RunDate       time.Time
-	NRDiagVersion string
-	Configuration interface{}
-	Results       []registration.TaskResult
+	Summary resultsSummary
+	// Recommendations are the actions to take to fix the issues found, most important first
+	Recommendations []recommendation
+	RunDate         time.Time
+	NRDiagVersion   string
+	Configuration   interface{}
+	Results         []registration.TaskResult
+	Timings         []taskTiming `json:",omitempty"`
 }
 
 type (
@@ -35,14 +39,52 @@ type (
 // wee bit of a hack for testing
 var OutputNow = time.Now
 
+// unfilteredResultsJSON holds every result of the run when -min-status left some of them out of nrdiag-output.json
+var unfilteredResultsJSON string
+
+// filterResultsByMinStatus returns the results at or above the -min-status severity, or all of them if the flag is not set
+func filterResultsByMinStatus(data []registration.TaskResult) []registration.TaskResult {
+	if config.Flags.MinStatus == "" {
+		return data
+	}
+	minStatus, err := tasks.StatusFromString(config.Flags.MinStatus)
+	if err != nil {
+		log.Debug("Ignoring -min-status:", err)
+		return data
+	}
+
+	filtered := []registration.TaskResult{}
+	for _, taskResult := range data {
+		if taskResult.Result.Status.IsAtLeast(minStatus) {
+			filtered = append(filtered, taskResult)
+		}
+	}
+	return filtered
+}
+
 //getResultsJSON takes in array of Result structs along with bool for indentation to be users. Outputs JSON of Results array -- if indented is true, output is nicely formatted.
-func getResultsJSON(data []registration.TaskResult) string {
+// The summary and recommendations are passed in so they can cover results left out of data by -min-status
+func getResultsJSON(data []registration.TaskResult, summary resultsSummary, recommendations []recommendation) string {
 
 	outputData := resultsOutput{
-		RunDate:       OutputNow(),
-		NRDiagVersion: config.Version,
-		Configuration: config.Flags,
-		Results:       data,
+		Summary:         summary,
+		Recommendations: recommendations,
+		RunDate:         OutputNow(),
+		NRDiagVersion:   config.Version,
+		Configuration:   config.Flags,
+		Results:         data,
+	}
+	if config.Flags.Timings {
+		outputData.Timings = getTimings(data)
+	}
+	// the flags hold the proxy and collector hosts given on the command line
+	if config.Flags.Anonymize {
+		configuration, err := anonymizePayload(config.Flags)
+		if err != nil {
+			log.Debug("Unable to anonymize the configuration:", err)
+			configuration = json.RawMessage(`"` + redactedValue + `"`)
+		}
+		outputData.Configuration = configuration
 	}
 
 	output, err := json.MarshalIndent(outputData, "", "	")
@@ -53,7 +95,7 @@ func getResultsJSON(data []registration.TaskResult) string {
 }
 
 func outputJSON(json string) {
-	jsonFile := filepath.Clean(config.Flags.OutputPath + "/nrdiag-output.json")
+	jsonFile := filepath.Clean(config.Flags.OutputPath + "/" + config.OutputFileName(".json"))
 	log.Debug("Creating json file:", jsonFile)
 	err := os.MkdirAll(config.Flags.OutputPath, 0777)
 	if err != nil {
@@ -69,13 +111,14 @@ func CreateZip() *zip.Writer {
 		log.Info("Error creating directory", err)
 		log.Info(permissionsError)
 	}
-	zipfile, err := os.Create(config.Flags.OutputPath + "/nrdiag-output.zip")
+	zipfile, err := os.Create(config.Flags.OutputPath + "/" + config.OutputFileName(".zip"))
 	if err != nil {
 		log.Info("Error creating zip file", err)
 		log.Info(permissionsError)
 	}
 
 	// Create a new zip archive.
+	resetManifest()
 	w := zip.NewWriter(zipfile)
 	return w
 }
@@ -83,6 +126,8 @@ func CreateZip() *zip.Writer {
 func CloseZip(zipfile *zip.Writer) {
 	// All done, now close the zip file
 	log.Debug("Done executing tasks, closing zip file")
+	// the manifest lists every other file, it goes in last
+	writeManifest(zipfile)
 	zipErr := zipfile.Close()
 	if zipErr != nil {
 		log.Info("error closing zip file: ", zipErr)
@@ -96,6 +141,17 @@ func mapContains(set map[string]struct{}, item string) bool {
 
 // CopyFilesToZip - Copies files to the zip archive
 func copyFilesToZip(dst *zip.Writer, filesToZip []tasks.FileCopyEnvelope) {
+	addFilesToZip(dst, filesToZip, false)
+}
+
+// copyCollectedFilesToZip - copies the files collected by the tasks to the zip archive, anonymized with -anonymize
+func copyCollectedFilesToZip(dst *zip.Writer, filesToZip []tasks.FileCopyEnvelope) {
+	addFilesToZip(dst, filesToZip, config.Flags.Anonymize)
+}
+
+// addFilesToZip - copies files to the zip archive, replacing their hostnames and IP addresses when anonymize is set.
+// The output files are anonymized as they are written, anonymizing them again would replace the pseudonyms
+func addFilesToZip(dst *zip.Writer, filesToZip []tasks.FileCopyEnvelope, anonymize bool) {
 
 	for _, envelope := range filesToZip {
 		//Add filepath and name to text file
@@ -107,8 +163,11 @@ func copyFilesToZip(dst *zip.Writer, filesToZip []tasks.FileCopyEnvelope) {
 				Method: zip.Deflate,
 			}
 
-			writer, _ := dst.CreateHeader(&header)
+			writer, _ := createZipEntry(dst, &header)
 			for s := range envelope.Stream {
+				if anonymize {
+					s = currentAnonymizer().anonymizeString(s)
+				}
 				_, _ = io.WriteString(writer, s)
 			}
 		} else {
@@ -142,14 +201,18 @@ func copyFilesToZip(dst *zip.Writer, filesToZip []tasks.FileCopyEnvelope) {
 			header.Method = zip.Deflate
 
 			// write zip file header
-			writer, err := dst.CreateHeader(header)
+			writer, err := createZipEntry(dst, header)
 
 			if err != nil {
 				log.Info("Error writing results to zip file: ", err)
 				return
 			}
 
-			_, err = io.Copy(writer, fileHandle)
+			if anonymize {
+				err = anonymizeCopy(writer, fileHandle, envelope.Path)
+			} else {
+				_, err = io.Copy(writer, fileHandle)
+			}
 			if err != nil {
 				log.Info("Error writing file into zip: ", err)
 			}
@@ -259,7 +322,7 @@ func WriteFileToZip(path string, info os.FileInfo, zipfile *zip.Writer) error {
 
 	header.Name = filepath.ToSlash("nrdiag-output/Include/" + path)
 	header.Method = zip.Deflate
-	writer, ok := zipfile.CreateHeader(header)
+	writer, ok := createZipEntry(zipfile, header)
 	if ok != nil {
 		log.Info("Error writing results to zip file: ", ok)
 		return ok
@@ -1,13 +1,19 @@
 package output
 
 import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
 	"io/ioutil"
+	"path/filepath"
 	"reflect"
 	"runtime"
+	"sort"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/newrelic/newrelic-diagnostics-cli/config"
 	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
 	"github.com/newrelic/newrelic-diagnostics-cli/registration"
 	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
@@ -24,7 +30,7 @@ func Test_GetResultsJSON(t *testing.T) {
 	if runtime.GOOS == "windows" {
 		expected = readFile("fixtures/test-output_windows.json")
 	}
-	observed := getResultsJSON(fakeResults)
+	observed := getResultsJSON(fakeResults, summarizeResults(fakeResults), recommendActions(fakeResults))
 
 	//if you intended to make changes to the output JSON:
 	// - uncomment the next line of code for one run
@@ -37,6 +43,18 @@ func Test_GetResultsJSON(t *testing.T) {
 		t.Error("Expected:", expected, "Observed:", observed)
 	}
 }
+func Test_GetResultsJSON_payloadVersion(t *testing.T) {
+	results := generateResultArray()
+	results[2].Result.Payload = []string{"200"}
+	results[2].Result.PayloadVersion = 1
+
+	observed := getResultsJSON(results, summarizeResults(results), recommendActions(results))
+
+	if strings.Count(observed, `"PayloadVersion": 1`) != 1 {
+		t.Error("Expected the PayloadVersion of the versioned payload only, observed:", observed)
+	}
+}
+
 func Test_StreamDataOutput(t *testing.T) {
 
 	dataChannel := make(chan string)
@@ -61,7 +79,7 @@ func Test_StreamDataOutput(t *testing.T) {
 	if runtime.GOOS == "windows" {
 		expected = readFile("fixtures/test-stream-output_windows.json")
 	}
-	observed := getResultsJSON(fakeResults)
+	observed := getResultsJSON(fakeResults, summarizeResults(fakeResults), recommendActions(fakeResults))
 
 	//if you intended to make changes to the output JSON:
 	// - uncomment the next line of code for one run
@@ -149,3 +167,98 @@ func readFile(file string) string {
 	replaced := strings.Replace(string(content), "\r\n", "\n", -1)
 	return replaced
 }
+
+func Test_filterResultsByMinStatus(t *testing.T) {
+	defer func() { config.Flags.MinStatus = "" }()
+	results := generateJUnitResults() // Success, Warning, Failure, Error
+
+	tests := []struct {
+		minStatus string
+		want      []tasks.Status
+	}{
+		{minStatus: "", want: []tasks.Status{tasks.Success, tasks.Warning, tasks.Failure, tasks.Error}},
+		{minStatus: "warning", want: []tasks.Status{tasks.Warning, tasks.Failure, tasks.Error}},
+		{minStatus: "Failure", want: []tasks.Status{tasks.Failure, tasks.Error}},
+		{minStatus: "error", want: []tasks.Status{tasks.Error}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.minStatus, func(t *testing.T) {
+			config.Flags.MinStatus = tt.minStatus
+			var got []tasks.Status
+			for _, taskResult := range filterResultsByMinStatus(results) {
+				got = append(got, taskResult.Result.Status)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("filterResultsByMinStatus() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_WriteOutputFileWithMinStatus(t *testing.T) {
+	outputPath := t.TempDir() + string(filepath.Separator)
+	config.Flags.OutputPath = outputPath
+	config.Flags.MinStatus = "error"
+	defer func() {
+		config.Flags.OutputPath = ""
+		config.Flags.MinStatus = ""
+		unfilteredResultsJSON = ""
+	}()
+
+	WriteOutputFile(generateJUnitResults())
+
+	var written struct {
+		Summary struct {
+			Total        int
+			StatusCounts statusCounts
+		}
+		Results []json.RawMessage
+	}
+	content, _ := ioutil.ReadFile(outputPath + "nrdiag-output.json")
+	if err := json.Unmarshal(content, &written); err != nil {
+		t.Fatal("Unable to parse nrdiag-output.json:", err)
+	}
+	if len(written.Results) != 1 {
+		t.Errorf("Expected only the Error result in nrdiag-output.json, got %d results", len(written.Results))
+	}
+	if written.Summary.Total != 4 || written.Summary.StatusCounts.Warning != 1 {
+		t.Errorf("Expected the summary to count every result of the run, got %+v", written.Summary)
+	}
+
+	var zipped bytes.Buffer
+	zipfile := zip.NewWriter(&zipped)
+	CopyOutputToZip(zipfile)
+	zipfile.Close()
+
+	reader, err := zip.NewReader(bytes.NewReader(zipped.Bytes()), int64(zipped.Len()))
+	if err != nil || len(reader.File) != 1 || reader.File[0].Name != "nrdiag-output/nrdiag-output.json" {
+		t.Fatal("Expected the zip file to contain nrdiag-output.json:", err)
+	}
+	zippedJSON, _ := reader.File[0].Open()
+	var bundled struct{ Results []json.RawMessage }
+	if err := json.NewDecoder(zippedJSON).Decode(&bundled); err != nil {
+		t.Fatal("Unable to parse the zipped nrdiag-output.json:", err)
+	}
+	if len(bundled.Results) != 4 {
+		t.Errorf("Expected every result in the zip file, got %d results", len(bundled.Results))
+	}
+}
+
+func Test_sortResultsByIdentifier(t *testing.T) {
+	results := generateJUnitResults()
+	// the order the results arrive in when tasks run in parallel
+	shuffled := []registration.TaskResult{results[3], results[0], results[2], results[1]}
+
+	sorted := sortResultsByIdentifier(shuffled)
+
+	var got []string
+	for _, taskResult := range sorted {
+		got = append(got, taskResult.Task.Identifier().String())
+	}
+	if !sort.StringsAreSorted(got) || len(got) != len(results) {
+		t.Errorf("sortResultsByIdentifier() = %v, want the identifiers sorted", got)
+	}
+	if shuffled[0].Task.Identifier().String() != results[3].Task.Identifier().String() {
+		t.Error("sortResultsByIdentifier() should not reorder the results passed in")
+	}
+}
@@ -0,0 +1,87 @@
+package output
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/config"
+	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
+	"github.com/newrelic/newrelic-diagnostics-cli/registration"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+)
+
+// PayloadFile takes the place of a payload larger than -payload-max-size in nrdiag-output.json. The path is relative
+// to nrdiag-output.json, both next to it in -output-path and in the zip file
+type PayloadFile struct {
+	PayloadFile string
+	Size        int
+}
+
+// payloadFiles are the paths of the payload files written by the last call of externalizePayloads, added to the zip
+// file by CopyOutputToZip
+var payloadFiles []string
+
+// payloadFilesDir is the directory of the payload files, named after the output files so that runs sharing an
+// -output-path don't overwrite each other's
+func payloadFilesDir() string {
+	return config.OutputFileName("-payloads")
+}
+
+// externalizePayloads returns a copy of the results where each payload larger than maxSizeKB KB, as it would be
+// marshaled in nrdiag-output.json, is written to its own file and replaced by a PayloadFile. A maxSizeKB of 0 keeps
+// every payload inline
+func externalizePayloads(data []registration.TaskResult, maxSizeKB int) []registration.TaskResult {
+	payloadFiles = nil
+	if maxSizeKB <= 0 {
+		return data
+	}
+	externalized := make([]registration.TaskResult, len(data))
+	for i, taskResult := range data {
+		externalized[i] = taskResult
+		if taskResult.Result.Payload == nil {
+			continue
+		}
+		payload, err := json.MarshalIndent(taskResult.Result.Payload, "", "	")
+		if err != nil || len(payload) <= maxSizeKB*1024 {
+			continue
+		}
+		path := filepath.ToSlash(filepath.Join(payloadFilesDir(), payloadFileName(taskResult.Task.Identifier())))
+		if err := writePayloadFile(path, payload); err != nil {
+			// the payload stays inline rather than being lost
+			log.Info("Couldn't save the payload of", taskResult.Task.Identifier().String(), "to its own file:", err)
+			continue
+		}
+		payloadFiles = append(payloadFiles, path)
+		externalized[i].Result.Payload = PayloadFile{PayloadFile: path, Size: len(payload)}
+	}
+	return externalized
+}
+
+// payloadFileName is the file name of the payload of a task, e.g. Base_Config_Validate.json
+func payloadFileName(identifier tasks.Identifier) string {
+	return strings.ReplaceAll(identifier.String(), "/", "_") + ".json"
+}
+
+func writePayloadFile(path string, payload []byte) error {
+	fullPath := filepath.Join(config.Flags.OutputPath, path)
+	if err := os.MkdirAll(filepath.Dir(fullPath), 0777); err != nil {
+		return err
+	}
+	return os.WriteFile(fullPath, payload, 0644)
+}
+
+// copyPayloadFilesToZip adds the payload files to the zip file, at the same path relative to nrdiag-output.json
+func copyPayloadFilesToZip(zipfile *zip.Writer) {
+	var envelopes []tasks.FileCopyEnvelope
+	for _, path := range payloadFiles {
+		envelopes = append(envelopes, tasks.FileCopyEnvelope{
+			Path: filepath.Join(config.Flags.OutputPath, path),
+			// the directory of the identifier is the one the file is stored in
+			Identifier: path,
+		})
+	}
+	copyFilesToZip(zipfile, envelopes)
+}
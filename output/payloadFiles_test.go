@@ -0,0 +1,82 @@
+package output
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/config"
+	"github.com/newrelic/newrelic-diagnostics-cli/registration"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+)
+
+func Test_WriteOutputFileWithPayloadMaxSize(t *testing.T) {
+	outputPath := t.TempDir() + string(filepath.Separator)
+	config.Flags.OutputPath = outputPath
+	config.Flags.PayloadMaxSize = 1
+	defer func() {
+		config.Flags.OutputPath = ""
+		config.Flags.PayloadMaxSize = 0
+		payloadFiles = nil
+	}()
+
+	largePayload := []string{strings.Repeat("x", 2048)}
+	WriteOutputFile([]registration.TaskResult{
+		{
+			Task:   registration.TasksForIdentifierString("Base/Config/Collect")[0],
+			Result: tasks.Result{Status: tasks.Info, Payload: largePayload},
+		},
+		{
+			Task:   registration.TasksForIdentifierString("Base/Config/ProxyDetect")[0],
+			Result: tasks.Result{Status: tasks.Success, Payload: []string{"small"}},
+		},
+	})
+
+	var written struct {
+		Results []struct {
+			Identifier tasks.Identifier
+			Result     struct{ Payload json.RawMessage }
+		}
+	}
+	content, _ := os.ReadFile(outputPath + "nrdiag-output.json")
+	if err := json.Unmarshal(content, &written); err != nil || len(written.Results) != 2 {
+		t.Fatal("Unable to parse nrdiag-output.json:", err)
+	}
+	var reference PayloadFile
+	if err := json.Unmarshal(written.Results[0].Result.Payload, &reference); err != nil || reference.PayloadFile != "nrdiag-output-payloads/Base_Config_Collect.json" {
+		t.Fatalf("Expected the large payload to reference its file, got %s", written.Results[0].Result.Payload)
+	}
+	var inline []string
+	if err := json.Unmarshal(written.Results[1].Result.Payload, &inline); err != nil || inline[0] != "small" {
+		t.Errorf("Expected the small payload to stay inline, got %s", written.Results[1].Result.Payload)
+	}
+
+	var externalized []string
+	payload, _ := os.ReadFile(filepath.Join(outputPath, reference.PayloadFile))
+	if err := json.Unmarshal(payload, &externalized); err != nil || externalized[0] != largePayload[0] || reference.Size != len(payload) {
+		t.Errorf("Expected the payload file to hold the payload, got %d bytes: %v", len(payload), err)
+	}
+
+	var zipped bytes.Buffer
+	zipfile := zip.NewWriter(&zipped)
+	CopyOutputToZip(zipfile)
+	zipfile.Close()
+	reader, err := zip.NewReader(bytes.NewReader(zipped.Bytes()), int64(zipped.Len()))
+	if err != nil || len(reader.File) != 2 || reader.File[1].Name != "nrdiag-output/nrdiag-output-payloads/Base_Config_Collect.json" {
+		t.Fatal("Expected the zip file to contain the payload file next to nrdiag-output.json:", err)
+	}
+}
+
+func Test_externalizePayloadsDisabled(t *testing.T) {
+	data := []registration.TaskResult{{
+		Task:   registration.TasksForIdentifierString("Base/Config/Collect")[0],
+		Result: tasks.Result{Status: tasks.Info, Payload: strings.Repeat("x", 4096)},
+	}}
+	if got := externalizePayloads(data, 0); got[0].Result.Payload != data[0].Result.Payload || len(payloadFiles) != 0 {
+		t.Errorf("Expected every payload to stay inline without -payload-max-size, got %v", got[0].Result.Payload)
+	}
+}
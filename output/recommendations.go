@@ -0,0 +1,180 @@
+package output
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/registration"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+)
+
+// recommendation is an action to take to fix the issues found, listed in nrdiag-output.json in the order to take them
+type recommendation struct {
+	Rank   int
+	Action string
+	Reason string
+	// Status is the most severe status of the results behind the recommendation, Failure or Warning
+	Status tasks.Status
+	// Tasks are the identifiers of the results the action addresses, the causes first
+	Tasks []string
+	URL   string `json:",omitempty"`
+}
+
+// recommendationRule recommends an action when one of its causes has a Failure or Warning result. The results of its
+// effects, e.g. the collector connections failing through a misconfigured proxy, are attached to the recommendation
+// instead of being recommended on their own
+type recommendationRule struct {
+	causes  []string
+	effects []string
+	action  string
+	reason  string
+}
+
+// collectorConnections are the tasks that fail when nrdiag can't reach New Relic
+var collectorConnections = []string{
+	"Base/Collector/ConnectUS",
+	"Base/Collector/ConnectEU",
+	"Base/Collector/ConnectGov",
+	"Base/Collector/ConnectTLS",
+	"Base/Collector/ConnectDualStack",
+	"Base/Collector/ConnectLogApi",
+	"Base/Collector/ConnectNerdGraph",
+	"Base/Collector/ConnectOTLP",
+	"Base/Collector/LargePayload",
+	"Base/Collector/Compression",
+	"Base/Collector/RecentData",
+}
+
+// recommendationRules are ranked from the issues that keep the other checks from being meaningful to the ones that
+// only affect themselves. The results no rule applies to are recommended after them, the failures first
+var recommendationRules = []recommendationRule{
+	{
+		causes:  []string{"Base/Config/Validate"},
+		effects: []string{"Base/Config/AppName", "Base/Config/LicenseKey", "Base/Config/LogLevel", "Base/Config/RegionDetect", "Base/Config/ValidateLicenseKey"},
+		action:  "Fix the syntax errors of the agent config file",
+		reason:  "The agent can't apply the settings of a config file it can't parse, and the settings checks read the file the same way.",
+	},
+	{
+		causes:  []string{"Base/Config/Permissions", "Base/Env/MAC", "Base/Env/SELinux"},
+		effects: []string{"Base/Log/Copy", "Base/Log/ReportingTo"},
+		action:  "Let the agent read its config file and write its log files",
+		reason:  "An agent denied access to its files runs with its defaults or not at all, and logs nothing about it.",
+	},
+	{
+		causes:  []string{"Base/Config/ProxyDetect"},
+		effects: collectorConnections,
+		action:  "Fix the proxy settings before retrying the collector connection checks",
+		reason:  "Every connection to New Relic goes through the proxy, the connection checks can't succeed until it is set up right.",
+	},
+	{
+		causes:  []string{"Base/Collector/DNSResolve"},
+		effects: collectorConnections,
+		action:  "Fix the DNS resolution of the New Relic endpoints before retrying the collector connection checks",
+		reason:  "The agent can't connect to the endpoints it can't resolve.",
+	},
+	{
+		causes:  []string{"Base/Env/ClockSkew"},
+		effects: []string{"Base/Collector/ConnectTLS", "Base/Collector/RecentData"},
+		action:  "Synchronize the system clock, e.g. with NTP",
+		reason:  "A clock that is off makes TLS certificates look invalid and the data reported land outside of the queried time windows.",
+	},
+	{
+		causes:  collectorConnections,
+		effects: []string{"Base/Log/ReportingTo"},
+		action:  "Allow the outbound HTTPS connections to the New Relic endpoints through the firewall",
+		reason:  "The agent can't report any data until it can connect to New Relic.",
+	},
+	{
+		causes:  []string{"Base/Config/LicenseKey", "Base/Config/ValidateLicenseKey", "Base/Config/LicenseKeyValidate", "Base/Config/RegionDetect"},
+		effects: []string{"Base/Collector/RecentData"},
+		action:  "Set the license key of the account the data should report to, for the region of that account",
+		reason:  "New Relic rejects the data sent with a missing, invalid or wrong region license key.",
+	},
+}
+
+// recommendActions - derives the ranked actions to take from the Failure and Warning results of the run. Each result
+// is addressed by a single recommendation, the first rule applying to it as a cause or an effect
+func recommendActions(data []registration.TaskResult) []recommendation {
+	issues := make(map[string]tasks.Result)
+	for _, taskResult := range data {
+		if isIssue(taskResult.Result.Status) {
+			issues[taskResult.Task.Identifier().String()] = taskResult.Result
+		}
+	}
+
+	recommendations := []recommendation{}
+	addressed := make(map[string]bool)
+	for _, rule := range recommendationRules {
+		causes := unaddressedIssues(rule.causes, issues, addressed)
+		if len(causes) == 0 {
+			continue
+		}
+		identifiers := append(causes, unaddressedIssues(rule.effects, issues, addressed)...)
+		recommendations = append(recommendations, recommendation{
+			Action: rule.action,
+			Reason: rule.reason,
+			Status: worstStatus(identifiers, issues),
+			Tasks:  identifiers,
+			URL:    issues[causes[0]].URL,
+		})
+	}
+
+	var remaining []string
+	for identifier := range issues {
+		if !addressed[identifier] {
+			remaining = append(remaining, identifier)
+		}
+	}
+	sort.Slice(remaining, func(i, j int) bool {
+		left, right := issues[remaining[i]].Status, issues[remaining[j]].Status
+		if left != right {
+			return left.Severity() > right.Severity()
+		}
+		return remaining[i] < remaining[j]
+	})
+	for _, identifier := range remaining {
+		result := issues[identifier]
+		recommendations = append(recommendations, recommendation{
+			Action: "Address the " + result.StatusToString() + " result of " + identifier,
+			Reason: firstLine(result.Summary),
+			Status: result.Status,
+			Tasks:  []string{identifier},
+			URL:    result.URL,
+		})
+	}
+
+	for i := range recommendations {
+		recommendations[i].Rank = i + 1
+	}
+	return recommendations
+}
+
+func isIssue(status tasks.Status) bool {
+	return status == tasks.Failure || status == tasks.Warning
+}
+
+// unaddressedIssues - the identifiers with an issue not addressed yet, in the rule's order, which are now addressed
+func unaddressedIssues(identifiers []string, issues map[string]tasks.Result, addressed map[string]bool) []string {
+	var found []string
+	for _, identifier := range identifiers {
+		if _, ok := issues[identifier]; ok && !addressed[identifier] {
+			found = append(found, identifier)
+			addressed[identifier] = true
+		}
+	}
+	return found
+}
+
+func worstStatus(identifiers []string, issues map[string]tasks.Result) tasks.Status {
+	worst := tasks.Warning
+	for _, identifier := range identifiers {
+		if issues[identifier].Status.Severity() > worst.Severity() {
+			worst = issues[identifier].Status
+		}
+	}
+	return worst
+}
+
+func firstLine(summary string) string {
+	return strings.TrimSpace(strings.SplitN(strings.TrimSpace(summary), "\n", 2)[0])
+}
@@ -0,0 +1,130 @@
+package output
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/registration"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+)
+
+// resultsWithStatuses - a result of each status for the tasks, in identifier order as the output gets them
+func resultsWithStatuses(statuses map[string]tasks.Status) []registration.TaskResult {
+	var identifiers []string
+	for identifier := range statuses {
+		identifiers = append(identifiers, identifier)
+	}
+	sort.Strings(identifiers)
+	var results []registration.TaskResult
+	for _, identifier := range identifiers {
+		results = append(results, registration.TaskResult{
+			Task: registration.TasksForIdentifierString(identifier)[0],
+			Result: tasks.Result{
+				Status:  statuses[identifier],
+				Summary: identifier + " summary\nsecond line",
+				URL:     "https://docs.newrelic.com/" + identifier,
+			},
+		})
+	}
+	return results
+}
+
+// recommendedTasks - the tasks of each recommendation, by rank
+func recommendedTasks(recommendations []recommendation) [][]string {
+	var ranked [][]string
+	for i, recommendation := range recommendations {
+		if recommendation.Rank != i+1 {
+			return nil
+		}
+		ranked = append(ranked, recommendation.Tasks)
+	}
+	return ranked
+}
+
+func Test_recommendActions(t *testing.T) {
+	tests := []struct {
+		name     string
+		statuses map[string]tasks.Status
+		want     [][]string
+	}{
+		{
+			name: "proxy before the collector connections",
+			statuses: map[string]tasks.Status{
+				"Base/Collector/ConnectEU":  tasks.Failure,
+				"Base/Collector/ConnectUS":  tasks.Failure,
+				"Base/Config/ProxyDetect":   tasks.Warning,
+				"Base/Collector/DNSResolve": tasks.Success,
+				"Java/Config/Agent":         tasks.Warning,
+			},
+			want: [][]string{
+				{"Base/Config/ProxyDetect", "Base/Collector/ConnectUS", "Base/Collector/ConnectEU"},
+				{"Java/Config/Agent"},
+			},
+		},
+		{
+			name: "collector connections without a cause",
+			statuses: map[string]tasks.Status{
+				"Base/Collector/ConnectUS": tasks.Failure,
+				"Base/Config/ProxyDetect":  tasks.Success,
+				"Base/Log/ReportingTo":     tasks.Warning,
+			},
+			want: [][]string{{"Base/Collector/ConnectUS", "Base/Log/ReportingTo"}},
+		},
+		{
+			name: "each result addressed once",
+			statuses: map[string]tasks.Status{
+				"Base/Collector/ConnectUS":  tasks.Failure,
+				"Base/Collector/DNSResolve": tasks.Failure,
+				"Base/Config/ProxyDetect":   tasks.Failure,
+				"Base/Config/Validate":      tasks.Failure,
+				"Base/Config/LicenseKey":    tasks.Warning,
+			},
+			want: [][]string{
+				{"Base/Config/Validate", "Base/Config/LicenseKey"},
+				{"Base/Config/ProxyDetect", "Base/Collector/ConnectUS"},
+				{"Base/Collector/DNSResolve"},
+			},
+		},
+		{
+			name: "failures before warnings without a rule",
+			statuses: map[string]tasks.Status{
+				"Base/Env/CollectEnvVars": tasks.Error,
+				"Base/Config/Collect":     tasks.Info,
+				"Node/Config/Agent":       tasks.Warning,
+				"Java/Config/Agent":       tasks.Warning,
+				"Python/Config/Agent":     tasks.Failure,
+			},
+			want: [][]string{{"Python/Config/Agent"}, {"Java/Config/Agent"}, {"Node/Config/Agent"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := recommendedTasks(recommendActions(resultsWithStatuses(tt.statuses))); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("recommendActions() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_recommendActions_details(t *testing.T) {
+	recommendations := recommendActions(resultsWithStatuses(map[string]tasks.Status{
+		"Base/Config/ProxyDetect":  tasks.Warning,
+		"Base/Collector/ConnectUS": tasks.Failure,
+		"Java/Config/Agent":        tasks.Warning,
+	}))
+
+	proxy := recommendations[0]
+	if proxy.Status != tasks.Failure || proxy.URL != "https://docs.newrelic.com/Base/Config/ProxyDetect" || !strings.HasPrefix(proxy.Action, "Fix the proxy settings") {
+		t.Errorf("recommendActions() = %+v, expected the proxy action with the URL of its cause and the status of the failing connection", proxy)
+	}
+	java := recommendations[1]
+	if java.Action != "Address the Warning result of Java/Config/Agent" || java.Reason != "Java/Config/Agent summary" {
+		t.Errorf("recommendActions() = %+v, expected the first line of the summary of the result", java)
+	}
+
+	if none := recommendActions(generateResultArray()[:1]); none == nil || len(none) != 0 {
+		t.Errorf("recommendActions() = %#v, expected an empty list without issues", none)
+	}
+}
@@ -0,0 +1,181 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"regexp"
+
+	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
+	"github.com/newrelic/newrelic-diagnostics-cli/registration"
+)
+
+// redactedValue replaces the secrets found in results, it matches the placeholder used for container environment variables
+const redactedValue = "_REDACTED_"
+
+type redactionRule struct {
+	name        string
+	pattern     *regexp.Regexp
+	replacement string
+}
+
+// redactionRules are applied in order to every summary and payload string written to the output files.
+// The setting names are the ones the agents and infrastructure agent use for license keys, API keys and proxy passwords
+var redactionRules = []redactionRule{
+	{
+		name:        "credentials in URLs",
+		pattern:     regexp.MustCompile(`(?i)([a-z][a-z0-9+.-]*://[^:/@\s"']+:)[^@\s/"']+@`),
+		replacement: "${1}" + redactedValue + "@",
+	},
+	{
+		name:        "secret settings",
+		pattern:     regexp.MustCompile(`(?i)((?:new_relic_license_key|new_relic_api_key|new_relic_insert_key|nria_license_key|license_key|licensekey|api_key|apikey|insert_key|proxy_pass|proxy_password|proxypassword|proxy_pwd)['"]?\s*[:=]\s*['"]?)[^\s'",;}]+`),
+		replacement: "${1}" + redactedValue,
+	},
+	{
+		name:        "secret command line options",
+		pattern:     regexp.MustCompile(`((?:^|[\s\[])--?(?:proxy-pw|proxy-user|api-key)(?:=|\s+))[^\s"'\]]+`),
+		replacement: "${1}" + redactedValue,
+	},
+	{
+		name:        "license keys ending in NRAL",
+		pattern:     regexp.MustCompile(`\b[0-9a-zA-Z]{36}NRAL\b`),
+		replacement: redactedValue,
+	},
+	{
+		name:        "40 character license keys",
+		pattern:     regexp.MustCompile(`(?i)\b(?:eu01xx[0-9a-f]{34}|[0-9a-f]{40})\b`),
+		replacement: redactedValue,
+	},
+	{
+		name:        "user and ingest API keys",
+		pattern:     regexp.MustCompile(`\bNR[A-Z]{2}-[A-Za-z0-9]{27,32}\b`),
+		replacement: redactedValue,
+	},
+}
+
+// sensitiveEnvVarKey matches the environment variable names, e.g. in the Base/Env/CollectEnvVars payload, whose value
+// is redacted whatever it looks like
+var sensitiveEnvVarKey = regexp.MustCompile(`^[A-Z0-9_]*(PASSWORD|PASSWD|SECRET|TOKEN)[A-Z0-9_]*$|^[A-Z0-9_]*(_PASS|_PWD|_KEY|_CREDENTIALS)$`)
+
+// redactString applies every redaction rule to a single string
+func redactString(s string) string {
+	for _, rule := range redactionRules {
+		s = rule.pattern.ReplaceAllString(s, rule.replacement)
+	}
+	return s
+}
+
+// redactResults returns a copy of the results with secrets scrubbed from summaries and payloads. The results
+// held in memory are left intact: tasks, usage data and -a need the actual license keys
+func redactResults(data []registration.TaskResult) []registration.TaskResult {
+	redacted := make([]registration.TaskResult, len(data))
+	for i, taskResult := range data {
+		redacted[i] = taskResult
+		redacted[i].Result.Summary = redactString(taskResult.Result.Summary)
+		if taskResult.Result.Payload == nil {
+			continue
+		}
+		payload, err := redactPayload(taskResult.Result.Payload)
+		if err != nil {
+			// better to leave the payload out than to write it unredacted
+			log.Debug("Unable to redact the payload of", taskResult.Task.Identifier().String(), ":", err)
+			redacted[i].Result.Payload = redactedValue
+			continue
+		}
+		redacted[i].Result.Payload = payload
+	}
+	return redacted
+}
+
+// redactPayload marshals a payload the way the output file would and redacts every string in it, map keys
+// included since some payloads are keyed by license key
+func redactPayload(payload interface{}) (json.RawMessage, error) {
+	marshaled, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	return redactJSON(marshaled)
+}
+
+// redactJSON redacts every string of a JSON document, replacing the whole value of the sensitive environment variables
+func redactJSON(document []byte) (json.RawMessage, error) {
+	return rewriteJSON(document, func(value string, sensitive bool) string {
+		if sensitive && value != "" {
+			value = redactedValue
+		}
+		return redactString(value)
+	})
+}
+
+// rewriteJSON rewrites a JSON document token by token, which keeps the fields in their original order. Every string,
+// map keys included, is replaced with what rewrite returns for it, sensitive being true for the values of the keys
+// that are sensitive environment variable names
+func rewriteJSON(document []byte, rewrite func(value string, sensitive bool) string) (json.RawMessage, error) {
+	decoder := json.NewDecoder(bytes.NewReader(document))
+	decoder.UseNumber()
+
+	type container struct {
+		object bool
+		tokens int
+	}
+	var stack []container
+	var output bytes.Buffer
+	// set when the key just read is a sensitive environment variable name
+	sensitiveValue := false
+
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		delim, isDelim := token.(json.Delim)
+		closing := isDelim && (delim == '}' || delim == ']')
+		isKey, sensitive := false, false
+		if len(stack) > 0 && !closing {
+			parent := &stack[len(stack)-1]
+			if parent.object && parent.tokens%2 == 1 {
+				output.WriteByte(':')
+				sensitive = sensitiveValue
+			} else if parent.tokens > 0 {
+				output.WriteByte(',')
+			}
+			isKey = parent.object && parent.tokens%2 == 0
+			parent.tokens++
+		}
+		sensitiveValue = false
+
+		switch value := token.(type) {
+		case json.Delim:
+			output.WriteRune(rune(value))
+			if closing {
+				stack = stack[:len(stack)-1]
+			} else {
+				stack = append(stack, container{object: value == '{'})
+			}
+		case string:
+			if isKey {
+				sensitiveValue = sensitiveEnvVarKey.MatchString(value)
+			}
+			encoded, _ := json.Marshal(rewrite(value, sensitive))
+			output.Write(encoded)
+		case json.Number:
+			output.WriteString(value.String())
+		case bool:
+			encoded, _ := json.Marshal(value)
+			output.Write(encoded)
+		case nil:
+			output.WriteString("null")
+		}
+	}
+
+	if len(stack) != 0 {
+		return nil, errors.New("unexpected end of JSON payload")
+	}
+	return json.RawMessage(output.Bytes()), nil
+}
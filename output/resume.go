@@ -0,0 +1,99 @@
+package output
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
+	"github.com/newrelic/newrelic-diagnostics-cli/registration"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+)
+
+// resumeFile is the part of nrdiag-output.json carried over by -resume. The payloads are kept as they were written,
+// the tasks depending on them run again rather than reading them back
+type resumeFile struct {
+	RunDate time.Time
+	Results []struct {
+		Identifier tasks.Identifier
+		Override   bool
+		// RunDate is set on the results the earlier run had itself carried over
+		RunDate time.Time
+		Result  struct {
+			Status         string
+			Summary        string
+			URL            string
+			Payload        json.RawMessage
+			PayloadVersion int
+			Remediation    *tasks.Remediation
+		}
+	}
+}
+
+// ReadResumeFile returns the results of an earlier nrdiag-output.json, each with the date of the run it comes from. The
+// results of the tasks not registered in this build, e.g. those of another OS, are left out
+func ReadResumeFile(path string) ([]registration.TaskResult, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var run resumeFile
+	if err := json.Unmarshal(content, &run); err != nil {
+		return nil, fmt.Errorf("%s is not an nrdiag output file: %s", path, err.Error())
+	}
+	if run.Results == nil {
+		return nil, errors.New(path + " has no Results, it is not an nrdiag output file")
+	}
+
+	var results []registration.TaskResult
+	for _, result := range run.Results {
+		identifier := result.Identifier.String()
+		status, err := tasks.StatusFromString(result.Result.Status)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", identifier, err.Error())
+		}
+		matched := registration.TasksForIdentifierString(identifier)
+		if len(matched) != 1 {
+			log.Debug("Not resuming the result of", identifier, ", the task is not registered")
+			continue
+		}
+		runDate := result.RunDate
+		if runDate.IsZero() {
+			runDate = run.RunDate
+		}
+		taskResult := registration.TaskResult{
+			Task:        matched[0],
+			WasOverride: result.Override,
+			RunDate:     runDate,
+			Result: tasks.Result{
+				Status:         status,
+				Summary:        result.Result.Summary,
+				URL:            result.Result.URL,
+				PayloadVersion: result.Result.PayloadVersion,
+				Remediation:    result.Result.Remediation,
+			},
+		}
+		if len(result.Result.Payload) > 0 && string(result.Result.Payload) != "null" {
+			taskResult.Result.Payload = result.Result.Payload
+		}
+		results = append(results, taskResult)
+	}
+	return results, nil
+}
+
+// MergeResumedResults returns the results of this run followed by the resumed results of the tasks that didn't run again
+func MergeResumedResults(current []registration.TaskResult, resumed []registration.TaskResult) []registration.TaskResult {
+	ran := make(map[string]bool, len(current))
+	for _, taskResult := range current {
+		ran[taskResult.Task.Identifier().String()] = true
+	}
+	merged := append([]registration.TaskResult{}, current...)
+	for _, taskResult := range resumed {
+		if !ran[taskResult.Task.Identifier().String()] {
+			merged = append(merged, taskResult)
+		}
+	}
+	return merged
+}
@@ -0,0 +1,85 @@
+package output
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/registration"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+)
+
+const resumeOutput = `{
+	"RunDate": "2024-01-03T10:00:00Z",
+	"Results": [
+		{"Identifier": {"Category": "Base", "Subcategory": "Config", "Name": "Collect"}, "Override": false, "Result": {"Status": "Success", "Summary": "2 config files(s) found", "Payload": [{"FileName": "newrelic.yml"}]}},
+		{"Identifier": {"Category": "Base", "Subcategory": "Collector", "Name": "ConnectUS"}, "Override": false, "RunDate": "2024-01-02T10:00:00Z", "Result": {"Status": "Failure", "URL": "https://docs.newrelic.com/", "Payload": null}},
+		{"Identifier": {"Category": "Not", "Subcategory": "A", "Name": "Task"}, "Override": false, "Result": {"Status": "Info"}}
+	]
+}`
+
+func writeResumeFile(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "nrdiag-output.json")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func Test_ReadResumeFile(t *testing.T) {
+	results, err := ReadResumeFile(writeResumeFile(t, resumeOutput))
+	if err != nil {
+		t.Fatalf("ReadResumeFile() error = %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("ReadResumeFile() = %+v, expected the results of the 2 registered tasks", results)
+	}
+
+	collect := results[0]
+	if collect.Task.Identifier().String() != "Base/Config/Collect" || collect.Result.Status != tasks.Success || string(collect.Result.Payload.(json.RawMessage)) != `[{"FileName": "newrelic.yml"}]` {
+		t.Errorf("ReadResumeFile() = %+v, expected the status and payload of Base/Config/Collect", collect)
+	}
+	if !collect.RunDate.Equal(time.Date(2024, 1, 3, 10, 0, 0, 0, time.UTC)) {
+		t.Errorf("ReadResumeFile() run date = %s, expected the date of the earlier run", collect.RunDate)
+	}
+	connect := results[1]
+	if connect.Result.Payload != nil || connect.Result.URL != "https://docs.newrelic.com/" {
+		t.Errorf("ReadResumeFile() = %+v, expected the URL and no payload", connect)
+	}
+	if !connect.RunDate.Equal(time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)) {
+		t.Errorf("ReadResumeFile() run date = %s, expected the date of the run the result was resumed from", connect.RunDate)
+	}
+
+	for _, content := range []string{`{"RunDate": "2024-01-03T10:00:00Z"}`, `not json`, `{"Results": [{"Identifier": {"Category": "Base", "Subcategory": "Config", "Name": "Collect"}, "Result": {"Status": "Broken"}}]}`} {
+		if _, err := ReadResumeFile(writeResumeFile(t, content)); err == nil {
+			t.Errorf("ReadResumeFile() of %s, expected an error", content)
+		}
+	}
+}
+
+func Test_MergeResumedResults(t *testing.T) {
+	resumed, err := ReadResumeFile(writeResumeFile(t, resumeOutput))
+	if err != nil {
+		t.Fatal(err)
+	}
+	current := []registration.TaskResult{{
+		Task:   registration.TasksForIdentifierString("Base/Collector/ConnectUS")[0],
+		Result: tasks.Result{Status: tasks.Success},
+	}}
+
+	merged := MergeResumedResults(current, resumed)
+	if len(merged) != 2 || merged[0].Result.Status != tasks.Success || merged[1].Task.Identifier().String() != "Base/Config/Collect" {
+		t.Fatalf("MergeResumedResults() = %+v, expected the new result of Base/Collector/ConnectUS and the earlier one of Base/Config/Collect", merged)
+	}
+
+	observed := getResultsJSON(merged, summarizeResults(merged), recommendActions(merged))
+	if strings.Count(observed, `"RunDate": "2024-01-03T10:00:00Z"`) != 1 || !strings.Contains(observed, `"FileName": "newrelic.yml"`) {
+		t.Error("Expected the run date and payload of the resumed result only, observed:", observed)
+	}
+	if timings := getTimings(merged); len(timings) != 1 {
+		t.Errorf("getTimings() = %+v, expected the timing of the task run only", timings)
+	}
+}
@@ -0,0 +1,44 @@
+package output
+
+import (
+	"archive/zip"
+	"path/filepath"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/config"
+	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+)
+
+func runLogPath() string {
+	return filepath.Clean(config.Flags.OutputPath + "/" + config.OutputFileName(log.RunLogFileExtension))
+}
+
+// OpenRunLog - starts writing every logged message to nrdiag-output.log in the output path, whatever the -log-level, so
+// the zip file always has the debug context of the run. The run log is redacted like the output files unless -no-redact,
+// and anonymized like them with -anonymize
+func OpenRunLog() {
+	var redact func(string) string
+	if !config.Flags.NoRedact {
+		redact = redactString
+	}
+	if config.Flags.Anonymize {
+		redact = anonymizeRunLog(redact)
+	}
+	err := log.OpenRunLog(runLogPath(), redact)
+	if err != nil {
+		log.Info("Error creating the run log", err)
+		log.Info(permissionsError)
+	}
+}
+
+// CopyRunLogToZip - closes the run log and adds it to the zip file. Messages logged afterwards only show on screen
+func CopyRunLogToZip(zipfile *zip.Writer) {
+	err := log.CloseRunLog()
+	if err != nil {
+		log.Debug("Error closing the run log:", err)
+	}
+	if config.Flags.Anonymize {
+		anonymizeFile(runLogPath())
+	}
+	copyFilesToZip(zipfile, []tasks.FileCopyEnvelope{{Path: runLogPath()}})
+}
@@ -0,0 +1,129 @@
+package output
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/config"
+	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
+	"github.com/newrelic/newrelic-diagnostics-cli/registration"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+)
+
+const (
+	sarifFileExtension = ".sarif"
+	sarifSchema        = "https://json.schemastore.org/sarif-2.1.0.json"
+	sarifVersion       = "2.1.0"
+)
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version,omitempty"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+	HelpURI          string       `json:"helpUri,omitempty"`
+}
+
+type sarifResult struct {
+	RuleID    string       `json:"ruleId"`
+	RuleIndex int          `json:"ruleIndex"`
+	Level     string       `json:"level"`
+	Message   sarifMessage `json:"message"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+// sarifLevel maps a task status to a SARIF result level
+func sarifLevel(status tasks.Status) string {
+	switch status {
+	case tasks.Failure, tasks.Error:
+		return "error"
+	case tasks.Warning:
+		return "warning"
+	default:
+		return "note"
+	}
+}
+
+// getResultsSARIF converts the results of the run into a SARIF log. Each task that did not succeed becomes a rule
+// identified by the task identifier, with its documentation URL as help URI, and a result holding the task summary.
+// Successful tasks and tasks that did not apply to this system (None) are not findings and are left out.
+func getResultsSARIF(data []registration.TaskResult) string {
+	driver := sarifDriver{
+		Name:           "nrdiag",
+		Version:        config.Version,
+		InformationURI: "https://github.com/newrelic/newrelic-diagnostics-cli",
+		Rules:          []sarifRule{},
+	}
+	results := []sarifResult{}
+
+	for _, taskResult := range data {
+		if taskResult.Result.Status == tasks.Success || taskResult.Result.Status == tasks.None {
+			continue
+		}
+		driver.Rules = append(driver.Rules, sarifRule{
+			ID:               taskResult.Task.Identifier().String(),
+			ShortDescription: sarifMessage{Text: taskResult.Task.Explain()},
+			HelpURI:          taskResult.Result.URL,
+		})
+		// SARIF consumers reject results without message text
+		message := taskResult.Result.Summary
+		if message == "" {
+			message = taskResult.Task.Identifier().String() + " returned " + taskResult.Result.Status.StatusToString()
+		}
+		results = append(results, sarifResult{
+			RuleID:    taskResult.Task.Identifier().String(),
+			RuleIndex: len(driver.Rules) - 1,
+			Level:     sarifLevel(taskResult.Result.Status),
+			Message:   sarifMessage{Text: message},
+		})
+	}
+
+	output, err := json.MarshalIndent(sarifLog{
+		Schema:  sarifSchema,
+		Version: sarifVersion,
+		Runs: []sarifRun{{
+			Tool:    sarifTool{Driver: driver},
+			Results: results,
+		}},
+	}, "", "	")
+	if err != nil {
+		log.Info("Couldn't save SARIF output: ", err)
+	}
+	return string(output)
+}
+
+func outputSARIF(report string) {
+	sarifFile := filepath.Clean(config.Flags.OutputPath + "/" + config.OutputFileName(sarifFileExtension))
+	log.Debug("Creating SARIF file:", sarifFile)
+	err := os.MkdirAll(config.Flags.OutputPath, 0777)
+	if err != nil {
+		log.Info("Error creating directory", err)
+		log.Info(permissionsError)
+	}
+	_ = ioutil.WriteFile(sarifFile, []byte(report), 0644)
+}
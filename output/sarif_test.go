@@ -0,0 +1,69 @@
+package output
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/registration"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+)
+
+func Test_getResultsSARIF(t *testing.T) {
+	results := append(generateJUnitResults(), registration.TaskResult{
+		Task:   registration.TasksForIdentifierString("Base/Config/RegionDetect")[0],
+		Result: tasks.Result{Status: tasks.Info},
+	})
+
+	var observed sarifLog
+	if err := json.Unmarshal([]byte(getResultsSARIF(results)), &observed); err != nil {
+		t.Fatal("Unable to parse SARIF output:", err)
+	}
+
+	if observed.Version != "2.1.0" || observed.Schema != sarifSchema || len(observed.Runs) != 1 {
+		t.Fatalf("Expected a single SARIF 2.1.0 run, got %+v", observed)
+	}
+	driver := observed.Runs[0].Tool.Driver
+	if driver.Name != "nrdiag" {
+		t.Errorf("Expected the nrdiag driver, got %s", driver.Name)
+	}
+
+	expectedRuleIDs := []string{"Base/Config/Validate", "Base/Collector/ConnectUS", "Base/Env/CollectEnvVars", "Base/Config/RegionDetect"}
+	var ruleIDs []string
+	for _, rule := range driver.Rules {
+		ruleIDs = append(ruleIDs, rule.ID)
+		if rule.ShortDescription.Text == "" {
+			t.Errorf("Expected rule %s to be described", rule.ID)
+		}
+	}
+	if !reflect.DeepEqual(ruleIDs, expectedRuleIDs) {
+		t.Errorf("Expected rules for the non-successful tasks %v, got %v", expectedRuleIDs, ruleIDs)
+	}
+	if driver.Rules[1].HelpURI != "https://docs.newrelic.com/docs/new-relic-solutions/get-started/networks/" || driver.Rules[0].HelpURI != "" {
+		t.Errorf("Expected the task URL as help URI, got %+v", driver.Rules)
+	}
+
+	expectedResults := []sarifResult{
+		{RuleID: "Base/Config/Validate", RuleIndex: 0, Level: "warning", Message: sarifMessage{Text: "Multiple config files found"}},
+		{RuleID: "Base/Collector/ConnectUS", RuleIndex: 1, Level: "error", Message: sarifMessage{Text: "There was an error connecting to collector.newrelic.com"}},
+		{RuleID: "Base/Env/CollectEnvVars", RuleIndex: 2, Level: "error", Message: sarifMessage{Text: "Unable to read the environment"}},
+		{RuleID: "Base/Config/RegionDetect", RuleIndex: 3, Level: "note", Message: sarifMessage{Text: "Base/Config/RegionDetect returned Info"}},
+	}
+	if !reflect.DeepEqual(observed.Runs[0].Results, expectedResults) {
+		t.Errorf("Expected results %+v, got %+v", expectedResults, observed.Runs[0].Results)
+	}
+}
+
+func Test_getResultsSARIFWithoutFindings(t *testing.T) {
+	observed := getResultsSARIF(generateResultArray())
+
+	var parsed map[string]interface{}
+	if err := json.Unmarshal([]byte(observed), &parsed); err != nil {
+		t.Fatal("Unable to parse SARIF output:", err)
+	}
+	run := parsed["runs"].([]interface{})[0].(map[string]interface{})
+	// results is required by consumers even when empty, it must not be serialized as null
+	if results, ok := run["results"].([]interface{}); !ok || len(results) != 0 {
+		t.Errorf("Expected an empty results array, got %v", run["results"])
+	}
+}
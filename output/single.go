@@ -0,0 +1,54 @@
+package output
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/config"
+	"github.com/newrelic/newrelic-diagnostics-cli/registration"
+)
+
+// singleOutput is where -single writes the result. The logger moves to stderr with -single so this stays JSON
+var singleOutput io.Writer = os.Stdout
+
+// SingleResult returns the result of the -single task among the results of the run
+func SingleResult(data []registration.TaskResult) (registration.TaskResult, bool) {
+	for _, taskResult := range data {
+		if strings.EqualFold(taskResult.Task.Identifier().String(), strings.TrimSpace(config.Flags.Single)) {
+			return taskResult, true
+		}
+	}
+	return registration.TaskResult{}, false
+}
+
+// WriteSingleResult prints the Result of the -single task as indented JSON. With -v every result of the run is printed
+// instead, in the order the tasks ran, so the dependencies come before the task that needed them
+func WriteSingleResult(data []registration.TaskResult) error {
+	target, ok := SingleResult(data)
+	if !ok {
+		return errors.New("no result for " + config.Flags.Single + ", the run was stopped before it completed")
+	}
+	if !config.Flags.NoRedact {
+		data = redactResults(data)
+		target = redactResults([]registration.TaskResult{target})[0]
+	}
+	if config.Flags.Anonymize {
+		data = anonymizeResults(data)
+		target = anonymizeResults([]registration.TaskResult{target})[0]
+	}
+
+	var document interface{} = target.Result
+	if config.Flags.Verbose {
+		document = data
+	}
+	marshaled, err := json.MarshalIndent(document, "", "	")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(singleOutput, string(marshaled))
+	return err
+}
@@ -0,0 +1,77 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/config"
+	"github.com/newrelic/newrelic-diagnostics-cli/registration"
+)
+
+func writeSingleResultTo(t *testing.T, single string, verbose bool, data []registration.TaskResult) (string, error) {
+	var printed bytes.Buffer
+	singleOutput = &printed
+	config.Flags.Single = single
+	config.Flags.Verbose = verbose
+	t.Cleanup(func() {
+		singleOutput = os.Stdout
+		config.Flags.Single = ""
+		config.Flags.Verbose = false
+	})
+	err := WriteSingleResult(data)
+	return printed.String(), err
+}
+
+func Test_WriteSingleResult(t *testing.T) {
+	results := generateJUnitResults()
+	results[2].Result.Payload = map[string]string{"license_key": sampleLicenseKey}
+
+	printed, err := writeSingleResultTo(t, "base/collector/connectus", false, results)
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+
+	var observed map[string]interface{}
+	if err := json.Unmarshal([]byte(printed), &observed); err != nil {
+		t.Fatalf("Expected a single JSON object, got %q: %s", printed, err)
+	}
+	if observed["Status"] != "Failure" || observed["Summary"] != "There was an error connecting to collector.newrelic.com" {
+		t.Errorf("Expected the result of Base/Collector/ConnectUS, got %v", observed)
+	}
+	if strings.Contains(printed, sampleLicenseKey) {
+		t.Errorf("Expected the license key in the payload to be redacted:\n%s", printed)
+	}
+}
+
+func Test_WriteSingleResult_verbose(t *testing.T) {
+	results := generateJUnitResults()
+
+	printed, err := writeSingleResultTo(t, "Base/Collector/ConnectUS", true, results)
+	if err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+
+	var observed []map[string]interface{}
+	if err := json.Unmarshal([]byte(printed), &observed); err != nil {
+		t.Fatalf("Expected a JSON array with -v, got %q: %s", printed, err)
+	}
+	if len(observed) != len(results) {
+		t.Fatalf("Expected the %d results of the run, got %d", len(results), len(observed))
+	}
+	if first, _ := observed[0]["Identifier"].(map[string]interface{}); first["Subcategory"] != "Config" || first["Name"] != "Collect" {
+		t.Errorf("Expected the results in the order the tasks ran, got %v first", observed[0]["Identifier"])
+	}
+}
+
+func Test_WriteSingleResult_missing(t *testing.T) {
+	printed, err := writeSingleResultTo(t, "Base/Collector/ConnectEU", false, generateJUnitResults())
+	if err == nil {
+		t.Error("Expected an error without a result for the -single task")
+	}
+	if printed != "" {
+		t.Errorf("Expected nothing to be printed, got %q", printed)
+	}
+}
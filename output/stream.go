@@ -0,0 +1,45 @@
+package output
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/config"
+	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
+	"github.com/newrelic/newrelic-diagnostics-cli/registration"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+)
+
+// streamOutput is where -stream writes the results. The logger moves to stderr while streaming so this stays NDJSON
+var streamOutput io.Writer = os.Stdout
+
+// streamedResult is the line written by -stream for each completed task
+type streamedResult struct {
+	Identifier string
+	Status     tasks.Status
+	Summary    string
+	URL        string `json:",omitempty"`
+	Override   bool   `json:",omitempty"`
+}
+
+// streamResult writes the result of a single task as one line of JSON
+func streamResult(result registration.TaskResult) {
+	summary := result.Result.Summary
+	if !config.Flags.NoRedact {
+		summary = redactString(summary)
+	}
+	if config.Flags.Anonymize {
+		summary = currentAnonymizer().anonymizeString(summary)
+	}
+	err := json.NewEncoder(streamOutput).Encode(streamedResult{
+		Identifier: result.Task.Identifier().String(),
+		Status:     result.Result.Status,
+		Summary:    summary,
+		URL:        result.Result.URL,
+		Override:   result.WasOverride,
+	})
+	if err != nil {
+		log.Info("Couldn't stream result of", result.Task.Identifier().String(), ":", err)
+	}
+}
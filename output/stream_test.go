@@ -0,0 +1,58 @@
+package output
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/config"
+	"github.com/newrelic/newrelic-diagnostics-cli/registration"
+)
+
+func Test_WriteLineResultsStream(t *testing.T) {
+	var streamed bytes.Buffer
+	originalChannel := registration.Work.ResultsChannel
+	streamOutput = &streamed
+	config.Flags.Stream = true
+	defer func() {
+		registration.Work.ResultsChannel = originalChannel
+		streamOutput = os.Stdout
+		config.Flags.Stream = false
+	}()
+
+	results := generateJUnitResults()
+	registration.Work.ResultsChannel = make(chan registration.TaskResult, len(results))
+	for _, result := range results {
+		registration.Work.ResultsChannel <- result
+	}
+	close(registration.Work.ResultsChannel)
+
+	returned := WriteLineResults()
+	if len(returned) != len(results) {
+		t.Errorf("Expected all %d results to still be returned, got %d", len(results), len(returned))
+	}
+
+	var lines []map[string]interface{}
+	scanner := bufio.NewScanner(&streamed)
+	for scanner.Scan() {
+		var line map[string]interface{}
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			t.Fatalf("Expected one JSON object per line, got %q: %s", scanner.Text(), err)
+		}
+		lines = append(lines, line)
+	}
+	if len(lines) != len(results) {
+		t.Fatalf("Expected %d streamed lines, got %d:\n%s", len(results), len(lines), streamed.String())
+	}
+
+	failure := lines[2]
+	if failure["Identifier"] != "Base/Collector/ConnectUS" || failure["Status"] != "Failure" ||
+		failure["Summary"] != "There was an error connecting to collector.newrelic.com" || failure["URL"] == nil {
+		t.Errorf("Unexpected streamed result: %v", failure)
+	}
+	if _, ok := lines[0]["URL"]; ok {
+		t.Errorf("Expected an empty URL to be omitted: %v", lines[0])
+	}
+}
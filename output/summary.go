@@ -0,0 +1,75 @@
+package output
+
+import (
+	"sort"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/config"
+	"github.com/newrelic/newrelic-diagnostics-cli/registration"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+)
+
+// resultsSummary is the rollup at the top of nrdiag-output.json, so automation can triage a run without going through
+// every result. It is computed from all the results of the run, including those -min-status leaves out of the file
+type resultsSummary struct {
+	Total        int
+	StatusCounts statusCounts
+	// Failing lists the identifiers of the Failure and Error results, sorted
+	Failing     []string
+	WorstStatus tasks.Status
+	// Mode is set when the run was limited to some of the tasks, e.g. "collect-only"
+	Mode string `json:",omitempty"`
+}
+
+// collectOnlyMode - the summary mode of a -collect-only run
+const collectOnlyMode = "collect-only"
+
+// statusCounts is the number of results of each status, from least to most severe
+type statusCounts struct {
+	None    int
+	Success int
+	Info    int
+	Warning int
+	Failure int
+	Error   int
+}
+
+func (c *statusCounts) add(status tasks.Status) {
+	switch status {
+	case tasks.None:
+		c.None++
+	case tasks.Success:
+		c.Success++
+	case tasks.Info:
+		c.Info++
+	case tasks.Warning:
+		c.Warning++
+	case tasks.Failure:
+		c.Failure++
+	case tasks.Error:
+		c.Error++
+	}
+}
+
+// summarizeResults - counts the results of each status and finds the failing ones and the most severe status
+func summarizeResults(data []registration.TaskResult) resultsSummary {
+	summary := resultsSummary{
+		Total:       len(data),
+		Failing:     []string{},
+		WorstStatus: tasks.None,
+	}
+	if config.Flags.CollectOnly {
+		summary.Mode = collectOnlyMode
+	}
+	for _, taskResult := range data {
+		status := taskResult.Result.Status
+		summary.StatusCounts.add(status)
+		if status.IsAtLeast(tasks.Failure) {
+			summary.Failing = append(summary.Failing, taskResult.Task.Identifier().String())
+		}
+		if status.Severity() > summary.WorstStatus.Severity() {
+			summary.WorstStatus = status
+		}
+	}
+	sort.Strings(summary.Failing)
+	return summary
+}
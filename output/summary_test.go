@@ -0,0 +1,44 @@
+package output
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/config"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+)
+
+func Test_summarizeResults(t *testing.T) {
+	observed := summarizeResults(generateJUnitResults())
+
+	expected := resultsSummary{
+		Total:        4,
+		StatusCounts: statusCounts{Success: 1, Warning: 1, Failure: 1, Error: 1},
+		Failing:      []string{"Base/Collector/ConnectUS", "Base/Env/CollectEnvVars"},
+		WorstStatus:  tasks.Error,
+	}
+	if !reflect.DeepEqual(observed, expected) {
+		t.Errorf("summarizeResults() = %+v, want %+v", observed, expected)
+	}
+}
+
+func Test_summarizeResults_noResults(t *testing.T) {
+	observed := summarizeResults(nil)
+
+	if observed.Total != 0 || len(observed.Failing) != 0 || observed.Failing == nil || observed.WorstStatus != tasks.None {
+		t.Errorf("summarizeResults() without results = %+v, want an empty summary with the None status", observed)
+	}
+}
+
+func Test_summarizeResults_collectOnly(t *testing.T) {
+	config.Flags.CollectOnly = true
+	defer func() { config.Flags.CollectOnly = false }()
+
+	if observed := summarizeResults(nil); observed.Mode != "collect-only" {
+		t.Errorf("summarizeResults() mode = %q, want collect-only", observed.Mode)
+	}
+	config.Flags.CollectOnly = false
+	if observed := summarizeResults(nil); observed.Mode != "" {
+		t.Errorf("summarizeResults() mode = %q, want it unset", observed.Mode)
+	}
+}
@@ -0,0 +1,56 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/registration"
+)
+
+// timingsOutput is where the -timings table is printed, kept off stdout like the upload progress
+var timingsOutput io.Writer = os.Stderr
+
+// taskTiming is how long a task took, as listed under the Timings key of nrdiag-output.json
+type taskTiming struct {
+	Identifier string
+	DurationMs int64
+
+	duration time.Duration
+}
+
+// getTimings returns the duration of every task run, slowest first. Tasks taking as long are sorted by identifier so the
+// order doesn't depend on the order they finished in. The results carried over by -resume didn't run and are left out
+func getTimings(data []registration.TaskResult) []taskTiming {
+	timings := make([]taskTiming, 0, len(data))
+	for _, taskResult := range data {
+		if !taskResult.RunDate.IsZero() {
+			continue
+		}
+		timings = append(timings, taskTiming{
+			Identifier: taskResult.Task.Identifier().String(),
+			DurationMs: taskResult.Duration.Milliseconds(),
+			duration:   taskResult.Duration,
+		})
+	}
+	sort.SliceStable(timings, func(i, j int) bool {
+		if timings[i].duration != timings[j].duration {
+			return timings[i].duration > timings[j].duration
+		}
+		return timings[i].Identifier < timings[j].Identifier
+	})
+	return timings
+}
+
+// WriteTimings prints a table of how long each task took to stderr, slowest first
+func WriteTimings(data []registration.TaskResult) {
+	writer := tabwriter.NewWriter(timingsOutput, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "Task\tDuration")
+	for _, timing := range getTimings(data) {
+		fmt.Fprintf(writer, "%s\t%s\n", timing.Identifier, timing.duration.Round(time.Millisecond))
+	}
+	_ = writer.Flush()
+}
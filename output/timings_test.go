@@ -0,0 +1,88 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/config"
+	"github.com/newrelic/newrelic-diagnostics-cli/registration"
+)
+
+func generateTimedResults() []registration.TaskResult {
+	results := generateJUnitResults()
+	results[0].Duration = 20 * time.Millisecond
+	results[1].Duration = 3 * time.Second
+	results[2].Duration = 1500 * time.Millisecond
+	results[3].Duration = 20 * time.Millisecond
+	return results
+}
+
+func Test_getTimings(t *testing.T) {
+	results := generateTimedResults()
+
+	var got []string
+	for _, timing := range getTimings(results) {
+		got = append(got, timing.Identifier)
+	}
+
+	want := []string{
+		results[1].Task.Identifier().String(),
+		results[2].Task.Identifier().String(),
+	}
+	// the two 20ms tasks are sorted by identifier
+	if results[0].Task.Identifier().String() < results[3].Task.Identifier().String() {
+		want = append(want, results[0].Task.Identifier().String(), results[3].Task.Identifier().String())
+	} else {
+		want = append(want, results[3].Task.Identifier().String(), results[0].Task.Identifier().String())
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("getTimings() = %v, want %v", got, want)
+	}
+}
+
+func Test_WriteTimings(t *testing.T) {
+	originalOutput := timingsOutput
+	defer func() { timingsOutput = originalOutput }()
+	var out bytes.Buffer
+	timingsOutput = &out
+
+	results := generateTimedResults()
+	WriteTimings(results[1:3])
+
+	want := "Task                      Duration\n" +
+		"Base/Config/Validate      3s\n" +
+		"Base/Collector/ConnectUS  1.5s\n"
+	if out.String() != want {
+		t.Errorf("WriteTimings() printed\n%s\nwant\n%s", out.String(), want)
+	}
+}
+
+func Test_getResultsJSONTimings(t *testing.T) {
+	defer func() { config.Flags.Timings = false }()
+	results := generateTimedResults()
+
+	var written struct {
+		Timings []taskTiming
+	}
+	_ = json.Unmarshal([]byte(getResultsJSON(results, summarizeResults(results), recommendActions(results))), &written)
+	if written.Timings != nil {
+		t.Error("Expected no timings key without -timings, got", written.Timings)
+	}
+
+	config.Flags.Timings = true
+	resultsJSON := getResultsJSON(results, summarizeResults(results), recommendActions(results))
+	if !strings.Contains(resultsJSON, `"Timings": [`) || !strings.Contains(resultsJSON, `"DurationMs": 3000`) {
+		t.Errorf("Expected the PascalCase keys of the rest of the file, got %s", resultsJSON)
+	}
+	_ = json.Unmarshal([]byte(resultsJSON), &written)
+	if len(written.Timings) != len(results) {
+		t.Fatalf("Expected %d timings, got %v", len(results), written.Timings)
+	}
+	if written.Timings[0].Identifier != results[1].Task.Identifier().String() || written.Timings[0].DurationMs != 3000 {
+		t.Errorf("Expected the slowest task first, got %+v", written.Timings[0])
+	}
+}
@@ -0,0 +1,59 @@
+package output
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/config"
+	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
+	"github.com/newrelic/newrelic-diagnostics-cli/registration"
+	"gopkg.in/yaml.v3"
+)
+
+const yamlFileExtension = ".yaml"
+
+// getResultsYAML converts the results into YAML with the same structure as getResultsJSON. The JSON output is
+// re-encoded rather than the results marshaled directly, so the custom JSON marshaling of results, statuses and
+// payloads, the field names and the field order all carry over.
+func getResultsYAML(data []registration.TaskResult, summary resultsSummary, recommendations []recommendation) string {
+	var document yaml.Node
+	// JSON is valid YAML, decoding it keeps the document in its original order
+	err := yaml.Unmarshal([]byte(getResultsJSON(data, summary, recommendations)), &document)
+	if err != nil {
+		log.Info("Couldn't save YAML output: ", err)
+		return ""
+	}
+	useBlockStyle(&document)
+
+	var output bytes.Buffer
+	encoder := yaml.NewEncoder(&output)
+	encoder.SetIndent(2)
+	err = encoder.Encode(&document)
+	if err != nil {
+		log.Info("Couldn't save YAML output: ", err)
+	}
+	_ = encoder.Close()
+	return output.String()
+}
+
+// useBlockStyle drops the flow style and quoting the nodes inherited from JSON. The encoder still quotes strings
+// that would otherwise be read back as another type, e.g. "true" or "1.0"
+func useBlockStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		useBlockStyle(child)
+	}
+}
+
+func outputYAML(report string) {
+	yamlFile := filepath.Clean(config.Flags.OutputPath + "/" + config.OutputFileName(yamlFileExtension))
+	log.Debug("Creating YAML file:", yamlFile)
+	err := os.MkdirAll(config.Flags.OutputPath, 0777)
+	if err != nil {
+		log.Info("Error creating directory", err)
+		log.Info(permissionsError)
+	}
+	_ = ioutil.WriteFile(yamlFile, []byte(report), 0644)
+}
@@ -0,0 +1,69 @@
+package output
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/registration"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	"gopkg.in/yaml.v3"
+)
+
+func Test_getResultsYAML(t *testing.T) {
+	OutputNow = func() time.Time {
+		return time.Date(2000, 12, 15, 17, 8, 00, 0, time.UTC)
+	}
+
+	results := generateResultArray()
+	results[1].Result.Summary = "first line\nsecond line"
+	results[1].Result.Payload = map[string]interface{}{
+		"Enabled":    "true",
+		"Version":    "1.10",
+		"Port":       8443,
+		"LicenseKey": nil,
+		"Hosts":      []string{"collector.newrelic.com", "*.nr-data.net"},
+		"Nested":     map[string]interface{}{"Empty": "", "Ratio": 0.5},
+	}
+	results = append(results, registration.TaskResult{
+		Task: registration.TasksForIdentifierString("Base/Collector/DNSResolve")[0],
+		Result: tasks.Result{
+			Status:  tasks.Failure,
+			Summary: "Unable to resolve collector.newrelic.com",
+			Payload: []struct {
+				Host  string
+				Error string
+			}{{Host: "collector.newrelic.com", Error: "no such host"}},
+		},
+	})
+
+	observed := getResultsYAML(results, summarizeResults(results), recommendActions(results))
+
+	var fromYAML interface{}
+	if err := yaml.Unmarshal([]byte(observed), &fromYAML); err != nil {
+		t.Fatal("Unable to parse YAML output:", err, "\n", observed)
+	}
+	// YAML decodes into a few different types (ints, timestamps), compare both documents through JSON
+	yamlAsJSON, err := json.Marshal(fromYAML)
+	if err != nil {
+		t.Fatal("Unable to convert YAML output:", err)
+	}
+	var roundTripped, expected interface{}
+	_ = json.Unmarshal(yamlAsJSON, &roundTripped)
+	_ = json.Unmarshal([]byte(getResultsJSON(results, summarizeResults(results), recommendActions(results))), &expected)
+
+	if !reflect.DeepEqual(roundTripped, expected) {
+		t.Errorf("Expected the YAML output to match the JSON output.\nYAML: %s\nJSON: %s", yamlAsJSON, getResultsJSON(results, summarizeResults(results), recommendActions(results)))
+	}
+
+	for _, expectedLine := range []string{"RunDate:", "NRDiagVersion:", "Configuration:", "Results:", "Status: Success", "Enabled: \"true\""} {
+		if !strings.Contains(observed, expectedLine) {
+			t.Errorf("Expected %q in the YAML output:\n%s", expectedLine, observed)
+		}
+	}
+	if strings.Index(observed, "RunDate:") > strings.Index(observed, "Results:") {
+		t.Error("Expected the fields in the same order as the JSON output:\n", observed)
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"os"
+	"path/filepath"
+	"runtime"
+	rpprof "runtime/pprof"
+	"strings"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/config"
+	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
+)
+
+// pprofAddress - the address the -pprof server listens on. A bare port listens on localhost, any other host than a
+// loopback one is refused: the profiles expose the command line and the memory of nrdiag
+func pprofAddress(value string) (string, error) {
+	value = strings.TrimSpace(value)
+	if !strings.Contains(value, ":") {
+		value = "localhost:" + value
+	}
+	host, port, err := net.SplitHostPort(value)
+	if err != nil {
+		return "", errors.New("-pprof takes a port or a local address, e.g. localhost:6060: " + err.Error())
+	}
+	if host == "" {
+		host = "localhost"
+	}
+	if ip := net.ParseIP(host); host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+		return "", errors.New("-pprof only listens on localhost or a loopback address, not " + host)
+	}
+	return net.JoinHostPort(host, port), nil
+}
+
+// pprofMux - the net/http/pprof handlers, on their own mux rather than http.DefaultServeMux
+func pprofMux() *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	return mux
+}
+
+// startPprof - serves the profiles of nrdiag on the -pprof address until the returned function is called
+func startPprof(value string) (func(), error) {
+	address, err := pprofAddress(value)
+	if err != nil {
+		return nil, err
+	}
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return nil, err
+	}
+	server := &http.Server{Handler: pprofMux()}
+	go func() {
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Debug("pprof server stopped:", err)
+		}
+	}()
+	log.Infof("Serving the pprof profiles of nrdiag on http://%s/debug/pprof/ until the tasks are done\n", listener.Addr().String())
+	return func() { server.Close() }, nil
+}
+
+// processPprof - starts the -pprof server, the returned function stops it
+func processPprof() (func(), error) {
+	if config.Flags.Pprof == "" {
+		return func() {}, nil
+	}
+	return startPprof(config.Flags.Pprof)
+}
+
+// writePprofProfiles - writes the heap and goroutine profiles of nrdiag to the output path for -pprof-profiles. They
+// are left out of the zip file, they describe nrdiag rather than the host
+func writePprofProfiles(outputPath string) []string {
+	// the heap profile reports the live objects as of the last garbage collection
+	runtime.GC()
+	var written []string
+	for _, name := range []string{"heap", "goroutine"} {
+		path := filepath.Join(outputPath, config.OutputFileName("-"+name+".pprof"))
+		if err := writeProfile(name, path); err != nil {
+			log.Warn("Unable to write the " + name + " profile: " + err.Error())
+			continue
+		}
+		written = append(written, path)
+	}
+	return written
+}
+
+func writeProfile(name string, path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return rpprof.Lookup(name).WriteTo(file, 0)
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func Test_pprofAddress(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    string
+		wantErr string
+	}{
+		{value: "6060", want: "localhost:6060"},
+		{value: ":6060", want: "localhost:6060"},
+		{value: "127.0.0.1:6060", want: "127.0.0.1:6060"},
+		{value: "[::1]:6060", want: "[::1]:6060"},
+		{value: "0.0.0.0:6060", wantErr: "only listens on localhost"},
+		{value: "example.com:6060", wantErr: "only listens on localhost"},
+		{value: "localhost:6060:1", wantErr: "takes a port or a local address"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := pprofAddress(tt.value)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("pprofAddress(%q) error = %v, want %q", tt.value, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("pprofAddress(%q) = %q, %v, want %q", tt.value, got, err, tt.want)
+			}
+		})
+	}
+}
+
+func Test_startPprof(t *testing.T) {
+	stop, err := startPprof("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	stop()
+
+	if _, err := startPprof("192.0.2.1:6060"); err == nil {
+		t.Error("Expected startPprof() to refuse a non loopback address")
+	}
+}
+
+func Test_pprofMux(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	pprofMux().ServeHTTP(recorder, httptest.NewRequest("GET", "/debug/pprof/goroutine?debug=1", nil))
+	if recorder.Code != http.StatusOK || !strings.Contains(recorder.Body.String(), "goroutine profile") {
+		t.Errorf("GET /debug/pprof/goroutine = %d %q, want the goroutine profile", recorder.Code, recorder.Body.String())
+	}
+}
+
+func Test_writePprofProfiles(t *testing.T) {
+	dir := t.TempDir()
+	written := writePprofProfiles(dir)
+	if len(written) != 2 {
+		t.Fatalf("writePprofProfiles() wrote %v, want the heap and goroutine profiles", written)
+	}
+	for _, path := range written {
+		if filepath.Dir(path) != dir || !strings.HasSuffix(path, ".pprof") {
+			t.Errorf("writePprofProfiles() wrote %s, want a .pprof file in %s", path, dir)
+		}
+		if info, err := os.Stat(path); err != nil || info.Size() == 0 {
+			t.Errorf("writePprofProfiles() left %s empty: %v", path, err)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/config"
+	"github.com/newrelic/newrelic-diagnostics-cli/helpers/httpHelper"
+	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
+	"github.com/newrelic/newrelic-diagnostics-cli/registration"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks/base/collector"
+)
+
+// preflightTimeoutSeconds - the preflight only tells a host without outbound access apart, it doesn't wait out a slow
+// network the way the collector tasks do
+const preflightTimeoutSeconds = 5
+
+// preflightSummary is the summary of the network tasks skipped after the preflight failed
+const preflightSummary = "skipped: New Relic could not be reached before the run"
+
+// proxyDetectIdentifier - the preflight waits for this task to set the proxy the network tasks use
+const proxyDetectIdentifier = "Base/Config/ProxyDetect"
+
+// preflightGetter makes the preflight request, replaced in tests
+var preflightGetter = httpHelper.MakeHTTPRequest
+
+// preflightTask - stands for the preflight in the results when it failed, it is not a registered task
+type preflightTask struct{}
+
+// Identifier - the preflight is reported alongside the collector tasks
+func (t preflightTask) Identifier() tasks.Identifier {
+	return tasks.IdentifierFromString("Base/Collector/Preflight")
+}
+
+// Explain - Returns the help text of the preflight
+func (t preflightTask) Explain() string {
+	return "Check New Relic can be reached before the tasks making network requests run"
+}
+
+// Dependencies - the preflight runs once Base/Config/ProxyDetect is done, see preflightState.check
+func (t preflightTask) Dependencies() []string {
+	return []string{proxyDetectIdentifier}
+}
+
+// Execute - the preflight is not run as a task, see preflightState.check
+func (t preflightTask) Execute(options tasks.Options, upstream map[string]tasks.Result) tasks.Result {
+	return tasks.Result{}
+}
+
+// preflightState - the outcome of the preflight of this run. The tasks running in parallel read skip, failed is the
+// result reporting a failed preflight and is only read once they are all done
+type preflightState struct {
+	once   sync.Once
+	skip   int32
+	failed *registration.TaskResult
+}
+
+// preflight is the preflight of this run, replaced in tests
+var preflight = &preflightState{}
+
+// preflightEnabled - the modes that make no network request, and -single, which only prints the result of its task,
+// don't make the preflight
+func preflightEnabled() bool {
+	return !config.Flags.SkipPreflight && !config.Flags.Offline && !config.Flags.CollectOnly &&
+		config.Flags.ValidateConfig == "" && config.Flags.Single == ""
+}
+
+// check - makes the preflight before the first network task that goes through the proxy, once Base/Config/ProxyDetect
+// has set it. The other tasks calling it wait for the outcome. Returns whether the network tasks are to be skipped
+func (p *preflightState) check(task tasks.Task, prompt func(string) bool) bool {
+	if preflightEnabled() && containsIdentifier(task.Dependencies(), proxyDetectIdentifier) {
+		p.once.Do(func() {
+			var skip bool
+			p.failed, skip = processPreflight(prompt)
+			if skip {
+				atomic.StoreInt32(&p.skip, 1)
+			}
+		})
+	}
+	return atomic.LoadInt32(&p.skip) == 1
+}
+
+// connectivityPreflight - makes a single request to the collector of the region. Any HTTP response, whatever its status
+// code, shows New Relic is reachable; only a request that gets no response fails
+func connectivityPreflight(url string) error {
+	resp, err := preflightGetter(httpHelper.RequestWrapper{
+		Method:         "GET",
+		URL:            url,
+		TimeoutSeconds: preflightTimeoutSeconds,
+	})
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// preflightProxy - describes the proxy the preflight request went through, for the warning and the result
+func preflightProxy(url string) string {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return "no proxy"
+	}
+	proxyURL, err := httpHelper.ProxyFromConfig(req)
+	if err != nil || proxyURL == nil {
+		return "no proxy"
+	}
+	return "the proxy " + httpHelper.RedactProxyURL(proxyURL.String())
+}
+
+// processPreflight - runs the connectivity preflight and, when it fails, offers to skip the network tasks. -y never
+// skips them, the failure is only reported. The version check and the usage data are skipped along with the tasks,
+// they would only time out too. Returns the result reporting the failure, nil when New Relic was reached, and whether
+// to skip the network tasks
+func processPreflight(prompt func(string) bool) (*registration.TaskResult, bool) {
+	url := collector.PreflightURL()
+	proxy := preflightProxy(url)
+	log.Debug("Connectivity preflight:", url, "through", proxy)
+	err := connectivityPreflight(url)
+	if err == nil {
+		return nil, false
+	}
+
+	summary := fmt.Sprintf("Unable to reach %s through %s before the network tasks ran: %s", url, proxy, err.Error())
+	log.Warnf("\nWarning: unable to reach %s through %s (%s).\nThe tasks making network requests will likely all fail after their timeout. Check the proxy settings of this host, or use -offline on an air-gapped host.\n\n", url, proxy, err.Error())
+	skip := !config.Flags.YesToAll && prompt("Skip the tasks making network requests?")
+	if skip {
+		config.Flags.UsageOptOut = true
+		config.Flags.SkipVersionCheck = true
+		summary += ". The tasks making network requests were skipped."
+	} else {
+		summary += ". The tasks making network requests were run anyway."
+	}
+	return &registration.TaskResult{
+		Task:   preflightTask{},
+		Result: tasks.Result{Status: tasks.Failure, Summary: summary},
+	}, skip
+}
+
+func containsIdentifier(identifiers []string, identifier string) bool {
+	for _, i := range identifiers {
+		if i == identifier {
+			return true
+		}
+	}
+	return false
+}
@@ -1,15 +1,23 @@
 package main
 
 import (
+	"encoding/json"
 	"errors"
 	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
 	"net/url"
 	"os"
+	"path/filepath"
 	"sort"
 	"strings"
 
 	"github.com/newrelic/newrelic-diagnostics-cli/config"
+	"github.com/newrelic/newrelic-diagnostics-cli/helpers/httpHelper"
+	"github.com/newrelic/newrelic-diagnostics-cli/helpers/pac"
 	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
+	"github.com/newrelic/newrelic-diagnostics-cli/output"
 	"github.com/newrelic/newrelic-diagnostics-cli/registration"
 	"github.com/newrelic/newrelic-diagnostics-cli/suites"
 	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
@@ -38,6 +46,281 @@ func validateHTTPProxy(proxy string) (bool, error) {
 
 }
 
+// processCABundle - loads the -ca-bundle certificates, if any, so every request trusts them
+func processCABundle() error {
+	if config.Flags.CABundle == "" {
+		return nil
+	}
+	err := httpHelper.LoadCABundle(config.Flags.CABundle)
+	if err != nil {
+		return err
+	}
+	log.Debug("Trusting the certificates of CA bundle", config.Flags.CABundle)
+	return nil
+}
+
+// processClientCertificate - loads the -client-cert and -client-key pair, if any, so every request presents it
+func processClientCertificate() error {
+	if config.Flags.ClientCert == "" && config.Flags.ClientKey == "" {
+		return nil
+	}
+	err := httpHelper.LoadClientCertificate(config.Flags.ClientCert, config.Flags.ClientKey)
+	if err != nil {
+		return err
+	}
+	log.Debug("Presenting client certificate", config.Flags.ClientCert)
+	return nil
+}
+
+// processOutputFormat - validates the -output-format flag argument
+// maxPACSize - PAC files are a few kilobytes, this only stops reading a URL that doesn't serve one
+const maxPACSize = 1 << 20
+
+// processPAC - loads the -pac-url PAC file, if any, so every request goes through the proxy it picks for the request URL
+func processPAC() error {
+	if config.Flags.PACURL == "" {
+		return nil
+	}
+	if config.Flags.Proxy != "" {
+		log.Info("The -proxy flag is set, the PAC file provided with -pac-url is not used")
+		return nil
+	}
+	content, err := readPAC(config.Flags.PACURL)
+	if err != nil {
+		return err
+	}
+	script, err := pac.Parse(content)
+	if err != nil {
+		// the checks would go through another proxy than the one the file picks, give it with -proxy instead
+		return fmt.Errorf("%s. Only the JavaScript listed under PAC files in the README is supported, provide the proxy with -proxy instead", err.Error())
+	}
+	httpHelper.SetPAC(script)
+	log.Debug("Picking the proxy of each request with the PAC file", config.Flags.PACURL)
+	return nil
+}
+
+// readPAC - the content of the PAC file at an http(s) URL or a path. The URL is fetched directly, as browsers do
+func readPAC(location string) (string, error) {
+	parsed, err := url.Parse(location)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		content, err := ioutil.ReadFile(strings.TrimPrefix(location, "file://"))
+		return string(content), err
+	}
+	if config.Flags.Offline {
+		return "", errors.New("the PAC file can't be fetched from " + location + " in -offline mode, provide a path instead")
+	}
+
+	wrapper := httpHelper.RequestWrapper{
+		Method:      "GET",
+		URL:         location,
+		BypassProxy: true,
+	}
+	resp, err := httpHelper.MakeHTTPRequest(wrapper)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return "", fmt.Errorf("fetching %s returned the status %s", location, resp.Status)
+	}
+	content, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxPACSize))
+	return string(content), err
+}
+
+// processIncludePaths - validates the -include-path directories and replaces them with their absolute path, symlinks
+// resolved, so a directory given twice or through a link is only searched once. A symlink loop fails to resolve here,
+// and the searches don't follow the symlinks found below the directories, see tasks.FindFiles
+func processIncludePaths() error {
+	var resolved []string
+	for _, path := range config.Flags.IncludePaths {
+		absPath, err := filepath.Abs(path)
+		if err != nil {
+			return err
+		}
+		realPath, err := filepath.EvalSymlinks(absPath)
+		if err != nil {
+			return errors.New("-include-path " + path + " can't be used: " + err.Error())
+		}
+		info, err := os.Stat(realPath)
+		if err != nil {
+			return errors.New("-include-path " + path + " can't be used: " + err.Error())
+		}
+		if !info.IsDir() {
+			return errors.New("-include-path " + path + " is not a directory, use -config-file for a config file")
+		}
+		if tasks.PosString(resolved, realPath) == -1 {
+			resolved = append(resolved, realPath)
+		}
+	}
+	config.Flags.IncludePaths = resolved
+	return nil
+}
+
+func processOutputFormat() error {
+	switch strings.ToLower(config.Flags.OutputFormat) {
+	case "", config.JSONOutputFormat, config.JUnitOutputFormat, config.HTMLOutputFormat, config.SARIFOutputFormat, config.YAMLOutputFormat:
+		config.Flags.OutputFormat = strings.ToLower(config.Flags.OutputFormat)
+		return nil
+	default:
+		return errors.New("unsupported output format '" + config.Flags.OutputFormat + "'. Accepted values: " + config.JSONOutputFormat + ", " + config.JUnitOutputFormat)
+	}
+}
+
+// processLogFormat - validates the -log-format flag argument
+func processLogFormat() error {
+	switch strings.ToLower(config.Flags.LogFormat) {
+	case "", config.TextLogFormat, config.JSONLogFormat:
+		config.Flags.LogFormat = strings.ToLower(config.Flags.LogFormat)
+		return nil
+	default:
+		return errors.New("unsupported log format '" + config.Flags.LogFormat + "'. Accepted values: " + config.TextLogFormat + ", " + config.JSONLogFormat)
+	}
+}
+
+// processLogLevel - validates the -log-level flag argument, config.ParseFlags already applied the valid ones
+func processLogLevel() error {
+	if config.Flags.LogLevel == "" {
+		return nil
+	}
+	_, err := config.ParseLogLevel(config.Flags.LogLevel)
+	return err
+}
+
+// processMinStatus - validates the -min-status flag argument
+func processMinStatus() error {
+	if config.Flags.MinStatus == "" {
+		return nil
+	}
+	_, err := tasks.StatusFromString(config.Flags.MinStatus)
+	return err
+}
+
+// processFailOn - validates the -fail-on flag argument. Only statuses that report a problem can fail the run
+func processFailOn() error {
+	failOn, err := tasks.StatusFromString(config.Flags.FailOn)
+	if err != nil || !failOn.IsAtLeast(tasks.Warning) {
+		return errors.New("unsupported -fail-on status '" + config.Flags.FailOn + "'. Accepted values: warning, failure, error")
+	}
+	return nil
+}
+
+// processProfile - reports the entries of the -profile file that were ignored, and why the file couldn't be used
+func processProfile() error {
+	for _, warning := range config.ProfileWarnings {
+		log.Warnf("Warning: %s\n", warning)
+	}
+	return config.ProfileError
+}
+
+// processTaskFile - merges the task identifiers listed in the -task-file file into the -t selection
+func processTaskFile() error {
+	if config.Flags.TaskFile == "" {
+		return nil
+	}
+	identifiers, err := readTaskFile(config.Flags.TaskFile)
+	if err != nil {
+		return err
+	}
+	// an empty selection would run every task
+	if len(identifiers) == 0 {
+		return errors.New("no task identifiers found in " + config.Flags.TaskFile)
+	}
+	if config.Flags.Tasks != "" {
+		identifiers = append([]string{config.Flags.Tasks}, identifiers...)
+	}
+	config.Flags.Tasks = strings.Join(identifiers, ",")
+	log.Debug("Tasks selected with -t and -task-file:", config.Flags.Tasks)
+	return nil
+}
+
+// processSingle - validates the -single flag argument: the exact identifier of one registered task, wildcards and lists
+// are for -t
+func processSingle() error {
+	if config.Flags.Single == "" {
+		return nil
+	}
+	identifier := strings.TrimSpace(config.Flags.Single)
+	if strings.ContainsAny(identifier, "*,") {
+		return errors.New("-single takes the identifier of a single task, use -t to run several")
+	}
+	if len(registration.TasksForIdentifierString(identifier)) == 0 {
+		return errors.New("no task matches '" + identifier + "', run with '-h tasks' to list them")
+	}
+	return nil
+}
+
+// processDiff - -diff takes the old output file, the new one is the only argument left after the flags. It returns the
+// new output file
+func processDiff() (string, error) {
+	if flag.NArg() != 1 {
+		return "", errors.New("-diff compares two output files: -diff old.json new.json, with the other flags before -diff")
+	}
+	for _, path := range []string{config.Flags.Diff, flag.Arg(0)} {
+		info, err := os.Stat(path)
+		if err != nil {
+			return "", err
+		}
+		if info.IsDir() {
+			return "", errors.New(path + " is a directory, not an nrdiag output file")
+		}
+	}
+	return flag.Arg(0), nil
+}
+
+// processCollectOnly - -collect-only picks the tasks to run itself, it can't be combined with the flags selecting them
+func processCollectOnly() error {
+	if !config.Flags.CollectOnly {
+		return nil
+	}
+	if config.Flags.Tasks != "" || config.Flags.Suites != "" || config.Flags.Single != "" || config.Flags.ValidateConfig != "" {
+		return errors.New("-collect-only runs the collection tasks only, it can't be combined with -t, -suites, -single or -validate-config")
+	}
+	return nil
+}
+
+// resumedResults are the results of the -resume output file, those of the tasks that don't run again are carried over
+var resumedResults []registration.TaskResult
+
+// processResume - -resume only runs again the tasks selected with -t or -task-file, it reads the results of the other
+// tasks from the earlier output file before the new one can overwrite it
+func processResume() error {
+	if config.Flags.Resume == "" {
+		return nil
+	}
+	if config.Flags.Tasks == "" {
+		return errors.New("-resume runs again the tasks selected with -t or -task-file, select at least one")
+	}
+	if config.Flags.Suites != "" || config.Flags.Single != "" || config.Flags.ValidateConfig != "" || config.Flags.CollectOnly {
+		return errors.New("-resume can't be combined with -suites, -single, -validate-config or -collect-only")
+	}
+	results, err := output.ReadResumeFile(config.Flags.Resume)
+	if err != nil {
+		return err
+	}
+	resumedResults = results
+	log.Debugf("Read %d results to resume from %s\n", len(results), config.Flags.Resume)
+	return nil
+}
+
+// readTaskFile - returns the task identifiers listed in a file, one per line. Everything after a '#' is a comment
+func readTaskFile(path string) ([]string, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var identifiers []string
+	for _, line := range strings.Split(string(content), "\n") {
+		if comment := strings.Index(line, "#"); comment >= 0 {
+			line = line[:comment]
+		}
+		line = strings.TrimSpace(line)
+		if line != "" {
+			identifiers = append(identifiers, line)
+		}
+	}
+	return identifiers, nil
+}
+
 // processHTTPProxy - Returns true if a proxy has been detected and set, otherwise false.
 func processHTTPProxy() (bool, error) {
 
@@ -59,11 +342,11 @@ func processHTTPProxy() (bool, error) {
 			proxyURL := splitURL[0] + "//" + url.QueryEscape(config.Flags.ProxyUser) + ":" + url.QueryEscape(config.Flags.ProxyPassword) + "@" + splitURL[1]
 
 			os.Setenv("HTTP_PROXY", proxyURL)
-			log.Debug("Setting proxy from command line:", proxyURL)
+			log.Debug("Setting proxy from command line:", httpHelper.RedactProxyURL(proxyURL))
 		} else {
 			//No user and pass? Use the -proxy arg as is
 			os.Setenv("HTTP_PROXY", config.Flags.Proxy)
-			log.Debug("Setting proxy from command line:", config.Flags.Proxy)
+			log.Debug("Setting proxy from command line:", httpHelper.RedactProxyURL(config.Flags.Proxy))
 		}
 
 	}
@@ -73,7 +356,7 @@ func processHTTPProxy() (bool, error) {
 		_, err := validateHTTPProxy(envProxy)
 		if err != nil {
 			log.Debug("Proxy url is malformed: " + err.Error())
-			log.Debug(envProxy)
+			log.Debug(httpHelper.RedactProxyURL(envProxy))
 			return false, errors.New("proxy format should be: -proxy http://poxy_host:proxy_port")
 		}
 
@@ -81,7 +364,7 @@ func processHTTPProxy() (bool, error) {
 			return false, errors.New("error setting proxy: " + envProxy)
 		}
 
-		log.Debug("Using proxy address from HTTP_PROXY environment variable:", envProxy)
+		log.Debug("Using proxy address from HTTP_PROXY environment variable:", httpHelper.RedactProxyURL(envProxy))
 
 		//ProxyParseNSet() passed
 		return true, nil
@@ -216,6 +499,16 @@ func printTasks() {
 
 }
 
+// printCatalog will output every registered task as JSON for tooling
+func printCatalog() error {
+	catalog, err := json.MarshalIndent(registration.Catalog(), "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(catalog))
+	return nil
+}
+
 //PrintOptions will output all the command line options
 func printOptions() {
 	flag.PrintDefaults()
@@ -232,12 +525,23 @@ func processOverrides() (tasks.Options, []override) {
 		options.Options["configFile"] = config.Flags.ConfigFile
 	}
 
+	// Pass in the -include-path directories, already validated
+	if len(config.Flags.IncludePaths) > 0 {
+		log.Debug("Searching the additional paths", config.Flags.IncludePaths)
+		options.Options[tasks.IncludePathsOption] = strings.Join(config.Flags.IncludePaths, string(os.PathListSeparator))
+	}
+
 	// Pass in Filter file override value
 	if config.Flags.Filter != "" {
 		log.Debug("Manually setting Filter to ", config.Flags.Filter)
 		options.Options["Filter"] = config.Flags.Filter
 	}
 
+	// Pass in the -offline mode, for the tasks that make requests without being network tasks
+	if config.Flags.Offline {
+		options.Options[tasks.OfflineOption] = "true"
+	}
+
 	// Pass in YesToAll file override value
 	if config.Flags.YesToAll {
 		log.Debug("Manually setting YesToAll to ", config.Flags.YesToAll)
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/config"
+	"github.com/newrelic/newrelic-diagnostics-cli/helpers/httpHelper"
+)
+
+func Test_processPAC(t *testing.T) {
+	original := config.Flags.PACURL
+	defer func() {
+		config.Flags.PACURL = original
+		httpHelper.SetPAC(nil)
+	}()
+	dir := t.TempDir()
+
+	tests := []struct {
+		name    string
+		script  string
+		missing bool
+		wantErr bool
+	}{
+		{name: "valid", script: `function FindProxyForURL(url, host) { return "PROXY proxy.example.com:8080"; }`},
+		{name: "unsupported construct", script: "var m = {\"newrelic.com\": \"PROXY o:1\"};\nfunction FindProxyForURL(url, host) { for (var k in m) return m[k]; }", wantErr: true},
+		{name: "missing file", missing: true, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, "proxy.pac")
+			if tt.missing {
+				path = filepath.Join(dir, "missing.pac")
+			} else if err := ioutil.WriteFile(path, []byte(tt.script), 0644); err != nil {
+				t.Fatal(err)
+			}
+			config.Flags.PACURL = path
+			if err := processPAC(); (err != nil) != tt.wantErr {
+				t.Errorf("processPAC() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -1,8 +1,11 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
+	"runtime"
 	"strings"
 	"sync"
 	"time"
@@ -11,6 +14,7 @@ import (
 
 	"github.com/newrelic/newrelic-diagnostics-cli/attach"
 	"github.com/newrelic/newrelic-diagnostics-cli/config"
+	"github.com/newrelic/newrelic-diagnostics-cli/helpers/httpHelper"
 	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
 	"github.com/newrelic/newrelic-diagnostics-cli/output"
 	"github.com/newrelic/newrelic-diagnostics-cli/registration"
@@ -18,16 +22,55 @@ import (
 	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
 )
 
+// validateConfigTasks are the only tasks run by -validate-config, in the order they run
+var validateConfigTasks = []string{"Base/Config/Collect", "Base/Config/Validate"}
+
+// collectOnlyTasks are the tasks run by -collect-only, with their dependencies: those gathering the config files, logs
+// and environment details. The tasks of another OS are not registered and are left out
+var collectOnlyTasks = []string{
+	"Base/Config/Collect",
+	"Base/Env/CollectEnvVars",
+	"Base/Env/CollectSysProps",
+	"Base/Env/HostInfo",
+	"Base/Log/Copy",
+	"Base/Log/WindowsEventLog",
+	"DotNet/CustomInstrumentation/Collect",
+	"DotNet/W3wp/Collect",
+	"DotNetCore/CustomInstrumentation/Collect",
+	"Infra/Config/DataDirectoryCollect",
+	"Infra/Config/IntegrationsCollect",
+	"Infra/Log/Collect",
+	"Infra/Log/Journal",
+	"Ruby/Config/Collect",
+	"Synthetics/Minion/CollectLogs",
+}
+
 func processTasksToRun() {
 	log.Debugf("There are %d tasks in this queue\n", len(registration.Work.WorkQueue))
 
-	if config.Flags.Tasks != "" {
+	if config.Flags.Single != "" {
+		// -t and -suites are ignored, only the -single task and its dependencies run
+		registration.AddTasksByIdentifier(strings.TrimSpace(config.Flags.Single))
+	} else if config.Flags.ValidateConfig != "" {
+		// Base/Config/Collect only needs the environment tasks it depends on to search the default locations, not for a given file
+		registration.AddIdentifiersWithoutDependencies(validateConfigTasks)
+	} else if config.Flags.CollectOnly {
+		for _, identifier := range collectOnlyTasks {
+			// not warning about the tasks of another OS, which are not registered
+			if len(registration.TasksForIdentifierString(identifier)) > 0 {
+				registration.AddTasksByIdentifier(identifier)
+			}
+		}
+	} else if config.Flags.Tasks != "" {
 		taskIdentifiers := processFlagsTasks(config.Flags.Tasks)
+		if unmatched := unmatchedTasks(taskIdentifiers); len(unmatched) > 0 {
+			log.Warnf("\nWarning: no task matches the following -t or -task-file entries, they are ignored:\n\n  \"%s\"\n\n", strings.Join(unmatched, "\"\n  \""))
+		}
 		registration.AddTasksByIdentifiers(taskIdentifiers)
 	} else if config.Flags.Suites != "" {
 		matchedSuites, err := processFlagsSuites(config.Flags.Suites, os.Args)
 		if err != nil {
-			log.Infof("\nError:\n%s", err.Error())
+			log.Errorf("\nError:\n%s", err.Error())
 			os.Exit(1)
 		}
 
@@ -46,109 +89,204 @@ func processTasksToRun() {
 	registration.CompleteTaskRegistration()
 }
 
-func processTasks(options tasks.Options, overrides []override, wg *sync.WaitGroup) {
+func processTasks(ctx context.Context, options tasks.Options, overrides []override, wg *sync.WaitGroup) {
 	log.Debugf("work queue has %d items\n", len(registration.Work.WorkQueue))
-	taskCount := 0
-	for task := range registration.Work.WorkQueue {
-		taskCount++
-		if taskCount == 1 && !config.Flags.VeryQuiet {
-			// writes to the screen
-			output.WriteOutputHeader()
-		}
-		var taskOptions = make(map[string]string)
-		// Loop through incoming options to assign out to the named task Options to avoid carrying in the wrong options
-		for key, value := range options.Options {
-			taskOptions[key] = value
+	var header sync.Once
+	runner := newRunner(options, overrides)
+	report := func(taskResult registration.TaskResult) {
+		header.Do(func() {
+			// -single only prints the result of its task
+			if !config.Flags.VeryQuiet && config.Flags.Single == "" {
+				// writes to the screen
+				output.WriteOutputHeader()
+			}
+		})
+		resultsLock.Lock()
+		registration.Work.Results[taskResult.Task.Identifier().String()] = taskResult //This should be done in output.go but due to async causes issues
+		resultsLock.Unlock()
+
+		registration.Work.ResultsChannel <- taskResult
+		if len(taskResult.Result.FilesToCopy) > 0 {
+			log.Debug(" - writing result to file channel")
+			registration.Work.FilesChannel <- taskResult
 		}
-		namedTaskOptions := tasks.Options{Options: taskOptions}
+	}
+	runner.Run(ctx, registration.Work.WorkQueue, report)
+	// the tasks are done, nothing changes the preflight anymore
+	if preflight.failed != nil {
+		report(*preflight.failed)
+	}
 
-		log.Debug("Running :", task.Identifier())
-		log.Debug("Incoming options are", options)
+	log.Debug("Closing task channel")
+	close(registration.Work.ResultsChannel)
+	close(registration.Work.FilesChannel)
 
-		// Check for dependancies on the task and include results if dependent
-		dependentResults := make(map[string]tasks.Result)
-		for _, depIdent := range task.Dependencies() {
-			log.Debug("dependency for processing: ", depIdent)
-			dependentResults[depIdent] = registration.Work.Results[depIdent].Result
-		}
+	log.Debug("Decrementing wait group in processTasks.")
+	wg.Done()
+}
 
-		//Parse overrides to detect which task we are running
-		for _, value := range overrides {
-			// Initialize the taskOptions object
-			log.Debugf("override %s: %s", value.Identifier, value.value)
-			if strings.EqualFold(value.Identifier.String(), task.Identifier().String()) {
-				log.Debug("Adding override to task namedTaskOptions", value.key, ":", value.value)
-				namedTaskOptions.Options[value.key] = value.value
-			}
+// resultsLock guards registration.Work.Results while tasks run in parallel
+var resultsLock sync.RWMutex
+
+// newRunner - the task runner set up from the command line: the -o overrides, -concurrency, -exclude and the modes
+// skipping some of the tasks
+func newRunner(options tasks.Options, overrides []override) *registration.Runner {
+	taskOptions := make(map[string]map[string]string)
+	for _, value := range overrides {
+		identifier := value.Identifier.String()
+		if taskOptions[identifier] == nil {
+			taskOptions[identifier] = make(map[string]string)
 		}
+		taskOptions[identifier][value.key] = value.value
+	}
+	return &registration.Runner{
+		Options:     options,
+		TaskOptions: taskOptions,
+		Concurrency: config.Flags.Concurrency,
+		HTTPClient:  httpHelper.Client(),
+		Exclude: func(task tasks.Task) bool {
+			return config.Flags.IsExcludedTask(task.Identifier().String())
+		},
+		Forced: isForcedTask,
+		Skip:   skipTask,
+	}
+}
 
-		log.Debug("Starting", task.Identifier(), "with options", namedTaskOptions)
-		var result tasks.Result
-		// Check for an option key to map to Status or Payload and if so, bypass task execution
-		overrideEnabled := false
-		if _, ok := namedTaskOptions.Options["Status"]; ok {
-			log.Debug("Override Status passed in for ", task.Identifier(), "Value of ", namedTaskOptions.Options["Status"])
-
-			switch status := strings.ToLower(namedTaskOptions.Options["Status"]); status {
-			case "success":
-				result.Status = tasks.Success
-			case "warning":
-				result.Status = tasks.Warning
-			case "failure":
-				result.Status = tasks.Failure
-			case "info":
-				result.Status = tasks.Info
-			case "error":
-				result.Status = tasks.Error
-			case "none":
-				result.Status = tasks.None
-			default:
-				log.Info("Attempted to set status override to invalid status", namedTaskOptions.Options["Status"])
-			}
+// The summaries of the tasks cut short by -deadline or Ctrl-C
+const (
+	runDeadlineSummary    = registration.RunDeadlineSummary
+	runInterruptedSummary = registration.RunInterruptedSummary
+)
 
-			result.Summary += "Status set by override to " + namedTaskOptions.Options["Status"] + "\n"
-			overrideEnabled = true
-		}
+// offlineSummary is the summary of the network tasks skipped with -offline
+const offlineSummary = "skipped: offline mode"
 
-		if _, ok := namedTaskOptions.Options["Payload"]; ok {
-			log.Debug("Override Payload passed in for ", task.Identifier())
-			result.Payload = namedTaskOptions.Options["Payload"]
-			result.Summary += "Payload set by override\n"
-			overrideEnabled = true
-		}
+// collectOnlySummary is the summary of the network tasks skipped with -collect-only
+const collectOnlySummary = "skipped: collect-only mode"
 
-		if !overrideEnabled {
-			result = task.Execute(namedTaskOptions, dependentResults)
-		}
+// isElevated reports whether nrdiag runs as root or Administrator, replaced in tests
+var isElevated = tasks.IsElevated
 
-		taskResult := registration.TaskResult{
-			Task:        task,
-			Result:      result,
-			WasOverride: overrideEnabled,
-		}
+// isForcedTask - whether the task was requested with -t or -single
+func isForcedTask(task tasks.Task) bool {
+	return config.Flags.IsForcedTask(task.Identifier().String())
+}
 
-		registration.Work.Results[task.Identifier().String()] = taskResult //This should be done in output.go but due to async causes issues
-		registration.Work.ResultsChannel <- taskResult
+// skipTask - the result of a task not run by -offline, -collect-only, a failed connectivity preflight or the lack of
+// the privileges it needs
+func skipTask(task tasks.Task, options tasks.Options) (tasks.Result, bool) {
+	if config.Flags.Offline && tasks.IsNetworkDependent(task) {
+		log.Debug("Not running", task.Identifier(), "in offline mode")
+		return tasks.Result{Status: tasks.None, Summary: offlineSummary}, true
+	}
+	if config.Flags.CollectOnly && tasks.IsNetworkDependent(task) {
+		log.Debug("Not running", task.Identifier(), "in collect-only mode")
+		return tasks.Result{Status: tasks.None, Summary: collectOnlySummary}, true
+	}
+	if tasks.IsNetworkDependent(task) && preflight.check(task, promptUser) {
+		log.Debug("Not running", task.Identifier(), "after the connectivity preflight failed")
+		return tasks.Result{Status: tasks.None, Summary: preflightSummary}, true
+	}
+	if tasks.RequiresElevation(task, options) && !isElevated() {
+		log.Debug("Not running", task.Identifier(), "without elevated privileges")
+		return tasks.Result{Status: tasks.Warning, Summary: elevationSummary(runtime.GOOS)}, true
+	}
+	return tasks.Result{}, false
+}
 
-		if len(result.FilesToCopy) > 0 {
-			log.Debug(" - writing result to file channel")
-			registration.Work.FilesChannel <- taskResult
-		}
+// executeTask - runs a task unless the flags skip it or the run context is done, see registration.ExecuteTask
+func executeTask(ctx context.Context, task tasks.Task, options tasks.Options, upstream map[string]tasks.Result) tasks.Result {
+	if result, skip := skipTask(task, options); skip {
+		return result
+	}
+	return registration.ExecuteTask(ctx, task, options, upstream)
+}
 
-	} // end of loop to run tasks in
+// elevationSummary - the guidance given for a task that needs more privileges than nrdiag was run with
+func elevationSummary(goos string) string {
+	if goos == "windows" {
+		return "This check requires elevated privileges: re-run " + tasks.ThisProgramFullName + " from an Administrator cmd prompt or PowerShell for this check."
+	}
+	return "This check requires elevated privileges: re-run " + tasks.ThisProgramFullName + " as root, e.g. with sudo, for this check."
+}
 
-	log.Debug("Closing task channel")
-	close(registration.Work.ResultsChannel)
-	close(registration.Work.FilesChannel)
+// processListTasks - prints the tasks selected by -t, -exclude and -suites in the order they would run, without running them
+func processListTasks() {
+	lines := listTasks(registration.Work.WorkQueue)
+	log.Infof("%d tasks would run:\n", len(lines))
+	for _, line := range lines {
+		log.Info(line)
+	}
+}
 
-	log.Debug("Decrementing wait group in processTasks.")
-	wg.Done()
+// listTasks - drains the work queue, which is already in dependency order, into one line per task
+func listTasks(queue <-chan tasks.Task) []string {
+	var lines []string
+	for task := range queue {
+		options := tasks.Options{Options: map[string]string{}}
+		if isForcedTask(task) {
+			options.Options[tasks.ForcedOption] = "true"
+		}
+		line := fmt.Sprintf("%3d. %s - %s", len(lines)+1, task.Identifier().String(), task.Explain())
+		if config.Flags.IsExcludedTask(task.Identifier().String()) {
+			line += " (skipped via -exclude)"
+		} else if config.Flags.Offline && tasks.IsNetworkDependent(task) {
+			line += " (skipped: offline mode)"
+		} else if config.Flags.CollectOnly && tasks.IsNetworkDependent(task) {
+			line += " (skipped: collect-only mode)"
+		} else if tasks.RequiresElevation(task, options) && !isElevated() {
+			line += " (skipped: requires elevated privileges)"
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// runSingle - runs the -single task and its dependencies and prints the task's result to stdout, returns the exit code.
+// Nothing is written to disk and the files the tasks collect are dropped
+func runSingle(options tasks.Options, overrides []override) int {
+	ctx, cancel := runContext(config.Flags.Deadline)
+	defer cancel()
+	stopInterrupts := cancelOnInterrupt(cancel)
+	httpHelper.SetRunContext(ctx)
+	defer httpHelper.SetRunContext(context.Background())
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go processTasks(ctx, options, overrides, &wg)
+	go func() {
+		for range registration.Work.FilesChannel {
+		}
+	}()
+
+	var results []registration.TaskResult
+	for result := range registration.Work.ResultsChannel {
+		results = append(results, result)
+	}
+	wg.Wait()
+	interrupted := errors.Is(ctx.Err(), context.Canceled)
+	stopInterrupts()
+
+	if err := output.WriteSingleResult(results); err != nil {
+		log.Error("Unable to print the result of " + config.Flags.Single + ". \nError: " + err.Error())
+		if interrupted {
+			return exitCodeInterrupted
+		}
+		return 1
+	}
+	if interrupted {
+		return exitCodeInterrupted
+	}
+	// only the task asked for decides the exit code, not its dependencies
+	target, _ := output.SingleResult(results)
+	return exitCodeForResults([]registration.TaskResult{target}, config.Flags.FailOn)
 }
 
 func processFlagsTasks(flagValue string) []string {
 	var validatedIdentifiers []string
 	identifiers := strings.Split(flagValue, ",")
 	for _, ident := range identifiers {
+		ident = strings.TrimSpace(ident)
 		if len(ident) > 0 { //This removes the adding of a blank identifier
 			validatedIdentifiers = append(validatedIdentifiers, ident)
 		}
@@ -156,6 +294,17 @@ func processFlagsTasks(flagValue string) []string {
 	return validatedIdentifiers
 }
 
+// unmatchedTasks - returns the task identifiers and patterns that match no registered task
+func unmatchedTasks(identifiers []string) []string {
+	var unmatched []string
+	for _, ident := range identifiers {
+		if len(registration.TasksForIdentifierString(ident)) == 0 {
+			unmatched = append(unmatched, ident)
+		}
+	}
+	return unmatched
+}
+
 func getLicenseKey(thisResult tasks.Result) ([]string, error) {
 	licenseKeyToSources, ok := thisResult.Payload.(map[string][]string)
 	if !ok {
@@ -206,13 +355,18 @@ func processUploads() {
 	//get timestamp to use attachment
 	timestamp := time.Now().UTC().Format(time.RFC3339)
 
+	if config.Flags.Offline && (config.Flags.APIKey != "" || config.Flags.AutoAttach) {
+		log.Info("The results are not uploaded in offline mode")
+		return
+	}
+
 	if config.Flags.YesToAll {
 		checkAttachmentFlags(timestamp)
 		return
 	}
 
 	if config.Flags.APIKey != "" || config.Flags.AutoAttach {
-		question := "We've created nrdiag-output.zip and nrdiag-output.json\n" +
+		question := "We've created " + config.OutputFileName(".zip") + " and " + config.OutputFileName(".json") + "\n" +
 			"Do you want to upload these to your New Relic account?"
 		if promptUser(question) {
 			checkAttachmentFlags(timestamp)
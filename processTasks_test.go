@@ -1,9 +1,17 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/newrelic/newrelic-diagnostics-cli/config"
+	"github.com/newrelic/newrelic-diagnostics-cli/mocks"
+	"github.com/newrelic/newrelic-diagnostics-cli/registration"
 	"github.com/newrelic/newrelic-diagnostics-cli/suites"
 	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
 	. "github.com/onsi/ginkgo/v2"
@@ -278,3 +286,422 @@ var _ = Describe("getLicenseKey()", func() {
 	})
 
 })
+
+// listedTask fails the test when it is run, listTasks only names the tasks
+func listedTask(identifier string) mocks.FakeTask {
+	return mocks.FakeTask{
+		ID: identifier,
+		ExecuteFunc: func(tasks.Options, map[string]tasks.Result) tasks.Result {
+			Fail("listTasks should not execute " + identifier)
+			return tasks.Result{}
+		},
+	}
+}
+
+var _ = Describe("unmatchedTasks()", func() {
+	It("Should return the identifiers and patterns matching no registered task", func() {
+		identifiers := []string{"Base/Config/Validate", "base/collector/*", "Base/Config/Validat", "Not/A/*"}
+		Expect(unmatchedTasks(identifiers)).To(Equal([]string{"Base/Config/Validat", "Not/A/*"}))
+	})
+
+	It("Should return nothing when every entry matches", func() {
+		Expect(unmatchedTasks([]string{"Base/Env/CollectEnvVars"})).To(BeEmpty())
+	})
+})
+
+var _ = Describe("listTasks()", func() {
+	var queue chan tasks.Task
+
+	BeforeEach(func() {
+		queue = make(chan tasks.Task, 2)
+		queue <- listedTask("Base/Config/RegionDetect")
+		queue <- listedTask("Base/Collector/ConnectUS")
+		close(queue)
+	})
+
+	AfterEach(func() {
+		config.Flags.Exclude = ""
+	})
+
+	Context("when tasks are queued", func() {
+		It("Should list them in queue order with their explain text", func() {
+			Expect(listTasks(queue)).To(Equal([]string{
+				"  1. Base/Config/RegionDetect - Explain Base/Config/RegionDetect",
+				"  2. Base/Collector/ConnectUS - Explain Base/Collector/ConnectUS",
+			}))
+		})
+	})
+
+	Context("when a queued task is excluded", func() {
+		BeforeEach(func() {
+			config.Flags.Exclude = "Base/Collector"
+		})
+		It("Should mark it as skipped", func() {
+			Expect(listTasks(queue)).To(Equal([]string{
+				"  1. Base/Config/RegionDetect - Explain Base/Config/RegionDetect",
+				"  2. Base/Collector/ConnectUS - Explain Base/Collector/ConnectUS (skipped via -exclude)",
+			}))
+		})
+	})
+})
+
+// blockingTask returns its result once release is closed
+type blockingTask struct {
+	release chan struct{}
+	ran     chan struct{}
+}
+
+func (t blockingTask) Identifier() tasks.Identifier {
+	return tasks.IdentifierFromString("Base/Test/Blocking")
+}
+
+func (t blockingTask) Explain() string {
+	return "Block until released"
+}
+
+func (t blockingTask) Dependencies() []string {
+	return []string{}
+}
+
+func (t blockingTask) Execute(op tasks.Options, upstream map[string]tasks.Result) tasks.Result {
+	close(t.ran)
+	<-t.release
+	return tasks.Result{Status: tasks.Success, Summary: "released"}
+}
+
+// networkTask is a blockingTask that makes outbound network requests
+type networkTask struct {
+	blockingTask
+}
+
+func (t networkTask) RequiresNetwork() {}
+
+// elevatedTask is a blockingTask that only works as root or Administrator
+type elevatedTask struct {
+	blockingTask
+	requiresElevation bool
+}
+
+func (t elevatedTask) RequiresElevation(options tasks.Options) bool {
+	return t.requiresElevation
+}
+
+var _ = Describe("executeTask()", func() {
+	var task blockingTask
+
+	BeforeEach(func() {
+		task = blockingTask{release: make(chan struct{}), ran: make(chan struct{})}
+	})
+
+	Context("when the task completes before the deadline", func() {
+		It("should return the task result", func() {
+			close(task.release)
+			ctx, cancel := runContext(60)
+			defer cancel()
+
+			result := executeTask(ctx, task, tasks.Options{}, map[string]tasks.Result{})
+
+			Expect(result.Status).To(Equal(tasks.Success))
+			Expect(result.Summary).To(Equal("released"))
+		})
+	})
+
+	Context("when the deadline is reached while the task runs", func() {
+		It("should report the task as cancelled", func() {
+			defer close(task.release)
+			ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+			defer cancel()
+
+			result := executeTask(ctx, task, tasks.Options{}, map[string]tasks.Result{})
+
+			Expect(task.ran).To(BeClosed())
+			Expect(result.Status).To(Equal(tasks.Error))
+			Expect(result.Summary).To(Equal("cancelled: run deadline exceeded"))
+		})
+	})
+
+	Context("when the run is interrupted while the task runs", func() {
+		It("should report the task as interrupted", func() {
+			defer close(task.release)
+			ctx, cancel := context.WithCancel(context.Background())
+			go func() {
+				<-task.ran
+				cancel()
+			}()
+
+			result := executeTask(ctx, task, tasks.Options{}, map[string]tasks.Result{})
+
+			Expect(result.Status).To(Equal(tasks.Error))
+			Expect(result.Summary).To(Equal("cancelled: run interrupted"))
+		})
+	})
+
+	Context("when a network task runs in offline mode", func() {
+		It("should skip the task", func() {
+			config.Flags.Offline = true
+			defer func() { config.Flags.Offline = false }()
+			ctx, cancel := runContext(60)
+			defer cancel()
+
+			result := executeTask(ctx, networkTask{task}, tasks.Options{}, map[string]tasks.Result{})
+
+			Expect(result.Status).To(Equal(tasks.None))
+			Expect(result.Summary).To(Equal("skipped: offline mode"))
+			Expect(task.ran).NotTo(BeClosed())
+		})
+	})
+
+	Context("when a network task runs in collect-only mode", func() {
+		It("should skip the task", func() {
+			config.Flags.CollectOnly = true
+			defer func() { config.Flags.CollectOnly = false }()
+			ctx, cancel := runContext(60)
+			defer cancel()
+
+			result := executeTask(ctx, networkTask{task}, tasks.Options{}, map[string]tasks.Result{})
+
+			Expect(result.Status).To(Equal(tasks.None))
+			Expect(result.Summary).To(Equal("skipped: collect-only mode"))
+			Expect(task.ran).NotTo(BeClosed())
+		})
+	})
+
+	Context("when a local task runs in offline mode", func() {
+		It("should run the task", func() {
+			config.Flags.Offline = true
+			defer func() { config.Flags.Offline = false }()
+			close(task.release)
+			ctx, cancel := runContext(60)
+			defer cancel()
+
+			result := executeTask(ctx, task, tasks.Options{}, map[string]tasks.Result{})
+
+			Expect(result.Status).To(Equal(tasks.Success))
+		})
+	})
+
+	Context("when a task requiring elevation runs without elevated privileges", func() {
+		It("should warn instead of running the task", func() {
+			isElevated = func() bool { return false }
+			defer func() { isElevated = tasks.IsElevated }()
+			ctx, cancel := runContext(60)
+			defer cancel()
+
+			result := executeTask(ctx, elevatedTask{task, true}, tasks.Options{}, map[string]tasks.Result{})
+
+			Expect(result.Status).To(Equal(tasks.Warning))
+			Expect(result.Summary).To(ContainSubstring("re-run Diagnostics CLI"))
+			Expect(task.ran).NotTo(BeClosed())
+		})
+	})
+
+	Context("when a task requiring elevation runs elevated", func() {
+		It("should run the task", func() {
+			isElevated = func() bool { return true }
+			defer func() { isElevated = tasks.IsElevated }()
+			close(task.release)
+			ctx, cancel := runContext(60)
+			defer cancel()
+
+			result := executeTask(ctx, elevatedTask{task, true}, tasks.Options{}, map[string]tasks.Result{})
+
+			Expect(result.Status).To(Equal(tasks.Success))
+		})
+	})
+
+	Context("when a task does not require elevation for this run", func() {
+		It("should run the task", func() {
+			isElevated = func() bool { return false }
+			defer func() { isElevated = tasks.IsElevated }()
+			close(task.release)
+			ctx, cancel := runContext(60)
+			defer cancel()
+
+			result := executeTask(ctx, elevatedTask{task, false}, tasks.Options{}, map[string]tasks.Result{})
+
+			Expect(result.Status).To(Equal(tasks.Success))
+		})
+	})
+
+	Context("when the deadline was reached before the task started", func() {
+		It("should not run the task", func() {
+			ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+			defer cancel()
+
+			result := executeTask(ctx, task, tasks.Options{}, map[string]tasks.Result{})
+
+			Expect(result.Status).To(Equal(tasks.Error))
+			Expect(result.Summary).To(Equal("cancelled: run deadline exceeded"))
+			Expect(task.ran).NotTo(BeClosed())
+		})
+	})
+})
+
+var _ = Describe("registration.RunQueue()", func() {
+	// queued in dependency order, the way registration.AddTaskToQueue adds them
+	dag := []mocks.FakeTask{
+		{ID: "Base/Env/CollectEnvVars"},
+		{ID: "Base/Config/Collect", Deps: []string{"Base/Env/CollectEnvVars"}},
+		{ID: "Base/Config/Validate", Deps: []string{"Base/Config/Collect"}},
+		{ID: "Base/Config/RegionDetect", Deps: []string{"Base/Config/Validate", "Base/Env/CollectEnvVars"}},
+		{ID: "Base/Collector/ConnectUS", Deps: []string{"Base/Config/RegionDetect"}},
+		{ID: "Base/Collector/ConnectEU", Deps: []string{"Base/Config/RegionDetect"}},
+		{ID: "Base/Log/Copy", Deps: []string{"Base/Config/Validate"}},
+		{ID: "Base/Env/HostInfo"},
+		{ID: "Java/Env/Version", Deps: []string{"Base/Env/HostInfo"}},
+	}
+
+	queueOf := func(dag []mocks.FakeTask) chan tasks.Task {
+		queue := make(chan tasks.Task, len(dag))
+		for _, task := range dag {
+			queue <- task
+		}
+		close(queue)
+		return queue
+	}
+
+	for _, concurrency := range []int{1, 2, 4, 16} {
+		concurrency := concurrency
+		Context(fmt.Sprintf("with a concurrency of %d", concurrency), func() {
+			It("should never start a task before the tasks it depends on are done", func() {
+				for run := 0; run < 20; run++ {
+					var lock sync.Mutex
+					started := make(map[string]int)
+					finished := make(map[string]int)
+					events, active, maxActive := 0, 0, 0
+
+					registration.RunQueue(queueOf(dag), concurrency, func(task tasks.Task) {
+						lock.Lock()
+						events++
+						started[task.Identifier().String()] = events
+						active++
+						if active > maxActive {
+							maxActive = active
+						}
+						lock.Unlock()
+
+						time.Sleep(time.Millisecond)
+
+						lock.Lock()
+						events++
+						finished[task.Identifier().String()] = events
+						active--
+						lock.Unlock()
+					})
+
+					Expect(finished).To(HaveLen(len(dag)))
+					for _, task := range dag {
+						for _, dependency := range task.Deps {
+							Expect(finished[dependency]).To(BeNumerically("<", started[task.ID]), task.ID+" started before "+dependency+" was done")
+						}
+					}
+					Expect(maxActive).To(BeNumerically("<=", concurrency))
+				}
+			})
+		})
+	}
+
+	Context("when independent tasks are queued", func() {
+		It("should run them at the same time", func() {
+			var waiting sync.WaitGroup
+			waiting.Add(2)
+			allStarted := make(chan struct{})
+			go func() {
+				waiting.Wait()
+				close(allStarted)
+			}()
+
+			var lock sync.Mutex
+			overlapped := 0
+			registration.RunQueue(queueOf([]mocks.FakeTask{{ID: "Base/Env/CollectEnvVars"}, {ID: "Base/Env/HostInfo"}}), 2, func(task tasks.Task) {
+				waiting.Done()
+				select {
+				case <-allStarted:
+					lock.Lock()
+					overlapped++
+					lock.Unlock()
+				case <-time.After(time.Second):
+				}
+			})
+			Expect(overlapped).To(Equal(2))
+		})
+	})
+
+	Context("when a dependency isn't queued", func() {
+		It("should run the task without waiting for it", func() {
+			var ran []string
+			registration.RunQueue(queueOf([]mocks.FakeTask{{ID: "Base/Config/Validate", Deps: []string{"Base/Config/Collect"}}}), 4, func(task tasks.Task) {
+				ran = append(ran, task.Identifier().String())
+			})
+			Expect(ran).To(Equal([]string{"Base/Config/Validate"}))
+		})
+	})
+})
+
+var _ = Describe("processSingle()", func() {
+	AfterEach(func() {
+		config.Flags.Single = ""
+	})
+
+	It("Should accept the identifier of a registered task in any case", func() {
+		config.Flags.Single = "base/env/collectenvvars"
+		Expect(processSingle()).To(Succeed())
+	})
+	It("Should reject a wildcard", func() {
+		config.Flags.Single = "Base/Env/*"
+		Expect(processSingle()).To(MatchError(ContainSubstring("use -t")))
+	})
+	It("Should reject a list of tasks", func() {
+		config.Flags.Single = "Base/Env/CollectEnvVars,Base/Config/Collect"
+		Expect(processSingle()).To(MatchError(ContainSubstring("use -t")))
+	})
+	It("Should reject an unknown task", func() {
+		config.Flags.Single = "Base/Env/DoesNotExist"
+		Expect(processSingle()).To(MatchError(ContainSubstring("no task matches")))
+	})
+})
+
+var _ = Describe("processCollectOnly()", func() {
+	AfterEach(func() {
+		config.Flags.CollectOnly = false
+		config.Flags.Tasks = ""
+	})
+
+	It("Should accept -collect-only on its own", func() {
+		config.Flags.CollectOnly = true
+		Expect(processCollectOnly()).To(Succeed())
+	})
+	It("Should reject -collect-only with -t", func() {
+		config.Flags.CollectOnly = true
+		config.Flags.Tasks = "Base/Env/*"
+		Expect(processCollectOnly()).To(MatchError(ContainSubstring("can't be combined with -t")))
+	})
+})
+
+var _ = Describe("processResume()", func() {
+	AfterEach(func() {
+		config.Flags.Resume = ""
+		config.Flags.Tasks = ""
+		config.Flags.CollectOnly = false
+		resumedResults = nil
+	})
+
+	It("Should read the results of the earlier output file", func() {
+		path := filepath.Join(GinkgoT().TempDir(), "nrdiag-output.json")
+		Expect(os.WriteFile(path, []byte(`{"RunDate": "2024-01-02T10:00:00Z", "Results": [{"Identifier": {"Category": "Base", "Subcategory": "Config", "Name": "Collect"}, "Result": {"Status": "Success"}}]}`), 0644)).To(Succeed())
+		config.Flags.Resume = path
+		config.Flags.Tasks = "Base/Collector/*"
+		Expect(processResume()).To(Succeed())
+		Expect(resumedResults).To(HaveLen(1))
+	})
+	It("Should reject -resume without -t", func() {
+		config.Flags.Resume = "nrdiag-output.json"
+		Expect(processResume()).To(MatchError(ContainSubstring("select at least one")))
+	})
+	It("Should reject -resume with -collect-only", func() {
+		config.Flags.Resume = "nrdiag-output.json"
+		config.Flags.Tasks = "Base/Collector/*"
+		config.Flags.CollectOnly = true
+		Expect(processResume()).To(MatchError(ContainSubstring("can't be combined")))
+	})
+})
@@ -15,6 +15,7 @@ import (
 	"github.com/newrelic/newrelic-diagnostics-cli/tasks/base/env"
 	logTasks "github.com/newrelic/newrelic-diagnostics-cli/tasks/base/log"
 	browserAgent "github.com/newrelic/newrelic-diagnostics-cli/tasks/browser/agent"
+	dotnetAgent "github.com/newrelic/newrelic-diagnostics-cli/tasks/dotnet/agent"
 	dotnetCoreAgent "github.com/newrelic/newrelic-diagnostics-cli/tasks/dotnetcore/agent"
 	dotnetCoreConfig "github.com/newrelic/newrelic-diagnostics-cli/tasks/dotnetcore/config"
 	dotnetCoreCustInst "github.com/newrelic/newrelic-diagnostics-cli/tasks/dotnetcore/custominstrumentation"
@@ -104,6 +105,7 @@ func init() {
 	iOSLog.RegisterWith(Register)
 	iOSEnv.RegisterWith(Register)
 	dotnetCoreAgent.RegisterWith(Register)
+	dotnetAgent.RegisterWith(Register)
 	browserAgent.RegisterWith(Register)
 	dotnetCoreConfig.RegisterWith(Register)
 	dotnetCoreLog.RegisterWith(Register)
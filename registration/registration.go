@@ -2,9 +2,12 @@ package registration
 
 import (
 	"encoding/json"
-	"regexp"
+	"fmt"
+	"sort"
 	"strings"
+	"time"
 
+	"github.com/newrelic/newrelic-diagnostics-cli/config"
 	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
 	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
 )
@@ -19,19 +22,30 @@ type TaskResult struct {
 	Task        tasks.Task
 	Result      tasks.Result
 	WasOverride bool
+	// Duration is how long Execute took, zero for tasks bypassed by an override or -exclude
+	Duration time.Duration
+	// RunDate is the date of the earlier run of a result carried over by -resume instead of run again, zero for the
+	// results of this run
+	RunDate time.Time
 }
 
 //MarshalJSON - custom JSON marshaling for this task, we'll strip out the passphrase to keep it only in memory, not on disk
 func (tr TaskResult) MarshalJSON() ([]byte, error) {
 	//note: this technique can be used to return anything you want, including modified values or nothing at all.
 	//anything that gets returned here ends up in the output json file
+	var runDate *time.Time
+	if !tr.RunDate.IsZero() {
+		runDate = &tr.RunDate
+	}
 	return json.Marshal(&struct {
 		Identifier tasks.Identifier
 		Override   bool
+		RunDate    *time.Time `json:",omitempty"`
 		Result     tasks.Result
 	}{
 		Identifier: tr.Task.Identifier(),
 		Override:   tr.WasOverride,
+		RunDate:    runDate,
 		Result:     tr.Result,
 	})
 }
@@ -66,12 +80,7 @@ func TasksForIdentifierString(ident string) []tasks.Task {
 	var tasks []tasks.Task
 
 	if strings.Contains(ident, "*") {
-		converter, _ := regexp.Compile(`\*`)
-		matchString := converter.ReplaceAllString(strings.ToLower(ident), ".*")
-		matcher, err := regexp.Compile(matchString)
-		if err != nil {
-			log.Info("Failed to compile identifier regex from '", matchString, "'")
-		}
+		matcher := config.CompileTaskPattern(strings.TrimSpace(ident))
 		for id, regTask := range registeredTasks {
 			if regTask.runByDefault && matcher.MatchString(id) {
 				tasks = append(tasks, regTask.Task)
@@ -86,6 +95,100 @@ func TasksForIdentifierString(ident string) []tasks.Task {
 	return tasks
 }
 
+// CatalogEntry - description of a registered task, as printed by -show-catalog
+type CatalogEntry struct {
+	Identifier   string   `json:"identifier"`
+	Explain      string   `json:"explain"`
+	Dependencies []string `json:"dependencies"`
+}
+
+// Catalog - returns every registered task, including those that only run when requested, sorted by identifier
+func Catalog() []CatalogEntry {
+	catalog := []CatalogEntry{}
+	for _, regTask := range registeredTasks {
+		dependencies := regTask.Task.Dependencies()
+		if dependencies == nil {
+			dependencies = []string{}
+		}
+		catalog = append(catalog, CatalogEntry{
+			Identifier:   regTask.Task.Identifier().String(),
+			Explain:      regTask.Task.Explain(),
+			Dependencies: dependencies,
+		})
+	}
+	sort.Slice(catalog, func(i, j int) bool {
+		return catalog[i].Identifier < catalog[j].Identifier
+	})
+	return catalog
+}
+
+// CheckDependencyCycles - returns an error naming the first cycle found in the dependencies of the registered tasks, e.g.
+// "A -> B -> A" for two tasks depending on each other. AddTaskToQueue would recurse forever on such a cycle
+func CheckDependencyCycles() error {
+	cycle := findDependencyCycle()
+	if cycle == nil {
+		return nil
+	}
+	return fmt.Errorf("dependency cycle in the registered tasks: %s", strings.Join(cycle, " -> "))
+}
+
+// findDependencyCycle - walks the dependencies of every registered task depth first and returns the identifiers of the
+// first cycle found, starting and ending with the same task, or nil if there are none
+func findDependencyCycle() []string {
+	const (
+		visiting = iota + 1
+		visited
+	)
+	state := make(map[string]int)
+	var path []string
+
+	var visit func(task tasks.Task) []string
+	visit = func(task tasks.Task) []string {
+		ident := task.Identifier().String()
+		key := strings.ToLower(ident)
+		switch state[key] {
+		case visited:
+			return nil
+		case visiting:
+			for i, pathIdent := range path {
+				if strings.EqualFold(pathIdent, ident) {
+					return append(append([]string{}, path[i:]...), ident)
+				}
+			}
+		}
+
+		state[key] = visiting
+		path = append(path, ident)
+		for _, depIdent := range task.Dependencies() {
+			deps := TasksForIdentifierString(depIdent)
+			sort.Slice(deps, func(i, j int) bool {
+				return deps[i].Identifier().String() < deps[j].Identifier().String()
+			})
+			for _, dep := range deps {
+				if cycle := visit(dep); cycle != nil {
+					return cycle
+				}
+			}
+		}
+		path = path[:len(path)-1]
+		state[key] = visited
+		return nil
+	}
+
+	// sorted, like the tasks matched by wildcards, so that the same cycle is reported on every run
+	var keys []string
+	for key := range registeredTasks {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if cycle := visit(registeredTasks[key].Task); cycle != nil {
+			return cycle
+		}
+	}
+	return nil
+}
+
 // AddAllToQueue - adds in all tasks that have been registered
 func AddAllToQueue() {
 	log.Debugf("Adding %d tasks to queue\n", len(registeredTasks))
@@ -128,7 +231,24 @@ func AddIdentifierToQueue(ident tasks.Identifier) {
 	}
 }
 
-// AddTaskToQueue - adds in a new task and resolves it's dependencies, could be prone to dependency loops
+// AddIdentifiersWithoutDependencies - adds the tasks to the work queue in the given order without resolving their dependencies.
+// The dependencies that are not queued are passed to the tasks as empty results
+func AddIdentifiersWithoutDependencies(idents []string) {
+	for _, ident := range idents {
+		regTask := registeredTasks[strings.ToLower(ident)]
+		if regTask.Task == nil {
+			log.Debug(" * Could not find task", ident)
+			continue
+		}
+		if _, ok := queuedTasks[regTask.Task.Identifier()]; !ok {
+			queuedTasks[regTask.Task.Identifier()] = true
+			Work.WorkQueue <- regTask.Task
+		}
+	}
+}
+
+// AddTaskToQueue - adds in a new task and resolves it's dependencies. CheckDependencyCycles must pass first, a dependency
+// loop would recurse forever
 func AddTaskToQueue(p tasks.Task) {
 	//QueuedTasks := make(map[tasks.Identifier]string)
 	//dent := p.Identifier().String()
@@ -138,10 +258,6 @@ func AddTaskToQueue(p tasks.Task) {
 		AddTasksByIdentifier(depIdent)
 	}
 
-	// somewhere in here may be a good place to detect dependency loops...
-	// if this task has dependencies *and* it's already in the  task list we are in an invalid state
-	// since it would be impossible to resolve the dependencies beore it runs
-
 	// if we have already created a key for the results then we aren't in the queue yet
 	log.Debug("Checking queue for ", p.Identifier(), ": ", queuedTasks[p.Identifier()])
 	if _, ok := queuedTasks[p.Identifier()]; !ok {
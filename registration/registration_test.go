@@ -3,6 +3,7 @@ package registration
 import (
 	"testing"
 
+	"github.com/newrelic/newrelic-diagnostics-cli/mocks"
 	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
 )
 
@@ -39,8 +40,28 @@ func TestRegisterDependentTasks(t *testing.T) {
 	AddIdentifierToQueue(tasks.IdentifierFromString("Base/Config/Validate"))
 	CompleteTaskRegistration()
 
-	if len(Work.WorkQueue) != 4 { //the expected length of the queue may have to continue going up as Base/Config/Validate becomes dependent on new nrdiag tasks that must be run prior to it
-		t.Error("WorkQueue expected to have 3 items after adding Base/Config/Validate; has:", len(Work.WorkQueue))
+	if len(Work.WorkQueue) != 6 { //the expected length of the queue may have to continue going up as Base/Config/Validate becomes dependent on new nrdiag tasks that must be run prior to it
+		t.Error("WorkQueue expected to have 6 items after adding Base/Config/Validate; has:", len(Work.WorkQueue))
+	}
+}
+
+func TestRegisterTasksWithoutDependencies(t *testing.T) {
+	// re-init the results struct
+	Work.Results = make(map[string]TaskResult)
+	// make a large channel so we don't block
+	Work.WorkQueue = make(chan tasks.Task, 100)
+	// re-init the queue
+	queuedTasks = make(map[tasks.Identifier]bool)
+
+	AddIdentifiersWithoutDependencies([]string{"Base/Config/Collect", "base/config/validate", "Base/Config/Collect", "Not/A/Task"})
+	CompleteTaskRegistration()
+
+	var queued []string
+	for task := range Work.WorkQueue {
+		queued = append(queued, task.Identifier().String())
+	}
+	if len(queued) != 2 || queued[0] != "Base/Config/Collect" || queued[1] != "Base/Config/Validate" {
+		t.Error("WorkQueue expected to hold Base/Config/Collect then Base/Config/Validate only; has:", queued)
 	}
 }
 
@@ -76,3 +97,189 @@ func TestTasksHaveValidExplain(t *testing.T) {
 		}
 	}
 }
+
+func TestTasksForIdentifierStringWildcard(t *testing.T) {
+	matched := TasksForIdentifierString("base/collector/*")
+	if len(matched) == 0 {
+		t.Fatal("expected base/collector/* to match the collector tasks")
+	}
+	for _, task := range matched {
+		if task.Identifier().Category != "Base" || task.Identifier().Subcategory != "Collector" {
+			t.Error("base/collector/* unexpectedly matched", task.Identifier().String())
+		}
+	}
+
+	for _, task := range TasksForIdentifierString("Collector/*") {
+		t.Error("patterns are anchored, Collector/* unexpectedly matched", task.Identifier().String())
+	}
+}
+
+func TestCatalog(t *testing.T) {
+	catalog := Catalog()
+	if len(catalog) != len(registeredTasks) {
+		t.Fatal("Catalog expected to list every registered task: ", len(catalog), " vs. ", len(registeredTasks))
+	}
+	for i, entry := range catalog {
+		if entry.Dependencies == nil {
+			t.Error(entry.Identifier, " has nil dependencies, expected an empty list")
+		}
+		if i > 0 && catalog[i-1].Identifier >= entry.Identifier {
+			t.Error("Catalog expected to be sorted by identifier: ", catalog[i-1].Identifier, " listed before ", entry.Identifier)
+		}
+	}
+}
+
+func TestCheckDependencyCycles(t *testing.T) {
+	original := registeredTasks
+	defer func() { registeredTasks = original }()
+
+	tests := []struct {
+		name      string
+		fakeTasks []mocks.FakeTask
+		want      string
+	}{
+		{
+			name: "no cycle",
+			fakeTasks: []mocks.FakeTask{
+				{ID: "Fake/Test/A", Deps: []string{"Fake/Test/B", "Fake/Test/C"}},
+				{ID: "Fake/Test/B", Deps: []string{"Fake/Test/C"}},
+				{ID: "Fake/Test/C"},
+			},
+		},
+		{
+			name: "two tasks depending on each other",
+			fakeTasks: []mocks.FakeTask{
+				{ID: "Fake/Test/A", Deps: []string{"Fake/Test/B"}},
+				{ID: "Fake/Test/B", Deps: []string{"Fake/Test/A"}},
+			},
+			want: "dependency cycle in the registered tasks: Fake/Test/A -> Fake/Test/B -> Fake/Test/A",
+		},
+		{
+			name: "task depending on itself",
+			fakeTasks: []mocks.FakeTask{
+				{ID: "Fake/Test/A", Deps: []string{"fake/test/a"}},
+			},
+			want: "dependency cycle in the registered tasks: Fake/Test/A -> Fake/Test/A",
+		},
+		{
+			name: "cycle through a wildcard below an acyclic task",
+			fakeTasks: []mocks.FakeTask{
+				{ID: "Fake/Test/A", Deps: []string{"Fake/Loop/B"}},
+				{ID: "Fake/Loop/B", Deps: []string{"Fake/Loop/C"}},
+				{ID: "Fake/Loop/C", Deps: []string{"Fake/Loop/*"}},
+			},
+			want: "dependency cycle in the registered tasks: Fake/Loop/B -> Fake/Loop/C -> Fake/Loop/B",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registeredTasks = make(map[string]registeredTask)
+			for _, task := range tt.fakeTasks {
+				Register(task, true)
+			}
+
+			err := CheckDependencyCycles()
+			if tt.want == "" && err != nil {
+				t.Errorf("CheckDependencyCycles() = %v, want no error", err)
+			}
+			if tt.want != "" && (err == nil || err.Error() != tt.want) {
+				t.Errorf("CheckDependencyCycles() = %v, want %s", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestRegisteredTasksHaveNoDependencyCycles(t *testing.T) {
+	if err := CheckDependencyCycles(); err != nil {
+		t.Error(err)
+	}
+}
+
+// networkTasks are the registered tasks making outbound requests, through httpHelper, the New Relic backend or a raw
+// connection. -offline only skips them if they are marked as such, add any new one here. Infra/Agent/Version is left
+// out: it still reports the installed version with -offline, without the release date lookup
+var networkTasks = []string{
+	"Base/Collector/Compression",
+	"Base/Collector/ConnectDualStack",
+	"Base/Collector/ConnectEU",
+	"Base/Collector/ConnectGov",
+	"Base/Collector/ConnectLogApi",
+	"Base/Collector/ConnectNerdGraph",
+	"Base/Collector/ConnectOTLP",
+	"Base/Collector/ConnectTLS",
+	"Base/Collector/ConnectUS",
+	"Base/Collector/DNSResolve",
+	"Base/Collector/LargePayload",
+	"Base/Collector/RecentData",
+	"Base/Collector/Traceroute",
+	"Base/Config/ValidateHSM",
+	"Base/Config/ValidateLicenseKey",
+	"Base/Env/DetectAWS",
+	"Base/Env/KubernetesIntegration",
+	"Browser/Agent/GetSource",
+	"Browser/Agent/Snippet",
+	"Infra/Agent/Connect",
+	"Infra/Env/ClockSkew",
+	"Synthetics/Minion/Connect",
+	"Synthetics/Minion/HordeConnect",
+}
+
+func TestNetworkTasksAreNetworkDependent(t *testing.T) {
+	for _, identifier := range networkTasks {
+		registered := TasksForIdentifierString(identifier)
+		if len(registered) == 0 {
+			// some tasks are only registered on the OS they apply to
+			t.Logf("%s is not registered on this OS", identifier)
+			continue
+		}
+		if !tasks.IsNetworkDependent(registered[0]) {
+			t.Errorf("%s makes outbound requests but does not implement RequiresNetwork, -offline would run it", identifier)
+		}
+	}
+}
+
+// directNetworkTasks don't go through the proxy: DNS lookups, raw connections and requests that bypass it
+var directNetworkTasks = []string{
+	"Base/Collector/DNSResolve",
+	"Base/Collector/Traceroute",
+	"Base/Env/DetectAWS",
+	"Base/Env/KubernetesIntegration",
+}
+
+// dependsOn returns whether the task registered as identifier depends on target, directly or through its dependencies
+func dependsOn(identifier string, target string, seen map[string]bool) bool {
+	if seen[identifier] {
+		return false
+	}
+	seen[identifier] = true
+	registered := TasksForIdentifierString(identifier)
+	if len(registered) == 0 {
+		return false
+	}
+	for _, dependency := range registered[0].Dependencies() {
+		if dependency == target || dependsOn(dependency, target, seen) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestNetworkTasksRunAfterProxyDetect(t *testing.T) {
+	for _, identifier := range networkTasks {
+		if len(TasksForIdentifierString(identifier)) == 0 || containsIdentifier(directNetworkTasks, identifier) {
+			continue
+		}
+		if !dependsOn(identifier, "Base/Config/ProxyDetect", map[string]bool{}) {
+			t.Errorf("%s goes through the proxy but does not depend on Base/Config/ProxyDetect, it could run before the proxy is set", identifier)
+		}
+	}
+}
+
+func containsIdentifier(identifiers []string, identifier string) bool {
+	for _, i := range identifiers {
+		if i == identifier {
+			return true
+		}
+	}
+	return false
+}
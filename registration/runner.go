@@ -0,0 +1,297 @@
+package registration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/helpers/httpHelper"
+	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+)
+
+// The summaries of the tasks cut short by the cancellation of the run context, e.g. by -deadline or Ctrl-C
+const (
+	RunDeadlineSummary    = "cancelled: run deadline exceeded"
+	RunInterruptedSummary = "cancelled: run interrupted"
+)
+
+// Runner runs queued tasks and passes each one the results of the tasks it depends on. It holds none of the command
+// line state: the CLI fills it in from its flags, and the nrdiag package from the options of the program embedding it
+type Runner struct {
+	// Options are passed to every task, and TaskOptions, keyed by task identifier, to that task only. A Status or
+	// Payload option sets the result of the task instead of running it
+	Options     tasks.Options
+	TaskOptions map[string]map[string]string
+	// Concurrency is the maximum number of tasks run at the same time, 1 or less runs them one after the other
+	Concurrency int
+	// Exclude returns true for the tasks reported with a None result without running, whatever their options
+	Exclude func(task tasks.Task) bool
+	// Forced returns true for the tasks explicitly requested, which get the tasks.ForcedOption: some tasks only run then
+	Forced func(task tasks.Task) bool
+	// Skip returns the result of a task that is not to run in this environment, e.g. a network task when offline. It
+	// gets the options the task would run with
+	Skip func(task tasks.Task, options tasks.Options) (tasks.Result, bool)
+	// HTTPClient is the client the tasks make their HTTP requests with while Run runs, see httpHelper.SetClient. nil
+	// leaves the client in place, the proxy aware httpHelper.Client unless set otherwise
+	HTTPClient *http.Client
+
+	lock    sync.RWMutex
+	results map[string]TaskResult
+}
+
+// excludedSummary is the summary of the tasks left out by Runner.Exclude
+const excludedSummary = "Task skipped via -exclude"
+
+// Run runs the tasks read from the queue until it is closed, and calls done with the result of each one as it
+// completes. The queue must list each task after the tasks it depends on, see TaskQueue. With a Concurrency above 1,
+// done is called from the goroutines running the tasks, concurrently: it must guard the state it shares
+func (r *Runner) Run(ctx context.Context, queue <-chan tasks.Task, done func(TaskResult)) {
+	if r.HTTPClient != nil {
+		httpHelper.SetClient(r.HTTPClient)
+		defer httpHelper.SetClient(nil)
+	}
+	RunQueue(queue, r.Concurrency, func(task tasks.Task) {
+		done(r.runTask(ctx, task))
+	})
+}
+
+// Result returns the result of a task run by Run, and an empty TaskResult for a task that didn't run
+func (r *Runner) Result(identifier string) TaskResult {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	return r.results[identifier]
+}
+
+// runTask runs a single task, or applies its options or the exclusion, and records the result for the tasks depending on it
+func (r *Runner) runTask(ctx context.Context, task tasks.Task) TaskResult {
+	identifier := task.Identifier().String()
+	// each task gets a copy to avoid carrying the options of another one
+	taskOptions := make(map[string]string)
+	for key, value := range r.Options.Options {
+		taskOptions[key] = value
+	}
+	if r.Forced != nil && r.Forced(task) {
+		taskOptions[tasks.ForcedOption] = "true"
+	}
+	for taskIdentifier, options := range r.TaskOptions {
+		if strings.EqualFold(taskIdentifier, identifier) {
+			for key, value := range options {
+				log.Debug("Adding override to task options", key, ":", value)
+				taskOptions[key] = value
+			}
+		}
+	}
+	namedTaskOptions := tasks.Options{Options: taskOptions}
+
+	// Check for dependancies on the task and include results if dependent
+	dependentResults := make(map[string]tasks.Result)
+	for _, depIdent := range task.Dependencies() {
+		dependentResults[depIdent] = r.Result(depIdent).Result
+	}
+
+	log.Debug("Starting", task.Identifier(), "with options", namedTaskOptions)
+	result, overrideEnabled := overrideResult(task, namedTaskOptions)
+	var duration time.Duration
+	// the exclusion takes precedence over everything else. The task is still reported so the output accounts for it
+	if r.Exclude != nil && r.Exclude(task) {
+		log.Debug("Skipping", task.Identifier(), "excluded via -exclude")
+		result = tasks.Result{
+			Status:  tasks.None,
+			Summary: excludedSummary,
+		}
+	} else if !overrideEnabled {
+		if skipped, skip := r.skip(task, namedTaskOptions); skip {
+			result = skipped
+		} else {
+			started := time.Now()
+			result = ExecuteTask(ctx, task, namedTaskOptions, dependentResults)
+			duration = time.Since(started)
+		}
+	}
+
+	taskResult := TaskResult{
+		Task:        task,
+		Result:      result,
+		WasOverride: overrideEnabled,
+		Duration:    duration,
+	}
+	r.lock.Lock()
+	if r.results == nil {
+		r.results = make(map[string]TaskResult)
+	}
+	r.results[identifier] = taskResult
+	r.lock.Unlock()
+	return taskResult
+}
+
+func (r *Runner) skip(task tasks.Task, options tasks.Options) (tasks.Result, bool) {
+	if r.Skip == nil {
+		return tasks.Result{}, false
+	}
+	return r.Skip(task, options)
+}
+
+// overrideResult returns the result set by the Status and Payload options of a task, and whether there was one, in
+// which case the task is not run
+func overrideResult(task tasks.Task, options tasks.Options) (tasks.Result, bool) {
+	var result tasks.Result
+	overrideEnabled := false
+	if status, ok := options.Options["Status"]; ok {
+		log.Debug("Override Status passed in for ", task.Identifier(), "Value of ", status)
+		switch strings.ToLower(status) {
+		case "success":
+			result.Status = tasks.Success
+		case "warning":
+			result.Status = tasks.Warning
+		case "failure":
+			result.Status = tasks.Failure
+		case "info":
+			result.Status = tasks.Info
+		case "error":
+			result.Status = tasks.Error
+		case "none":
+			result.Status = tasks.None
+		default:
+			log.Warn("Attempted to set status override to invalid status", status)
+		}
+		result.Summary += "Status set by override to " + status + "\n"
+		overrideEnabled = true
+	}
+	if payload, ok := options.Options["Payload"]; ok {
+		log.Debug("Override Payload passed in for ", task.Identifier())
+		result.Payload = payload
+		result.Summary += "Payload set by override\n"
+		overrideEnabled = true
+	}
+	return result, overrideEnabled
+}
+
+// ExecuteTask runs a task unless the context is done. Tasks can't be interrupted, so a task still running when the
+// context is done is abandoned and reported as an error; the HTTP requests it has in flight are cancelled with the
+// run context of httpHelper
+func ExecuteTask(ctx context.Context, task tasks.Task, options tasks.Options, upstream map[string]tasks.Result) tasks.Result {
+	if ctx.Err() != nil {
+		log.Debug("Not running", task.Identifier(), ctx.Err())
+		return cancelledResult(ctx)
+	}
+
+	done := make(chan tasks.Result, 1)
+	go func() {
+		done <- task.Execute(options, upstream)
+	}()
+
+	select {
+	case result := <-done:
+		return result
+	case <-ctx.Done():
+		log.Debug("Abandoning", task.Identifier(), ctx.Err())
+		return cancelledResult(ctx)
+	}
+}
+
+func cancelledResult(ctx context.Context) tasks.Result {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return tasks.Result{Status: tasks.Error, Summary: RunDeadlineSummary}
+	}
+	return tasks.Result{Status: tasks.Error, Summary: RunInterruptedSummary}
+}
+
+// RunQueue runs the tasks read from the queue, up to concurrency of them at a time. The queue lists each task after the
+// tasks it depends on, and a task only starts once those are done. A concurrency of 1 or less runs the tasks one after
+// the other in queue order
+func RunQueue(queue <-chan tasks.Task, concurrency int, run func(tasks.Task)) {
+	if concurrency <= 1 {
+		for task := range queue {
+			run(task)
+		}
+		return
+	}
+
+	workers := make(chan struct{}, concurrency)
+	done := make(map[string]chan struct{})
+	var running sync.WaitGroup
+	for task := range queue {
+		var dependencies []chan struct{}
+		for _, depIdent := range task.Dependencies() {
+			// dependencies that aren't queued, e.g. with -validate-config, have nothing to wait for
+			if depDone, ok := done[depIdent]; ok {
+				dependencies = append(dependencies, depDone)
+			}
+		}
+		taskDone := make(chan struct{})
+		done[task.Identifier().String()] = taskDone
+
+		running.Add(1)
+		go func(task tasks.Task) {
+			defer running.Done()
+			defer close(taskDone)
+			for _, depDone := range dependencies {
+				<-depDone
+			}
+			workers <- struct{}{}
+			defer func() { <-workers }()
+			run(task)
+		}(task)
+	}
+	running.Wait()
+}
+
+// TaskQueue returns the registered tasks matching the identifiers, which can have wildcards, each one after the tasks
+// it depends on. No identifier selects the tasks run by default. Unlike AddTasksByIdentifiers it leaves the work
+// queue of the CLI alone, so it can be called any number of times
+func TaskQueue(identifiers []string) ([]tasks.Task, error) {
+	if err := CheckDependencyCycles(); err != nil {
+		return nil, err
+	}
+
+	var selected []tasks.Task
+	if len(identifiers) == 0 {
+		for _, regTask := range registeredTasks {
+			if regTask.runByDefault {
+				selected = append(selected, regTask.Task)
+			}
+		}
+	} else {
+		var unmatched []string
+		for _, ident := range identifiers {
+			matched := TasksForIdentifierString(strings.TrimSpace(ident))
+			if len(matched) == 0 {
+				unmatched = append(unmatched, ident)
+			}
+			selected = append(selected, matched...)
+		}
+		if len(unmatched) > 0 {
+			return nil, fmt.Errorf("no task matches %s", strings.Join(unmatched, ", "))
+		}
+	}
+	// the tasks matched by wildcards and the default ones come from a map, sorting keeps the order the same on every call
+	sort.SliceStable(selected, func(i, j int) bool {
+		return selected[i].Identifier().String() < selected[j].Identifier().String()
+	})
+
+	var queue []tasks.Task
+	queued := make(map[tasks.Identifier]bool)
+	var add func(task tasks.Task)
+	add = func(task tasks.Task) {
+		if queued[task.Identifier()] {
+			return
+		}
+		for _, depIdent := range task.Dependencies() {
+			for _, dep := range TasksForIdentifierString(depIdent) {
+				add(dep)
+			}
+		}
+		queued[task.Identifier()] = true
+		queue = append(queue, task)
+	}
+	for _, task := range selected {
+		add(task)
+	}
+	return queue, nil
+}
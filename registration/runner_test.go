@@ -0,0 +1,179 @@
+package registration
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/helpers/httpHelper"
+	"github.com/newrelic/newrelic-diagnostics-cli/mocks"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+)
+
+// upstreamTask reports the statuses of the results it was given for its dependencies
+func upstreamTask(identifier string, dependencies ...string) mocks.FakeTask {
+	return mocks.FakeTask{
+		ID:   identifier,
+		Deps: dependencies,
+		ExecuteFunc: func(options tasks.Options, upstream map[string]tasks.Result) tasks.Result {
+			summary := options.Options["greeting"]
+			for _, dependency := range dependencies {
+				summary += " " + dependency + "=" + upstream[dependency].StatusToString()
+			}
+			return tasks.Result{Status: tasks.Success, Summary: summary}
+		},
+	}
+}
+
+func TestTaskQueue(t *testing.T) {
+	queue, err := TaskQueue([]string{"Base/Config/Validate"})
+	if err != nil {
+		t.Fatal("TaskQueue() error:", err)
+	}
+	position := make(map[string]int)
+	for i, task := range queue {
+		position[task.Identifier().String()] = i
+	}
+	// the same tasks as the CLI queues, see TestRegisterDependentTasks
+	if len(queue) != 6 || position["Base/Config/Collect"] >= position["Base/Config/Validate"] {
+		t.Errorf("TaskQueue() = %v, expected Base/Config/Validate after its 5 dependencies", queue)
+	}
+
+	again, _ := TaskQueue([]string{"Base/Config/Validate"})
+	for i := range queue {
+		if again[i].Identifier() != queue[i].Identifier() {
+			t.Errorf("TaskQueue() returned %v then %v", queue, again)
+			break
+		}
+	}
+
+	if _, err := TaskQueue([]string{"Base/Config/Validate", "Not/A/Task"}); err == nil || err.Error() != "no task matches Not/A/Task" {
+		t.Errorf("TaskQueue() error = %v, expected the unmatched identifier", err)
+	}
+}
+
+func TestRunner_Run(t *testing.T) {
+	queue := make(chan tasks.Task, 4)
+	queue <- upstreamTask("Test/Runner/First")
+	queue <- upstreamTask("Test/Runner/Overridden")
+	queue <- upstreamTask("Test/Runner/Excluded")
+	queue <- upstreamTask("Test/Runner/Last", "Test/Runner/First", "Test/Runner/Overridden", "Test/Runner/Excluded")
+	close(queue)
+
+	runner := Runner{
+		Options: tasks.Options{Options: map[string]string{"greeting": "hello"}},
+		TaskOptions: map[string]map[string]string{
+			"test/runner/overridden": {"Status": "failure"},
+			"Test/Runner/Last":       {"greeting": "hi"},
+		},
+		Concurrency: 2,
+		Exclude: func(task tasks.Task) bool {
+			return task.Identifier().String() == "Test/Runner/Excluded"
+		},
+	}
+	var doneLock sync.Mutex
+	var done []string
+	runner.Run(context.Background(), queue, func(result TaskResult) {
+		doneLock.Lock()
+		defer doneLock.Unlock()
+		done = append(done, result.Task.Identifier().String())
+	})
+
+	if len(done) != 4 || done[3] != "Test/Runner/Last" {
+		t.Errorf("Run() completed %v, expected Test/Runner/Last after its dependencies", done)
+	}
+	if overridden := runner.Result("Test/Runner/Overridden"); overridden.Result.Status != tasks.Failure || !overridden.WasOverride {
+		t.Errorf("Run() result = %+v, expected the Failure status set by the option", overridden)
+	}
+	if excluded := runner.Result("Test/Runner/Excluded").Result; excluded.Status != tasks.None || excluded.Summary != excludedSummary {
+		t.Errorf("Run() result = %+v, expected the task to be excluded", excluded)
+	}
+	want := "hi Test/Runner/First=Success Test/Runner/Overridden=Failure Test/Runner/Excluded=None"
+	if last := runner.Result("Test/Runner/Last").Result; last.Summary != want {
+		t.Errorf("Run() summary = %q, want %q", last.Summary, want)
+	}
+}
+
+func TestRunner_Skip(t *testing.T) {
+	queue := make(chan tasks.Task, 1)
+	queue <- upstreamTask("Test/Runner/Skipped")
+	close(queue)
+
+	runner := Runner{
+		Skip: func(task tasks.Task, options tasks.Options) (tasks.Result, bool) {
+			return tasks.Result{Status: tasks.None, Summary: "skipped: offline mode"}, true
+		},
+	}
+	runner.Run(context.Background(), queue, func(TaskResult) {})
+	if skipped := runner.Result("Test/Runner/Skipped").Result; skipped.Summary != "skipped: offline mode" {
+		t.Errorf("Run() result = %+v, expected the result of Skip", skipped)
+	}
+}
+
+func TestRunner_Forced(t *testing.T) {
+	forcedTask := func(identifier string) mocks.FakeTask {
+		return mocks.FakeTask{
+			ID: identifier,
+			ExecuteFunc: func(options tasks.Options, upstream map[string]tasks.Result) tasks.Result {
+				if options.IsForced() {
+					return tasks.Result{Status: tasks.Success}
+				}
+				return tasks.Result{Status: tasks.None}
+			},
+		}
+	}
+	queue := make(chan tasks.Task, 2)
+	queue <- forcedTask("Test/Runner/Forced")
+	queue <- forcedTask("Test/Runner/Default")
+	close(queue)
+
+	runner := Runner{
+		Forced: func(task tasks.Task) bool {
+			return task.Identifier().String() == "Test/Runner/Forced"
+		},
+	}
+	runner.Run(context.Background(), queue, func(TaskResult) {})
+	if forced := runner.Result("Test/Runner/Forced").Result; forced.Status != tasks.Success {
+		t.Errorf("Run() result = %+v, expected the task to get the forced option", forced)
+	}
+	if other := runner.Result("Test/Runner/Default").Result; other.Status != tasks.None {
+		t.Errorf("Run() result = %+v, expected the task not to get the forced option", other)
+	}
+}
+
+// requestTask makes a request through httpHelper and reports the response status
+func requestTask(identifier string) mocks.FakeTask {
+	return mocks.FakeTask{
+		ID: identifier,
+		ExecuteFunc: func(options tasks.Options, upstream map[string]tasks.Result) tasks.Result {
+			resp, err := httpHelper.MakeHTTPRequest(httpHelper.RequestWrapper{Method: "GET", URL: "https://collector.newrelic.invalid/status"})
+			if err != nil {
+				return tasks.Result{Status: tasks.Error, Summary: err.Error()}
+			}
+			return tasks.Result{Status: tasks.Success, Summary: resp.Status}
+		},
+	}
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestRunner_HTTPClient(t *testing.T) {
+	queue := make(chan tasks.Task, 1)
+	queue <- requestTask("Test/Runner/Request")
+	close(queue)
+
+	runner := Runner{
+		HTTPClient: &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			return &http.Response{StatusCode: http.StatusAccepted, Status: "202 Accepted", Body: http.NoBody, Request: req}, nil
+		})},
+	}
+	runner.Run(context.Background(), queue, func(TaskResult) {})
+	if result := runner.Result("Test/Runner/Request").Result; result.Summary != "202 Accepted" {
+		t.Errorf("Run() result = %+v, expected the response of the HTTP client of the runner", result)
+	}
+}
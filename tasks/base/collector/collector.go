@@ -1,6 +1,10 @@
 package collector
 
 import (
+	"net"
+	"runtime"
+	"time"
+
 	"github.com/newrelic/newrelic-diagnostics-cli/helpers/httpHelper"
 	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
 	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
@@ -10,12 +14,42 @@ import (
 func RegisterWith(registrationFunc func(tasks.Task, bool)) {
 	log.Debug("Registering Base/Collector/*")
 
-	registrationFunc(BaseCollectorConnectUS{
+	registrationFunc(BaseCollectorDNSResolve{
+		lookupHost: net.LookupHost,
+	}, true)
+	for _, region := range collectorRegions {
+		registrationFunc(BaseCollectorConnect{
+			region:     region,
+			httpGetter: httpHelper.MakeHTTPRequest,
+		}, true)
+	}
+	registrationFunc(BaseCollectorConnectDualStack{
+		httpGetter: httpHelper.MakeHTTPRequest,
+	}, true)
+	registrationFunc(BaseCollectorLargePayload{
+		httpGetter: httpHelper.MakeHTTPRequest,
+	}, true)
+	registrationFunc(BaseCollectorCompression{
+		httpGetter: httpHelper.MakeHTTPRequest,
+	}, true)
+	registrationFunc(BaseCollectorConnectLogAPI{
+		httpGetter: httpHelper.MakeHTTPRequest,
+	}, true)
+	registrationFunc(BaseCollectorConnectNerdGraph{
+		httpGetter: httpHelper.MakeHTTPRequest,
+	}, true)
+	registrationFunc(BaseCollectorRecentData{
 		httpGetter: httpHelper.MakeHTTPRequest,
+		now:        time.Now,
 	}, true)
-	registrationFunc(BaseCollectorConnectEU{
+	registrationFunc(BaseCollectorConnectOTLP{
 		httpGetter: httpHelper.MakeHTTPRequest,
+		dialer:     net.DialTimeout,
 	}, true)
+	registrationFunc(BaseCollectorTraceroute{
+		cmdExec:     tasks.CmdExecutor,
+		runtimeGOOS: runtime.GOOS,
+	}, false)
 	registrationFunc(BaseCollectorTLS{
 		httpGetter: httpHelper.MakeHTTPRequest,
 	}, true)
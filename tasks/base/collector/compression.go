@@ -0,0 +1,163 @@
+package collector
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"strconv"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/helpers/httpHelper"
+
+	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+)
+
+// compressionBody - the body posted to the collector, once as is and once gzip encoded. Agent payloads are JSON
+var compressionBody = []byte(`[{"nrdiag":"compression check"}]`)
+
+// compressionResponseMaxBytes - how much of each response body is kept in the payload
+const compressionResponseMaxBytes = 512
+
+// CompressionResponse - outcome of posting the body with a single Content-Encoding
+type CompressionResponse struct {
+	ContentEncoding string
+	StatusCode      int    `json:",omitempty"`
+	Body            string `json:",omitempty"`
+	Error           string `json:",omitempty"`
+}
+
+// CompressionStatus - outcome of posting the same body uncompressed and gzip encoded to a collector
+type CompressionStatus struct {
+	URL          string
+	Uncompressed CompressionResponse
+	Gzip         CompressionResponse
+}
+
+// compressionPayloadVersion - the PayloadVersion of the results, bump it when CompressionStatus changes shape
+const compressionPayloadVersion = 1
+
+// BaseCollectorCompression - This task posts the same body uncompressed and gzip encoded to a collector the ping reached and checks both get the same response
+type BaseCollectorCompression struct {
+	httpGetter requestFunc
+}
+
+// Identifier - This returns the Category, Subcategory and Name of each task
+func (p BaseCollectorCompression) Identifier() tasks.Identifier {
+	return tasks.IdentifierFromString("Base/Collector/Compression")
+}
+
+// Explain - Returns the help text for each individual task
+func (p BaseCollectorCompression) Explain() string {
+	return "Check that gzip encoded request bodies, as sent by the agents, reach the New Relic collector intact, to detect proxies rewriting them" + tasks.TimeoutExplanation
+}
+
+// Dependencies - This task depends on Base/Config/ProxyDetect and the collector connect checks
+func (p BaseCollectorCompression) Dependencies() []string {
+	dependencies := []string{
+		"Base/Config/ProxyDetect",
+	}
+	for _, region := range collectorRegions {
+		dependencies = append(dependencies, region.identifier)
+	}
+	return dependencies
+}
+
+// RequiresNetwork - This task posts data to the collector, it is skipped with -offline
+func (p BaseCollectorCompression) RequiresNetwork() {}
+
+// Execute - Posts the body uncompressed then gzip encoded to the first collector whose ping succeeded
+func (p BaseCollectorCompression) Execute(op tasks.Options, upstream map[string]tasks.Result) tasks.Result {
+	region, ok := connectedRegion(upstream)
+	if !ok {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "No collector connect check succeeded, skipping the compression check",
+		}
+	}
+
+	var gzipped bytes.Buffer
+	writer := gzip.NewWriter(&gzipped)
+	writer.Write(compressionBody)
+	writer.Close()
+
+	url := "https://" + region.collectorHost() + "/jserrors/ping"
+	status := CompressionStatus{
+		URL:          url,
+		Uncompressed: p.post(url, "identity", compressionBody),
+		Gzip:         p.post(url, "gzip", gzipped.Bytes()),
+	}
+	return prepareCompressionResult(status, upstream)
+}
+
+func (p BaseCollectorCompression) post(url string, contentEncoding string, body []byte) CompressionResponse {
+	headers := map[string]string{"Content-Type": "application/json"}
+	if contentEncoding != "identity" {
+		headers["Content-Encoding"] = contentEncoding
+	}
+	wrapper := httpHelper.RequestWrapper{
+		Method:  "POST",
+		URL:     url,
+		Headers: headers,
+		Payload: bytes.NewReader(body),
+		Length:  int64(len(body)),
+		Context: httpHelper.RunContext(),
+	}
+	response := CompressionResponse{ContentEncoding: contentEncoding}
+	resp, err := p.httpGetter(wrapper)
+	if err != nil {
+		log.Debug("Error posting the", contentEncoding, "body to", url, ":", err)
+		response.Error = err.Error()
+		return response
+	}
+	defer resp.Body.Close()
+
+	log.Debug("Response received from", url, "for the", contentEncoding, "body:", resp.StatusCode)
+	response.StatusCode = resp.StatusCode
+	responseBody, err := io.ReadAll(io.LimitReader(resp.Body, compressionResponseMaxBytes))
+	if err != nil {
+		response.Error = err.Error()
+	}
+	response.Body = string(responseBody)
+	return response
+}
+
+func prepareCompressionResult(status CompressionStatus, upstream map[string]tasks.Result) tasks.Result {
+	result := tasks.Result{
+		Status:         tasks.Success,
+		Payload:        status,
+		PayloadVersion: compressionPayloadVersion,
+	}
+
+	if status.Uncompressed.Error != "" {
+		// with the plain body failing too the network path is at fault, not the compression, see Base/Collector/LargePayload
+		result.Status = tasks.None
+		result.Summary = "The uncompressed body did not reach " + status.URL + ", the gzip encoded body can't be compared to it\nError = " + status.Uncompressed.Error
+		return result
+	}
+	if status.Gzip.Error == "" && statusClass(status.Gzip.StatusCode) == statusClass(status.Uncompressed.StatusCode) {
+		result.Summary = "The uncompressed and gzip encoded bodies got the same response from " + status.URL + ": " + strconv.Itoa(status.Gzip.StatusCode)
+		return result
+	}
+
+	result.Status = tasks.Warning
+	result.Summary = "The uncompressed body got a " + strconv.Itoa(status.Uncompressed.StatusCode) + " response from " + status.URL + " but the gzip encoded body "
+	if status.Gzip.Error != "" {
+		result.Summary += "failed\nError = " + status.Gzip.Error
+	} else {
+		result.Summary += "got a " + strconv.Itoa(status.Gzip.StatusCode) + " response"
+	}
+	result.Summary += "\nThis is typical of a proxy rewriting the requests it forwards without updating their Content-Encoding header, e.g. by decompressing or scanning the body. Agents send their data gzip encoded and the collector rejects it, while the pings keep working. Let the proxy pass the requests to the New Relic endpoints through unchanged."
+	result.Summary += proxySummary(upstream)
+	result.URL = networksDocURL
+	// the proxy is set up by the customer, and so is its list of hosts to pass through unchanged
+	if detectedProxy(upstream) != "" {
+		result.Remediation = tasks.ConfigChangeRemediation()
+	}
+	return result
+}
+
+// statusClass - the class of a status code, e.g. 4 for a 415: different codes of the same class, such as a 200 and a
+// 202, are the same outcome
+func statusClass(statusCode int) int {
+	return statusCode / 100
+}
@@ -0,0 +1,123 @@
+package collector
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/helpers/httpHelper"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+)
+
+// mockRewritingProxy - a proxy decompressing the bodies it forwards but leaving their Content-Encoding header, which
+// the collector then rejects
+func mockRewritingProxy(wrapper httpHelper.RequestWrapper) (*http.Response, error) {
+	body, _ := ioutil.ReadAll(wrapper.Payload)
+	if wrapper.Headers["Content-Encoding"] == "gzip" {
+		reader, err := gzip.NewReader(bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		body, _ = ioutil.ReadAll(reader)
+		if _, err := gzip.NewReader(bytes.NewReader(body)); err != nil {
+			return &http.Response{StatusCode: 415, Body: ioutil.NopCloser(strings.NewReader("Unsupported Media Type"))}, nil
+		}
+	}
+	return &http.Response{StatusCode: 200, Body: ioutil.NopCloser(strings.NewReader("ok"))}, nil
+}
+
+// mockDroppingProxy - a proxy resetting the connection of the requests with a gzip encoded body
+func mockDroppingProxy(wrapper httpHelper.RequestWrapper) (*http.Response, error) {
+	if wrapper.Headers["Content-Encoding"] == "gzip" {
+		return nil, errors.New("read tcp 10.0.0.1:53144->162.247.241.2:443: read: connection reset by peer")
+	}
+	return mockSuccessfulRequest200(wrapper)
+}
+
+func TestBaseCollectorCompression_Execute(t *testing.T) {
+	tests := []struct {
+		name        string
+		upstream    map[string]tasks.Result
+		httpGetter  requestFunc
+		want        tasks.Status
+		wantSummary string
+	}{
+		{
+			name: "should return a None result when no collector ping succeeded",
+			upstream: map[string]tasks.Result{
+				"Base/Collector/ConnectUS": {Status: tasks.Failure},
+			},
+			want: tasks.None,
+		},
+		{
+			name: "should return a Success result when both bodies get the same response",
+			upstream: map[string]tasks.Result{
+				"Base/Collector/ConnectUS": {Status: tasks.Success},
+			},
+			httpGetter:  mockSuccessfulRequest200,
+			want:        tasks.Success,
+			wantSummary: "same response from https://collector.newrelic.com/jserrors/ping: 200",
+		},
+		{
+			name: "should return a None result when the uncompressed body fails",
+			upstream: map[string]tasks.Result{
+				"Base/Collector/ConnectUS": {Status: tasks.Success},
+			},
+			httpGetter:  mockUnsuccessfulRequestError,
+			want:        tasks.None,
+			wantSummary: "The uncompressed body did not reach",
+		},
+		{
+			name: "should return a Warning result when the gzip encoded body is rejected",
+			upstream: map[string]tasks.Result{
+				"Base/Collector/ConnectUS": {Status: tasks.None},
+				"Base/Collector/ConnectEU": {Status: tasks.Success},
+			},
+			httpGetter:  mockRewritingProxy,
+			want:        tasks.Warning,
+			wantSummary: "The uncompressed body got a 200 response from https://collector.eu.newrelic.com/jserrors/ping but the gzip encoded body got a 415 response",
+		},
+		{
+			name: "should return a Warning result when the gzip encoded body fails",
+			upstream: map[string]tasks.Result{
+				"Base/Collector/ConnectUS": {Status: tasks.Success},
+			},
+			httpGetter:  mockDroppingProxy,
+			want:        tasks.Warning,
+			wantSummary: "but the gzip encoded body failed\nError = read tcp",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := BaseCollectorCompression{httpGetter: tt.httpGetter}
+			got := p.Execute(tasks.Options{}, tt.upstream)
+			if got.Status != tt.want {
+				t.Fatalf("BaseCollectorCompression.Execute() = %v, want %v: %s", got.Status, tt.want, got.Summary)
+			}
+			if !strings.Contains(got.Summary, tt.wantSummary) {
+				t.Errorf("BaseCollectorCompression.Execute() summary = %q, want it to contain %q", got.Summary, tt.wantSummary)
+			}
+		})
+	}
+}
+
+func TestBaseCollectorCompression_payload(t *testing.T) {
+	p := BaseCollectorCompression{httpGetter: mockRewritingProxy}
+	got := p.Execute(tasks.Options{}, map[string]tasks.Result{"Base/Collector/ConnectUS": {Status: tasks.Success}})
+	status, ok := got.Payload.(CompressionStatus)
+	if !ok {
+		t.Fatalf("BaseCollectorCompression.Execute() payload = %T, want CompressionStatus", got.Payload)
+	}
+	want := CompressionStatus{
+		URL:          "https://collector.newrelic.com/jserrors/ping",
+		Uncompressed: CompressionResponse{ContentEncoding: "identity", StatusCode: 200, Body: "ok"},
+		Gzip:         CompressionResponse{ContentEncoding: "gzip", StatusCode: 415, Body: "Unsupported Media Type"},
+	}
+	if status != want {
+		t.Errorf("BaseCollectorCompression.Execute() payload = %+v, want %+v", status, want)
+	}
+}
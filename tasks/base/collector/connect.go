@@ -0,0 +1,294 @@
+package collector
+
+import (
+	"io/ioutil"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/config"
+	"github.com/newrelic/newrelic-diagnostics-cli/helpers/httpHelper"
+
+	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+)
+
+const networksDocURL = "https://docs.newrelic.com/docs/apm/new-relic-apm/getting-started/networks"
+
+// collectorRegion - describes the collector endpoint checked by a BaseCollectorConnect task
+type collectorRegion struct {
+	identifier string // task identifier, e.g. Base/Collector/ConnectEU
+	key        string // region as reported by Base/Config/RegionDetect, e.g. eu01
+	name       string // short name used in result summaries, e.g. EU
+	longName   string // name used in the task explanation
+	host       string
+	docsURL    string
+	// skipSummary is the summary of the None result when the region was not detected
+	skipSummary string
+}
+
+var usRegion = collectorRegion{
+	identifier:  "Base/Collector/ConnectUS",
+	key:         "us01",
+	name:        "US",
+	longName:    "US",
+	host:        "collector.newrelic.com",
+	docsURL:     networksDocURL,
+	skipSummary: "US Region not detected, skipping US collector connect check",
+}
+
+var euRegion = collectorRegion{
+	identifier:  "Base/Collector/ConnectEU",
+	key:         "eu01",
+	name:        "EU",
+	longName:    "EU",
+	host:        "collector.eu.newrelic.com",
+	docsURL:     networksDocURL,
+	skipSummary: "EU Region not detected, skipping EU collector connect check",
+}
+
+var govRegion = collectorRegion{
+	identifier:  "Base/Collector/ConnectGov",
+	key:         "gov01",
+	name:        "Gov",
+	longName:    "FedRAMP (Gov)",
+	host:        "gov-collector.newrelic.com",
+	docsURL:     "https://docs.newrelic.com/docs/security/security-privacy/compliance/fedramp-compliant-endpoints",
+	skipSummary: "Gov region not detected, skipping Gov collector connect check",
+}
+
+// collectorRegions - every region a BaseCollectorConnect task is registered for
+var collectorRegions = []collectorRegion{
+	usRegion,
+	euRegion,
+	govRegion,
+}
+
+// collectorHost - returns the host to connect to for this region, honoring the -collector-host override
+func (r collectorRegion) collectorHost() string {
+	if config.Flags.CollectorHost == "" {
+		return r.host
+	}
+	host := strings.TrimPrefix(strings.TrimPrefix(config.Flags.CollectorHost, "https://"), "http://")
+	return strings.TrimSuffix(host, "/")
+}
+
+// PreflightURL - the endpoint probed before the run to tell whether New Relic can be reached at all: the collector of
+// the account region, or the -collector-host override
+func PreflightURL() string {
+	region := usRegion
+	if config.SelectedRegion() == config.EURegion {
+		region = euRegion
+	}
+	return "https://" + region.collectorHost() + "/jserrors/ping"
+}
+
+// BaseCollectorConnect - This task connects to the collector of a New Relic region and reports the status
+type BaseCollectorConnect struct {
+	region     collectorRegion
+	upstream   map[string]tasks.Result
+	httpGetter requestFunc
+}
+
+// Identifier - This returns the Category, Subcategory and Name of each task
+func (p BaseCollectorConnect) Identifier() tasks.Identifier {
+	return tasks.IdentifierFromString(p.region.identifier)
+}
+
+// Explain - Returns the help text for each individual task
+func (p BaseCollectorConnect) Explain() string {
+	return "Check network connection to New Relic " + p.region.longName + " region collector endpoint" + tasks.TimeoutExplanation
+}
+
+// Dependencies - This task depends on Base/Config/ProxyDetect, Base/Config/RegionDetect, Base/Collector/DNSResolve and Base/Env/DetectContainer
+func (p BaseCollectorConnect) Dependencies() []string {
+	return []string{
+		"Base/Config/ProxyDetect", //we are not using the payload of this task, but we want to make sure that it was already detected and set before running any HTTP request
+		"Base/Config/RegionDetect",
+		"Base/Collector/DNSResolve",
+		"Base/Env/DetectContainer",
+	}
+}
+
+// RequiresNetwork - This task connects to the collector of its region, it is skipped with -offline
+func (p BaseCollectorConnect) RequiresNetwork() {}
+
+// Execute - Attempts to connect to the region's collector endpoint
+func (p BaseCollectorConnect) Execute(op tasks.Options, upstream map[string]tasks.Result) tasks.Result {
+	p.upstream = upstream
+
+	url := "https://" + p.region.collectorHost() + "/jserrors/ping"
+
+	// Was the task not explicitely provided on -t ?
+	if !op.IsForced() {
+		result := p.prepareEarlyResult()
+		// Early result received, bailing
+		if !(reflect.DeepEqual(result, tasks.Result{})) {
+			return result
+		}
+	}
+
+	// Make request
+	wrapper := httpHelper.RequestWrapper{
+		Method: "GET",
+		URL:    url,
+		// Ctrl-C or -deadline stops the request and its retries instead of waiting out the timeout
+		Context: httpHelper.RunContext(),
+		// a single dropped connection should not be reported as a failure
+		RetryCount:          2,
+		RetryBackoffSeconds: 1,
+	}
+	start := time.Now()
+	resp, err := p.httpGetter(wrapper)
+	received := time.Now()
+
+	if err != nil {
+		// HTTP error
+		return p.prepareCollectorErrorResult(err)
+	}
+
+	defer resp.Body.Close()
+
+	// Parse HTTP response body
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		// body parse error result
+		return p.prepareResponseErrorResult(err, strconv.Itoa(resp.StatusCode))
+	}
+
+	//Successful request, return result based on status code
+	// the latency is the one of the attempt that got the response, the failed attempts and their backoff are left out
+	attempts := 1
+	if attempt := httpHelper.GetRequestAttempt(resp); attempt != nil {
+		start, attempts = attempt.Start, attempt.Number
+	}
+	payload := newConnectPayload(httpHelper.GetTLSConnectionInfo(resp), httpHelper.GetLeafCertificateInfo(resp), received.Sub(start))
+	payload.setAttempts(attempts)
+	payload.setClockTimes(resp.Header.Get("Date"), received)
+	return p.prepareResult(string(body), strconv.Itoa(resp.StatusCode), payload)
+
+}
+
+func (p BaseCollectorConnect) prepareEarlyResult() tasks.Result {
+	var result tasks.Result
+	regions, ok := p.upstream["Base/Config/RegionDetect"].Payload.([]string)
+	if ok {
+		// If this region was not in the non-empty list of detected region, return early.
+		// If no regions were detected, we run all collector connect checks.
+		if !tasks.StringInSlice(p.region.key, regions) && len(regions) > 0 {
+			result.Status = tasks.None
+			result.Summary = p.region.skipSummary
+			return result
+		}
+	}
+	return result
+}
+
+func (p BaseCollectorConnect) prepareCollectorErrorResult(e error) tasks.Result {
+	var result tasks.Result
+	if e == nil {
+		return result
+	}
+	result.Status = tasks.Failure
+	// A failed lookup makes the connection error itself uninformative, report the resolution error instead
+	if dnsErr := dnsFailureFor(p.region.collectorHost(), p.upstream); dnsErr != "" {
+		result.Payload = ConnectError{Category: dnsErrorCategory, Error: dnsErr}
+		result.Summary = "DNS resolution failed for " + p.region.collectorHost() + " (" + p.region.name + " Region)"
+		result.Summary += "\nPlease check the DNS settings of this host and try again or see -help for more options."
+		result.Summary += "\nError = " + dnsErr
+		result.Summary += proxySummary(p.upstream)
+		result.Summary += customHostSummary()
+		result.Summary += containerSummary(p.upstream)
+		result.URL = p.region.docsURL
+		return result
+	}
+	category := classifyConnectError(e)
+	result.Payload = ConnectError{Category: category, Error: e.Error()}
+	result.URL = p.region.docsURL
+	summary, url := connectErrorSummary(category, p.region.collectorHost()+" ("+p.region.name+" Region)")
+	if summary == "" {
+		result.Summary = "There was an error connecting to " + p.region.collectorHost() + " (" + p.region.name + " Region)"
+		result.Summary += "\nPlease check network and proxy settings and try again or see -help for more options."
+	} else {
+		result.Summary = summary
+	}
+	if url != "" {
+		result.URL = url
+	}
+	result.Summary += "\nError = " + e.Error()
+	result.Remediation = proxyRemediation(e, "", p.upstream)
+	result.Summary += attemptsSummary(e)
+	result.Summary += proxySummary(p.upstream)
+	result.Summary += customHostSummary()
+	result.Summary += containerSummary(p.upstream)
+
+	return result
+}
+
+func (p BaseCollectorConnect) prepareResponseErrorResult(e error, statusCode string) tasks.Result {
+	var result tasks.Result
+	if e == nil {
+		return result
+	}
+	result.Status = tasks.Warning
+	result.Summary = "Status = " + statusCode + ". When connecting to the " + p.region.name + " Region collector, there was an issue reading the body. "
+	result.Summary += "\nPlease check network and proxy settings and try again or see -help for more options."
+	result.Summary += "Error = " + e.Error()
+	result.URL = p.region.docsURL
+
+	return result
+}
+
+func (p BaseCollectorConnect) prepareResult(body, statusCode string, payload ConnectPayload) tasks.Result {
+	var result tasks.Result
+	result.Payload = payload
+	result.PayloadVersion = connectPayloadVersion
+
+	if statusCode == "200" && payload.exceedsLatencyThreshold() {
+		log.Debug("Slow response received from "+p.region.collectorHost()+":", payload.LatencyMs, "ms")
+		result.Status = tasks.Warning
+		result.Summary = "Status Code = " + statusCode + ", Latency = " + payload.latencyString() + payload.attemptsString()
+		result.Summary += "\nThe connection succeeded but took longer than " + strconv.FormatInt(LatencyWarningThreshold.Milliseconds(), 10) + "ms. High latency to the collector can cause agents to drop data."
+		result.Summary += tlsSummary(payload)
+		result.Summary += certSummary(payload)
+		result.URL = p.region.docsURL
+	} else if statusCode == "200" {
+		log.Debug("Successfully connected (" + p.region.name + " Region)")
+		result.Status = tasks.Success
+		result.Summary = "Status Code = " + statusCode + ", Latency = " + payload.latencyString() + payload.attemptsString() + " Body = " + body
+		result.Summary += tlsSummary(payload)
+		result.Summary += certSummary(payload)
+	} else {
+		log.Debug("Non-200 response received from "+p.region.collectorHost()+":", statusCode)
+		log.Debug("Body:", body)
+		result.Status = tasks.Warning
+		result.Summary = p.region.collectorHost() + " (" + p.region.name + " Region) returned a non-200 STATUS CODE: " + statusCode
+		result.Remediation = proxyRemediation(nil, statusCode, p.upstream)
+		result.Summary += "\nPlease check network and proxy settings and try again or see -help for more options."
+		result.Summary += "\nResponse Body: " + body
+		result.Summary += tlsSummary(payload)
+		result.Summary += certSummary(payload)
+		result.URL = p.region.docsURL
+	}
+
+	result.Summary += proxySummary(p.upstream)
+	result.Summary += customHostSummary()
+	result.Summary += containerSummary(p.upstream)
+
+	// An expired or soon to expire certificate usually means a proxy is intercepting the connection with its own certificate
+	switch checkCertExpiry(payload.Certificate, time.Now()) {
+	case tasks.Failure:
+		result.Status = tasks.Failure
+		result.Summary += "\nThe certificate presented by " + p.region.collectorHost() + " has expired. If the issuer is not a public certificate authority, a proxy may be intercepting the connection."
+		result.URL = p.region.docsURL
+	case tasks.Warning:
+		if result.Status == tasks.Success {
+			result.Status = tasks.Warning
+		}
+		result.Summary += "\nThe certificate presented by " + p.region.collectorHost() + " expires in less than " + strconv.Itoa(int(CertExpiryWarningWindow.Hours()/24)) + " days."
+		result.URL = p.region.docsURL
+	}
+
+	return result
+}
@@ -0,0 +1,195 @@
+package collector
+
+import (
+	"net"
+	"strings"
+	"time"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/config"
+	"github.com/newrelic/newrelic-diagnostics-cli/helpers/httpHelper"
+
+	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+)
+
+// ipVersionUnavailableErrors - dial errors meaning the host or the collector has no address of the IP version, rather
+// than a path that is broken
+var ipVersionUnavailableErrors = []string{
+	"no suitable address found",
+	"network is unreachable",
+	"cannot assign requested address",
+	"address family not supported",
+}
+
+// IPVersionAttempt - outcome of connecting to the collector over a single IP version
+type IPVersionAttempt struct {
+	Network    string
+	StatusCode int   `json:",omitempty"`
+	LatencyMs  int64 `json:",omitempty"`
+	// Unavailable is true when the IP version is not set up on the host or the collector has no address of it
+	Unavailable bool   `json:",omitempty"`
+	Error       string `json:",omitempty"`
+}
+
+func (a IPVersionAttempt) succeeded() bool {
+	return a.Error == ""
+}
+
+func (a IPVersionAttempt) failed() bool {
+	return a.Error != "" && !a.Unavailable
+}
+
+// DualStackStatus - outcome of connecting to the same collector over IPv4 and over IPv6
+type DualStackStatus struct {
+	URL  string
+	IPv4 IPVersionAttempt
+	IPv6 IPVersionAttempt
+}
+
+// dualStackPayloadVersion - the PayloadVersion of the results, bump it when DualStackStatus changes shape
+const dualStackPayloadVersion = 1
+
+// BaseCollectorConnectDualStack - This task connects to the collector over IPv4 and over IPv6 separately and reports each one
+type BaseCollectorConnectDualStack struct {
+	httpGetter requestFunc
+}
+
+// Identifier - This returns the Category, Subcategory and Name of each task
+func (p BaseCollectorConnectDualStack) Identifier() tasks.Identifier {
+	return tasks.IdentifierFromString("Base/Collector/ConnectDualStack")
+}
+
+// Explain - Returns the help text for each individual task
+func (p BaseCollectorConnectDualStack) Explain() string {
+	return "Check network connection to the New Relic collector over IPv4 and over IPv6 separately, to detect a broken IPv6 path hidden by the IPv4 fallback" + tasks.TimeoutExplanation
+}
+
+// Dependencies - This task depends on Base/Config/ProxyDetect, Base/Collector/DNSResolve and the collector connect checks
+func (p BaseCollectorConnectDualStack) Dependencies() []string {
+	dependencies := []string{
+		"Base/Config/ProxyDetect",
+		"Base/Collector/DNSResolve",
+	}
+	for _, region := range collectorRegions {
+		dependencies = append(dependencies, region.identifier)
+	}
+	return dependencies
+}
+
+// RequiresNetwork - This task connects to the collector, it is skipped with -offline
+func (p BaseCollectorConnectDualStack) RequiresNetwork() {}
+
+// Execute - Connects to the collector of the first region whose ping succeeded, or of the account region, over each IP version
+func (p BaseCollectorConnectDualStack) Execute(op tasks.Options, upstream map[string]tasks.Result) tasks.Result {
+	// the proxy connects to the collector on the agents' behalf, over whichever IP version it picks
+	if proxy := detectedProxy(upstream); proxy != "" {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "The collector is reached through the proxy " + httpHelper.RedactProxyURL(proxy) + ", which picks the IP version of the connection, skipping the IPv4 and IPv6 connection check",
+		}
+	}
+
+	region, ok := connectedRegion(upstream)
+	if !ok {
+		region = usRegion
+		if config.SelectedRegion() == config.EURegion {
+			region = euRegion
+		}
+	}
+	host := region.collectorHost()
+	url := "https://" + host + "/jserrors/ping"
+	status := DualStackStatus{
+		URL:  url,
+		IPv4: p.connect(url, "tcp4"),
+		IPv6: p.connect(url, "tcp6"),
+	}
+	// a host without AAAA record fails with a dial error that doesn't always say so
+	if status.IPv6.Error != "" && !hasIPv6Address(host, upstream) {
+		status.IPv6.Unavailable = true
+	}
+	return prepareDualStackResult(status)
+}
+
+func (p BaseCollectorConnectDualStack) connect(url string, network string) IPVersionAttempt {
+	wrapper := httpHelper.RequestWrapper{
+		Method:      "GET",
+		URL:         url,
+		Context:     httpHelper.RunContext(),
+		BypassProxy: true,
+		Network:     network,
+	}
+	attempt := IPVersionAttempt{Network: network}
+	start := time.Now()
+	resp, err := p.httpGetter(wrapper)
+	if err != nil {
+		log.Debug("Error connecting to", url, "over", network, ":", err)
+		attempt.Error = err.Error()
+		for _, unavailable := range ipVersionUnavailableErrors {
+			if strings.Contains(attempt.Error, unavailable) {
+				attempt.Unavailable = true
+			}
+		}
+		return attempt
+	}
+	defer resp.Body.Close()
+
+	log.Debug("Response received from", url, "over", network, ":", resp.StatusCode)
+	attempt.StatusCode = resp.StatusCode
+	attempt.LatencyMs = time.Since(start).Milliseconds()
+	return attempt
+}
+
+// hasIPv6Address - whether Base/Collector/DNSResolve resolved the host to an IPv6 address, true when it didn't resolve
+// the host so that the error of the connection is reported as is
+func hasIPv6Address(host string, upstream map[string]tasks.Result) bool {
+	resolutions, ok := upstream["Base/Collector/DNSResolve"].Payload.([]DNSResolution)
+	if !ok {
+		return true
+	}
+	for _, resolution := range resolutions {
+		if resolution.Host != host || resolution.Error != "" {
+			continue
+		}
+		for _, ip := range resolution.IPs {
+			if parsed := net.ParseIP(ip); parsed != nil && parsed.To4() == nil {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+func prepareDualStackResult(status DualStackStatus) tasks.Result {
+	result := tasks.Result{
+		Status:         tasks.Success,
+		Payload:        status,
+		PayloadVersion: dualStackPayloadVersion,
+	}
+
+	switch {
+	case status.IPv4.succeeded() && status.IPv6.succeeded():
+		result.Summary = "Connected to " + status.URL + " over both IPv4 and IPv6"
+	case status.IPv4.succeeded() && status.IPv6.Unavailable:
+		result.Summary = "Connected to " + status.URL + " over IPv4, IPv6 is not available to reach it from this host\nIPv6 error = " + status.IPv6.Error
+	case status.IPv4.succeeded():
+		result.Status = tasks.Warning
+		result.Summary = "Connected to " + status.URL + " over IPv4 but not over IPv6\nIPv6 error = " + status.IPv6.Error
+		result.Summary += "\nThis host has an IPv6 address for the collector but the IPv6 path to it is broken. Clients falling back to IPv4 (happy eyeballs) hide the problem behind slow connections, while agents or runtimes that stick to the first address resolved fail to connect. Fix the IPv6 routing or firewall rules of this host, or have it prefer IPv4."
+		result.URL = networksDocURL
+	case status.IPv6.succeeded():
+		result.Status = tasks.Warning
+		result.Summary = "Connected to " + status.URL + " over IPv6 but not over IPv4\nIPv4 error = " + status.IPv4.Error
+		result.Summary += "\nAgents on this host need IPv6 to reach New Relic, check the IPv4 routing or firewall rules of this host."
+		result.URL = networksDocURL
+	default:
+		result.Status = tasks.Failure
+		result.Summary = "Unable to connect to " + status.URL + " over IPv4"
+		if status.IPv6.failed() {
+			result.Summary += " or IPv6"
+		}
+		result.Summary += "\nIPv4 error = " + status.IPv4.Error + "\nIPv6 error = " + status.IPv6.Error
+		result.URL = networksDocURL
+	}
+	return result
+}
@@ -57,7 +57,8 @@ func TestBaseCollectorConnectEU_prepareEarlyResult(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			p := BaseCollectorConnectEU{
+			p := BaseCollectorConnect{
+				region:   euRegion,
 				upstream: tt.fields.upstream,
 			}
 			if got := p.prepareEarlyResult(); !reflect.DeepEqual(got, tt.want) {
@@ -96,7 +97,8 @@ func TestBaseCollectorConnectEU_prepareCollectorErrorResult(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			p := BaseCollectorConnectEU{
+			p := BaseCollectorConnect{
+				region:   euRegion,
 				upstream: tt.fields.upstream,
 			}
 			got := p.prepareCollectorErrorResult(tt.args.e)
@@ -143,7 +145,8 @@ func TestBaseCollectorConnectEU_prepareResponseErrorResult(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			p := BaseCollectorConnectEU{
+			p := BaseCollectorConnect{
+				region:   euRegion,
 				upstream: tt.fields.upstream,
 			}
 			got := p.prepareResponseErrorResult(tt.args.e, tt.args.statusCode)
@@ -189,10 +192,11 @@ func TestBaseCollectorConnectEU_prepareResult(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			p := BaseCollectorConnectEU{
+			p := BaseCollectorConnect{
+				region:   euRegion,
 				upstream: tt.fields.upstream,
 			}
-			got := p.prepareResult(tt.args.body, tt.args.statusCode)
+			got := p.prepareResult(tt.args.body, tt.args.statusCode, ConnectPayload{})
 			if got.Status != tt.want {
 				t.Errorf("prepareResult() = %v, want %v", got, tt.want)
 			}
@@ -290,7 +294,8 @@ func TestBaseCollectorConnectEU_Execute(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 
-			p := BaseCollectorConnectEU{
+			p := BaseCollectorConnect{
+				region:     euRegion,
 				upstream:   tt.fields.upstream,
 				httpGetter: tt.fields.httpGetter,
 			}
@@ -0,0 +1,152 @@
+package collector
+
+import (
+	"bytes"
+	"strconv"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/helpers/httpHelper"
+
+	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+)
+
+// logAPIEndpoints - log API endpoint for each region key reported by Base/Config/RegionDetect
+var logAPIEndpoints = map[string]string{
+	"us01": "https://log-api.newrelic.com/log/v1",
+	"eu01": "https://log-api.eu.newrelic.com/log/v1",
+}
+
+// The log API rejects an empty, unauthenticated payload, but any of these status codes means the request reached it
+var logAPIReachableStatusCodes = []int{200, 202, 400, 401, 403}
+
+// LogAPIEndpointStatus - outcome of connecting to a single log API endpoint
+type LogAPIEndpointStatus struct {
+	URL        string
+	StatusCode int `json:",omitempty"`
+	Reachable  bool
+	Error      string `json:",omitempty"`
+}
+
+// logAPIPayloadVersion - the PayloadVersion of the results, bump it when LogAPIEndpointStatus changes shape
+const logAPIPayloadVersion = 1
+
+// BaseCollectorConnectLogAPI - This task connects to the log API used by agent log forwarding and reports the status
+type BaseCollectorConnectLogAPI struct {
+	upstream   map[string]tasks.Result
+	httpGetter requestFunc
+}
+
+// Identifier - This returns the Category, Subcategory and Name of each task
+func (p BaseCollectorConnectLogAPI) Identifier() tasks.Identifier {
+	return tasks.IdentifierFromString("Base/Collector/ConnectLogApi")
+}
+
+// Explain - Returns the help text for each individual task
+func (p BaseCollectorConnectLogAPI) Explain() string {
+	return "Check network connection to New Relic log API endpoint used for log forwarding" + tasks.TimeoutExplanation
+}
+
+// Dependencies - This task depends on Base/Config/ProxyDetect and Base/Config/RegionDetect
+func (p BaseCollectorConnectLogAPI) Dependencies() []string {
+	return []string{
+		"Base/Config/ProxyDetect",
+		"Base/Config/RegionDetect",
+	}
+}
+
+// RequiresNetwork - This task sends a request to the Log API, it is skipped with -offline
+func (p BaseCollectorConnectLogAPI) RequiresNetwork() {}
+
+// Execute - Attempts to connect to the log API endpoint of each detected region
+func (p BaseCollectorConnectLogAPI) Execute(op tasks.Options, upstream map[string]tasks.Result) tasks.Result {
+	p.upstream = upstream
+
+	var statuses []LogAPIEndpointStatus
+	for _, url := range p.getEndpoints() {
+		statuses = append(statuses, p.checkEndpoint(url))
+	}
+
+	return p.prepareResult(statuses)
+}
+
+// getEndpoints - returns the log API endpoints of the detected regions, defaulting to US
+func (p BaseCollectorConnectLogAPI) getEndpoints() []string {
+	var endpoints []string
+	regions, _ := p.upstream["Base/Config/RegionDetect"].Payload.([]string)
+	for _, region := range regions {
+		if url, ok := logAPIEndpoints[region]; ok {
+			endpoints = append(endpoints, url)
+		}
+	}
+	if len(endpoints) == 0 {
+		endpoints = append(endpoints, logAPIEndpoints["us01"])
+	}
+	return endpoints
+}
+
+func (p BaseCollectorConnectLogAPI) checkEndpoint(url string) LogAPIEndpointStatus {
+	wrapper := httpHelper.RequestWrapper{
+		Method:  "POST",
+		URL:     url,
+		Headers: map[string]string{"Content-Type": "application/json"},
+		Payload: bytes.NewReader([]byte("[]")),
+		Context: httpHelper.RunContext(),
+	}
+	resp, err := p.httpGetter(wrapper)
+	if err != nil {
+		log.Debug("Error connecting to", url, ":", err)
+		return LogAPIEndpointStatus{
+			URL:   url,
+			Error: err.Error(),
+		}
+	}
+	defer resp.Body.Close()
+
+	log.Debug("Response received from", url, ":", resp.StatusCode)
+	return LogAPIEndpointStatus{
+		URL:        url,
+		StatusCode: resp.StatusCode,
+		Reachable:  isLogAPIReachableStatusCode(resp.StatusCode),
+	}
+}
+
+func isLogAPIReachableStatusCode(statusCode int) bool {
+	for _, reachable := range logAPIReachableStatusCodes {
+		if statusCode == reachable {
+			return true
+		}
+	}
+	return false
+}
+
+func (p BaseCollectorConnectLogAPI) prepareResult(statuses []LogAPIEndpointStatus) tasks.Result {
+	result := tasks.Result{
+		Status:         tasks.Success,
+		Payload:        statuses,
+		PayloadVersion: logAPIPayloadVersion,
+	}
+
+	for _, status := range statuses {
+		if status.Error != "" {
+			result.Status = tasks.Failure
+			result.Summary += "There was an error connecting to " + status.URL
+			result.Summary += "\nError = " + status.Error + "\n"
+			continue
+		}
+		if !status.Reachable {
+			if result.Status != tasks.Failure {
+				result.Status = tasks.Warning
+			}
+			result.Summary += status.URL + " returned an unexpected STATUS CODE: " + strconv.Itoa(status.StatusCode) + "\n"
+			continue
+		}
+		result.Summary += status.URL + " is reachable (Status Code = " + strconv.Itoa(status.StatusCode) + ")\n"
+	}
+
+	if result.Status != tasks.Success {
+		result.Summary += "Please check network and proxy settings and try again or see -help for more options."
+		result.URL = networksDocURL
+	}
+
+	return result
+}
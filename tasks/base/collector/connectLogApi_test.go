@@ -0,0 +1,86 @@
+package collector
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+)
+
+func TestBaseCollectorConnectLogAPI_getEndpoints(t *testing.T) {
+	tests := []struct {
+		name     string
+		upstream map[string]tasks.Result
+		want     []string
+	}{
+		{
+			name:     "should default to the US endpoint when no regions are detected",
+			upstream: map[string]tasks.Result{},
+			want:     []string{"https://log-api.newrelic.com/log/v1"},
+		},
+		{
+			name: "should return the EU endpoint when the EU region is detected",
+			upstream: map[string]tasks.Result{
+				"Base/Config/RegionDetect": {Payload: []string{"eu01"}},
+			},
+			want: []string{"https://log-api.eu.newrelic.com/log/v1"},
+		},
+		{
+			name: "should return every endpoint of the detected regions",
+			upstream: map[string]tasks.Result{
+				"Base/Config/RegionDetect": {Payload: []string{"us01", "eu01"}},
+			},
+			want: []string{"https://log-api.newrelic.com/log/v1", "https://log-api.eu.newrelic.com/log/v1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := BaseCollectorConnectLogAPI{upstream: tt.upstream}
+			if got := p.getEndpoints(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("getEndpoints() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBaseCollectorConnectLogAPI_Execute(t *testing.T) {
+	tests := []struct {
+		name       string
+		httpGetter requestFunc
+		want       tasks.Status
+	}{
+		{
+			name:       "should return a Success result when the log API rejects the empty payload",
+			httpGetter: mockUnsuccessfulRequest400,
+			want:       tasks.Success,
+		},
+		{
+			name:       "should return a Failure result on a failed request",
+			httpGetter: mockUnsuccessfulRequestError,
+			want:       tasks.Failure,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := BaseCollectorConnectLogAPI{httpGetter: tt.httpGetter}
+			got := p.Execute(tasks.Options{}, map[string]tasks.Result{})
+			if got.Status != tt.want {
+				t.Errorf("BaseCollectorConnectLogAPI.Execute() = %v, want %v", got.Status, tt.want)
+			}
+		})
+	}
+}
+
+func TestBaseCollectorConnectLogAPI_prepareResult(t *testing.T) {
+	statuses := []LogAPIEndpointStatus{
+		{URL: "https://log-api.newrelic.com/log/v1", StatusCode: 202, Reachable: true},
+		{URL: "https://log-api.eu.newrelic.com/log/v1", StatusCode: 502},
+	}
+	got := BaseCollectorConnectLogAPI{}.prepareResult(statuses)
+	if got.Status != tasks.Warning {
+		t.Errorf("prepareResult() = %v, want %v", got.Status, tasks.Warning)
+	}
+	if got.URL == "" {
+		t.Errorf("prepareResult() should set a documentation URL on a Warning result")
+	}
+}
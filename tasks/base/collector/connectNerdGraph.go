@@ -0,0 +1,194 @@
+package collector
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"strconv"
+	"strings"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/config"
+	"github.com/newrelic/newrelic-diagnostics-cli/helpers/httpHelper"
+
+	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+)
+
+// nerdGraphEndpoints - NerdGraph endpoint of each account region
+var nerdGraphEndpoints = map[config.Region]string{
+	config.USRegion: "https://api.newrelic.com/graphql",
+	config.EURegion: "https://api.eu.newrelic.com/graphql",
+}
+
+// nerdGraphQuery - the smallest query that needs a valid user API key
+const nerdGraphQuery = `{"query":"{ actor { user { email } } }"}`
+
+// nerdGraphDocURL - how to create and find user API keys
+const nerdGraphDocURL = "https://docs.newrelic.com/docs/apis/intro-apis/new-relic-api-keys/#user-key"
+
+// NerdGraphStatus - outcome of the NerdGraph query made with the -api-key key
+type NerdGraphStatus struct {
+	URL         string
+	StatusCode  int `json:",omitempty"`
+	Reachable   bool
+	KeyAccepted bool
+	Error       string `json:",omitempty"`
+}
+
+// nerdGraphPayloadVersion - the PayloadVersion of the results, bump it when NerdGraphStatus changes shape
+const nerdGraphPayloadVersion = 1
+
+// nerdGraphResponse - the parts of the query response telling whether the key was accepted. The user's email is not kept
+type nerdGraphResponse struct {
+	Data struct {
+		Actor struct {
+			User *struct{} `json:"user"`
+		} `json:"actor"`
+	} `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+// BaseCollectorConnectNerdGraph - This task queries NerdGraph with the -api-key user API key and reports whether the key is accepted
+type BaseCollectorConnectNerdGraph struct {
+	httpGetter requestFunc
+}
+
+// Identifier - This returns the Category, Subcategory and Name of each task
+func (p BaseCollectorConnectNerdGraph) Identifier() tasks.Identifier {
+	return tasks.IdentifierFromString("Base/Collector/ConnectNerdGraph")
+}
+
+// Explain - Returns the help text for each individual task
+func (p BaseCollectorConnectNerdGraph) Explain() string {
+	return "Check network connection to the New Relic NerdGraph API and that the -api-key user API key is accepted" + tasks.TimeoutExplanation
+}
+
+// Dependencies - This task depends on Base/Config/ProxyDetect
+func (p BaseCollectorConnectNerdGraph) Dependencies() []string {
+	return []string{
+		"Base/Config/ProxyDetect",
+	}
+}
+
+// RequiresNetwork - This task sends a request to NerdGraph, it is skipped with -offline
+func (p BaseCollectorConnectNerdGraph) RequiresNetwork() {}
+
+// Execute - Sends a minimal query to the NerdGraph endpoint of the account region
+func (p BaseCollectorConnectNerdGraph) Execute(op tasks.Options, upstream map[string]tasks.Result) tasks.Result {
+	apiKey := strings.TrimSpace(config.Flags.APIKey)
+	if apiKey == "" {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "No user API key was given with -api-key, NerdGraph was not queried.",
+		}
+	}
+
+	return prepareNerdGraphResult(p.query(nerdGraphURL(), apiKey))
+}
+
+func (p BaseCollectorConnectNerdGraph) query(url string, apiKey string) NerdGraphStatus {
+	resp, err := p.httpGetter(nerdGraphRequest(url, apiKey, []byte(nerdGraphQuery)))
+	if err != nil {
+		// the error of a failed request may quote its headers or URL, the key must not end up in the output
+		errorMessage := redactAPIKey(err.Error(), apiKey)
+		log.Debug("Error connecting to", url, ":", errorMessage)
+		return NerdGraphStatus{
+			URL:   url,
+			Error: errorMessage,
+		}
+	}
+	defer resp.Body.Close()
+
+	log.Debug("Response received from", url, ":", resp.StatusCode)
+	status := NerdGraphStatus{
+		URL:        url,
+		StatusCode: resp.StatusCode,
+		Reachable:  true,
+	}
+	if resp.StatusCode != 200 {
+		return status
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		status.Error = err.Error()
+		return status
+	}
+	var response nerdGraphResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		status.Error = "unable to parse the NerdGraph response: " + err.Error()
+		return status
+	}
+	if len(response.Errors) > 0 {
+		status.Error = redactAPIKey(response.Errors[0].Message, apiKey)
+		return status
+	}
+	status.KeyAccepted = response.Data.Actor.User != nil
+	return status
+}
+
+// nerdGraphRequest - a NerdGraph query sent with the user API key
+func nerdGraphRequest(url string, apiKey string, query []byte) httpHelper.RequestWrapper {
+	return httpHelper.RequestWrapper{
+		Method: "POST",
+		URL:    url,
+		Headers: map[string]string{
+			"Content-Type": "application/json",
+			"API-Key":      apiKey,
+		},
+		Payload: bytes.NewReader(query),
+		Context: httpHelper.RunContext(),
+	}
+}
+
+// nerdGraphURL - the NerdGraph endpoint of the -region or NEW_RELIC_REGION account region
+func nerdGraphURL() string {
+	if config.SelectedRegion() == config.EURegion {
+		return nerdGraphEndpoints[config.EURegion]
+	}
+	return nerdGraphEndpoints[config.USRegion]
+}
+
+func redactAPIKey(message string, apiKey string) string {
+	return strings.ReplaceAll(message, apiKey, "_REDACTED_")
+}
+
+func prepareNerdGraphResult(status NerdGraphStatus) tasks.Result {
+	result := tasks.Result{
+		Payload:        status,
+		PayloadVersion: nerdGraphPayloadVersion,
+	}
+
+	switch {
+	case !status.Reachable:
+		result.Status = tasks.Failure
+		result.Summary = "There was an error connecting to " + status.URL + "\nError = " + status.Error + "\nPlease check network and proxy settings and try again or see -help for more options."
+		result.URL = networksDocURL
+	case status.KeyAccepted:
+		result.Status = tasks.Success
+		result.Summary = status.URL + " is reachable and the user API key was accepted."
+	case status.StatusCode == 401 || status.StatusCode == 403 || status.StatusCode == 200:
+		result.Status = tasks.Warning
+		result.Summary = status.URL + " is reachable but the user API key was not accepted (Status Code = " + strconv.Itoa(status.StatusCode) + ")"
+		if status.Error != "" {
+			result.Summary += "\nError = " + status.Error
+		}
+		result.Summary += "\nCheck that -api-key is a user API key, starting with NRAK, of an account in the " + nerdGraphRegionName(status.URL) + " region."
+		result.URL = nerdGraphDocURL
+	default:
+		result.Status = tasks.Warning
+		result.Summary = status.URL + " returned an unexpected STATUS CODE: " + strconv.Itoa(status.StatusCode)
+		result.URL = networksDocURL
+	}
+	return result
+}
+
+// nerdGraphRegionName - the region of a NerdGraph endpoint, for the summary
+func nerdGraphRegionName(url string) string {
+	if url == nerdGraphEndpoints[config.EURegion] {
+		return "EU"
+	}
+	return "US"
+}
@@ -0,0 +1,123 @@
+package collector
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/config"
+	"github.com/newrelic/newrelic-diagnostics-cli/helpers/httpHelper"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+)
+
+const testUserAPIKey = "NRAK-ABCDEFGHIJKLMNOPQRSTUVWXYZ0"
+
+func mockNerdGraphResponse(statusCode int, body string) requestFunc {
+	return func(wrapper httpHelper.RequestWrapper) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: statusCode,
+			Body:       ioutil.NopCloser(bytes.NewReader([]byte(body))),
+		}, nil
+	}
+}
+
+func TestBaseCollectorConnectNerdGraph_Execute(t *testing.T) {
+	tests := []struct {
+		name       string
+		apiKey     string
+		region     string
+		httpGetter requestFunc
+		want       tasks.Status
+		wantURL    string
+	}{
+		{
+			name:   "should return a None result without -api-key",
+			apiKey: "",
+			want:   tasks.None,
+		},
+		{
+			name:       "should return a Success result when the user is returned",
+			apiKey:     testUserAPIKey,
+			httpGetter: mockNerdGraphResponse(200, `{"data":{"actor":{"user":{"email":"jane@example.com"}}}}`),
+			want:       tasks.Success,
+			wantURL:    "https://api.newrelic.com/graphql",
+		},
+		{
+			name:       "should query the EU endpoint in the EU region",
+			apiKey:     testUserAPIKey,
+			region:     "eu",
+			httpGetter: mockNerdGraphResponse(200, `{"data":{"actor":{"user":{"email":"jane@example.com"}}}}`),
+			want:       tasks.Success,
+			wantURL:    "https://api.eu.newrelic.com/graphql",
+		},
+		{
+			name:       "should return a Warning result when the key is rejected",
+			apiKey:     testUserAPIKey,
+			httpGetter: mockNerdGraphResponse(401, `{"errors":[{"message":"Invalid API key"}]}`),
+			want:       tasks.Warning,
+		},
+		{
+			name:       "should return a Warning result when the query returns errors",
+			apiKey:     testUserAPIKey,
+			httpGetter: mockNerdGraphResponse(200, `{"data":{"actor":{"user":null}},"errors":[{"message":"Unauthorized"}]}`),
+			want:       tasks.Warning,
+		},
+		{
+			name:       "should return a Failure result on a failed request",
+			apiKey:     testUserAPIKey,
+			httpGetter: mockUnsuccessfulRequestError,
+			want:       tasks.Failure,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config.Flags.APIKey = tt.apiKey
+			config.Flags.Region = tt.region
+			defer func() {
+				config.Flags.APIKey = ""
+				config.Flags.Region = ""
+			}()
+
+			var requestedURL, sentKey string
+			httpGetter := func(wrapper httpHelper.RequestWrapper) (*http.Response, error) {
+				requestedURL = wrapper.URL
+				sentKey = wrapper.Headers["API-Key"]
+				return tt.httpGetter(wrapper)
+			}
+			p := BaseCollectorConnectNerdGraph{httpGetter: httpGetter}
+			got := p.Execute(tasks.Options{}, map[string]tasks.Result{})
+			if got.Status != tt.want {
+				t.Errorf("BaseCollectorConnectNerdGraph.Execute() = %v, want %v: %s", got.Status, tt.want, got.Summary)
+			}
+			if tt.wantURL != "" && requestedURL != tt.wantURL {
+				t.Errorf("BaseCollectorConnectNerdGraph.Execute() queried %s, want %s", requestedURL, tt.wantURL)
+			}
+			if tt.httpGetter != nil && sentKey != tt.apiKey {
+				t.Errorf("BaseCollectorConnectNerdGraph.Execute() sent API-Key %q, want %q", sentKey, tt.apiKey)
+			}
+		})
+	}
+}
+
+func TestBaseCollectorConnectNerdGraph_redactsKey(t *testing.T) {
+	config.Flags.APIKey = testUserAPIKey
+	defer func() { config.Flags.APIKey = "" }()
+
+	p := BaseCollectorConnectNerdGraph{httpGetter: func(wrapper httpHelper.RequestWrapper) (*http.Response, error) {
+		return nil, errors.New("proxyconnect tcp: request with API-Key " + wrapper.Headers["API-Key"] + " refused")
+	}}
+	got := p.Execute(tasks.Options{}, map[string]tasks.Result{})
+	if got.Status != tasks.Failure {
+		t.Errorf("BaseCollectorConnectNerdGraph.Execute() = %v, want %v", got.Status, tasks.Failure)
+	}
+	status := got.Payload.(NerdGraphStatus)
+	if strings.Contains(got.Summary, testUserAPIKey) || strings.Contains(status.Error, testUserAPIKey) {
+		t.Errorf("BaseCollectorConnectNerdGraph.Execute() result contains the API key: %s", got.Summary)
+	}
+	if !strings.Contains(status.Error, "_REDACTED_") {
+		t.Errorf("BaseCollectorConnectNerdGraph.Execute() error = %q, want the key redacted", status.Error)
+	}
+}
@@ -0,0 +1,197 @@
+package collector
+
+import (
+	"bytes"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/helpers/httpHelper"
+
+	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+)
+
+const (
+	otlpHTTPPort = "4318"
+	otlpGRPCPort = "4317"
+	// timeout of the plain TCP connect used to check the gRPC port
+	otlpDialTimeout = 10 * time.Second
+)
+
+// otlpHosts - OTLP endpoint host for each region key reported by Base/Config/RegionDetect
+var otlpHosts = map[string]string{
+	"us01": "otlp.nr-data.net",
+	"eu01": "otlp.eu01.nr-data.net",
+}
+
+// The OTLP endpoint rejects an empty, unauthenticated export request, but any of these status codes means the request reached it
+var otlpReachableStatusCodes = []int{200, 400, 401, 403, 415}
+
+type dialFunc func(network, address string, timeout time.Duration) (net.Conn, error)
+
+// OTLPEndpointStatus - outcome of connecting to a single OTLP host and port
+type OTLPEndpointStatus struct {
+	Host       string
+	Port       string
+	Protocol   string
+	StatusCode int `json:",omitempty"`
+	Reachable  bool
+	Error      string `json:",omitempty"`
+}
+
+// otlpPayloadVersion - the PayloadVersion of the results, bump it when OTLPEndpointStatus changes shape
+const otlpPayloadVersion = 1
+
+// BaseCollectorConnectOTLP - This task connects to the OpenTelemetry (OTLP) endpoint and reports which protocols are reachable
+type BaseCollectorConnectOTLP struct {
+	upstream   map[string]tasks.Result
+	httpGetter requestFunc
+	dialer     dialFunc
+}
+
+// Identifier - This returns the Category, Subcategory and Name of each task
+func (p BaseCollectorConnectOTLP) Identifier() tasks.Identifier {
+	return tasks.IdentifierFromString("Base/Collector/ConnectOTLP")
+}
+
+// Explain - Returns the help text for each individual task
+func (p BaseCollectorConnectOTLP) Explain() string {
+	return "Check network connection to New Relic OTLP endpoint over HTTP and gRPC" + tasks.TimeoutExplanation
+}
+
+// Dependencies - This task depends on Base/Config/ProxyDetect and Base/Config/RegionDetect
+func (p BaseCollectorConnectOTLP) Dependencies() []string {
+	return []string{
+		"Base/Config/ProxyDetect",
+		"Base/Config/RegionDetect",
+	}
+}
+
+// RequiresNetwork - This task connects to the OTLP endpoints, it is skipped with -offline
+func (p BaseCollectorConnectOTLP) RequiresNetwork() {}
+
+// Execute - Attempts to connect to the OTLP HTTP and gRPC ports of each detected region
+func (p BaseCollectorConnectOTLP) Execute(op tasks.Options, upstream map[string]tasks.Result) tasks.Result {
+	p.upstream = upstream
+
+	var statuses []OTLPEndpointStatus
+	for _, host := range p.getHosts() {
+		statuses = append(statuses, p.checkHTTP(host), p.checkGRPC(host))
+	}
+
+	return p.prepareResult(statuses)
+}
+
+// getHosts - returns the OTLP hosts of the detected regions, defaulting to US
+func (p BaseCollectorConnectOTLP) getHosts() []string {
+	var hosts []string
+	regions, _ := p.upstream["Base/Config/RegionDetect"].Payload.([]string)
+	for _, region := range regions {
+		if host, ok := otlpHosts[region]; ok {
+			hosts = append(hosts, host)
+		}
+	}
+	if len(hosts) == 0 {
+		hosts = append(hosts, otlpHosts["us01"])
+	}
+	return hosts
+}
+
+func (p BaseCollectorConnectOTLP) checkHTTP(host string) OTLPEndpointStatus {
+	status := OTLPEndpointStatus{
+		Host:     host,
+		Port:     otlpHTTPPort,
+		Protocol: "http",
+	}
+	wrapper := httpHelper.RequestWrapper{
+		Method:  "POST",
+		URL:     "https://" + net.JoinHostPort(host, otlpHTTPPort) + "/v1/traces",
+		Headers: map[string]string{"Content-Type": "application/x-protobuf"},
+		Payload: bytes.NewReader([]byte{}),
+		Context: httpHelper.RunContext(),
+	}
+	resp, err := p.httpGetter(wrapper)
+	if err != nil {
+		log.Debug("Error connecting to OTLP HTTP endpoint", host, ":", err)
+		status.Error = err.Error()
+		return status
+	}
+	defer resp.Body.Close()
+
+	status.StatusCode = resp.StatusCode
+	status.Reachable = isOTLPReachableStatusCode(resp.StatusCode)
+	return status
+}
+
+// checkGRPC - gRPC is not spoken here, a TCP connect is enough to know the port is reachable. Note this does not go through an HTTP proxy
+func (p BaseCollectorConnectOTLP) checkGRPC(host string) OTLPEndpointStatus {
+	status := OTLPEndpointStatus{
+		Host:     host,
+		Port:     otlpGRPCPort,
+		Protocol: "grpc",
+	}
+	conn, err := p.dialer("tcp", net.JoinHostPort(host, otlpGRPCPort), otlpDialTimeout)
+	if err != nil {
+		log.Debug("Error connecting to OTLP gRPC port", host, ":", err)
+		status.Error = err.Error()
+		return status
+	}
+	conn.Close()
+
+	status.Reachable = true
+	return status
+}
+
+func isOTLPReachableStatusCode(statusCode int) bool {
+	for _, reachable := range otlpReachableStatusCodes {
+		if statusCode == reachable {
+			return true
+		}
+	}
+	return false
+}
+
+func (p BaseCollectorConnectOTLP) prepareResult(statuses []OTLPEndpointStatus) tasks.Result {
+	reachable := 0
+	var summary string
+	for _, status := range statuses {
+		endpoint := status.Host + ":" + status.Port + " (" + status.Protocol + ")"
+		switch {
+		case status.Reachable:
+			reachable++
+			summary += endpoint + " is reachable\n"
+		case status.Error != "":
+			summary += "There was an error connecting to " + endpoint + "\nError = " + status.Error + "\n"
+		default:
+			summary += endpoint + " returned an unexpected STATUS CODE: " + strconv.Itoa(status.StatusCode) + "\n"
+		}
+	}
+
+	if reachable == len(statuses) {
+		return tasks.Result{
+			Status:         tasks.Success,
+			Summary:        summary,
+			Payload:        statuses,
+			PayloadVersion: otlpPayloadVersion,
+		}
+	}
+
+	summary += "Please check network and proxy settings and try again or see -help for more options."
+	if reachable == 0 {
+		return tasks.Result{
+			Status:         tasks.Failure,
+			Summary:        summary,
+			URL:            "https://docs.newrelic.com/docs/more-integrations/open-source-telemetry-integrations/opentelemetry/get-started/opentelemetry-set-up-your-app",
+			Payload:        statuses,
+			PayloadVersion: otlpPayloadVersion,
+		}
+	}
+	return tasks.Result{
+		Status:         tasks.Warning,
+		Summary:        summary,
+		URL:            "https://docs.newrelic.com/docs/more-integrations/open-source-telemetry-integrations/opentelemetry/get-started/opentelemetry-set-up-your-app",
+		Payload:        statuses,
+		PayloadVersion: otlpPayloadVersion,
+	}
+}
@@ -0,0 +1,90 @@
+package collector
+
+import (
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+)
+
+func mockSuccessfulDial(network, address string, timeout time.Duration) (net.Conn, error) {
+	client, server := net.Pipe()
+	server.Close()
+	return client, nil
+}
+
+func mockUnsuccessfulDial(network, address string, timeout time.Duration) (net.Conn, error) {
+	return nil, errors.New("dial tcp: i/o timeout")
+}
+
+func TestBaseCollectorConnectOTLP_Execute(t *testing.T) {
+	tests := []struct {
+		name       string
+		upstream   map[string]tasks.Result
+		httpGetter requestFunc
+		dialer     dialFunc
+		want       tasks.Status
+		wantCount  int
+	}{
+		{
+			name:       "should return a Success result when both ports are reachable",
+			upstream:   map[string]tasks.Result{},
+			httpGetter: mockUnsuccessfulRequest400,
+			dialer:     mockSuccessfulDial,
+			want:       tasks.Success,
+			wantCount:  2,
+		},
+		{
+			name:       "should return a Warning result when only the HTTP port is reachable",
+			upstream:   map[string]tasks.Result{},
+			httpGetter: mockUnsuccessfulRequest400,
+			dialer:     mockUnsuccessfulDial,
+			want:       tasks.Warning,
+			wantCount:  2,
+		},
+		{
+			name:       "should return a Warning result when only the gRPC port is reachable",
+			upstream:   map[string]tasks.Result{},
+			httpGetter: mockUnsuccessfulRequestError,
+			dialer:     mockSuccessfulDial,
+			want:       tasks.Warning,
+			wantCount:  2,
+		},
+		{
+			name:       "should return a Failure result when neither port is reachable",
+			upstream:   map[string]tasks.Result{},
+			httpGetter: mockUnsuccessfulRequestError,
+			dialer:     mockUnsuccessfulDial,
+			want:       tasks.Failure,
+			wantCount:  2,
+		},
+		{
+			name: "should check the hosts of every detected region",
+			upstream: map[string]tasks.Result{
+				"Base/Config/RegionDetect": {Payload: []string{"us01", "eu01"}},
+			},
+			httpGetter: mockUnsuccessfulRequest400,
+			dialer:     mockSuccessfulDial,
+			want:       tasks.Success,
+			wantCount:  4,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := BaseCollectorConnectOTLP{
+				httpGetter: tt.httpGetter,
+				dialer:     tt.dialer,
+			}
+			got := p.Execute(tasks.Options{}, tt.upstream)
+			if got.Status != tt.want {
+				t.Errorf("BaseCollectorConnectOTLP.Execute() = %v, want %v", got.Status, tt.want)
+			}
+			statuses, ok := got.Payload.([]OTLPEndpointStatus)
+			if !ok || len(statuses) != tt.wantCount {
+				t.Errorf("BaseCollectorConnectOTLP.Execute() payload = %v, want %d endpoint statuses", got.Payload, tt.wantCount)
+			}
+		})
+	}
+}
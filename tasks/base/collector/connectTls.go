@@ -24,7 +24,7 @@ func (p BaseCollectorTLS) Identifier() tasks.Identifier {
 }
 
 func (p BaseCollectorTLS) Explain() string {
-	return "Check network connection to New Relic US region collector endpoint"
+	return "Check network connection to New Relic US region collector endpoint" + tasks.TimeoutExplanation
 }
 
 func (p BaseCollectorTLS) Dependencies() []string {
@@ -33,15 +33,18 @@ func (p BaseCollectorTLS) Dependencies() []string {
 	}
 }
 
+// RequiresNetwork - This task opens a TLS connection to the collector, it is skipped with -offline
+func (p BaseCollectorTLS) RequiresNetwork() {}
+
 func (p BaseCollectorTLS) Execute(op tasks.Options, upstream map[string]tasks.Result) tasks.Result {
 	p.upstream = upstream
 
 	url := "https://connection-test.newrelic.com/"
 
 	wrapper := httpHelper.RequestWrapper{
-		Method:         "GET",
-		URL:            url,
-		TimeoutSeconds: 30,
+		Method:  "GET",
+		URL:     url,
+		Context: httpHelper.RunContext(),
 	}
 	resp, err := p.httpGetter(wrapper)
 	if err != nil {
@@ -64,10 +67,21 @@ func (p BaseCollectorTLS) prepareCollectorErrorResult(e error) tasks.Result {
 		return result
 	}
 	result.Status = tasks.Failure
-	result.Summary = "There was an error connecting to connection-test.newrelic.com."
-	result.Summary += "\nPlease check network and proxy settings and try again or see -help for more options."
-	result.Summary += "\nError = " + e.Error()
+	category := classifyConnectError(e)
+	result.Payload = ConnectError{Category: category, Error: e.Error()}
 	result.URL = "https://docs.newrelic.com/docs/new-relic-solutions/get-started/networks"
+	summary, url := connectErrorSummary(category, "connection-test.newrelic.com")
+	if summary == "" {
+		result.Summary = "There was an error connecting to connection-test.newrelic.com."
+		result.Summary += "\nPlease check network and proxy settings and try again or see -help for more options."
+	} else {
+		result.Summary = summary
+	}
+	if url != "" {
+		result.URL = url
+	}
+	result.Summary += "\nError = " + e.Error()
+	result.Remediation = proxyRemediation(e, "", p.upstream)
 
 	return result
 }
@@ -94,6 +108,7 @@ func (p BaseCollectorTLS) prepareResult(body, statusCode string) tasks.Result {
 		log.Debug("Body:", body)
 		result.Status = tasks.Warning
 		result.Summary = "connection-test.newrelic.com returned a non-200 STATUS CODE: " + statusCode
+		result.Remediation = proxyRemediation(nil, statusCode, p.upstream)
 		result.Summary += "\nPlease check network and proxy settings and try again or see -help for more options."
 		result.Summary += "\nResponse Body: " + body
 		result.URL = "https://docs.newrelic.com/docs/new-relic-solutions/get-started/networks"
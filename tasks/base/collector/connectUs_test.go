@@ -3,8 +3,11 @@ package collector
 import (
 	"errors"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/newrelic/newrelic-diagnostics-cli/helpers/httpHelper"
 	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
 )
 
@@ -57,7 +60,8 @@ func TestBaseCollectorConnectUS_prepareEarlyResult(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			p := BaseCollectorConnectUS{
+			p := BaseCollectorConnect{
+				region:   usRegion,
 				upstream: tt.fields.upstream,
 			}
 			if got := p.prepareEarlyResult(); !reflect.DeepEqual(got, tt.want) {
@@ -96,7 +100,8 @@ func TestBaseCollectorConnectUS_prepareCollectorErrorResult(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			p := BaseCollectorConnectUS{
+			p := BaseCollectorConnect{
+				region:   usRegion,
 				upstream: tt.fields.upstream,
 			}
 			got := p.prepareCollectorErrorResult(tt.args.e)
@@ -108,6 +113,78 @@ func TestBaseCollectorConnectUS_prepareCollectorErrorResult(t *testing.T) {
 	}
 }
 
+func TestBaseCollectorConnectUS_prepareCollectorErrorResultDNS(t *testing.T) {
+	upstream := map[string]tasks.Result{
+		"Base/Collector/DNSResolve": {
+			Status: tasks.Failure,
+			Payload: []DNSResolution{
+				{Host: "collector.newrelic.com", Error: "lookup collector.newrelic.com: no such host"},
+			},
+		},
+	}
+	p := BaseCollectorConnect{
+		region:   usRegion,
+		upstream: upstream,
+	}
+	got := p.prepareCollectorErrorResult(errors.New("dial tcp: lookup collector.newrelic.com: no such host"))
+
+	if got.Status != tasks.Failure {
+		t.Errorf("prepareCollectorErrorResult() Status = %v, want %v", got.Status, tasks.Failure)
+	}
+	if !strings.HasPrefix(got.Summary, "DNS resolution failed for collector.newrelic.com") {
+		t.Errorf("prepareCollectorErrorResult() Summary = %v, want DNS resolution failure", got.Summary)
+	}
+}
+
+func TestBaseCollectorConnectUS_prepareCollectorErrorResultCategory(t *testing.T) {
+	tests := []struct {
+		name        string
+		err         error
+		wantSummary string
+		wantURL     string
+	}{
+		{
+			name:        "connection reset",
+			err:         errors.New(`Get "https://collector.newrelic.com/jserrors/ping": read tcp 10.0.0.5:51234->162.247.241.2:443: read: connection reset by peer`),
+			wantSummary: "The connection to collector.newrelic.com (US Region) was reset (connection reset)\nThe TCP connection was opened",
+			wantURL:     connectErrorAdvices[resetErrorCategory].url,
+		},
+		{
+			name:        "TLS handshake",
+			err:         errors.New(`Get "https://collector.newrelic.com/jserrors/ping": x509: certificate signed by unknown authority`),
+			wantSummary: "The TLS handshake with collector.newrelic.com (US Region) failed (TLS handshake)",
+			wantURL:     connectErrorAdvices[tlsHandshakeErrorCategory].url,
+		},
+		{
+			name:        "timeout",
+			err:         errors.New(`Get "https://collector.newrelic.com/jserrors/ping": dial tcp 162.247.241.2:443: i/o timeout`),
+			wantSummary: "The connection to collector.newrelic.com (US Region) timed out (timeout)",
+			wantURL:     networksDocURL,
+		},
+		{
+			name:        "other",
+			err:         errors.New("received HTTP Error: this is an error"),
+			wantSummary: "There was an error connecting to collector.newrelic.com (US Region)",
+			wantURL:     networksDocURL,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := BaseCollectorConnect{region: usRegion}
+			got := p.prepareCollectorErrorResult(tt.err)
+			if !strings.HasPrefix(got.Summary, tt.wantSummary) {
+				t.Errorf("prepareCollectorErrorResult() Summary = %v, want prefix %v", got.Summary, tt.wantSummary)
+			}
+			if got.URL != tt.wantURL {
+				t.Errorf("prepareCollectorErrorResult() URL = %v, want %v", got.URL, tt.wantURL)
+			}
+			if payload := got.Payload.(ConnectError); payload.Category != classifyConnectError(tt.err) || payload.Error != tt.err.Error() {
+				t.Errorf("prepareCollectorErrorResult() Payload = %v", payload)
+			}
+		})
+	}
+}
+
 func TestBaseCollectorConnectUS_prepareResponseErrorResult(t *testing.T) {
 	sampleError := errors.New("could not parse response body")
 	type fields struct {
@@ -143,7 +220,8 @@ func TestBaseCollectorConnectUS_prepareResponseErrorResult(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			p := BaseCollectorConnectUS{
+			p := BaseCollectorConnect{
+				region:   usRegion,
 				upstream: tt.fields.upstream,
 			}
 			got := p.prepareResponseErrorResult(tt.args.e, tt.args.statusCode)
@@ -161,6 +239,7 @@ func TestBaseCollectorConnectUS_prepareResult(t *testing.T) {
 	type args struct {
 		body       string
 		statusCode string
+		payload    ConnectPayload
 	}
 	tests := []struct {
 		name   string
@@ -177,6 +256,16 @@ func TestBaseCollectorConnectUS_prepareResult(t *testing.T) {
 			fields: fields{},
 			want:   tasks.Success,
 		},
+		{
+			name: "should return a Warning result given '200' status code above the latency threshold",
+			args: args{
+				body:       "mongrel ==> up (true)",
+				statusCode: "200",
+				payload:    ConnectPayload{LatencyMs: 2500},
+			},
+			fields: fields{},
+			want:   tasks.Warning,
+		},
 		{
 			name: "should return a Warning result given a non '200' status code",
 			args: args{
@@ -186,13 +275,44 @@ func TestBaseCollectorConnectUS_prepareResult(t *testing.T) {
 			fields: fields{},
 			want:   tasks.Warning,
 		},
+		{
+			name: "should return a Success result given a certificate that is not close to expiring",
+			args: args{
+				body:       "mongrel ==> up (true)",
+				statusCode: "200",
+				payload:    ConnectPayload{Certificate: &httpHelper.CertificateInfo{NotAfter: time.Now().Add(90 * 24 * time.Hour)}},
+			},
+			fields: fields{},
+			want:   tasks.Success,
+		},
+		{
+			name: "should return a Warning result given a certificate expiring within 30 days",
+			args: args{
+				body:       "mongrel ==> up (true)",
+				statusCode: "200",
+				payload:    ConnectPayload{Certificate: &httpHelper.CertificateInfo{NotAfter: time.Now().Add(10 * 24 * time.Hour)}},
+			},
+			fields: fields{},
+			want:   tasks.Warning,
+		},
+		{
+			name: "should return a Failure result given an expired certificate",
+			args: args{
+				body:       "mongrel ==> up (true)",
+				statusCode: "200",
+				payload:    ConnectPayload{Certificate: &httpHelper.CertificateInfo{NotAfter: time.Now().Add(-24 * time.Hour)}},
+			},
+			fields: fields{},
+			want:   tasks.Failure,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			p := BaseCollectorConnectUS{
+			p := BaseCollectorConnect{
+				region:   usRegion,
 				upstream: tt.fields.upstream,
 			}
-			got := p.prepareResult(tt.args.body, tt.args.statusCode)
+			got := p.prepareResult(tt.args.body, tt.args.statusCode, tt.args.payload)
 			if got.Status != tt.want {
 				t.Errorf("prepareResult() = %v, want %v", got, tt.want)
 			}
@@ -290,7 +410,8 @@ func TestBaseCollectorConnectUS_Execute(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 
-			p := BaseCollectorConnectUS{
+			p := BaseCollectorConnect{
+				region:     usRegion,
 				upstream:   tt.fields.upstream,
 				httpGetter: tt.fields.httpGetter,
 			}
@@ -301,3 +422,57 @@ func TestBaseCollectorConnectUS_Execute(t *testing.T) {
 		})
 	}
 }
+
+func TestBaseCollectorConnectUS_ExecuteTLSPayload(t *testing.T) {
+	tests := []struct {
+		name       string
+		httpGetter requestFunc
+		want       ConnectPayload
+	}{
+		{
+			name:       "should report the negotiated TLS version and cipher suite",
+			httpGetter: mockSuccessfulRequest200TLS,
+			want: ConnectPayload{
+				TLSVersion:     "TLS 1.2",
+				TLSCipherSuite: "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256",
+			},
+		},
+		{
+			name:       "should omit TLS details when the response has no TLS state",
+			httpGetter: mockSuccessfulRequest200,
+			want:       ConnectPayload{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := BaseCollectorConnect{
+				region:     usRegion,
+				httpGetter: tt.httpGetter,
+			}
+			got := p.Execute(tasks.Options{}, map[string]tasks.Result{})
+			payload, ok := got.Payload.(ConnectPayload)
+			if !ok {
+				t.Fatalf("BaseCollectorConnectUS.Execute() payload = %T, want ConnectPayload", got.Payload)
+			}
+			// latency of the mocked request is not deterministic
+			payload.LatencyMs = 0
+			if !reflect.DeepEqual(payload, tt.want) {
+				t.Errorf("BaseCollectorConnectUS.Execute() payload = %v, want %v", got.Payload, tt.want)
+			}
+		})
+	}
+}
+
+func TestBaseCollectorConnectUS_ExecuteRetryError(t *testing.T) {
+	p := BaseCollectorConnect{
+		region:     usRegion,
+		httpGetter: mockUnsuccessfulRequestRetryError,
+	}
+	got := p.Execute(tasks.Options{}, map[string]tasks.Result{})
+	if got.Status != tasks.Failure {
+		t.Errorf("BaseCollectorConnectUS.Execute() = %v, want %v", got.Status, tasks.Failure)
+	}
+	if !strings.Contains(got.Summary, "Error = failed request (connection reset)\nAttempts = 3") {
+		t.Errorf("BaseCollectorConnectUS.Execute() summary = %q, want last error and attempt count", got.Summary)
+	}
+}
@@ -2,15 +2,287 @@ package collector
 
 import (
 	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"errors"
+	"fmt"
+	"io"
 	"io/ioutil"
+	"net"
 	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
 
+	"github.com/newrelic/newrelic-diagnostics-cli/config"
 	"github.com/newrelic/newrelic-diagnostics-cli/helpers/httpHelper"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	baseConfig "github.com/newrelic/newrelic-diagnostics-cli/tasks/base/config"
+	baseEnv "github.com/newrelic/newrelic-diagnostics-cli/tasks/base/env"
 )
 
 type requestFunc func(wrapper httpHelper.RequestWrapper) (*http.Response, error)
 
+// LatencyWarningThreshold - collector round-trip time above which a successful connect check is reported as a Warning
+var LatencyWarningThreshold = 2000 * time.Millisecond
+
+// CertExpiryWarningWindow - a collector certificate expiring within this window is reported as a Warning
+var CertExpiryWarningWindow = 30 * 24 * time.Hour
+
+// ConnectPayload - details about the connection made to a collector endpoint
+type ConnectPayload struct {
+	TLSVersion     string                      `json:",omitempty"`
+	TLSCipherSuite string                      `json:",omitempty"`
+	Certificate    *httpHelper.CertificateInfo `json:",omitempty"`
+	LatencyMs      int64
+	// Attempts is the number of attempts the request took when it was retried, LatencyMs is the one of the last attempt
+	Attempts int `json:",omitempty"`
+	// ServerTime is the Date header of the response and ReceivedAt the local time it was received, see Base/Env/ClockSkew
+	ServerTime *time.Time `json:",omitempty"`
+	ReceivedAt *time.Time `json:",omitempty"`
+}
+
+// connectPayloadVersion - the PayloadVersion of the Base/Collector/Connect* results, bump it when ConnectPayload changes shape
+const connectPayloadVersion = 2
+
+func newConnectPayload(tlsInfo *httpHelper.TLSConnectionInfo, cert *httpHelper.CertificateInfo, latency time.Duration) ConnectPayload {
+	payload := ConnectPayload{
+		Certificate: cert,
+		LatencyMs:   latency.Milliseconds(),
+	}
+	if tlsInfo != nil {
+		payload.TLSVersion = tlsInfo.Version
+		payload.TLSCipherSuite = tlsInfo.CipherSuite
+	}
+	return payload
+}
+
+// setClockTimes - records the collector clock from the Date header of the response, left unset when the header is missing
+func (p *ConnectPayload) setClockTimes(dateHeader string, receivedAt time.Time) {
+	serverTime, err := http.ParseTime(dateHeader)
+	if err != nil {
+		return
+	}
+	serverTime = serverTime.UTC()
+	receivedAt = receivedAt.UTC()
+	p.ServerTime = &serverTime
+	p.ReceivedAt = &receivedAt
+}
+
+// ClockTimes - the local and collector clocks when the response was received, false when the response had no Date header
+func (p ConnectPayload) ClockTimes() (time.Time, time.Time, bool) {
+	if p.ServerTime == nil || p.ReceivedAt == nil {
+		return time.Time{}, time.Time{}, false
+	}
+	return *p.ReceivedAt, *p.ServerTime, true
+}
+
+func (p ConnectPayload) exceedsLatencyThreshold() bool {
+	return p.LatencyMs > LatencyWarningThreshold.Milliseconds()
+}
+
+func (p ConnectPayload) latencyString() string {
+	return strconv.FormatInt(p.LatencyMs, 10) + "ms"
+}
+
+// setAttempts - records the attempts the request took, left unset when the first attempt got the response
+func (p *ConnectPayload) setAttempts(attempts int) {
+	if attempts > 1 {
+		p.Attempts = attempts
+	}
+}
+
+// attemptsString - reported next to the latency when the request was retried, empty otherwise
+func (p ConnectPayload) attemptsString() string {
+	if p.Attempts == 0 {
+		return ""
+	}
+	return " (Attempts = " + strconv.Itoa(p.Attempts) + ")"
+}
+
+// tlsSummary - summary line reporting the negotiated TLS version, empty if the connection was not made over TLS
+func tlsSummary(payload ConnectPayload) string {
+	if payload.TLSVersion == "" {
+		return ""
+	}
+	return "\nTLS Version = " + payload.TLSVersion + ", Cipher Suite = " + payload.TLSCipherSuite
+}
+
+// certSummary - summary line reporting the leaf certificate presented by the server, empty if no certificate was presented
+func certSummary(payload ConnectPayload) string {
+	if payload.Certificate == nil {
+		return ""
+	}
+	return "\nCertificate Subject = " + payload.Certificate.Subject + ", Issuer = " + payload.Certificate.Issuer + ", Expires = " + payload.Certificate.NotAfter.Format(time.RFC3339)
+}
+
+// checkCertExpiry - returns Failure if the leaf certificate has expired, Warning if it expires within CertExpiryWarningWindow and None otherwise
+func checkCertExpiry(cert *httpHelper.CertificateInfo, now time.Time) tasks.Status {
+	if cert == nil {
+		return tasks.None
+	}
+	if now.After(cert.NotAfter) {
+		return tasks.Failure
+	}
+	if cert.NotAfter.Sub(now) < CertExpiryWarningWindow {
+		return tasks.Warning
+	}
+	return tasks.None
+}
+
+// customHostSummary - summary line noting that the -collector-host override was used instead of the region's collector host
+func customHostSummary() string {
+	if config.Flags.CollectorHost == "" {
+		return ""
+	}
+	return "\nNote: a custom collector host was used (-collector-host " + config.Flags.CollectorHost + ")"
+}
+
+// containerSummary - summary line noting the check ran inside a container, whose network egress may differ from the host's,
+// empty when Base/Env/DetectContainer found none
+func containerSummary(upstream map[string]tasks.Result) string {
+	container, ok := upstream["Base/Env/DetectContainer"].Payload.(baseEnv.ContainerEnvironment)
+	if !ok {
+		return ""
+	}
+	return "\nNote: this check ran inside a " + container.Runtime + " container, the network egress of the host may differ"
+}
+
+// proxySummary - summary line reporting the proxy the collector request went through, with any credentials redacted
+func proxySummary(upstream map[string]tasks.Result) string {
+	proxy := detectedProxy(upstream)
+	if proxy == "" {
+		return "\nProxy = no proxy"
+	}
+	return "\nProxy = " + httpHelper.RedactProxyURL(proxy)
+}
+
+// detectedProxy - returns the proxy found by Base/Config/ProxyDetect, falling back to the proxy environment variables set by the user or by the -proxy flag
+func detectedProxy(upstream map[string]tasks.Result) string {
+	if proxyConfig, ok := upstream["Base/Config/ProxyDetect"].Payload.(baseConfig.ProxyConfig); ok && proxyConfig.URL() != "" {
+		return proxyConfig.URL()
+	}
+	for _, key := range []string{"HTTPS_PROXY", "https_proxy", "HTTP_PROXY", "http_proxy", "ALL_PROXY", "all_proxy"} {
+		if proxy := os.Getenv(key); proxy != "" {
+			return proxy
+		}
+	}
+	return ""
+}
+
+// proxyRemediation - tags the failures caused by the proxy settings, a proxy that can't be reached or that rejects
+// the credentials, which the customer fixes in the proxy settings of the agent and nrdiag. nil for any other failure
+func proxyRemediation(e error, statusCode string, upstream map[string]tasks.Result) *tasks.Remediation {
+	if detectedProxy(upstream) == "" {
+		return nil
+	}
+	if statusCode == strconv.Itoa(http.StatusProxyAuthRequired) {
+		return tasks.ConfigChangeRemediation()
+	}
+	if e == nil {
+		return nil
+	}
+	message := strings.ToLower(e.Error())
+	for _, proxyError := range []string{"proxyconnect", "proxy authentication required", "socks connect"} {
+		if strings.Contains(message, proxyError) {
+			return tasks.ConfigChangeRemediation()
+		}
+	}
+	return nil
+}
+
+// attemptsSummary - summary line reporting how many attempts were made when a request failed after being retried
+func attemptsSummary(e error) string {
+	var retryErr httpHelper.RetryError
+	if !errors.As(e, &retryErr) {
+		return ""
+	}
+	return "\nAttempts = " + strconv.Itoa(retryErr.Attempts)
+}
+
+// The categories of the errors of a failed collector connection, reported in the payload of its Failure result
+const (
+	resetErrorCategory        = "connection reset"
+	tlsHandshakeErrorCategory = "TLS handshake"
+	timeoutErrorCategory      = "timeout"
+	refusedErrorCategory      = "connection refused"
+	networkErrorCategory      = "network error"
+	dnsErrorCategory          = "DNS resolution"
+)
+
+// ConnectError - the error of a failed connection and its category, e.g. a connection reset by a firewall
+type ConnectError struct {
+	Category string
+	Error    string
+}
+
+// connectErrorAdvice - what a category of connection error usually means and where to read about it
+type connectErrorAdvice struct {
+	headline string
+	advice   string
+	url      string
+}
+
+// connectErrorAdvices - the advice for each category but networkErrorCategory, which keeps the generic summary of the task
+var connectErrorAdvices = map[string]connectErrorAdvice{
+	resetErrorCategory: {
+		headline: "The connection to %s was reset",
+		advice:   "The TCP connection was opened, then reset or closed before the request completed, usually during the TLS handshake. This is the mark of a firewall, IDS or TLS inspecting proxy between this host and New Relic: ask the network team to allow the New Relic domains and exempt them from TLS inspection.",
+		url:      "https://docs.newrelic.com/docs/new-relic-solutions/get-started/networks#endpoints",
+	},
+	tlsHandshakeErrorCategory: {
+		headline: "The TLS handshake with %s failed",
+		advice:   "The connection was opened but no TLS session could be negotiated. A proxy presenting its own certificate, a missing CA certificate on this host or a device blocking TLS 1.2 and above are the usual causes: check the certificate chain received and the trusted CAs of the host.",
+		url:      "https://docs.newrelic.com/docs/new-relic-solutions/get-started/networks#tls",
+	},
+	timeoutErrorCategory: {
+		headline: "The connection to %s timed out",
+		advice:   "No answer came back in time, the traffic may be dropped silently by a firewall or need a proxy. Check the outbound rules for port 443, the proxy settings and the -http-timeout flag.",
+	},
+	refusedErrorCategory: {
+		headline: "%s refused the connection",
+		advice:   "Nothing accepted the connection on port 443, which usually means a local firewall rejected it or the proxy set is not listening.",
+	},
+}
+
+// classifyConnectError - the category of the error of a failed request, by its type and, for the errors only known
+// by their message such as those of Windows sockets, by its text
+func classifyConnectError(e error) string {
+	var recordHeaderErr tls.RecordHeaderError
+	var unknownAuthorityErr x509.UnknownAuthorityError
+	var certificateInvalidErr x509.CertificateInvalidError
+	var hostnameErr x509.HostnameError
+	var netErr net.Error
+	message := strings.ToLower(e.Error())
+	switch {
+	case errors.Is(e, syscall.ECONNRESET), errors.Is(e, io.EOF), errors.Is(e, io.ErrUnexpectedEOF),
+		strings.Contains(message, "connection reset"), strings.Contains(message, "forcibly closed by the remote host"):
+		return resetErrorCategory
+	case errors.As(e, &recordHeaderErr), errors.As(e, &unknownAuthorityErr), errors.As(e, &certificateInvalidErr), errors.As(e, &hostnameErr),
+		strings.Contains(message, "tls:"), strings.Contains(message, "tls handshake"), strings.Contains(message, "x509:"):
+		return tlsHandshakeErrorCategory
+	case errors.Is(e, context.DeadlineExceeded), errors.Is(e, os.ErrDeadlineExceeded), errors.As(e, &netErr) && netErr.Timeout(),
+		strings.Contains(message, "timeout"), strings.Contains(message, "timed out"):
+		return timeoutErrorCategory
+	case errors.Is(e, syscall.ECONNREFUSED), strings.Contains(message, "connection refused"), strings.Contains(message, "actively refused"):
+		return refusedErrorCategory
+	}
+	return networkErrorCategory
+}
+
+// connectErrorSummary - the first lines of the summary of a failed connection to host and the page on its category.
+// Both are empty for networkErrorCategory, and the URL for the categories the networks page of the task covers
+func connectErrorSummary(category string, host string) (string, string) {
+	advice, ok := connectErrorAdvices[category]
+	if !ok {
+		return "", ""
+	}
+	return fmt.Sprintf(advice.headline, host) + " (" + category + ")\n" + advice.advice, advice.url
+}
+
 func mockSuccessfulRequest200(wrapper httpHelper.RequestWrapper) (*http.Response, error) {
 	return &http.Response{
 		StatusCode: 200,
@@ -18,6 +290,17 @@ func mockSuccessfulRequest200(wrapper httpHelper.RequestWrapper) (*http.Response
 	}, nil
 }
 
+func mockSuccessfulRequest200TLS(wrapper httpHelper.RequestWrapper) (*http.Response, error) {
+	return &http.Response{
+		StatusCode: 200,
+		Body:       ioutil.NopCloser(bytes.NewReader([]byte("test body"))),
+		TLS: &tls.ConnectionState{
+			Version:     tls.VersionTLS12,
+			CipherSuite: tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+		},
+	}, nil
+}
+
 func mockUnsuccessfulRequest400(wrapper httpHelper.RequestWrapper) (*http.Response, error) {
 	return &http.Response{
 		StatusCode: 400,
@@ -28,3 +311,10 @@ func mockUnsuccessfulRequest400(wrapper httpHelper.RequestWrapper) (*http.Respon
 func mockUnsuccessfulRequestError(wrapper httpHelper.RequestWrapper) (*http.Response, error) {
 	return &http.Response{}, errors.New("failed request (timeout)")
 }
+
+func mockUnsuccessfulRequestRetryError(wrapper httpHelper.RequestWrapper) (*http.Response, error) {
+	return nil, httpHelper.RetryError{
+		Attempts: wrapper.RetryCount + 1,
+		Err:      errors.New("failed request (connection reset)"),
+	}
+}
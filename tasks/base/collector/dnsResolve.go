@@ -0,0 +1,148 @@
+package collector
+
+import (
+	"net"
+	"strings"
+
+	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+)
+
+type lookupHostFunc func(host string) ([]string, error)
+
+// DNSResolution - outcome of resolving a single collector hostname
+type DNSResolution struct {
+	Host  string
+	IPs   []string `json:",omitempty"`
+	Error string   `json:",omitempty"`
+}
+
+// dnsPayloadVersion - the PayloadVersion of the results, bump it when DNSResolution changes shape
+const dnsPayloadVersion = 1
+
+// BaseCollectorDNSResolve - This task resolves the collector hostnames and reports the resolved addresses
+type BaseCollectorDNSResolve struct {
+	upstream   map[string]tasks.Result
+	lookupHost lookupHostFunc
+}
+
+// Identifier - This returns the Category, Subcategory and Name of each task
+func (p BaseCollectorDNSResolve) Identifier() tasks.Identifier {
+	return tasks.IdentifierFromString("Base/Collector/DNSResolve")
+}
+
+// Explain - Returns the help text for each individual task
+func (p BaseCollectorDNSResolve) Explain() string {
+	return "Check DNS resolution of New Relic collector hostnames"
+}
+
+// Dependencies - This task depends on Base/Config/RegionDetect
+func (p BaseCollectorDNSResolve) Dependencies() []string {
+	return []string{
+		"Base/Config/RegionDetect",
+	}
+}
+
+// RequiresNetwork - This task queries DNS for the collector hostnames, it is skipped with -offline
+func (p BaseCollectorDNSResolve) RequiresNetwork() {}
+
+// Execute - Resolves the collector hostname of each detected region
+func (p BaseCollectorDNSResolve) Execute(op tasks.Options, upstream map[string]tasks.Result) tasks.Result {
+	p.upstream = upstream
+
+	var resolutions []DNSResolution
+	resolved := make(map[string]bool)
+	for _, region := range p.getRegions() {
+		// with -collector-host every region shares the same host
+		host := region.collectorHost()
+		if resolved[host] {
+			continue
+		}
+		resolved[host] = true
+		resolutions = append(resolutions, p.resolve(host))
+	}
+
+	return p.prepareResult(resolutions)
+}
+
+// getRegions - returns the collector regions matching the detected regions. If no regions were detected, all regions are returned
+func (p BaseCollectorDNSResolve) getRegions() []collectorRegion {
+	detected, _ := p.upstream["Base/Config/RegionDetect"].Payload.([]string)
+	if len(detected) == 0 {
+		return collectorRegions
+	}
+
+	var regions []collectorRegion
+	for _, region := range collectorRegions {
+		if tasks.StringInSlice(region.key, detected) {
+			regions = append(regions, region)
+		}
+	}
+	return regions
+}
+
+func (p BaseCollectorDNSResolve) resolve(host string) DNSResolution {
+	hostname := host
+	// the -collector-host override may include a port
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		hostname = h
+	}
+	ips, err := p.lookupHost(hostname)
+	if err != nil {
+		log.Debug("Unable to resolve", host, ":", err)
+		return DNSResolution{
+			Host:  host,
+			Error: err.Error(),
+		}
+	}
+	log.Debug(host, "resolved to", ips)
+	return DNSResolution{
+		Host: host,
+		IPs:  ips,
+	}
+}
+
+func (p BaseCollectorDNSResolve) prepareResult(resolutions []DNSResolution) tasks.Result {
+	var failures []string
+	var summary string
+	for _, resolution := range resolutions {
+		if resolution.Error != "" {
+			failures = append(failures, resolution.Host)
+			summary += "Unable to resolve " + resolution.Host + "\nError = " + resolution.Error + "\n"
+			continue
+		}
+		summary += resolution.Host + " resolved to " + strings.Join(resolution.IPs, ", ") + "\n"
+	}
+
+	if len(failures) > 0 {
+		summary += "Please check the DNS settings of this host. If you connect through a proxy, the proxy may still be able to resolve these hostnames on your behalf."
+		return tasks.Result{
+			Status:         tasks.Failure,
+			Summary:        summary,
+			URL:            networksDocURL,
+			Payload:        resolutions,
+			PayloadVersion: dnsPayloadVersion,
+		}
+	}
+
+	return tasks.Result{
+		Status:         tasks.Success,
+		Summary:        summary,
+		Payload:        resolutions,
+		PayloadVersion: dnsPayloadVersion,
+	}
+}
+
+// dnsFailureFor - returns the resolution error reported by Base/Collector/DNSResolve for a host, if any
+func dnsFailureFor(host string, upstream map[string]tasks.Result) string {
+	resolutions, ok := upstream["Base/Collector/DNSResolve"].Payload.([]DNSResolution)
+	if !ok {
+		return ""
+	}
+	for _, resolution := range resolutions {
+		if resolution.Host == host {
+			return resolution.Error
+		}
+	}
+	return ""
+}
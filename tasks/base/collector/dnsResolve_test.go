@@ -0,0 +1,118 @@
+package collector
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/config"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+)
+
+func mockSuccessfulLookup(host string) ([]string, error) {
+	return []string{"162.247.241.2"}, nil
+}
+
+func mockUnsuccessfulLookup(host string) ([]string, error) {
+	return nil, errors.New("lookup " + host + ": no such host")
+}
+
+func TestBaseCollectorDNSResolve_Execute(t *testing.T) {
+	tests := []struct {
+		name       string
+		upstream   map[string]tasks.Result
+		lookupHost lookupHostFunc
+		want       tasks.Status
+		wantHosts  []string
+	}{
+		{
+			name:       "should resolve every collector host when no region was detected",
+			upstream:   map[string]tasks.Result{},
+			lookupHost: mockSuccessfulLookup,
+			want:       tasks.Success,
+			wantHosts:  []string{"collector.newrelic.com", "collector.eu.newrelic.com", "gov-collector.newrelic.com"},
+		},
+		{
+			name: "should only resolve the collector hosts of the detected regions",
+			upstream: map[string]tasks.Result{
+				"Base/Config/RegionDetect": {Payload: []string{"eu01"}},
+			},
+			lookupHost: mockSuccessfulLookup,
+			want:       tasks.Success,
+			wantHosts:  []string{"collector.eu.newrelic.com"},
+		},
+		{
+			name: "should return a Failure result when a host can not be resolved",
+			upstream: map[string]tasks.Result{
+				"Base/Config/RegionDetect": {Payload: []string{"us01"}},
+			},
+			lookupHost: mockUnsuccessfulLookup,
+			want:       tasks.Failure,
+			wantHosts:  []string{"collector.newrelic.com"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := BaseCollectorDNSResolve{
+				lookupHost: tt.lookupHost,
+			}
+			got := p.Execute(tasks.Options{}, tt.upstream)
+
+			if got.Status != tt.want {
+				t.Errorf("Execute() Status = %v, want %v", got.Status, tt.want)
+			}
+			resolutions, ok := got.Payload.([]DNSResolution)
+			if !ok {
+				t.Fatalf("Execute() Payload = %T, want []DNSResolution", got.Payload)
+			}
+			var hosts []string
+			for _, resolution := range resolutions {
+				hosts = append(hosts, resolution.Host)
+			}
+			if !reflect.DeepEqual(hosts, tt.wantHosts) {
+				t.Errorf("Execute() resolved hosts = %v, want %v", hosts, tt.wantHosts)
+			}
+		})
+	}
+}
+
+func TestBaseCollectorDNSResolve_ExecuteCustomCollectorHost(t *testing.T) {
+	config.Flags.CollectorHost = "relay.example.com:8443"
+	defer func() { config.Flags.CollectorHost = "" }()
+
+	var lookedUp []string
+	p := BaseCollectorDNSResolve{
+		lookupHost: func(host string) ([]string, error) {
+			lookedUp = append(lookedUp, host)
+			return mockSuccessfulLookup(host)
+		},
+	}
+	got := p.Execute(tasks.Options{}, map[string]tasks.Result{})
+
+	if !reflect.DeepEqual(lookedUp, []string{"relay.example.com"}) {
+		t.Errorf("Execute() looked up %v, want [relay.example.com]", lookedUp)
+	}
+	if got.Status != tasks.Success {
+		t.Errorf("Execute() Status = %v, want %v", got.Status, tasks.Success)
+	}
+}
+
+func Test_dnsFailureFor(t *testing.T) {
+	upstream := map[string]tasks.Result{
+		"Base/Collector/DNSResolve": {
+			Payload: []DNSResolution{
+				{Host: "collector.newrelic.com", IPs: []string{"162.247.241.2"}},
+				{Host: "collector.eu.newrelic.com", Error: "no such host"},
+			},
+		},
+	}
+	if got := dnsFailureFor("collector.newrelic.com", upstream); got != "" {
+		t.Errorf("dnsFailureFor() = %v, want empty", got)
+	}
+	if got := dnsFailureFor("collector.eu.newrelic.com", upstream); got != "no such host" {
+		t.Errorf("dnsFailureFor() = %v, want no such host", got)
+	}
+	if got := dnsFailureFor("collector.newrelic.com", map[string]tasks.Result{}); got != "" {
+		t.Errorf("dnsFailureFor() = %v, want empty", got)
+	}
+}
@@ -0,0 +1,191 @@
+package collector
+
+import (
+	"bytes"
+	"net/http"
+	"strconv"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/helpers/httpHelper"
+
+	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+)
+
+// largePayloadSizes - the sizes of the dummy bodies posted to the collector, in bytes, smallest first. Agent payloads
+// are up to 1MB, a path MTU black hole usually shows from a few KB
+var largePayloadSizes = []int{
+	1 << 10,   // 1KB
+	8 << 10,   // 8KB
+	64 << 10,  // 64KB
+	256 << 10, // 256KB
+	1 << 20,   // 1MB
+}
+
+// largePayloadTimeoutSeconds - the timeout of each request. A black holed request hangs rather than failing, so it is
+// lower than the -http-timeout default
+const largePayloadTimeoutSeconds = 10
+
+// LargePayloadAttempt - outcome of posting a single dummy body
+type LargePayloadAttempt struct {
+	Bytes      int
+	StatusCode int    `json:",omitempty"`
+	Error      string `json:",omitempty"`
+	// Rejected is true when the response was not the collector's own, see rejectedStatus
+	Rejected bool `json:",omitempty"`
+}
+
+// LargePayloadStatus - outcome of posting dummy bodies of increasing size to a collector
+type LargePayloadStatus struct {
+	URL                    string
+	LargestSuccessfulBytes int
+	Attempts               []LargePayloadAttempt
+}
+
+// largePayloadPayloadVersion - the PayloadVersion of the results, bump it when LargePayloadStatus changes shape
+const largePayloadPayloadVersion = 2
+
+// BaseCollectorLargePayload - This task posts bodies of increasing size to a collector the ping reached and reports the largest one that went through
+type BaseCollectorLargePayload struct {
+	httpGetter requestFunc
+}
+
+// Identifier - This returns the Category, Subcategory and Name of each task
+func (p BaseCollectorLargePayload) Identifier() tasks.Identifier {
+	return tasks.IdentifierFromString("Base/Collector/LargePayload")
+}
+
+// Explain - Returns the help text for each individual task
+func (p BaseCollectorLargePayload) Explain() string {
+	return "Check that request bodies as large as agent payloads reach the New Relic collector, to detect MTU and proxy issues (requests time out after " + strconv.Itoa(largePayloadTimeoutSeconds) + " seconds)"
+}
+
+// Dependencies - This task depends on Base/Config/ProxyDetect and the collector connect checks
+func (p BaseCollectorLargePayload) Dependencies() []string {
+	dependencies := []string{
+		"Base/Config/ProxyDetect",
+	}
+	for _, region := range collectorRegions {
+		dependencies = append(dependencies, region.identifier)
+	}
+	return dependencies
+}
+
+// RequiresNetwork - This task posts data to the collector, it is skipped with -offline
+func (p BaseCollectorLargePayload) RequiresNetwork() {}
+
+// Execute - Posts the dummy bodies to the first collector whose ping succeeded, up to the first size that fails
+func (p BaseCollectorLargePayload) Execute(op tasks.Options, upstream map[string]tasks.Result) tasks.Result {
+	region, ok := connectedRegion(upstream)
+	if !ok {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "No collector connect check succeeded, skipping the large payload check",
+		}
+	}
+
+	url := "https://" + region.collectorHost() + "/jserrors/ping"
+	status := LargePayloadStatus{URL: url}
+	for _, size := range largePayloadSizes {
+		attempt := p.post(url, size)
+		attempt.Rejected = rejectedStatus(attempt, status.Attempts)
+		status.Attempts = append(status.Attempts, attempt)
+		if attempt.Error != "" || attempt.Rejected {
+			break
+		}
+		status.LargestSuccessfulBytes = size
+	}
+
+	return prepareLargePayloadResult(status, upstream)
+}
+
+// connectedRegion - the first region whose Base/Collector/Connect* check succeeded
+func connectedRegion(upstream map[string]tasks.Result) (collectorRegion, bool) {
+	for _, region := range collectorRegions {
+		if upstream[region.identifier].Status == tasks.Success {
+			return region, true
+		}
+	}
+	return collectorRegion{}, false
+}
+
+// rejectedStatus - whether a response was returned by the network path rather than the collector: a 413, or an error
+// status other than the one of the smallest body, which is the collector's answer to the dummy bodies. Any other
+// status code counts as a success, the body made it through the network path
+func rejectedStatus(attempt LargePayloadAttempt, previous []LargePayloadAttempt) bool {
+	if attempt.StatusCode == http.StatusRequestEntityTooLarge {
+		return true
+	}
+	if attempt.StatusCode < 400 || len(previous) == 0 {
+		return false
+	}
+	return attempt.StatusCode != previous[0].StatusCode
+}
+
+// post - posts a dummy body of the given size, see rejectedStatus for the responses that count as a failure
+func (p BaseCollectorLargePayload) post(url string, size int) LargePayloadAttempt {
+	wrapper := httpHelper.RequestWrapper{
+		Method:         "POST",
+		URL:            url,
+		Headers:        map[string]string{"Content-Type": "text/plain"},
+		Payload:        bytes.NewReader(bytes.Repeat([]byte("x"), size)),
+		Context:        httpHelper.RunContext(),
+		TimeoutSeconds: largePayloadTimeoutSeconds,
+	}
+	resp, err := p.httpGetter(wrapper)
+	if err != nil {
+		log.Debug("Error posting", size, "bytes to", url, ":", err)
+		return LargePayloadAttempt{Bytes: size, Error: err.Error()}
+	}
+	defer resp.Body.Close()
+
+	log.Debug("Response received from", url, "for", size, "bytes:", resp.StatusCode)
+	return LargePayloadAttempt{Bytes: size, StatusCode: resp.StatusCode}
+}
+
+func prepareLargePayloadResult(status LargePayloadStatus, upstream map[string]tasks.Result) tasks.Result {
+	result := tasks.Result{
+		Status:         tasks.Success,
+		Payload:        status,
+		PayloadVersion: largePayloadPayloadVersion,
+	}
+
+	last := status.Attempts[len(status.Attempts)-1]
+	if last.Rejected {
+		result.Status = tasks.Failure
+		result.Summary = "A " + formatPayloadSize(last.Bytes) + " request body to " + status.URL + " was rejected with status " + strconv.Itoa(last.StatusCode) + " " + http.StatusText(last.StatusCode)
+		if status.LargestSuccessfulBytes > 0 {
+			result.Summary += ", request bodies of up to " + formatPayloadSize(status.LargestSuccessfulBytes) + " went through"
+		}
+		result.Summary += "\nThe response did not come from the collector: a proxy or firewall on the network path limits the request size. Agents may connect but fail to send their data."
+		result.Summary += proxySummary(upstream)
+		result.URL = networksDocURL
+		return result
+	}
+	if last.Error == "" {
+		result.Summary = "Request bodies of up to " + formatPayloadSize(status.LargestSuccessfulBytes) + " reached " + status.URL
+		return result
+	}
+
+	result.Status = tasks.Warning
+	if status.LargestSuccessfulBytes == 0 {
+		result.Summary = "The collector ping succeeded but a " + formatPayloadSize(last.Bytes) + " request body did not reach " + status.URL
+	} else {
+		result.Summary = "Request bodies of up to " + formatPayloadSize(status.LargestSuccessfulBytes) + " reached " + status.URL + " but a " + formatPayloadSize(last.Bytes) + " body did not"
+	}
+	result.Summary += "\nError = " + last.Error
+	result.Summary += "\nSmall requests working while larger ones fail is typical of an MTU mismatch on the network path or of a proxy limiting the request size. Agents may connect but fail to send their data."
+	result.Summary += proxySummary(upstream)
+	result.URL = networksDocURL
+	return result
+}
+
+// formatPayloadSize - a size in bytes as KB or MB, e.g. 64KB
+func formatPayloadSize(size int) string {
+	if size >= 1<<20 && size%(1<<20) == 0 {
+		return strconv.Itoa(size>>20) + "MB"
+	}
+	if size >= 1<<10 && size%(1<<10) == 0 {
+		return strconv.Itoa(size>>10) + "KB"
+	}
+	return strconv.Itoa(size) + " bytes"
+}
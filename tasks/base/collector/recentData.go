@@ -0,0 +1,298 @@
+package collector
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/config"
+	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	baseConfig "github.com/newrelic/newrelic-diagnostics-cli/tasks/base/config"
+)
+
+// defaultRecentDataMinutes - how long an entity can go without sending data before it is reported as not reporting,
+// unless set with the minutes override
+const defaultRecentDataMinutes = 15
+
+// entitySearchQuery - finds the entities with the app name in every account the user API key can see
+const entitySearchQuery = `query($query: String!) { actor { entitySearch(query: $query) { results { entities { guid name accountId entityType } } } } }`
+
+// latestDataQuery - the NRQL query returning the timestamp of the most recent data of an entity
+const latestDataQuery = `query($accountId: Int!, $nrql: Nrql!) { actor { account(id: $accountId) { nrql(query: $nrql) { results } } } }`
+
+// notReportingDocURL - troubleshooting agents that are configured but don't report
+const notReportingDocURL = "https://docs.newrelic.com/docs/apm/agents/manage-apm-agents/troubleshooting/not-seeing-data/"
+
+// EntityDataStatus - the most recent data received from an entity named after an app name of the agent config
+type EntityDataStatus struct {
+	AppName    string
+	GUID       string `json:",omitempty"`
+	EntityType string `json:",omitempty"`
+	AccountID  int    `json:",omitempty"`
+	// LatestData is nil when nothing was received in the last day
+	LatestData *time.Time `json:",omitempty"`
+	Error      string     `json:",omitempty"`
+}
+
+// recentDataPayloadVersion - the PayloadVersion of the results, bump it when EntityDataStatus changes shape
+const recentDataPayloadVersion = 1
+
+type entitySearchResponse struct {
+	Data struct {
+		Actor struct {
+			EntitySearch struct {
+				Results struct {
+					Entities []struct {
+						GUID       string `json:"guid"`
+						Name       string `json:"name"`
+						AccountID  int    `json:"accountId"`
+						EntityType string `json:"entityType"`
+					} `json:"entities"`
+				} `json:"results"`
+			} `json:"entitySearch"`
+		} `json:"actor"`
+	} `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+type latestDataResponse struct {
+	Data struct {
+		Actor struct {
+			Account struct {
+				NRQL struct {
+					Results []map[string]*float64 `json:"results"`
+				} `json:"nrql"`
+			} `json:"account"`
+		} `json:"actor"`
+	} `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+// BaseCollectorRecentData - This task queries NerdGraph for the most recent data received from the entities named
+// after the app names of the agent config, as a successful connection does not mean the data is ingested
+type BaseCollectorRecentData struct {
+	httpGetter requestFunc
+	now        func() time.Time
+}
+
+// Identifier - This returns the Category, Subcategory and Name of each task
+func (p BaseCollectorRecentData) Identifier() tasks.Identifier {
+	return tasks.IdentifierFromString("Base/Collector/RecentData")
+}
+
+// Explain - Returns the help text for each individual task
+func (p BaseCollectorRecentData) Explain() string {
+	return "Check with the -api-key user API key that New Relic received data recently from the applications configured on this host" + tasks.TimeoutExplanation
+}
+
+// Dependencies - This task depends on Base/Collector/ConnectNerdGraph, for an accepted key, Base/Config/AppName and
+// Base/Config/Topology, as a host of a clustered setup may not be the one reporting
+func (p BaseCollectorRecentData) Dependencies() []string {
+	return []string{
+		"Base/Config/ProxyDetect",
+		"Base/Config/AppName",
+		"Base/Config/Topology",
+		"Base/Collector/ConnectNerdGraph",
+	}
+}
+
+// RequiresNetwork - This task queries NerdGraph, it is skipped with -offline
+func (p BaseCollectorRecentData) RequiresNetwork() {}
+
+// Execute - Looks up the entities of each app name and the timestamp of their latest data
+func (p BaseCollectorRecentData) Execute(options tasks.Options, upstream map[string]tasks.Result) tasks.Result {
+	if upstream["Base/Collector/ConnectNerdGraph"].Status != tasks.Success {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "NerdGraph can't be queried without a user API key accepted by Base/Collector/ConnectNerdGraph, see -api-key.",
+		}
+	}
+
+	maxAge := time.Duration(defaultRecentDataMinutes) * time.Minute
+	if options.Options["minutes"] != "" {
+		minutes, err := strconv.Atoi(options.Options["minutes"])
+		if err != nil || minutes <= 0 {
+			return tasks.Result{
+				Status:  tasks.Error,
+				Summary: "The minutes override must be a number of minutes, e.g. -o Base/Collector/RecentData.minutes=30, got " + options.Options["minutes"],
+			}
+		}
+		maxAge = time.Duration(minutes) * time.Minute
+	}
+
+	appNames := recentDataAppNames(options, upstream["Base/Config/AppName"])
+	if len(appNames) == 0 {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "No app name was found in the agent config, set one with -o Base/Collector/RecentData.appName=<name>.",
+		}
+	}
+
+	apiKey := strings.TrimSpace(config.Flags.APIKey)
+	url := nerdGraphURL()
+	var statuses []EntityDataStatus
+	for _, appName := range appNames {
+		statuses = append(statuses, p.entityDataStatuses(url, apiKey, appName)...)
+	}
+	topology, _ := upstream["Base/Config/Topology"].Payload.(baseConfig.AgentTopology)
+	return prepareRecentDataResult(statuses, maxAge, p.now(), topology)
+}
+
+// recentDataAppNames - the appName override, or the unique app names found by Base/Config/AppName
+func recentDataAppNames(options tasks.Options, appNameResult tasks.Result) []string {
+	if appName := strings.TrimSpace(options.Options["appName"]); appName != "" {
+		return []string{appName}
+	}
+	appNameInfos, ok := appNameResult.Payload.([]baseConfig.AppNameInfo)
+	if !ok || appNameResult.Status != tasks.Success {
+		return nil
+	}
+	var appNames []string
+	for _, appNameInfo := range appNameInfos {
+		if appNameInfo.Name != "" && tasks.PosString(appNames, appNameInfo.Name) == -1 {
+			appNames = append(appNames, appNameInfo.Name)
+		}
+	}
+	return appNames
+}
+
+// entityDataStatuses - the latest data of each entity with the app name. An app name without entity is returned
+// without GUID
+func (p BaseCollectorRecentData) entityDataStatuses(url string, apiKey string, appName string) []EntityDataStatus {
+	var search entitySearchResponse
+	err := p.queryNerdGraph(url, apiKey, entitySearchQuery, map[string]interface{}{
+		"query": "name = '" + strings.ReplaceAll(appName, "'", `\'`) + "'",
+	}, &search)
+	if err != nil {
+		return []EntityDataStatus{{AppName: appName, Error: err.Error()}}
+	}
+	if len(search.Errors) > 0 {
+		return []EntityDataStatus{{AppName: appName, Error: redactAPIKey(search.Errors[0].Message, apiKey)}}
+	}
+
+	var statuses []EntityDataStatus
+	for _, entity := range search.Data.Actor.EntitySearch.Results.Entities {
+		// the search also matches names containing the app name
+		if entity.Name != appName {
+			continue
+		}
+		status := EntityDataStatus{AppName: appName, GUID: entity.GUID, EntityType: entity.EntityType, AccountID: entity.AccountID}
+		status.LatestData, err = p.latestData(url, apiKey, entity.AccountID, entity.GUID)
+		if err != nil {
+			status.Error = err.Error()
+		}
+		statuses = append(statuses, status)
+	}
+	if len(statuses) == 0 {
+		return []EntityDataStatus{{AppName: appName}}
+	}
+	return statuses
+}
+
+// latestData - the timestamp of the most recent event of the entity in the last day, nil when there is none
+func (p BaseCollectorRecentData) latestData(url string, apiKey string, accountID int, guid string) (*time.Time, error) {
+	var response latestDataResponse
+	err := p.queryNerdGraph(url, apiKey, latestDataQuery, map[string]interface{}{
+		"accountId": accountID,
+		"nrql":      "SELECT latest(timestamp) FROM Transaction, TransactionError, Span, SystemSample WHERE entityGuid = '" + guid + "' SINCE 1 day ago",
+	}, &response)
+	if err != nil {
+		return nil, err
+	}
+	if len(response.Errors) > 0 {
+		return nil, errors.New(redactAPIKey(response.Errors[0].Message, apiKey))
+	}
+	for _, result := range response.Data.Actor.Account.NRQL.Results {
+		if timestamp := result["latest.timestamp"]; timestamp != nil {
+			latest := time.Unix(0, int64(*timestamp)*int64(time.Millisecond)).UTC()
+			return &latest, nil
+		}
+	}
+	return nil, nil
+}
+
+func (p BaseCollectorRecentData) queryNerdGraph(url string, apiKey string, query string, variables map[string]interface{}, response interface{}) error {
+	body, err := json.Marshal(map[string]interface{}{"query": query, "variables": variables})
+	if err != nil {
+		return err
+	}
+	resp, err := p.httpGetter(nerdGraphRequest(url, apiKey, body))
+	if err != nil {
+		// the error of a failed request may quote its headers or URL, the key must not end up in the output
+		errorMessage := redactAPIKey(err.Error(), apiKey)
+		log.Debug("Error querying", url, ":", errorMessage)
+		return errors.New(errorMessage)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return errors.New(url + " returned STATUS CODE: " + strconv.Itoa(resp.StatusCode))
+	}
+	responseBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(responseBody, response); err != nil {
+		return errors.New("unable to parse the NerdGraph response: " + err.Error())
+	}
+	return nil
+}
+
+func prepareRecentDataResult(statuses []EntityDataStatus, maxAge time.Duration, now time.Time, topology baseConfig.AgentTopology) tasks.Result {
+	result := tasks.Result{
+		Status:         tasks.Success,
+		Payload:        statuses,
+		PayloadVersion: recentDataPayloadVersion,
+	}
+
+	var reporting, stale, failed []string
+	for _, status := range statuses {
+		switch {
+		case status.Error != "":
+			failed = append(failed, fmt.Sprintf("\t%s: %s", status.AppName, status.Error))
+		case status.GUID == "":
+			stale = append(stale, fmt.Sprintf("\t%s: no entity with this name, the agent has never reported", status.AppName))
+		case status.LatestData == nil:
+			stale = append(stale, fmt.Sprintf("\t%s (%s %s): no data in the last day", status.AppName, status.EntityType, status.GUID))
+		case now.Sub(*status.LatestData) > maxAge:
+			stale = append(stale, fmt.Sprintf("\t%s (%s %s): last data %s ago, at %s", status.AppName, status.EntityType, status.GUID, now.Sub(*status.LatestData).Round(time.Second), status.LatestData.Format(time.RFC3339)))
+		default:
+			reporting = append(reporting, fmt.Sprintf("\t%s (%s %s): last data %s ago", status.AppName, status.EntityType, status.GUID, now.Sub(*status.LatestData).Round(time.Second)))
+		}
+	}
+
+	var summary []string
+	if len(stale) > 0 {
+		result.Status = tasks.Warning
+		result.URL = notReportingDocURL
+		summary = append(summary, fmt.Sprintf("No data was received in the last %d minutes though the agent is configured with these app names:", int(maxAge.Minutes())))
+		summary = append(summary, stale...)
+		summary = append(summary, "\tCheck the agent logs for errors sending data, and that the license key is of the account of the -api-key user.")
+		// a standby node, or one whose data is reported by another member, is expected to be quiet
+		if topology.Detected() {
+			result.Status = tasks.Info
+			summary = append(summary, "\tThis host is part of a "+strings.Join(topology.Topologies, ", ")+" setup, see Base/Config/Topology: a standby node, or one whose data another host reports or that reports to another endpoint, may not send data of its own.")
+		}
+	}
+	if len(failed) > 0 {
+		if result.Status != tasks.Warning && result.Status != tasks.Info {
+			result.Status = tasks.Error
+		}
+		summary = append(summary, "Unable to get the latest data of:")
+		summary = append(summary, failed...)
+	}
+	if len(reporting) > 0 {
+		summary = append(summary, fmt.Sprintf("Data was received in the last %d minutes from:", int(maxAge.Minutes())))
+		summary = append(summary, reporting...)
+	}
+	result.Summary = strings.Join(summary, "\n")
+	return result
+}
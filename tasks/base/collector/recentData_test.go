@@ -0,0 +1,190 @@
+package collector
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/config"
+	"github.com/newrelic/newrelic-diagnostics-cli/helpers/httpHelper"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	baseConfig "github.com/newrelic/newrelic-diagnostics-cli/tasks/base/config"
+)
+
+var recentDataNow = time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+
+// mockRecentDataNerdGraph - answers the entity search with the entities and the NRQL query with the latest timestamp
+// of the queried entity, null when it has none
+func mockRecentDataNerdGraph(t *testing.T, entities string, latest map[string]string) requestFunc {
+	return func(wrapper httpHelper.RequestWrapper) (*http.Response, error) {
+		var request struct {
+			Query     string
+			Variables map[string]interface{}
+		}
+		body, _ := ioutil.ReadAll(wrapper.Payload)
+		if err := json.Unmarshal(body, &request); err != nil {
+			t.Fatalf("invalid NerdGraph request %s: %v", body, err)
+		}
+
+		response := `{"data":{"actor":{"entitySearch":{"results":{"entities":` + entities + `}}}}}`
+		if nrql, ok := request.Variables["nrql"].(string); ok {
+			timestamp := "null"
+			for guid, value := range latest {
+				if strings.Contains(nrql, guid) {
+					timestamp = value
+				}
+			}
+			response = `{"data":{"actor":{"account":{"nrql":{"results":[{"latest.timestamp":` + timestamp + `}]}}}}}`
+		}
+		return &http.Response{
+			StatusCode: 200,
+			Body:       ioutil.NopCloser(bytes.NewReader([]byte(response))),
+		}, nil
+	}
+}
+
+func TestBaseCollectorRecentData_Execute(t *testing.T) {
+	connected := tasks.Result{Status: tasks.Success}
+	appNames := tasks.Result{Status: tasks.Success, Payload: []baseConfig.AppNameInfo{
+		{Name: "checkout", FilePath: "/app/newrelic.yml"},
+		{Name: "checkout", FilePath: "/app/newrelic.js"},
+	}}
+	checkoutEntity := `[{"guid":"GUID1","name":"checkout","accountId":1234,"entityType":"APM_APPLICATION_ENTITY"},{"guid":"GUID2","name":"checkout-worker","accountId":1234,"entityType":"APM_APPLICATION_ENTITY"}]`
+	fiveMinutesAgo := "1704189300000"
+	twoHoursAgo := "1704182400000"
+
+	tests := []struct {
+		name        string
+		options     map[string]string
+		upstream    map[string]tasks.Result
+		httpGetter  requestFunc
+		want        tasks.Status
+		wantSummary string
+	}{
+		{
+			name:     "should return None when the key was not accepted",
+			upstream: map[string]tasks.Result{"Base/Collector/ConnectNerdGraph": {Status: tasks.None}, "Base/Config/AppName": appNames},
+			want:     tasks.None,
+		},
+		{
+			name:     "should return None without app name",
+			upstream: map[string]tasks.Result{"Base/Collector/ConnectNerdGraph": connected, "Base/Config/AppName": {Status: tasks.Warning}},
+			want:     tasks.None,
+		},
+		{
+			name:        "should return Success when the entity sent data recently",
+			upstream:    map[string]tasks.Result{"Base/Collector/ConnectNerdGraph": connected, "Base/Config/AppName": appNames},
+			httpGetter:  mockRecentDataNerdGraph(t, checkoutEntity, map[string]string{"GUID1": fiveMinutesAgo}),
+			want:        tasks.Success,
+			wantSummary: "checkout (APM_APPLICATION_ENTITY GUID1): last data 5m0s ago",
+		},
+		{
+			name:        "should return a Warning when the entity's data is older than the minutes override",
+			options:     map[string]string{"minutes": "60"},
+			upstream:    map[string]tasks.Result{"Base/Collector/ConnectNerdGraph": connected, "Base/Config/AppName": appNames},
+			httpGetter:  mockRecentDataNerdGraph(t, checkoutEntity, map[string]string{"GUID1": twoHoursAgo}),
+			want:        tasks.Warning,
+			wantSummary: "last data 2h0m0s ago",
+		},
+		{
+			name:        "should return a Warning when the entity sent no data in the last day",
+			upstream:    map[string]tasks.Result{"Base/Collector/ConnectNerdGraph": connected, "Base/Config/AppName": appNames},
+			httpGetter:  mockRecentDataNerdGraph(t, checkoutEntity, nil),
+			want:        tasks.Warning,
+			wantSummary: "no data in the last day",
+		},
+		{
+			name: "should return Info when the entity of a clustered host sent no data",
+			upstream: map[string]tasks.Result{"Base/Collector/ConnectNerdGraph": connected, "Base/Config/AppName": appNames, "Base/Config/Topology": {
+				Status:  tasks.Info,
+				Payload: baseConfig.AgentTopology{Topologies: []string{baseConfig.TopologyCluster}, ClusterNames: []string{"production"}},
+			}},
+			httpGetter:  mockRecentDataNerdGraph(t, checkoutEntity, nil),
+			want:        tasks.Info,
+			wantSummary: "This host is part of a cluster setup",
+		},
+		{
+			name:        "should return a Warning when no entity has the appName override",
+			options:     map[string]string{"appName": "billing"},
+			upstream:    map[string]tasks.Result{"Base/Collector/ConnectNerdGraph": connected},
+			httpGetter:  mockRecentDataNerdGraph(t, `[]`, nil),
+			want:        tasks.Warning,
+			wantSummary: "billing: no entity with this name",
+		},
+		{
+			name:        "should return an Error when NerdGraph can't be queried",
+			upstream:    map[string]tasks.Result{"Base/Collector/ConnectNerdGraph": connected, "Base/Config/AppName": appNames},
+			httpGetter:  mockUnsuccessfulRequestError,
+			want:        tasks.Error,
+			wantSummary: "Unable to get the latest data of:",
+		},
+		{
+			name:     "should return an Error for an invalid minutes override",
+			options:  map[string]string{"minutes": "soon"},
+			upstream: map[string]tasks.Result{"Base/Collector/ConnectNerdGraph": connected, "Base/Config/AppName": appNames},
+			want:     tasks.Error,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config.Flags.APIKey = testUserAPIKey
+			defer func() { config.Flags.APIKey = "" }()
+
+			p := BaseCollectorRecentData{httpGetter: tt.httpGetter, now: func() time.Time { return recentDataNow }}
+			got := p.Execute(tasks.Options{Options: tt.options}, tt.upstream)
+			if got.Status != tt.want {
+				t.Errorf("BaseCollectorRecentData.Execute() = %v, want %v: %s", got.Status, tt.want, got.Summary)
+			}
+			if !strings.Contains(got.Summary, tt.wantSummary) {
+				t.Errorf("BaseCollectorRecentData.Execute() summary = %q, want it to contain %q", got.Summary, tt.wantSummary)
+			}
+		})
+	}
+}
+
+func TestBaseCollectorRecentData_queriesEntity(t *testing.T) {
+	config.Flags.APIKey = testUserAPIKey
+	config.Flags.Region = "eu"
+	defer func() {
+		config.Flags.APIKey = ""
+		config.Flags.Region = ""
+	}()
+
+	var requestedURLs []string
+	var requests []string
+	mock := mockRecentDataNerdGraph(t, `[{"guid":"GUID1","name":"it's","accountId":1234,"entityType":"APM_APPLICATION_ENTITY"}]`, map[string]string{"GUID1": "1704189300000"})
+	p := BaseCollectorRecentData{
+		httpGetter: func(wrapper httpHelper.RequestWrapper) (*http.Response, error) {
+			body, _ := ioutil.ReadAll(wrapper.Payload)
+			requestedURLs = append(requestedURLs, wrapper.URL)
+			requests = append(requests, string(body))
+			wrapper.Payload = bytes.NewReader(body)
+			return mock(wrapper)
+		},
+		now: func() time.Time { return recentDataNow },
+	}
+	got := p.Execute(tasks.Options{Options: map[string]string{"appName": "it's"}}, map[string]tasks.Result{"Base/Collector/ConnectNerdGraph": {Status: tasks.Success}})
+
+	if got.Status != tasks.Success || len(requests) != 2 {
+		t.Fatalf("BaseCollectorRecentData.Execute() = %v after %d queries, want Success after 2: %s", got.Status, len(requests), got.Summary)
+	}
+	for _, url := range requestedURLs {
+		if url != "https://api.eu.newrelic.com/graphql" {
+			t.Errorf("BaseCollectorRecentData.Execute() queried %s, want the EU endpoint", url)
+		}
+	}
+	if !strings.Contains(requests[0], `"query":"name = 'it\\'s'"`) {
+		t.Errorf("entity search = %s, want the quote of the app name escaped", requests[0])
+	}
+	if !strings.Contains(requests[1], `"accountId":1234`) || !strings.Contains(requests[1], "entityGuid = 'GUID1'") {
+		t.Errorf("latest data query = %s, want the account and GUID of the entity", requests[1])
+	}
+	statuses := got.Payload.([]EntityDataStatus)
+	if statuses[0].LatestData == nil || !statuses[0].LatestData.Equal(recentDataNow.Add(-5*time.Minute)) {
+		t.Errorf("LatestData = %v, want %v", statuses[0].LatestData, recentDataNow.Add(-5*time.Minute))
+	}
+}
@@ -0,0 +1,255 @@
+package collector
+
+import (
+	"errors"
+	"net"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/config"
+	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+)
+
+// Matches the destination address in the header line of traceroute ("traceroute to host (1.2.3.4), 30 hops max")
+// and tracert ("Tracing route to host [1.2.3.4]")
+var tracerouteDestinationRegex = regexp.MustCompile(`(?i)(?:traceroute|tracing route) to \S+ [(\[]([0-9a-fA-F.:]+)[)\]]`)
+
+// Output fragments printed by traceroute when the TCP probes need raw sockets the current user is not allowed to open
+var traceroutePrivilegeErrors = []string{
+	"operation not permitted",
+	"not enough privileges",
+	"must be root",
+	"permission denied",
+}
+
+// TracerouteHop - a single hop of the network path to a collector host. LatencyMs is the first probe that got a reply
+type TracerouteHop struct {
+	Hop       int
+	Address   string  `json:",omitempty"`
+	LatencyMs float64 `json:",omitempty"`
+	TimedOut  bool
+}
+
+// TraceroutePayload - network path to a collector host
+type TraceroutePayload struct {
+	Host        string
+	Destination string `json:",omitempty"`
+	Reached     bool
+	Hops        []TracerouteHop
+}
+
+// traceroutePayloadVersion - the PayloadVersion of the results, bump it when TraceroutePayload or TracerouteHop changes shape
+const traceroutePayloadVersion = 1
+
+// BaseCollectorTraceroute - This task traces the network path to the collector host of the detected regions
+type BaseCollectorTraceroute struct {
+	upstream    map[string]tasks.Result
+	cmdExec     tasks.CmdExecFunc
+	runtimeGOOS string
+}
+
+// Identifier - This returns the Category, Subcategory and Name of each task
+func (p BaseCollectorTraceroute) Identifier() tasks.Identifier {
+	return tasks.IdentifierFromString("Base/Collector/Traceroute")
+}
+
+// Explain - Returns the help text for each individual task
+func (p BaseCollectorTraceroute) Explain() string {
+	return "Trace the network path to the New Relic collector endpoint (only runs when provided with -t)"
+}
+
+// Dependencies - This task depends on Base/Config/RegionDetect
+func (p BaseCollectorTraceroute) Dependencies() []string {
+	return []string{
+		"Base/Config/RegionDetect",
+	}
+}
+
+// RequiresNetwork - This task traces the route to the collector, it is skipped with -offline
+func (p BaseCollectorTraceroute) RequiresNetwork() {}
+
+// RequiresElevation - The TCP probes of traceroute on Linux need raw sockets, so root. tracert on Windows and the
+// setuid traceroute of macOS do not
+func (p BaseCollectorTraceroute) RequiresElevation(options tasks.Options) bool {
+	// when not requested with -t the task is skipped anyway
+	if !options.IsForced() {
+		return false
+	}
+	return p.runtimeGOOS != "windows" && p.runtimeGOOS != "darwin"
+}
+
+// Execute - Runs the system traceroute command against the collector host of each detected region
+func (p BaseCollectorTraceroute) Execute(op tasks.Options, upstream map[string]tasks.Result) tasks.Result {
+	p.upstream = upstream
+
+	// A traceroute can take minutes and usually requires elevated privileges, so it is opt-in
+	if !op.IsForced() {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "This task only runs when requested with -t " + p.Identifier().String(),
+		}
+	}
+
+	var payloads []TraceroutePayload
+	for _, region := range p.getRegions() {
+		host := region.collectorHost()
+		output, err := p.runTraceroute(host)
+		if err != nil {
+			return p.prepareCommandErrorResult(host, output, err)
+		}
+		payloads = append(payloads, p.parseOutput(host, string(output)))
+	}
+
+	return p.prepareResult(payloads)
+}
+
+// getRegions - returns the collector regions matching the detected regions, defaulting to US
+func (p BaseCollectorTraceroute) getRegions() []collectorRegion {
+	detected, _ := p.upstream["Base/Config/RegionDetect"].Payload.([]string)
+	var regions []collectorRegion
+	for _, region := range collectorRegions {
+		if tasks.StringInSlice(region.key, detected) {
+			regions = append(regions, region)
+		}
+	}
+	if len(regions) == 0 || config.Flags.CollectorHost != "" {
+		// with -collector-host every region shares the same host, there is no point tracing it more than once
+		return []collectorRegion{usRegion}
+	}
+	return regions
+}
+
+func (p BaseCollectorTraceroute) runTraceroute(host string) ([]byte, error) {
+	hostname := host
+	// the -collector-host override may include a port
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		hostname = h
+	}
+	switch p.runtimeGOOS {
+	case "windows":
+		// tracert only sends ICMP probes, which do not require an elevated prompt
+		return p.cmdExec("tracert", "-d", "-h", "30", "-w", "2000", hostname)
+	case "darwin":
+		return p.cmdExec("traceroute", "-n", "-q", "1", "-w", "2", "-m", "30", "-P", "tcp", "-p", "443", hostname)
+	default:
+		// TCP probes to 443 follow the same path as agent traffic, unlike the default UDP probes
+		return p.cmdExec("traceroute", "-n", "-q", "1", "-w", "2", "-m", "30", "-T", "-p", "443", hostname)
+	}
+}
+
+func (p BaseCollectorTraceroute) prepareCommandErrorResult(host string, output []byte, err error) tasks.Result {
+	log.Debug("Error running traceroute to", host, ":", err, string(output))
+
+	if errors.Is(err, exec.ErrNotFound) {
+		return tasks.Result{
+			Status:  tasks.Info,
+			Summary: "Unable to trace the network path to " + host + ": no traceroute command was found on this host." + p.installHint(),
+		}
+	}
+
+	lowerOutput := strings.ToLower(string(output))
+	for _, privilegeError := range traceroutePrivilegeErrors {
+		if strings.Contains(lowerOutput, privilegeError) {
+			return tasks.Result{
+				Status:  tasks.Info,
+				Summary: "Unable to trace the network path to " + host + ": traceroute needs permission to open raw sockets. Re-run nrdiag as root (or as Administrator on Windows) to include this check.",
+			}
+		}
+	}
+
+	return tasks.Result{
+		Status:  tasks.Info,
+		Summary: "Unable to trace the network path to " + host + ".\nError = " + err.Error() + "\nOutput = " + strings.TrimSpace(string(output)),
+	}
+}
+
+func (p BaseCollectorTraceroute) installHint() string {
+	if p.runtimeGOOS == "windows" || p.runtimeGOOS == "darwin" {
+		return ""
+	}
+	return " It is usually provided by the traceroute package of your distribution."
+}
+
+// parseOutput - parses the output of traceroute or tracert into hops
+func (p BaseCollectorTraceroute) parseOutput(host, output string) TraceroutePayload {
+	payload := TraceroutePayload{Host: host}
+
+	for _, line := range strings.Split(output, "\n") {
+		if matches := tracerouteDestinationRegex.FindStringSubmatch(line); matches != nil {
+			payload.Destination = matches[1]
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		hopNumber, err := strconv.Atoi(fields[0])
+		if err != nil {
+			continue
+		}
+		payload.Hops = append(payload.Hops, parseHop(hopNumber, fields[1:], p.runtimeGOOS == "windows"))
+	}
+
+	if len(payload.Hops) > 0 && payload.Destination != "" {
+		payload.Reached = payload.Hops[len(payload.Hops)-1].Address == payload.Destination
+	}
+	return payload
+}
+
+// parseHop - parses the fields following the hop number. traceroute prints the address before the latency ("10.0.0.1  0.512 ms")
+// while tracert prints it last ("<1 ms  <1 ms  <1 ms  10.0.0.1")
+func parseHop(hopNumber int, fields []string, addressLast bool) TracerouteHop {
+	hop := TracerouteHop{Hop: hopNumber}
+
+	for i, field := range fields {
+		if field != "ms" || i == 0 || hop.LatencyMs != 0 {
+			continue
+		}
+		latency, err := strconv.ParseFloat(strings.TrimPrefix(fields[i-1], "<"), 64)
+		if err == nil {
+			hop.LatencyMs = latency
+		}
+	}
+
+	var address string
+	if addressLast {
+		address = fields[len(fields)-1]
+	} else {
+		address = fields[0]
+	}
+	if hop.LatencyMs == 0 || address == "*" {
+		hop.TimedOut = true
+		return hop
+	}
+	hop.Address = address
+	return hop
+}
+
+func (p BaseCollectorTraceroute) prepareResult(payloads []TraceroutePayload) tasks.Result {
+	result := tasks.Result{
+		Status:         tasks.Success,
+		Payload:        payloads,
+		PayloadVersion: traceroutePayloadVersion,
+	}
+
+	for _, payload := range payloads {
+		result.Summary += "Network path to " + payload.Host + ":\n"
+		for _, hop := range payload.Hops {
+			if hop.TimedOut {
+				result.Summary += strconv.Itoa(hop.Hop) + "  *\n"
+				continue
+			}
+			result.Summary += strconv.Itoa(hop.Hop) + "  " + hop.Address + "  " + strconv.FormatFloat(hop.LatencyMs, 'f', -1, 64) + "ms\n"
+		}
+		if !payload.Reached {
+			result.Status = tasks.Warning
+			result.Summary += "The trace did not reach " + payload.Host + ". The last responding hop may be where traffic is being blocked.\n"
+			result.URL = networksDocURL
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,184 @@
+package collector
+
+import (
+	"errors"
+	"os/exec"
+	"reflect"
+	"testing"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+)
+
+var tracerouteLinuxOutput = `traceroute to collector.newrelic.com (162.247.241.2), 30 hops max, 60 byte packets
+ 1  10.0.0.1  0.512 ms
+ 2  *
+ 3  162.247.241.2  12.345 ms
+`
+
+var tracertWindowsOutput = `
+Tracing route to collector.newrelic.com [162.247.241.2]
+over a maximum of 30 hops:
+
+  1    <1 ms    <1 ms    <1 ms  10.0.0.1
+  2     *        *        *     Request timed out.
+  3    12 ms    11 ms    12 ms  162.247.241.2
+
+Trace complete.
+`
+
+func TestBaseCollectorTraceroute_parseOutput(t *testing.T) {
+	tests := []struct {
+		name        string
+		runtimeGOOS string
+		output      string
+	}{
+		{name: "traceroute output", runtimeGOOS: "linux", output: tracerouteLinuxOutput},
+		{name: "tracert output", runtimeGOOS: "windows", output: tracertWindowsOutput},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := BaseCollectorTraceroute{runtimeGOOS: tt.runtimeGOOS}
+			got := p.parseOutput("collector.newrelic.com", tt.output)
+
+			if got.Destination != "162.247.241.2" || !got.Reached {
+				t.Errorf("parseOutput() Destination = %v, Reached = %v, want 162.247.241.2 reached", got.Destination, got.Reached)
+			}
+			if len(got.Hops) != 3 {
+				t.Fatalf("parseOutput() Hops = %v, want 3 hops", got.Hops)
+			}
+			if got.Hops[0].Address != "10.0.0.1" || got.Hops[0].LatencyMs == 0 {
+				t.Errorf("parseOutput() first hop = %+v", got.Hops[0])
+			}
+			if !got.Hops[1].TimedOut {
+				t.Errorf("parseOutput() second hop = %+v, want timed out", got.Hops[1])
+			}
+		})
+	}
+}
+
+func TestBaseCollectorTraceroute_Execute(t *testing.T) {
+	tests := []struct {
+		name    string
+		forced  bool
+		cmdExec tasks.CmdExecFunc
+		want    tasks.Status
+	}{
+		{
+			name:   "should not run unless provided with -t",
+			forced: false,
+			cmdExec: func(name string, arg ...string) ([]byte, error) {
+				t.Error("traceroute should not have been run")
+				return nil, nil
+			},
+			want: tasks.None,
+		},
+		{
+			name:   "should return a Success result when the collector is reached",
+			forced: true,
+			cmdExec: func(name string, arg ...string) ([]byte, error) {
+				return []byte(tracerouteLinuxOutput), nil
+			},
+			want: tasks.Success,
+		},
+		{
+			name:   "should return a Warning result when the collector is not reached",
+			forced: true,
+			cmdExec: func(name string, arg ...string) ([]byte, error) {
+				return []byte("traceroute to collector.newrelic.com (162.247.241.2), 30 hops max\n 1  10.0.0.1  0.512 ms\n 2  *\n"), nil
+			},
+			want: tasks.Warning,
+		},
+		{
+			name:   "should return an Info result when raw sockets are not permitted",
+			forced: true,
+			cmdExec: func(name string, arg ...string) ([]byte, error) {
+				return []byte("You do not have enough privileges to use this traceroute method.\nsocket: Operation not permitted"), errors.New("exit status 1")
+			},
+			want: tasks.Info,
+		},
+		{
+			name:   "should return an Info result when traceroute is not installed",
+			forced: true,
+			cmdExec: func(name string, arg ...string) ([]byte, error) {
+				return nil, &exec.Error{Name: name, Err: exec.ErrNotFound}
+			},
+			want: tasks.Info,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := tasks.Options{Options: map[string]string{}}
+			if tt.forced {
+				options.Options[tasks.ForcedOption] = "true"
+			}
+
+			p := BaseCollectorTraceroute{
+				cmdExec:     tt.cmdExec,
+				runtimeGOOS: "linux",
+			}
+			got := p.Execute(options, map[string]tasks.Result{})
+			if got.Status != tt.want {
+				t.Errorf("Execute() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBaseCollectorTraceroute_runTraceroute(t *testing.T) {
+	tests := []struct {
+		runtimeGOOS string
+		wantName    string
+	}{
+		{runtimeGOOS: "linux", wantName: "traceroute"},
+		{runtimeGOOS: "darwin", wantName: "traceroute"},
+		{runtimeGOOS: "windows", wantName: "tracert"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.runtimeGOOS, func(t *testing.T) {
+			var gotName string
+			var gotArgs []string
+			p := BaseCollectorTraceroute{
+				runtimeGOOS: tt.runtimeGOOS,
+				cmdExec: func(name string, arg ...string) ([]byte, error) {
+					gotName = name
+					gotArgs = arg
+					return nil, nil
+				},
+			}
+			p.runTraceroute("relay.example.com:8443")
+			if gotName != tt.wantName {
+				t.Errorf("runTraceroute() ran %v, want %v", gotName, tt.wantName)
+			}
+			if !reflect.DeepEqual(gotArgs[len(gotArgs)-1:], []string{"relay.example.com"}) {
+				t.Errorf("runTraceroute() args = %v, want the host without port last", gotArgs)
+			}
+		})
+	}
+}
+
+func TestBaseCollectorTraceroute_RequiresElevation(t *testing.T) {
+	tests := []struct {
+		name        string
+		forced      bool
+		runtimeGOOS string
+		want        bool
+	}{
+		{name: "not requested", forced: false, runtimeGOOS: "linux", want: false},
+		{name: "requested on linux", forced: true, runtimeGOOS: "linux", want: true},
+		{name: "requested on darwin", forced: true, runtimeGOOS: "darwin", want: false},
+		{name: "requested on windows", forced: true, runtimeGOOS: "windows", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := tasks.Options{Options: map[string]string{}}
+			if tt.forced {
+				options.Options[tasks.ForcedOption] = "true"
+			}
+
+			p := BaseCollectorTraceroute{runtimeGOOS: tt.runtimeGOOS}
+			if got := p.RequiresElevation(options); got != tt.want {
+				t.Errorf("RequiresElevation() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,146 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+)
+
+// AppNameGroup - an application name and every agent configured with it: config files, Java processes setting
+// -Dnewrelic.config.app_name and NEW_RELIC_APP_NAME
+type AppNameGroup struct {
+	Name    string
+	Sources []string
+}
+
+// BaseConfigAppNameCollision - This task checks whether different services on the host report under the same application name
+type BaseConfigAppNameCollision struct {
+}
+
+// Identifier - This returns the Category, Subcategory and Name of each task
+func (t BaseConfigAppNameCollision) Identifier() tasks.Identifier {
+	return tasks.IdentifierFromString("Base/Config/AppNameCollision")
+}
+
+// Explain - Returns the help text for each individual task
+func (t BaseConfigAppNameCollision) Explain() string {
+	return "Check for New Relic agents on this host sharing the same application name"
+}
+
+// Dependencies - Returns the dependencies for each task.
+func (t BaseConfigAppNameCollision) Dependencies() []string {
+	return []string{
+		"Base/Config/Validate",
+		"Base/Env/CollectEnvVars",
+		"Base/Env/CollectSysProps",
+	}
+}
+
+// Execute - The core work within each task
+func (t BaseConfigAppNameCollision) Execute(options tasks.Options, upstream map[string]tasks.Result) tasks.Result {
+	var configElements []ValidateElement
+	if upstream["Base/Config/Validate"].HasPayload() {
+		var ok bool
+		configElements, ok = upstream["Base/Config/Validate"].Payload.([]ValidateElement)
+		if !ok {
+			return tasks.Result{
+				Status:  tasks.Error,
+				Summary: tasks.AssertionErrorSummary,
+			}
+		}
+	}
+
+	groups := groupAppNames(appNameSources(configElements, upstream))
+	if len(groups) == 0 {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "No New Relic app names were found in the agent config files, system properties or NEW_RELIC_APP_NAME.",
+		}
+	}
+
+	var collisions []string
+	for _, group := range groups {
+		if len(group.Sources) > 1 {
+			collisions = append(collisions, fmt.Sprintf("\n\t\"%s\" is set in %s", group.Name, strings.Join(group.Sources, ", ")))
+		}
+	}
+	if len(collisions) > 0 {
+		return tasks.Result{
+			Status:  tasks.Warning,
+			Summary: fmt.Sprintf("Several agents on this host report under the same application name:%s\nThe data of services sharing an application name is blended together in New Relic, and one of them may seem to be missing. Give each service a unique application name, unless these are the same service configured in more than one place.", strings.Join(collisions, "")),
+			URL:     "https://docs.newrelic.com/docs/agents/manage-apm-agents/app-naming/name-your-application",
+			Payload: groups,
+		}
+	}
+
+	names := []string{}
+	for _, group := range groups {
+		names = append(names, group.Name)
+	}
+	return tasks.Result{
+		Status:  tasks.Info,
+		Summary: fmt.Sprintf("%d application name(s) found, each used by a single agent: %s", len(names), strings.Join(names, ", ")),
+		Payload: groups,
+	}
+}
+
+// appNameSource - the primary application name set in one place
+type appNameSource struct {
+	name   string
+	source string
+}
+
+// appNameSources - the primary application name of each config file and Java process, and NEW_RELIC_APP_NAME. The
+// environment variable of this shell is a single source, however many agents are started from it
+func appNameSources(configElements []ValidateElement, upstream map[string]tasks.Result) []appNameSource {
+	var sources []appNameSource
+	seenFiles := map[string]bool{}
+	for _, appNameInfo := range getAppNamesFromConfig(configElements) {
+		// a file listing several names reports under the first one
+		if seenFiles[appNameInfo.FilePath] {
+			continue
+		}
+		seenFiles[appNameInfo.FilePath] = true
+		sources = append(sources, appNameSource{name: primaryAppName(appNameInfo.Name), source: appNameInfo.FilePath})
+	}
+
+	if sysProps, ok := upstream["Base/Env/CollectSysProps"].Payload.([]tasks.ProcIDSysProps); ok {
+		for _, procSysProps := range sysProps {
+			if appName, isPresent := procSysProps.SysPropsKeyToVal[appNameSysProp]; isPresent {
+				sources = append(sources, appNameSource{name: primaryAppName(appName), source: fmt.Sprintf("%s of process %d", appNameSysProp, procSysProps.ProcID)})
+			}
+		}
+	}
+
+	if appNameInfo := getAppNameFromEnvVar(upstream); appNameInfo.Name != "" {
+		sources = append(sources, appNameSource{name: primaryAppName(appNameInfo.Name), source: appNameEnvVarKey})
+	}
+	return sources
+}
+
+// primaryAppName - the first of a semicolon separated list of names. The other names are rollups, shared on purpose
+func primaryAppName(appName string) string {
+	return strings.TrimSpace(strings.Split(appName, ";")[0])
+}
+
+// groupAppNames - the sources of each application name, sorted by name
+func groupAppNames(sources []appNameSource) []AppNameGroup {
+	byName := map[string][]string{}
+	for _, source := range sources {
+		if source.name == "" {
+			continue
+		}
+		byName[source.name] = append(byName[source.name], source.source)
+	}
+
+	groups := []AppNameGroup{}
+	for name, nameSources := range byName {
+		groups = append(groups, AppNameGroup{Name: name, Sources: nameSources})
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		return groups[i].Name < groups[j].Name
+	})
+	return groups
+}
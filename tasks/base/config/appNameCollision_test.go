@@ -0,0 +1,125 @@
+package config
+
+import (
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func appNameConfigElement(filePath string, appName string) ValidateElement {
+	return ValidateElement{
+		Config: ConfigElement{
+			FileName: "newrelic.yml",
+			FilePath: filePath,
+		},
+		ParsedResult: tasks.ValidateBlob{
+			Key:      "app_name",
+			RawValue: appName,
+		},
+	}
+}
+
+var _ = Describe("Base/Config/AppNameCollision", func() {
+	var p BaseConfigAppNameCollision
+
+	Describe("Execute()", func() {
+		var (
+			result   tasks.Result
+			upstream map[string]tasks.Result
+		)
+
+		JustBeforeEach(func() {
+			result = p.Execute(tasks.Options{}, upstream)
+		})
+
+		Context("when no app name is configured", func() {
+			BeforeEach(func() {
+				upstream = map[string]tasks.Result{
+					"Base/Config/Validate":     {Status: tasks.None},
+					"Base/Env/CollectEnvVars":  {Status: tasks.Info, Payload: map[string]string{}},
+					"Base/Env/CollectSysProps": {Status: tasks.None},
+				}
+			})
+			It("should return a None result", func() {
+				Expect(result.Status).To(Equal(tasks.None))
+			})
+		})
+
+		Context("when a single app is configured", func() {
+			BeforeEach(func() {
+				upstream = map[string]tasks.Result{
+					"Base/Config/Validate": {Status: tasks.Success, Payload: []ValidateElement{
+						appNameConfigElement("/app/orders/", "Orders;Storefront"),
+					}},
+				}
+			})
+			It("should return an Info result with the name", func() {
+				Expect(result.Status).To(Equal(tasks.Info))
+				Expect(result.Summary).To(ContainSubstring("Orders"))
+				Expect(result.Payload).To(Equal([]AppNameGroup{{Name: "Orders", Sources: []string{"/app/orders/newrelic.yml"}}}))
+			})
+		})
+
+		Context("when services only share a rollup name", func() {
+			BeforeEach(func() {
+				upstream = map[string]tasks.Result{
+					"Base/Config/Validate": {Status: tasks.Success, Payload: []ValidateElement{
+						appNameConfigElement("/app/orders/", "Orders;Storefront"),
+						appNameConfigElement("/app/billing/", "Billing;Storefront"),
+					}},
+				}
+			})
+			It("should not warn", func() {
+				Expect(result.Status).To(Equal(tasks.Info))
+			})
+		})
+
+		Context("when two services share a name", func() {
+			BeforeEach(func() {
+				upstream = map[string]tasks.Result{
+					"Base/Config/Validate": {Status: tasks.Success, Payload: []ValidateElement{
+						appNameConfigElement("/app/orders/", "Storefront"),
+						appNameConfigElement("/app/billing/", "Billing"),
+					}},
+					"Base/Env/CollectSysProps": {Status: tasks.Info, Payload: []tasks.ProcIDSysProps{
+						{ProcID: 4242, SysPropsKeyToVal: map[string]string{"-Dnewrelic.config.app_name": "Storefront"}},
+					}},
+				}
+			})
+			It("should return a Warning result listing them in the payload", func() {
+				Expect(result.Status).To(Equal(tasks.Warning))
+				Expect(result.Summary).To(ContainSubstring(`"Storefront" is set in /app/orders/newrelic.yml, -Dnewrelic.config.app_name of process 4242`))
+				Expect(result.Payload).To(Equal([]AppNameGroup{
+					{Name: "Billing", Sources: []string{"/app/billing/newrelic.yml"}},
+					{Name: "Storefront", Sources: []string{"/app/orders/newrelic.yml", "-Dnewrelic.config.app_name of process 4242"}},
+				}))
+			})
+		})
+
+		Context("when NEW_RELIC_APP_NAME is set to the name of a config file", func() {
+			BeforeEach(func() {
+				upstream = map[string]tasks.Result{
+					"Base/Config/Validate": {Status: tasks.Success, Payload: []ValidateElement{
+						appNameConfigElement("/app/orders/", "Storefront"),
+					}},
+					"Base/Env/CollectEnvVars": {Status: tasks.Info, Payload: map[string]string{"NEW_RELIC_APP_NAME": "Storefront"}},
+				}
+			})
+			It("should return a Warning result", func() {
+				Expect(result.Status).To(Equal(tasks.Warning))
+				Expect(result.Summary).To(ContainSubstring("NEW_RELIC_APP_NAME"))
+			})
+		})
+
+		Context("when Base/Config/Validate returns an unexpected payload", func() {
+			BeforeEach(func() {
+				upstream = map[string]tasks.Result{
+					"Base/Config/Validate": {Status: tasks.Success, Payload: "unexpected"},
+				}
+			})
+			It("should return an Error result", func() {
+				Expect(result.Status).To(Equal(tasks.Error))
+			})
+		})
+	})
+})
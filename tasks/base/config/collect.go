@@ -4,7 +4,6 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
-	"runtime"
 	"strings"
 
 	"github.com/newrelic/newrelic-diagnostics-cli/config"
@@ -90,6 +89,8 @@ func (p BaseConfigCollect) Dependencies() []string {
 	return []string{
 		"Base/Env/CollectEnvVars",
 		"Base/Env/CollectSysProps",
+		"Base/Env/DetectContainer",
+		"Base/Config/Discovery",
 	}
 }
 
@@ -127,32 +128,12 @@ func (p BaseConfigCollect) Execute(options tasks.Options, upstream map[string]ta
 		}
 	}
 
-	// Search for config file in standard/default expected locations
-
-	var paths []string
-
-	localPath, err := os.Getwd()
-
-	if err != nil {
-		log.Debug("Error reading local working directory")
-	}
-
-	paths = append(paths, localPath)
-
-	if runtime.GOOS == "windows" {
-		sysProgramFiles := envVars["ProgramFiles"]
-		sysProgramData := envVars["ProgramData"]
-		paths = append(paths, sysProgramFiles+`\New Relic`)
-		paths = append(paths, sysProgramData+`\New Relic\`)
-	} else {
-		paths = append(paths, "/etc/")
-		paths = append(paths, "/opt/newrelic/synthetics/.newrelic/synthetics/minion/")
-		paths = append(paths, "/usr/local/newrelic-netcore20-agent/")
-		paths = append(paths, "/usr/local/newrelic-dotnet-agent/") // https://github.com/newrelic/newrelic-diagnostics-cli/issues/114
+	// Search for config file in standard/default expected locations, the ones reported by Base/Config/Discovery
+	discovery, ok := upstream["Base/Config/Discovery"].Payload.(ConfigDiscovery)
+	if !ok {
+		discovery = discoverConfigFiles(configSearchPaths(envVars, upstream["Base/Env/DetectContainer"], options.IncludePaths()))
 	}
-
-	//Find insecure paths
-	foundConfigs := tasks.FindFiles(patterns, paths)
+	paths, foundConfigs := discovery.defaultSearch()
 
 	// These are files to skip for the secure files prompt
 	var skippedSecureConfigs = make(map[string]struct{})
@@ -242,7 +223,7 @@ func (p BaseConfigCollect) Execute(options tasks.Options, upstream map[string]ta
 		}
 		return tasks.Result{
 			Status:  tasks.Failure,
-			Summary: "New Relic configuration files not found where the " + tasks.ThisProgramFullName + " was executed. Please ensure the " + tasks.ThisProgramFullName + " executable is within your application's directory alongside your New Relic agent configuration file(s). If you cannot set New Relic configuration files in your application's directory, move the " + tasks.ThisProgramFullName + " to that directory or use the -c <file_path> to specify the New Relic configuration file location." + warningSummaryCannotCollect,
+			Summary: "New Relic configuration files not found where the " + tasks.ThisProgramFullName + " was executed. Please ensure the " + tasks.ThisProgramFullName + " executable is within your application's directory alongside your New Relic agent configuration file(s). If you cannot set New Relic configuration files in your application's directory, move the " + tasks.ThisProgramFullName + " to that directory or use the -c <file_path> to specify the New Relic configuration file location.\nSearched paths:\n" + discovery.searchedSummary() + warningSummaryCannotCollect,
 		}
 	}
 
@@ -1,6 +1,9 @@
 package config
 
 import (
+	"io/ioutil"
+	"runtime"
+
 	"github.com/newrelic/newrelic-diagnostics-cli/internal/haberdasher"
 	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
 	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
@@ -11,6 +14,7 @@ func RegisterWith(registrationFunc func(tasks.Task, bool)) {
 	log.Debug("Registering Base/Config/*")
 
 	registrationFunc(BaseConfigValidate{}, true)
+	registrationFunc(BaseConfigDiscovery{}, true)
 	registrationFunc(BaseConfigCollect{}, true)
 	registrationFunc(BaseConfigLogLevel{}, false)
 	registrationFunc(BaseConfigProxyDetect{}, true)
@@ -18,8 +22,20 @@ func RegisterWith(registrationFunc func(tasks.Task, bool)) {
 	registrationFunc(BaseConfigValidateLicenseKey{
 		validateAgainstAccount: validateAgainstAccount,
 	}, true)
+	registrationFunc(BaseConfigLicenseKeyValidate{}, true)
 	registrationFunc(BaseConfigAppName{}, true)
+	registrationFunc(BaseConfigAppNameCollision{}, true)
+	registrationFunc(BaseConfigDistributedTracing{}, true)
+	registrationFunc(BaseConfigTopology{}, true)
+	registrationFunc(BaseConfigEnvironment{
+		readFile: ioutil.ReadFile,
+	}, true)
 	registrationFunc(BaseConfigRegionDetect{}, true)
+	registrationFunc(BaseConfigPermissions{
+		goos:          runtime.GOOS,
+		findAgentUser: findAgentUsers,
+		statOwnership: statOwnership,
+	}, true)
 	registrationFunc(BaseConfigValidateHSM{
 		hsmService: haberdasherHSMService,
 	}, true)
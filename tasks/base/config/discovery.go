@@ -0,0 +1,278 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+
+	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+)
+
+// The sources of the searched paths other than the environment variables and system properties, named after them
+const (
+	workingDirSource    = "working directory"
+	defaultPathSource   = "default location"
+	containerHostSource = "host filesystem"
+	configFlagSource    = "-config-file"
+	includePathSource   = "-include-path"
+)
+
+// configFileKind - the agents configured by the files matching some of the patterns of Base/Config/Collect
+type configFileKind struct {
+	agentType string
+	patterns  []string
+}
+
+// configFileKinds cover each of the patterns once. A newrelic.yml or newrelic.ini is only told apart by its keys, see agentTypeRules
+var configFileKinds = []configFileKind{
+	{agentType: "Java, Ruby", patterns: []string{"newrelic[.]yml"}},
+	{agentType: ".NET", patterns: []string{"^(?i)newrelic[.]config$", "newrelic[.]xml"}},
+	{agentType: "Node", patterns: []string{"newrelic[.]js"}},
+	{agentType: "PHP, Python", patterns: []string{"newrelic[.]ini", "newrelic[.]cfg"}},
+	{agentType: "Infrastructure", patterns: []string{"newrelic-infra[.]yml"}},
+	{agentType: "Synthetics private minion", patterns: []string{"private-location-settings[.]json"}},
+	{agentType: "iOS", patterns: []string{"Podfile", "NewRelic[.]h"}},
+	{agentType: "Android", patterns: []string{"proguard-rules[.]pro", "proguard[.]multidex[.]config", "dexguard-release[.]pro", "newrelic[.]properties", "NewRelicConfig[.]java", "gradle-wrapper[.]properties"}},
+}
+
+// ConfigSearchPath - a path searched for config files, where it comes from and the config files found under it
+type ConfigSearchPath struct {
+	Path   string
+	Source string
+	Found  []string
+	Error  string `json:",omitempty"`
+}
+
+// AgentConfigSearch - the config file names of an agent type and the files found with one of them
+type AgentConfigSearch struct {
+	AgentType string
+	FileNames []string
+	Found     []string
+}
+
+// ConfigDiscovery - every path searched for agent config files and what was found, by path and by agent type
+type ConfigDiscovery struct {
+	SearchPaths []ConfigSearchPath
+	Agents      []AgentConfigSearch
+}
+
+// defaultSearch - the searched directories that are not set by an agent environment variable or system property, and the
+// config files found in them. Base/Config/Collect reports those set explicitly itself, as it has to validate them
+func (d ConfigDiscovery) defaultSearch() ([]string, []string) {
+	var paths, found []string
+	for _, searchPath := range d.SearchPaths {
+		switch searchPath.Source {
+		case workingDirSource, defaultPathSource, containerHostSource, includePathSource:
+			paths = append(paths, searchPath.Path)
+			found = append(found, searchPath.Found...)
+		}
+	}
+	return paths, found
+}
+
+// searchedSummary - one line per searched path, with the number of config files found or why it couldn't be searched
+func (d ConfigDiscovery) searchedSummary() string {
+	var lines []string
+	for _, searchPath := range d.SearchPaths {
+		result := fmt.Sprintf("%d found", len(searchPath.Found))
+		if searchPath.Error != "" {
+			result = searchPath.Error
+		}
+		lines = append(lines, fmt.Sprintf("\t%s (%s): %s", searchPath.Path, searchPath.Source, result))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// BaseConfigDiscovery - This task reports the paths searched for agent config files and the files found, by agent type
+type BaseConfigDiscovery struct {
+}
+
+// Identifier - This returns the Category, Subcategory and Name of each task
+func (p BaseConfigDiscovery) Identifier() tasks.Identifier {
+	return tasks.IdentifierFromString("Base/Config/Discovery")
+}
+
+// Explain - Returns the help text for each individual task
+func (p BaseConfigDiscovery) Explain() string {
+	return "Report the paths searched for New Relic configuration files and the files found for each agent type"
+}
+
+// Dependencies - Returns the dependencies for each task.
+func (p BaseConfigDiscovery) Dependencies() []string {
+	return []string{
+		"Base/Env/CollectEnvVars",
+		"Base/Env/CollectSysProps",
+		"Base/Env/DetectContainer",
+	}
+}
+
+// Execute - The core work within each task
+func (p BaseConfigDiscovery) Execute(options tasks.Options, upstream map[string]tasks.Result) tasks.Result {
+	envVars, ok := upstream["Base/Env/CollectEnvVars"].Payload.(map[string]string)
+	if !ok {
+		log.Debug("Could not get envVars from upstream")
+	}
+
+	var searchPaths []ConfigSearchPath
+	if options.Options["configFile"] != "" {
+		configFile, err := filepath.Abs(options.Options["configFile"])
+		if err != nil {
+			configFile = options.Options["configFile"]
+		}
+		searchPaths = []ConfigSearchPath{{Path: configFile, Source: configFlagSource}}
+	} else {
+		searchPaths = append(configSearchPaths(envVars, upstream["Base/Env/DetectContainer"], options.IncludePaths()), explicitConfigPaths(envVars, upstream["Base/Env/CollectSysProps"])...)
+	}
+	discovery := discoverConfigFiles(searchPaths)
+
+	summary := fmt.Sprintf("Searched %d path(s) for New Relic config files:\n", len(searchPaths)) + discovery.searchedSummary()
+	summary += "\nConfig files by agent type:"
+	for _, agent := range discovery.Agents {
+		found := "not found"
+		if len(agent.Found) > 0 {
+			found = strings.Join(agent.Found, ", ")
+		}
+		summary += fmt.Sprintf("\n\t%s (%s): %s", agent.AgentType, strings.Join(agent.FileNames, ", "), found)
+	}
+
+	return tasks.Result{
+		Status:  tasks.Info,
+		Summary: summary,
+		Payload: discovery,
+	}
+}
+
+// discoverConfigFiles - searches each of the paths for config files
+func discoverConfigFiles(searchPaths []ConfigSearchPath) ConfigDiscovery {
+	for i := range searchPaths {
+		searchPaths[i].Found, searchPaths[i].Error = searchConfigPath(searchPaths[i].Path)
+	}
+	return ConfigDiscovery{
+		SearchPaths: searchPaths,
+		Agents:      configFilesByAgentType(searchPaths),
+	}
+}
+
+// configSearchPaths - the working directory and the default install locations of the agents, with the same locations
+// on the mounted host filesystem when running in a container, as the agents installed on the host are only found there,
+// and the -include-path directories
+func configSearchPaths(envVars map[string]string, containerResult tasks.Result, includePaths []string) []ConfigSearchPath {
+	var paths []ConfigSearchPath
+	localPath, err := os.Getwd()
+	if err != nil {
+		log.Debug("Error reading local working directory")
+	} else {
+		paths = append(paths, ConfigSearchPath{Path: localPath, Source: workingDirSource})
+	}
+
+	if runtime.GOOS == "windows" {
+		paths = append(paths,
+			ConfigSearchPath{Path: envVars["ProgramFiles"] + `\New Relic`, Source: defaultPathSource},
+			ConfigSearchPath{Path: envVars["ProgramData"] + `\New Relic\`, Source: defaultPathSource},
+		)
+		return appendIncludePaths(paths, includePaths)
+	}
+	defaultPaths := []string{
+		"/etc/",
+		"/opt/newrelic/synthetics/.newrelic/synthetics/minion/",
+		"/usr/local/newrelic-netcore20-agent/",
+		"/usr/local/newrelic-dotnet-agent/", // https://github.com/newrelic/newrelic-diagnostics-cli/issues/114
+	}
+	for _, path := range defaultPaths {
+		paths = append(paths, ConfigSearchPath{Path: path, Source: defaultPathSource})
+	}
+	if container, ok := containerResult.Payload.(tasks.ContainerEnvironment); ok {
+		for _, path := range container.HostPaths(defaultPaths) {
+			paths = append(paths, ConfigSearchPath{Path: path, Source: containerHostSource})
+		}
+	}
+	return appendIncludePaths(paths, includePaths)
+}
+
+func appendIncludePaths(paths []ConfigSearchPath, includePaths []string) []ConfigSearchPath {
+	for _, path := range includePaths {
+		paths = append(paths, ConfigSearchPath{Path: path, Source: includePathSource})
+	}
+	return paths
+}
+
+// explicitConfigPaths - the config file locations set with the -Dnewrelic.config.file system property of the Java
+// processes and the agents' config environment variables
+func explicitConfigPaths(envVars map[string]string, sysPropsResult tasks.Result) []ConfigSearchPath {
+	var paths []ConfigSearchPath
+	if processes, ok := sysPropsResult.Payload.([]tasks.ProcIDSysProps); ok && sysPropsResult.Status == tasks.Info {
+		for _, process := range processes {
+			if configPath, isPresent := process.SysPropsKeyToVal[configSysProp]; isPresent {
+				paths = append(paths, ConfigSearchPath{Path: configPath, Source: fmt.Sprintf("%s of process %d", configSysProp, process.ProcID)})
+			}
+		}
+	}
+	for _, envVarKey := range configEnvVarKeys {
+		if configPath, isPresent := envVars[envVarKey]; isPresent {
+			paths = append(paths, ConfigSearchPath{Path: configPath, Source: envVarKey})
+		}
+	}
+	return paths
+}
+
+// searchConfigPath - the config files under a directory, sorted, or the file itself when the path is one: a file set
+// explicitly is the agent's config whatever its name
+func searchConfigPath(path string) ([]string, string) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return []string{}, err.Error()
+	}
+	if !info.IsDir() {
+		return []string{path}, ""
+	}
+	found := []string{}
+	for _, file := range tasks.FindFiles(patterns, []string{path}) {
+		if !isConfigFileinPathToIgnore(filepath.Dir(file)) {
+			found = append(found, file)
+		}
+	}
+	sort.Strings(found)
+	return found, ""
+}
+
+// configFilesByAgentType - the files found in every searched path, grouped by the agent type their name is for
+func configFilesByAgentType(searchPaths []ConfigSearchPath) []AgentConfigSearch {
+	agents := make([]AgentConfigSearch, 0, len(configFileKinds))
+	for _, kind := range configFileKinds {
+		agent := AgentConfigSearch{AgentType: kind.agentType, Found: []string{}}
+		var matchers []*regexp.Regexp
+		for _, pattern := range kind.patterns {
+			agent.FileNames = append(agent.FileNames, patternFileName(pattern))
+			matchers = append(matchers, regexp.MustCompile(pattern))
+		}
+		for _, searchPath := range searchPaths {
+			for _, file := range searchPath.Found {
+				if matchesAny(matchers, filepath.Base(file)) && tasks.PosString(agent.Found, file) == -1 {
+					agent.Found = append(agent.Found, file)
+				}
+			}
+		}
+		sort.Strings(agent.Found)
+		agents = append(agents, agent)
+	}
+	return agents
+}
+
+func matchesAny(matchers []*regexp.Regexp, name string) bool {
+	for _, matcher := range matchers {
+		if matcher.MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// patternFileName - the file name a pattern of Base/Config/Collect matches, e.g. newrelic.yml for newrelic[.]yml
+func patternFileName(pattern string) string {
+	return strings.NewReplacer("[.]", ".", "^(?i)", "", "$", "").Replace(pattern)
+}
@@ -0,0 +1,122 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func writeDiscoveryFile(path string) {
+	Expect(os.MkdirAll(filepath.Dir(path), 0755)).To(Succeed())
+	Expect(os.WriteFile(path, []byte("license_key: abc\n"), 0644)).To(Succeed())
+}
+
+var _ = Describe("Base/Config/Discovery", func() {
+	var dir string
+
+	BeforeEach(func() {
+		dir = GinkgoT().TempDir()
+		writeDiscoveryFile(filepath.Join(dir, "app", "newrelic.yml"))
+		writeDiscoveryFile(filepath.Join(dir, "app", "newrelic-infra.yml"))
+		writeDiscoveryFile(filepath.Join(dir, "node_modules", "newrelic", "newrelic.js"))
+		writeDiscoveryFile(filepath.Join(dir, "custom", "agent.yml"))
+	})
+
+	Describe("configFileKinds", func() {
+		It("Should give an agent type to each config file pattern once", func() {
+			var kindPatterns []string
+			for _, kind := range configFileKinds {
+				kindPatterns = append(kindPatterns, kind.patterns...)
+			}
+			Expect(kindPatterns).To(ConsistOf(patterns))
+		})
+	})
+
+	Describe("discoverConfigFiles", func() {
+		It("Should report the files found in each path and by agent type", func() {
+			discovery := discoverConfigFiles([]ConfigSearchPath{
+				{Path: dir, Source: workingDirSource},
+				{Path: filepath.Join(dir, "custom", "agent.yml"), Source: "NEW_RELIC_CONFIG_FILE"},
+				{Path: filepath.Join(dir, "missing"), Source: defaultPathSource},
+			})
+
+			Expect(discovery.SearchPaths[0].Found).To(Equal([]string{
+				filepath.Join(dir, "app", "newrelic-infra.yml"),
+				filepath.Join(dir, "app", "newrelic.yml"),
+			}))
+			Expect(discovery.SearchPaths[1].Found).To(Equal([]string{filepath.Join(dir, "custom", "agent.yml")}))
+			Expect(discovery.SearchPaths[2].Found).To(BeEmpty())
+			Expect(discovery.SearchPaths[2].Error).To(ContainSubstring("no such file or directory"))
+
+			Expect(discovery.Agents).To(HaveLen(len(configFileKinds)))
+			Expect(discovery.Agents[0]).To(Equal(AgentConfigSearch{
+				AgentType: "Java, Ruby",
+				FileNames: []string{"newrelic.yml"},
+				Found:     []string{filepath.Join(dir, "app", "newrelic.yml")},
+			}))
+			Expect(discovery.Agents[1].FileNames).To(Equal([]string{"newrelic.config", "newrelic.xml"}))
+			Expect(discovery.Agents[2].Found).To(BeEmpty())
+			Expect(discovery.Agents[4].Found).To(Equal([]string{filepath.Join(dir, "app", "newrelic-infra.yml")}))
+		})
+
+		It("Should pass the default search of the working directory and default locations on to Base/Config/Collect", func() {
+			discovery := discoverConfigFiles([]ConfigSearchPath{
+				{Path: filepath.Join(dir, "app"), Source: defaultPathSource},
+				{Path: filepath.Join(dir, "custom", "agent.yml"), Source: "NEW_RELIC_CONFIG_FILE"},
+			})
+			paths, found := discovery.defaultSearch()
+			Expect(paths).To(Equal([]string{filepath.Join(dir, "app")}))
+			Expect(found).To(HaveLen(2))
+		})
+	})
+
+	Describe("configSearchPaths", func() {
+		It("Should search the -include-path directories after the default locations", func() {
+			options := tasks.Options{Options: map[string]string{tasks.IncludePathsOption: filepath.Join(dir, "app") + string(os.PathListSeparator) + filepath.Join(dir, "custom")}}
+			paths := configSearchPaths(map[string]string{}, tasks.Result{}, options.IncludePaths())
+			Expect(paths[0].Source).To(Equal(workingDirSource))
+			Expect(paths[len(paths)-2:]).To(Equal([]ConfigSearchPath{
+				{Path: filepath.Join(dir, "app"), Source: includePathSource},
+				{Path: filepath.Join(dir, "custom"), Source: includePathSource},
+			}))
+
+			searched, found := discoverConfigFiles(paths[len(paths)-2:]).defaultSearch()
+			Expect(searched).To(HaveLen(2))
+			Expect(found).To(ConsistOf(filepath.Join(dir, "app", "newrelic.yml"), filepath.Join(dir, "app", "newrelic-infra.yml")))
+		})
+	})
+
+	Describe("explicitConfigPaths", func() {
+		It("Should list the system property and environment variable locations", func() {
+			paths := explicitConfigPaths(
+				map[string]string{"NEW_RELIC_HOME": "/opt/newrelic", "PATH": "/usr/bin"},
+				tasks.Result{Status: tasks.Info, Payload: []tasks.ProcIDSysProps{
+					{ProcID: 42, SysPropsKeyToVal: map[string]string{"-Dnewrelic.config.file": "/srv/newrelic.yml"}},
+				}},
+			)
+			Expect(paths).To(Equal([]ConfigSearchPath{
+				{Path: "/srv/newrelic.yml", Source: "-Dnewrelic.config.file of process 42"},
+				{Path: "/opt/newrelic", Source: "NEW_RELIC_HOME"},
+			}))
+		})
+	})
+
+	Describe("Execute", func() {
+		It("Should only search the -config-file path when it is given", func() {
+			configFile := filepath.Join(dir, "custom", "agent.yml")
+			result := BaseConfigDiscovery{}.Execute(tasks.Options{Options: map[string]string{"configFile": configFile}}, map[string]tasks.Result{})
+
+			Expect(result.Status).To(Equal(tasks.Info))
+			discovery, ok := result.Payload.(ConfigDiscovery)
+			Expect(ok).To(BeTrue())
+			Expect(discovery.SearchPaths).To(Equal([]ConfigSearchPath{
+				{Path: configFile, Source: configFlagSource, Found: []string{configFile}},
+			}))
+			Expect(result.Summary).To(ContainSubstring("Searched 1 path(s)"))
+			Expect(result.Summary).To(ContainSubstring("Node (newrelic.js): not found"))
+		})
+	})
+})
@@ -0,0 +1,209 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+)
+
+// The distributed tracing setting of an agent, once its configured value is read
+const (
+	dtEnabled  = "enabled"
+	dtDisabled = "disabled"
+	// dtDefault - the agent config doesn't set distributed tracing, which current agents enable by default
+	dtDefault = "default"
+	// dtUnknown - the value is not a boolean, e.g. a placeholder substituted when the service is deployed
+	dtUnknown = "unknown"
+)
+
+var distributedTracingEnvVarKey = "NEW_RELIC_DISTRIBUTED_TRACING_ENABLED"
+var distributedTracingSysProp = "-Dnewrelic.config.distributed_tracing.enabled"
+
+// distributedTracingFlatKeys are the settings holding the value themselves
+var distributedTracingFlatKeys = []string{
+	"distributed_tracing.enabled",          // Python, Ruby
+	"newrelic.distributed_tracing_enabled", // PHP
+}
+
+// distributedTracingSections are the settings whose enabled child holds the value
+var distributedTracingSections = []struct {
+	key     string
+	enabled string
+}{
+	{key: "distributed_tracing", enabled: "enabled"}, // Java, Node, Ruby
+	{key: "distributedTracing", enabled: "-enabled"}, // .NET, as an attribute
+}
+
+// DistributedTracingSetting - the distributed tracing setting of an agent, as found in its config file, the system
+// properties of its process or the environment
+type DistributedTracingSetting struct {
+	Source    string
+	AgentType string `json:",omitempty"`
+	Setting   string
+	// Value is the configured value, empty when the setting is the default
+	Value string `json:",omitempty"`
+}
+
+// BaseConfigDistributedTracing - This task checks that the agents on the host agree on distributed tracing
+type BaseConfigDistributedTracing struct {
+}
+
+// Identifier - This returns the Category, Subcategory and Name of each task
+func (t BaseConfigDistributedTracing) Identifier() tasks.Identifier {
+	return tasks.IdentifierFromString("Base/Config/DistributedTracing")
+}
+
+// Explain - Returns the help text for each individual task
+func (t BaseConfigDistributedTracing) Explain() string {
+	return "Check that distributed tracing is enabled or disabled alike for the New Relic agents on this host"
+}
+
+// Dependencies - Returns the dependencies for each task.
+func (t BaseConfigDistributedTracing) Dependencies() []string {
+	return []string{
+		"Base/Config/Validate",
+		"Base/Env/CollectEnvVars",
+		"Base/Env/CollectSysProps",
+	}
+}
+
+// Execute - The core work within each task
+func (t BaseConfigDistributedTracing) Execute(options tasks.Options, upstream map[string]tasks.Result) tasks.Result {
+	var configElements []ValidateElement
+	if upstream["Base/Config/Validate"].HasPayload() {
+		var ok bool
+		configElements, ok = upstream["Base/Config/Validate"].Payload.([]ValidateElement)
+		if !ok {
+			return tasks.Result{
+				Status:  tasks.Error,
+				Summary: tasks.AssertionErrorSummary,
+			}
+		}
+	}
+
+	settings := distributedTracingSettings(configElements, upstream)
+	if len(settings) == 0 {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "No New Relic APM agent config file, system property or " + distributedTracingEnvVarKey + " was found to read the distributed tracing setting from.",
+		}
+	}
+
+	var lines []string
+	var enabled, disabled int
+	for _, setting := range settings {
+		line := fmt.Sprintf("\n\t%s: %s", setting.Source, setting.Setting)
+		if setting.Value != "" {
+			line += fmt.Sprintf(" (%s)", setting.Value)
+		}
+		lines = append(lines, line)
+		switch setting.Setting {
+		case dtEnabled, dtDefault:
+			enabled++
+		case dtDisabled:
+			disabled++
+		}
+	}
+
+	if enabled > 0 && disabled > 0 {
+		return tasks.Result{
+			Status:  tasks.Warning,
+			Summary: fmt.Sprintf("Distributed tracing is enabled for some of the agents on this host and disabled for others:%s\nTraces break at the services that don't take part in distributed tracing, without any error. Enable it for every service the requests go through, unless they are disabled on purpose. A default setting is enabled in current agents.", strings.Join(lines, "")),
+			URL:     "https://docs.newrelic.com/docs/distributed-tracing/enable-configure/language-agents-enable-distributed-tracing/",
+			Payload: settings,
+		}
+	}
+	return tasks.Result{
+		Status:  tasks.Info,
+		Summary: fmt.Sprintf("The distributed tracing setting of %d source(s) is consistent:%s", len(settings), strings.Join(lines, "")),
+		Payload: settings,
+	}
+}
+
+// distributedTracingSettings - the setting of each APM agent config file and Java process, and of the environment. A
+// config file of unknown agent type is only listed when it sets distributed tracing, the infrastructure agent has no
+// such setting
+func distributedTracingSettings(configElements []ValidateElement, upstream map[string]tasks.Result) []DistributedTracingSetting {
+	var settings []DistributedTracingSetting
+	for _, configElement := range configElements {
+		if configElement.Status != tasks.Success || configElement.AgentType == "Infrastructure" {
+			continue
+		}
+		value, found := findDistributedTracingValue(configElement.ParsedResult)
+		if !found && configElement.AgentType == "" {
+			continue
+		}
+		setting := DistributedTracingSetting{
+			Source:    configElement.Config.FilePath + configElement.Config.FileName,
+			AgentType: configElement.AgentType,
+			Setting:   dtDefault,
+		}
+		if found {
+			setting.Setting = distributedTracingState(value)
+			setting.Value = value
+		}
+		settings = append(settings, setting)
+	}
+
+	if sysProps, ok := upstream["Base/Env/CollectSysProps"].Payload.([]tasks.ProcIDSysProps); ok {
+		for _, procSysProps := range sysProps {
+			if value, isPresent := procSysProps.SysPropsKeyToVal[distributedTracingSysProp]; isPresent {
+				settings = append(settings, DistributedTracingSetting{
+					Source:    fmt.Sprintf("%s of process %d", distributedTracingSysProp, procSysProps.ProcID),
+					AgentType: "Java",
+					Setting:   distributedTracingState(value),
+					Value:     value,
+				})
+			}
+		}
+	}
+
+	if envVars, ok := upstream["Base/Env/CollectEnvVars"].Payload.(map[string]string); ok {
+		if value, isPresent := envVars[distributedTracingEnvVarKey]; isPresent {
+			settings = append(settings, DistributedTracingSetting{
+				Source:  distributedTracingEnvVarKey,
+				Setting: distributedTracingState(value),
+				Value:   value,
+			})
+		}
+	}
+	return settings
+}
+
+// findDistributedTracingValue - the distributed tracing value of a config file. When several environments of a file
+// set it, the common one applies to all of them
+func findDistributedTracingValue(parsedConfig tasks.ValidateBlob) (string, bool) {
+	var found []tasks.ValidateBlob
+	for _, key := range distributedTracingFlatKeys {
+		found = append(found, parsedConfig.FindKey(key)...)
+	}
+	for _, section := range distributedTracingSections {
+		for _, sectionBlob := range parsedConfig.FindKey(section.key) {
+			for _, child := range sectionBlob.Children {
+				if child.Key == section.enabled {
+					found = append(found, child)
+				}
+			}
+		}
+	}
+	if len(found) == 0 {
+		return "", false
+	}
+	for _, blob := range found {
+		if strings.Contains(blob.Path, "common") {
+			return strings.TrimSpace(blob.Value()), true
+		}
+	}
+	return strings.TrimSpace(found[0].Value()), true
+}
+
+func distributedTracingState(value string) string {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "true", "1", "on", "yes":
+		return dtEnabled
+	case "false", "0", "off", "no":
+		return dtDisabled
+	}
+	return dtUnknown
+}
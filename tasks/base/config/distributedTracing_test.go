@@ -0,0 +1,147 @@
+package config
+
+import (
+	"strings"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// dtConfigElement - a config file parsed as Base/Config/Validate does
+func dtConfigElement(filePath string, fileName string, content string) ValidateElement {
+	var parsed tasks.ValidateBlob
+	var err error
+	switch {
+	case strings.HasSuffix(fileName, ".yml"):
+		parsed, err = ParseYaml(strings.NewReader(content))
+	case strings.HasSuffix(fileName, ".config"):
+		parsed, err = parseXML(strings.NewReader(content))
+	default:
+		parsed, err = parseIni(strings.NewReader(content))
+	}
+	Expect(err).To(BeNil())
+	return ValidateElement{
+		Config:       ConfigElement{FileName: fileName, FilePath: filePath},
+		Status:       tasks.Success,
+		ParsedResult: parsed,
+		AgentType:    detectAgentType(fileName[strings.LastIndex(fileName, "."):], parsed),
+	}
+}
+
+const dtJavaConfig = `common: &default_settings
+  license_key: '<%= license_key %>'
+  app_name: Orders
+  enable_auto_app_naming: false
+  distributed_tracing:
+    enabled: true
+production:
+  <<: *default_settings
+`
+
+const dtDotNetConfig = `<?xml version="1.0"?>
+<configuration xmlns="urn:newrelic-config" agentEnabled="true">
+  <service licenseKey="REPLACE_WITH_LICENSE_KEY"/>
+  <application><name>Billing</name></application>
+  <distributedTracing enabled="false"/>
+</configuration>`
+
+const dtPythonConfig = `[newrelic]
+license_key = REPLACE_WITH_LICENSE_KEY
+app_name = Search
+monitor_mode = true
+`
+
+var _ = Describe("Base/Config/DistributedTracing", func() {
+	var p BaseConfigDistributedTracing
+
+	Describe("Execute()", func() {
+		var (
+			result   tasks.Result
+			upstream map[string]tasks.Result
+		)
+
+		JustBeforeEach(func() {
+			result = p.Execute(tasks.Options{}, upstream)
+		})
+
+		Context("when no agent is configured", func() {
+			BeforeEach(func() {
+				upstream = map[string]tasks.Result{
+					"Base/Config/Validate":     {Status: tasks.None},
+					"Base/Env/CollectEnvVars":  {Status: tasks.Info, Payload: map[string]string{}},
+					"Base/Env/CollectSysProps": {Status: tasks.None},
+				}
+			})
+			It("should return a None result", func() {
+				Expect(result.Status).To(Equal(tasks.None))
+			})
+		})
+
+		Context("when the agents agree", func() {
+			BeforeEach(func() {
+				upstream = map[string]tasks.Result{
+					"Base/Config/Validate": {Status: tasks.Success, Payload: []ValidateElement{
+						dtConfigElement("/app/orders/", "newrelic.yml", dtJavaConfig),
+						dtConfigElement("/app/search/", "newrelic.ini", dtPythonConfig),
+					}},
+				}
+			})
+			It("should return an Info result with the setting of each agent", func() {
+				Expect(result.Status).To(Equal(tasks.Info))
+				Expect(result.Payload).To(Equal([]DistributedTracingSetting{
+					{Source: "/app/orders/newrelic.yml", AgentType: "Java", Setting: dtEnabled, Value: "true"},
+					{Source: "/app/search/newrelic.ini", AgentType: "Python", Setting: dtDefault},
+				}))
+			})
+		})
+
+		Context("when an agent disables distributed tracing", func() {
+			BeforeEach(func() {
+				upstream = map[string]tasks.Result{
+					"Base/Config/Validate": {Status: tasks.Success, Payload: []ValidateElement{
+						dtConfigElement("/app/orders/", "newrelic.yml", dtJavaConfig),
+						dtConfigElement(`C:\ProgramData\New Relic\.NET Agent\`, "newrelic.config", dtDotNetConfig),
+					}},
+					"Base/Env/CollectSysProps": {Status: tasks.Info, Payload: []tasks.ProcIDSysProps{
+						{ProcID: 1234, SysPropsKeyToVal: map[string]string{distributedTracingSysProp: "false"}},
+					}},
+					"Base/Env/CollectEnvVars": {Status: tasks.Info, Payload: map[string]string{distributedTracingEnvVarKey: "${DT_ENABLED}"}},
+				}
+			})
+			It("should return a Warning result listing each setting", func() {
+				Expect(result.Status).To(Equal(tasks.Warning))
+				Expect(result.Summary).To(ContainSubstring("/app/orders/newrelic.yml: enabled (true)"))
+				Expect(result.Summary).To(ContainSubstring(`C:\ProgramData\New Relic\.NET Agent\newrelic.config: disabled (false)`))
+				Expect(result.Summary).To(ContainSubstring(distributedTracingSysProp + " of process 1234: disabled (false)"))
+				Expect(result.Payload).To(ContainElement(DistributedTracingSetting{Source: distributedTracingEnvVarKey, Setting: dtUnknown, Value: "${DT_ENABLED}"}))
+			})
+		})
+
+		Context("when the payload of Base/Config/Validate is unexpected", func() {
+			BeforeEach(func() {
+				upstream = map[string]tasks.Result{
+					"Base/Config/Validate": {Status: tasks.Success, Payload: "not config elements"},
+				}
+			})
+			It("should return an Error result", func() {
+				Expect(result.Status).To(Equal(tasks.Error))
+			})
+		})
+	})
+
+	Describe("findDistributedTracingValue()", func() {
+		It("should read the PHP setting", func() {
+			parsed, _ := parseIni(strings.NewReader("newrelic.appname = \"Blog\"\nnewrelic.distributed_tracing_enabled = 0\n"))
+			value, found := findDistributedTracingValue(parsed)
+			Expect(found).To(BeTrue())
+			Expect(value).To(Equal("0"))
+		})
+		It("should prefer the common section", func() {
+			parsed, _ := ParseYaml(strings.NewReader("development:\n  distributed_tracing:\n    enabled: false\ncommon:\n  distributed_tracing:\n    enabled: true\n"))
+			value, found := findDistributedTracingValue(parsed)
+			Expect(found).To(BeTrue())
+			Expect(value).To(Equal("true"))
+		})
+	})
+})
@@ -0,0 +1,249 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+)
+
+// rubyEnvironmentEnvVars are read in this order by the Ruby agent to pick the section of newrelic.yml it applies
+var rubyEnvironmentEnvVars = []string{"NEW_RELIC_ENV", "RUBY_ENV", "RAILS_ENV", "APP_ENV", "RACK_ENV"}
+
+// rubyDefaultEnvironment - the section the Ruby agent applies when none of rubyEnvironmentEnvVars is set
+const rubyDefaultEnvironment = "development"
+
+// pythonEnvironmentEnvVar - the Python agent applies the [newrelic:<environment>] section over [newrelic] when it is set
+const pythonEnvironmentEnvVar = "NEW_RELIC_ENVIRONMENT"
+
+const pythonBaseSection = "newrelic"
+
+const (
+	rubyConfigDocURL   = "https://docs.newrelic.com/docs/apm/agents/ruby-agent/configuration/ruby-agent-configuration/"
+	pythonConfigDocURL = "https://docs.newrelic.com/docs/apm/agents/python-agent/configuration/python-agent-configuration/"
+)
+
+// rubyEnvironmentSections - the usual sections of newrelic.yml, telling a file with environment sections apart from
+// one with its settings at the top level
+var rubyEnvironmentSections = []string{"common", "development", "test", "production", "staging"}
+
+// disabledSettings turn the agent off in the environment they are set for
+var disabledSettings = []string{"monitor_mode", "agent_enabled"}
+
+// ConfigEnvironment - the environment section of a Ruby or Python agent config file the agent applies, and the
+// settings in effect once it is applied
+type ConfigEnvironment struct {
+	File      string
+	AgentType string
+	// Environment is the active environment, empty when the Python agent applies no environment section
+	Environment string `json:",omitempty"`
+	// Source is the environment variable the environment was read from, or default
+	Source string `json:",omitempty"`
+	// Sections are the environment sections of the file
+	Sections []string
+	// Found is false when the file has no section for the active environment
+	Found bool
+	// Settings are the settings in effect, the environment section overriding the [newrelic] section of a Python file
+	Settings map[string]string
+}
+
+// BaseConfigEnvironment - This task resolves the environment section of the Ruby and Python agent config files that
+// the agent applies, with the environment variables of the current shell
+type BaseConfigEnvironment struct {
+	readFile func(string) ([]byte, error)
+}
+
+// Identifier - This returns the Category, Subcategory and Name of each task
+func (t BaseConfigEnvironment) Identifier() tasks.Identifier {
+	return tasks.IdentifierFromString("Base/Config/Environment")
+}
+
+// Explain - Returns the help text for each individual task
+func (t BaseConfigEnvironment) Explain() string {
+	return "Resolve the active environment section of the Ruby and Python agent config files and report the settings in effect"
+}
+
+// Dependencies - Returns the dependencies for each task.
+func (t BaseConfigEnvironment) Dependencies() []string {
+	return []string{
+		"Base/Config/Validate",
+		"Base/Env/CollectEnvVars",
+	}
+}
+
+// Execute - The core work within each task
+func (t BaseConfigEnvironment) Execute(options tasks.Options, upstream map[string]tasks.Result) tasks.Result {
+	var configElements []ValidateElement
+	if upstream["Base/Config/Validate"].HasPayload() {
+		var ok bool
+		configElements, ok = upstream["Base/Config/Validate"].Payload.([]ValidateElement)
+		if !ok {
+			return tasks.Result{
+				Status:  tasks.Error,
+				Summary: tasks.AssertionErrorSummary,
+			}
+		}
+	}
+	envVars, _ := upstream["Base/Env/CollectEnvVars"].Payload.(map[string]string)
+
+	var environments []ConfigEnvironment
+	for _, configElement := range configElements {
+		if configElement.Status != tasks.Success {
+			continue
+		}
+		var environment ConfigEnvironment
+		var ok bool
+		switch configElement.AgentType {
+		case "Ruby":
+			environment, ok = rubyEnvironment(configElement, envVars)
+		case "Python":
+			environment, ok = t.pythonEnvironment(configElement, envVars)
+		}
+		if ok {
+			environments = append(environments, environment)
+		}
+	}
+	if len(environments) == 0 {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "No Ruby or Python agent config file with environment sections was found.",
+		}
+	}
+
+	var missing, resolved []string
+	docURL := rubyConfigDocURL
+	for _, environment := range environments {
+		switch {
+		case !environment.Found:
+			if len(missing) == 0 && environment.AgentType == "Python" {
+				docURL = pythonConfigDocURL
+			}
+			missing = append(missing, fmt.Sprintf("\n\t%s: no section for the %s environment (%s), the file has %s", environment.File, environment.Environment, environment.Source, sectionList(environment.Sections)))
+		case environment.Environment == "":
+			resolved = append(resolved, fmt.Sprintf("\n\t%s: [%s] only, %s is not set", environment.File, pythonBaseSection, pythonEnvironmentEnvVar))
+		default:
+			line := fmt.Sprintf("\n\t%s: %s environment (%s), %d settings in effect", environment.File, environment.Environment, environment.Source, len(environment.Settings))
+			for _, setting := range disabledSettings {
+				if enabled, err := strconv.ParseBool(environment.Settings[setting]); err == nil && !enabled {
+					line += fmt.Sprintf(", the agent is disabled by %s", setting)
+				}
+			}
+			resolved = append(resolved, line)
+		}
+	}
+
+	if len(missing) > 0 {
+		return tasks.Result{
+			Status:  tasks.Warning,
+			Summary: "The active environment has no section in these agent config files:" + strings.Join(missing, "") + "\nThe agent then runs without the settings of the environment, or with its defaults only. Add the section, or set the environment variable to one of the sections of the file. The environment is read from the environment variables of the shell nrdiag runs in, which may differ from the application's." + resolvedSummary(resolved),
+			URL:     docURL,
+			Payload: environments,
+		}
+	}
+	return tasks.Result{
+		Status:  tasks.Info,
+		Summary: "The active environment of the agent config files was resolved:" + strings.Join(resolved, ""),
+		Payload: environments,
+	}
+}
+
+func resolvedSummary(resolved []string) string {
+	if len(resolved) == 0 {
+		return ""
+	}
+	return "\nThe active environment of the other agent config files was resolved:" + strings.Join(resolved, "")
+}
+
+func sectionList(sections []string) string {
+	if len(sections) == 0 {
+		return "no environment section"
+	}
+	return strings.Join(sections, ", ")
+}
+
+// rubyEnvironment - the section of newrelic.yml the Ruby agent applies, which is the only one it reads: the common
+// settings only apply through the YAML aliases of the environment sections. false when the file has no environment
+// sections
+func rubyEnvironment(configElement ValidateElement, envVars map[string]string) (ConfigEnvironment, bool) {
+	environment := ConfigEnvironment{
+		File:        configElement.Config.FilePath + configElement.Config.FileName,
+		AgentType:   configElement.AgentType,
+		Environment: rubyDefaultEnvironment,
+		Source:      "default",
+		Sections:    []string{},
+		Settings:    map[string]string{},
+	}
+	for _, envVar := range rubyEnvironmentEnvVars {
+		if value := strings.TrimSpace(envVars[envVar]); value != "" {
+			environment.Environment = value
+			environment.Source = envVar
+			break
+		}
+	}
+
+	var isEnvironmentFile bool
+	for _, section := range configElement.ParsedResult.Children {
+		if section.IsLeaf() {
+			continue
+		}
+		environment.Sections = append(environment.Sections, section.Key)
+		if tasks.PosString(rubyEnvironmentSections, section.Key) != -1 || section.Key == environment.Environment {
+			isEnvironmentFile = true
+		}
+		if section.Key == environment.Environment {
+			environment.Found = true
+			addSettings(environment.Settings, section, "/"+section.Key+"/")
+		}
+	}
+	sort.Strings(environment.Sections)
+	return environment, isEnvironmentFile
+}
+
+// pythonEnvironment - the [newrelic] section of newrelic.ini, overridden by its [newrelic:<environment>] section when
+// NEW_RELIC_ENVIRONMENT is set. false when the file has no environment sections and no environment is set
+func (t BaseConfigEnvironment) pythonEnvironment(configElement ValidateElement, envVars map[string]string) (ConfigEnvironment, bool) {
+	file := configElement.Config.FilePath + configElement.Config.FileName
+	content, err := t.readFile(file)
+	if err != nil {
+		return ConfigEnvironment{}, false
+	}
+	sections := parseIniSections(bytes.NewReader(content))
+
+	environment := ConfigEnvironment{
+		File:      file,
+		AgentType: configElement.AgentType,
+		Sections:  []string{},
+		Settings:  map[string]string{},
+	}
+	for name := range sections {
+		if strings.HasPrefix(name, pythonBaseSection+":") {
+			environment.Sections = append(environment.Sections, strings.TrimPrefix(name, pythonBaseSection+":"))
+		}
+	}
+	sort.Strings(environment.Sections)
+
+	addSettings(environment.Settings, sections[pythonBaseSection], "/")
+	environment.Found = true
+	if value := strings.TrimSpace(envVars[pythonEnvironmentEnvVar]); value != "" {
+		environment.Environment = value
+		environment.Source = pythonEnvironmentEnvVar
+		section, ok := sections[pythonBaseSection+":"+value]
+		environment.Found = ok
+		addSettings(environment.Settings, section, "/")
+	}
+	return environment, len(environment.Sections) > 0 || environment.Environment != ""
+}
+
+// addSettings - adds the leaves under a section to the settings, named after their path within the section
+func addSettings(settings map[string]string, section tasks.ValidateBlob, prefix string) {
+	for _, child := range section.Children {
+		if !child.IsLeaf() {
+			addSettings(settings, child, prefix)
+			continue
+		}
+		settings[strings.TrimPrefix(child.PathAndKey(), prefix)] = child.Value()
+	}
+}
@@ -0,0 +1,120 @@
+package config
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+const envRubyConfig = `common: &default_settings
+  license_key: '<%= license_key %>'
+  app_name: Storefront
+  monitor_mode: true
+  transaction_tracer:
+    enabled: true
+development:
+  <<: *default_settings
+  app_name: Storefront (Development)
+  monitor_mode: false
+production:
+  <<: *default_settings
+`
+
+const envPythonConfig = `[newrelic]
+license_key = REPLACE_WITH_LICENSE_KEY
+app_name = Search
+monitor_mode = true
+
+[newrelic:staging]
+app_name = Search (Staging)
+monitor_mode = false
+`
+
+var _ = Describe("Base/Config/Environment", func() {
+	p := BaseConfigEnvironment{
+		readFile: func(path string) ([]byte, error) {
+			if path == "/app/search/newrelic.ini" {
+				return []byte(envPythonConfig), nil
+			}
+			return nil, errors.New("no such file")
+		},
+	}
+
+	Describe("Execute()", func() {
+		var (
+			result   tasks.Result
+			envVars  map[string]string
+			upstream map[string]tasks.Result
+		)
+
+		BeforeEach(func() {
+			envVars = map[string]string{}
+		})
+
+		JustBeforeEach(func() {
+			upstream = map[string]tasks.Result{
+				"Base/Config/Validate": {Status: tasks.Success, Payload: []ValidateElement{
+					dtConfigElement("/app/storefront/config/", "newrelic.yml", envRubyConfig),
+					dtConfigElement("/app/search/", "newrelic.ini", envPythonConfig),
+				}},
+				"Base/Env/CollectEnvVars": {Status: tasks.Info, Payload: envVars},
+			}
+			result = p.Execute(tasks.Options{}, upstream)
+		})
+
+		Context("when no environment is set", func() {
+			It("should return an Info result with the default section of the Ruby agent", func() {
+				Expect(result.Status).To(Equal(tasks.Info))
+				Expect(result.Summary).To(ContainSubstring("/app/storefront/config/newrelic.yml: development environment (default), 4 settings in effect, the agent is disabled by monitor_mode"))
+				Expect(result.Summary).To(ContainSubstring("/app/search/newrelic.ini: [newrelic] only, NEW_RELIC_ENVIRONMENT is not set"))
+				environments := result.Payload.([]ConfigEnvironment)
+				Expect(environments[0].Settings).To(HaveKeyWithValue("app_name", "Storefront (Development)"))
+				Expect(environments[0].Settings).To(HaveKeyWithValue("transaction_tracer/enabled", "true"))
+				Expect(environments[0].Sections).To(Equal([]string{"common", "development", "production"}))
+				Expect(environments[1].Settings).To(HaveKeyWithValue("app_name", "Search"))
+			})
+		})
+
+		Context("when the environments are set and have a section", func() {
+			BeforeEach(func() {
+				envVars = map[string]string{"RAILS_ENV": "production", "RACK_ENV": "development", "NEW_RELIC_ENVIRONMENT": "staging"}
+			})
+			It("should return an Info result with the settings of each environment", func() {
+				Expect(result.Status).To(Equal(tasks.Info))
+				environments := result.Payload.([]ConfigEnvironment)
+				Expect(environments[0].Environment).To(Equal("production"))
+				Expect(environments[0].Source).To(Equal("RAILS_ENV"))
+				Expect(environments[0].Found).To(BeTrue())
+				Expect(environments[0].Settings).To(HaveKeyWithValue("app_name", "Storefront"))
+				Expect(environments[1].Settings).To(Equal(map[string]string{
+					"license_key":  "REPLACE_WITH_LICENSE_KEY",
+					"app_name":     "Search (Staging)",
+					"monitor_mode": "false",
+				}))
+			})
+		})
+
+		Context("when an active environment has no section", func() {
+			BeforeEach(func() {
+				envVars = map[string]string{"NEW_RELIC_ENV": "qa", "NEW_RELIC_ENVIRONMENT": "production"}
+			})
+			It("should return a Warning result naming the missing sections", func() {
+				Expect(result.Status).To(Equal(tasks.Warning))
+				Expect(result.Summary).To(ContainSubstring("/app/storefront/config/newrelic.yml: no section for the qa environment (NEW_RELIC_ENV), the file has common, development, production"))
+				Expect(result.Summary).To(ContainSubstring("/app/search/newrelic.ini: no section for the production environment (NEW_RELIC_ENVIRONMENT), the file has staging"))
+				Expect(result.URL).To(Equal(rubyConfigDocURL))
+			})
+		})
+	})
+
+	Describe("parseIniSections()", func() {
+		It("should keep the settings of each section apart", func() {
+			sections := parseIniSections(strings.NewReader("; comment\n[newrelic]\napp_name = Search\n[newrelic:test]\napp_name = \"Search (Test)\"\n"))
+			Expect(sections["newrelic"].FindKey("app_name")[0].Value()).To(Equal("Search"))
+			Expect(sections["newrelic:test"].FindKey("app_name")[0].Value()).To(Equal("Search (Test)"))
+		})
+	})
+})
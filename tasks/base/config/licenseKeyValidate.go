@@ -0,0 +1,185 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/config"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+)
+
+// licenseKeyLength - the length of every New Relic license key, region prefix included
+const licenseKeyLength = 40
+
+// licenseKeyDocURL - describes the license key and where to copy it from
+const licenseKeyDocURL = "https://docs.newrelic.com/docs/apis/intro-apis/new-relic-api-keys/#license-key"
+
+// regionSetting - the region keys, as reported by Base/Config/RegionDetect, of the -region and NEW_RELIC_REGION values
+var regionSetting = map[string]string{
+	"us": "us01",
+	"eu": "eu01",
+}
+
+// regionKeyMatches - whether a key for keyRegion belongs to the region set for the run. FedRAMP accounts are in the US
+// region, their gov01 keys included
+func regionKeyMatches(keyRegion string, region string) bool {
+	return keyRegion == region || (keyRegion == "gov01" && region == "us01")
+}
+
+// licenseKeyCheckPayloadVersion - the version of the []LicenseKeyCheck payload
+const licenseKeyCheckPayloadVersion = 1
+
+// LicenseKeyCheck - the result of the checks of a license key, the key itself masked
+type LicenseKeyCheck struct {
+	MaskedKey string
+	Sources   []string
+	Region    string
+	Problems  []string
+}
+
+// BaseConfigLicenseKeyValidate - This task checks the format of the license keys found, without any network request
+type BaseConfigLicenseKeyValidate struct {
+}
+
+// Identifier - This returns the Category, Subcategory and Name of each task
+func (t BaseConfigLicenseKeyValidate) Identifier() tasks.Identifier {
+	return tasks.IdentifierFromString("Base/Config/LicenseKeyValidate")
+}
+
+// Explain - Returns the help text for each individual task
+func (t BaseConfigLicenseKeyValidate) Explain() string {
+	return "Check the length, characters and region prefix of the New Relic license key(s) found"
+}
+
+// Dependencies - This task depends on Base/Config/LicenseKey and on Base/Env/CollectEnvVars for NEW_RELIC_REGION
+func (t BaseConfigLicenseKeyValidate) Dependencies() []string {
+	return []string{
+		"Base/Config/LicenseKey",
+		"Base/Env/CollectEnvVars",
+	}
+}
+
+// Execute - The core work within each task
+func (t BaseConfigLicenseKeyValidate) Execute(options tasks.Options, upstream map[string]tasks.Result) tasks.Result {
+	licenseKeys, _ := upstream["Base/Config/LicenseKey"].Payload.([]LicenseKey)
+	if len(licenseKeys) == 0 {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "No New Relic license keys were found. Task to check the license key format did not run",
+		}
+	}
+	envVars, _ := upstream["Base/Env/CollectEnvVars"].Payload.(map[string]string)
+	region, regionSource := configuredRegion(config.Flags.Region, envVars["NEW_RELIC_REGION"])
+
+	checks := checkLicenseKeys(licenseKeys, region, regionSource)
+	var problems []string
+	for _, check := range checks {
+		if len(check.Problems) > 0 {
+			problems = append(problems, fmt.Sprintf("The license key %s found in %s %s.", check.MaskedKey, strings.Join(check.Sources, ", "), strings.Join(check.Problems, ", and it ")))
+		}
+	}
+	if len(problems) > 0 {
+		return tasks.Result{
+			Status:         tasks.Failure,
+			Summary:        strings.Join(problems, "\n") + "\nCopy the license key of the account again from the New Relic UI.",
+			URL:            licenseKeyDocURL,
+			Payload:        checks,
+			PayloadVersion: licenseKeyCheckPayloadVersion,
+		}
+	}
+	return tasks.Result{
+		Status:         tasks.Success,
+		Summary:        fmt.Sprintf("%d license key(s) found with a valid length, characters and region prefix.", len(checks)),
+		Payload:        checks,
+		PayloadVersion: licenseKeyCheckPayloadVersion,
+	}
+}
+
+// checkLicenseKeys - checks each distinct license key once, in the order of their masked value. region is the region key
+// set for the run, empty when none is set
+func checkLicenseKeys(licenseKeys []LicenseKey, region string, regionSource string) []LicenseKeyCheck {
+	sources := map[string][]string{}
+	var keys []string
+	for _, lk := range licenseKeys {
+		// the quotes around a value in a config file are not part of the key
+		key := strings.Trim(strings.TrimSpace(lk.Value), `'"`)
+		if _, ok := sources[key]; !ok {
+			keys = append(keys, key)
+		}
+		sources[key] = append(sources[key], lk.Source)
+	}
+
+	checks := []LicenseKeyCheck{}
+	for _, key := range keys {
+		check := LicenseKeyCheck{
+			MaskedKey: maskLicenseKey(key),
+			Sources:   sources[key],
+			Region:    parseRegion(key),
+			Problems:  licenseKeyProblems(key),
+		}
+		if region != "" && !regionKeyMatches(check.Region, region) {
+			check.Problems = append(check.Problems, fmt.Sprintf("is for the %s region while %s is set to %s: the agent would connect to the wrong region", check.Region, regionSource, region))
+		}
+		checks = append(checks, check)
+	}
+	sort.SliceStable(checks, func(i, j int) bool {
+		return checks[i].MaskedKey < checks[j].MaskedKey
+	})
+	return checks
+}
+
+// licenseKeyProblems - describes what keeps a key from being a New Relic license key: its length and any character
+// other than an ASCII letter or digit
+func licenseKeyProblems(key string) []string {
+	var problems []string
+	chars := []rune(key)
+	if len(chars) != licenseKeyLength {
+		problem := fmt.Sprintf("is %d characters long instead of %d", len(chars), licenseKeyLength)
+		if len(chars) < licenseKeyLength {
+			problem += ", it may have been truncated when copied"
+		}
+		problems = append(problems, problem)
+	}
+	for position, char := range chars {
+		if char > unicode.MaxASCII || !(unicode.IsLetter(char) || unicode.IsDigit(char)) {
+			problems = append(problems, fmt.Sprintf("has an invalid %s at position %d, only letters and digits are allowed", describeChar(char), position+1))
+			break
+		}
+	}
+	return problems
+}
+
+func describeChar(char rune) string {
+	switch {
+	case unicode.IsSpace(char):
+		return "whitespace character"
+	case char == '"' || char == '\'':
+		return "quote"
+	default:
+		return fmt.Sprintf("character %q", char)
+	}
+}
+
+// configuredRegion - the region key set with -region, or else NEW_RELIC_REGION, and where it was set. Empty when neither
+// is set, the region of the key then can't be wrong
+func configuredRegion(regionFlag string, regionEnv string) (string, string) {
+	if region, ok := regionSetting[strings.ToLower(strings.TrimSpace(regionFlag))]; ok {
+		return region, "-region"
+	}
+	if region, ok := regionSetting[strings.ToLower(strings.TrimSpace(regionEnv))]; ok {
+		return region, "NEW_RELIC_REGION"
+	}
+	return "", ""
+}
+
+// maskLicenseKey - keeps the last 4 characters of a key, enough to tell keys apart, and masks the others
+func maskLicenseKey(key string) string {
+	const visible = 4
+	chars := []rune(key)
+	if len(chars) <= visible {
+		return strings.Repeat("*", len(chars))
+	}
+	return strings.Repeat("*", len(chars)-visible) + string(chars[len(chars)-visible:])
+}
@@ -0,0 +1,142 @@
+package config
+
+import (
+	"github.com/newrelic/newrelic-diagnostics-cli/config"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("BaseConfigLicenseKeyValidate", func() {
+
+	var p BaseConfigLicenseKeyValidate
+
+	Describe("Execute()", func() {
+
+		var (
+			result   tasks.Result
+			upstream map[string]tasks.Result
+		)
+
+		JustBeforeEach(func() {
+			result = p.Execute(tasks.Options{}, upstream)
+		})
+
+		Context("when no license key was found", func() {
+			BeforeEach(func() {
+				upstream = map[string]tasks.Result{
+					"Base/Config/LicenseKey": {Status: tasks.None},
+				}
+			})
+			It("should return a None result", func() {
+				Expect(result.Status).To(Equal(tasks.None))
+			})
+		})
+
+		Context("when the license keys are well formed", func() {
+			BeforeEach(func() {
+				upstream = map[string]tasks.Result{
+					"Base/Config/LicenseKey": {Payload: []LicenseKey{
+						{Value: "08a2ad66c637a29c3982469a3fe8d1982d002c4a", Source: "newrelic.yml"},
+						{Value: "'08a2ad66c637a29c3982469a3fe8d1982d002c4a'", Source: "NEW_RELIC_LICENSE_KEY"},
+					}},
+				}
+			})
+			It("should return a Success result checking each key once", func() {
+				Expect(result.Status).To(Equal(tasks.Success))
+				checks := result.Payload.([]LicenseKeyCheck)
+				Expect(checks).To(HaveLen(1))
+				Expect(checks[0].Sources).To(Equal([]string{"newrelic.yml", "NEW_RELIC_LICENSE_KEY"}))
+				Expect(checks[0].Region).To(Equal("us01"))
+				Expect(result.PayloadVersion).To(Equal(1))
+			})
+		})
+
+		Context("when a license key was truncated", func() {
+			BeforeEach(func() {
+				upstream = map[string]tasks.Result{
+					"Base/Config/LicenseKey": {Payload: []LicenseKey{
+						{Value: "08a2ad66c637a29c3982469a3fe8d1982d00", Source: "newrelic.yml"},
+					}},
+				}
+			})
+			It("should return a Failure result with the length, masking the key", func() {
+				Expect(result.Status).To(Equal(tasks.Failure))
+				Expect(result.Summary).To(ContainSubstring("is 36 characters long instead of 40, it may have been truncated when copied"))
+				Expect(result.Summary).To(ContainSubstring("********************************2d00 found in newrelic.yml"))
+				Expect(result.Summary).NotTo(ContainSubstring("08a2ad66"))
+				Expect(result.URL).NotTo(BeEmpty())
+			})
+		})
+
+		Context("when a license key has an invalid character", func() {
+			BeforeEach(func() {
+				upstream = map[string]tasks.Result{
+					"Base/Config/LicenseKey": {Payload: []LicenseKey{
+						{Value: "08a2ad66c637a29c 982469a3fe8d1982d002c4a", Source: "newrelic.yml"},
+					}},
+				}
+			})
+			It("should return a Failure result with the position of the character", func() {
+				Expect(result.Status).To(Equal(tasks.Failure))
+				Expect(result.Summary).To(ContainSubstring("has an invalid whitespace character at position 17"))
+			})
+		})
+
+		Context("when the license key is for another region than the one set", func() {
+			BeforeEach(func() {
+				upstream = map[string]tasks.Result{
+					"Base/Config/LicenseKey": {Payload: []LicenseKey{
+						{Value: fakeEUKey1, Source: "newrelic.yml"},
+					}},
+					"Base/Env/CollectEnvVars": {Payload: map[string]string{"NEW_RELIC_REGION": "US"}},
+				}
+			})
+			It("should return a Failure result naming both regions", func() {
+				Expect(result.Status).To(Equal(tasks.Failure))
+				Expect(result.Summary).To(ContainSubstring("is for the eu01 region while NEW_RELIC_REGION is set to us01"))
+			})
+		})
+
+		Context("when -region takes precedence over NEW_RELIC_REGION", func() {
+			BeforeEach(func() {
+				config.Flags.Region = "eu"
+				upstream = map[string]tasks.Result{
+					"Base/Config/LicenseKey": {Payload: []LicenseKey{
+						{Value: fakeEUKey1, Source: "newrelic.yml"},
+					}},
+					"Base/Env/CollectEnvVars": {Payload: map[string]string{"NEW_RELIC_REGION": "US"}},
+				}
+			})
+			AfterEach(func() {
+				config.Flags.Region = ""
+			})
+			It("should return a Success result", func() {
+				Expect(result.Status).To(Equal(tasks.Success))
+			})
+		})
+
+		Context("when a FedRAMP license key is used in the US region", func() {
+			BeforeEach(func() {
+				upstream = map[string]tasks.Result{
+					"Base/Config/LicenseKey": {Payload: []LicenseKey{
+						{Value: "gov01x66c637a29c3982469a3fe8d1982d00NRAL", Source: "newrelic.yml"},
+					}},
+					"Base/Env/CollectEnvVars": {Payload: map[string]string{"NEW_RELIC_REGION": "us"}},
+				}
+			})
+			It("should return a Success result", func() {
+				Expect(result.Status).To(Equal(tasks.Success))
+			})
+		})
+	})
+
+	Describe("maskLicenseKey()", func() {
+		It("should keep only the last 4 characters", func() {
+			Expect(maskLicenseKey("eu01xx66c637a29c3982469a3fe8d1982d00NRAL")).To(Equal("************************************NRAL"))
+		})
+		It("should mask a key of 4 characters or less entirely", func() {
+			Expect(maskLicenseKey("NRAL")).To(Equal("****"))
+		})
+	})
+})
@@ -0,0 +1,334 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	"github.com/shirou/gopsutil/v3/process"
+)
+
+// The accesses an agent needs, as the permission bits of its user, group or others
+const (
+	readAccess    os.FileMode = 4
+	writeAccess   os.FileMode = 2
+	executeAccess os.FileMode = 1
+)
+
+// The outcome of the check of a path for the agent users
+const (
+	accessGranted    = "granted"
+	accessDenied     = "denied"
+	accessNotChecked = "not checked"
+)
+
+// The kinds of paths checked
+const (
+	configFilePathKind = "config file"
+	logDirKind         = "log directory"
+	logFileKind        = "log file"
+)
+
+// AgentUser - a user a New Relic agent runs as, with the groups its access to a file can come from
+type AgentUser struct {
+	Name   string
+	UID    string
+	GIDs   []string `json:"-"`
+	Source string
+}
+
+// FilePermission - the mode and owner of a config file or log path, and whether an agent user can use it
+type FilePermission struct {
+	Path   string
+	Kind   string
+	Mode   string
+	Owner  string `json:",omitempty"`
+	Group  string `json:",omitempty"`
+	Access string
+	Reason string `json:",omitempty"`
+}
+
+// PermissionsPayload - the agent users and the paths checked against them
+type PermissionsPayload struct {
+	AgentUsers []AgentUser
+	Files      []FilePermission
+}
+
+// fileOwnership - the mode and the owning user and group IDs of a path
+type fileOwnership struct {
+	Mode os.FileMode
+	UID  string
+	GID  string
+}
+
+// BaseConfigPermissions - This task checks that the users the agents run as can read their config files and write their
+// logs. The log paths are read from the payload of Base/Log/Copy, see tasks.LogElement
+type BaseConfigPermissions struct {
+	goos          string
+	findAgentUser func() []AgentUser
+	statOwnership func(string) (fileOwnership, error)
+}
+
+// Identifier - This returns the Category, Subcategory and Name of each task
+func (p BaseConfigPermissions) Identifier() tasks.Identifier {
+	return tasks.IdentifierFromString("Base/Config/Permissions")
+}
+
+// Explain - Returns the help text for each individual task
+func (p BaseConfigPermissions) Explain() string {
+	return "Check that the users running the New Relic agents can read their config files and write their logs"
+}
+
+// Dependencies - Returns the dependencies for each task.
+func (p BaseConfigPermissions) Dependencies() []string {
+	return []string{
+		"Base/Config/Discovery",
+		"Base/Log/Copy",
+	}
+}
+
+// Execute - The core work within each task
+func (p BaseConfigPermissions) Execute(options tasks.Options, upstream map[string]tasks.Result) tasks.Result {
+	var configFiles []string
+	if discovery, ok := upstream["Base/Config/Discovery"].Payload.(ConfigDiscovery); ok {
+		for _, agent := range discovery.Agents {
+			configFiles = append(configFiles, agent.Found...)
+		}
+	}
+	var logFiles []string
+	if logs, ok := upstream["Base/Log/Copy"].Payload.([]tasks.LogElement); ok {
+		for _, logElement := range logs {
+			logFiles = append(logFiles, filepath.Join(logElement.FilePath, logElement.FileName))
+		}
+	}
+	if len(configFiles) == 0 && len(logFiles) == 0 {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "No New Relic config or log file was found to check the permissions of.",
+		}
+	}
+
+	if p.goos == "windows" {
+		return tasks.Result{
+			Status:  tasks.Info,
+			Summary: "The access of the agent users to the config and log files is not checked on Windows, where it is set by ACLs. The modes of the files are in the payload.",
+			Payload: PermissionsPayload{Files: fileModes(configFiles, logFiles)},
+		}
+	}
+
+	agentUsers := p.findAgentUser()
+	var files []FilePermission
+	for _, configFile := range configFiles {
+		files = append(files, p.checkPath(configFile, configFilePathKind, readAccess, agentUsers))
+	}
+	logDirs := []string{}
+	for _, logFile := range logFiles {
+		if dir := filepath.Dir(logFile); tasks.PosString(logDirs, dir) == -1 {
+			logDirs = append(logDirs, dir)
+		}
+	}
+	sort.Strings(logDirs)
+	for _, logDir := range logDirs {
+		files = append(files, p.checkPath(logDir, logDirKind, writeAccess|executeAccess, agentUsers))
+	}
+	for _, logFile := range logFiles {
+		files = append(files, p.checkPath(logFile, logFileKind, writeAccess, agentUsers))
+	}
+	return preparePermissionsResult(PermissionsPayload{AgentUsers: agentUsers, Files: files})
+}
+
+// checkPath - whether one of the agent users has the access to the path and can traverse its parent directories. The
+// agents of a host can run as different users, and which one uses a file is not known, so one of them is enough
+func (p BaseConfigPermissions) checkPath(path string, kind string, access os.FileMode, agentUsers []AgentUser) FilePermission {
+	permission := FilePermission{Path: path, Kind: kind}
+	ownership, err := p.statOwnership(path)
+	if err != nil {
+		permission.Access = accessNotChecked
+		permission.Reason = err.Error()
+		return permission
+	}
+	permission.Mode = ownership.Mode.String()
+	permission.Owner = userName(ownership.UID)
+	permission.Group = groupName(ownership.GID)
+	if len(agentUsers) == 0 {
+		permission.Access = accessNotChecked
+		permission.Reason = "the user running the agents couldn't be found"
+		return permission
+	}
+
+	var reasons []string
+	for _, agentUser := range agentUsers {
+		reason := p.userAccess(agentUser, path, ownership, access)
+		if reason == "" {
+			permission.Access = accessGranted
+			return permission
+		}
+		reasons = append(reasons, reason)
+	}
+	permission.Access = accessDenied
+	permission.Reason = strings.Join(reasons, "; ")
+	return permission
+}
+
+// userAccess - why the user can't use the path, empty when it can
+func (p BaseConfigPermissions) userAccess(agentUser AgentUser, path string, ownership fileOwnership, access os.FileMode) string {
+	if !hasAccess(agentUser, ownership, access) {
+		return fmt.Sprintf("%s can't %s it", agentUser.Name, accessName(access))
+	}
+	for dir := filepath.Dir(path); ; dir = filepath.Dir(dir) {
+		dirOwnership, err := p.statOwnership(dir)
+		if err == nil && !hasAccess(agentUser, dirOwnership, executeAccess) {
+			return fmt.Sprintf("%s can't traverse %s (%s)", agentUser.Name, dir, dirOwnership.Mode.String())
+		}
+		if filepath.Dir(dir) == dir {
+			return ""
+		}
+	}
+}
+
+// hasAccess - whether the permission bits that apply to the user, those of the owner, the group or the others, grant the access
+func hasAccess(agentUser AgentUser, ownership fileOwnership, access os.FileMode) bool {
+	if agentUser.UID == "0" {
+		return true
+	}
+	perm := ownership.Mode.Perm()
+	switch {
+	case ownership.UID == agentUser.UID:
+		perm >>= 6
+	case tasks.PosString(agentUser.GIDs, ownership.GID) != -1:
+		perm >>= 3
+	}
+	return perm&access == access
+}
+
+func accessName(access os.FileMode) string {
+	switch access {
+	case readAccess:
+		return "read"
+	case writeAccess:
+		return "write to"
+	}
+	return "create files in"
+}
+
+func preparePermissionsResult(payload PermissionsPayload) tasks.Result {
+	result := tasks.Result{
+		Status:  tasks.Success,
+		Payload: payload,
+	}
+	var users []string
+	for _, agentUser := range payload.AgentUsers {
+		users = append(users, fmt.Sprintf("%s (%s)", agentUser.Name, agentUser.Source))
+	}
+
+	var denied []string
+	for _, file := range payload.Files {
+		if file.Access == accessDenied {
+			denied = append(denied, fmt.Sprintf("\t%s %s (%s %s:%s): %s", file.Kind, file.Path, file.Mode, file.Owner, file.Group, file.Reason))
+		}
+	}
+	if len(denied) > 0 {
+		result.Status = tasks.Warning
+		result.Summary = "The agent users " + strings.Join(users, ", ") + " can't use these files, the agents can't read their config or write their logs:\n" + strings.Join(denied, "\n")
+		result.URL = "https://docs.newrelic.com/docs/apm/agents/manage-apm-agents/troubleshooting/not-seeing-data/"
+		return result
+	}
+	result.Summary = fmt.Sprintf("The agent users %s can read the config files and write the logs checked.", strings.Join(users, ", "))
+	return result
+}
+
+// fileModes - the modes of the paths, as far as they can be told without the ACLs
+func fileModes(configFiles []string, logFiles []string) []FilePermission {
+	var files []FilePermission
+	for _, paths := range []struct {
+		kind  string
+		paths []string
+	}{{configFilePathKind, configFiles}, {logFileKind, logFiles}} {
+		for _, path := range paths.paths {
+			permission := FilePermission{Path: path, Kind: paths.kind, Access: accessNotChecked}
+			if info, err := os.Stat(path); err == nil {
+				permission.Mode = info.Mode().String()
+			} else {
+				permission.Reason = err.Error()
+			}
+			files = append(files, permission)
+		}
+	}
+	return files
+}
+
+// findAgentUsers - the users of the running processes loading a New Relic agent, or the user running nrdiag when there is none
+func findAgentUsers() []AgentUser {
+	var agentUsers []AgentUser
+	processes, err := process.Processes()
+	if err != nil {
+		log.Debug("Unable to list the processes:", err)
+	}
+	for _, proc := range processes {
+		if proc.Pid == int32(os.Getpid()) {
+			continue
+		}
+		cmdline, err := proc.Cmdline()
+		if err != nil || !strings.Contains(strings.ToLower(cmdline), "newrelic") {
+			continue
+		}
+		name, err := proc.Username()
+		if err != nil {
+			log.Debug("Unable to get the user of process", proc.Pid, ":", err)
+			continue
+		}
+		if agentUserIndex(agentUsers, name) != -1 {
+			continue
+		}
+		agentUsers = append(agentUsers, lookupAgentUser(name, fmt.Sprintf("process %d", proc.Pid)))
+	}
+	if len(agentUsers) == 0 {
+		if current, err := user.Current(); err == nil {
+			agentUsers = append(agentUsers, lookupAgentUser(current.Username, "the user running nrdiag, no agent process was found"))
+		}
+	}
+	return agentUsers
+}
+
+func agentUserIndex(agentUsers []AgentUser, name string) int {
+	for i, agentUser := range agentUsers {
+		if agentUser.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func lookupAgentUser(name string, source string) AgentUser {
+	agentUser := AgentUser{Name: name, Source: source}
+	found, err := user.Lookup(name)
+	if err != nil {
+		log.Debug("Unable to look up user", name, ":", err)
+		return agentUser
+	}
+	agentUser.UID = found.Uid
+	agentUser.GIDs = []string{found.Gid}
+	if groups, err := found.GroupIds(); err == nil {
+		agentUser.GIDs = append(agentUser.GIDs, groups...)
+	}
+	return agentUser
+}
+
+func userName(uid string) string {
+	if found, err := user.LookupId(uid); err == nil {
+		return found.Username
+	}
+	return uid
+}
+
+func groupName(gid string) string {
+	if found, err := user.LookupGroupId(gid); err == nil {
+		return found.Name
+	}
+	return gid
+}
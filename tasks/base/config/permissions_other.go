@@ -0,0 +1,28 @@
+//go:build !windows
+// +build !windows
+
+package config
+
+import (
+	"errors"
+	"os"
+	"strconv"
+	"syscall"
+)
+
+// statOwnership - the mode and the owning user and group of a path
+func statOwnership(path string) (fileOwnership, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fileOwnership{}, err
+	}
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return fileOwnership{}, errors.New("the owner of " + path + " can't be determined")
+	}
+	return fileOwnership{
+		Mode: info.Mode(),
+		UID:  strconv.FormatUint(uint64(stat.Uid), 10),
+		GID:  strconv.FormatUint(uint64(stat.Gid), 10),
+	}, nil
+}
@@ -0,0 +1,127 @@
+package config
+
+import (
+	"errors"
+	"os"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// fakeOwnerships - statOwnership for a fake filesystem, where the directories not listed are traversable by anyone
+func fakeOwnerships(ownerships map[string]fileOwnership) func(string) (fileOwnership, error) {
+	return func(path string) (fileOwnership, error) {
+		if ownership, ok := ownerships[path]; ok {
+			return ownership, nil
+		}
+		if path == "/srv/app/missing.yml" {
+			return fileOwnership{}, errors.New("stat /srv/app/missing.yml: no such file or directory")
+		}
+		return fileOwnership{Mode: os.ModeDir | 0755, UID: "0", GID: "0"}, nil
+	}
+}
+
+var _ = Describe("Base/Config/Permissions", func() {
+	var (
+		task       BaseConfigPermissions
+		upstream   map[string]tasks.Result
+		ownerships map[string]fileOwnership
+	)
+	tomcat := AgentUser{Name: "tomcat", UID: "1001", GIDs: []string{"1001", "50"}, Source: "process 42"}
+
+	BeforeEach(func() {
+		ownerships = map[string]fileOwnership{
+			"/srv/app/newrelic.yml":            {Mode: 0640, UID: "0", GID: "50"},
+			"/srv/app/logs":                    {Mode: os.ModeDir | 0755, UID: "1001", GID: "1001"},
+			"/srv/app/logs/newrelic_agent.log": {Mode: 0644, UID: "1001", GID: "1001"},
+		}
+		task = BaseConfigPermissions{
+			goos:          "linux",
+			findAgentUser: func() []AgentUser { return []AgentUser{tomcat} },
+			statOwnership: fakeOwnerships(ownerships),
+		}
+		upstream = map[string]tasks.Result{
+			"Base/Config/Discovery": {Status: tasks.Info, Payload: ConfigDiscovery{Agents: []AgentConfigSearch{
+				{AgentType: "Java, Ruby", FileNames: []string{"newrelic.yml"}, Found: []string{"/srv/app/newrelic.yml"}},
+			}}},
+			"Base/Log/Copy": {Status: tasks.Success, Payload: []tasks.LogElement{
+				{FileName: "newrelic_agent.log", FilePath: "/srv/app/logs"},
+			}},
+		}
+	})
+
+	Describe("Execute", func() {
+		It("Should return a Success result when the agent user can read the config and write the logs", func() {
+			result := task.Execute(tasks.Options{}, upstream)
+			Expect(result.Status).To(Equal(tasks.Success))
+			payload := result.Payload.(PermissionsPayload)
+			Expect(payload.AgentUsers).To(Equal([]AgentUser{tomcat}))
+			Expect(payload.Files).To(Equal([]FilePermission{
+				{Path: "/srv/app/newrelic.yml", Kind: configFilePathKind, Mode: "-rw-r-----", Owner: userName("0"), Group: groupName("50"), Access: accessGranted},
+				{Path: "/srv/app/logs", Kind: logDirKind, Mode: "drwxr-xr-x", Owner: userName("1001"), Group: groupName("1001"), Access: accessGranted},
+				{Path: "/srv/app/logs/newrelic_agent.log", Kind: logFileKind, Mode: "-rw-r--r--", Owner: userName("1001"), Group: groupName("1001"), Access: accessGranted},
+			}))
+		})
+
+		It("Should return a Warning result when the agent user can't read the config file", func() {
+			ownerships["/srv/app/newrelic.yml"] = fileOwnership{Mode: 0600, UID: "0", GID: "50"}
+			result := task.Execute(tasks.Options{}, upstream)
+			Expect(result.Status).To(Equal(tasks.Warning))
+			Expect(result.Summary).To(ContainSubstring("config file /srv/app/newrelic.yml (-rw-------"))
+			Expect(result.Summary).To(ContainSubstring("tomcat can't read it"))
+		})
+
+		It("Should return a Warning result when the agent user can't traverse a parent of the log directory", func() {
+			ownerships["/srv"] = fileOwnership{Mode: os.ModeDir | 0700, UID: "0", GID: "0"}
+			result := task.Execute(tasks.Options{}, upstream)
+			Expect(result.Status).To(Equal(tasks.Warning))
+			Expect(result.Summary).To(ContainSubstring("tomcat can't traverse /srv (drwx------)"))
+		})
+
+		It("Should return a Warning result when the log file is not writable", func() {
+			ownerships["/srv/app/logs/newrelic_agent.log"] = fileOwnership{Mode: 0644, UID: "0", GID: "0"}
+			result := task.Execute(tasks.Options{}, upstream)
+			Expect(result.Status).To(Equal(tasks.Warning))
+			Expect(result.Summary).To(ContainSubstring("tomcat can't write to it"))
+		})
+
+		It("Should accept the access of any of the agent users", func() {
+			ownerships["/srv/app/newrelic.yml"] = fileOwnership{Mode: 0600, UID: "0", GID: "0"}
+			task.findAgentUser = func() []AgentUser {
+				return []AgentUser{tomcat, {Name: "root", UID: "0", Source: "process 7"}}
+			}
+			Expect(task.Execute(tasks.Options{}, upstream).Status).To(Equal(tasks.Success))
+		})
+
+		It("Should report the files that can't be checked without a Warning", func() {
+			upstream["Base/Config/Discovery"].Payload.(ConfigDiscovery).Agents[0].Found[0] = "/srv/app/missing.yml"
+			result := task.Execute(tasks.Options{}, upstream)
+			Expect(result.Status).To(Equal(tasks.Success))
+			Expect(result.Payload.(PermissionsPayload).Files[0].Access).To(Equal(accessNotChecked))
+		})
+
+		It("Should return an Info result with the modes on Windows", func() {
+			task.goos = "windows"
+			result := task.Execute(tasks.Options{}, upstream)
+			Expect(result.Status).To(Equal(tasks.Info))
+			Expect(result.Payload.(PermissionsPayload).Files).To(HaveLen(2))
+		})
+
+		It("Should return a None result without config or log files", func() {
+			Expect(task.Execute(tasks.Options{}, map[string]tasks.Result{}).Status).To(Equal(tasks.None))
+		})
+	})
+
+	Describe("hasAccess", func() {
+		It("Should use the permission bits of the owner, then the group, then the others", func() {
+			Expect(hasAccess(tomcat, fileOwnership{Mode: 0400, UID: "1001", GID: "0"}, readAccess)).To(BeTrue())
+			Expect(hasAccess(tomcat, fileOwnership{Mode: 0044, UID: "1001", GID: "0"}, readAccess)).To(BeFalse())
+			Expect(hasAccess(tomcat, fileOwnership{Mode: 0040, UID: "0", GID: "50"}, readAccess)).To(BeTrue())
+			Expect(hasAccess(tomcat, fileOwnership{Mode: 0704, UID: "0", GID: "50"}, readAccess)).To(BeFalse())
+			Expect(hasAccess(tomcat, fileOwnership{Mode: 0004, UID: "0", GID: "0"}, readAccess)).To(BeTrue())
+			Expect(hasAccess(tomcat, fileOwnership{Mode: 0005, UID: "0", GID: "0"}, writeAccess|executeAccess)).To(BeFalse())
+			Expect(hasAccess(AgentUser{UID: "0"}, fileOwnership{Mode: 0, UID: "1001", GID: "1001"}, readAccess)).To(BeTrue())
+		})
+	})
+})
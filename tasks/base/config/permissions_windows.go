@@ -0,0 +1,8 @@
+package config
+
+import "errors"
+
+// statOwnership - access on Windows is set by ACLs, which Base/Config/Permissions doesn't check
+func statOwnership(path string) (fileOwnership, error) {
+	return fileOwnership{}, errors.New("the ACLs of " + path + " are not checked")
+}
@@ -7,6 +7,7 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/newrelic/newrelic-diagnostics-cli/helpers/httpHelper"
 	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
 	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
 )
@@ -23,6 +24,24 @@ type ProxyConfig struct {
 	proxySource   string
 }
 
+// String - used when logging a ProxyConfig, with the password and any credentials in the proxy URL redacted
+func (p ProxyConfig) String() string {
+	password := ""
+	if p.proxyPassword != "" {
+		password = "****"
+	}
+	return fmt.Sprintf("{host:%s port:%s user:%s password:%s url:%s scheme:%s processID:%d source:%s}",
+		p.proxyHost, p.proxyPort, p.proxyUser, password, httpHelper.RedactProxyURL(p.proxyURL), p.proxyScheme, p.processID, p.proxySource)
+}
+
+// URL - returns the proxy URL built from the detected settings, or an empty string if no proxy host was detected
+func (p ProxyConfig) URL() string {
+	if p.proxyHost == "" && p.proxyURL == "" {
+		return ""
+	}
+	return setProxyURL(p)
+}
+
 var proxyEnvVarsKeys = []string{
 	"NRIA_PROXY",               //Infra: https://proxy_user.access_10@proxy_01:1080
 	"NEW_RELIC_PROXY_HOST",     //Java, Node, Python, Ruby
@@ -134,10 +153,10 @@ func (p BaseConfigProxyDetect) Execute(options tasks.Options, upstream map[strin
 			if httpsProxyKey == "" {
 				os.Setenv("HTTP_PROXY", proxyURL) //Set this env var temporarily to be used by: https://github.com/newrelic/newrelic-diagnostics-cli/blob/main/processOptions.go#L39
 			}
-			log.Debug(proxyConfig)
+			log.Debug("Detected proxy", httpHelper.RedactProxyURL(proxyURL), "from", proxyConfig.proxySource)
 			return tasks.Result{
 				Status:  tasks.Success,
-				Summary: fmt.Sprintf("We have succesfully detected a proxy URL set %s via New Relic proxy settings using %s\n", proxyURL, proxyConfig.proxySource),
+				Summary: fmt.Sprintf("We have succesfully detected a proxy URL set %s via New Relic proxy settings using %s\n", httpHelper.RedactProxyURL(proxyURL), proxyConfig.proxySource),
 				Payload: proxyConfig,
 			}
 		}
@@ -146,7 +165,7 @@ func (p BaseConfigProxyDetect) Execute(options tasks.Options, upstream map[strin
 	if httpsProxyKey != "" {
 		return tasks.Result{
 			Status:  tasks.Warning,
-			Summary: fmt.Sprintf("We have detected a proxy set via %s: %s\nThough this may be a valid configuration for you app and it is supported by New Relic Infinite Tracing, keep in mind that New Relic agents support their own specific proxy settings.", httpsProxyKey, httpsProxyVal),
+			Summary: fmt.Sprintf("We have detected a proxy set via %s: %s\nThough this may be a valid configuration for you app and it is supported by New Relic Infinite Tracing, keep in mind that New Relic agents support their own specific proxy settings.", httpsProxyKey, httpHelper.RedactProxyURL(httpsProxyVal)),
 			URL:     "https://docs.newrelic.com/docs/using-new-relic/cross-product-functions/install-configure/configure-agent",
 		}
 	}
@@ -193,7 +212,7 @@ func getProxyConfig(validations []ValidateElement, options tasks.Options, upstre
 		for _, proxyConfigKeys := range nrProxyConfigs {
 			proxyConfig, multipleProxyConfigs := findProxyValuesFromConfigFile(proxyConfigKeys, validation)
 			log.Debug("ProxyConfig found through config file: ", proxyConfig)
-			log.Debug("Detected multipleProxyConfigs: ", multipleProxyConfigs)
+			log.Debug("Detected multipleProxyConfigs: ", len(multipleProxyConfigs))
 			if proxyConfig.proxyHost != "" {
 				return proxyConfig, nil //return as soon as we have a match
 			}
@@ -237,7 +256,7 @@ func setProxyURL(proxy ProxyConfig) string {
 	}
 	//default to http
 	proxyURL = "http://" + proxyURL
-	log.Debug("Setting proxy via detected config to", proxyURL)
+	log.Debug("Setting proxy via detected config to", httpHelper.RedactProxyURL(proxyURL))
 	return proxyURL
 }
 
@@ -428,6 +447,11 @@ func findProxyValuesFromConfigFile(proxyConfigKeys ProxyConfig, fileElement Vali
 		}
 	}
 
-	log.Debug("combined proxy map is", proxyKeyPathToVal)
+	// only the keys are logged, the values may include a proxy password
+	var proxyKeyPaths []string
+	for keyPath := range proxyKeyPathToVal {
+		proxyKeyPaths = append(proxyKeyPaths, keyPath)
+	}
+	log.Debug("combined proxy map keys are", proxyKeyPaths)
 	return proxyConfig, proxyKeyPathToVal
 }
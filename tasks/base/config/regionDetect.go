@@ -3,6 +3,7 @@ package config
 import (
 	"regexp"
 	"strconv"
+	"strings"
 
 	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
 )
@@ -11,6 +12,14 @@ import (
 // to US region-- this is expected for legacy APM license keys.
 const defaultRegion = "us01"
 
+// regionLicenseRegex - the region of a license key is its prefix before the x padding, matched on the lowercased key and
+// reported as is:
+//
+//	eu01xx... - eu01, the EU region
+//	gov01x... - gov01, the FedRAMP region
+//	us01xx... - us01, as are the legacy keys without a prefix
+//
+// These are the region keys the Base/Collector/Connect* tasks run for
 var regionLicenseRegex = regexp.MustCompile(`^([a-z]{2,3}[0-9]{2})x{1,2}`)
 
 // BaseConfigRegionDetect - receiver struct for task definition
@@ -29,7 +38,10 @@ func (t BaseConfigRegionDetect) Explain() string {
 
 // Dependencies - Returns the dependencies for ech task.
 func (t BaseConfigRegionDetect) Dependencies() []string {
-	return []string{"Base/Config/ValidateLicenseKey"}
+	return []string{
+		"Base/Config/LicenseKey",
+		"Base/Config/ValidateLicenseKey",
+	}
 }
 
 // Execute - Returns all datacenter regions detected from upstream license keys.
@@ -42,8 +54,9 @@ func (t BaseConfigRegionDetect) Execute(options tasks.Options, upstream map[stri
 
 	licenseKeyToSources, ok := upstream["Base/Config/ValidateLicenseKey"].Payload.(map[string][]string)
 
-	if !ok {
-		return result
+	if !ok || len(licenseKeyToSources) == 0 {
+		// a key that failed the validation, e.g. against an account it doesn't belong to, still tells the region it is for
+		licenseKeyToSources = foundLicenseKeys(upstream)
 	}
 
 	if len(licenseKeyToSources) == 0 {
@@ -70,10 +83,25 @@ func detectRegions(licenseKeyToSources map[string][]string) []string {
 	return detectedRegions
 }
 
+// foundLicenseKeys - the license keys found by Base/Config/LicenseKey, whether or not their format is valid
+func foundLicenseKeys(upstream map[string]tasks.Result) map[string][]string {
+	licenseKeyToSources := map[string][]string{}
+	licenseKeys, ok := upstream["Base/Config/LicenseKey"].Payload.([]LicenseKey)
+	if !ok {
+		return licenseKeyToSources
+	}
+	for _, lk := range licenseKeys {
+		if key := sanitizeLicenseKey(lk.Value); key != "" {
+			licenseKeyToSources[key] = append(licenseKeyToSources[key], lk.Source)
+		}
+	}
+	return licenseKeyToSources
+}
+
 func parseRegion(licenseKey string) string {
 	parsedRegion := defaultRegion
 
-	m := regionLicenseRegex.FindStringSubmatch(licenseKey)
+	m := regionLicenseRegex.FindStringSubmatch(strings.ToLower(licenseKey))
 	if len(m) > 1 {
 		parsedRegion = m[1]
 	}
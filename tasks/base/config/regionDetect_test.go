@@ -4,6 +4,8 @@ import (
 	"reflect"
 	"sort"
 	"testing"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
 )
 
 var fakeEUKey1 = "eu01xx66c637a29c3982469a3fe8d1982d00NRAL"
@@ -102,3 +104,64 @@ func Test_parseRegion(t *testing.T) {
 		})
 	}
 }
+
+func Test_parseRegion_prefixes(t *testing.T) {
+	tests := []struct {
+		name string
+		key  string
+		want string
+	}{
+		{name: "legacy US key", key: "08a2ad66c637a29c3982469a3fe8d1982d002c4a", want: "us01"},
+		{name: "US key with a prefix", key: "us01xx66c637a29c3982469a3fe8d1982d00NRAL", want: "us01"},
+		{name: "EU key", key: "eu01xx66c637a29c3982469a3fe8d1982d00NRAL", want: "eu01"},
+		{name: "EU key in upper case", key: "EU01XX66C637A29C3982469A3FE8D1982D00NRAL", want: "eu01"},
+		{name: "FedRAMP key", key: "gov01x66c637a29c3982469a3fe8d1982d00NRAL", want: "gov01"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseRegion(tt.key); got != tt.want {
+				t.Errorf("parseRegion() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBaseConfigRegionDetect_Execute(t *testing.T) {
+	tests := []struct {
+		name     string
+		upstream map[string]tasks.Result
+		want     []string
+	}{
+		{
+			name: "it should use the validated license keys",
+			upstream: map[string]tasks.Result{
+				"Base/Config/ValidateLicenseKey": {Payload: map[string][]string{fakeEUKey1: {"newrelic.yml"}}},
+				"Base/Config/LicenseKey":         {Payload: []LicenseKey{{Value: "gov01x66c637a29c3982469a3fe8d1982d00NRAL", Source: "newrelic.yml"}}},
+			},
+			want: []string{"eu01"},
+		},
+		{
+			name: "it should fall back to the license keys found when none was validated",
+			upstream: map[string]tasks.Result{
+				"Base/Config/ValidateLicenseKey": {Status: tasks.Failure},
+				"Base/Config/LicenseKey": {Payload: []LicenseKey{
+					{Value: "'gov01x66c637a29c3982469a3fe8d1982d00NRAL'", Source: "NEW_RELIC_LICENSE_KEY"},
+				}},
+			},
+			want: []string{"gov01"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := BaseConfigRegionDetect{}.Execute(tasks.Options{}, tt.upstream)
+			if result.Status != tasks.Info || !reflect.DeepEqual(result.Payload, tt.want) {
+				t.Errorf("Execute() = %v %v, want the %v regions", result.Status, result.Payload, tt.want)
+			}
+		})
+	}
+
+	result := BaseConfigRegionDetect{}.Execute(tasks.Options{}, map[string]tasks.Result{})
+	if result.Status != tasks.None {
+		t.Errorf("Execute() without license keys = %v, want None", result.Status)
+	}
+}
@@ -0,0 +1,215 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+)
+
+// The topologies an agent setup can be part of, in which a single host is not expected to report all of the data
+const (
+	TopologyCluster           = "cluster"
+	TopologyForwardOnly       = "forward-only"
+	TopologyMultipleEndpoints = "multiple collector endpoints"
+)
+
+// forwardOnlySettings - the infrastructure agent only forwards the data of its integrations, without reporting the host
+var forwardOnlySettings = []string{"is_forward_only", "is_secure_forward_only"}
+var forwardOnlyEnvVars = []string{"NRIA_IS_FORWARD_ONLY", "NRIA_IS_SECURE_FORWARD_ONLY"}
+
+// clusterSettings - the cluster the agent reports as a member of, e.g. with the Kubernetes integration
+var clusterSettings = []string{"cluster_name", "clusterName"}
+var clusterEnvVars = []string{"NRIA_CLUSTER_NAME", "CLUSTER_NAME"}
+
+// collectorSettings - the settings overriding the collector the agents report to. host is only read at the top level
+// or in an environment of the file, other sections have host settings of their own, e.g. the proxy's
+var collectorSettings = []string{
+	"collector_url",                  // Infrastructure
+	"newrelic.daemon.collector_host", // PHP
+}
+var collectorEnvVars = []string{"NEW_RELIC_HOST", "NRIA_COLLECTOR_URL"}
+var collectorSysProp = "-Dnewrelic.config.host"
+
+// TopologySetting - a setting of an agent config file, environment variable or system property revealing the topology
+type TopologySetting struct {
+	Setting string
+	Value   string
+	Source  string
+}
+
+// AgentTopology - the clustered or high availability setups the agents on the host are part of. Tasks expecting a
+// single host to report everything, such as Base/Collector/RecentData, read it to adjust their expectations
+type AgentTopology struct {
+	Topologies         []string
+	ClusterNames       []string `json:",omitempty"`
+	CollectorEndpoints []string `json:",omitempty"`
+	Settings           []TopologySetting
+}
+
+// Detected - whether the host is part of any topology
+func (t AgentTopology) Detected() bool {
+	return len(t.Topologies) > 0
+}
+
+// BaseConfigTopology - This task detects the clustered and high availability agent setups from the agent config
+type BaseConfigTopology struct {
+}
+
+// Identifier - This returns the Category, Subcategory and Name of each task
+func (t BaseConfigTopology) Identifier() tasks.Identifier {
+	return tasks.IdentifierFromString("Base/Config/Topology")
+}
+
+// Explain - Returns the help text for each individual task
+func (t BaseConfigTopology) Explain() string {
+	return "Detect clustered, forward-only and multiple collector endpoint setups of the New Relic agents on this host"
+}
+
+// Dependencies - Returns the dependencies for each task.
+func (t BaseConfigTopology) Dependencies() []string {
+	return []string{
+		"Base/Config/Validate",
+		"Base/Env/CollectEnvVars",
+		"Base/Env/CollectSysProps",
+	}
+}
+
+// Execute - The core work within each task
+func (t BaseConfigTopology) Execute(options tasks.Options, upstream map[string]tasks.Result) tasks.Result {
+	var configElements []ValidateElement
+	if upstream["Base/Config/Validate"].HasPayload() {
+		var ok bool
+		configElements, ok = upstream["Base/Config/Validate"].Payload.([]ValidateElement)
+		if !ok {
+			return tasks.Result{
+				Status:  tasks.Error,
+				Summary: tasks.AssertionErrorSummary,
+			}
+		}
+	}
+
+	topology := detectTopology(topologySettings(configElements, upstream))
+	if !topology.Detected() {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "No clustered or high availability setup was found in the agent config, the host is expected to report on its own.",
+			Payload: topology,
+		}
+	}
+
+	summary := "The agents on this host are part of a " + strings.Join(topology.Topologies, ", ") + " setup, other hosts may report some of the data expected from this one:"
+	for _, setting := range topology.Settings {
+		summary += fmt.Sprintf("\n\t%s = %s (%s)", setting.Setting, setting.Value, setting.Source)
+	}
+	return tasks.Result{
+		Status:  tasks.Info,
+		Summary: summary,
+		Payload: topology,
+	}
+}
+
+// topologySettings - the topology settings of the agent config files, the environment and the Java system properties
+func topologySettings(configElements []ValidateElement, upstream map[string]tasks.Result) []TopologySetting {
+	settings := []TopologySetting{}
+	for _, configFile := range configElements {
+		if configFile.Status != tasks.Success {
+			continue
+		}
+		source := configFile.Config.FilePath + configFile.Config.FileName
+		keys := append(append(append([]string{}, forwardOnlySettings...), clusterSettings...), collectorSettings...)
+		for _, setting := range keys {
+			for _, key := range configFile.ParsedResult.FindKey(setting) {
+				if value := strings.TrimSpace(key.Value()); key.IsLeaf() && value != "" {
+					settings = append(settings, TopologySetting{Setting: setting, Value: value, Source: source})
+				}
+			}
+		}
+		for _, key := range configFile.ParsedResult.FindKey("host") {
+			if value := strings.TrimSpace(key.Value()); key.IsLeaf() && value != "" && strings.Count(key.Path, "/") <= 1 {
+				settings = append(settings, TopologySetting{Setting: "host", Value: value, Source: source})
+			}
+		}
+		// the .NET agent sets the collector as an attribute of its service element
+		for _, service := range configFile.ParsedResult.FindKey("service") {
+			for _, child := range service.Children {
+				if value := strings.TrimSpace(child.Value()); child.Key == "-host" && value != "" {
+					settings = append(settings, TopologySetting{Setting: "service host", Value: value, Source: source})
+				}
+			}
+		}
+	}
+
+	if envVars, ok := upstream["Base/Env/CollectEnvVars"].Payload.(map[string]string); ok {
+		for _, envVar := range append(append(append([]string{}, forwardOnlyEnvVars...), clusterEnvVars...), collectorEnvVars...) {
+			if value := strings.TrimSpace(envVars[envVar]); value != "" {
+				settings = append(settings, TopologySetting{Setting: envVar, Value: value, Source: "environment"})
+			}
+		}
+	}
+
+	if sysProps, ok := upstream["Base/Env/CollectSysProps"].Payload.([]tasks.ProcIDSysProps); ok {
+		for _, procSysProps := range sysProps {
+			if value := strings.TrimSpace(procSysProps.SysPropsKeyToVal[collectorSysProp]); value != "" {
+				settings = append(settings, TopologySetting{Setting: collectorSysProp, Value: value, Source: fmt.Sprintf("process %d", procSysProps.ProcID)})
+			}
+		}
+	}
+	return settings
+}
+
+// detectTopology - the topologies the settings reveal. Forward-only is only a topology when enabled, and a single
+// collector override, e.g. the EU or FedRAMP collector, is not: the agents then report to several endpoints, e.g. a
+// primary and a secondary region, only when the settings disagree
+func detectTopology(settings []TopologySetting) AgentTopology {
+	topology := AgentTopology{Topologies: []string{}, Settings: []TopologySetting{}}
+	var forwardOnly bool
+	var collectorSettingsFound []TopologySetting
+	for _, setting := range settings {
+		switch {
+		case tasks.PosString(forwardOnlySettings, setting.Setting) != -1 || tasks.PosString(forwardOnlyEnvVars, setting.Setting) != -1:
+			if enabled, err := strconv.ParseBool(setting.Value); err != nil || !enabled {
+				continue
+			}
+			forwardOnly = true
+		case tasks.PosString(clusterSettings, setting.Setting) != -1 || tasks.PosString(clusterEnvVars, setting.Setting) != -1:
+			if tasks.PosString(topology.ClusterNames, setting.Value) == -1 {
+				topology.ClusterNames = append(topology.ClusterNames, setting.Value)
+			}
+		default:
+			collectorSettingsFound = append(collectorSettingsFound, setting)
+			if endpoint := normalizeCollectorEndpoint(setting.Value); tasks.PosString(topology.CollectorEndpoints, endpoint) == -1 {
+				topology.CollectorEndpoints = append(topology.CollectorEndpoints, endpoint)
+			}
+			continue
+		}
+		topology.Settings = append(topology.Settings, setting)
+	}
+
+	if len(topology.ClusterNames) > 0 {
+		topology.Topologies = append(topology.Topologies, TopologyCluster)
+	}
+	if forwardOnly {
+		topology.Topologies = append(topology.Topologies, TopologyForwardOnly)
+	}
+	if len(topology.CollectorEndpoints) > 1 {
+		topology.Topologies = append(topology.Topologies, TopologyMultipleEndpoints)
+		topology.Settings = append(topology.Settings, collectorSettingsFound...)
+	}
+	sort.Strings(topology.ClusterNames)
+	sort.Strings(topology.CollectorEndpoints)
+	return topology
+}
+
+// normalizeCollectorEndpoint - the host of a collector setting, which is a URL for the infrastructure agent and a
+// host for the APM agents
+func normalizeCollectorEndpoint(value string) string {
+	endpoint := strings.ToLower(strings.TrimSpace(value))
+	if i := strings.Index(endpoint, "://"); i != -1 {
+		endpoint = endpoint[i+3:]
+	}
+	endpoint = strings.SplitN(endpoint, "/", 2)[0]
+	return strings.TrimSuffix(endpoint, ":443")
+}
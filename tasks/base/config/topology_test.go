@@ -0,0 +1,115 @@
+package config
+
+import (
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+const topologyInfraConfig = `license_key: REPLACE_WITH_LICENSE_KEY
+is_forward_only: true
+collector_url: https://infra-api.newrelic.com/
+custom_attributes:
+  environment: production
+`
+
+const topologyJavaConfig = `common: &default_settings
+  license_key: '<%= license_key %>'
+  app_name: Orders
+  host: collector.eu.newrelic.com
+  proxy_host: proxy.example.com
+production:
+  <<: *default_settings
+`
+
+const topologyDotNetConfig = `<?xml version="1.0"?>
+<configuration xmlns="urn:newrelic-config" agentEnabled="true">
+  <service licenseKey="REPLACE_WITH_LICENSE_KEY" host="collector.newrelic.com"/>
+  <application><name>Billing</name></application>
+</configuration>`
+
+var _ = Describe("Base/Config/Topology", func() {
+	var p BaseConfigTopology
+
+	Describe("Execute()", func() {
+		var (
+			result   tasks.Result
+			upstream map[string]tasks.Result
+		)
+
+		JustBeforeEach(func() {
+			result = p.Execute(tasks.Options{}, upstream)
+		})
+
+		Context("when the agents report on their own", func() {
+			BeforeEach(func() {
+				upstream = map[string]tasks.Result{
+					"Base/Config/Validate": {Status: tasks.Success, Payload: []ValidateElement{
+						dtConfigElement("/app/orders/", "newrelic.yml", topologyJavaConfig),
+					}},
+					"Base/Env/CollectEnvVars": {Status: tasks.Info, Payload: map[string]string{"NRIA_IS_FORWARD_ONLY": "false"}},
+				}
+			})
+			It("should return a None result", func() {
+				Expect(result.Status).To(Equal(tasks.None))
+				Expect(result.Payload.(AgentTopology).CollectorEndpoints).To(Equal([]string{"collector.eu.newrelic.com"}))
+			})
+		})
+
+		Context("when the infrastructure agent is forward-only in a cluster", func() {
+			BeforeEach(func() {
+				upstream = map[string]tasks.Result{
+					"Base/Config/Validate": {Status: tasks.Success, Payload: []ValidateElement{
+						dtConfigElement("/etc/", "newrelic-infra.yml", topologyInfraConfig),
+					}},
+					"Base/Env/CollectEnvVars": {Status: tasks.Info, Payload: map[string]string{"CLUSTER_NAME": "production"}},
+				}
+			})
+			It("should return an Info result with the topologies", func() {
+				Expect(result.Status).To(Equal(tasks.Info))
+				Expect(result.Summary).To(ContainSubstring("is_forward_only = true (/etc/newrelic-infra.yml)"))
+				topology := result.Payload.(AgentTopology)
+				Expect(topology.Topologies).To(Equal([]string{TopologyCluster, TopologyForwardOnly}))
+				Expect(topology.ClusterNames).To(Equal([]string{"production"}))
+			})
+		})
+
+		Context("when the agents report to different collectors", func() {
+			BeforeEach(func() {
+				upstream = map[string]tasks.Result{
+					"Base/Config/Validate": {Status: tasks.Success, Payload: []ValidateElement{
+						dtConfigElement("/app/orders/", "newrelic.yml", topologyJavaConfig),
+						dtConfigElement(`C:\ProgramData\New Relic\.NET Agent\`, "newrelic.config", topologyDotNetConfig),
+					}},
+					"Base/Env/CollectSysProps": {Status: tasks.Info, Payload: []tasks.ProcIDSysProps{
+						{ProcID: 1234, SysPropsKeyToVal: map[string]string{collectorSysProp: "COLLECTOR.EU.NEWRELIC.COM"}},
+					}},
+				}
+			})
+			It("should return an Info result listing each endpoint once", func() {
+				Expect(result.Status).To(Equal(tasks.Info))
+				topology := result.Payload.(AgentTopology)
+				Expect(topology.Topologies).To(Equal([]string{TopologyMultipleEndpoints}))
+				Expect(topology.CollectorEndpoints).To(Equal([]string{"collector.eu.newrelic.com", "collector.newrelic.com"}))
+				Expect(topology.Settings).To(ContainElement(TopologySetting{Setting: "service host", Value: "collector.newrelic.com", Source: `C:\ProgramData\New Relic\.NET Agent\newrelic.config`}))
+			})
+		})
+
+		Context("when the payload of Base/Config/Validate is unexpected", func() {
+			BeforeEach(func() {
+				upstream = map[string]tasks.Result{
+					"Base/Config/Validate": {Status: tasks.Success, Payload: "not config elements"},
+				}
+			})
+			It("should return an Error result", func() {
+				Expect(result.Status).To(Equal(tasks.Error))
+			})
+		})
+	})
+
+	Describe("normalizeCollectorEndpoint()", func() {
+		It("should keep the host of a URL", func() {
+			Expect(normalizeCollectorEndpoint(" HTTPS://Infra-API.newrelic.com:443/infra/v2/ ")).To(Equal("infra-api.newrelic.com"))
+		})
+	})
+})
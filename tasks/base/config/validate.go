@@ -2,6 +2,7 @@ package config
 
 import (
 	"bufio"
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -32,8 +33,33 @@ type ValidateElement struct {
 	Status       tasks.Status
 	ParsedResult tasks.ValidateBlob
 	Error        string
+	// AgentType is the agent the file configures, detected from its settings. Empty when it can't be told
+	AgentType string
+	// Line is the line of the syntax error when parsing failed and the parser reported one
+	Line int
 }
 
+// agentTypeRule - an agent is detected when a config file has one of its extensions and, if keys are set, one of its keys
+type agentTypeRule struct {
+	agentType  string
+	extensions []string
+	keys       []string
+}
+
+// agentTypeRules are checked in order, the first match wins. The keys are settings shared by no other agent's config
+var agentTypeRules = []agentTypeRule{
+	{agentType: "Node", extensions: []string{".js"}},
+	{agentType: ".NET", extensions: []string{".config", ".xml"}, keys: []string{"-licenseKey", "service"}},
+	{agentType: "PHP", extensions: []string{".ini", ".cfg"}, keys: []string{"newrelic.license", "newrelic.enabled", "newrelic.appname", "logfile"}},
+	{agentType: "Python", extensions: []string{".ini", ".cfg"}, keys: []string{"license_key", "monitor_mode", "transaction_tracer.function_trace"}},
+	{agentType: "Java", extensions: []string{".yml", ".yaml"}, keys: []string{"enable_auto_app_naming", "enable_auto_transaction_naming", "max_stack_trace_lines", "class_transformer"}},
+	{agentType: "Ruby", extensions: []string{".yml", ".yaml"}, keys: []string{"developer_mode", "monitor_mode", "marshaller"}},
+	{agentType: "Infrastructure", extensions: []string{".yml", ".yaml"}, keys: []string{"custom_attributes", "display_name", "enable_process_metrics", "verbose"}},
+}
+
+// errorLine finds the line number in the syntax errors of the YAML and XML parsers, e.g. "yaml: line 3: ..."
+var errorLine = regexp.MustCompile(`\bline (\d+)`)
+
 var (
 	errConfigFileNotParse = errors.New("we cannot parse this file extension for this New Relic config file")
 	errConfigFileNotRead  = "We ran into an error when trying to read your New Relic config file"
@@ -45,12 +71,16 @@ var (
 func (el ValidateElement) MarshalJSON() ([]byte, error) {
 	return json.Marshal(&struct {
 		ConfigElement
-		Status tasks.Status
-		Error  string
+		Status    tasks.Status
+		Error     string
+		AgentType string `json:",omitempty"`
+		Line      int    `json:",omitempty"`
 	}{
 		ConfigElement: el.Config,
 		Status:        el.Status,
 		Error:         el.Error,
+		AgentType:     el.AgentType,
+		Line:          el.Line,
 	})
 }
 
@@ -137,6 +167,7 @@ func (p BaseConfigValidate) Execute(options tasks.Options, results map[string]ta
 			Status:  tasks.Failure,
 			Summary: fmt.Sprintf("Errors parsing the following %d configuration file(s):%s", failureCounter, parsingErrors),
 			URL:     "https://docs.newrelic.com/docs/agents/manage-apm-agents/configuration/configure-agent",
+			Payload: validatedResults,
 		}
 	}
 
@@ -201,15 +232,64 @@ func processConfig(config ConfigElement) (ValidateElement, error) {
 			Status:       tasks.Failure,
 			ParsedResult: parsedConfig,
 			Error:        err.Error(),
+			Line:         syntaxErrorLine(err, file),
 		}, nil
 	}
 	return ValidateElement{
 		Config:       config,
 		Status:       tasks.Success,
 		ParsedResult: parsedConfig,
+		AgentType:    detectAgentType(fileType, parsedConfig),
 	}, nil
 }
 
+// detectAgentType - returns the agent a parsed config file is for, based on its extension and the settings it has
+func detectAgentType(fileType string, parsedConfig tasks.ValidateBlob) string {
+	for _, rule := range agentTypeRules {
+		if !tasks.ContainsString(rule.extensions, strings.ToLower(fileType)) {
+			continue
+		}
+		if len(rule.keys) == 0 {
+			return rule.agentType
+		}
+		for _, key := range rule.keys {
+			if len(parsedConfig.FindKey(key)) > 0 {
+				return rule.agentType
+			}
+		}
+	}
+	return ""
+}
+
+// syntaxErrorLine - returns the line of the file a parsing error points to, or 0 when the error has no position
+func syntaxErrorLine(err error, file string) int {
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		return lineAtOffset(file, syntaxErr.Offset)
+	}
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		return lineAtOffset(file, typeErr.Offset)
+	}
+	if match := errorLine.FindStringSubmatch(err.Error()); match != nil {
+		line, _ := strconv.Atoi(match[1])
+		return line
+	}
+	return 0
+}
+
+// lineAtOffset - converts the byte offset the JSON decoder reports into a line number
+func lineAtOffset(file string, offset int64) int {
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		return 0
+	}
+	if offset > int64(len(content)) {
+		offset = int64(len(content))
+	}
+	return bytes.Count(content[:offset], []byte("\n")) + 1
+}
+
 //ParseYaml - This function reads a yml file to a map that can be searched via the FindString function
 func ParseYaml(reader io.Reader) (tasks.ValidateBlob, error) {
 	var t interface{}
@@ -447,6 +527,37 @@ func parseIni(reader io.Reader) (result tasks.ValidateBlob, err error) {
 	return
 }
 
+// parseIniSections - parses an ini file like parseIni, keeping the settings of each [section] apart. The settings
+// before the first section are under the empty name
+func parseIniSections(reader io.Reader) map[string]tasks.ValidateBlob {
+	sections := map[string]map[string]interface{}{"": {}}
+	current := ""
+
+	scanner := bufio.NewScanner(reader)
+	scanner.Split(bufio.ScanLines)
+	sectionRegex := regexp.MustCompile(`^\[([^\]]+)\]`)
+	keyRegex := regexp.MustCompile("^([^;#][a-zA-Z_.]*)([ ]*)[= ][ ]*(.*)")
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if match := sectionRegex.FindStringSubmatch(line); match != nil {
+			current = strings.TrimSpace(match[1])
+			if _, ok := sections[current]; !ok {
+				sections[current] = make(map[string]interface{})
+			}
+			continue
+		}
+		for _, value := range keyRegex.FindAllStringSubmatch(line, -1) {
+			sections[current][value[1]] = trimQuotes(value[3])
+		}
+	}
+
+	result := make(map[string]tasks.ValidateBlob)
+	for name, settings := range sections {
+		result[name] = convertToValidateBlob(settings)
+	}
+	return result
+}
+
 func convertToValidateBlob(input interface{}) (output tasks.ValidateBlob) {
 	// peel back each layer of the configuration item and step through
 	output.Path = ""
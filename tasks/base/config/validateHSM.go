@@ -66,6 +66,9 @@ func (t BaseConfigValidateHSM) Dependencies() []string {
 	}
 }
 
+// RequiresNetwork - This task checks the license keys with the New Relic backend, it is skipped with -offline
+func (t BaseConfigValidateHSM) RequiresNetwork() {}
+
 // Execute - The core work within each task
 func (t BaseConfigValidateHSM) Execute(options tasks.Options, upstream map[string]tasks.Result) tasks.Result {
 
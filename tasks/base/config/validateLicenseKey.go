@@ -34,9 +34,13 @@ func (p BaseConfigValidateLicenseKey) Explain() string {
 func (p BaseConfigValidateLicenseKey) Dependencies() []string {
 	return []string{
 		"Base/Config/LicenseKey",
+		"Base/Config/ProxyDetect",
 	}
 }
 
+// RequiresNetwork - This task validates the license keys with the New Relic backend, it is skipped with -offline
+func (p BaseConfigValidateLicenseKey) RequiresNetwork() {}
+
 // Execute - The core work within each task
 func (p BaseConfigValidateLicenseKey) Execute(options tasks.Options, upstream map[string]tasks.Result) tasks.Result {
 
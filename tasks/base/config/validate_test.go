@@ -669,6 +669,59 @@ var _ = Describe("Base/Config/Validate", func() {
 				Expect(processErr).To(Not(BeNil()))
 			})
 		})
+		Context("When parsing a .json file with a syntax error", func() {
+			input := ConfigElement{
+				FileName: "validate_bad.json",
+				FilePath: "fixtures/",
+			}
+			result, processErr := processConfig(input)
+			It("Should return the line of the error", func() {
+				Expect(processErr).To(BeNil())
+				Expect(result.Status).To(Equal(tasks.Failure))
+				Expect(result.Line).To(Equal(3))
+			})
+		})
+		Context("When parsing a .yml file with a syntax error", func() {
+			input := ConfigElement{
+				FileName: "validate_bad.yml",
+				FilePath: "fixtures/",
+			}
+			result, processErr := processConfig(input)
+			It("Should return the line of the error", func() {
+				Expect(processErr).To(BeNil())
+				Expect(result.Status).To(Equal(tasks.Failure))
+				Expect(result.Line).To(Equal(4))
+			})
+		})
+	})
+
+	Describe("detectAgentType", func() {
+		agentConfigs := map[string]ConfigElement{
+			"Java":           {FileName: "newrelic.yml", FilePath: "../../fixtures/java/newrelic/"},
+			"Ruby":           {FileName: "newrelic.yml", FilePath: "../../fixtures/ruby/config/"},
+			"Infrastructure": {FileName: "newrelic-infra.yml", FilePath: "../../fixtures/infra/root/etc/"},
+			"Node":           {FileName: "newrelic.js", FilePath: "../../fixtures/node/"},
+			"Python":         {FileName: "newrelic.ini", FilePath: "../../fixtures/python/"},
+			"PHP":            {FileName: "newrelic.ini", FilePath: "../../fixtures/php/root/etc/php5/conf.d/"},
+			".NET":           {FileName: "newrelic.config", FilePath: "../../fixtures/dotnet/root/ProgramData/New Relic/.NET Agent/"},
+		}
+		for agentType, input := range agentConfigs {
+			agentType, input := agentType, input
+			It("Should detect the "+agentType+" agent from "+input.FilePath+input.FileName, func() {
+				result, processErr := processConfig(input)
+				Expect(processErr).To(BeNil())
+				Expect(result.Status).To(Equal(tasks.Success))
+				Expect(result.AgentType).To(Equal(agentType))
+			})
+		}
+		It("Should not guess the agent of an unknown file", func() {
+			result, processErr := processConfig(ConfigElement{
+				FileName: "private-location-settings-full.json",
+				FilePath: "../../fixtures/Synthetics/root/opt/newrelic/synthetics/.newrelic/synthetics/minion/",
+			})
+			Expect(processErr).To(BeNil())
+			Expect(result.AgentType).To(Equal(""))
+		})
 	})
 
 })
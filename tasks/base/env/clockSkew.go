@@ -0,0 +1,122 @@
+package env
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+)
+
+const (
+	// ClockSkewWarningSeconds - above this difference with the collector clock agents start dropping data
+	ClockSkewWarningSeconds = 60
+	// ClockSkewFailureSeconds - above this difference the agent handshakes and the payload timestamps are rejected
+	ClockSkewFailureSeconds = 300
+)
+
+// clockSkewSources - the collector connect checks whose responses carry the collector clock, in order of preference
+var clockSkewSources = []string{
+	"Base/Collector/ConnectUS",
+	"Base/Collector/ConnectEU",
+	"Base/Collector/ConnectGov",
+}
+
+// collectorClock - implemented by the payload of the Base/Collector/Connect* tasks. An interface, as the collector
+// package already depends on this one
+type collectorClock interface {
+	ClockTimes() (localTime time.Time, serverTime time.Time, ok bool)
+}
+
+// ClockSkew - the difference between the host clock and the collector clock, positive when the host is ahead
+type ClockSkew struct {
+	Source        string
+	LocalTime     time.Time
+	CollectorTime time.Time
+	SkewSeconds   int
+}
+
+// BaseEnvClockSkew - This task compares the host clock with the Date header of the collector ping response
+type BaseEnvClockSkew struct {
+}
+
+// Identifier - This returns the Category, Subcategory and Name of each task
+func (p BaseEnvClockSkew) Identifier() tasks.Identifier {
+	return tasks.IdentifierFromString("Base/Env/ClockSkew")
+}
+
+// Explain - Returns the help text for each individual task
+func (p BaseEnvClockSkew) Explain() string {
+	return "Detect if the host clock is skewed from the New Relic collector clock"
+}
+
+// Dependencies - Returns the dependencies for each task.
+func (p BaseEnvClockSkew) Dependencies() []string {
+	return clockSkewSources
+}
+
+// Execute - The core work within each task
+func (p BaseEnvClockSkew) Execute(options tasks.Options, upstream map[string]tasks.Result) tasks.Result {
+	skew, ok := measureClockSkew(upstream)
+	if !ok {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "No collector response with a Date header was received, the clock skew could not be measured",
+		}
+	}
+
+	summary := fmt.Sprintf("The host clock is %d seconds %s the New Relic collector clock:", int(math.Abs(float64(skew.SkewSeconds))), aheadOrBehind(skew.SkewSeconds))
+	summary += fmt.Sprintf("\n\t%-16v%s", "Host time:", skew.LocalTime.String())
+	summary += fmt.Sprintf("\n\t%-16v%s", "Collector time:", skew.CollectorTime.String())
+
+	switch absSkew := int(math.Abs(float64(skew.SkewSeconds))); {
+	case absSkew > ClockSkewFailureSeconds:
+		return tasks.Result{
+			Status:  tasks.Failure,
+			Summary: summary + fmt.Sprintf("\nWith more than %d seconds of skew, agents fail to connect or their data is dropped. Please sync the host clock, for example using NTP.", ClockSkewFailureSeconds),
+			URL:     "https://docs.newrelic.com/docs/apm/agents/manage-apm-agents/troubleshooting/no-data-appears-apm",
+			Payload: skew,
+		}
+	case absSkew > ClockSkewWarningSeconds:
+		return tasks.Result{
+			Status:  tasks.Warning,
+			Summary: summary + fmt.Sprintf("\nWith more than %d seconds of skew, data may be dropped or show up at the wrong time in charts. Please consider using NTP to keep the host clock in sync.", ClockSkewWarningSeconds),
+			URL:     "https://docs.newrelic.com/docs/apm/agents/manage-apm-agents/troubleshooting/no-data-appears-apm",
+			Payload: skew,
+		}
+	}
+	return tasks.Result{
+		Status:  tasks.Success,
+		Summary: summary,
+		Payload: skew,
+	}
+}
+
+// measureClockSkew - the skew measured by the first collector connect check whose response had a Date header
+func measureClockSkew(upstream map[string]tasks.Result) (ClockSkew, bool) {
+	for _, source := range clockSkewSources {
+		clock, ok := upstream[source].Payload.(collectorClock)
+		if !ok {
+			continue
+		}
+		localTime, serverTime, ok := clock.ClockTimes()
+		if !ok {
+			continue
+		}
+		return ClockSkew{
+			Source:        source,
+			LocalTime:     localTime,
+			CollectorTime: serverTime,
+			// the Date header has a one second resolution
+			SkewSeconds: int(localTime.Sub(serverTime).Round(time.Second).Seconds()),
+		}, true
+	}
+	return ClockSkew{}, false
+}
+
+func aheadOrBehind(skewSeconds int) string {
+	if skewSeconds < 0 {
+		return "behind"
+	}
+	return "ahead of"
+}
@@ -0,0 +1,80 @@
+package env
+
+import (
+	"time"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+type mockCollectorClock struct {
+	localTime  time.Time
+	serverTime time.Time
+}
+
+func (m mockCollectorClock) ClockTimes() (time.Time, time.Time, bool) {
+	return m.localTime, m.serverTime, !m.serverTime.IsZero()
+}
+
+var _ = Describe("Base/Env/ClockSkew", func() {
+	var (
+		p        BaseEnvClockSkew
+		result   tasks.Result
+		upstream map[string]tasks.Result
+		now      = time.Date(2021, 3, 4, 10, 0, 0, 0, time.UTC)
+	)
+
+	JustBeforeEach(func() {
+		result = p.Execute(tasks.Options{}, upstream)
+	})
+
+	Context("when no collector response had a Date header", func() {
+		BeforeEach(func() {
+			upstream = map[string]tasks.Result{
+				"Base/Collector/ConnectUS": {Status: tasks.Failure},
+				"Base/Collector/ConnectEU": {Status: tasks.Success, Payload: mockCollectorClock{localTime: now}},
+			}
+		})
+		It("Should return a None result", func() {
+			Expect(result.Status).To(Equal(tasks.None))
+		})
+	})
+
+	Context("when the clocks are in sync", func() {
+		BeforeEach(func() {
+			upstream = map[string]tasks.Result{
+				"Base/Collector/ConnectUS": {Status: tasks.Success, Payload: mockCollectorClock{localTime: now, serverTime: now.Add(-400 * time.Millisecond)}},
+			}
+		})
+		It("Should return a Success result with the skew", func() {
+			Expect(result.Status).To(Equal(tasks.Success))
+			Expect(result.Payload).To(Equal(ClockSkew{Source: "Base/Collector/ConnectUS", LocalTime: now, CollectorTime: now.Add(-400 * time.Millisecond), SkewSeconds: 0}))
+		})
+	})
+
+	Context("when the host is 90 seconds behind", func() {
+		BeforeEach(func() {
+			upstream = map[string]tasks.Result{
+				"Base/Collector/ConnectEU": {Status: tasks.Success, Payload: mockCollectorClock{localTime: now, serverTime: now.Add(90 * time.Second)}},
+			}
+		})
+		It("Should return a Warning result", func() {
+			Expect(result.Status).To(Equal(tasks.Warning))
+			Expect(result.Summary).To(ContainSubstring("The host clock is 90 seconds behind the New Relic collector clock"))
+			Expect(result.Payload.(ClockSkew).SkewSeconds).To(Equal(-90))
+		})
+	})
+
+	Context("when the host is 10 minutes ahead", func() {
+		BeforeEach(func() {
+			upstream = map[string]tasks.Result{
+				"Base/Collector/ConnectUS": {Status: tasks.Success, Payload: mockCollectorClock{localTime: now, serverTime: now.Add(-10 * time.Minute)}},
+			}
+		})
+		It("Should return a Failure result", func() {
+			Expect(result.Status).To(Equal(tasks.Failure))
+			Expect(result.Summary).To(ContainSubstring("600 seconds ahead of"))
+		})
+	})
+})
@@ -1,12 +1,50 @@
 package env
 
 import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/config"
 	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
 	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
 )
 
-// BaseEnvCollectEnvVars - This struct defined the sample plugin which can be used as a starting point
+// defaultEnvVarPatterns - the variables captured by default: the New Relic, infrastructure agent and profiler settings,
+// the proxy settings and the few host variables other tasks read. Arbitrary host variables are not collected, they may
+// hold unrelated secrets. Matching is case-insensitive and a '*' matches any sequence of characters
+var defaultEnvVarPatterns = []string{
+	"NEW_RELIC_*",
+	"NEWRELIC*",
+	"NRIA_*",
+	"CORECLR_*",
+	"COR_*",
+	"HTTP_PROXY",
+	"HTTPS_PROXY",
+	"ALL_PROXY",
+	"NO_PROXY",
+	"PATH",
+	"HOME",
+	"PYTHONPATH",
+	"RUBY_ENV",
+	"RAILS_ENV",
+	"APP_ENV",
+	"RACK_ENV",
+	"LOCALAPPDATA",
+	"DOTNET_INSTALL_PATH",
+	"ProgramFiles",
+	"ProgramData",
+	"APPDATA",
+	"JBOSS_HOME",
+	"WEBSITE_SITE_NAME", //Needed for detecting Azure environment
+	"KAFKA_HOME",
+	"ZOOKEEPER_HOME",
+	"JAVA_HOME",
+}
+
+// BaseEnvCollectEnvVars - This task collects the New Relic related environment variables of the current shell
 type BaseEnvCollectEnvVars struct {
+	getShellEnvVars func() (tasks.EnvironmentVariables, error)
 }
 
 // Identifier - This returns the Category, Subcategory and Name of each task
@@ -16,7 +54,7 @@ func (t BaseEnvCollectEnvVars) Identifier() tasks.Identifier {
 
 // Explain - Returns the help text for each individual task
 func (t BaseEnvCollectEnvVars) Explain() string {
-	return "Collect environment variables"
+	return "Collect New Relic related environment variables (tune with -env-allow and -env-deny)"
 }
 
 // Dependencies - Returns the dependencies for ech task.
@@ -27,7 +65,7 @@ func (t BaseEnvCollectEnvVars) Dependencies() []string {
 // Execute - The core work within each task
 func (t BaseEnvCollectEnvVars) Execute(options tasks.Options, upstream map[string]tasks.Result) tasks.Result {
 	var result tasks.Result
-	envVars, err := tasks.GetShellEnvVars()
+	envVars, err := t.getShellEnvVars()
 
 	if err != nil {
 		log.Debug(err.Error())
@@ -36,11 +74,54 @@ func (t BaseEnvCollectEnvVars) Execute(options tasks.Options, upstream map[strin
 		return result
 	}
 
-	filteredEnvVars := envVars.WithDefaultFilter()
+	allow := append(append([]string{}, defaultEnvVarPatterns...), splitEnvVarPatterns(config.Flags.EnvAllow)...)
+	filteredEnvVars := filterEnvVars(envVars.All, allow, splitEnvVarPatterns(config.Flags.EnvDeny))
 
 	result.Payload = filteredEnvVars
 	result.Status = tasks.Info
 	result.Summary = "Gathered Environment variables of current shell."
+	result.Summary += fmt.Sprintf(" %d of %d variables matched the New Relic, proxy and -env-allow patterns, the others are not collected.", len(filteredEnvVars), len(envVars.All))
 
 	return result
 }
+
+// filterEnvVars - keeps the variables matching an allow pattern and no deny pattern, deny takes precedence
+func filterEnvVars(envVars map[string]string, allow []string, deny []string) map[string]string {
+	allowed := compileEnvVarPatterns(allow)
+	denied := compileEnvVarPatterns(deny)
+	filtered := make(map[string]string)
+	for key, value := range envVars {
+		if matchesEnvVarPattern(key, allowed) && !matchesEnvVarPattern(key, denied) {
+			filtered[key] = value
+		}
+	}
+	return filtered
+}
+
+func splitEnvVarPatterns(list string) []string {
+	patterns := []string{}
+	for _, pattern := range strings.Split(list, ",") {
+		if pattern = strings.TrimSpace(pattern); pattern != "" {
+			patterns = append(patterns, pattern)
+		}
+	}
+	return patterns
+}
+
+func compileEnvVarPatterns(patterns []string) []*regexp.Regexp {
+	compiled := []*regexp.Regexp{}
+	for _, pattern := range patterns {
+		// same wildcard syntax as the -t task identifiers
+		compiled = append(compiled, config.CompileTaskPattern(pattern))
+	}
+	return compiled
+}
+
+func matchesEnvVarPattern(key string, patterns []*regexp.Regexp) bool {
+	for _, pattern := range patterns {
+		if pattern.MatchString(key) {
+			return true
+		}
+	}
+	return false
+}
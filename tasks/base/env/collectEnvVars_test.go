@@ -0,0 +1,83 @@
+package env
+
+import (
+	"errors"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/config"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Base/Env/CollectEnvVars", func() {
+	var (
+		p      BaseEnvCollectEnvVars
+		result tasks.Result
+		shell  map[string]string
+		err    error
+	)
+
+	BeforeEach(func() {
+		shell = map[string]string{
+			"NEW_RELIC_APP_NAME":       "checkout",
+			"NRIA_LICENSE_KEY":         "0123",
+			"CORECLR_ENABLE_PROFILING": "1",
+			"https_proxy":              "http://proxy.example.com:8080",
+			"PATH":                     "/usr/bin",
+			"AWS_SECRET_ACCESS_KEY":    "s3cr3t",
+			"MY_APP_MODE":              "blue",
+		}
+		err = nil
+		p = BaseEnvCollectEnvVars{
+			getShellEnvVars: func() (tasks.EnvironmentVariables, error) {
+				return tasks.EnvironmentVariables{All: shell, Scope: tasks.Shell}, err
+			},
+		}
+	})
+
+	JustBeforeEach(func() {
+		result = p.Execute(tasks.Options{}, map[string]tasks.Result{})
+	})
+
+	AfterEach(func() {
+		config.Flags.EnvAllow = ""
+		config.Flags.EnvDeny = ""
+	})
+
+	Context("with the default patterns", func() {
+		It("Should only collect the New Relic, profiler, proxy and host settings", func() {
+			Expect(result.Status).To(Equal(tasks.Info))
+			Expect(result.Payload).To(Equal(map[string]string{
+				"NEW_RELIC_APP_NAME":       "checkout",
+				"NRIA_LICENSE_KEY":         "0123",
+				"CORECLR_ENABLE_PROFILING": "1",
+				"https_proxy":              "http://proxy.example.com:8080",
+				"PATH":                     "/usr/bin",
+			}))
+			Expect(result.Summary).To(ContainSubstring("5 of 7 variables matched"))
+		})
+	})
+
+	Context("with -env-allow and -env-deny", func() {
+		BeforeEach(func() {
+			config.Flags.EnvAllow = "my_app_*"
+			config.Flags.EnvDeny = "PATH, *_proxy"
+		})
+		It("Should add the allowed variables and leave out the denied ones", func() {
+			payload := result.Payload.(map[string]string)
+			Expect(payload).To(HaveKeyWithValue("MY_APP_MODE", "blue"))
+			Expect(payload).ToNot(HaveKey("PATH"))
+			Expect(payload).ToNot(HaveKey("https_proxy"))
+			Expect(payload).ToNot(HaveKey("AWS_SECRET_ACCESS_KEY"))
+		})
+	})
+
+	Context("when the shell variables cannot be read", func() {
+		BeforeEach(func() {
+			err = errors.New("no variables")
+		})
+		It("Should return an error", func() {
+			Expect(result.Status).To(Equal(tasks.Error))
+		})
+	})
+})
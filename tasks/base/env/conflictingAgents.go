@@ -0,0 +1,319 @@
+package env
+
+import (
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+)
+
+const newRelicAgentVendor = "New Relic"
+
+// apmAgentSignature - a substring of the -javaagent jar or of the preloaded Node module identifying the APM agent
+type apmAgentSignature struct {
+	match  string
+	vendor string
+	inPath bool // matched against the whole path instead of the file name, for agents shipped as a generic javaagent.jar
+}
+
+var javaAgentSignatures = []apmAgentSignature{
+	{match: "newrelic", vendor: newRelicAgentVendor},
+	{match: "dd-java-agent", vendor: "Datadog"},
+	{match: "elastic-apm-agent", vendor: "Elastic APM"},
+	{match: "opentelemetry-javaagent", vendor: "OpenTelemetry"},
+	{match: "appserveragent", vendor: "AppDynamics", inPath: true},
+	{match: "appdynamics", vendor: "AppDynamics", inPath: true},
+	{match: "oneagent", vendor: "Dynatrace"},
+	{match: "dynatrace", vendor: "Dynatrace", inPath: true},
+	{match: "skywalking-agent", vendor: "Apache SkyWalking"},
+}
+
+// Node modules are preloaded by name, e.g. -r dd-trace/init
+var nodeAgentSignatures = []apmAgentSignature{
+	{match: "newrelic", vendor: newRelicAgentVendor, inPath: true},
+	{match: "dd-trace", vendor: "Datadog", inPath: true},
+	{match: "elastic-apm-node", vendor: "Elastic APM", inPath: true},
+	{match: "@opentelemetry/auto-instrumentations-node", vendor: "OpenTelemetry", inPath: true},
+	{match: "appdynamics", vendor: "AppDynamics", inPath: true},
+	{match: "@dynatrace", vendor: "Dynatrace", inPath: true},
+}
+
+// the CLSIDs New Relic profilers register with, see DotNet/Agent/Profiler
+var newRelicProfilerClsids = []string{
+	"{71DA0A04-7777-4EC6-9643-7D28B46A8A41}",
+	"{FF68FEB9-E58A-4B75-A2B8-90CE7D915A26}",
+	"{36032161-FFC0-4B61-B559-F6C5D41BAE5A}",
+}
+
+var infraInstallPaths = map[string][]string{
+	"windows": {
+		`C:\Program Files\New Relic\newrelic-infra\newrelic-infra.exe`,
+		`C:\Program Files (x86)\New Relic\newrelic-infra\newrelic-infra.exe`,
+	},
+	"default": {
+		"/usr/bin/newrelic-infra",
+		"/usr/local/bin/newrelic-infra",
+		"/opt/newrelic-infra/newrelic-infra",
+	},
+}
+
+// ProcessCmdline - the executable and arguments of a running process
+type ProcessCmdline struct {
+	PID  int32
+	Exe  string
+	Args []string
+}
+
+// AgentConflict - the agents found instrumenting the same runtime, or the infrastructure agent installs of the host
+type AgentConflict struct {
+	Runtime string
+	Agents  []string
+}
+
+// BaseEnvConflictingAgents - This task looks for more than one agent instrumenting the same runtime or host
+type BaseEnvConflictingAgents struct {
+	getJavaProcArgs func() []tasks.JavaProcArgs
+	listProcesses   func(string) []ProcessCmdline
+	fileExists      tasks.FileExistsFunc
+	evalSymlinks    func(string) (string, error)
+	runtimeOs       string
+}
+
+// Identifier - This returns the Category, Subcategory and Name of each task
+func (p BaseEnvConflictingAgents) Identifier() tasks.Identifier {
+	return tasks.IdentifierFromString("Base/Env/ConflictingAgents")
+}
+
+// Explain - Returns the help text for each individual task
+func (p BaseEnvConflictingAgents) Explain() string {
+	return "Detect more than one APM agent instrumenting the same runtime, or duplicate infrastructure agent installs"
+}
+
+// Dependencies - The agent detection tasks of the runtimes checked for conflicts
+func (p BaseEnvConflictingAgents) Dependencies() []string {
+	dependencies := []string{
+		"Base/Env/CollectEnvVars",
+		"Java/Config/Agent",
+		"Node/Config/Agent",
+		"DotNetCore/Agent/Installed",
+	}
+	if runtime.GOOS == "windows" {
+		dependencies = append(dependencies, "DotNet/Agent/Installed")
+	}
+	return dependencies
+}
+
+// Execute - The core work within each task
+func (p BaseEnvConflictingAgents) Execute(options tasks.Options, upstream map[string]tasks.Result) tasks.Result {
+	var checked []string
+	var conflicts []AgentConflict
+
+	if upstream["Java/Config/Agent"].Status == tasks.Success {
+		checked = append(checked, "JVMs")
+		conflicts = append(conflicts, p.javaConflicts()...)
+	}
+	if upstream["Node/Config/Agent"].Status == tasks.Success {
+		checked = append(checked, "Node processes")
+		conflicts = append(conflicts, p.nodeConflicts()...)
+	}
+	if upstream["DotNet/Agent/Installed"].Status == tasks.Success || upstream["DotNetCore/Agent/Installed"].Status == tasks.Success {
+		checked = append(checked, ".NET profiler settings")
+		envVars, _ := upstream["Base/Env/CollectEnvVars"].Payload.(map[string]string)
+		conflicts = append(conflicts, dotnetProfilerConflicts(envVars)...)
+	}
+	infraConflicts, infraFound := p.infraConflicts()
+	if infraFound {
+		checked = append(checked, "infrastructure agent installs")
+		conflicts = append(conflicts, infraConflicts...)
+	}
+
+	if len(checked) == 0 {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "No Java, Node, .NET or infrastructure agent was detected on this host. This task did not run",
+		}
+	}
+	if len(conflicts) == 0 {
+		return tasks.Result{
+			Status:  tasks.Success,
+			Summary: "No conflicting agents were found in the " + strings.Join(checked, ", ") + " of this host.",
+		}
+	}
+
+	summaries := []string{"More than one agent was found instrumenting the same runtime. Agents competing for the same instrumentation hooks cause missing or duplicated data, errors and crashes; keep only one of them:"}
+	for _, conflict := range conflicts {
+		summaries = append(summaries, " - "+conflict.Runtime+": "+strings.Join(conflict.Agents, ", "))
+	}
+	return tasks.Result{
+		Status:  tasks.Warning,
+		Summary: strings.Join(summaries, "\n"),
+		URL:     "https://docs.newrelic.com/docs/apm/new-relic-apm/troubleshooting/agent-compatibility-other-agents/",
+		Payload: conflicts,
+	}
+}
+
+// javaConflicts - JVMs started with the New Relic agent and another -javaagent or -agentpath APM agent, or with two
+// New Relic agents
+func (p BaseEnvConflictingAgents) javaConflicts() []AgentConflict {
+	var conflicts []AgentConflict
+	for _, proc := range p.getJavaProcArgs() {
+		var agents []string
+		for _, arg := range proc.Args {
+			var path string
+			if strings.HasPrefix(arg, "-javaagent:") {
+				path = strings.SplitN(strings.TrimPrefix(arg, "-javaagent:"), "=", 2)[0]
+			} else if strings.HasPrefix(arg, "-agentpath:") {
+				path = strings.SplitN(strings.TrimPrefix(arg, "-agentpath:"), "=", 2)[0]
+			} else {
+				continue
+			}
+			if vendor := matchAgentSignature(path, javaAgentSignatures); vendor != "" {
+				agents = append(agents, fmt.Sprintf("%s agent (%s)", vendor, path))
+			}
+		}
+		if hasNewRelicConflict(agents) {
+			conflicts = append(conflicts, AgentConflict{Runtime: fmt.Sprintf("JVM (PID %d)", proc.ProcID), Agents: agents})
+		}
+	}
+	return conflicts
+}
+
+// nodeConflicts - Node processes preloading the New Relic agent and another APM agent with -r, --require or --import
+func (p BaseEnvConflictingAgents) nodeConflicts() []AgentConflict {
+	var conflicts []AgentConflict
+	for _, proc := range p.listProcesses("node") {
+		var agents []string
+		for i, arg := range proc.Args {
+			module := ""
+			for _, flag := range []string{"-r", "--require", "--import"} {
+				if arg == flag && i+1 < len(proc.Args) {
+					module = proc.Args[i+1]
+				} else if strings.HasPrefix(arg, flag+"=") {
+					module = strings.TrimPrefix(arg, flag+"=")
+				}
+			}
+			if module == "" {
+				continue
+			}
+			if vendor := matchAgentSignature(module, nodeAgentSignatures); vendor != "" {
+				agents = append(agents, fmt.Sprintf("%s agent (%s)", vendor, module))
+			}
+		}
+		if hasNewRelicConflict(agents) {
+			conflicts = append(conflicts, AgentConflict{Runtime: fmt.Sprintf("Node process (PID %d)", proc.PID), Agents: agents})
+		}
+	}
+	return conflicts
+}
+
+// dotnetProfilerConflicts - only one profiler attaches to the CLR, another one registered in the environment of this
+// shell replaces the New Relic agent in the applications started from it
+func dotnetProfilerConflicts(envVars map[string]string) []AgentConflict {
+	var conflicts []AgentConflict
+	for _, name := range []string{"COR_PROFILER", "CORECLR_PROFILER"} {
+		for key, clsid := range envVars {
+			clsid = strings.TrimSpace(clsid)
+			if !strings.EqualFold(key, name) || clsid == "" || isNewRelicProfiler(clsid) {
+				continue
+			}
+			conflicts = append(conflicts, AgentConflict{
+				Runtime: ".NET CLR (" + name + " environment variable)",
+				Agents:  []string{"New Relic .NET agent (installed)", "profiler " + clsid + " (" + name + ")"},
+			})
+		}
+	}
+	return conflicts
+}
+
+// infraConflicts - more than one infrastructure agent binary, or more than one newrelic-infra process. The
+// newrelic-infra-service supervisor runs a single newrelic-infra process
+func (p BaseEnvConflictingAgents) infraConflicts() ([]AgentConflict, bool) {
+	paths, ok := infraInstallPaths[p.runtimeOs]
+	if !ok {
+		paths = infraInstallPaths["default"]
+	}
+	installs := map[string]bool{}
+	for _, path := range paths {
+		if !p.fileExists(path) {
+			continue
+		}
+		if resolved, err := p.evalSymlinks(path); err == nil {
+			path = resolved
+		}
+		installs[path] = true
+	}
+	processes := p.listProcesses("newrelic-infra")
+
+	var conflicts []AgentConflict
+	if len(installs) > 1 {
+		var agents []string
+		for path := range installs {
+			agents = append(agents, path)
+		}
+		sort.Strings(agents)
+		conflicts = append(conflicts, AgentConflict{Runtime: "Infrastructure agent installs", Agents: agents})
+	}
+	if len(processes) > 1 {
+		var agents []string
+		for _, proc := range processes {
+			agents = append(agents, fmt.Sprintf("PID %d (%s)", proc.PID, proc.Exe))
+		}
+		conflicts = append(conflicts, AgentConflict{Runtime: "Running infrastructure agents", Agents: agents})
+	}
+	return conflicts, len(installs) > 0 || len(processes) > 0
+}
+
+func matchAgentSignature(value string, signatures []apmAgentSignature) string {
+	lowerValue := strings.ToLower(value)
+	base := strings.ToLower(filepath.Base(value))
+	for _, signature := range signatures {
+		if strings.Contains(base, signature.match) || signature.inPath && strings.Contains(lowerValue, signature.match) {
+			return signature.vendor
+		}
+	}
+	return ""
+}
+
+// hasNewRelicConflict - another APM agent besides the New Relic one, or the New Relic agent loaded twice
+func hasNewRelicConflict(agents []string) bool {
+	newRelic := 0
+	for _, agent := range agents {
+		if strings.HasPrefix(agent, newRelicAgentVendor+" ") {
+			newRelic++
+		}
+	}
+	return newRelic > 0 && len(agents) > 1
+}
+
+func isNewRelicProfiler(clsid string) bool {
+	for _, nrClsid := range newRelicProfilerClsids {
+		if strings.EqualFold(clsid, nrClsid) {
+			return true
+		}
+	}
+	return false
+}
+
+// listProcesses - the running processes with the given name, with their executable and arguments
+func listProcesses(name string) []ProcessCmdline {
+	procs, err := tasks.FindProcessByName(name)
+	if err != nil {
+		log.Debug("Unable to list the", name, "processes:", err)
+	}
+	var found []ProcessCmdline
+	for i := range procs {
+		proc := &procs[i]
+		args, err := proc.CmdlineSlice()
+		if err != nil {
+			log.Debug("Unable to read the arguments of process", proc.Pid, ":", err)
+			continue
+		}
+		exe, _ := proc.Exe()
+		found = append(found, ProcessCmdline{PID: proc.Pid, Exe: exe, Args: args})
+	}
+	return found
+}
@@ -0,0 +1,155 @@
+package env
+
+import (
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Base/Env/ConflictingAgents", func() {
+	var (
+		p         BaseEnvConflictingAgents
+		result    tasks.Result
+		upstream  map[string]tasks.Result
+		javaProcs []tasks.JavaProcArgs
+		processes map[string][]ProcessCmdline
+		files     map[string]string
+	)
+
+	BeforeEach(func() {
+		upstream = map[string]tasks.Result{}
+		javaProcs = nil
+		processes = map[string][]ProcessCmdline{}
+		files = map[string]string{}
+		p = BaseEnvConflictingAgents{
+			getJavaProcArgs: func() []tasks.JavaProcArgs { return javaProcs },
+			listProcesses:   func(name string) []ProcessCmdline { return processes[name] },
+			fileExists: func(path string) bool {
+				_, ok := files[path]
+				return ok
+			},
+			evalSymlinks: func(path string) (string, error) {
+				if target := files[path]; target != "" {
+					return target, nil
+				}
+				return path, nil
+			},
+			runtimeOs: "linux",
+		}
+	})
+
+	JustBeforeEach(func() {
+		result = p.Execute(tasks.Options{}, upstream)
+	})
+
+	Context("when no agent is detected", func() {
+		It("Should return a None result", func() {
+			Expect(result.Status).To(Equal(tasks.None))
+		})
+	})
+
+	Context("when a JVM runs the New Relic agent alone", func() {
+		BeforeEach(func() {
+			upstream["Java/Config/Agent"] = tasks.Result{Status: tasks.Success}
+			javaProcs = []tasks.JavaProcArgs{{ProcID: 10, Args: []string{"java", "-javaagent:/opt/newrelic/newrelic.jar", "-jar", "app.jar"}}}
+		})
+		It("Should not report a conflict", func() {
+			Expect(result.Status).To(Equal(tasks.Success))
+			Expect(result.Summary).To(ContainSubstring("JVMs"))
+		})
+	})
+
+	Context("when a JVM runs the New Relic agent and another APM agent", func() {
+		BeforeEach(func() {
+			upstream["Java/Config/Agent"] = tasks.Result{Status: tasks.Success}
+			javaProcs = []tasks.JavaProcArgs{{ProcID: 10, Args: []string{
+				"java",
+				"-javaagent:/opt/newrelic/newrelic.jar",
+				"-javaagent:/opt/appdynamics/AppServerAgent/javaagent.jar",
+				"-javaagent:/opt/lombok.jar=ECJ",
+			}}}
+		})
+		It("Should list both agents and where they are loaded from", func() {
+			Expect(result.Status).To(Equal(tasks.Warning))
+			Expect(result.Payload).To(Equal([]AgentConflict{{
+				Runtime: "JVM (PID 10)",
+				Agents:  []string{"New Relic agent (/opt/newrelic/newrelic.jar)", "AppDynamics agent (/opt/appdynamics/AppServerAgent/javaagent.jar)"},
+			}}))
+			Expect(result.Summary).To(ContainSubstring("JVM (PID 10): New Relic agent (/opt/newrelic/newrelic.jar), AppDynamics agent"))
+		})
+	})
+
+	Context("when a Node process preloads two APM agents", func() {
+		BeforeEach(func() {
+			upstream["Node/Config/Agent"] = tasks.Result{Status: tasks.Success}
+			processes["node"] = []ProcessCmdline{{PID: 20, Args: []string{"node", "-r", "newrelic", "--require=dd-trace/init", "server.js"}}}
+		})
+		It("Should report the conflict", func() {
+			Expect(result.Status).To(Equal(tasks.Warning))
+			Expect(result.Payload.([]AgentConflict)[0].Agents).To(Equal([]string{"New Relic agent (newrelic)", "Datadog agent (dd-trace/init)"}))
+		})
+	})
+
+	Context("when another .NET profiler is registered", func() {
+		BeforeEach(func() {
+			upstream["DotNetCore/Agent/Installed"] = tasks.Result{Status: tasks.Success}
+			upstream["Base/Env/CollectEnvVars"] = tasks.Result{Status: tasks.Info, Payload: map[string]string{
+				"CORECLR_PROFILER": "{846F5F1C-F9AE-4B07-969E-05C26BC060D8}",
+			}}
+		})
+		It("Should report the profiler", func() {
+			Expect(result.Status).To(Equal(tasks.Warning))
+			Expect(result.Summary).To(ContainSubstring("profiler {846F5F1C-F9AE-4B07-969E-05C26BC060D8} (CORECLR_PROFILER)"))
+		})
+	})
+
+	Context("when the New Relic .NET profiler is registered", func() {
+		BeforeEach(func() {
+			upstream["DotNetCore/Agent/Installed"] = tasks.Result{Status: tasks.Success}
+			upstream["Base/Env/CollectEnvVars"] = tasks.Result{Status: tasks.Info, Payload: map[string]string{
+				"CORECLR_PROFILER": "{36032161-ffc0-4b61-b559-f6c5d41bae5a}",
+			}}
+		})
+		It("Should not report a conflict", func() {
+			Expect(result.Status).To(Equal(tasks.Success))
+		})
+	})
+
+	Context("when the infrastructure agent is installed twice", func() {
+		BeforeEach(func() {
+			files["/usr/bin/newrelic-infra"] = ""
+			files["/opt/newrelic-infra/newrelic-infra"] = ""
+		})
+		It("Should list the installs", func() {
+			Expect(result.Status).To(Equal(tasks.Warning))
+			Expect(result.Payload).To(Equal([]AgentConflict{{
+				Runtime: "Infrastructure agent installs",
+				Agents:  []string{"/opt/newrelic-infra/newrelic-infra", "/usr/bin/newrelic-infra"},
+			}}))
+		})
+	})
+
+	Context("when an infrastructure agent path is a link to the other install", func() {
+		BeforeEach(func() {
+			files["/usr/bin/newrelic-infra"] = ""
+			files["/usr/local/bin/newrelic-infra"] = "/usr/bin/newrelic-infra"
+			processes["newrelic-infra"] = []ProcessCmdline{{PID: 30, Exe: "/usr/bin/newrelic-infra"}}
+		})
+		It("Should not report a conflict", func() {
+			Expect(result.Status).To(Equal(tasks.Success))
+		})
+	})
+
+	Context("when two infrastructure agents are running", func() {
+		BeforeEach(func() {
+			processes["newrelic-infra"] = []ProcessCmdline{
+				{PID: 30, Exe: "/usr/bin/newrelic-infra"},
+				{PID: 31, Exe: "/var/lib/docker/overlay2/abc/merged/usr/bin/newrelic-infra"},
+			}
+		})
+		It("Should list the processes", func() {
+			Expect(result.Status).To(Equal(tasks.Warning))
+			Expect(result.Summary).To(ContainSubstring("Running infrastructure agents: PID 30 (/usr/bin/newrelic-infra), PID 31"))
+		})
+	})
+})
@@ -17,7 +17,7 @@ func (p BaseEnvDetectAWS) Identifier() tasks.Identifier {
 
 // Explain - Returns the help text for each individual task
 func (p BaseEnvDetectAWS) Explain() string {
-	return "Detect if running in AWS environment"
+	return "Detect if running in AWS environment (uses its own 2 second timeout, -http-timeout does not apply)"
 }
 
 // Dependencies - Returns the dependencies for each task.
@@ -25,6 +25,9 @@ func (p BaseEnvDetectAWS) Dependencies() []string {
 	return []string{}
 }
 
+// RequiresNetwork - This task queries the EC2 metadata endpoint, it is skipped with -offline
+func (p BaseEnvDetectAWS) RequiresNetwork() {}
+
 // Execute - The core work within each task
 func (p BaseEnvDetectAWS) Execute(options tasks.Options, upstream map[string]tasks.Result) tasks.Result {
 	var result tasks.Result
@@ -53,7 +53,7 @@ var _ = Describe("Base/Env/DetectAWS", func() {
 
 	Describe("Explain()", func() {
 		It("Should return correct explanation of task", func() {
-			Expect(p.Explain()).To(Equal("Detect if running in AWS environment"))
+			Expect(p.Explain()).To(Equal("Detect if running in AWS environment (uses its own 2 second timeout, -http-timeout does not apply)"))
 		})
 	})
 
@@ -0,0 +1,120 @@
+package env
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+)
+
+// hostRootEnvVar lets the user point to where the host filesystem is mounted in the container
+const hostRootEnvVar = "NRDIAG_HOST_ROOT"
+
+// defaultHostRoot is where the New Relic Kubernetes charts and the infra agent image mount the host filesystem
+const defaultHostRoot = "/host"
+
+// cgroupRuntimes maps the names found in a cgroup path to the container runtime, in the order they are checked.
+// The Kubernetes pods are checked first since their cgroups also name the runtime running them
+var cgroupRuntimes = []struct {
+	marker  string
+	runtime string
+}{
+	{"kubepods", "kubernetes"},
+	{"libpod", "podman"},
+	{"docker", "docker"},
+	{"crio", "cri-o"},
+	{"containerd", "containerd"},
+	{"lxc", "lxc"},
+}
+
+// ContainerEnvironment - defines the payload of Base/Env/DetectContainer, see tasks.ContainerEnvironment
+type ContainerEnvironment = tasks.ContainerEnvironment
+
+// BaseEnvDetectContainer - This task detects if running inside a container and which runtime runs it
+type BaseEnvDetectContainer struct {
+	getenv     func(string) string
+	readFile   func(string) ([]byte, error)
+	fileExists tasks.FileExistsFunc
+}
+
+// Identifier - This returns the Category, Subcategory and Name of each task
+func (p BaseEnvDetectContainer) Identifier() tasks.Identifier {
+	return tasks.IdentifierFromString("Base/Env/DetectContainer")
+}
+
+// Explain - Returns the help text for each individual task
+func (p BaseEnvDetectContainer) Explain() string {
+	return "Detect if running inside a container"
+}
+
+// Dependencies - Returns the dependencies for each task.
+func (p BaseEnvDetectContainer) Dependencies() []string {
+	return []string{}
+}
+
+// Execute - The core work within each task
+func (p BaseEnvDetectContainer) Execute(options tasks.Options, upstream map[string]tasks.Result) tasks.Result {
+	runtime := p.detectRuntime()
+	if runtime == "" {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "Detected that this is not running inside a container.",
+		}
+	}
+
+	container := ContainerEnvironment{
+		Runtime:  runtime,
+		HostRoot: p.hostRoot(),
+	}
+	summary := "Identified this as running inside a " + runtime + " container."
+	if container.HostRoot != "" {
+		summary += " The host filesystem is mounted at " + container.HostRoot + ", config and log files are also searched there."
+	} else {
+		summary += " Config and log files on the host can't be found unless the host filesystem is mounted at " + defaultHostRoot + " or at the path set by " + hostRootEnvVar + "."
+	}
+	return tasks.Result{
+		Status:  tasks.Info,
+		Summary: summary,
+		Payload: container,
+	}
+}
+
+// detectRuntime - returns the name of the container runtime, empty when not running inside a container
+func (p BaseEnvDetectContainer) detectRuntime() string {
+	if p.fileExists("/.dockerenv") {
+		return "docker"
+	}
+	if p.fileExists("/run/.containerenv") {
+		return "podman"
+	}
+	// set by podman, lxc and systemd-nspawn among others
+	if runtime := p.getenv("container"); runtime != "" {
+		return runtime
+	}
+	for _, cgroupFile := range []string{"/proc/1/cgroup", "/proc/self/cgroup"} {
+		cgroups, err := p.readFile(cgroupFile)
+		if err != nil {
+			continue
+		}
+		for _, cgroupRuntime := range cgroupRuntimes {
+			if strings.Contains(string(cgroups), cgroupRuntime.marker) {
+				return cgroupRuntime.runtime
+			}
+		}
+	}
+	// cgroup v2 hides the container's cgroup path, the pod environment still tells
+	if p.getenv("KUBERNETES_SERVICE_HOST") != "" {
+		return "kubernetes"
+	}
+	return ""
+}
+
+func (p BaseEnvDetectContainer) hostRoot() string {
+	if hostRoot := p.getenv(hostRootEnvVar); hostRoot != "" {
+		return hostRoot
+	}
+	if p.fileExists(filepath.Join(defaultHostRoot, "etc")) {
+		return defaultHostRoot
+	}
+	return ""
+}
@@ -0,0 +1,107 @@
+package env
+
+import (
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Base/Env/DetectContainer", func() {
+	var (
+		p      BaseEnvDetectContainer
+		result tasks.Result
+		env    map[string]string
+		files  map[string]string
+	)
+
+	BeforeEach(func() {
+		env = map[string]string{}
+		files = map[string]string{"/proc/1/cgroup": "0::/init.scope\n"}
+		p = BaseEnvDetectContainer{
+			getenv:   func(key string) string { return env[key] },
+			readFile: mockServiceAccountFiles(files),
+			fileExists: func(path string) bool {
+				_, ok := files[path]
+				return ok
+			},
+		}
+	})
+
+	JustBeforeEach(func() {
+		result = p.Execute(tasks.Options{}, map[string]tasks.Result{})
+	})
+
+	Context("when running on a host", func() {
+		It("Should return a None result", func() {
+			Expect(result.Status).To(Equal(tasks.None))
+		})
+	})
+
+	Context("when /.dockerenv exists", func() {
+		BeforeEach(func() {
+			files["/.dockerenv"] = ""
+		})
+		It("Should detect docker", func() {
+			Expect(result.Status).To(Equal(tasks.Info))
+			Expect(result.Payload).To(Equal(ContainerEnvironment{Runtime: "docker"}))
+			Expect(result.Summary).To(ContainSubstring("unless the host filesystem is mounted at /host or at the path set by NRDIAG_HOST_ROOT"))
+		})
+	})
+
+	Context("when the container env var is set", func() {
+		BeforeEach(func() {
+			env["container"] = "lxc"
+		})
+		It("Should report its value as the runtime", func() {
+			Expect(result.Payload.(ContainerEnvironment).Runtime).To(Equal("lxc"))
+		})
+	})
+
+	Context("when the cgroups belong to a Kubernetes pod", func() {
+		BeforeEach(func() {
+			files["/proc/1/cgroup"] = "12:memory:/kubepods/burstable/pod5d2c/0f1e\n11:cpu:/docker/0f1e\n"
+		})
+		It("Should detect kubernetes", func() {
+			Expect(result.Payload.(ContainerEnvironment).Runtime).To(Equal("kubernetes"))
+		})
+	})
+
+	Context("when the cgroups belong to containerd", func() {
+		BeforeEach(func() {
+			files["/proc/1/cgroup"] = "0::/system.slice/containerd.service/cri-containerd-0f1e.scope\n"
+		})
+		It("Should detect containerd", func() {
+			Expect(result.Payload.(ContainerEnvironment).Runtime).To(Equal("containerd"))
+		})
+	})
+
+	Context("when the host filesystem is mounted at /host", func() {
+		BeforeEach(func() {
+			files["/run/.containerenv"] = ""
+			files["/host/etc"] = ""
+		})
+		It("Should report the host root", func() {
+			Expect(result.Payload).To(Equal(ContainerEnvironment{Runtime: "podman", HostRoot: "/host"}))
+			Expect(result.Summary).To(ContainSubstring("The host filesystem is mounted at /host"))
+		})
+	})
+
+	Context("when NRDIAG_HOST_ROOT is set", func() {
+		BeforeEach(func() {
+			files["/.dockerenv"] = ""
+			env["NRDIAG_HOST_ROOT"] = "/rootfs"
+		})
+		It("Should use it as the host root", func() {
+			Expect(result.Payload.(ContainerEnvironment).HostRoot).To(Equal("/rootfs"))
+		})
+	})
+
+	Describe("HostPaths()", func() {
+		It("Should return nothing without a host root", func() {
+			Expect(ContainerEnvironment{Runtime: "docker"}.HostPaths([]string{"/etc/"})).To(BeNil())
+		})
+		It("Should prefix the paths with the host root", func() {
+			Expect(ContainerEnvironment{Runtime: "docker", HostRoot: "/host"}.HostPaths([]string{"/etc/", "/var/log"})).To(Equal([]string{"/host/etc/", "/host/var/log/"}))
+		})
+	})
+})
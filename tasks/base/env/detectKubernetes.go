@@ -0,0 +1,74 @@
+package env
+
+import (
+	"strings"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+)
+
+// kubernetesServiceAccountDir is where Kubernetes mounts the service account credentials in every pod
+const kubernetesServiceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount/"
+
+// KubernetesEnvironment - defines the payload of Base/Env/DetectKubernetes, the pod this program is running in
+type KubernetesEnvironment struct {
+	Namespace   string
+	PodName     string
+	ServiceHost string
+	ServicePort string
+	// ServiceAccount is true when the service account token is mounted, allowing requests to the API server
+	ServiceAccount bool
+}
+
+// BaseEnvDetectKubernetes - This task detects if running inside a Kubernetes pod
+type BaseEnvDetectKubernetes struct {
+	getenv   func(string) string
+	readFile func(string) ([]byte, error)
+}
+
+// Identifier - This returns the Category, Subcategory and Name of each task
+func (p BaseEnvDetectKubernetes) Identifier() tasks.Identifier {
+	return tasks.IdentifierFromString("Base/Env/DetectKubernetes")
+}
+
+// Explain - Returns the help text for each individual task
+func (p BaseEnvDetectKubernetes) Explain() string {
+	return "Detect if running inside a Kubernetes pod"
+}
+
+// Dependencies - Returns the dependencies for each task.
+func (p BaseEnvDetectKubernetes) Dependencies() []string {
+	return []string{}
+}
+
+// Execute - The core work within each task
+func (p BaseEnvDetectKubernetes) Execute(options tasks.Options, upstream map[string]tasks.Result) tasks.Result {
+	kubernetesEnv := KubernetesEnvironment{
+		ServiceHost: p.getenv("KUBERNETES_SERVICE_HOST"),
+		ServicePort: p.getenv("KUBERNETES_SERVICE_PORT"),
+		// the pod name is the hostname of its containers, unless the pod spec sets one
+		PodName: p.getenv("HOSTNAME"),
+	}
+	if namespace, err := p.readFile(kubernetesServiceAccountDir + "namespace"); err == nil {
+		kubernetesEnv.Namespace = strings.TrimSpace(string(namespace))
+	}
+	if _, err := p.readFile(kubernetesServiceAccountDir + "token"); err == nil {
+		kubernetesEnv.ServiceAccount = true
+	}
+
+	if kubernetesEnv.ServiceHost == "" && kubernetesEnv.Namespace == "" {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "Detected that this is not a Kubernetes pod.",
+		}
+	}
+
+	summary := "Identified this as a Kubernetes pod"
+	if kubernetesEnv.Namespace != "" {
+		summary += " in the " + kubernetesEnv.Namespace + " namespace"
+	}
+	return tasks.Result{
+		Status:  tasks.Info,
+		Summary: summary + ".",
+		Payload: kubernetesEnv,
+	}
+}
@@ -0,0 +1,239 @@
+package env
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"os"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/helpers/httpHelper"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func mockServiceAccountFiles(files map[string]string) func(string) ([]byte, error) {
+	return func(path string) ([]byte, error) {
+		content, ok := files[path]
+		if !ok {
+			return nil, os.ErrNotExist
+		}
+		return []byte(content), nil
+	}
+}
+
+var inClusterFiles = map[string]string{
+	kubernetesServiceAccountDir + "namespace": "shop\n",
+	kubernetesServiceAccountDir + "token":     "service-account-token",
+}
+
+var _ = Describe("Base/Env/DetectKubernetes", func() {
+	var (
+		p      BaseEnvDetectKubernetes
+		result tasks.Result
+		env    map[string]string
+	)
+
+	BeforeEach(func() {
+		env = map[string]string{}
+		p = BaseEnvDetectKubernetes{
+			getenv:   func(key string) string { return env[key] },
+			readFile: mockServiceAccountFiles(map[string]string{}),
+		}
+	})
+
+	JustBeforeEach(func() {
+		result = p.Execute(tasks.Options{}, map[string]tasks.Result{})
+	})
+
+	Context("when running on a host", func() {
+		It("Should return a None result", func() {
+			Expect(result.Status).To(Equal(tasks.None))
+		})
+	})
+
+	Context("when running inside a pod", func() {
+		BeforeEach(func() {
+			env = map[string]string{"KUBERNETES_SERVICE_HOST": "10.0.0.1", "KUBERNETES_SERVICE_PORT": "443", "HOSTNAME": "checkout-5d9c7"}
+			p.readFile = mockServiceAccountFiles(inClusterFiles)
+		})
+		It("Should report the namespace and pod", func() {
+			Expect(result.Status).To(Equal(tasks.Info))
+			Expect(result.Summary).To(Equal("Identified this as a Kubernetes pod in the shop namespace."))
+			Expect(result.Payload).To(Equal(KubernetesEnvironment{
+				Namespace:      "shop",
+				PodName:        "checkout-5d9c7",
+				ServiceHost:    "10.0.0.1",
+				ServicePort:    "443",
+				ServiceAccount: true,
+			}))
+		})
+	})
+
+	Context("when the service account is not mounted", func() {
+		BeforeEach(func() {
+			env = map[string]string{"KUBERNETES_SERVICE_HOST": "10.0.0.1"}
+		})
+		It("Should still detect the pod from the environment", func() {
+			Expect(result.Status).To(Equal(tasks.Info))
+			Expect(result.Payload.(KubernetesEnvironment).ServiceAccount).To(BeFalse())
+		})
+	})
+})
+
+var _ = Describe("Base/Env/KubernetesIntegration", func() {
+	var (
+		p        BaseEnvKubernetesIntegration
+		result   tasks.Result
+		upstream map[string]tasks.Result
+		requests []httpHelper.RequestWrapper
+		statuses map[string]int
+		body     string
+	)
+
+	BeforeEach(func() {
+		requests = nil
+		statuses = map[string]int{}
+		body = `{"items": [
+			{"metadata": {"name": "checkout-5d9c7", "namespace": "shop"}, "status": {"phase": "Running", "containerStatuses": [{"ready": true}]}},
+			{"metadata": {"name": "newrelic-bundle-newrelic-infrastructure-x2x4f", "namespace": "newrelic"}, "status": {"phase": "Running", "containerStatuses": [{"ready": true, "restartCount": 1}, {"ready": true}]}},
+			{"metadata": {"name": "newrelic-bundle-nri-kube-events-7c8d4", "namespace": "newrelic"}, "status": {"phase": "Running", "containerStatuses": [{"ready": true}]}}
+		]}`
+		p = BaseEnvKubernetesIntegration{
+			httpGetter: func(wrapper httpHelper.RequestWrapper) (*http.Response, error) {
+				requests = append(requests, wrapper)
+				status, ok := statuses[wrapper.URL]
+				if !ok {
+					status = http.StatusOK
+				}
+				return &http.Response{
+					StatusCode: status,
+					Status:     http.StatusText(status),
+					Body:       ioutil.NopCloser(bytes.NewReader([]byte(body))),
+				}, nil
+			},
+			readFile: mockServiceAccountFiles(inClusterFiles),
+		}
+		upstream = map[string]tasks.Result{
+			"Base/Env/DetectKubernetes": {
+				Status:  tasks.Info,
+				Payload: KubernetesEnvironment{Namespace: "shop", ServiceHost: "10.0.0.1", ServicePort: "443", ServiceAccount: true},
+			},
+		}
+	})
+
+	JustBeforeEach(func() {
+		result = p.Execute(tasks.Options{}, upstream)
+	})
+
+	Context("when not running inside a pod", func() {
+		BeforeEach(func() {
+			upstream["Base/Env/DetectKubernetes"] = tasks.Result{Status: tasks.None}
+		})
+		It("Should return a None result", func() {
+			Expect(result.Status).To(Equal(tasks.None))
+			Expect(requests).To(BeEmpty())
+		})
+	})
+
+	Context("when the integration pods are ready", func() {
+		It("Should list the pods of the cluster with the service account token", func() {
+			Expect(requests).To(HaveLen(1))
+			Expect(requests[0].URL).To(Equal("https://10.0.0.1:443/api/v1/pods"))
+			Expect(requests[0].Headers["Authorization"]).To(Equal("Bearer service-account-token"))
+			Expect(requests[0].BypassProxy).To(BeTrue())
+		})
+		It("Should report only the New Relic pods as healthy", func() {
+			Expect(result.Status).To(Equal(tasks.Success))
+			Expect(result.Payload).To(Equal([]KubernetesPod{
+				{Namespace: "newrelic", Name: "newrelic-bundle-newrelic-infrastructure-x2x4f", Phase: "Running", Ready: true, Restarts: 1},
+				{Namespace: "newrelic", Name: "newrelic-bundle-nri-kube-events-7c8d4", Phase: "Running", Ready: true},
+			}))
+		})
+	})
+
+	Context("when an integration pod is not ready", func() {
+		BeforeEach(func() {
+			body = `{"items": [{"metadata": {"name": "nri-metadata-injection-6f7", "namespace": "newrelic"}, "status": {"phase": "Pending"}}]}`
+		})
+		It("Should return a Warning result naming the pod", func() {
+			Expect(result.Status).To(Equal(tasks.Warning))
+			Expect(result.Summary).To(Equal("1 of 1 New Relic integration pods are not ready:\nnewrelic/nri-metadata-injection-6f7 (Pending, 0 restarts)"))
+		})
+	})
+
+	Context("when the Jobs of the charts have completed", func() {
+		BeforeEach(func() {
+			body = `{"items": [
+				{"metadata": {"name": "newrelic-bundle-nri-kube-events-7c8d4", "namespace": "newrelic"}, "status": {"phase": "Running", "containerStatuses": [{"ready": true}]}},
+				{"metadata": {"name": "nri-metadata-injection-admission-create-8kq2p", "namespace": "newrelic", "ownerReferences": [{"kind": "Job", "name": "nri-metadata-injection-admission-create"}]}, "status": {"phase": "Succeeded", "containerStatuses": [{"ready": false}]}},
+				{"metadata": {"name": "nri-metadata-injection-admission-patch-1", "namespace": "newrelic", "creationTimestamp": "2024-05-01T10:00:00Z", "ownerReferences": [{"kind": "Job", "name": "nri-metadata-injection-admission-patch"}]}, "status": {"phase": "Failed", "containerStatuses": [{"ready": false, "restartCount": 0}]}},
+				{"metadata": {"name": "nri-metadata-injection-admission-patch-2", "namespace": "newrelic", "creationTimestamp": "2024-05-01T10:01:00Z", "ownerReferences": [{"kind": "Job", "name": "nri-metadata-injection-admission-patch"}]}, "status": {"phase": "Succeeded", "containerStatuses": [{"ready": false}]}}
+			]}`
+		})
+		It("Should leave out the completed pods and the failed attempts retried since", func() {
+			Expect(result.Status).To(Equal(tasks.Success))
+			Expect(result.Payload).To(Equal([]KubernetesPod{
+				{Namespace: "newrelic", Name: "newrelic-bundle-nri-kube-events-7c8d4", Phase: "Running", Ready: true},
+			}))
+		})
+	})
+
+	Context("when the last attempt of a Job failed", func() {
+		BeforeEach(func() {
+			body = `{"items": [
+				{"metadata": {"name": "nri-metadata-injection-admission-patch-1", "namespace": "newrelic", "creationTimestamp": "2024-05-01T10:00:00Z", "ownerReferences": [{"kind": "Job", "name": "nri-metadata-injection-admission-patch"}]}, "status": {"phase": "Failed"}},
+				{"metadata": {"name": "nri-metadata-injection-admission-patch-2", "namespace": "newrelic", "creationTimestamp": "2024-05-01T10:01:00Z", "ownerReferences": [{"kind": "Job", "name": "nri-metadata-injection-admission-patch"}]}, "status": {"phase": "Failed"}}
+			]}`
+		})
+		It("Should only report the last failed attempt", func() {
+			Expect(result.Status).To(Equal(tasks.Warning))
+			Expect(result.Summary).To(Equal("1 of 1 New Relic integration pods are not ready:\nnewrelic/nri-metadata-injection-admission-patch-2 (Failed, 0 restarts)"))
+		})
+	})
+
+	Context("when the service account can only list the pods of its namespace", func() {
+		BeforeEach(func() {
+			statuses["https://10.0.0.1:443/api/v1/pods"] = http.StatusForbidden
+		})
+		It("Should fall back to the namespace", func() {
+			Expect(requests).To(HaveLen(2))
+			Expect(requests[1].URL).To(Equal("https://10.0.0.1:443/api/v1/namespaces/shop/pods"))
+			Expect(result.Status).To(Equal(tasks.Success))
+		})
+	})
+
+	Context("when RBAC prevents listing pods", func() {
+		BeforeEach(func() {
+			statuses["https://10.0.0.1:443/api/v1/pods"] = http.StatusForbidden
+			statuses["https://10.0.0.1:443/api/v1/namespaces/shop/pods"] = http.StatusForbidden
+		})
+		It("Should degrade to an Info result", func() {
+			Expect(result.Status).To(Equal(tasks.Info))
+			Expect(result.Summary).To(ContainSubstring("not allowed to list pods"))
+		})
+	})
+
+	Context("when the API server can't be reached", func() {
+		BeforeEach(func() {
+			p.httpGetter = func(wrapper httpHelper.RequestWrapper) (*http.Response, error) {
+				return nil, errors.New("dial tcp 10.0.0.1:443: i/o timeout")
+			}
+		})
+		It("Should return an Error result", func() {
+			Expect(result.Status).To(Equal(tasks.Error))
+			Expect(result.Summary).To(Equal("Unable to list the pods from the Kubernetes API server: dial tcp 10.0.0.1:443: i/o timeout"))
+		})
+	})
+
+	Context("when no service account is mounted", func() {
+		BeforeEach(func() {
+			upstream["Base/Env/DetectKubernetes"] = tasks.Result{Status: tasks.Info, Payload: KubernetesEnvironment{ServiceHost: "10.0.0.1"}}
+		})
+		It("Should return an Info result without requests", func() {
+			Expect(result.Status).To(Equal(tasks.Info))
+			Expect(requests).To(BeEmpty())
+		})
+	})
+})
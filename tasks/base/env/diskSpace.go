@@ -0,0 +1,199 @@
+package env
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/config"
+	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	"github.com/shirou/gopsutil/v3/disk"
+)
+
+const (
+	// DiskSpaceWarningBytes - below this much free space the output may not fit once the logs are added
+	DiskSpaceWarningBytes uint64 = 1 << 30
+	// DiskSpaceCriticalBytes - below this much free space the log collection tasks do not copy any file
+	DiskSpaceCriticalBytes uint64 = 100 << 20
+)
+
+// LogFilesEstimator - returns the size and the count of the log files the log collection tasks would copy. Set by
+// tasks/base/log, which finds them and imports this package
+var LogFilesEstimator func(options tasks.Options, upstream map[string]tasks.Result) (uint64, int)
+
+// DiskSpace - free space of the volume the output is written to, and the estimated size of the files collected in it
+type DiskSpace struct {
+	OutputPath string
+	VolumePath string
+	FreeBytes  uint64
+	TotalBytes uint64
+	// EstimatedBytes is the size of the config and log files found before they are collected, in EstimatedFiles files
+	EstimatedBytes uint64
+	EstimatedFiles int
+}
+
+// CriticallyLow - whether there is too little space left to collect logs at all
+func (d DiskSpace) CriticallyLow() bool {
+	return d.FreeBytes < DiskSpaceCriticalBytes
+}
+
+// Fits - whether files of the estimated size can be collected while keeping the critical margin free
+func (d DiskSpace) Fits(estimatedBytes uint64) bool {
+	return !d.CriticallyLow() && estimatedBytes <= d.FreeBytes-DiskSpaceCriticalBytes
+}
+
+// FitsBundle - whether the estimated config and log files can be collected while keeping the critical margin free
+func (d DiskSpace) FitsBundle() bool {
+	return d.Fits(d.EstimatedBytes)
+}
+
+// BaseEnvDiskSpace - This task checks the free space of the volume the output is written to against the estimated size of the files collected
+type BaseEnvDiskSpace struct {
+	outputPath   func() string
+	diskUsage    func(string) (*disk.UsageStat, error)
+	estimateLogs func(tasks.Options, map[string]tasks.Result) (uint64, int)
+	fileSize     func(string) (uint64, bool)
+}
+
+// Identifier - This returns the Category, Subcategory and Name of each task
+func (p BaseEnvDiskSpace) Identifier() tasks.Identifier {
+	return tasks.IdentifierFromString("Base/Env/DiskSpace")
+}
+
+// Explain - Returns the help text for each individual task
+func (p BaseEnvDiskSpace) Explain() string {
+	return "Check there is enough free disk space to write the output and the collected config and log files"
+}
+
+// Dependencies - the tasks finding the config and log files, whose size is estimated before they are collected
+func (p BaseEnvDiskSpace) Dependencies() []string {
+	return []string{
+		"Base/Env/CollectEnvVars",
+		"Base/Env/CollectSysProps",
+		"Base/Env/DetectContainer",
+		"Base/Config/Collect",
+		"Base/Config/Validate",
+	}
+}
+
+// Execute - The core work within each task
+func (p BaseEnvDiskSpace) Execute(options tasks.Options, upstream map[string]tasks.Result) tasks.Result {
+	outputPath := p.outputPath()
+	volumePath := existingParent(outputPath)
+	usage, err := p.diskUsage(volumePath)
+	if err != nil {
+		log.Debug("Unable to read the disk usage of", volumePath, ":", err)
+		return tasks.Result{
+			Status:  tasks.Error,
+			Summary: "Unable to read the free disk space of " + volumePath + ": " + err.Error(),
+		}
+	}
+
+	space := DiskSpace{
+		OutputPath: outputPath,
+		VolumePath: volumePath,
+		FreeBytes:  usage.Free,
+		TotalBytes: usage.Total,
+	}
+	space.EstimatedBytes, space.EstimatedFiles = p.estimateBundle(options, upstream)
+	summary := fmt.Sprintf("%s of %s are free on the volume of the output path %s, %s of config and log files were found in %d file(s).", FormatBytes(space.FreeBytes), FormatBytes(space.TotalBytes), outputPath, FormatBytes(space.EstimatedBytes), space.EstimatedFiles)
+	if space.CriticallyLow() {
+		return tasks.Result{
+			Status:  tasks.Warning,
+			Summary: summary + fmt.Sprintf(" Log files will not be collected below %s of free space. Free up disk space or use -output-path to write the results to another volume.", FormatBytes(DiskSpaceCriticalBytes)),
+			Payload: space,
+		}
+	}
+	if !space.FitsBundle() {
+		return tasks.Result{
+			Status:  tasks.Warning,
+			Summary: summary + " They do not fit in the free space, log files will not be collected. Free up disk space or use -output-path to write the results to another volume.",
+			Payload: space,
+		}
+	}
+	if space.FreeBytes < DiskSpaceWarningBytes {
+		return tasks.Result{
+			Status:  tasks.Warning,
+			Summary: summary + " Large log files may not fit in the output. Free up disk space or use -output-path to write the results to another volume.",
+			Payload: space,
+		}
+	}
+	return tasks.Result{
+		Status:  tasks.Info,
+		Summary: summary,
+		Payload: space,
+	}
+}
+
+// estimateBundle - the size and count of the config files Base/Config/Collect found and of the log files the log
+// collection tasks would copy
+func (p BaseEnvDiskSpace) estimateBundle(options tasks.Options, upstream map[string]tasks.Result) (uint64, int) {
+	var estimatedBytes uint64
+	var files int
+	for _, envelope := range upstream["Base/Config/Collect"].FilesToCopy {
+		if size, ok := p.fileSize(envelope.Path); ok {
+			estimatedBytes += size
+			files++
+		}
+	}
+	if p.estimateLogs != nil {
+		logBytes, logFiles := p.estimateLogs(options, upstream)
+		estimatedBytes += logBytes
+		files += logFiles
+	}
+	return estimatedBytes, files
+}
+
+// estimateLogFiles - calls LogFilesEstimator, the logs are not estimated when tasks/base/log did not set it
+func estimateLogFiles(options tasks.Options, upstream map[string]tasks.Result) (uint64, int) {
+	if LogFilesEstimator == nil {
+		return 0, 0
+	}
+	return LogFilesEstimator(options, upstream)
+}
+
+func statFileSize(path string) (uint64, bool) {
+	info, err := os.Stat(path)
+	if err != nil || info.IsDir() {
+		return 0, false
+	}
+	return uint64(info.Size()), true
+}
+
+// existingParent - the output directory is only created when the results are written, so the usage is read from its
+// closest existing parent
+func existingParent(path string) string {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return path
+	}
+	for {
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+		parent := filepath.Dir(path)
+		if parent == path {
+			return path
+		}
+		path = parent
+	}
+}
+
+// FormatBytes - returns a byte count in the largest unit it reaches, e.g. 1.5 MB
+func FormatBytes(count uint64) string {
+	const unit = 1024
+	if count < unit {
+		return fmt.Sprintf("%d B", count)
+	}
+	div, exp := uint64(unit), 0
+	for n := count / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(count)/float64(div), "KMGTPE"[exp])
+}
+
+func configOutputPath() string {
+	return config.Flags.OutputPath
+}
@@ -0,0 +1,127 @@
+package env
+
+import (
+	"errors"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/shirou/gopsutil/v3/disk"
+)
+
+var _ = Describe("Base/Env/DiskSpace", func() {
+	var (
+		p        BaseEnvDiskSpace
+		result   tasks.Result
+		usage    *disk.UsageStat
+		usageErr error
+		upstream map[string]tasks.Result
+		logBytes uint64
+	)
+
+	BeforeEach(func() {
+		usage = &disk.UsageStat{Free: 20 << 30, Total: 100 << 30}
+		usageErr = nil
+		upstream = map[string]tasks.Result{}
+		logBytes = 0
+		p = BaseEnvDiskSpace{
+			outputPath: func() string { return "./" },
+			diskUsage: func(string) (*disk.UsageStat, error) {
+				return usage, usageErr
+			},
+			estimateLogs: func(tasks.Options, map[string]tasks.Result) (uint64, int) {
+				if logBytes == 0 {
+					return 0, 0
+				}
+				return logBytes, 2
+			},
+			fileSize: func(path string) (uint64, bool) {
+				return 1 << 20, path != "/missing/newrelic.yml"
+			},
+		}
+	})
+
+	JustBeforeEach(func() {
+		result = p.Execute(tasks.Options{}, upstream)
+	})
+
+	Context("when there is plenty of free space", func() {
+		It("Should report the free space", func() {
+			Expect(result.Status).To(Equal(tasks.Info))
+			Expect(result.Summary).To(ContainSubstring("20.0 GB of 100.0 GB are free"))
+			space := result.Payload.(DiskSpace)
+			Expect(space.FreeBytes).To(Equal(uint64(20 << 30)))
+			Expect(space.TotalBytes).To(Equal(uint64(100 << 30)))
+			Expect(space.Fits(1 << 30)).To(BeTrue())
+		})
+	})
+
+	Context("when less than the warning threshold is free", func() {
+		BeforeEach(func() {
+			usage.Free = 500 << 20
+		})
+		It("Should return a warning", func() {
+			Expect(result.Status).To(Equal(tasks.Warning))
+			Expect(result.Summary).To(ContainSubstring("Large log files may not fit"))
+			space := result.Payload.(DiskSpace)
+			Expect(space.CriticallyLow()).To(BeFalse())
+			Expect(space.Fits(300 << 20)).To(BeTrue())
+			Expect(space.Fits(450 << 20)).To(BeFalse())
+		})
+	})
+
+	Context("when the free space is dangerously low", func() {
+		BeforeEach(func() {
+			usage.Free = 10 << 20
+		})
+		It("Should return a warning the logs will not be collected", func() {
+			Expect(result.Status).To(Equal(tasks.Warning))
+			Expect(result.Summary).To(ContainSubstring("Log files will not be collected below 100.0 MB"))
+			Expect(result.Payload.(DiskSpace).Fits(0)).To(BeFalse())
+		})
+	})
+
+	Context("when config and log files were found", func() {
+		BeforeEach(func() {
+			usage.Free = 5 << 30
+			logBytes = 2 << 30
+			upstream["Base/Config/Collect"] = tasks.Result{
+				Status: tasks.Success,
+				FilesToCopy: []tasks.FileCopyEnvelope{
+					{Path: "/app/newrelic.yml"},
+					{Path: "/missing/newrelic.yml"},
+				},
+			}
+		})
+		It("Should report their estimated size", func() {
+			Expect(result.Status).To(Equal(tasks.Info))
+			Expect(result.Summary).To(ContainSubstring("2.0 GB of config and log files were found in 3 file(s)"))
+			space := result.Payload.(DiskSpace)
+			Expect(space.EstimatedBytes).To(Equal(uint64(2<<30 + 1<<20)))
+			Expect(space.EstimatedFiles).To(Equal(3))
+			Expect(space.FitsBundle()).To(BeTrue())
+		})
+	})
+
+	Context("when the estimated files do not fit in the free space", func() {
+		BeforeEach(func() {
+			usage.Free = 2 << 30
+			logBytes = 3 << 30
+		})
+		It("Should return a warning the logs will not be collected", func() {
+			Expect(result.Status).To(Equal(tasks.Warning))
+			Expect(result.Summary).To(ContainSubstring("3.0 GB of config and log files were found in 2 file(s). They do not fit in the free space"))
+			Expect(result.Payload.(DiskSpace).FitsBundle()).To(BeFalse())
+		})
+	})
+
+	Context("when the disk usage cannot be read", func() {
+		BeforeEach(func() {
+			usageErr = errors.New("permission denied")
+		})
+		It("Should return an error", func() {
+			Expect(result.Status).To(Equal(tasks.Error))
+			Expect(result.Summary).To(ContainSubstring("permission denied"))
+		})
+	})
+})
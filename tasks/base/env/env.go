@@ -1,11 +1,14 @@
 package env
 
 import (
+	"io/ioutil"
+	"os"
 	"path/filepath"
 	"runtime"
 
 	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
 	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	"github.com/shirou/gopsutil/v3/disk"
 )
 
 // RegisterWith - will register any plugins in this package
@@ -16,7 +19,9 @@ func RegisterWith(registrationFunc func(tasks.Task, bool)) {
 		HostInfoProvider:            NewHostInfo,
 		HostInfoProviderWithContext: NewHostInfoWithContext,
 	}, true)
-	registrationFunc(BaseEnvCollectEnvVars{}, true)
+	registrationFunc(BaseEnvCollectEnvVars{
+		getShellEnvVars: tasks.GetShellEnvVars,
+	}, true)
 	registrationFunc(BaseEnvCollectSysProps{}, true)
 	registrationFunc(BaseEnvDetectAWS{
 		httpGetter: tasks.HTTPRequester,
@@ -26,4 +31,40 @@ func RegisterWith(registrationFunc func(tasks.Task, bool)) {
 		evalSymlink: filepath.EvalSymlinks,
 	}, true)
 	registrationFunc(BaseEnvDetectAzure{}, true)
+	registrationFunc(BaseEnvDetectContainer{
+		getenv:     os.Getenv,
+		readFile:   ioutil.ReadFile,
+		fileExists: tasks.FileExists,
+	}, true)
+	registrationFunc(BaseEnvDetectKubernetes{
+		getenv:   os.Getenv,
+		readFile: ioutil.ReadFile,
+	}, true)
+	registrationFunc(BaseEnvKubernetesIntegration{
+		httpGetter: tasks.HTTPRequester,
+		readFile:   ioutil.ReadFile,
+	}, true)
+	registrationFunc(BaseEnvConflictingAgents{
+		getJavaProcArgs: tasks.GetJavaProcArgs,
+		listProcesses:   listProcesses,
+		fileExists:      tasks.FileExists,
+		evalSymlinks:    filepath.EvalSymlinks,
+		runtimeOs:       runtime.GOOS,
+	}, true)
+	registrationFunc(BaseEnvDiskSpace{
+		outputPath:   configOutputPath,
+		diskUsage:    disk.Usage,
+		estimateLogs: estimateLogFiles,
+		fileSize:     statFileSize,
+	}, true)
+	registrationFunc(BaseEnvClockSkew{}, true)
+	registrationFunc(BaseEnvHostIdentity{
+		hostname:   os.Hostname,
+		lookupFQDN: lookupFQDN,
+	}, true)
+	registrationFunc(BaseEnvResourceLimits{
+		runtimeOs:      runtime.GOOS,
+		agentProcesses: newRelicAgentProcesses,
+		processLimits:  readProcessLimits,
+	}, true)
 }
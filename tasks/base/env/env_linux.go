@@ -1,6 +1,8 @@
 package env
 
 import (
+	"os"
+
 	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
 	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
 )
@@ -12,4 +14,9 @@ func RegisterLinuxWith(registrationFunc func(tasks.Task, bool)) {
 	registrationFunc(BaseEnvCheckSELinux{
 		cmdExec: tasks.CmdExecutor,
 	}, true)
+	registrationFunc(BaseEnvMAC{
+		readFile:       os.ReadFile,
+		tailFile:       tailFile,
+		agentProcesses: newRelicProcessLabels,
+	}, true)
 }
@@ -0,0 +1,213 @@
+package env
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	baseConfig "github.com/newrelic/newrelic-diagnostics-cli/tasks/base/config"
+)
+
+// The settings naming the host. override_hostname replaces the hostname the infrastructure agent reports, which APM
+// entities are linked on; display_name only changes the name shown
+const (
+	hostnameSetting        = "override_hostname"
+	displayNameSetting     = "display_name"
+	overrideHostnameEnvVar = "NRIA_OVERRIDE_HOSTNAME"
+	infraDisplayNameEnvVar = "NRIA_DISPLAY_NAME"
+	apmDisplayNameEnvVar   = "NEW_RELIC_PROCESS_HOST_DISPLAY_NAME"
+	apmDisplayNameSysProp  = "-Dnewrelic.config.process_host.display_name"
+	hostIdentityDocURL     = "https://docs.newrelic.com/docs/infrastructure/install-infrastructure-agent/configuration/infrastructure-agent-configuration-settings/#override-hostname"
+)
+
+// HostName - a name of the host set in an agent config file, environment variable or system property
+type HostName struct {
+	Setting string
+	Value   string
+	Source  string
+}
+
+// HostIdentity - the names the host is known by: its OS hostname, its FQDN and the names the agents are configured with
+type HostIdentity struct {
+	Hostname   string
+	FQDN       string `json:",omitempty"`
+	Configured []HostName
+}
+
+// BaseEnvHostIdentity - This task checks that the agents on the host report consistent host names
+type BaseEnvHostIdentity struct {
+	hostname   func() (string, error)
+	lookupFQDN func(string) string
+}
+
+// Identifier - This returns the Category, Subcategory and Name of each task
+func (p BaseEnvHostIdentity) Identifier() tasks.Identifier {
+	return tasks.IdentifierFromString("Base/Env/HostIdentity")
+}
+
+// Explain - Returns the help text for each individual task
+func (p BaseEnvHostIdentity) Explain() string {
+	return "Check that the hostname, FQDN and agent configured host names are consistent, so APM and Infrastructure entities are linked"
+}
+
+// Dependencies - Returns the dependencies for each task.
+func (p BaseEnvHostIdentity) Dependencies() []string {
+	return []string{
+		"Base/Config/Validate",
+		"Base/Env/CollectEnvVars",
+		"Base/Env/CollectSysProps",
+	}
+}
+
+// Execute - The core work within each task
+func (p BaseEnvHostIdentity) Execute(options tasks.Options, upstream map[string]tasks.Result) tasks.Result {
+	hostname, err := p.hostname()
+	if err != nil {
+		return tasks.Result{
+			Status:  tasks.Error,
+			Summary: "Unable to read the hostname of this host: " + err.Error(),
+		}
+	}
+	var configElements []baseConfig.ValidateElement
+	if upstream["Base/Config/Validate"].HasPayload() {
+		var ok bool
+		configElements, ok = upstream["Base/Config/Validate"].Payload.([]baseConfig.ValidateElement)
+		if !ok {
+			return tasks.Result{
+				Status:  tasks.Error,
+				Summary: tasks.AssertionErrorSummary,
+			}
+		}
+	}
+
+	identity := HostIdentity{
+		Hostname:   hostname,
+		FQDN:       p.lookupFQDN(hostname),
+		Configured: configuredHostNames(configElements, upstream),
+	}
+
+	problems := hostIdentityProblems(identity)
+	if len(problems) > 0 {
+		return tasks.Result{
+			Status:  tasks.Warning,
+			Summary: "The host is not reported under a consistent name, APM and Infrastructure entities may not be linked:\n\t" + strings.Join(problems, "\n\t") + "\n" + hostIdentitySummary(identity),
+			URL:     hostIdentityDocURL,
+			Payload: identity,
+		}
+	}
+	return tasks.Result{
+		Status:  tasks.Info,
+		Summary: hostIdentitySummary(identity),
+		Payload: identity,
+	}
+}
+
+// configuredHostNames - the host names set in the agent config files, the NRIA_* and NEW_RELIC_* environment variables
+// and the Java system properties, sorted by setting and source
+func configuredHostNames(configElements []baseConfig.ValidateElement, upstream map[string]tasks.Result) []HostName {
+	names := []HostName{}
+	for _, configFile := range configElements {
+		source := configFile.Config.FilePath + configFile.Config.FileName
+		for _, setting := range []string{hostnameSetting, displayNameSetting} {
+			for _, key := range configFile.ParsedResult.FindKey(setting) {
+				if value := strings.TrimSpace(key.Value()); key.IsLeaf() && value != "" {
+					names = append(names, HostName{Setting: key.PathAndKey(), Value: value, Source: source})
+				}
+			}
+		}
+	}
+
+	if envVars, ok := upstream["Base/Env/CollectEnvVars"].Payload.(map[string]string); ok {
+		for _, envVar := range []string{overrideHostnameEnvVar, infraDisplayNameEnvVar, apmDisplayNameEnvVar} {
+			if value := strings.TrimSpace(envVars[envVar]); value != "" {
+				names = append(names, HostName{Setting: envVar, Value: value, Source: "environment"})
+			}
+		}
+	}
+
+	if sysProps, ok := upstream["Base/Env/CollectSysProps"].Payload.([]tasks.ProcIDSysProps); ok {
+		for _, procSysProps := range sysProps {
+			if value := strings.TrimSpace(procSysProps.SysPropsKeyToVal[apmDisplayNameSysProp]); value != "" {
+				names = append(names, HostName{Setting: apmDisplayNameSysProp, Value: value, Source: fmt.Sprintf("process %d", procSysProps.ProcID)})
+			}
+		}
+	}
+
+	sort.SliceStable(names, func(i, j int) bool {
+		if names[i].Setting != names[j].Setting {
+			return names[i].Setting < names[j].Setting
+		}
+		return names[i].Source < names[j].Source
+	})
+	return names
+}
+
+// hostIdentityProblems - the disagreements that break the link between APM and Infrastructure entities: APM agents
+// report the OS hostname, so the infrastructure agent reporting another one is not linked to them, and agents using
+// the FQDN don't match those using the short hostname when it isn't the first label of the FQDN
+func hostIdentityProblems(identity HostIdentity) []string {
+	var problems []string
+	if identity.FQDN != "" && !strings.EqualFold(identity.FQDN, identity.Hostname) && !strings.EqualFold(firstLabel(identity.FQDN), firstLabel(identity.Hostname)) {
+		problems = append(problems, fmt.Sprintf("the hostname %s is not the short name of the FQDN %s", identity.Hostname, identity.FQDN))
+	}
+	for _, name := range identity.Configured {
+		if !isHostnameSetting(name.Setting) {
+			continue
+		}
+		if !strings.EqualFold(name.Value, identity.Hostname) && !strings.EqualFold(name.Value, identity.FQDN) {
+			problems = append(problems, fmt.Sprintf("%s in %s is set to %s, while APM agents report %s", name.Setting, name.Source, name.Value, identity.Hostname))
+		}
+	}
+	return problems
+}
+
+func isHostnameSetting(setting string) bool {
+	return setting == overrideHostnameEnvVar || strings.HasSuffix(setting, hostnameSetting)
+}
+
+func firstLabel(name string) string {
+	return strings.SplitN(name, ".", 2)[0]
+}
+
+func hostIdentitySummary(identity HostIdentity) string {
+	summary := fmt.Sprintf("%-12v%s", "Hostname:", identity.Hostname)
+	if identity.FQDN != "" {
+		summary += fmt.Sprintf("\n%-12v%s", "FQDN:", identity.FQDN)
+	}
+	for _, name := range identity.Configured {
+		summary += fmt.Sprintf("\n%s = %s (%s)", name.Setting, name.Value, name.Source)
+	}
+	return summary
+}
+
+// lookupFQDN - the fully qualified name of the host from its canonical DNS name, or the reverse lookup of its
+// addresses. Empty when neither has a domain
+func lookupFQDN(hostname string) string {
+	if cname, err := net.LookupCNAME(hostname); err == nil {
+		if name := strings.TrimSuffix(cname, "."); strings.Contains(name, ".") {
+			return name
+		}
+	}
+	addresses, err := net.LookupHost(hostname)
+	if err != nil {
+		return ""
+	}
+	for _, address := range addresses {
+		// the loopback addresses resolve to localhost, not to the name of the host
+		if ip := net.ParseIP(address); ip == nil || ip.IsLoopback() {
+			continue
+		}
+		names, err := net.LookupAddr(address)
+		if err != nil {
+			continue
+		}
+		for _, name := range names {
+			if name = strings.TrimSuffix(name, "."); strings.Contains(name, ".") {
+				return name
+			}
+		}
+	}
+	return ""
+}
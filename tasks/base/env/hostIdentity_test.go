@@ -0,0 +1,103 @@
+package env
+
+import (
+	"errors"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	baseConfig "github.com/newrelic/newrelic-diagnostics-cli/tasks/base/config"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func hostConfigElement(fileName string, settings ...tasks.ValidateBlob) baseConfig.ValidateElement {
+	return baseConfig.ValidateElement{
+		Config:       baseConfig.ConfigElement{FileName: fileName, FilePath: "/etc/"},
+		ParsedResult: tasks.ValidateBlob{Children: settings},
+	}
+}
+
+var _ = Describe("Base/Env/HostIdentity", func() {
+	var (
+		p        BaseEnvHostIdentity
+		result   tasks.Result
+		upstream map[string]tasks.Result
+		fqdn     string
+	)
+
+	BeforeEach(func() {
+		fqdn = "web-01.example.com"
+		upstream = map[string]tasks.Result{}
+		p = BaseEnvHostIdentity{
+			hostname:   func() (string, error) { return "web-01", nil },
+			lookupFQDN: func(string) string { return fqdn },
+		}
+	})
+
+	JustBeforeEach(func() {
+		result = p.Execute(tasks.Options{}, upstream)
+	})
+
+	Context("when the host names are consistent", func() {
+		BeforeEach(func() {
+			upstream["Base/Config/Validate"] = tasks.Result{Status: tasks.Success, Payload: []baseConfig.ValidateElement{
+				hostConfigElement("newrelic-infra.yml",
+					tasks.ValidateBlob{Key: "display_name", RawValue: "Web server"},
+					tasks.ValidateBlob{Key: "override_hostname", RawValue: "WEB-01.example.com"},
+				),
+			}}
+			upstream["Base/Env/CollectEnvVars"] = tasks.Result{Payload: map[string]string{"NEW_RELIC_PROCESS_HOST_DISPLAY_NAME": "Web"}}
+		})
+		It("Should return an Info result listing every name", func() {
+			Expect(result.Status).To(Equal(tasks.Info))
+			Expect(result.Payload).To(Equal(HostIdentity{
+				Hostname: "web-01",
+				FQDN:     "web-01.example.com",
+				Configured: []HostName{
+					{Setting: "/display_name", Value: "Web server", Source: "/etc/newrelic-infra.yml"},
+					{Setting: "/override_hostname", Value: "WEB-01.example.com", Source: "/etc/newrelic-infra.yml"},
+					{Setting: "NEW_RELIC_PROCESS_HOST_DISPLAY_NAME", Value: "Web", Source: "environment"},
+				},
+			}))
+		})
+	})
+
+	Context("when the infrastructure agent overrides the hostname", func() {
+		BeforeEach(func() {
+			upstream["Base/Env/CollectEnvVars"] = tasks.Result{Payload: map[string]string{"NRIA_OVERRIDE_HOSTNAME": "frontend"}}
+		})
+		It("Should return a Warning result", func() {
+			Expect(result.Status).To(Equal(tasks.Warning))
+			Expect(result.Summary).To(ContainSubstring("NRIA_OVERRIDE_HOSTNAME in environment is set to frontend, while APM agents report web-01"))
+			Expect(result.URL).NotTo(BeEmpty())
+		})
+	})
+
+	Context("when the hostname is not the short name of the FQDN", func() {
+		BeforeEach(func() {
+			fqdn = "ip-10-0-0-12.ec2.internal"
+		})
+		It("Should return a Warning result", func() {
+			Expect(result.Status).To(Equal(tasks.Warning))
+			Expect(result.Summary).To(ContainSubstring("the hostname web-01 is not the short name of the FQDN ip-10-0-0-12.ec2.internal"))
+		})
+	})
+
+	Context("when the host has no domain", func() {
+		BeforeEach(func() {
+			fqdn = ""
+		})
+		It("Should return an Info result", func() {
+			Expect(result.Status).To(Equal(tasks.Info))
+			Expect(result.Summary).NotTo(ContainSubstring("FQDN"))
+		})
+	})
+
+	Context("when the hostname can't be read", func() {
+		BeforeEach(func() {
+			p.hostname = func() (string, error) { return "", errors.New("no hostname") }
+		})
+		It("Should return an Error result", func() {
+			Expect(result.Status).To(Equal(tasks.Error))
+		})
+	})
+})
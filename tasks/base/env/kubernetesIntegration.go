@@ -0,0 +1,260 @@
+package env
+
+import (
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/helpers/httpHelper"
+	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+)
+
+// newRelicPodPrefixes are the name prefixes of the pods deployed by the New Relic Kubernetes charts (nri-bundle)
+var newRelicPodPrefixes = []string{"newrelic-", "nri-"}
+
+// KubernetesPod - a New Relic integration pod and whether it appears healthy
+type KubernetesPod struct {
+	Namespace string
+	Name      string
+	Phase     string
+	// Ready is true when the pod is running and all of its containers are ready
+	Ready    bool
+	Restarts int
+}
+
+// kubernetesPodList is the part of the API server's PodList this task reads
+type kubernetesPodList struct {
+	Items []kubernetesPodItem `json:"items"`
+}
+
+type kubernetesPodItem struct {
+	Metadata struct {
+		Name              string    `json:"name"`
+		Namespace         string    `json:"namespace"`
+		CreationTimestamp time.Time `json:"creationTimestamp"`
+		OwnerReferences   []struct {
+			Kind string `json:"kind"`
+			Name string `json:"name"`
+		} `json:"ownerReferences"`
+	} `json:"metadata"`
+	Status struct {
+		Phase             string `json:"phase"`
+		ContainerStatuses []struct {
+			Ready        bool `json:"ready"`
+			RestartCount int  `json:"restartCount"`
+		} `json:"containerStatuses"`
+	} `json:"status"`
+}
+
+// job - the namespace and name of the Job that created the pod, empty for the pods of a Deployment or a DaemonSet
+func (item kubernetesPodItem) job() string {
+	for _, owner := range item.Metadata.OwnerReferences {
+		if owner.Kind == "Job" {
+			return item.Metadata.Namespace + "/" + owner.Name
+		}
+	}
+	return ""
+}
+
+// BaseEnvKubernetesIntegration - This task checks the health of the New Relic Kubernetes integration pods through the API server
+type BaseEnvKubernetesIntegration struct {
+	httpGetter tasks.HTTPRequestFunc
+	readFile   func(string) ([]byte, error)
+}
+
+// Identifier - This returns the Category, Subcategory and Name of each task
+func (p BaseEnvKubernetesIntegration) Identifier() tasks.Identifier {
+	return tasks.IdentifierFromString("Base/Env/KubernetesIntegration")
+}
+
+// Explain - Returns the help text for each individual task
+func (p BaseEnvKubernetesIntegration) Explain() string {
+	return "Check the health of the New Relic Kubernetes integration pods"
+}
+
+// Dependencies - Returns the dependencies for each task.
+func (p BaseEnvKubernetesIntegration) Dependencies() []string {
+	return []string{"Base/Env/DetectKubernetes"}
+}
+
+// RequiresNetwork - This task queries the Kubernetes API, it is skipped with -offline
+func (p BaseEnvKubernetesIntegration) RequiresNetwork() {}
+
+// Execute - Lists the pods of the cluster, or of the pod's namespace when the service account can't list them all, and
+// reports the health of those deployed by New Relic
+func (p BaseEnvKubernetesIntegration) Execute(options tasks.Options, upstream map[string]tasks.Result) tasks.Result {
+	if upstream["Base/Env/DetectKubernetes"].Status != tasks.Info {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "Not executing task. Not running inside a Kubernetes pod.",
+		}
+	}
+	kubernetesEnv, ok := upstream["Base/Env/DetectKubernetes"].Payload.(KubernetesEnvironment)
+	if !ok {
+		return tasks.Result{
+			Status:  tasks.Error,
+			Summary: tasks.AssertionErrorSummary,
+		}
+	}
+	if !kubernetesEnv.ServiceAccount || kubernetesEnv.ServiceHost == "" {
+		return tasks.Result{
+			Status:  tasks.Info,
+			Summary: "No service account is mounted in this pod, the New Relic integration pods could not be checked.",
+		}
+	}
+
+	token, err := p.readFile(kubernetesServiceAccountDir + "token")
+	if err != nil {
+		return tasks.Result{
+			Status:  tasks.Error,
+			Summary: "Unable to read the service account token: " + err.Error(),
+		}
+	}
+	rootCAs := x509.NewCertPool()
+	if ca, err := p.readFile(kubernetesServiceAccountDir + "ca.crt"); err == nil {
+		rootCAs.AppendCertsFromPEM(ca)
+	}
+
+	port := kubernetesEnv.ServicePort
+	if port == "" {
+		port = "443"
+	}
+	apiServer := "https://" + net.JoinHostPort(kubernetesEnv.ServiceHost, port)
+	urls := []string{apiServer + "/api/v1/pods"}
+	if kubernetesEnv.Namespace != "" {
+		urls = append(urls, apiServer+"/api/v1/namespaces/"+kubernetesEnv.Namespace+"/pods")
+	}
+
+	var pods []KubernetesPod
+	forbidden := false
+	for _, url := range urls {
+		pods, forbidden, err = p.listPods(url, strings.TrimSpace(string(token)), rootCAs)
+		if !forbidden {
+			break
+		}
+		log.Debug("The service account is not allowed to list the pods at", url)
+	}
+
+	if forbidden {
+		return tasks.Result{
+			Status:  tasks.Info,
+			Summary: "The service account of this pod is not allowed to list pods, the New Relic integration pods could not be checked.",
+		}
+	}
+	if err != nil {
+		return tasks.Result{
+			Status:  tasks.Error,
+			Summary: "Unable to list the pods from the Kubernetes API server: " + err.Error(),
+		}
+	}
+	if len(pods) == 0 {
+		return tasks.Result{
+			Status:  tasks.Info,
+			Summary: "No New Relic integration pods are visible to the service account of this pod.",
+		}
+	}
+
+	var unhealthy []string
+	for _, pod := range pods {
+		if !pod.Ready {
+			unhealthy = append(unhealthy, fmt.Sprintf("%s/%s (%s, %d restarts)", pod.Namespace, pod.Name, pod.Phase, pod.Restarts))
+		}
+	}
+	if len(unhealthy) > 0 {
+		return tasks.Result{
+			Status:  tasks.Warning,
+			Summary: fmt.Sprintf("%d of %d New Relic integration pods are not ready:\n%s", len(unhealthy), len(pods), strings.Join(unhealthy, "\n")),
+			Payload: pods,
+		}
+	}
+	return tasks.Result{
+		Status:  tasks.Success,
+		Summary: fmt.Sprintf("All %d New Relic integration pods are running and ready.", len(pods)),
+		Payload: pods,
+	}
+}
+
+// listPods - returns the New Relic pods listed at url. forbidden is true when RBAC prevents the service account from listing them
+func (p BaseEnvKubernetesIntegration) listPods(url string, token string, rootCAs *x509.CertPool) (pods []KubernetesPod, forbidden bool, err error) {
+	resp, err := p.httpGetter(httpHelper.RequestWrapper{
+		Method:      "GET",
+		URL:         url,
+		Headers:     map[string]string{"Authorization": "Bearer " + token, "Accept": "application/json"},
+		BypassProxy: true,
+		RootCAs:     rootCAs,
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+		return nil, true, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, false, fmt.Errorf("unexpected response status %s", resp.Status)
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, false, err
+	}
+	var podList kubernetesPodList
+	if err := json.Unmarshal(body, &podList); err != nil {
+		return nil, false, err
+	}
+
+	// a Job retries with a new pod, a failed attempt followed by another one is no longer relevant
+	newestJobPods := make(map[string]time.Time)
+	for _, item := range podList.Items {
+		if job := item.job(); job != "" && item.Metadata.CreationTimestamp.After(newestJobPods[job]) {
+			newestJobPods[job] = item.Metadata.CreationTimestamp
+		}
+	}
+	for _, item := range podList.Items {
+		if !isNewRelicPod(item.Metadata.Name) {
+			continue
+		}
+		// the pods of the charts' Jobs, e.g. the admission webhook certificate ones, complete once they are done
+		if item.Status.Phase == "Succeeded" {
+			continue
+		}
+		if job := item.job(); item.Status.Phase == "Failed" && job != "" && newestJobPods[job].After(item.Metadata.CreationTimestamp) {
+			continue
+		}
+		pod := KubernetesPod{
+			Namespace: item.Metadata.Namespace,
+			Name:      item.Metadata.Name,
+			Phase:     item.Status.Phase,
+			Ready:     item.Status.Phase == "Running",
+		}
+		for _, container := range item.Status.ContainerStatuses {
+			pod.Ready = pod.Ready && container.Ready
+			pod.Restarts += container.RestartCount
+		}
+		pods = append(pods, pod)
+	}
+	sort.Slice(pods, func(i, j int) bool {
+		if pods[i].Namespace != pods[j].Namespace {
+			return pods[i].Namespace < pods[j].Namespace
+		}
+		return pods[i].Name < pods[j].Name
+	})
+	return pods, false, nil
+}
+
+func isNewRelicPod(name string) bool {
+	for _, prefix := range newRelicPodPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,267 @@
+package env
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	"github.com/shirou/gopsutil/v3/process"
+)
+
+// The kernel interfaces telling whether SELinux and AppArmor are enabled and what they confine
+const (
+	seLinuxEnforceFile    = "/sys/fs/selinux/enforce"
+	appArmorEnabledFile   = "/sys/module/apparmor/parameters/enabled"
+	appArmorProfilesFile  = "/sys/kernel/security/apparmor/profiles"
+	macDeniedLinesMax     = 20
+	macAuditLogTailLength = 4 << 20
+)
+
+// macAuditLogs - where the SELinux AVC denials and the AppArmor DENIED events are logged, depending on whether auditd runs
+var macAuditLogs = []string{
+	"/var/log/audit/audit.log",
+	"/var/log/kern.log",
+	"/var/log/syslog",
+	"/var/log/messages",
+}
+
+var (
+	macDenialRegex   = regexp.MustCompile(`avc:\s+denied|apparmor="DENIED"`)
+	newRelicMACRegex = regexp.MustCompile(`(?i)newrelic|nri-`)
+)
+
+// The SELinux modes, from /sys/fs/selinux/enforce
+const (
+	seLinuxDisabled   = "disabled"
+	seLinuxPermissive = "permissive"
+	seLinuxEnforcing  = "enforcing"
+)
+
+// ProcessLabel - the SELinux context or AppArmor profile a New Relic process runs confined by
+type ProcessLabel struct {
+	PID   int32
+	Name  string
+	Label string
+}
+
+// MACStatus - the mandatory access control of the host: the SELinux mode, whether AppArmor is enabled and the profiles
+// naming New Relic, the labels of the New Relic processes and the latest New Relic denials of the audit logs
+type MACStatus struct {
+	SELinux          string
+	AppArmor         bool
+	AppArmorProfiles []string `json:",omitempty"`
+	AgentProcesses   []ProcessLabel
+	Denials          []string
+	AuditLogs        []string
+}
+
+// BaseEnvMAC - This task detects SELinux or AppArmor confining the New Relic agents and the accesses they denied them
+type BaseEnvMAC struct {
+	readFile       func(string) ([]byte, error)
+	tailFile       func(string, int64) ([]byte, error)
+	agentProcesses func() []ProcessLabel
+}
+
+// Identifier - This returns the Category, Subcategory and Name of each task
+func (p BaseEnvMAC) Identifier() tasks.Identifier {
+	return tasks.IdentifierFromString("Base/Env/MAC")
+}
+
+// Explain - Returns the help text for this task
+func (p BaseEnvMAC) Explain() string {
+	return "Check for SELinux or AppArmor confining the New Relic agents and for the accesses they denied them"
+}
+
+// Dependencies - Returns the dependencies for each task.
+func (p BaseEnvMAC) Dependencies() []string {
+	return []string{}
+}
+
+// Execute - The core work within each task
+func (p BaseEnvMAC) Execute(options tasks.Options, upstream map[string]tasks.Result) tasks.Result {
+	status := MACStatus{
+		SELinux:        p.seLinuxMode(),
+		AgentProcesses: p.agentProcesses(),
+		Denials:        []string{},
+	}
+	if enabled, err := p.readFile(appArmorEnabledFile); err == nil {
+		status.AppArmor = strings.TrimSpace(string(enabled)) == "Y"
+	}
+	if status.AppArmor {
+		status.AppArmorProfiles = p.newRelicAppArmorProfiles()
+	}
+	if status.SELinux == seLinuxDisabled && !status.AppArmor {
+		return tasks.Result{
+			Status:  tasks.Success,
+			Summary: "Neither SELinux nor AppArmor is enabled, they can't block the New Relic agents.",
+			Payload: status,
+		}
+	}
+	status.AuditLogs, status.Denials = p.newRelicDenials()
+
+	return prepareMACResult(status)
+}
+
+func (p BaseEnvMAC) seLinuxMode() string {
+	enforce, err := p.readFile(seLinuxEnforceFile)
+	if err != nil {
+		return seLinuxDisabled
+	}
+	if strings.TrimSpace(string(enforce)) == "1" {
+		return seLinuxEnforcing
+	}
+	return seLinuxPermissive
+}
+
+// newRelicAppArmorProfiles - the loaded profiles naming New Relic, e.g. "/usr/bin/newrelic-infra (enforce)". Listing
+// them needs root
+func (p BaseEnvMAC) newRelicAppArmorProfiles() []string {
+	profiles, err := p.readFile(appArmorProfilesFile)
+	if err != nil {
+		log.Debug("Unable to list the AppArmor profiles:", err)
+		return nil
+	}
+	var newRelicProfiles []string
+	for _, profile := range strings.Split(string(profiles), "\n") {
+		if newRelicMACRegex.MatchString(profile) {
+			newRelicProfiles = append(newRelicProfiles, strings.TrimSpace(profile))
+		}
+	}
+	return newRelicProfiles
+}
+
+// newRelicDenials - the audit logs read and the last macDeniedLinesMax denials naming New Relic found at their end
+func (p BaseEnvMAC) newRelicDenials() ([]string, []string) {
+	auditLogs := []string{}
+	denials := []string{}
+	for _, auditLog := range macAuditLogs {
+		content, err := p.tailFile(auditLog, macAuditLogTailLength)
+		if err != nil {
+			continue
+		}
+		auditLogs = append(auditLogs, auditLog)
+		scanner := bufio.NewScanner(bytes.NewReader(content))
+		scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			line := scanner.Text()
+			if macDenialRegex.MatchString(line) && newRelicMACRegex.MatchString(line) {
+				denials = append(denials, line)
+			}
+		}
+	}
+	if len(denials) > macDeniedLinesMax {
+		denials = denials[len(denials)-macDeniedLinesMax:]
+	}
+	return auditLogs, denials
+}
+
+func prepareMACResult(status MACStatus) tasks.Result {
+	result := tasks.Result{
+		Status:  tasks.Info,
+		Payload: status,
+	}
+
+	var confinement []string
+	if status.SELinux != seLinuxDisabled {
+		confinement = append(confinement, "SELinux is "+status.SELinux)
+	}
+	if status.AppArmor {
+		appArmor := "AppArmor is enabled"
+		if len(status.AppArmorProfiles) > 0 {
+			appArmor += " with the profiles " + strings.Join(status.AppArmorProfiles, ", ")
+		}
+		confinement = append(confinement, appArmor)
+	}
+	for _, agentProcess := range status.AgentProcesses {
+		confinement = append(confinement, fmt.Sprintf("%s (%d) runs as %s", agentProcess.Name, agentProcess.PID, agentProcess.Label))
+	}
+	result.Summary = strings.Join(confinement, ", ") + "."
+
+	// AppArmor profiles in complain mode log ALLOWED rather than DENIED, so a DENIED event was enforced
+	enforced := status.SELinux == seLinuxEnforcing || confinedByAppArmor(status.AgentProcesses) || strings.Contains(strings.Join(status.Denials, "\n"), `apparmor="DENIED"`)
+	switch {
+	case len(status.Denials) == 0:
+		result.Summary += fmt.Sprintf("\nNo denial naming New Relic was found at the end of %s.", auditLogsDescription(status.AuditLogs))
+	case enforced:
+		result.Status = tasks.Warning
+		result.Summary += fmt.Sprintf("\nThe latest %d denial(s) naming New Relic, the agent may not be able to read its files or connect:\n\t%s", len(status.Denials), strings.Join(status.Denials, "\n\t"))
+		result.Summary += "\nAllow these accesses in the policy, e.g. with audit2allow for SELinux or aa-logprof for AppArmor, or check whether the issue goes away with SELinux permissive or the profile in complain mode."
+		result.URL = "https://docs.newrelic.com/docs/infrastructure/install-infrastructure-agent/linux-installation/linux-agent-running-modes/"
+	default:
+		result.Summary += fmt.Sprintf("\n%d denial(s) naming New Relic were logged, they are not enforced but would be in enforcing mode.", len(status.Denials))
+	}
+	return result
+}
+
+// confinedByAppArmor - whether a process runs with an enforced AppArmor profile, e.g. "newrelic-infra (enforce)"
+func confinedByAppArmor(processes []ProcessLabel) bool {
+	for _, agentProcess := range processes {
+		if strings.HasSuffix(agentProcess.Label, "(enforce)") {
+			return true
+		}
+	}
+	return false
+}
+
+func auditLogsDescription(auditLogs []string) string {
+	if len(auditLogs) == 0 {
+		return "the audit logs, none of " + strings.Join(macAuditLogs, ", ") + " could be read"
+	}
+	return strings.Join(auditLogs, ", ")
+}
+
+// tailFile - the last maxLength bytes of a file, starting at the next line when the file is longer
+func tailFile(path string, maxLength int64) ([]byte, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if info.Size() <= maxLength {
+		return io.ReadAll(file)
+	}
+	if _, err := file.Seek(info.Size()-maxLength, io.SeekStart); err != nil {
+		return nil, err
+	}
+	content, err := io.ReadAll(file)
+	if err != nil {
+		return nil, err
+	}
+	if newline := bytes.IndexByte(content, '\n'); newline != -1 {
+		content = content[newline+1:]
+	}
+	return content, nil
+}
+
+// newRelicProcessLabels - the SELinux context or AppArmor profile of the running New Relic processes
+func newRelicProcessLabels() []ProcessLabel {
+	labels := []ProcessLabel{}
+	processes, err := process.Processes()
+	if err != nil {
+		log.Debug("Unable to list the processes:", err)
+		return labels
+	}
+	for _, proc := range processes {
+		name, err := proc.Name()
+		if err != nil || !newRelicMACRegex.MatchString(name) || proc.Pid == int32(os.Getpid()) {
+			continue
+		}
+		label, err := os.ReadFile("/proc/" + strconv.Itoa(int(proc.Pid)) + "/attr/current")
+		if err != nil {
+			continue
+		}
+		labels = append(labels, ProcessLabel{PID: proc.Pid, Name: name, Label: strings.TrimRight(string(label), "\x00\n")})
+	}
+	return labels
+}
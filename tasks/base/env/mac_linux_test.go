@@ -0,0 +1,127 @@
+package env
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+const infraAVCDenial = `type=AVC msg=audit(1704189300.123:456): avc:  denied  { read } for  pid=1234 comm="newrelic-infra" name="newrelic-infra.yml" dev="dm-0" ino=123 scontext=system_u:system_r:unconfined_service_t:s0 tcontext=system_u:object_r:admin_home_t:s0 tclass=file permissive=0`
+
+func fakeFiles(files map[string]string) func(string) ([]byte, error) {
+	return func(path string) ([]byte, error) {
+		if content, ok := files[path]; ok {
+			return []byte(content), nil
+		}
+		return nil, os.ErrNotExist
+	}
+}
+
+var _ = Describe("Base/Env/MAC", func() {
+	var (
+		p         BaseEnvMAC
+		sysFiles  map[string]string
+		auditLogs map[string]string
+		processes []ProcessLabel
+	)
+
+	BeforeEach(func() {
+		sysFiles = map[string]string{}
+		auditLogs = map[string]string{}
+		processes = []ProcessLabel{}
+		p = BaseEnvMAC{
+			readFile:       fakeFiles(sysFiles),
+			tailFile:       func(path string, maxLength int64) ([]byte, error) { return fakeFiles(auditLogs)(path) },
+			agentProcesses: func() []ProcessLabel { return processes },
+		}
+	})
+
+	Describe("Execute()", func() {
+		It("Should return a Success result when neither SELinux nor AppArmor is enabled", func() {
+			result := p.Execute(tasks.Options{}, map[string]tasks.Result{})
+			Expect(result.Status).To(Equal(tasks.Success))
+			Expect(result.Payload.(MACStatus).SELinux).To(Equal(seLinuxDisabled))
+		})
+
+		It("Should return a Warning result when SELinux is enforcing and denied the infra agent", func() {
+			sysFiles[seLinuxEnforceFile] = "1"
+			processes = []ProcessLabel{{PID: 1234, Name: "newrelic-infra", Label: "system_u:system_r:unconfined_service_t:s0"}}
+			auditLogs["/var/log/audit/audit.log"] = "type=AVC msg=audit(1704189200.000:1): avc:  denied  { read } for  pid=99 comm=\"httpd\" tclass=file\n" + infraAVCDenial + "\n"
+
+			result := p.Execute(tasks.Options{}, map[string]tasks.Result{})
+			Expect(result.Status).To(Equal(tasks.Warning))
+			Expect(result.Summary).To(ContainSubstring("SELinux is enforcing, newrelic-infra (1234) runs as system_u:system_r:unconfined_service_t:s0"))
+			status := result.Payload.(MACStatus)
+			Expect(status.Denials).To(Equal([]string{infraAVCDenial}))
+			Expect(status.AuditLogs).To(Equal([]string{"/var/log/audit/audit.log"}))
+		})
+
+		It("Should return an Info result when SELinux is enforcing without New Relic denials", func() {
+			sysFiles[seLinuxEnforceFile] = "1\n"
+			auditLogs["/var/log/audit/audit.log"] = "type=AVC msg=audit(1704189200.000:1): avc:  denied  { read } for  pid=99 comm=\"httpd\" tclass=file\n"
+
+			result := p.Execute(tasks.Options{}, map[string]tasks.Result{})
+			Expect(result.Status).To(Equal(tasks.Info))
+			Expect(result.Summary).To(ContainSubstring("No denial naming New Relic was found at the end of /var/log/audit/audit.log"))
+		})
+
+		It("Should return an Info result for the denials logged in permissive mode", func() {
+			sysFiles[seLinuxEnforceFile] = "0"
+			auditLogs["/var/log/audit/audit.log"] = infraAVCDenial
+
+			result := p.Execute(tasks.Options{}, map[string]tasks.Result{})
+			Expect(result.Status).To(Equal(tasks.Info))
+			Expect(result.Summary).To(ContainSubstring("SELinux is permissive"))
+			Expect(result.Summary).To(ContainSubstring("1 denial(s) naming New Relic were logged, they are not enforced"))
+		})
+
+		It("Should return a Warning result for the AppArmor denials of a profile naming New Relic", func() {
+			sysFiles[appArmorEnabledFile] = "Y\n"
+			sysFiles[appArmorProfilesFile] = "/usr/sbin/tcpdump (enforce)\n/usr/bin/newrelic-infra (enforce)\n"
+			auditLogs["/var/log/kern.log"] = `Jan  2 10:00:00 host kernel: audit: type=1400 apparmor="DENIED" operation="open" profile="/usr/bin/newrelic-infra" name="/etc/newrelic-infra.yml" comm="newrelic-infra" requested_mask="r"`
+
+			result := p.Execute(tasks.Options{}, map[string]tasks.Result{})
+			Expect(result.Status).To(Equal(tasks.Warning))
+			Expect(result.Summary).To(ContainSubstring("AppArmor is enabled with the profiles /usr/bin/newrelic-infra (enforce)"))
+		})
+
+		It("Should keep the latest denials only", func() {
+			sysFiles[seLinuxEnforceFile] = "1"
+			var lines []string
+			for i := 0; i < macDeniedLinesMax+5; i++ {
+				lines = append(lines, infraAVCDenial)
+			}
+			lines[len(lines)-1] = strings.Replace(infraAVCDenial, "{ read }", "{ name_connect }", 1)
+			auditLogs["/var/log/audit/audit.log"] = strings.Join(lines, "\n")
+
+			denials := p.Execute(tasks.Options{}, map[string]tasks.Result{}).Payload.(MACStatus).Denials
+			Expect(denials).To(HaveLen(macDeniedLinesMax))
+			Expect(denials[macDeniedLinesMax-1]).To(ContainSubstring("name_connect"))
+		})
+	})
+
+	Describe("tailFile()", func() {
+		It("Should return the end of the file from the next line", func() {
+			path := filepath.Join(GinkgoT().TempDir(), "audit.log")
+			Expect(os.WriteFile(path, []byte("first line\nsecond line\nthird\n"), 0644)).To(Succeed())
+
+			content, err := tailFile(path, 15)
+			Expect(err).To(BeNil())
+			Expect(string(content)).To(Equal("third\n"))
+
+			content, err = tailFile(path, 100)
+			Expect(err).To(BeNil())
+			Expect(string(content)).To(Equal("first line\nsecond line\nthird\n"))
+		})
+
+		It("Should return an error for a missing file", func() {
+			_, err := tailFile(filepath.Join(GinkgoT().TempDir(), "missing.log"), 100)
+			Expect(errors.Is(err, os.ErrNotExist)).To(BeTrue())
+		})
+	})
+})
@@ -0,0 +1,218 @@
+package env
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	"github.com/shirou/gopsutil/v3/process"
+)
+
+// The soft limits below which a busy agent can run out of file descriptors, for its connections and log files, or of
+// threads, counted against the processes limit of its user on Linux
+const (
+	recommendedOpenFiles = 4096
+	recommendedProcesses = 4096
+)
+
+// openFilesUsageWarning - the share of the open files limit in use above which the agent is about to run out
+const openFilesUsageWarning = 0.8
+
+// resourceLimitsDocURL - raising the limits of a service
+const resourceLimitsDocURL = "https://docs.newrelic.com/docs/infrastructure/infrastructure-troubleshooting/troubleshoot-infrastructure/"
+
+// unlimited - the value of a limit that is not set
+const unlimited = -1
+
+// agentBinaries - the executables of the New Relic agents running as their own process. The JVMs running the Java agent
+// come from Java/Env/Process
+var agentBinaries = []string{"newrelic-infra", "newrelic-infra-service", "newrelic-daemon"}
+
+// ResourceLimit - the soft and hard values of a limit, -1 when unlimited, with the soft value recommended
+type ResourceLimit struct {
+	Resource    string
+	Soft        int64
+	Hard        int64
+	Used        int64 `json:",omitempty"`
+	Recommended int64
+}
+
+// ProcessResourceLimits - the limits of a New Relic agent process
+type ProcessResourceLimits struct {
+	PID     int32
+	Name    string
+	Threads int32 `json:",omitempty"`
+	Source  string
+	Limits  []ResourceLimit
+}
+
+// BaseEnvResourceLimits - This task checks the open files and processes limits of the New Relic agents
+type BaseEnvResourceLimits struct {
+	runtimeOs      string
+	agentProcesses func() []*process.Process
+	processLimits  func(*process.Process) (ProcessResourceLimits, error)
+}
+
+// Identifier - This returns the Category, Subcategory and Name of each task
+func (p BaseEnvResourceLimits) Identifier() tasks.Identifier {
+	return tasks.IdentifierFromString("Base/Env/ResourceLimits")
+}
+
+// Explain - Returns the help text for each individual task
+func (p BaseEnvResourceLimits) Explain() string {
+	return "Check that the open files and processes limits of the New Relic agents are high enough for a busy host"
+}
+
+// Dependencies - Returns the dependencies for each task.
+func (p BaseEnvResourceLimits) Dependencies() []string {
+	return []string{"Java/Env/Process"}
+}
+
+// Execute - The core work within each task
+func (p BaseEnvResourceLimits) Execute(options tasks.Options, upstream map[string]tasks.Result) tasks.Result {
+	if p.runtimeOs == "windows" {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "Windows has no open files or processes limit to check.",
+		}
+	}
+
+	procs := p.agentProcesses()
+	if javaProcs, ok := upstream["Java/Env/Process"].Payload.([]tasks.ProcIdAndArgs); ok {
+		for i := range javaProcs {
+			procs = append(procs, &javaProcs[i].Proc)
+		}
+	}
+	if len(procs) == 0 {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "No running New Relic agent process was found, there are no limits to check.",
+		}
+	}
+
+	var payload []ProcessResourceLimits
+	var unreadable []string
+	for _, proc := range procs {
+		limits, err := p.processLimits(proc)
+		if err != nil {
+			log.Debug("Unable to read the limits of process", proc.Pid, ":", err)
+			unreadable = append(unreadable, strconv.Itoa(int(proc.Pid)))
+			continue
+		}
+		payload = append(payload, limits)
+	}
+	if len(payload) == 0 {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "The limits of the New Relic agent processes " + strings.Join(unreadable, ", ") + " could not be read, they are only read from /proc on Linux.",
+		}
+	}
+	return prepareResourceLimitsResult(payload)
+}
+
+func prepareResourceLimitsResult(payload []ProcessResourceLimits) tasks.Result {
+	var low, ok []string
+	for _, proc := range payload {
+		for _, limit := range proc.Limits {
+			line := fmt.Sprintf("\t%s (%d) %s: soft %s, hard %s", proc.Name, proc.PID, limit.Resource, limitString(limit.Soft), limitString(limit.Hard))
+			if limit.Used > 0 {
+				line += fmt.Sprintf(", %d in use", limit.Used)
+			}
+			switch {
+			case limit.Soft != unlimited && limit.Soft < limit.Recommended:
+				low = append(low, fmt.Sprintf("%s, below the recommended %d", line, limit.Recommended))
+			case limit.Soft != unlimited && limit.Used > 0 && float64(limit.Used) >= openFilesUsageWarning*float64(limit.Soft):
+				low = append(low, fmt.Sprintf("%s, %d%% of the limit", line, limit.Used*100/limit.Soft))
+			default:
+				ok = append(ok, line)
+			}
+		}
+	}
+
+	if len(low) > 0 {
+		summary := "These limits are low for an agent on a busy host, it may drop data when it runs out of file descriptors or threads:\n" + strings.Join(low, "\n")
+		summary += "\nRaise them with LimitNOFILE and LimitNPROC in the systemd unit of the service, or in /etc/security/limits.conf, and restart the agent."
+		return tasks.Result{
+			Status:  tasks.Warning,
+			Summary: summary,
+			URL:     resourceLimitsDocURL,
+			Payload: payload,
+		}
+	}
+	return tasks.Result{
+		Status:  tasks.Success,
+		Summary: "The open files and processes limits are at or above the recommended values:\n" + strings.Join(ok, "\n"),
+		Payload: payload,
+	}
+}
+
+func limitString(value int64) string {
+	if value == unlimited {
+		return "unlimited"
+	}
+	return strconv.FormatInt(value, 10)
+}
+
+// limitValue - a limit read as an unsigned number, where the largest value means unlimited
+func limitValue(value uint64) int64 {
+	if value > math.MaxInt64 {
+		return unlimited
+	}
+	return int64(value)
+}
+
+// newRelicAgentProcesses - the running processes of the agent binaries, e.g. newrelic-infra or the PHP daemon
+func newRelicAgentProcesses() []*process.Process {
+	var agents []*process.Process
+	processes, err := process.Processes()
+	if err != nil {
+		log.Debug("Unable to list the processes:", err)
+		return agents
+	}
+	for _, proc := range processes {
+		if proc.Pid == int32(os.Getpid()) {
+			continue
+		}
+		name, err := proc.Name()
+		if err == nil && isAgentBinary(name) {
+			agents = append(agents, proc)
+		}
+	}
+	return agents
+}
+
+// isAgentBinary - whether a process name is one of agentBinaries. A Windows executable name ends with .exe
+func isAgentBinary(name string) bool {
+	name = strings.TrimSuffix(strings.ToLower(name), ".exe")
+	for _, binary := range agentBinaries {
+		if name == binary {
+			return true
+		}
+	}
+	return false
+}
+
+// readProcessLimits - the open files and processes limits of a process, with the file descriptors it has open. It is
+// only implemented on Linux, where they are read from /proc
+func readProcessLimits(proc *process.Process) (ProcessResourceLimits, error) {
+	rlimits, err := proc.RlimitUsage(true)
+	if err != nil {
+		return ProcessResourceLimits{}, err
+	}
+	name, _ := proc.Name()
+	threads, _ := proc.NumThreads()
+	limits := ProcessResourceLimits{PID: proc.Pid, Name: name, Threads: threads, Source: "process limits"}
+	for _, rlimit := range rlimits {
+		switch rlimit.Resource {
+		case process.RLIMIT_NOFILE:
+			limits.Limits = append(limits.Limits, ResourceLimit{Resource: "nofile", Soft: limitValue(rlimit.Soft), Hard: limitValue(rlimit.Hard), Used: int64(rlimit.Used), Recommended: recommendedOpenFiles})
+		case process.RLIMIT_NPROC:
+			limits.Limits = append(limits.Limits, ResourceLimit{Resource: "nproc", Soft: limitValue(rlimit.Soft), Hard: limitValue(rlimit.Hard), Recommended: recommendedProcesses})
+		}
+	}
+	return limits, nil
+}
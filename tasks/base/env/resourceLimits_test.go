@@ -0,0 +1,106 @@
+package env
+
+import (
+	"errors"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/shirou/gopsutil/v3/process"
+)
+
+var _ = Describe("Base/Env/ResourceLimits", func() {
+	var (
+		p      BaseEnvResourceLimits
+		limits map[int32]ProcessResourceLimits
+	)
+	infraAgent := &process.Process{Pid: 1234}
+
+	BeforeEach(func() {
+		limits = map[int32]ProcessResourceLimits{
+			1234: {PID: 1234, Name: "newrelic-infra", Source: "process limits", Limits: []ResourceLimit{
+				{Resource: "nofile", Soft: 65536, Hard: 65536, Used: 42, Recommended: recommendedOpenFiles},
+				{Resource: "nproc", Soft: unlimited, Hard: unlimited, Recommended: recommendedProcesses},
+			}},
+		}
+		p = BaseEnvResourceLimits{
+			runtimeOs:      "linux",
+			agentProcesses: func() []*process.Process { return []*process.Process{infraAgent} },
+			processLimits: func(proc *process.Process) (ProcessResourceLimits, error) {
+				if found, ok := limits[proc.Pid]; ok {
+					return found, nil
+				}
+				return ProcessResourceLimits{}, errors.New("not implemented yet")
+			},
+		}
+	})
+
+	Describe("Execute()", func() {
+		It("Should return a Success result when the agent limits are above the recommended values", func() {
+			result := p.Execute(tasks.Options{}, map[string]tasks.Result{})
+			Expect(result.Status).To(Equal(tasks.Success))
+			Expect(result.Summary).To(ContainSubstring("newrelic-infra (1234) nofile: soft 65536, hard 65536, 42 in use"))
+			Expect(result.Summary).To(ContainSubstring("newrelic-infra (1234) nproc: soft unlimited, hard unlimited"))
+			Expect(result.Payload).To(Equal([]ProcessResourceLimits{limits[1234]}))
+		})
+
+		It("Should return a Warning result when a soft limit is below the recommended value", func() {
+			limits[1234].Limits[0] = ResourceLimit{Resource: "nofile", Soft: 1024, Hard: 524288, Used: 12, Recommended: recommendedOpenFiles}
+			result := p.Execute(tasks.Options{}, map[string]tasks.Result{})
+			Expect(result.Status).To(Equal(tasks.Warning))
+			Expect(result.Summary).To(ContainSubstring("newrelic-infra (1234) nofile: soft 1024, hard 524288, 12 in use, below the recommended 4096"))
+			Expect(result.Summary).To(ContainSubstring("LimitNOFILE"))
+		})
+
+		It("Should return a Warning result when most of the open files limit is in use", func() {
+			limits[1234].Limits[0] = ResourceLimit{Resource: "nofile", Soft: 8192, Hard: 8192, Used: 7000, Recommended: recommendedOpenFiles}
+			result := p.Execute(tasks.Options{}, map[string]tasks.Result{})
+			Expect(result.Status).To(Equal(tasks.Warning))
+			Expect(result.Summary).To(ContainSubstring("7000 in use, 85% of the limit"))
+		})
+
+		It("Should check the JVMs running the Java agent", func() {
+			limits[4321] = ProcessResourceLimits{PID: 4321, Name: "java", Source: "process limits", Limits: []ResourceLimit{
+				{Resource: "nofile", Soft: 1024, Hard: 4096, Used: 100, Recommended: recommendedOpenFiles},
+			}}
+			upstream := map[string]tasks.Result{
+				"Java/Env/Process": {Status: tasks.Success, Payload: []tasks.ProcIdAndArgs{{Proc: process.Process{Pid: 4321}}}},
+			}
+			result := p.Execute(tasks.Options{}, upstream)
+			Expect(result.Status).To(Equal(tasks.Warning))
+			Expect(result.Summary).To(ContainSubstring("java (4321) nofile: soft 1024, hard 4096, 100 in use, below the recommended 4096"))
+			Expect(result.Payload).To(HaveLen(2))
+		})
+
+		It("Should return a None result when no agent process is running", func() {
+			p.agentProcesses = func() []*process.Process { return nil }
+			result := p.Execute(tasks.Options{}, map[string]tasks.Result{})
+			Expect(result.Status).To(Equal(tasks.None))
+			Expect(result.Summary).To(ContainSubstring("No running New Relic agent process"))
+		})
+
+		It("Should return a None result when the limits of the agents can't be read", func() {
+			p.agentProcesses = func() []*process.Process { return []*process.Process{{Pid: 99}} }
+			result := p.Execute(tasks.Options{}, map[string]tasks.Result{})
+			Expect(result.Status).To(Equal(tasks.None))
+			Expect(result.Summary).To(ContainSubstring("processes 99 could not be read"))
+			Expect(result.Payload).To(BeNil())
+		})
+
+		It("Should return a None result on Windows", func() {
+			p.runtimeOs = "windows"
+			Expect(p.Execute(tasks.Options{}, map[string]tasks.Result{}).Status).To(Equal(tasks.None))
+		})
+	})
+
+	Describe("isAgentBinary()", func() {
+		It("Should only match the agent binaries", func() {
+			Expect(isAgentBinary("newrelic-infra")).To(BeTrue())
+			Expect(isAgentBinary("newrelic-infra-service.exe")).To(BeTrue())
+			Expect(isAgentBinary("newrelic-daemon")).To(BeTrue())
+			Expect(isAgentBinary("vim")).To(BeFalse())
+			Expect(isAgentBinary("newrelic-diagnostics-cli")).To(BeFalse())
+			Expect(isAgentBinary("tail")).To(BeFalse())
+		})
+	})
+})
@@ -6,11 +6,14 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/newrelic/newrelic-diagnostics-cli/config"
 	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
 	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
 	baseConfig "github.com/newrelic/newrelic-diagnostics-cli/tasks/base/config"
+	baseEnv "github.com/newrelic/newrelic-diagnostics-cli/tasks/base/env"
 )
 
 // BaseLogCollect - Primary task to search for and find config file. Will optionally take command line input as source
@@ -37,7 +40,9 @@ func (p BaseLogCollect) Dependencies() []string {
 	return []string{
 		"Base/Env/CollectEnvVars",
 		"Base/Env/CollectSysProps",
+		"Base/Env/DetectContainer",
 		"Base/Config/Validate",
+		"Base/Env/DiskSpace",
 	}
 }
 
@@ -83,17 +88,39 @@ func (p BaseLogCollect) Execute(options tasks.Options, upstream map[string]tasks
 		})
 	} else {
 		//ignoring secure logs for now
-		logs = collectFilePaths(envVars, configElements, foundSysProps, options)
+		container, _ := upstream["Base/Env/DetectContainer"].Payload.(baseEnv.ContainerEnvironment)
+		logs = collectFilePaths(envVars, configElements, foundSysProps, container, options)
 	}
 
 	if len(logs) > 0 {
+		window := newLogWindow(config.Flags.LogAge, config.Flags.LogMaxSize, time.Now())
+		if !window.cutoff.IsZero() {
+			logs = withNewestRotations(logs, window)
+		}
+		if skipped, ok := skipForDiskSpace(upstream); ok {
+			skipped.Payload = logs
+			return skipped
+		}
 		result.Status = tasks.Success
 		// format the output of the result to return the files found and their content
 
-		for _, log := range logs {
+		var truncated, tooOld int
+		for idx, log := range logs {
 			dir, fileName := filepath.Split(log.Source.FullPath)
 			log.FileName = fileName
 			log.FilePath = dir
+			if window.isLimited() || isGzipped(log.Source.FullPath) {
+				envelope, collected := windowedEnvelope(&logs[idx], window, p.Identifier().String())
+				if !collected {
+					tooOld++
+					continue
+				}
+				if logs[idx].Truncated {
+					truncated++
+				}
+				filesToCopy = append(filesToCopy, envelope)
+				continue
+			}
 			ch, _ := prunedReader(log.Source.FullPath)
 
 			filesToCopy = append(filesToCopy, tasks.FileCopyEnvelope{Path: log.Source.FullPath, Stream: ch, Identifier: p.Identifier().String()})
@@ -104,6 +131,12 @@ func (p BaseLogCollect) Execute(options tasks.Options, upstream map[string]tasks
 
 		result.Payload = logs
 		result.Summary = fmt.Sprintf("There were %d file(s) found", len(logs))
+		if tooOld > 0 {
+			result.Summary += fmt.Sprintf(", %d of them were not collected as they were last modified more than %d day(s) ago (-log-age)", tooOld, config.Flags.LogAge)
+		}
+		if truncated > 0 {
+			result.Summary += fmt.Sprintf(", %d were truncated to their most recent lines (-log-age, -log-max-size)", truncated)
+		}
 		result.FilesToCopy = filesToCopy
 
 	} else {
@@ -116,6 +149,65 @@ func (p BaseLogCollect) Execute(options tasks.Options, upstream map[string]tasks
 	return result
 }
 
+// windowedEnvelope - streams the lines of a log within the -log-age and -log-max-size limits, noting in the log element
+// what was dropped. Returns false when the whole file is older than -log-age. Base/Log/Collect and Base/Log/Copy both
+// apply the window to the logs they copy
+func windowedEnvelope(logElement *LogElement, window logWindow, identifier string) (tasks.FileCopyEnvelope, bool) {
+	path := logElement.Source.FullPath
+	// gzipped rotations are decompressed, so they are stored without their extension
+	envelope := tasks.FileCopyEnvelope{Path: strings.TrimSuffix(path, filepath.Ext(path)), Identifier: identifier}
+	if !isGzipped(path) {
+		envelope.Path = path
+	}
+
+	if info, err := os.Stat(path); err == nil && !window.includesFile(info.ModTime()) {
+		logElement.CanCollect = false
+		logElement.ReasonToNotCollect = "The file was last modified on " + info.ModTime().Format("2006-01-02") + ", before the window set by -log-age"
+		return envelope, false
+	}
+	windowed, err := window.window(path)
+	if err != nil {
+		log.Debug("Unable to read", path, "to apply the log collection window:", err)
+		envelope.Stream, _ = prunedReader(path)
+		return envelope, true
+	}
+	logElement.TruncationNote = windowed.truncationNote(window)
+	logElement.Truncated = logElement.TruncationNote != ""
+	envelope.Stream = windowed.stream()
+	return envelope, true
+}
+
+// withNewestRotations - adds the newest rotation of each log when it was modified within the window, its first lines
+// are still recent when the log was rotated shortly before the run
+func withNewestRotations(logs []LogElement, window logWindow) []LogElement {
+	found := map[string]bool{}
+	for _, logElement := range logs {
+		found[logElement.Source.FullPath] = true
+	}
+	withRotations := logs
+	for _, logElement := range logs {
+		if logElement.Source.FullPath == "" {
+			continue
+		}
+		rotation, info, ok := newestRotation(logElement.Source.FullPath)
+		if !ok || found[rotation] || !window.includesFile(info.ModTime()) {
+			continue
+		}
+		found[rotation] = true
+		dir, fileName := filepath.Split(rotation)
+		withRotations = append(withRotations, LogElement{
+			FileName: fileName,
+			FilePath: dir,
+			Source: LogSourceData{
+				FoundBy:  logPathRotationSource,
+				FullPath: rotation,
+			},
+			CanCollect: true,
+		})
+	}
+	return withRotations
+}
+
 func prunedReader(path string) (c chan string, err error) {
 	file, err := os.Open(path)
 
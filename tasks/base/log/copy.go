@@ -15,6 +15,7 @@ import (
 	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
 	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
 	baseConfig "github.com/newrelic/newrelic-diagnostics-cli/tasks/base/config"
+	baseEnv "github.com/newrelic/newrelic-diagnostics-cli/tasks/base/env"
 )
 
 // BaseLogCopy - Primary task to search for and find config file. Will optionally take command line input as source
@@ -41,7 +42,9 @@ func (p BaseLogCopy) Dependencies() []string {
 	return []string{
 		"Base/Env/CollectEnvVars",
 		"Base/Env/CollectSysProps",
+		"Base/Env/DetectContainer",
 		"Base/Config/Validate",
+		"Base/Env/DiskSpace",
 	}
 }
 
@@ -65,10 +68,16 @@ func (p BaseLogCopy) Execute(options tasks.Options, upstream map[string]tasks.Re
 		}
 	}
 
+	// -log-age and -log-max-size bound the logs copied, the newest rotations are added like in Base/Log/Collect
+	window := newLogWindow(config.Flags.LogAge, config.Flags.LogMaxSize, time.Now())
+	if !window.cutoff.IsZero() {
+		logElementsFound = withNewestRotations(logElementsFound, window)
+	}
 	logElements := dedupeLogPaths(logElementsFound)
 
 	var invalidLogPaths []string //will be use to name the log locations we were unable to collect from
 	var validLogPaths []string   //will be use to list the filesToCopy into nrdiag.zip
+	var validLogIndexes []int    //the log elements of validLogPaths, noting what the collection window dropped
 	var failureSummary string
 
 	for idx, logElem := range logElements {
@@ -81,6 +90,7 @@ func (p BaseLogCopy) Execute(options tasks.Options, upstream map[string]tasks.Re
 				logElements[idx] = setLogElement(logElem.FileName, logElem.FilePath, logElem.Source, logElem.IsSecureLocation, false, (logFileStatus.ErrorMsg).Error())
 			} else {
 				validLogPaths = append(validLogPaths, logElem.Source.FullPath)
+				validLogIndexes = append(validLogIndexes, idx)
 			}
 		} else { //cases where customer rejected the prompt to collect the file
 			invalidLogPaths = append(invalidLogPaths, logElem.Source.FullPath)
@@ -93,14 +103,37 @@ func (p BaseLogCopy) Execute(options tasks.Options, upstream map[string]tasks.Re
 	hasValidLogs := len(validLogPaths) > 0
 
 	if hasValidLogs {
+		if skipped, ok := skipForDiskSpace(upstream); ok {
+			skipped.Payload = logElements
+			return skipped
+		}
 		var filesToCopyToResult []tasks.FileCopyEnvelope
 		var successSummary = "Succesfully collected one or more New Relic Log file(s). Those file names will be listed in the nrdiag-output.json, under the payload section with the field 'CanCollect' set to true.\n"
-		for _, validPath := range validLogPaths {
+		var truncated, tooOld int
+		for i, validPath := range validLogPaths {
+			if window.isLimited() {
+				envelope, collected := windowedEnvelope(&logElements[validLogIndexes[i]], window, p.Identifier().String())
+				if !collected {
+					tooOld++
+					continue
+				}
+				if logElements[validLogIndexes[i]].Truncated {
+					truncated++
+				}
+				filesToCopyToResult = append(filesToCopyToResult, envelope)
+				continue
+			}
 			filesToCopyToResult = append(filesToCopyToResult, tasks.FileCopyEnvelope{
 				Path:       validPath,
 				Identifier: p.Identifier().String(),
 			})
 		}
+		if tooOld > 0 {
+			successSummary += fmt.Sprintf("%d of them were not collected as they were last modified more than %d day(s) ago (-log-age).\n", tooOld, config.Flags.LogAge)
+		}
+		if truncated > 0 {
+			successSummary += fmt.Sprintf("%d were truncated to their most recent lines (-log-age, -log-max-size).\n", truncated)
+		}
 		//Look for NET log files. There are too many so we'll only include one file in the payload. By now all files should had been captured as part of filesToCopyToResult
 		var resultPayload interface{}
 		if hasDotnetLogs(logElements) {
@@ -165,6 +198,29 @@ func hasDotnetLogs(logElements []LogElement) bool {
 }
 
 func searchForLogPaths(options tasks.Options, upstream map[string]tasks.Result) ([]LogElement, error) {
+	logFilesFound, err := findLogPaths(options, upstream)
+	if err != nil || options.Options["logpath"] != "" {
+		return logFilesFound, err
+	}
+	for i, logFileFound := range logFilesFound {
+		if logFileFound.IsSecureLocation {
+			question := fmt.Sprintf("We've found a file that may contain secure information: %s\n", logFileFound.Source.FullPath) +
+				"Include this file in nrdiag-output.zip?"
+			if !(tasks.PromptUser(question, options)) {
+				//update logElement with new data
+				reasonCannotCollect := "User opted out when " + tasks.ThisProgramFullName + " asked if it can collect this file that may contain secure information."
+				logFilesFound[i] = setLogElement(logFileFound.FileName, logFileFound.FilePath, logFileFound.Source, true, false, reasonCannotCollect)
+			}
+		}
+
+	}
+	return logFilesFound, nil
+}
+
+// findLogPaths - the log files found from the env vars, the config files, the system properties and the standard
+// locations, or the logpath override. Unlike searchForLogPaths it doesn't ask whether to collect the files in secure
+// locations
+func findLogPaths(options tasks.Options, upstream map[string]tasks.Result) ([]LogElement, error) {
 
 	//get payload from env vars
 	foundEnvVars := make(map[string]string)
@@ -213,19 +269,8 @@ func searchForLogPaths(options tasks.Options, upstream map[string]tasks.Result)
 		dir, fileName := filepath.Split(options.Options["logpath"])
 		logFilesFound = append(logFilesFound, setLogElement(fileName, dir, logSourceData, false, true, ""))
 	} else {
-		logFilesFound = collectFilePaths(foundEnvVars, foundConfigElements, foundSysProps, options) //At this point foundSysPropPath may be not be have an assigned value but we'll check for length on the other end
-		for i, logFileFound := range logFilesFound {
-			if logFileFound.IsSecureLocation {
-				question := fmt.Sprintf("We've found a file that may contain secure information: %s\n", logFileFound.Source.FullPath) +
-					"Include this file in nrdiag-output.zip?"
-				if !(tasks.PromptUser(question, options)) {
-					//update logElement with new data
-					reasonCannotCollect := "User opted out when " + tasks.ThisProgramFullName + " asked if it can collect this file that may contain secure information."
-					logFilesFound[i] = setLogElement(logFileFound.FileName, logFileFound.FilePath, logFileFound.Source, true, false, reasonCannotCollect)
-				}
-			}
-
-		}
+		container, _ := upstream["Base/Env/DetectContainer"].Payload.(baseEnv.ContainerEnvironment)
+		logFilesFound = collectFilePaths(foundEnvVars, foundConfigElements, foundSysProps, container, options) //At this point foundSysPropPath may be not be have an assigned value but we'll check for length on the other end
 	}
 	return logFilesFound, nil
 }
@@ -1,15 +1,21 @@
 package log
 
 import (
+	"runtime"
+
 	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
 	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	baseEnv "github.com/newrelic/newrelic-diagnostics-cli/tasks/base/env"
 )
 
 // RegisterWith - will register any plugins in this package
 func RegisterWith(registrationFunc func(tasks.Task, bool)) {
 	log.Debug("Registering Base/Log/*")
+	// Base/Env/DiskSpace estimates the size of the log files found here before they are collected
+	baseEnv.LogFilesEstimator = estimateLogFiles
 
 	registrationFunc(BaseLogCollect{}, false)
 	registrationFunc(BaseLogCopy{}, true)
 	registrationFunc(BaseLogReportingTo{}, true)
+	registrationFunc(BaseLogWindowsEventLog{goos: runtime.GOOS, collectEvents: collectWindowsEvents}, true)
 }
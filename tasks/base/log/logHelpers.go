@@ -7,10 +7,13 @@ import (
 	"regexp"
 	"runtime"
 	"strings"
+	"time"
 
+	"github.com/newrelic/newrelic-diagnostics-cli/config"
 	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
 	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
 	baseConfig "github.com/newrelic/newrelic-diagnostics-cli/tasks/base/config"
+	baseEnv "github.com/newrelic/newrelic-diagnostics-cli/tasks/base/env"
 )
 
 var logFilenamePatterns = []string{"newrelic_agent.*[.]log$",
@@ -68,31 +71,23 @@ var keysInConfigFile = map[string][]string{
 	*/
 }
 
-type LogElement struct {
-	FileName           string
-	FilePath           string
-	Source             LogSourceData
-	IsSecureLocation   bool
-	CanCollect         bool
-	ReasonToNotCollect string
-}
+// LogElement - a log file found by the log tasks, the payload of Base/Log/Copy, see tasks.LogElement
+type LogElement = tasks.LogElement
 
-type LogSourceData struct {
-	FoundBy  string
-	KeyVals  map[string]string
-	FullPath string
-}
+// LogSourceData - how a log file was found, see tasks.LogSourceData
+type LogSourceData = tasks.LogSourceData
 
 var (
 	logPathDefaultSource          = "Found by looking at standard locations"
 	logPathConfigFileSource       = "Found by looking at values in New Relic config file settings"
 	logPathEnvVarSource           = "Found by looking at New Relic environment variables"
 	logPathSysPropSource          = "Found by looking at JVM arguments"
+	logPathRotationSource         = "Found as the newest rotation of a log file, modified within the " + tasks.ThisProgramFullName + " command line flag '-log-age' window"
 	logPathDiagnosticsFlagSource  = "Found by looking at the path defined by the user through the " + tasks.ThisProgramFullName + " command line flag '-logpath'"
 	dotnetLogsDownsizeExplanation = "Not all .NET profiler logs get listed here in the 'Payload'. To view the full list, review the 'FilesToCopy' value or the nrdiag-filelist.txt"
 )
 
-func collectFilePaths(envVars map[string]string, configElements []baseConfig.ValidateElement, foundSysProps map[string]string, options tasks.Options) []LogElement {
+func collectFilePaths(envVars map[string]string, configElements []baseConfig.ValidateElement, foundSysProps map[string]string, container baseEnv.ContainerEnvironment, options tasks.Options) []LogElement {
 	var paths []string
 	currentPath, err := os.Getwd()
 	if err != nil {
@@ -132,12 +127,19 @@ func collectFilePaths(envVars map[string]string, configElements []baseConfig.Val
 			where a NR log file is expected, as only paths in this slice (no subdirectories)
 			will be resolved from symbolic links. Matches will be deduped by tasks.FindFiles
 		*/
-		paths = append(paths, "/tmp")                                     //For Python Agent log and PHP installation log
-		paths = append(paths, "/var/log")                                 //For Syn Minion and Infra
-		paths = append(paths, "/var/log/newrelic")                        // For PHP agent and daemon log
-		paths = append(paths, "/usr/local/newrelic-netcore20-agent/logs") // for dotnetcore up to v10
-		paths = append(paths, "/usr/local/newrelic-dotnet-agent/logs") // for dotnetcore v10+
+		defaultPaths := []string{
+			"/tmp",                                     //For Python Agent log and PHP installation log
+			"/var/log",                                 //For Syn Minion and Infra
+			"/var/log/newrelic",                        // For PHP agent and daemon log
+			"/usr/local/newrelic-netcore20-agent/logs", // for dotnetcore up to v10
+			"/usr/local/newrelic-dotnet-agent/logs",    // for dotnetcore v10+
+		}
+		paths = append(paths, defaultPaths...)
+		//inside a container the logs of the agents installed on the host are only found through the mounted host filesystem
+		paths = append(paths, container.HostPaths(defaultPaths)...)
 	}
+	// the non-standard install directories given with -include-path, already resolved from symbolic links
+	paths = append(paths, options.IncludePaths()...)
 	/*
 		Collect log file paths in this order
 		1.Non-new relic log files, such as docker and syslog, by looking in the standard, expected locations
@@ -561,3 +563,49 @@ func dedupeLogPaths(logElements []LogElement) []LogElement {
 	}
 	return deDuped
 }
+
+// skipForDiskSpace - returns a tasks.None result when Base/Env/DiskSpace reported the output volume is almost full, or
+// that the config and log files it estimated would not fit in it. The log files are copied while the output is written,
+// so a full disk would otherwise fail the whole zip
+func skipForDiskSpace(upstream map[string]tasks.Result) (tasks.Result, bool) {
+	space, ok := upstream["Base/Env/DiskSpace"].Payload.(baseEnv.DiskSpace)
+	if !ok || space.FitsBundle() {
+		return tasks.Result{}, false
+	}
+	return tasks.Result{
+		Status:  tasks.None,
+		Summary: fmt.Sprintf("Log files were not collected because there is not enough free disk space on the volume of %s: %s free, %s of config and log files found in %d file(s). Free up disk space or use -output-path to write the results to another volume.", space.OutputPath, baseEnv.FormatBytes(space.FreeBytes), baseEnv.FormatBytes(space.EstimatedBytes), space.EstimatedFiles),
+	}, true
+}
+
+// estimateLogFiles - the size and count of the log files Base/Log/Copy would collect within -log-age and
+// -log-max-size, for Base/Env/DiskSpace to check they fit before they are copied. The files in secure locations are
+// counted without asking whether to collect them
+func estimateLogFiles(options tasks.Options, upstream map[string]tasks.Result) (uint64, int) {
+	logElements, err := findLogPaths(options, upstream)
+	if err != nil {
+		return 0, 0
+	}
+	window := newLogWindow(config.Flags.LogAge, config.Flags.LogMaxSize, time.Now())
+	if !window.cutoff.IsZero() {
+		logElements = withNewestRotations(logElements, window)
+	}
+	var estimatedBytes uint64
+	var files int
+	for _, logElement := range dedupeLogPaths(logElements) {
+		if !logElement.CanCollect {
+			continue
+		}
+		info, err := os.Stat(logElement.Source.FullPath)
+		if err != nil || info.IsDir() || !window.includesFile(info.ModTime()) {
+			continue
+		}
+		size := info.Size()
+		if window.maxBytes > 0 && size > window.maxBytes {
+			size = window.maxBytes
+		}
+		estimatedBytes += uint64(size)
+		files++
+	}
+	return estimatedBytes, files
+}
@@ -0,0 +1,79 @@
+package log
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	baseEnv "github.com/newrelic/newrelic-diagnostics-cli/tasks/base/env"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("skipForDiskSpace()", func() {
+	var upstream map[string]tasks.Result
+
+	Context("when Base/Env/DiskSpace did not report the free space", func() {
+		It("Should not skip the collection", func() {
+			_, skipped := skipForDiskSpace(map[string]tasks.Result{})
+			Expect(skipped).To(BeFalse())
+		})
+	})
+
+	Context("when the estimated files fit in the free space", func() {
+		BeforeEach(func() {
+			upstream = map[string]tasks.Result{
+				"Base/Env/DiskSpace": {Status: tasks.Info, Payload: baseEnv.DiskSpace{OutputPath: "./", FreeBytes: 10 << 30, EstimatedBytes: 2 << 30, EstimatedFiles: 3}},
+			}
+		})
+		It("Should not skip the collection", func() {
+			_, skipped := skipForDiskSpace(upstream)
+			Expect(skipped).To(BeFalse())
+		})
+	})
+
+	Context("when the estimated files do not fit in the free space", func() {
+		BeforeEach(func() {
+			upstream = map[string]tasks.Result{
+				"Base/Env/DiskSpace": {Status: tasks.Warning, Payload: baseEnv.DiskSpace{OutputPath: "./", FreeBytes: 2 << 30, EstimatedBytes: 3 << 30, EstimatedFiles: 4}},
+			}
+		})
+		It("Should return a None result with the numbers", func() {
+			result, skipped := skipForDiskSpace(upstream)
+			Expect(skipped).To(BeTrue())
+			Expect(result.Status).To(Equal(tasks.None))
+			Expect(result.Summary).To(ContainSubstring("2.0 GB free, 3.0 GB of config and log files found in 4 file(s)"))
+		})
+	})
+
+	Context("when the free space is dangerously low", func() {
+		BeforeEach(func() {
+			upstream = map[string]tasks.Result{
+				"Base/Env/DiskSpace": {Status: tasks.Warning, Payload: baseEnv.DiskSpace{OutputPath: "./", FreeBytes: 50 << 20, EstimatedBytes: 2048, EstimatedFiles: 1}},
+			}
+		})
+		It("Should return a None result with the numbers", func() {
+			result, skipped := skipForDiskSpace(upstream)
+			Expect(skipped).To(BeTrue())
+			Expect(result.Status).To(Equal(tasks.None))
+			Expect(result.Summary).To(ContainSubstring("50.0 MB free, 2.0 KB of config and log files found in 1 file(s)"))
+		})
+	})
+})
+
+var _ = Describe("estimateLogFiles()", func() {
+	It("Should return the size and count of the log files found", func() {
+		logFile := filepath.Join(GinkgoT().TempDir(), "newrelic_agent.log")
+		Expect(os.WriteFile(logFile, make([]byte, 2048), 0600)).To(Succeed())
+
+		estimatedBytes, files := estimateLogFiles(tasks.Options{Options: map[string]string{"logpath": logFile}}, map[string]tasks.Result{})
+		Expect(estimatedBytes).To(Equal(uint64(2048)))
+		Expect(files).To(Equal(1))
+	})
+
+	It("Should leave out the log files that cannot be read", func() {
+		estimatedBytes, files := estimateLogFiles(tasks.Options{Options: map[string]string{"logpath": filepath.Join(GinkgoT().TempDir(), "missing.log")}}, map[string]tasks.Result{})
+		Expect(estimatedBytes).To(BeZero())
+		Expect(files).To(BeZero())
+	})
+})
@@ -0,0 +1,278 @@
+package log
+
+import (
+	"bufio"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
+	baseEnv "github.com/newrelic/newrelic-diagnostics-cli/tasks/base/env"
+)
+
+// timestampPrefixMax - how far into a line its timestamp is looked for, past the level or the JSON keys some agents
+// write first
+const timestampPrefixMax = 64
+
+var logTimestampFormats = []struct {
+	regex  *regexp.Regexp
+	layout string
+}{
+	{regexp.MustCompile(`\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}:\d{2}`), "2006-01-02 15:04:05"},
+	// Java agent: Jan 2, 2024 15:04:05 -0700
+	{regexp.MustCompile(`[A-Z][a-z]{2} \d{1,2}, \d{4} \d{1,2}:\d{2}:\d{2}`), "Jan 2, 2006 15:04:05"},
+	{regexp.MustCompile(`\d{4}/\d{2}/\d{2} \d{2}:\d{2}:\d{2}`), "2006/01/02 15:04:05"},
+}
+
+// logWindow - the -log-age and -log-max-size limits applied to the log files collected
+type logWindow struct {
+	cutoff   time.Time // zero when every line is collected
+	maxBytes int64     // zero when there is no size limit
+}
+
+func newLogWindow(ageDays int, maxSizeMB int, now time.Time) logWindow {
+	window := logWindow{}
+	if ageDays > 0 {
+		window.cutoff = now.AddDate(0, 0, -ageDays)
+	}
+	if maxSizeMB > 0 {
+		window.maxBytes = int64(maxSizeMB) << 20
+	}
+	return window
+}
+
+func (w logWindow) isLimited() bool {
+	return !w.cutoff.IsZero() || w.maxBytes > 0
+}
+
+// includesFile - a file last modified before the cutoff has no line within the window
+func (w logWindow) includesFile(modTime time.Time) bool {
+	return w.cutoff.IsZero() || !modTime.Before(w.cutoff)
+}
+
+// newestRotation - returns the most recently modified rotation of a log file, e.g. newrelic_agent.log.1,
+// newrelic_agent.log.2024-01-02 or newrelic-infra.log-20240102.gz
+func newestRotation(path string) (string, os.FileInfo, bool) {
+	var rotations []string
+	for _, pattern := range []string{path + ".*", path + "-*"} {
+		matches, _ := filepath.Glob(pattern)
+		rotations = append(rotations, matches...)
+	}
+	var newest string
+	var newestInfo os.FileInfo
+	for _, rotation := range rotations {
+		info, err := os.Stat(rotation)
+		if err != nil || info.IsDir() {
+			continue
+		}
+		if newestInfo == nil || info.ModTime().After(newestInfo.ModTime()) {
+			newest, newestInfo = rotation, info
+		}
+	}
+	return newest, newestInfo, newestInfo != nil
+}
+
+// windowedLog - the part of a log file that is kept, from the first line at or after start
+type windowedLog struct {
+	path          string
+	start         int64
+	total         int64
+	ageTruncated  bool
+	sizeTruncated bool
+}
+
+// truncationNote - explains which lines were dropped, empty when the whole file is kept
+func (l windowedLog) truncationNote(w logWindow) string {
+	if l.start == 0 {
+		return ""
+	}
+	var limits []string
+	if l.ageTruncated {
+		limits = append(limits, "lines older than "+w.cutoff.Format("2006-01-02 15:04:05"))
+	}
+	if l.sizeTruncated {
+		limits = append(limits, fmt.Sprintf("lines beyond the %s limit of -log-max-size", baseEnv.FormatBytes(uint64(w.maxBytes))))
+	}
+	if l.start >= l.total {
+		return fmt.Sprintf("no line of the %s of the file is within the collection window", baseEnv.FormatBytes(uint64(l.total)))
+	}
+	return fmt.Sprintf("truncated from the front to the last %s of %s: %s were dropped", baseEnv.FormatBytes(uint64(l.total-l.start)), baseEnv.FormatBytes(uint64(l.total)), strings.Join(limits, " and "))
+}
+
+// window - finds where the kept lines start. Lines without a timestamp, like stack traces, go with the line above them
+func (w logWindow) window(path string) (windowedLog, error) {
+	l := windowedLog{path: path}
+	if w.cutoff.IsZero() && !isGzipped(path) {
+		info, err := os.Stat(path)
+		if err != nil {
+			return l, err
+		}
+		l.total = info.Size()
+	} else {
+		reader, err := openLog(path)
+		if err != nil {
+			return l, err
+		}
+		defer reader.Close()
+		ageStart := int64(-1)
+		sawTimestamp := false
+		err = readLines(reader, func(offset int64, line string) {
+			if ageStart >= 0 || w.cutoff.IsZero() {
+				return
+			}
+			if timestamp, ok := parseLogTimestamp(line); ok {
+				sawTimestamp = true
+				if !timestamp.Before(w.cutoff) {
+					ageStart = offset
+				}
+			}
+		}, &l.total)
+		if err != nil {
+			return l, err
+		}
+		// without any timestamp the age of the lines is unknown and they are all kept
+		if ageStart > 0 {
+			l.start = ageStart
+		} else if ageStart < 0 && sawTimestamp {
+			l.start = l.total
+		}
+		l.ageTruncated = l.start > 0
+	}
+	if w.maxBytes > 0 && l.total-l.start > w.maxBytes {
+		l.start = l.total - w.maxBytes
+		l.sizeTruncated = true
+	}
+	return l, nil
+}
+
+// stream - sends the kept lines. Plain files are read from start, a line cut by the size limit is dropped
+func (l windowedLog) stream() chan string {
+	logChannel := make(chan string, 10)
+	go func() {
+		defer close(logChannel)
+		reader, err := openLog(l.path)
+		if err != nil {
+			log.Debug("Log prune failed: ", err)
+			return
+		}
+		defer reader.Close()
+
+		start := l.start
+		if file, ok := reader.(*os.File); ok && start > 0 {
+			if err := seekToLine(file, start); err != nil {
+				log.Debug("Log prune failed: ", err)
+				return
+			}
+			start = 0
+		}
+		var total int64
+		err = readLines(reader, func(offset int64, line string) {
+			if offset >= start {
+				logChannel <- line
+			}
+		}, &total)
+		if err != nil {
+			log.Debug("Log prune failed: ", err)
+		}
+	}()
+	return logChannel
+}
+
+// seekToLine - moves to the first line starting at or after offset
+func seekToLine(file *os.File, offset int64) error {
+	if _, err := file.Seek(offset-1, io.SeekStart); err != nil {
+		return err
+	}
+	previous := make([]byte, 1)
+	if _, err := io.ReadFull(file, previous); err != nil {
+		return err
+	}
+	if previous[0] == '\n' {
+		return nil
+	}
+	// the rest of the cut line is skipped by starting after it
+	rest, err := bufio.NewReader(file).ReadString('\n')
+	if err == io.EOF {
+		_, err = file.Seek(0, io.SeekEnd)
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	_, err = file.Seek(offset+int64(len(rest)), io.SeekStart)
+	return err
+}
+
+func isGzipped(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".gz")
+}
+
+// openLog - opens a log file, decompressing gzipped rotations
+func openLog(path string) (io.ReadCloser, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	if !isGzipped(path) {
+		return file, nil
+	}
+	gzipReader, err := gzip.NewReader(file)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return gzipLog{Reader: gzipReader, file: file}, nil
+}
+
+type gzipLog struct {
+	*gzip.Reader
+	file *os.File
+}
+
+func (g gzipLog) Close() error {
+	g.Reader.Close()
+	return g.file.Close()
+}
+
+// readLines - calls each with every line and the offset it starts at, total is set to the size read
+func readLines(reader io.Reader, each func(int64, string), total *int64) error {
+	buffered := bufio.NewReader(reader)
+	var offset int64
+	for {
+		line, err := buffered.ReadString('\n')
+		if line != "" {
+			each(offset, line)
+			offset += int64(len(line))
+		}
+		if err == io.EOF {
+			*total = offset
+			return nil
+		}
+		if err != nil {
+			*total = offset
+			return err
+		}
+	}
+}
+
+func parseLogTimestamp(line string) (time.Time, bool) {
+	if len(line) > timestampPrefixMax {
+		line = line[:timestampPrefixMax]
+	}
+	for _, format := range logTimestampFormats {
+		match := format.regex.FindString(line)
+		if match == "" {
+			continue
+		}
+		timestamp, err := time.ParseInLocation(format.layout, strings.Replace(match, "T", " ", 1), time.Local)
+		if err == nil {
+			return timestamp, true
+		}
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,202 @@
+package log
+
+import (
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/config"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func collectStream(stream chan string) string {
+	var lines []string
+	for line := range stream {
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "")
+}
+
+var _ = Describe("logWindow", func() {
+	var (
+		dir string
+		now time.Time
+	)
+
+	BeforeEach(func() {
+		dir = GinkgoT().TempDir()
+		now = time.Date(2024, 3, 10, 12, 0, 0, 0, time.Local)
+	})
+
+	writeLog := func(name string, content string) string {
+		path := filepath.Join(dir, name)
+		Expect(os.WriteFile(path, []byte(content), 0600)).To(Succeed())
+		return path
+	}
+
+	Describe("parseLogTimestamp()", func() {
+		It("Should parse the timestamps written by the agents", func() {
+			for _, line := range []string{
+				"2024-03-09 08:15:00,123 (1234) newrelic INFO - connected\n",
+				`{"v":0,"level":30,"time":"2024-03-09T08:15:00.000Z","msg":"connected"}` + "\n",
+				"Mar 9, 2024 08:15:00 +0000 [1234 1] com.newrelic INFO: connected\n",
+				"[2024/03/09 08:15:00] INFO: connected\n",
+			} {
+				timestamp, ok := parseLogTimestamp(line)
+				Expect(ok).To(BeTrue(), line)
+				Expect(timestamp.Format("2006-01-02 15:04")).To(Equal("2024-03-09 08:15"))
+			}
+		})
+		It("Should not find a timestamp in a stack trace line", func() {
+			_, ok := parseLogTimestamp("\tat com.example.Main.run(Main.java:42)\n")
+			Expect(ok).To(BeFalse())
+		})
+	})
+
+	Context("with -log-age", func() {
+		It("Should drop the lines older than the window with their stack traces", func() {
+			path := writeLog("newrelic_agent.log",
+				"2024-03-01 10:00:00 ERROR old failure\n\tat old.Frame\n"+
+					"2024-03-09 10:00:00 ERROR recent failure\n\tat recent.Frame\n")
+			window := newLogWindow(2, 0, now)
+
+			windowed, err := window.window(path)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(collectStream(windowed.stream())).To(Equal("2024-03-09 10:00:00 ERROR recent failure\n\tat recent.Frame\n"))
+			Expect(windowed.truncationNote(window)).To(ContainSubstring("lines older than 2024-03-08"))
+		})
+		It("Should keep every line of a log without timestamps", func() {
+			path := writeLog("newrelic_agent.log", "no timestamp\nat all\n")
+			windowed, err := newLogWindow(2, 0, now).window(path)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(collectStream(windowed.stream())).To(Equal("no timestamp\nat all\n"))
+			Expect(windowed.truncationNote(newLogWindow(2, 0, now))).To(BeEmpty())
+		})
+		It("Should only include the files modified within the window", func() {
+			window := newLogWindow(2, 0, now)
+			Expect(window.includesFile(now.AddDate(0, 0, -1))).To(BeTrue())
+			Expect(window.includesFile(now.AddDate(0, 0, -3))).To(BeFalse())
+			Expect(newLogWindow(0, 0, now).includesFile(now.AddDate(-1, 0, 0))).To(BeTrue())
+		})
+	})
+
+	Context("with -log-max-size", func() {
+		It("Should keep the most recent complete lines within the size", func() {
+			path := writeLog("newrelic_agent.log", "line 1 aaaa\nline 2 bbbb\nline 3 cccc\nline 4 dddd\n")
+			window := logWindow{maxBytes: 30}
+
+			windowed, err := window.window(path)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(collectStream(windowed.stream())).To(Equal("line 3 cccc\nline 4 dddd\n"))
+			Expect(windowed.truncationNote(window)).To(ContainSubstring("limit of -log-max-size"))
+			Expect(windowed.truncationNote(window)).ToNot(ContainSubstring("older than"))
+		})
+		It("Should not truncate a file within the size", func() {
+			path := writeLog("newrelic_agent.log", "line 1\n")
+			windowed, err := logWindow{maxBytes: 30}.window(path)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(windowed.truncationNote(logWindow{maxBytes: 30})).To(BeEmpty())
+			Expect(collectStream(windowed.stream())).To(Equal("line 1\n"))
+		})
+	})
+
+	Context("with a gzipped rotation", func() {
+		It("Should find the newest rotation and read it decompressed", func() {
+			path := writeLog("newrelic-infra.log", "2024-03-10 11:00:00 current\n")
+			older := writeLog("newrelic-infra.log.2", "2024-03-01 11:00:00 older\n")
+			Expect(os.Chtimes(older, now.AddDate(0, 0, -9), now.AddDate(0, 0, -9))).To(Succeed())
+
+			rotation := filepath.Join(dir, "newrelic-infra.log.1.gz")
+			file, err := os.Create(rotation)
+			Expect(err).ToNot(HaveOccurred())
+			writer := gzip.NewWriter(file)
+			_, err = writer.Write([]byte("2024-03-05 11:00:00 old\n2024-03-09 11:00:00 rotated\n"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(writer.Close()).To(Succeed())
+			Expect(file.Close()).To(Succeed())
+
+			found, _, ok := newestRotation(path)
+			Expect(ok).To(BeTrue())
+			Expect(found).To(Equal(rotation))
+
+			windowed, err := newLogWindow(2, 0, now).window(rotation)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(collectStream(windowed.stream())).To(Equal("2024-03-09 11:00:00 rotated\n"))
+		})
+	})
+
+	Describe("windowedEnvelope()", func() {
+		It("Should skip a log older than -log-age and store a gzipped rotation without its extension", func() {
+			recent := writeLog("newrelic_agent.log", "2024-03-10 11:00:00 current\n")
+			Expect(os.Chtimes(recent, now, now)).To(Succeed())
+			old := writeLog("newrelic_agent.log.1.gz", "")
+			Expect(os.Chtimes(old, now.AddDate(0, 0, -5), now.AddDate(0, 0, -5))).To(Succeed())
+			window := newLogWindow(2, 0, now)
+
+			logs := withNewestRotations([]LogElement{{Source: LogSourceData{FullPath: recent}}}, window)
+			Expect(logs).To(HaveLen(1))
+
+			oldLog := LogElement{Source: LogSourceData{FullPath: old}, CanCollect: true}
+			_, collected := windowedEnvelope(&oldLog, window, "Base/Log/Collect")
+			Expect(collected).To(BeFalse())
+			Expect(oldLog.CanCollect).To(BeFalse())
+			Expect(oldLog.ReasonToNotCollect).To(ContainSubstring("-log-age"))
+
+			Expect(os.Chtimes(old, now, now)).To(Succeed())
+			logs = withNewestRotations([]LogElement{{Source: LogSourceData{FullPath: recent}}}, window)
+			Expect(logs).To(HaveLen(2))
+			Expect(logs[1].Source.FoundBy).To(Equal(logPathRotationSource))
+			envelope, collected := windowedEnvelope(&logs[1], logWindow{}, "Base/Log/Collect")
+			Expect(collected).To(BeTrue())
+			Expect(envelope.Path).To(Equal(filepath.Join(dir, "newrelic_agent.log.1")))
+		})
+	})
+
+	Describe("BaseLogCopy Execute() with -log-age and -log-max-size", func() {
+		var originalAge, originalMaxSize int
+		BeforeEach(func() {
+			originalAge, originalMaxSize = config.Flags.LogAge, config.Flags.LogMaxSize
+		})
+		AfterEach(func() {
+			config.Flags.LogAge, config.Flags.LogMaxSize = originalAge, originalMaxSize
+		})
+
+		It("Should copy the lines of the window only", func() {
+			realNow := time.Now()
+			path := writeLog("newrelic_agent.log",
+				realNow.AddDate(0, 0, -3).Format("2006-01-02 15:04:05")+" old line\n"+
+					realNow.Add(-time.Hour).Format("2006-01-02 15:04:05")+" recent line\n")
+			config.Flags.LogAge = 1
+
+			result := BaseLogCopy{}.Execute(tasks.Options{Options: map[string]string{"logpath": path}}, map[string]tasks.Result{})
+			Expect(result.Status).To(Equal(tasks.Success))
+			Expect(result.FilesToCopy).To(HaveLen(1))
+			Expect(result.FilesToCopy[0].Path).To(Equal(path))
+			Expect(collectStream(result.FilesToCopy[0].Stream)).To(Equal(realNow.Add(-time.Hour).Format("2006-01-02 15:04:05") + " recent line\n"))
+			Expect(result.Summary).To(ContainSubstring("truncated to their most recent lines"))
+			Expect(result.Payload.([]LogElement)[0].Truncated).To(BeTrue())
+		})
+
+		It("Should not copy a log last modified before the window", func() {
+			path := writeLog("newrelic_agent.log", "old line\n")
+			Expect(os.Chtimes(path, time.Now().AddDate(0, 0, -5), time.Now().AddDate(0, 0, -5))).To(Succeed())
+			config.Flags.LogAge = 2
+
+			result := BaseLogCopy{}.Execute(tasks.Options{Options: map[string]string{"logpath": path}}, map[string]tasks.Result{})
+			Expect(result.FilesToCopy).To(BeEmpty())
+			Expect(result.Summary).To(ContainSubstring("not collected as they were last modified more than 2 day(s) ago"))
+			Expect(result.Payload.([]LogElement)[0].CanCollect).To(BeFalse())
+		})
+
+		It("Should copy whole files without limits", func() {
+			path := writeLog("newrelic_agent.log", "line\n")
+
+			result := BaseLogCopy{}.Execute(tasks.Options{Options: map[string]string{"logpath": path}}, map[string]tasks.Result{})
+			Expect(result.FilesToCopy).To(Equal([]tasks.FileCopyEnvelope{{Path: path, Identifier: "Base/Log/Copy"}}))
+		})
+	})
+})
@@ -0,0 +1,143 @@
+package log
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+)
+
+const (
+	// windowsEventLogWindow is how far back the event log entries are collected
+	windowsEventLogWindow = 7 * 24 * time.Hour
+	// windowsEventLogMaxEvents caps the number of entries collected, newest first, to keep the payload small
+	windowsEventLogMaxEvents = 200
+	windowsEventLogFileName  = "WindowsEventLog.json"
+)
+
+// WindowsEvent - an entry of the Application or System event log written by a New Relic source
+type WindowsEvent struct {
+	TimeCreated      string
+	LogName          string
+	ProviderName     string
+	ID               int `json:"Id"`
+	LevelDisplayName string
+	Message          string
+}
+
+type collectWindowsEventsFunc func(window time.Duration, maxEvents int) ([]WindowsEvent, error)
+
+// BaseLogWindowsEventLog - This task collects the recent Windows Event Log entries of the New Relic agents
+type BaseLogWindowsEventLog struct {
+	goos          string
+	collectEvents collectWindowsEventsFunc
+}
+
+// Identifier - This returns the Category, Subcategory and Name of each task
+func (t BaseLogWindowsEventLog) Identifier() tasks.Identifier {
+	return tasks.IdentifierFromString("Base/Log/WindowsEventLog")
+}
+
+// Explain - Returns the help text for each individual task
+func (t BaseLogWindowsEventLog) Explain() string {
+	return "Collect recent Windows Event Log entries from New Relic sources"
+}
+
+// Dependencies - Returns the dependencies for each task. The agent detection tasks are only registered on Windows
+func (t BaseLogWindowsEventLog) Dependencies() []string {
+	return windowsAgentTasks
+}
+
+// Execute - The core work within each task
+func (t BaseLogWindowsEventLog) Execute(options tasks.Options, upstream map[string]tasks.Result) tasks.Result {
+	if t.goos != "windows" {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "The Windows Event Log is only collected on Windows",
+		}
+	}
+
+	if !windowsAgentDetected(upstream) {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "No New Relic .NET agent detected, the Windows Event Log was not collected",
+		}
+	}
+
+	events, err := t.collectEvents(windowsEventLogWindow, windowsEventLogMaxEvents)
+	if err != nil {
+		return tasks.Result{
+			Status:  tasks.Error,
+			Summary: "Unable to read the Windows Event Log: " + err.Error(),
+		}
+	}
+
+	if len(events) == 0 {
+		return tasks.Result{
+			Status:  tasks.Info,
+			Summary: "No Application or System event log entries from New Relic sources in the last 7 days",
+		}
+	}
+
+	blob, err := json.MarshalIndent(events, "", "  ")
+	if err != nil {
+		log.Debug("Unable to marshal the Windows events:", err)
+	}
+	// stream the entries to the zip file for troubleshooting alongside the agent logs
+	stream := make(chan string, 1)
+	stream <- string(blob)
+	close(stream)
+
+	return tasks.Result{
+		Status:      tasks.Info,
+		Summary:     fmt.Sprintf("Collected %d Application and System event log entries from New Relic sources in the last 7 days. See %s for more info.", len(events), windowsEventLogFileName),
+		Payload:     events,
+		FilesToCopy: []tasks.FileCopyEnvelope{{Path: windowsEventLogFileName, Stream: stream}},
+	}
+}
+
+// windowsAgentDetected - true when one of the agent detection tasks found an agent
+func windowsAgentDetected(upstream map[string]tasks.Result) bool {
+	for _, agentTask := range windowsAgentTasks {
+		if upstream[agentTask].Status == tasks.Success {
+			return true
+		}
+	}
+	return false
+}
+
+// collectWindowsEvents - reads the newest Application and System event log entries of the providers named after New
+// Relic with PowerShell, which the Get-WinEvent cmdlet has shipped with since Windows 7
+func collectWindowsEvents(window time.Duration, maxEvents int) ([]WindowsEvent, error) {
+	output, err := tasks.CmdExecutor("powershell", "-NoProfile", "-NonInteractive", "-Command", windowsEventsScript(window, maxEvents))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", err.Error(), strings.TrimSpace(string(output)))
+	}
+	return parseWindowsEvents(output)
+}
+
+// windowsEventsScript - the PowerShell script printing the events as a JSON array. Get-WinEvent fails when no event
+// matches, so missing events are not reported as errors
+func windowsEventsScript(window time.Duration, maxEvents int) string {
+	return fmt.Sprintf(`$providers = @(Get-WinEvent -ListProvider '*New Relic*','*NewRelic*' -ErrorAction SilentlyContinue | Select-Object -ExpandProperty Name)
+if ($providers.Count -eq 0) { '[]'; exit }
+$events = @(Get-WinEvent -FilterHashtable @{LogName='Application','System'; ProviderName=$providers; StartTime=(Get-Date).AddSeconds(-%d)} -MaxEvents %d -ErrorAction SilentlyContinue |
+	Select-Object @{n='TimeCreated';e={$_.TimeCreated.ToUniversalTime().ToString('o')}},LogName,ProviderName,Id,LevelDisplayName,Message)
+ConvertTo-Json -Compress -InputObject $events`, int(window.Seconds()), maxEvents)
+}
+
+func parseWindowsEvents(output []byte) ([]WindowsEvent, error) {
+	trimmed := strings.TrimSpace(string(output))
+	if trimmed == "" {
+		return []WindowsEvent{}, nil
+	}
+	var events []WindowsEvent
+	err := json.Unmarshal([]byte(trimmed), &events)
+	if err != nil {
+		return nil, fmt.Errorf("unexpected Get-WinEvent output: %s", err.Error())
+	}
+	return events, nil
+}
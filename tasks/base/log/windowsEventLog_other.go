@@ -0,0 +1,7 @@
+//go:build !windows
+// +build !windows
+
+package log
+
+// windowsAgentTasks is empty outside of Windows, where the .NET Framework agent detection is not registered
+var windowsAgentTasks = []string{}
@@ -0,0 +1,120 @@
+package log
+
+import (
+	"errors"
+	"time"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Base/Log/WindowsEventLog", func() {
+	var (
+		p        BaseLogWindowsEventLog
+		result   tasks.Result
+		upstream map[string]tasks.Result
+		events   []WindowsEvent
+		err      error
+		window   time.Duration
+		max      int
+	)
+
+	BeforeEach(func() {
+		events, err, window, max = nil, nil, 0, 0
+		p = BaseLogWindowsEventLog{
+			goos: "windows",
+			collectEvents: func(w time.Duration, maxEvents int) ([]WindowsEvent, error) {
+				window, max = w, maxEvents
+				return events, err
+			},
+		}
+		upstream = map[string]tasks.Result{
+			"DotNet/Agent/Installed": {Status: tasks.Success},
+		}
+	})
+
+	JustBeforeEach(func() {
+		// the agent detection tasks are only dependencies on Windows
+		originalAgentTasks := windowsAgentTasks
+		windowsAgentTasks = []string{"DotNet/Agent/Installed", "DotNetCore/Agent/Installed"}
+		defer func() { windowsAgentTasks = originalAgentTasks }()
+		result = p.Execute(tasks.Options{}, upstream)
+	})
+
+	Context("when not running on Windows", func() {
+		BeforeEach(func() {
+			p.goos = "linux"
+		})
+		It("should return a None result", func() {
+			Expect(result.Status).To(Equal(tasks.None))
+			Expect(result.Summary).To(Equal("The Windows Event Log is only collected on Windows"))
+		})
+	})
+
+	Context("when no Windows agent was detected", func() {
+		BeforeEach(func() {
+			upstream = map[string]tasks.Result{
+				"DotNet/Agent/Installed":     {Status: tasks.None},
+				"DotNetCore/Agent/Installed": {Status: tasks.Failure},
+			}
+		})
+		It("should return a None result without reading the event log", func() {
+			Expect(result.Status).To(Equal(tasks.None))
+			Expect(max).To(Equal(0))
+		})
+	})
+
+	Context("when New Relic sources logged events", func() {
+		BeforeEach(func() {
+			events = []WindowsEvent{
+				{TimeCreated: "2026-10-13T08:00:00.0000000Z", LogName: "Application", ProviderName: "New Relic .NET Agent", ID: 1000, LevelDisplayName: "Error", Message: "Unable to connect"},
+				{TimeCreated: "2026-10-12T08:00:00.0000000Z", LogName: "Application", ProviderName: "New Relic .NET Agent", ID: 1001, LevelDisplayName: "Information", Message: "Started"},
+			}
+		})
+		It("should bound the window and the number of events", func() {
+			Expect(window).To(Equal(7 * 24 * time.Hour))
+			Expect(max).To(Equal(200))
+		})
+		It("should include the events in the payload and the zip file", func() {
+			Expect(result.Status).To(Equal(tasks.Info))
+			Expect(result.Summary).To(HavePrefix("Collected 2 Application and System event log entries"))
+			Expect(result.Payload).To(Equal(events))
+			Expect(result.FilesToCopy).To(HaveLen(1))
+			Expect(result.FilesToCopy[0].Path).To(Equal("WindowsEventLog.json"))
+			Expect(<-result.FilesToCopy[0].Stream).To(ContainSubstring(`"ProviderName": "New Relic .NET Agent"`))
+		})
+	})
+
+	Context("when there are no events", func() {
+		BeforeEach(func() {
+			events = []WindowsEvent{}
+		})
+		It("should return an Info result without files", func() {
+			Expect(result.Status).To(Equal(tasks.Info))
+			Expect(result.FilesToCopy).To(BeEmpty())
+		})
+	})
+
+	Context("when the event log can't be read", func() {
+		BeforeEach(func() {
+			err = errors.New("exit status 1: Get-WinEvent is not recognized")
+		})
+		It("should return an Error result", func() {
+			Expect(result.Status).To(Equal(tasks.Error))
+			Expect(result.Summary).To(Equal("Unable to read the Windows Event Log: exit status 1: Get-WinEvent is not recognized"))
+		})
+	})
+
+	Describe("parseWindowsEvents()", func() {
+		It("should parse the JSON array printed by the script", func() {
+			parsed, err := parseWindowsEvents([]byte(`[{"TimeCreated":"2026-10-13T08:00:00.0000000Z","LogName":"System","ProviderName":"NewRelicInfra","Id":7,"LevelDisplayName":"Warning","Message":"Restarted"}]` + "\r\n"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(parsed).To(Equal([]WindowsEvent{{TimeCreated: "2026-10-13T08:00:00.0000000Z", LogName: "System", ProviderName: "NewRelicInfra", ID: 7, LevelDisplayName: "Warning", Message: "Restarted"}}))
+		})
+		It("should return an error for unexpected output", func() {
+			_, err := parseWindowsEvents([]byte("Get-WinEvent : The term is not recognized"))
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
@@ -0,0 +1,7 @@
+package log
+
+// windowsAgentTasks detect the agents whose issues the Windows Event Log can explain
+var windowsAgentTasks = []string{
+	"DotNet/Agent/Installed",
+	"DotNetCore/Agent/Installed",
+}
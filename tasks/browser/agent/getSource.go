@@ -46,9 +46,12 @@ func (t BrowserAgentGetSource) Explain() string {
 
 // Dependencies - Returns the dependencies for ech task.
 func (t BrowserAgentGetSource) Dependencies() []string {
-	return []string{}
+	return []string{"Base/Config/ProxyDetect"}
 }
 
+// RequiresNetwork - This task downloads the page source from the -browser-url, it is skipped with -offline
+func (t BrowserAgentGetSource) RequiresNetwork() {}
+
 // Execute - The core work within each task
 func (t BrowserAgentGetSource) Execute(options tasks.Options, upstream map[string]tasks.Result) tasks.Result {
 	log.Debug(options)
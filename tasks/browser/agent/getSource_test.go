@@ -31,7 +31,7 @@ var _ = Describe("Browser/Agent/GetSource", func() {
 
 	Describe("Dependencies()", func() {
 		It("Should return correct slice", func() {
-			expectedDependencies := []string{}
+			expectedDependencies := []string{"Base/Config/ProxyDetect"}
 			Expect(p.Dependencies()).To(Equal(expectedDependencies))
 		})
 	})
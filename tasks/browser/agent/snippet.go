@@ -0,0 +1,187 @@
+package agent
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/helpers/httpHelper"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+)
+
+var (
+	scriptElementRegex = regexp.MustCompile(`(?is)<script\b([^>]*)>(.*?)</script>`)
+	scriptSrcRegex     = regexp.MustCompile(`(?i)\bsrc\s*=\s*["']([^"']+)["']`)
+	scriptTypeRegex    = regexp.MustCompile(`(?i)\btype\s*=\s*["']([^"']+)["']`)
+	headEndRegex       = regexp.MustCompile(`(?i)</head\s*>`)
+	nrLoaderSrcRegex   = regexp.MustCompile(`js-agent\.newrelic\.com/|nr-loader-[\w.-]*\.js`)
+	nreumRegex         = regexp.MustCompile(`\bNREUM\b`)
+	applicationIDRegex = regexp.MustCompile(`applicationID["'\s]*:\s*["']?([0-9,]+)`)
+	licenseKeyRegex    = regexp.MustCompile(`licenseKey["'\s]*:\s*["']([\w-]+)["']`)
+)
+
+// BrowserAgentSnippetPayload - what was found of the Browser agent snippet in the page
+type BrowserAgentSnippetPayload struct {
+	URL             string
+	StatusCode      int
+	SnippetFound    bool
+	LoaderSrc       string
+	NREUMDefined    bool
+	ApplicationID   string
+	LicenseKeyFound bool
+	InHead          bool
+	ScriptsBefore   []string
+}
+
+// BrowserAgentSnippet - This task fetches a page and checks the Browser agent snippet is injected early in its head
+type BrowserAgentSnippet struct {
+	httpGetter func(httpHelper.RequestWrapper) (*http.Response, error)
+}
+
+// Identifier - This returns the Category, Subcategory and Name of each task
+func (t BrowserAgentSnippet) Identifier() tasks.Identifier {
+	return tasks.IdentifierFromString("Browser/Agent/Snippet")
+}
+
+// Explain - Returns the help text for each individual task
+func (t BrowserAgentSnippet) Explain() string {
+	return "Check the New Relic Browser agent snippet is injected at the top of the page from provided URL"
+	// ./nrdiag -browser-url http://thecustomers-website-url --suites browser
+}
+
+// Dependencies - Returns the dependencies for each task.
+func (t BrowserAgentSnippet) Dependencies() []string {
+	return []string{"Base/Config/ProxyDetect"}
+}
+
+// RequiresNetwork - This task requests the page given with -browser-url, it is skipped with -offline
+func (t BrowserAgentSnippet) RequiresNetwork() {}
+
+// Execute - The core work within each task
+func (t BrowserAgentSnippet) Execute(options tasks.Options, upstream map[string]tasks.Result) tasks.Result {
+	url := options.Options["url"]
+	if url == "" {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "This health check requires the usage of the command option '-browser-url'. Please re-run " + tasks.ThisProgramFullName + " using the flag in this manner: ./nrdiag -browser-url http://YOUR-WEBSITE-URL -suites browser",
+		}
+	}
+
+	// the request goes through the configured proxy, like the page requests of the visitors behind it would
+	resp, err := t.httpGetter(httpHelper.RequestWrapper{
+		Method: "GET",
+		URL:    url,
+	})
+	if err != nil {
+		return tasks.Result{
+			Status:  tasks.Failure,
+			Summary: fmt.Sprintf("Failed to connect to %s. Please make sure to add a protocol to the URL or verify connectivity. Encountered error: %s", url, err.Error()),
+		}
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return tasks.Result{
+			Status:  tasks.Failure,
+			Summary: fmt.Sprintf("Failed to read the page from %s: %s", url, err.Error()),
+		}
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return tasks.Result{
+			Status:  tasks.Failure,
+			Summary: fmt.Sprintf("%s returned the status %s, the Browser agent snippet could not be checked.", url, resp.Status),
+		}
+	}
+
+	payload := findBrowserSnippet(string(body))
+	payload.URL = url
+	payload.StatusCode = resp.StatusCode
+
+	if !payload.SnippetFound {
+		return tasks.Result{
+			Status:  tasks.Warning,
+			Summary: fmt.Sprintf("%s loaded but no New Relic Browser agent snippet was found in it: neither the NREUM loader script nor a js-agent.newrelic.com script. If the application relies on APM auto-injection, check it is enabled for this page.", url),
+			URL:     "https://docs.newrelic.com/docs/browser/browser-monitoring/installation/install-browser-monitoring-agent",
+			Payload: payload,
+		}
+	}
+
+	var problems []string
+	if !payload.NREUMDefined {
+		problems = append(problems, "the NREUM object is not defined by the page, the loader script is incomplete.")
+	}
+	if payload.ApplicationID == "" {
+		problems = append(problems, "no applicationID was found in the snippet, the agent does not know which application to report to.")
+	}
+	if !payload.InHead {
+		problems = append(problems, "the snippet is not in the <head> of the page.")
+	}
+	if len(payload.ScriptsBefore) > 0 {
+		problems = append(problems, fmt.Sprintf("the snippet is placed after %d other script(s) (%s). It must be the first script of the <head> to wrap the browser APIs before they are used.", len(payload.ScriptsBefore), strings.Join(payload.ScriptsBefore, ", ")))
+	}
+	if len(problems) > 0 {
+		return tasks.Result{
+			Status:  tasks.Warning,
+			Summary: "The New Relic Browser agent snippet was found in " + url + " but:\n - " + strings.Join(problems, "\n - "),
+			URL:     "https://docs.newrelic.com/docs/browser/browser-monitoring/installation/install-browser-monitoring-agent",
+			Payload: payload,
+		}
+	}
+	return tasks.Result{
+		Status:  tasks.Success,
+		Summary: fmt.Sprintf("The New Relic Browser agent snippet for application %s is the first script of the <head> of %s.", payload.ApplicationID, url),
+		Payload: payload,
+	}
+}
+
+// findBrowserSnippet - finds the first script defining NREUM or loading the agent from js-agent.newrelic.com, and the
+// scripts executed before it. Non JavaScript scripts like JSON-LD don't count
+func findBrowserSnippet(page string) BrowserAgentSnippetPayload {
+	payload := BrowserAgentSnippetPayload{ScriptsBefore: []string{}}
+	headEnd := len(page)
+	if loc := headEndRegex.FindStringIndex(page); loc != nil {
+		headEnd = loc[0]
+	}
+	payload.NREUMDefined = strings.Contains(page, "window.NREUM") || strings.Contains(page, "NREUM=")
+
+	var before []string
+	for _, loc := range scriptElementRegex.FindAllStringSubmatchIndex(page, -1) {
+		attributes := page[loc[2]:loc[3]]
+		content := page[loc[4]:loc[5]]
+		src := ""
+		if match := scriptSrcRegex.FindStringSubmatch(attributes); match != nil {
+			src = match[1]
+		}
+		if match := scriptTypeRegex.FindStringSubmatch(attributes); match != nil && !isJavaScriptType(match[1]) {
+			continue
+		}
+
+		isLoader := nrLoaderSrcRegex.MatchString(src)
+		if !isLoader && !nreumRegex.MatchString(content) {
+			if src == "" {
+				src = "inline script"
+			}
+			before = append(before, src)
+			continue
+		}
+
+		payload.SnippetFound = true
+		payload.LoaderSrc = src
+		payload.InHead = loc[0] < headEnd
+		payload.ScriptsBefore = append(payload.ScriptsBefore, before...)
+		if match := applicationIDRegex.FindStringSubmatch(page); match != nil {
+			payload.ApplicationID = match[1]
+		}
+		payload.LicenseKeyFound = licenseKeyRegex.MatchString(page)
+		break
+	}
+	return payload
+}
+
+func isJavaScriptType(scriptType string) bool {
+	scriptType = strings.ToLower(strings.TrimSpace(scriptType))
+	return scriptType == "" || scriptType == "module" || strings.Contains(scriptType, "javascript") || strings.Contains(scriptType, "ecmascript")
+}
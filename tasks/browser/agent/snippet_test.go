@@ -0,0 +1,148 @@
+package agent
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/helpers/httpHelper"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+const (
+	snippetLoader = `<script type="text/javascript">;window.NREUM||(NREUM={});NREUM.init={distributed_tracing:{enabled:true}};
+NREUM.loader_config={accountID:"1",trustKey:"1",agentID:"601",licenseKey:"NRJS-abc123",applicationID:"601"};
+NREUM.info={beacon:"bam.nr-data.net",errorBeacon:"bam.nr-data.net",licenseKey:"NRJS-abc123",applicationID:"601",sa:1}</script>`
+	snippetPageGood = `<!DOCTYPE html><html><head><meta charset="utf-8">` + snippetLoader + `
+<script type="application/ld+json">{"@type":"Organization"}</script>
+<script src="/js/jquery.min.js"></script></head><body></body></html>`
+	snippetPageLate = `<html><head><script src="https://www.googletagmanager.com/gtag/js"></script><script>dataLayer = [];</script>` + snippetLoader + `</head><body></body></html>`
+	snippetPageNone = `<html><head><script src="/js/app.js"></script></head><body>Hello</body></html>`
+	snippetPageBody = `<html><head><title>shop</title></head><body><script src="https://js-agent.newrelic.com/nr-loader-spa-1.250.0.min.js"></script></body></html>`
+)
+
+var _ = Describe("Browser/Agent/Snippet", func() {
+	var (
+		p          BrowserAgentSnippet
+		options    tasks.Options
+		page       string
+		statusCode int
+		requestErr error
+		requested  httpHelper.RequestWrapper
+		result     tasks.Result
+	)
+
+	BeforeEach(func() {
+		options = tasks.Options{Options: map[string]string{"url": "https://shop.example.com"}}
+		statusCode = 200
+		requestErr = nil
+	})
+
+	JustBeforeEach(func() {
+		p = BrowserAgentSnippet{
+			httpGetter: func(wrapper httpHelper.RequestWrapper) (*http.Response, error) {
+				requested = wrapper
+				if requestErr != nil {
+					return nil, requestErr
+				}
+				return &http.Response{
+					StatusCode: statusCode,
+					Status:     http.StatusText(statusCode),
+					Body:       ioutil.NopCloser(strings.NewReader(page)),
+				}, nil
+			},
+		}
+		result = p.Execute(options, map[string]tasks.Result{})
+	})
+
+	Context("when no URL was given", func() {
+		BeforeEach(func() {
+			options = tasks.Options{Options: map[string]string{}}
+		})
+		It("should return a None result", func() {
+			Expect(result.Status).To(Equal(tasks.None))
+		})
+	})
+
+	Context("when the page can't be fetched", func() {
+		BeforeEach(func() {
+			requestErr = errors.New("proxyconnect tcp: dial tcp 10.0.0.1:3128: connect: connection refused")
+		})
+		It("should return a Failure result", func() {
+			Expect(result.Status).To(Equal(tasks.Failure))
+			Expect(result.Summary).To(ContainSubstring("connection refused"))
+		})
+	})
+
+	Context("when the page returns an error status", func() {
+		BeforeEach(func() {
+			statusCode = 503
+			page = "unavailable"
+		})
+		It("should return a Failure result", func() {
+			Expect(result.Status).To(Equal(tasks.Failure))
+			Expect(result.Summary).To(ContainSubstring("returned the status Service Unavailable"))
+		})
+	})
+
+	Context("when the snippet is the first script of the head", func() {
+		BeforeEach(func() {
+			page = snippetPageGood
+		})
+		It("should fetch the page without bypassing the proxy", func() {
+			Expect(requested.URL).To(Equal("https://shop.example.com"))
+			Expect(requested.BypassProxy).To(BeFalse())
+		})
+		It("should return a Success result with what was found", func() {
+			Expect(result.Status).To(Equal(tasks.Success))
+			Expect(result.Summary).To(Equal("The New Relic Browser agent snippet for application 601 is the first script of the <head> of https://shop.example.com."))
+			Expect(result.Payload).To(Equal(BrowserAgentSnippetPayload{
+				URL:             "https://shop.example.com",
+				StatusCode:      200,
+				SnippetFound:    true,
+				NREUMDefined:    true,
+				ApplicationID:   "601",
+				LicenseKeyFound: true,
+				InHead:          true,
+				ScriptsBefore:   []string{},
+			}))
+		})
+	})
+
+	Context("when the snippet is placed after other scripts", func() {
+		BeforeEach(func() {
+			page = snippetPageLate
+		})
+		It("should return a Warning result listing them", func() {
+			Expect(result.Status).To(Equal(tasks.Warning))
+			Expect(result.Summary).To(ContainSubstring("the snippet is placed after 2 other script(s) (https://www.googletagmanager.com/gtag/js, inline script)."))
+		})
+	})
+
+	Context("when the page has no snippet", func() {
+		BeforeEach(func() {
+			page = snippetPageNone
+		})
+		It("should return a Warning result", func() {
+			Expect(result.Status).To(Equal(tasks.Warning))
+			Expect(result.Summary).To(ContainSubstring("no New Relic Browser agent snippet was found"))
+			Expect(result.Payload.(BrowserAgentSnippetPayload).SnippetFound).To(BeFalse())
+		})
+	})
+
+	Context("when only the loader script is included, in the body", func() {
+		BeforeEach(func() {
+			page = snippetPageBody
+		})
+		It("should return a Warning result with each problem", func() {
+			Expect(result.Status).To(Equal(tasks.Warning))
+			Expect(result.Summary).To(ContainSubstring("the NREUM object is not defined"))
+			Expect(result.Summary).To(ContainSubstring("no applicationID was found"))
+			Expect(result.Summary).To(ContainSubstring("the snippet is not in the <head> of the page."))
+			Expect(result.Payload.(BrowserAgentSnippetPayload).LoaderSrc).To(Equal("https://js-agent.newrelic.com/nr-loader-spa-1.250.0.min.js"))
+		})
+	})
+})
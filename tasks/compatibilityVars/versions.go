@@ -70,11 +70,6 @@ var SupportedForJavaAgentLegacy = map[string][]string{
 	"JRockit": {"1-1.6.0.50"},
 }
 
-var NodeSupportedVersions = map[string][]string{
-	"12": {"6.0.0+"},
-	"10": {"4.6.0-7.*"},
-}
-
 // https://docs.newrelic.com/docs/agents/net-agent/getting-started/net-agent-compatibility-requirements-net-framework#net-version
 // .NET framework as keys and .NET agent as values
 var DotnetFrameworkSupportedVersions = map[string][]string{
@@ -0,0 +1,13 @@
+package agent
+
+import (
+	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+)
+
+// RegisterWith - will register the plugins of this package that also check the .NET Core agent outside Windows
+func RegisterWith(registrationFunc func(tasks.Task, bool)) {
+	log.Debug("Registering DotNet/Agent/Profiler")
+
+	registrationFunc(DotNetAgentProfiler{readRegistry: readProfilerRegistry}, true)
+}
@@ -0,0 +1,170 @@
+package agent
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+)
+
+// The CLSIDs New Relic profilers register with, see DotNet/Profiler/EnvVarKey for the .NET Framework ones
+const (
+	nrFrameworkProfilerClsid    = `{71DA0A04-7777-4EC6-9643-7D28B46A8A41}`
+	nrOldFrameworkProfilerClsid = `{FF68FEB9-E58A-4B75-A2B8-90CE7D915A26}`
+	nrCoreProfilerClsid         = `{36032161-FFC0-4B61-B559-F6C5D41BAE5A}`
+)
+
+// profilerVariables - the variables enabling a profiler, for .NET Framework (COR_) then .NET Core (CORECLR_)
+var profilerVariables = []struct {
+	runtime        string
+	enableVariable string
+	clsidVariable  string
+	pathVariable   string
+	nrClsids       []string
+}{
+	{".NET Framework", "COR_ENABLE_PROFILING", "COR_PROFILER", "COR_PROFILER_PATH", []string{nrFrameworkProfilerClsid, nrOldFrameworkProfilerClsid}},
+	{".NET Core", "CORECLR_ENABLE_PROFILING", "CORECLR_PROFILER", "CORECLR_PROFILER_PATH", []string{nrCoreProfilerClsid}},
+}
+
+// ProfilerSetting - a profiler variable and where it was found
+type ProfilerSetting struct {
+	// Source is the environment of this process, or the registry key setting it on Windows
+	Source string
+	Name   string
+	Value  string
+}
+
+// DotNetAgentProfiler - This task validates the variables enabling the New Relic profiler
+type DotNetAgentProfiler struct {
+	readRegistry func() ([]ProfilerSetting, error)
+}
+
+// Identifier - This returns the Category, Subcategory and Name of each task
+func (p DotNetAgentProfiler) Identifier() tasks.Identifier {
+	return tasks.IdentifierFromString("DotNet/Agent/Profiler")
+}
+
+// Explain - Returns the help text for each individual task
+func (p DotNetAgentProfiler) Explain() string {
+	return "Validate the environment variables enabling the New Relic .NET profiler"
+}
+
+// Dependencies - The agent detection tasks differ by OS, see profilerAgentTasks
+func (p DotNetAgentProfiler) Dependencies() []string {
+	return append([]string{"Base/Env/CollectEnvVars"}, profilerAgentTasks...)
+}
+
+// Execute - Reads the profiler variables from the environment and, on Windows, the registry. Profiling disabled for the
+// New Relic profiler or another profiler set in its place keeps the agent from loading
+func (p DotNetAgentProfiler) Execute(options tasks.Options, upstream map[string]tasks.Result) tasks.Result {
+	agentDetected := false
+	for _, agentTask := range profilerAgentTasks {
+		agentDetected = agentDetected || upstream[agentTask].Status == tasks.Success
+	}
+	if !agentDetected {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: tasks.NoAgentUpstreamSummary + strings.Join(profilerAgentTasks, ", "),
+		}
+	}
+
+	envVars, _ := upstream["Base/Env/CollectEnvVars"].Payload.(map[string]string)
+	settings := profilerSettingsFromEnv(envVars)
+	registrySettings, err := p.readRegistry()
+	if err != nil {
+		return tasks.Result{
+			Status:  tasks.Error,
+			Summary: "Unable to read the profiler settings from the registry: " + err.Error(),
+		}
+	}
+	settings = append(settings, registrySettings...)
+
+	if len(settings) == 0 {
+		return tasks.Result{
+			Status:  tasks.Warning,
+			Summary: "None of the environment variables enabling the .NET profiler are set: " + profilerVariableNames() + ". The New Relic .NET agent can't instrument the application without them, re-run the agent installer or set them as documented.",
+			URL:     "https://docs.newrelic.com/docs/apm/agents/net-agent/other-installation/understanding-net-agent-environment-variables/",
+		}
+	}
+
+	problems := validateProfilerSettings(settings)
+	if len(problems) > 0 {
+		return tasks.Result{
+			Status:  tasks.Failure,
+			Summary: "The .NET profiler settings keep the New Relic .NET agent from loading:\n" + strings.Join(problems, "\n"),
+			URL:     "https://docs.newrelic.com/docs/agents/net-agent/troubleshooting/profiler-conflicts",
+			Payload: settings,
+		}
+	}
+
+	return tasks.Result{
+		Status:  tasks.Success,
+		Summary: "The New Relic .NET profiler is enabled and no other profiler is registered",
+		Payload: settings,
+	}
+}
+
+// profilerSettingsFromEnv - the profiler variables set in the environment of this process. The names of environment
+// variables are case insensitive on Windows
+func profilerSettingsFromEnv(envVars map[string]string) []ProfilerSetting {
+	var settings []ProfilerSetting
+	for _, variables := range profilerVariables {
+		for _, name := range []string{variables.enableVariable, variables.clsidVariable, variables.pathVariable} {
+			for key, value := range envVars {
+				if strings.EqualFold(key, name) {
+					settings = append(settings, ProfilerSetting{Source: "environment", Name: name, Value: value})
+				}
+			}
+		}
+	}
+	return settings
+}
+
+// validateProfilerSettings - returns a line for each source disabling profiling or registering another profiler
+func validateProfilerSettings(settings []ProfilerSetting) []string {
+	var sources []string
+	settingsBySource := map[string]map[string]string{}
+	for _, setting := range settings {
+		if _, ok := settingsBySource[setting.Source]; !ok {
+			sources = append(sources, setting.Source)
+			settingsBySource[setting.Source] = map[string]string{}
+		}
+		settingsBySource[setting.Source][strings.ToUpper(setting.Name)] = strings.TrimSpace(setting.Value)
+	}
+
+	var problems []string
+	for _, source := range sources {
+		values := settingsBySource[source]
+		for _, variables := range profilerVariables {
+			clsid, clsidSet := values[variables.clsidVariable]
+			if !clsidSet {
+				continue
+			}
+			if !isNewRelicClsid(clsid, variables.nrClsids) {
+				problems = append(problems, fmt.Sprintf("%s: %s=%s registers another profiler than New Relic's %s profiler %s. Only one profiler can be attached to a %s application.", source, variables.clsidVariable, clsid, variables.runtime, variables.nrClsids[0], variables.runtime))
+				continue
+			}
+			if enabled := values[variables.enableVariable]; enabled != "1" {
+				problems = append(problems, fmt.Sprintf("%s: %s=%q disables the New Relic %s profiler, it must be set to 1.", source, variables.enableVariable, enabled, variables.runtime))
+			}
+		}
+	}
+	return problems
+}
+
+func isNewRelicClsid(clsid string, nrClsids []string) bool {
+	for _, nrClsid := range nrClsids {
+		if strings.EqualFold(clsid, nrClsid) {
+			return true
+		}
+	}
+	return false
+}
+
+func profilerVariableNames() string {
+	var names []string
+	for _, variables := range profilerVariables {
+		names = append(names, variables.enableVariable, variables.clsidVariable)
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,12 @@
+//go:build !windows
+// +build !windows
+
+package agent
+
+// profilerAgentTasks - only the .NET Core agent runs outside Windows
+var profilerAgentTasks = []string{"DotNetCore/Agent/Installed"}
+
+// readProfilerRegistry - there is no registry outside Windows, the profiler is only enabled by environment variables
+func readProfilerRegistry() ([]ProfilerSetting, error) {
+	return nil, nil
+}
@@ -0,0 +1,116 @@
+package agent
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+)
+
+func profilerUpstream(envVars map[string]string) map[string]tasks.Result {
+	upstream := map[string]tasks.Result{
+		"Base/Env/CollectEnvVars": {Status: tasks.Info, Payload: envVars},
+	}
+	for _, agentTask := range profilerAgentTasks {
+		upstream[agentTask] = tasks.Result{Status: tasks.Success}
+	}
+	return upstream
+}
+
+func noRegistry() ([]ProfilerSetting, error) {
+	return nil, nil
+}
+
+func TestDotNetAgentProfiler_Execute(t *testing.T) {
+	tests := []struct {
+		name         string
+		upstream     map[string]tasks.Result
+		readRegistry func() ([]ProfilerSetting, error)
+		wantStatus   tasks.Status
+		wantSummary  string
+	}{
+		{
+			name:         "no agent detected",
+			upstream:     map[string]tasks.Result{},
+			readRegistry: noRegistry,
+			wantStatus:   tasks.None,
+		},
+		{
+			name:         "New Relic .NET Core profiler enabled",
+			upstream:     profilerUpstream(map[string]string{"CORECLR_ENABLE_PROFILING": "1", "CORECLR_PROFILER": "{36032161-FFC0-4B61-B559-F6C5D41BAE5A}", "PATH": "/usr/bin"}),
+			readRegistry: noRegistry,
+			wantStatus:   tasks.Success,
+		},
+		{
+			name:         "profiling disabled",
+			upstream:     profilerUpstream(map[string]string{"CORECLR_ENABLE_PROFILING": "0", "CORECLR_PROFILER": "{36032161-ffc0-4b61-b559-f6c5d41bae5a}"}),
+			readRegistry: noRegistry,
+			wantStatus:   tasks.Failure,
+			wantSummary:  `environment: CORECLR_ENABLE_PROFILING="0" disables the New Relic .NET Core profiler, it must be set to 1.`,
+		},
+		{
+			name:         "another profiler registered",
+			upstream:     profilerUpstream(map[string]string{"COR_ENABLE_PROFILING": "1", "COR_PROFILER": "{846F5F1C-F9AE-4B07-969E-05C26BC060D8}"}),
+			readRegistry: noRegistry,
+			wantStatus:   tasks.Failure,
+			wantSummary:  "environment: COR_PROFILER={846F5F1C-F9AE-4B07-969E-05C26BC060D8} registers another profiler than New Relic's .NET Framework profiler {71DA0A04-7777-4EC6-9643-7D28B46A8A41}.",
+		},
+		{
+			name:     "another profiler registered for IIS",
+			upstream: profilerUpstream(map[string]string{"COR_ENABLE_PROFILING": "1", "COR_PROFILER": "{71DA0A04-7777-4EC6-9643-7D28B46A8A41}"}),
+			readRegistry: func() ([]ProfilerSetting, error) {
+				return []ProfilerSetting{
+					{Source: `HKLM\SYSTEM\CurrentControlSet\Services\W3SVC\Environment`, Name: "COR_ENABLE_PROFILING", Value: "1"},
+					{Source: `HKLM\SYSTEM\CurrentControlSet\Services\W3SVC\Environment`, Name: "COR_PROFILER", Value: "{B7038F67-52FC-4DA2-AB02-969B3C1EDA03}"},
+				}, nil
+			},
+			wantStatus:  tasks.Failure,
+			wantSummary: `HKLM\SYSTEM\CurrentControlSet\Services\W3SVC\Environment: COR_PROFILER={B7038F67-52FC-4DA2-AB02-969B3C1EDA03}`,
+		},
+		{
+			name:         "no profiler variables",
+			upstream:     profilerUpstream(map[string]string{"PATH": "/usr/bin"}),
+			readRegistry: noRegistry,
+			wantStatus:   tasks.Warning,
+			wantSummary:  "COR_ENABLE_PROFILING, COR_PROFILER, CORECLR_ENABLE_PROFILING, CORECLR_PROFILER",
+		},
+		{
+			name:     "registry error",
+			upstream: profilerUpstream(map[string]string{}),
+			readRegistry: func() ([]ProfilerSetting, error) {
+				return nil, errors.New("Access is denied.")
+			},
+			wantStatus:  tasks.Error,
+			wantSummary: "Unable to read the profiler settings from the registry: Access is denied.",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := DotNetAgentProfiler{readRegistry: tt.readRegistry}
+			got := p.Execute(tasks.Options{}, tt.upstream)
+			if got.Status != tt.wantStatus {
+				t.Errorf("Execute() status = %v, want %v: %s", got.Status, tt.wantStatus, got.Summary)
+			}
+			if !strings.Contains(got.Summary, tt.wantSummary) {
+				t.Errorf("Execute() summary = %q, want it to contain %q", got.Summary, tt.wantSummary)
+			}
+		})
+	}
+}
+
+func Test_profilerSettingsFromEnv(t *testing.T) {
+	got := profilerSettingsFromEnv(map[string]string{
+		"Cor_Profiler":          "{71DA0A04-7777-4EC6-9643-7D28B46A8A41}",
+		"CORECLR_PROFILER_PATH": "/usr/local/newrelic-dotnet-agent/libNewRelicProfiler.so",
+		"NEW_RELIC_APP_NAME":    "checkout",
+	})
+	want := []ProfilerSetting{
+		{Source: "environment", Name: "COR_PROFILER", Value: "{71DA0A04-7777-4EC6-9643-7D28B46A8A41}"},
+		{Source: "environment", Name: "CORECLR_PROFILER_PATH", Value: "/usr/local/newrelic-dotnet-agent/libNewRelicProfiler.so"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("profilerSettingsFromEnv() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,73 @@
+//go:build windows
+// +build windows
+
+package agent
+
+import (
+	"strings"
+
+	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
+	"golang.org/x/sys/windows/registry"
+)
+
+// profilerAgentTasks - the .NET Framework and .NET Core agents are both detected on Windows
+var profilerAgentTasks = []string{"DotNet/Agent/Installed", "DotNetCore/Agent/Installed"}
+
+const systemEnvironmentRegPath = `System\CurrentControlSet\Control\Session Manager\Environment`
+
+// iisServiceRegPaths - the services whose Environment value sets the variables of the IIS worker processes
+var iisServiceRegPaths = []string{
+	`SYSTEM\CurrentControlSet\Services\W3SVC`,
+	`SYSTEM\CurrentControlSet\Services\WAS`,
+}
+
+// readProfilerRegistry - the profiler variables set system wide and for IIS. Missing keys and values are skipped
+func readProfilerRegistry() ([]ProfilerSetting, error) {
+	var settings []ProfilerSetting
+
+	systemKey, err := registry.OpenKey(registry.LOCAL_MACHINE, systemEnvironmentRegPath, registry.QUERY_VALUE)
+	if err != nil {
+		log.Debug("Error opening the system Environment Reg Key. Error = ", err.Error())
+	} else {
+		for _, variables := range profilerVariables {
+			for _, name := range []string{variables.enableVariable, variables.clsidVariable, variables.pathVariable} {
+				if value, _, err := systemKey.GetStringValue(name); err == nil {
+					settings = append(settings, ProfilerSetting{Source: `HKLM\` + systemEnvironmentRegPath, Name: name, Value: value})
+				}
+			}
+		}
+		systemKey.Close()
+	}
+
+	for _, servicePath := range iisServiceRegPaths {
+		serviceKey, err := registry.OpenKey(registry.LOCAL_MACHINE, servicePath, registry.QUERY_VALUE)
+		if err != nil {
+			log.Debug("Error opening the Reg Key", servicePath, "Error = ", err.Error())
+			continue
+		}
+		environment, _, err := serviceKey.GetStringsValue("Environment")
+		serviceKey.Close()
+		if err != nil {
+			continue
+		}
+		for _, variable := range environment {
+			nameAndValue := strings.SplitN(variable, "=", 2)
+			if len(nameAndValue) != 2 || !isProfilerVariable(nameAndValue[0]) {
+				continue
+			}
+			settings = append(settings, ProfilerSetting{Source: `HKLM\` + servicePath + `\Environment`, Name: nameAndValue[0], Value: nameAndValue[1]})
+		}
+	}
+	return settings, nil
+}
+
+func isProfilerVariable(name string) bool {
+	for _, variables := range profilerVariables {
+		for _, profilerVariable := range []string{variables.enableVariable, variables.clsidVariable, variables.pathVariable} {
+			if strings.EqualFold(name, profilerVariable) {
+				return true
+			}
+		}
+	}
+	return false
+}
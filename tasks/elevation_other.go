@@ -0,0 +1,11 @@
+//go:build !windows
+// +build !windows
+
+package tasks
+
+import "os"
+
+// IsElevated returns true if the process runs as root
+func IsElevated() bool {
+	return os.Geteuid() == 0
+}
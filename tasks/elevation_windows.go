@@ -0,0 +1,11 @@
+//go:build windows
+// +build windows
+
+package tasks
+
+import "golang.org/x/sys/windows"
+
+// IsElevated returns true if the process runs from an elevated prompt, as Administrator
+func IsElevated() bool {
+	return windows.GetCurrentProcessToken().IsElevated()
+}
@@ -1,6 +1,9 @@
 package agent
 
 import (
+	"os"
+	"path/filepath"
+
 	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
 	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
 )
@@ -9,4 +12,9 @@ import (
 func RegisterWith(registrationFunc func(tasks.Task, bool)) {
 	log.Debug("Registering Go/Agent/*")
 
+	registrationFunc(GoAgentIntegration{
+		getwd:    os.Getwd,
+		readFile: os.ReadFile,
+		walkDir:  filepath.WalkDir,
+	}, true)
 }
@@ -0,0 +1,207 @@
+package agent
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+)
+
+const (
+	goAgentModule = "github.com/newrelic/go-agent"
+	// goSourceFilesMax caps how many .go files are scanned, the detection stops being useful long before that
+	goSourceFilesMax = 5000
+)
+
+var (
+	goModRequireRegex     = regexp.MustCompile(`^\s*(?:require\s+)?(` + regexp.QuoteMeta(goAgentModule) + `(?:/v[0-9]+)?)\s+(v\S+)`)
+	goAgentImportRegex    = regexp.MustCompile(`"(` + regexp.QuoteMeta(goAgentModule) + `(?:/v[0-9]+)?(?:/[\w./-]+)?)"`)
+	newApplicationRegex   = regexp.MustCompile(`\bNewApplication\(`)
+	instrumentationRegex  = regexp.MustCompile(`\b(WrapHandle|WrapHandleFunc|StartTransaction|WrapListen)\(`)
+	integrationImportPath = regexp.MustCompile(`/integrations/(nr\w+)`)
+	skippedGoDirs         = map[string]bool{"vendor": true, ".git": true, "node_modules": true, "testdata": true}
+
+	errGoSourceFilesMax = errors.New("too many Go source files")
+)
+
+// GoAgentIntegrationPayload - where the Go agent is required and wired up in the project
+type GoAgentIntegrationPayload struct {
+	GoMod                string
+	Module               string
+	Version              string
+	ImportFiles          []string
+	NewApplicationFiles  []string
+	InstrumentationFiles []string
+	Integrations         []string
+}
+
+// GoAgentIntegration - This task looks for the Go agent dependency and its setup in the project source
+type GoAgentIntegration struct {
+	getwd    func() (string, error)
+	readFile func(string) ([]byte, error)
+	walkDir  func(string, fs.WalkDirFunc) error
+}
+
+// Identifier - This returns the Category, Subcategory and Name of each task
+func (t GoAgentIntegration) Identifier() tasks.Identifier {
+	return tasks.IdentifierFromString("Go/Agent/Integration")
+}
+
+// Explain - Returns the help text for each individual task
+func (t GoAgentIntegration) Explain() string {
+	return "Check the Go project in the working directory depends on and sets up the New Relic Go agent"
+}
+
+// Dependencies - Returns the dependencies for each task.
+func (t GoAgentIntegration) Dependencies() []string {
+	return []string{}
+}
+
+// Execute - The core work within each task
+func (t GoAgentIntegration) Execute(options tasks.Options, upstream map[string]tasks.Result) tasks.Result {
+	root, err := t.getwd()
+	if err != nil {
+		return tasks.Result{
+			Status:  tasks.Error,
+			Summary: "Unable to read the working directory: " + err.Error(),
+		}
+	}
+
+	integration := GoAgentIntegrationPayload{}
+	goModPath := filepath.Join(root, "go.mod")
+	goMod, goModErr := t.readFile(goModPath)
+	if goModErr == nil {
+		integration.GoMod = goModPath
+		integration.Module, integration.Version = parseGoAgentRequire(goMod)
+	}
+
+	goFiles, err := t.scanGoSources(root, &integration)
+	if err != nil {
+		log.Debug("Error scanning the Go sources of", root, ":", err)
+	}
+	if goModErr != nil && goFiles == 0 {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "No go.mod or Go source file was found in " + root + ". Run " + tasks.ThisProgramFullName + " from the root of your Go project to check the Go agent integration.",
+		}
+	}
+
+	if integration.Module == "" && len(integration.ImportFiles) == 0 {
+		return tasks.Result{
+			Status:  tasks.Warning,
+			Summary: "The New Relic Go agent (" + goAgentModule + ") is not a dependency of the Go project in " + root + ".",
+			URL:     "https://docs.newrelic.com/docs/apm/agents/go-agent/installation/install-new-relic-go/",
+			Payload: integration,
+		}
+	}
+
+	// static detection is best effort, the application may be set up in a dependency or through a wrapper
+	dependency := goAgentModule
+	if integration.Module != "" {
+		dependency = integration.Module + " " + integration.Version
+	}
+	if len(integration.NewApplicationFiles) == 0 {
+		return tasks.Result{
+			Status:  tasks.Warning,
+			Summary: "The project depends on " + dependency + " but no call to newrelic.NewApplication was found. The agent only connects once the application is created.",
+			URL:     "https://docs.newrelic.com/docs/apm/agents/go-agent/installation/install-new-relic-go/",
+			Payload: integration,
+		}
+	}
+	if len(integration.InstrumentationFiles) == 0 && len(integration.Integrations) == 0 {
+		return tasks.Result{
+			Status:  tasks.Warning,
+			Summary: fmt.Sprintf("The project depends on %s and creates the application in %s, but no transaction instrumentation (WrapHandle, StartTransaction or an integration package) was found. Without transactions no data is reported.", dependency, strings.Join(integration.NewApplicationFiles, ", ")),
+			URL:     "https://docs.newrelic.com/docs/apm/agents/go-agent/instrumentation/instrument-go-transactions/",
+			Payload: integration,
+		}
+	}
+
+	summary := fmt.Sprintf("The Go agent integration appears present: the project depends on %s, creates the application in %s", dependency, strings.Join(integration.NewApplicationFiles, ", "))
+	if len(integration.Integrations) > 0 {
+		summary += " and uses the " + strings.Join(integration.Integrations, ", ") + " integration(s)"
+	}
+	if len(integration.InstrumentationFiles) > 0 {
+		summary += " and instruments transactions in " + strings.Join(integration.InstrumentationFiles, ", ")
+	}
+	return tasks.Result{
+		Status:  tasks.Info,
+		Summary: summary + ".",
+		Payload: integration,
+	}
+}
+
+// parseGoAgentRequire - returns the Go agent module and version required by a go.mod
+func parseGoAgentRequire(goMod []byte) (string, string) {
+	scanner := bufio.NewScanner(bytes.NewReader(goMod))
+	for scanner.Scan() {
+		if match := goModRequireRegex.FindStringSubmatch(scanner.Text()); match != nil {
+			return match[1], match[2]
+		}
+	}
+	return "", ""
+}
+
+// scanGoSources - records the Go files importing the agent, creating the application and instrumenting transactions.
+// Only the files importing the agent are searched, returns how many Go files were found
+func (t GoAgentIntegration) scanGoSources(root string, integration *GoAgentIntegrationPayload) (int, error) {
+	goFiles := 0
+	integrations := map[string]bool{}
+	err := t.walkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if entry.IsDir() {
+			if path != root && skippedGoDirs[entry.Name()] {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if filepath.Ext(path) != ".go" || strings.HasSuffix(path, "_test.go") {
+			return nil
+		}
+		goFiles++
+		if goFiles > goSourceFilesMax {
+			return errGoSourceFilesMax
+		}
+		content, err := t.readFile(path)
+		if err != nil {
+			return nil
+		}
+		imports := goAgentImportRegex.FindAllSubmatch(content, -1)
+		if len(imports) == 0 {
+			return nil
+		}
+		relPath, _ := filepath.Rel(root, path)
+		integration.ImportFiles = append(integration.ImportFiles, relPath)
+		for _, imported := range imports {
+			if match := integrationImportPath.FindSubmatch(imported[1]); match != nil {
+				integrations[string(match[1])] = true
+			}
+		}
+		if newApplicationRegex.Match(content) {
+			integration.NewApplicationFiles = append(integration.NewApplicationFiles, relPath)
+		}
+		if instrumentationRegex.Match(content) {
+			integration.InstrumentationFiles = append(integration.InstrumentationFiles, relPath)
+		}
+		return nil
+	})
+	for name := range integrations {
+		integration.Integrations = append(integration.Integrations, name)
+	}
+	sort.Strings(integration.Integrations)
+	if err == errGoSourceFilesMax {
+		log.Debug("Stopped scanning the Go sources after", goSourceFilesMax, "files")
+		return goFiles, nil
+	}
+	return goFiles, err
+}
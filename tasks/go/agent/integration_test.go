@@ -0,0 +1,153 @@
+package agent
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+)
+
+func writeGoProject(t *testing.T, files map[string]string) string {
+	root := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+const goModWithAgent = `module example.com/shop
+
+go 1.21
+
+require (
+	github.com/gin-gonic/gin v1.9.1
+	github.com/newrelic/go-agent/v3 v3.28.0
+	github.com/newrelic/go-agent/v3/integrations/nrgin v1.2.1
+)
+`
+
+const goMainWithApplication = `package main
+
+import (
+	"net/http"
+
+	"github.com/newrelic/go-agent/v3/newrelic"
+)
+
+func main() {
+	app, _ := newrelic.NewApplication(newrelic.ConfigAppName("shop"), newrelic.ConfigFromEnvironment())
+	http.HandleFunc(newrelic.WrapHandleFunc(app, "/", index))
+	http.ListenAndServe(":8000", nil)
+}
+`
+
+func TestGoAgentIntegration_Execute(t *testing.T) {
+	tests := []struct {
+		name        string
+		files       map[string]string
+		wantStatus  tasks.Status
+		wantSummary string
+		wantPayload *GoAgentIntegrationPayload
+	}{
+		{
+			name:        "not a Go project",
+			files:       map[string]string{"package.json": "{}"},
+			wantStatus:  tasks.None,
+			wantSummary: "No go.mod or Go source file was found",
+		},
+		{
+			name:        "agent not a dependency",
+			files:       map[string]string{"go.mod": "module example.com/shop\n\ngo 1.21\n", "main.go": "package main\n\nfunc main() {}\n"},
+			wantStatus:  tasks.Warning,
+			wantSummary: "The New Relic Go agent (github.com/newrelic/go-agent) is not a dependency",
+		},
+		{
+			name: "application never created",
+			files: map[string]string{
+				"go.mod":  goModWithAgent,
+				"main.go": "package main\n\nimport \"github.com/newrelic/go-agent/v3/newrelic\"\n\nvar cfg newrelic.Config\n",
+			},
+			wantStatus:  tasks.Warning,
+			wantSummary: "The project depends on github.com/newrelic/go-agent/v3 v3.28.0 but no call to newrelic.NewApplication was found.",
+		},
+		{
+			name: "no transaction instrumentation",
+			files: map[string]string{
+				"go.mod":           goModWithAgent,
+				"cmd/shop/main.go": "package main\n\nimport \"github.com/newrelic/go-agent/v3/newrelic\"\n\nfunc main() { newrelic.NewApplication() }\n",
+			},
+			wantStatus:  tasks.Warning,
+			wantSummary: "creates the application in cmd/shop/main.go, but no transaction instrumentation",
+		},
+		{
+			name: "integration present",
+			files: map[string]string{
+				"go.mod":                   goModWithAgent,
+				"main.go":                  goMainWithApplication,
+				"router/router.go":         "package router\n\nimport \"github.com/newrelic/go-agent/v3/integrations/nrgin\"\n\nvar middleware = nrgin.Middleware\n",
+				"main_test.go":             "package main\n\nimport \"github.com/newrelic/go-agent/v3/newrelic\"\n\nvar _ = newrelic.NewApplication\n",
+				"vendor/github.com/x/x.go": "package x\n\nimport \"github.com/newrelic/go-agent/v3/newrelic\"\n",
+			},
+			wantStatus:  tasks.Info,
+			wantSummary: "The Go agent integration appears present: the project depends on github.com/newrelic/go-agent/v3 v3.28.0, creates the application in main.go and uses the nrgin integration(s) and instruments transactions in main.go.",
+			wantPayload: &GoAgentIntegrationPayload{
+				Module:               "github.com/newrelic/go-agent/v3",
+				Version:              "v3.28.0",
+				ImportFiles:          []string{"main.go", filepath.Join("router", "router.go")},
+				NewApplicationFiles:  []string{"main.go"},
+				InstrumentationFiles: []string{"main.go"},
+				Integrations:         []string{"nrgin"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := writeGoProject(t, tt.files)
+			p := GoAgentIntegration{
+				getwd:    func() (string, error) { return root, nil },
+				readFile: os.ReadFile,
+				walkDir:  filepath.WalkDir,
+			}
+			got := p.Execute(tasks.Options{}, map[string]tasks.Result{})
+			if got.Status != tt.wantStatus {
+				t.Errorf("Execute() status = %v, want %v: %s", got.Status, tt.wantStatus, got.Summary)
+			}
+			if !strings.Contains(got.Summary, tt.wantSummary) {
+				t.Errorf("Execute() summary = %q, want it to contain %q", got.Summary, tt.wantSummary)
+			}
+			if tt.wantPayload != nil {
+				tt.wantPayload.GoMod = filepath.Join(root, "go.mod")
+				if !reflect.DeepEqual(got.Payload, *tt.wantPayload) {
+					t.Errorf("Execute() payload = %+v, want %+v", got.Payload, *tt.wantPayload)
+				}
+			}
+		})
+	}
+}
+
+func Test_parseGoAgentRequire(t *testing.T) {
+	tests := []struct {
+		goMod       string
+		wantModule  string
+		wantVersion string
+	}{
+		{goMod: goModWithAgent, wantModule: "github.com/newrelic/go-agent/v3", wantVersion: "v3.28.0"},
+		{goMod: "module x\n\nrequire github.com/newrelic/go-agent v2.16.3+incompatible\n", wantModule: "github.com/newrelic/go-agent", wantVersion: "v2.16.3+incompatible"},
+		{goMod: "module x\n\nrequire github.com/newrelic/go-agent-contrib v1.0.0\n"},
+	}
+	for _, tt := range tests {
+		module, version := parseGoAgentRequire([]byte(tt.goMod))
+		if module != tt.wantModule || version != tt.wantVersion {
+			t.Errorf("parseGoAgentRequire() = %q, %q, want %q, %q", module, version, tt.wantModule, tt.wantVersion)
+		}
+	}
+}
@@ -32,7 +32,7 @@ func (p InfraAgentConnect) Identifier() tasks.Identifier {
 
 // Explain - Returns the help text for each individual task
 func (p InfraAgentConnect) Explain() string {
-	return "Check network connection to New Relic Infrastructure collector endpoint"
+	return "Check network connection to New Relic Infrastructure collector endpoint" + tasks.TimeoutExplanation
 }
 
 // Dependencies - Returns the dependencies for each task.
@@ -44,6 +44,9 @@ func (p InfraAgentConnect) Dependencies() []string {
 	}
 }
 
+// RequiresNetwork - This task connects to the infrastructure agent endpoints, it is skipped with -offline
+func (p InfraAgentConnect) RequiresNetwork() {}
+
 // Execute - The core work within each task
 func (p InfraAgentConnect) Execute(options tasks.Options, upstream map[string]tasks.Result) tasks.Result {
 	domains := map[string]string{
@@ -109,9 +112,8 @@ func makeRequests(urls []string, HTTPagent requestFunc) map[string]RequestResult
 		var statusCode int
 
 		wrapper := httpHelper.RequestWrapper{
-			Method:         "GET",
-			URL:            url,
-			TimeoutSeconds: 30,
+			Method: "GET",
+			URL:    url,
 		}
 
 		response, err := HTTPagent(wrapper)
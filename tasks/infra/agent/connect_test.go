@@ -46,7 +46,7 @@ var _ = Describe("Infra/Agent/Connect", func() {
 
 	Describe("Explain()", func() {
 		It("Should return correct explain string", func() {
-			Expect(p.Explain()).To(Equal("Check network connection to New Relic Infrastructure collector endpoint"))
+			Expect(p.Explain()).To(Equal("Check network connection to New Relic Infrastructure collector endpoint" + tasks.TimeoutExplanation))
 		})
 	})
 
@@ -118,6 +118,15 @@ func (p InfraAgentVersion) Execute(options tasks.Options, upstream map[string]ta
 		}
 	}
 
+	// the release date is looked up on GitHub, so in offline mode only the installed version is reported
+	if options.IsOffline() {
+		return tasks.Result{
+			Status:  tasks.Info,
+			Summary: matches[1],
+			Payload: ver,
+		}
+	}
+
 	err = p.validatePublishDate(ver)
 	if err != nil {
 		urlUpdateTask := tasks.Result{
@@ -194,9 +203,8 @@ func (p InfraAgentVersion) validatePublishDate(version tasks.Ver) error {
 
 func (p InfraAgentVersion) getGithubPublishDate(version string) (time.Time, error) {
 	wrapper := httpHelper.RequestWrapper{
-		Method:         "GET",
-		URL:            githubAPIReleaseURL + version,
-		TimeoutSeconds: 30,
+		Method: "GET",
+		URL:    githubAPIReleaseURL + version,
 	}
 
 	response, err := p.httpGetter(wrapper)
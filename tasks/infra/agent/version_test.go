@@ -228,6 +228,36 @@ var _ = Describe("Infra/Agent/Version", func() {
 			})
 		})
 
+		Context("Infrastructure agent is present in offline mode", func() {
+
+			BeforeEach(func() {
+				options = tasks.Options{Options: map[string]string{tasks.OfflineOption: "true"}}
+				upstream = map[string]tasks.Result{
+					"Base/Env/CollectEnvVars": tasks.Result{
+						Status:  tasks.Info,
+						Payload: map[string]string{},
+					},
+					"Infra/Config/Agent": tasks.Result{
+						Status: tasks.Success,
+					},
+				}
+				p.runtimeOS = "linux"
+				p.cmdExecutor = func(a string, b ...string) ([]byte, error) {
+					return []byte("New Relic Infrastructure Agent version: 1.13.0"), nil
+				}
+				p.httpGetter = func(wrapper httpHelper.RequestWrapper) (*http.Response, error) {
+					Fail("the release date should not be requested in offline mode")
+					return nil, nil
+				}
+			})
+
+			It("should return the installed version without checking its release date", func() {
+				Expect(result.Status).To(Equal(tasks.Info))
+				Expect(result.Summary).To(Equal("1.13.0"))
+				Expect(result.Payload).To(Equal(tasks.Ver{Major: 1, Minor: 13, Patch: 0, Build: 0}))
+			})
+		})
+
 		Context("Infrastructure agent is present but -version returns unparseable result", func() {
 
 			BeforeEach(func() {
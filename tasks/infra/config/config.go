@@ -1,6 +1,7 @@
 package config
 
 import (
+	"io/ioutil"
 	"os"
 	"runtime"
 
@@ -14,6 +15,7 @@ func RegisterWith(registrationFunc func(tasks.Task, bool)) {
 
 	registrationFunc(InfraConfigDataDirectoryCollect{dataDirectoryGetter: getDataDir, dataDirectoryPathGetter: getDataDirPath}, true)
 	registrationFunc(InfraConfigAgent{validationChecker: checkValidation, configChecker: checkConfig, binaryChecker: checkForBinary}, true)
+	registrationFunc(InfraConfigEffectiveConfig{readDir: ioutil.ReadDir, readFile: ioutil.ReadFile}, true)
 	registrationFunc(InfraConfigIntegrationsCollect{fileFinder: tasks.FindFiles}, true)
 	registrationFunc(InfraConfigIntegrationsValidate{fileReader: os.Open}, true)
 	registrationFunc(InfraConfigIntegrationsMatch{
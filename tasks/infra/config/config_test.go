@@ -2,6 +2,7 @@ package config
 
 import (
 	"fmt"
+	"io/ioutil"
 	"os"
 	"runtime"
 	"testing"
@@ -26,7 +27,7 @@ func TestRegisterWithCount(t *testing.T) {
 		registrationFunc func(tasks.Task, bool)
 	}
 
-	expectedRegisteredTaskCount := 7
+	expectedRegisteredTaskCount := 8
 
 	tests := []struct {
 		name      string
@@ -61,6 +62,7 @@ func TestRegisterWith(t *testing.T) {
 	expectedRegisteredTasks := []tasks.Task{
 		InfraConfigDataDirectoryCollect{dataDirectoryGetter: getDataDir, dataDirectoryPathGetter: getDataDirPath},
 		InfraConfigAgent{validationChecker: checkValidation, configChecker: checkConfig, binaryChecker: checkForBinary},
+		InfraConfigEffectiveConfig{readDir: ioutil.ReadDir, readFile: ioutil.ReadFile},
 		InfraConfigIntegrationsCollect{fileFinder: tasks.FindFiles},
 		InfraConfigIntegrationsValidate{fileReader: os.Open},
 		InfraConfigIntegrationsMatch{runtimeOS: runtime.GOOS},
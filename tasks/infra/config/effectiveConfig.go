@@ -0,0 +1,392 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/helpers/httpHelper"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks/base/config"
+)
+
+// infraEnvPrefix is the prefix of the environment variables overriding the infra agent settings
+const infraEnvPrefix = "NRIA_"
+
+// secretInfraSettings matches the settings whose values are never reported
+var secretInfraSettings = regexp.MustCompile(`(?i)(license_key|password|passphrase|secret|token|api_key|insert_key)`)
+
+// infraDefaultConfigFiles are the newrelic-infra.yml files the agent loads when NRIA_CONFIG_FILE is not set, the first
+// one found wins
+var infraDefaultConfigFiles = []string{
+	"/etc/newrelic-infra.yml",
+	"/usr/local/etc/newrelic-infra/newrelic-infra.yml",
+	`C:\Program Files\New Relic\newrelic-infra\newrelic-infra.yml`,
+}
+
+// infraDropInDir is the directory, next to the config file the agent loads, whose YAML files are applied over it in
+// the order of their names
+const infraDropInDir = "newrelic-infra.d"
+
+// InfraConfigEffectiveConfig - This task reports the infra agent settings resolved from the config files and NRIA_* environment variables
+type InfraConfigEffectiveConfig struct {
+	readDir  func(string) ([]os.FileInfo, error)
+	readFile func(string) ([]byte, error)
+}
+
+// EffectiveSetting - an infra agent setting and where its resolved value comes from
+type EffectiveSetting struct {
+	Key    string
+	EnvVar string
+	Value  string
+	// Source is the config file or the environment variable the value was read from
+	Source string
+}
+
+// SettingConflict - a setting given different values by a config file and an environment variable, or by two config files
+type SettingConflict struct {
+	Key             string
+	OverriddenValue string
+	OverriddenBy    string
+	Value           string
+	Source          string
+}
+
+// InfraEffectiveConfig - defines the payload returned by this task
+type InfraEffectiveConfig struct {
+	// ConfigFile is the newrelic-infra.yml file the agent loads, empty when the settings only come from the environment
+	ConfigFile string `json:",omitempty"`
+	// DropInFiles are the files of the drop-in directory applied over ConfigFile
+	DropInFiles []string `json:",omitempty"`
+	// IgnoredFiles are the other newrelic-infra.yml files found, which the agent does not load
+	IgnoredFiles []string `json:",omitempty"`
+	Settings     []EffectiveSetting
+	Conflicts    []SettingConflict
+}
+
+// Identifier - This returns the Category, Subcategory and Name of each task
+func (p InfraConfigEffectiveConfig) Identifier() tasks.Identifier {
+	return tasks.IdentifierFromString("Infra/Config/EffectiveConfig")
+}
+
+// Explain - Returns the help text for each individual task
+func (p InfraConfigEffectiveConfig) Explain() string {
+	return "Report the New Relic Infrastructure agent settings resolved from its config files and environment variables"
+}
+
+// Dependencies - Returns the dependencies for each task.
+func (p InfraConfigEffectiveConfig) Dependencies() []string {
+	return []string{
+		"Infra/Config/Agent",
+		"Base/Env/CollectEnvVars",
+	}
+}
+
+// Execute - Merges the settings of the newrelic-infra.yml file the agent loads and of its drop-in files with the
+// NRIA_* environment variables, which take precedence over the files like they do in the agent
+func (p InfraConfigEffectiveConfig) Execute(options tasks.Options, upstream map[string]tasks.Result) tasks.Result {
+	if upstream["Infra/Config/Agent"].Status != tasks.Success {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "Not executing task. Infra agent not found.",
+		}
+	}
+
+	// the agent found from its binary alone has no config file in its payload
+	var configElements []config.ValidateElement
+	if upstream["Infra/Config/Agent"].Payload != nil {
+		elements, ok := upstream["Infra/Config/Agent"].Payload.([]config.ValidateElement)
+		if !ok {
+			return tasks.Result{
+				Status:  tasks.Error,
+				Summary: tasks.AssertionErrorSummary,
+			}
+		}
+		configElements = elements
+	}
+
+	envVars := map[string]string{}
+	if upstream["Base/Env/CollectEnvVars"].Status == tasks.Info {
+		collected, ok := upstream["Base/Env/CollectEnvVars"].Payload.(map[string]string)
+		if !ok {
+			return tasks.Result{
+				Status:  tasks.Error,
+				Summary: tasks.AssertionErrorSummary,
+			}
+		}
+		envVars = collected
+	}
+
+	configFile, ignoredFiles := loadedInfraConfig(configElements, envVars)
+	var dropIns []infraConfigFile
+	if configFile.path != "" {
+		dropIns = p.dropInFiles(filepath.Dir(configFile.path))
+	}
+	effectiveConfig := mergeInfraSettings(configFile, dropIns, envVars)
+	if len(effectiveConfig.Settings) == 0 {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "No New Relic Infrastructure agent settings found in config files or NRIA_* environment variables",
+		}
+	}
+	effectiveConfig.IgnoredFiles = ignoredFiles
+
+	summary := fmt.Sprintf("Resolved %d New Relic Infrastructure agent settings from config files and environment variables", len(effectiveConfig.Settings))
+	if effectiveConfig.ConfigFile != "" {
+		summary += " with " + effectiveConfig.ConfigFile
+		if len(effectiveConfig.DropInFiles) > 0 {
+			summary += fmt.Sprintf(" and %d drop-in files", len(effectiveConfig.DropInFiles))
+		}
+	}
+	if len(ignoredFiles) > 0 {
+		summary += ". The agent does not load the other config files found: " + strings.Join(ignoredFiles, ", ")
+	}
+	if len(effectiveConfig.Conflicts) == 0 {
+		return tasks.Result{
+			Status:  tasks.Info,
+			Summary: summary,
+			Payload: effectiveConfig,
+		}
+	}
+
+	summary += ". The following settings are set more than once, the agent uses the last value:"
+	for _, conflict := range effectiveConfig.Conflicts {
+		summary += fmt.Sprintf("\n%s: %q from %s is overridden by %q from %s", conflict.Key, conflict.OverriddenValue, conflict.OverriddenBy, conflict.Value, conflict.Source)
+	}
+	return tasks.Result{
+		Status:  tasks.Warning,
+		Summary: summary,
+		Payload: effectiveConfig,
+	}
+}
+
+// infraConfigFile - a config file of the agent and its settings, keyed by their path
+type infraConfigFile struct {
+	path   string
+	values map[string]interface{}
+}
+
+// loadedInfraConfig - the config file the agent loads: the one NRIA_CONFIG_FILE names, else the first default location
+// found, else the first file found, e.g. with -config-file. The agent loads a single file, the others are returned as
+// ignored
+func loadedInfraConfig(configElements []config.ValidateElement, envVars map[string]string) (infraConfigFile, []string) {
+	var paths []string
+	files := map[string]config.ValidateElement{}
+	for _, element := range configElements {
+		if element.Config.FileName != "newrelic-infra.yml" {
+			continue
+		}
+		path := element.Config.FilePath + element.Config.FileName
+		if _, ok := files[path]; !ok {
+			paths = append(paths, path)
+		}
+		files[path] = element
+	}
+	if len(paths) == 0 {
+		return infraConfigFile{}, nil
+	}
+
+	loaded := ""
+	if configFileEnv := envVars["NRIA_CONFIG_FILE"]; configFileEnv != "" {
+		loaded = matchingInfraConfigPath(paths, []string{configFileEnv})
+	} else {
+		loaded = matchingInfraConfigPath(paths, infraDefaultConfigFiles)
+		if loaded == "" {
+			loaded = paths[0]
+		}
+	}
+
+	var ignored []string
+	for _, path := range paths {
+		if path != loaded {
+			ignored = append(ignored, path)
+		}
+	}
+	if loaded == "" {
+		return infraConfigFile{}, ignored
+	}
+	return infraConfigFile{path: loaded, values: files[loaded].ParsedResult.AsMap()}, ignored
+}
+
+// matchingInfraConfigPath - the first of the candidates found among the paths, compared as cleaned paths and whatever
+// their case, as on Windows
+func matchingInfraConfigPath(paths []string, candidates []string) string {
+	for _, candidate := range candidates {
+		for _, path := range paths {
+			if strings.EqualFold(filepath.Clean(path), filepath.Clean(candidate)) {
+				return path
+			}
+		}
+	}
+	return ""
+}
+
+// dropInFiles - the YAML files of the drop-in directory next to the loaded config file, sorted by name. The files that
+// can't be read or parsed are skipped, the agent would not apply them either
+func (p InfraConfigEffectiveConfig) dropInFiles(configDir string) []infraConfigFile {
+	if p.readDir == nil || p.readFile == nil {
+		return nil
+	}
+	dir := filepath.Join(configDir, infraDropInDir)
+	entries, err := p.readDir(dir)
+	if err != nil {
+		return nil
+	}
+	var names []string
+	for _, entry := range entries {
+		if ext := strings.ToLower(filepath.Ext(entry.Name())); !entry.IsDir() && (ext == ".yml" || ext == ".yaml") {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+
+	var dropIns []infraConfigFile
+	for _, name := range names {
+		path := filepath.Join(dir, name)
+		content, err := p.readFile(path)
+		if err != nil {
+			continue
+		}
+		parsed, err := config.ParseYaml(bytes.NewReader(content))
+		if err != nil {
+			continue
+		}
+		dropIns = append(dropIns, infraConfigFile{path: path, values: parsed.AsMap()})
+	}
+	return dropIns
+}
+
+// mergeInfraSettings - applies the settings of the loaded config file, then those of its drop-in files, then the
+// NRIA_* environment variables. Conflicts are found on the actual values, the settings are sorted by key and secrets
+// are redacted in both
+func mergeInfraSettings(configFile infraConfigFile, dropIns []infraConfigFile, envVars map[string]string) InfraEffectiveConfig {
+	settings := map[string]EffectiveSetting{}
+	rawValues := map[string]string{}
+	var conflicts []SettingConflict
+
+	apply := func(setting EffectiveSetting, rawValue string) {
+		if previous, ok := settings[setting.Key]; ok && !strings.EqualFold(rawValues[setting.Key], rawValue) {
+			conflicts = append(conflicts, SettingConflict{
+				Key:             setting.Key,
+				OverriddenValue: previous.Value,
+				OverriddenBy:    previous.Source,
+				Value:           setting.Value,
+				Source:          setting.Source,
+			})
+		}
+		settings[setting.Key] = setting
+		rawValues[setting.Key] = rawValue
+	}
+
+	effectiveConfig := InfraEffectiveConfig{ConfigFile: configFile.path}
+	files := []infraConfigFile{}
+	if configFile.path != "" {
+		files = append(files, configFile)
+	}
+	for _, dropIn := range dropIns {
+		files = append(files, dropIn)
+		effectiveConfig.DropInFiles = append(effectiveConfig.DropInFiles, dropIn.path)
+	}
+	for _, file := range files {
+		for _, pathAndKey := range sortedKeys(file.values) {
+			key := strings.TrimPrefix(pathAndKey, "/")
+			if key == "" {
+				continue
+			}
+			value := settingValue(file.values[pathAndKey])
+			apply(EffectiveSetting{
+				Key:    key,
+				EnvVar: infraEnvVar(key),
+				Value:  redactInfraSetting(key, value),
+				Source: file.path,
+			}, value)
+		}
+	}
+
+	// env vars are matched to the file settings they override by name, the others are reported under their own name
+	keysByEnvVar := map[string]string{}
+	for key, setting := range settings {
+		keysByEnvVar[setting.EnvVar] = key
+	}
+	for _, name := range sortedEnvVars(envVars) {
+		envVar := strings.ToUpper(name)
+		// NRIA_CONFIG_FILE selects the config file rather than overriding one of its settings
+		if !strings.HasPrefix(envVar, infraEnvPrefix) || envVar == "NRIA_CONFIG_FILE" {
+			continue
+		}
+		key, ok := keysByEnvVar[envVar]
+		if !ok {
+			key = strings.ToLower(strings.TrimPrefix(envVar, infraEnvPrefix))
+		}
+		apply(EffectiveSetting{
+			Key:    key,
+			EnvVar: envVar,
+			Value:  redactInfraSetting(key, envVars[name]),
+			Source: envVar + " environment variable",
+		}, envVars[name])
+	}
+
+	effectiveConfig.Conflicts = conflicts
+	for _, key := range sortedSettingKeys(settings) {
+		effectiveConfig.Settings = append(effectiveConfig.Settings, settings[key])
+	}
+	return effectiveConfig
+}
+
+// settingValue - the value of a YAML setting as it would be written in an environment variable
+func settingValue(rawValue interface{}) string {
+	if rawValue == nil {
+		return ""
+	}
+	return fmt.Sprint(rawValue)
+}
+
+// infraEnvVar - the environment variable overriding a setting, nested keys like log/file become NRIA_LOG_FILE
+func infraEnvVar(key string) string {
+	return infraEnvPrefix + strings.ToUpper(strings.ReplaceAll(key, "/", "_"))
+}
+
+// redactInfraSetting - hides the value of secret settings and the credentials of proxy URLs
+func redactInfraSetting(key string, value string) string {
+	if value == "" {
+		return value
+	}
+	if secretInfraSettings.MatchString(key) {
+		return "_REDACTED_"
+	}
+	if strings.Contains(strings.ToLower(key), "proxy") {
+		return httpHelper.RedactProxyURL(value)
+	}
+	return value
+}
+
+func sortedKeys(values map[string]interface{}) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func sortedEnvVars(envVars map[string]string) []string {
+	names := make([]string, 0, len(envVars))
+	for name := range envVars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func sortedSettingKeys(settings map[string]EffectiveSetting) []string {
+	keys := make([]string, 0, len(settings))
+	for key := range settings {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -35,7 +35,7 @@ func (p InfraEnvClockSkew) Identifier() tasks.Identifier {
 
 // Explain - Returns the help text for each individual task
 func (p InfraEnvClockSkew) Explain() string {
-	return "Detect if host has clock skew from New Relic collector"
+	return "Detect if host has clock skew from New Relic collector" + tasks.TimeoutExplanation
 }
 
 // Dependencies - Returns the dependencies for each task.
@@ -43,6 +43,9 @@ func (p InfraEnvClockSkew) Dependencies() []string {
 	return []string{"Infra/Agent/Connect", "Base/Config/ProxyDetect"}
 }
 
+// RequiresNetwork - This task reads the time from the collector, it is skipped with -offline
+func (p InfraEnvClockSkew) RequiresNetwork() {}
+
 // Execute - Returns result containing the log_file value(s) parsed from any found newrelic-infra.yml files previously collected.
 func (p InfraEnvClockSkew) Execute(options tasks.Options, upstream map[string]tasks.Result) tasks.Result {
 
@@ -103,9 +106,8 @@ func (p InfraEnvClockSkew) Execute(options tasks.Options, upstream map[string]ta
 func (p InfraEnvClockSkew) getCollectorTime(apiEndpoint string) (time.Time, error) {
 
 	wrapper := httpHelper.RequestWrapper{
-		Method:         "GET",
-		URL:            apiEndpoint,
-		TimeoutSeconds: 30,
+		Method: "GET",
+		URL:    apiEndpoint,
 	}
 
 	//Make request
@@ -52,7 +52,7 @@ var _ = Describe("Infra/Env/ClockSkew", func() {
 
 	Describe("Explain()", func() {
 		It("Should return correct explain string", func() {
-			Expect(p.Explain()).To(Equal("Detect if host has clock skew from New Relic collector"))
+			Expect(p.Explain()).To(Equal("Detect if host has clock skew from New Relic collector" + tasks.TimeoutExplanation))
 		})
 	})
 
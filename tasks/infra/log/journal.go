@@ -0,0 +1,125 @@
+package log
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+)
+
+const (
+	// journalWindow is how far back the infra agent journal entries are collected
+	journalWindow = 72 * time.Hour
+	// journalMaxBytes caps the captured journal, the newest entries are kept
+	journalMaxBytes = 5 * 1024 * 1024
+	journalFileName = "newrelic-infra-journal.log"
+	// journalNoEntries is what journalctl prints when no entry matches
+	journalNoEntries = "-- No entries --"
+)
+
+// InfraLogJournal - This task collects the infra agent logs written to the systemd journal
+type InfraLogJournal struct {
+	runtimeOS         string
+	journaldAvailable func() bool
+	cmdExec           tasks.CmdExecFunc
+	now               func() time.Time
+}
+
+// Identifier - This returns the Category, Subcategory and Name of each task
+func (p InfraLogJournal) Identifier() tasks.Identifier {
+	return tasks.IdentifierFromString("Infra/Log/Journal")
+}
+
+// Explain - Returns the help text for each individual task
+func (p InfraLogJournal) Explain() string {
+	return "Collect New Relic Infrastructure agent logs from the systemd journal"
+}
+
+// Dependencies - Returns the dependencies for each task.
+func (p InfraLogJournal) Dependencies() []string {
+	return []string{
+		"Infra/Config/Agent",
+	}
+}
+
+// Execute - Returns result containing the newrelic-infra unit entries of the last 72 hours read with journalctl
+func (p InfraLogJournal) Execute(options tasks.Options, upstream map[string]tasks.Result) tasks.Result {
+	if p.runtimeOS != "linux" {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "Not executing task. The systemd journal is only collected on Linux.",
+		}
+	}
+
+	if upstream["Infra/Config/Agent"].Status != tasks.Success {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "Not executing task. Infra agent not found.",
+		}
+	}
+
+	if !p.journaldAvailable() {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "Not executing task. journald was not detected on this host.",
+		}
+	}
+
+	since := p.now().Add(-journalWindow).Format("2006-01-02 15:04:05")
+	output, err := p.cmdExec("journalctl", "-u", "newrelic-infra", "--since", since, "--no-pager", "-o", "short-iso")
+	if err != nil {
+		return tasks.Result{
+			Status:  tasks.Error,
+			Summary: fmt.Sprintf("Unable to read the newrelic-infra journal: %s: %s", err.Error(), strings.TrimSpace(string(output))),
+		}
+	}
+
+	journal := strings.TrimSpace(string(output))
+	if journal == "" || journal == journalNoEntries {
+		return tasks.Result{
+			Status:  tasks.Info,
+			Summary: "No newrelic-infra journal entries in the last 72 hours",
+		}
+	}
+
+	summary := fmt.Sprintf("Collected the newrelic-infra journal entries of the last 72 hours. See %s for more info.", journalFileName)
+	if len(journal) > journalMaxBytes {
+		journal = truncateJournal(journal, journalMaxBytes)
+		summary = fmt.Sprintf("Collected the newest %d bytes of the newrelic-infra journal entries of the last 72 hours. See %s for more info.", journalMaxBytes, journalFileName)
+	}
+
+	stream := make(chan string, 1)
+	stream <- journal + "\n"
+	close(stream)
+
+	return tasks.Result{
+		Status:      tasks.Info,
+		Summary:     summary,
+		Payload:     journal,
+		FilesToCopy: []tasks.FileCopyEnvelope{{Path: journalFileName, Stream: stream}},
+	}
+}
+
+// truncateJournal - keeps the newest entries of the journal that fit in maxBytes, starting on a whole line
+func truncateJournal(journal string, maxBytes int) string {
+	truncated := journal[len(journal)-maxBytes:]
+	if journal[len(journal)-maxBytes-1] == '\n' {
+		return truncated
+	}
+	if newline := strings.Index(truncated, "\n"); newline >= 0 {
+		truncated = truncated[newline+1:]
+	}
+	return truncated
+}
+
+// journaldAvailable - true when journald is running, its sockets live in /run/systemd/journal, and journalctl can be found in the PATH
+func journaldAvailable() bool {
+	if _, err := os.Stat("/run/systemd/journal"); err != nil {
+		return false
+	}
+	_, err := exec.LookPath("journalctl")
+	return err == nil
+}
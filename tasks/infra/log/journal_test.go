@@ -0,0 +1,139 @@
+package log
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Infra/Log/Journal", func() {
+	var (
+		p        InfraLogJournal
+		result   tasks.Result
+		upstream map[string]tasks.Result
+		output   string
+		err      error
+		args     []string
+	)
+
+	BeforeEach(func() {
+		output, err, args = "", nil, nil
+		p = InfraLogJournal{
+			runtimeOS:         "linux",
+			journaldAvailable: func() bool { return true },
+			cmdExec: func(name string, arg ...string) ([]byte, error) {
+				args = append([]string{name}, arg...)
+				return []byte(output), err
+			},
+			now: func() time.Time { return time.Date(2026, 10, 14, 12, 0, 0, 0, time.Local) },
+		}
+		upstream = map[string]tasks.Result{
+			"Infra/Config/Agent": {Status: tasks.Success},
+		}
+	})
+
+	JustBeforeEach(func() {
+		result = p.Execute(tasks.Options{}, upstream)
+	})
+
+	Describe("Dependencies()", func() {
+		It("Should depend on the infra agent detection", func() {
+			Expect(p.Dependencies()).To(Equal([]string{"Infra/Config/Agent"}))
+		})
+	})
+
+	Context("when not running on Linux", func() {
+		BeforeEach(func() {
+			p.runtimeOS = "darwin"
+		})
+		It("Should return a None result", func() {
+			Expect(result.Status).To(Equal(tasks.None))
+			Expect(args).To(BeNil())
+		})
+	})
+
+	Context("when the infra agent was not found", func() {
+		BeforeEach(func() {
+			upstream["Infra/Config/Agent"] = tasks.Result{Status: tasks.Failure}
+		})
+		It("Should return a None result", func() {
+			Expect(result.Status).To(Equal(tasks.None))
+			Expect(result.Summary).To(Equal("Not executing task. Infra agent not found."))
+		})
+	})
+
+	Context("when journald is not available", func() {
+		BeforeEach(func() {
+			p.journaldAvailable = func() bool { return false }
+		})
+		It("Should return a None result without running journalctl", func() {
+			Expect(result.Status).To(Equal(tasks.None))
+			Expect(args).To(BeNil())
+		})
+	})
+
+	Context("when the journal has newrelic-infra entries", func() {
+		BeforeEach(func() {
+			output = "2026-10-14T11:00:00+0000 host newrelic-infra[42]: time=\"2026-10-14T11:00:00Z\" level=info msg=\"Agent service manager started\"\n"
+		})
+		It("Should read the last 72 hours of the newrelic-infra unit", func() {
+			Expect(args).To(Equal([]string{"journalctl", "-u", "newrelic-infra", "--since", "2026-10-11 12:00:00", "--no-pager", "-o", "short-iso"}))
+		})
+		It("Should include the entries in the payload and the zip file", func() {
+			Expect(result.Status).To(Equal(tasks.Info))
+			Expect(result.Payload).To(Equal(strings.TrimSpace(output)))
+			Expect(result.FilesToCopy).To(HaveLen(1))
+			Expect(result.FilesToCopy[0].Path).To(Equal("newrelic-infra-journal.log"))
+			Expect(<-result.FilesToCopy[0].Stream).To(Equal(output))
+		})
+	})
+
+	Context("when the journal has no newrelic-infra entries", func() {
+		BeforeEach(func() {
+			output = "-- No entries --\n"
+		})
+		It("Should return an Info result without files", func() {
+			Expect(result.Status).To(Equal(tasks.Info))
+			Expect(result.Summary).To(Equal("No newrelic-infra journal entries in the last 72 hours"))
+			Expect(result.FilesToCopy).To(BeEmpty())
+		})
+	})
+
+	Context("when journalctl fails", func() {
+		BeforeEach(func() {
+			output = "Failed to open journal"
+			err = errors.New("exit status 1")
+		})
+		It("Should return an Error result", func() {
+			Expect(result.Status).To(Equal(tasks.Error))
+			Expect(result.Summary).To(Equal("Unable to read the newrelic-infra journal: exit status 1: Failed to open journal"))
+		})
+	})
+
+	Context("when the journal is larger than the cap", func() {
+		BeforeEach(func() {
+			line := strings.Repeat("x", 1023) + "\n"
+			output = strings.Repeat(line, 6*1024) + "newest entry\n"
+		})
+		It("Should keep the newest whole lines under the cap", func() {
+			payload := result.Payload.(string)
+			Expect(len(payload)).To(BeNumerically("<=", journalMaxBytes))
+			Expect(payload).To(HavePrefix("xxx"))
+			Expect(payload).To(HaveSuffix("newest entry"))
+			Expect(result.Summary).To(HavePrefix("Collected the newest 5242880 bytes"))
+		})
+	})
+
+	Describe("truncateJournal()", func() {
+		It("Should drop the partial first line", func() {
+			Expect(truncateJournal("first\nsecond\nthird", 10)).To(Equal("third"))
+		})
+		It("Should keep a line starting at the cut", func() {
+			Expect(truncateJournal("first\nsecond\nthird", 12)).To(Equal("second\nthird"))
+		})
+	})
+})
@@ -1,6 +1,9 @@
 package log
 
 import (
+	"runtime"
+	"time"
+
 	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
 	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
 )
@@ -13,4 +16,10 @@ func RegisterWith(registrationFunc func(tasks.Task, bool)) {
 		validatePaths: tasks.ValidatePaths,
 		findFiles:     tasks.FindFiles,
 	}, true)
+	registrationFunc(InfraLogJournal{
+		runtimeOS:         runtime.GOOS,
+		journaldAvailable: journaldAvailable,
+		cmdExec:           tasks.CmdExecutor,
+		now:               time.Now,
+	}, true)
 }
@@ -16,4 +16,9 @@ func RegisterWith(registrationFunc func(tasks.Task, bool)) {
 		cmdExec:      tasks.CmdExecutor,
 		findTheFiles: tasks.FindFiles,
 	}, true)
+	registrationFunc(JavaAgentJvmArgs{
+		getJavaProcArgs: tasks.GetJavaProcArgs,
+		getCwd:          getProcCwd,
+		fileExists:      tasks.FileExists,
+	}, true)
 }
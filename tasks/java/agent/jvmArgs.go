@@ -0,0 +1,173 @@
+package agent
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	"github.com/shirou/gopsutil/v3/process"
+)
+
+const javaAgentArgPrefix = "-javaagent:"
+
+// JavaAgentArg - a -javaagent argument passed to a JVM
+type JavaAgentArg struct {
+	Arg      string
+	Path     string
+	NewRelic bool
+	Exists   bool
+}
+
+// JvmAgentArgs - the -javaagent arguments of a running JVM
+type JvmAgentArgs struct {
+	ProcID     int32
+	JavaAgents []JavaAgentArg
+	Problems   []string
+}
+
+// JavaAgentJvmArgs - This task checks that the running JVMs are passed the New Relic -javaagent argument
+type JavaAgentJvmArgs struct {
+	getJavaProcArgs func() []tasks.JavaProcArgs
+	getCwd          func(int32) (string, error)
+	fileExists      tasks.FileExistsFunc
+}
+
+// Identifier - This returns the Category, Subcategory and Name of each task
+func (p JavaAgentJvmArgs) Identifier() tasks.Identifier {
+	return tasks.IdentifierFromString("Java/Agent/JvmArgs")
+}
+
+// Explain - Returns the help text for each individual task
+func (p JavaAgentJvmArgs) Explain() string {
+	return "Check the running Java processes are passed the New Relic -javaagent argument"
+}
+
+// Dependencies - Returns the dependencies for each task.
+func (p JavaAgentJvmArgs) Dependencies() []string {
+	return []string{
+		"Java/Config/Agent",
+	}
+}
+
+// Execute - The core work within each task
+func (p JavaAgentJvmArgs) Execute(options tasks.Options, upstream map[string]tasks.Result) tasks.Result {
+
+	if upstream["Java/Config/Agent"].Status != tasks.Success {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "Java agent not detected. Task did not run.",
+		}
+	}
+
+	javaProcs := p.getJavaProcArgs()
+	if len(javaProcs) == 0 {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "No running Java processes were found. Please re-run " + tasks.ThisProgramFullName + " after starting your Java application.",
+		}
+	}
+
+	status := tasks.Success
+	jvms := []JvmAgentArgs{}
+	summaries := []string{}
+	for _, proc := range javaProcs {
+		jvm, procStatus := p.checkJvmArgs(proc)
+		jvms = append(jvms, jvm)
+		if procStatus > status {
+			status = procStatus
+		}
+		for _, problem := range jvm.Problems {
+			summaries = append(summaries, fmt.Sprintf("Java process %d: %s", jvm.ProcID, problem))
+		}
+	}
+
+	if status == tasks.Success {
+		return tasks.Result{
+			Status:  tasks.Success,
+			Summary: fmt.Sprintf("All %d running Java process(es) are passed the New Relic -javaagent argument.", len(jvms)),
+			Payload: jvms,
+		}
+	}
+	return tasks.Result{
+		Status:  status,
+		Summary: strings.Join(summaries, "\n"),
+		URL:     "https://docs.newrelic.com/docs/agents/java-agent/installation/include-java-agent-jvm-argument",
+		Payload: jvms,
+	}
+}
+
+// checkJvmArgs - lists the -javaagent arguments of a JVM and the problems they have, with the status they warrant
+func (p JavaAgentJvmArgs) checkJvmArgs(proc tasks.JavaProcArgs) (JvmAgentArgs, tasks.Status) {
+	jvm := JvmAgentArgs{ProcID: proc.ProcID, JavaAgents: []JavaAgentArg{}}
+	cwd, err := p.getCwd(proc.ProcID)
+	if err != nil {
+		log.Debug("Unable to get the working directory of Java process", proc.ProcID, ":", err)
+	}
+
+	status := tasks.Success
+	newRelicAgents := 0
+	for _, arg := range proc.Args {
+		javaAgent, ok := parseJavaAgentArg(arg)
+		if !ok {
+			continue
+		}
+		// relative paths are resolved by the JVM against its working directory
+		path := javaAgent.Path
+		if !filepath.IsAbs(path) && cwd != "" {
+			path = filepath.Join(cwd, path)
+		}
+		javaAgent.Exists = p.fileExists(path)
+		jvm.JavaAgents = append(jvm.JavaAgents, javaAgent)
+
+		if javaAgent.NewRelic {
+			newRelicAgents++
+		} else {
+			jvm.Problems = append(jvm.Problems, javaAgent.Arg+" loads another Java agent, the New Relic Java agent is not compatible with other agents.")
+			status = tasks.Warning
+		}
+		if !javaAgent.Exists {
+			jvm.Problems = append(jvm.Problems, "the jar of "+javaAgent.Arg+" does not exist at "+path+".")
+			status = tasks.Warning
+		}
+	}
+
+	if newRelicAgents == 0 {
+		jvm.Problems = append(jvm.Problems, "the -javaagent:/path/to/newrelic.jar argument is missing, the New Relic Java agent is not attached.")
+		return jvm, tasks.Failure
+	}
+	if newRelicAgents > 1 {
+		jvm.Problems = append(jvm.Problems, fmt.Sprintf("the New Relic -javaagent argument is passed %d times, it must only be passed once.", newRelicAgents))
+		status = tasks.Warning
+	}
+	return jvm, status
+}
+
+// parseJavaAgentArg - returns the jar path of a -javaagent:<path>[=<options>] argument. The agent is New Relic's when
+// the jar name includes newrelic, as the jar may be renamed
+func parseJavaAgentArg(arg string) (JavaAgentArg, bool) {
+	if !strings.HasPrefix(arg, javaAgentArgPrefix) {
+		return JavaAgentArg{}, false
+	}
+	path := strings.TrimPrefix(arg, javaAgentArgPrefix)
+	if i := strings.Index(path, "="); i >= 0 {
+		path = path[:i]
+	}
+	path = strings.Trim(path, `"'`)
+	fileName := strings.ToLower(filepath.Base(path))
+	return JavaAgentArg{
+		Arg:      arg,
+		Path:     path,
+		NewRelic: strings.Contains(fileName, "newrelic") && strings.HasSuffix(fileName, ".jar"),
+	}, true
+}
+
+// getProcCwd - returns the working directory of a running process
+func getProcCwd(pid int32) (string, error) {
+	proc, err := process.NewProcess(pid)
+	if err != nil {
+		return "", err
+	}
+	return proc.Cwd()
+}
@@ -0,0 +1,123 @@
+package agent
+
+import (
+	"errors"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Java/Agent/JvmArgs", func() {
+	var p JavaAgentJvmArgs
+
+	Describe("Identifier()", func() {
+		It("Should return correct identifier", func() {
+			Expect(p.Identifier()).To(Equal(tasks.Identifier{Category: "Java", Subcategory: "Agent", Name: "JvmArgs"}))
+		})
+	})
+
+	Describe("Execute()", func() {
+		var (
+			upstream  map[string]tasks.Result
+			javaProcs []tasks.JavaProcArgs
+			jars      map[string]bool
+			result    tasks.Result
+		)
+
+		BeforeEach(func() {
+			upstream = map[string]tasks.Result{
+				"Java/Config/Agent": {Status: tasks.Success},
+			}
+			jars = map[string]bool{"/opt/newrelic/newrelic.jar": true, "/srv/app/newrelic/newrelic.jar": true}
+			javaProcs = []tasks.JavaProcArgs{}
+		})
+
+		JustBeforeEach(func() {
+			p = JavaAgentJvmArgs{
+				getJavaProcArgs: func() []tasks.JavaProcArgs { return javaProcs },
+				getCwd: func(pid int32) (string, error) {
+					if pid == 404 {
+						return "", errors.New("no such process")
+					}
+					return "/srv/app", nil
+				},
+				fileExists: func(path string) bool { return jars[path] },
+			}
+			result = p.Execute(tasks.Options{}, upstream)
+		})
+
+		Context("when no Java agent config file was found", func() {
+			BeforeEach(func() {
+				upstream["Java/Config/Agent"] = tasks.Result{Status: tasks.None}
+			})
+			It("should return a None result", func() {
+				Expect(result.Status).To(Equal(tasks.None))
+			})
+		})
+
+		Context("when no Java process is running", func() {
+			It("should return a None result", func() {
+				Expect(result.Status).To(Equal(tasks.None))
+				Expect(result.Summary).To(ContainSubstring("No running Java processes were found"))
+			})
+		})
+
+		Context("when every Java process is passed the New Relic -javaagent argument", func() {
+			BeforeEach(func() {
+				javaProcs = []tasks.JavaProcArgs{
+					{ProcID: 12, Args: []string{"java", "-Xmx512m", "-javaagent:/opt/newrelic/newrelic.jar", "-jar", "app.jar"}},
+					{ProcID: 13, Args: []string{"java", "-javaagent:newrelic/newrelic.jar=debug", "-jar", "app.jar"}},
+				}
+			})
+			It("should return a Success result with the agent paths", func() {
+				Expect(result.Status).To(Equal(tasks.Success))
+				Expect(result.Summary).To(Equal("All 2 running Java process(es) are passed the New Relic -javaagent argument."))
+				jvms := result.Payload.([]JvmAgentArgs)
+				Expect(jvms[0].JavaAgents).To(Equal([]JavaAgentArg{{Arg: "-javaagent:/opt/newrelic/newrelic.jar", Path: "/opt/newrelic/newrelic.jar", NewRelic: true, Exists: true}}))
+				Expect(jvms[1].JavaAgents[0].Path).To(Equal("newrelic/newrelic.jar"))
+				Expect(jvms[1].JavaAgents[0].Exists).To(BeTrue())
+			})
+		})
+
+		Context("when a Java process is running without the -javaagent argument", func() {
+			BeforeEach(func() {
+				javaProcs = []tasks.JavaProcArgs{
+					{ProcID: 12, Args: []string{"java", "-javaagent:/opt/newrelic/newrelic.jar", "-jar", "app.jar"}},
+					{ProcID: 14, Args: []string{"java", "-jar", "batch.jar"}},
+				}
+			})
+			It("should return a Failure result naming the process", func() {
+				Expect(result.Status).To(Equal(tasks.Failure))
+				Expect(result.Summary).To(Equal("Java process 14: the -javaagent:/path/to/newrelic.jar argument is missing, the New Relic Java agent is not attached."))
+				Expect(result.URL).To(ContainSubstring("include-java-agent-jvm-argument"))
+			})
+		})
+
+		Context("when the New Relic jar does not exist", func() {
+			BeforeEach(func() {
+				javaProcs = []tasks.JavaProcArgs{
+					{ProcID: 404, Args: []string{"java", "-javaagent:/opt/old/newrelic.jar", "-jar", "app.jar"}},
+				}
+			})
+			It("should return a Warning result with the jar path", func() {
+				Expect(result.Status).To(Equal(tasks.Warning))
+				Expect(result.Summary).To(Equal("Java process 404: the jar of -javaagent:/opt/old/newrelic.jar does not exist at /opt/old/newrelic.jar."))
+			})
+		})
+
+		Context("when duplicate and other Java agents are passed", func() {
+			BeforeEach(func() {
+				javaProcs = []tasks.JavaProcArgs{
+					{ProcID: 12, Args: []string{"java", "-javaagent:/opt/newrelic/newrelic.jar", "-javaagent:/srv/app/newrelic/newrelic.jar", "-javaagent:/opt/other/dd-java-agent.jar", "-jar", "app.jar"}},
+				}
+				jars["/opt/other/dd-java-agent.jar"] = true
+			})
+			It("should return a Warning result listing the conflicts", func() {
+				Expect(result.Status).To(Equal(tasks.Warning))
+				Expect(result.Summary).To(Equal("Java process 12: -javaagent:/opt/other/dd-java-agent.jar loads another Java agent, the New Relic Java agent is not compatible with other agents.\n" +
+					"Java process 12: the New Relic -javaagent argument is passed 2 times, it must only be passed once."))
+			})
+		})
+	})
+})
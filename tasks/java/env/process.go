@@ -11,14 +11,8 @@ import (
 	"github.com/shirou/gopsutil/v3/process"
 )
 
-/* structure to contain a process and its corresponding command line args */
-type ProcIdAndArgs struct {
-	Proc        process.Process
-	CmdLineArgs []string
-	Cwd         string
-	JarPath     string
-	EnvVars     map[string]string
-}
+/* structure to contain a process and its corresponding command line args, shared with the tasks outside of Java */
+type ProcIdAndArgs = tasks.ProcIdAndArgs
 
 type JavaEnvProcess struct {
 	findProcByName tasks.FindProcessByNameFunc
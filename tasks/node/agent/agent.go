@@ -1,6 +1,9 @@
 package agent
 
 import (
+	"io/ioutil"
+	"os"
+
 	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
 	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
 )
@@ -10,4 +13,9 @@ func RegisterWith(registrationFunc func(tasks.Task, bool)) {
 	log.Debug("Registering Node/Agent/*")
 
 	registrationFunc(NodeAgentVersion{}, true)
+	registrationFunc(NodeAgentVersionCompatibility{
+		matrix:   compatibilityJSON,
+		getwd:    os.Getwd,
+		readFile: ioutil.ReadFile,
+	}, true)
 }
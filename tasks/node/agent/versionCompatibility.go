@@ -0,0 +1,162 @@
+package agent
+
+import (
+	_ "embed" // the compatibility matrix is embedded in the binary
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+)
+
+// compatibilityJSON is the Node.js agent compatibility matrix. To support a new Node.js release, add its major version
+// with the agent versions supporting it, using the requirement syntax of tasks.VersionIsCompatible
+//
+//go:embed compatibility.json
+var compatibilityJSON []byte
+
+// compatibilityMatrix - maps each supported major version of Node.js to the agent versions supporting it
+type compatibilityMatrix struct {
+	DocsURL      string              `json:"docsURL"`
+	NodeVersions map[string][]string `json:"nodeVersions"`
+}
+
+func parseCompatibilityMatrix(data []byte) (compatibilityMatrix, error) {
+	var matrix compatibilityMatrix
+	err := json.Unmarshal(data, &matrix)
+	return matrix, err
+}
+
+// supportedNodeVersions - the major versions of Node.js in the matrix, in numeric order
+func (m compatibilityMatrix) supportedNodeVersions() []string {
+	var versions []string
+	for version := range m.NodeVersions {
+		versions = append(versions, version)
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		first, _ := strconv.Atoi(versions[i])
+		second, _ := strconv.Atoi(versions[j])
+		return first < second
+	})
+	return versions
+}
+
+// NodeAgentVersionCompatibility - This struct defines the task checking the agent version supports the Node.js version
+type NodeAgentVersionCompatibility struct {
+	matrix   []byte
+	getwd    func() (string, error)
+	readFile func(string) ([]byte, error)
+}
+
+// Identifier - This returns the Category, Subcategory and Name of this task
+func (t NodeAgentVersionCompatibility) Identifier() tasks.Identifier {
+	return tasks.IdentifierFromString("Node/Agent/VersionCompatibility")
+}
+
+// Explain - Returns the help text for this task
+func (t NodeAgentVersionCompatibility) Explain() string {
+	return "Check the New Relic Nodejs agent version supports the Nodejs version"
+}
+
+// Dependencies - Returns the dependencies for this task.
+func (t NodeAgentVersionCompatibility) Dependencies() []string {
+	return []string{
+		"Node/Env/Version",
+		"Node/Agent/Version",
+	}
+}
+
+// Execute - The core work within this task
+func (t NodeAgentVersionCompatibility) Execute(options tasks.Options, upstream map[string]tasks.Result) tasks.Result {
+	if upstream["Node/Env/Version"].Status != tasks.Info {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "Nodejs version not detected. This task did not run.",
+		}
+	}
+	nodeVersion, ok := upstream["Node/Env/Version"].Payload.(tasks.Ver)
+	if !ok {
+		return tasks.Result{
+			Status:  tasks.Error,
+			Summary: tasks.AssertionErrorSummary,
+		}
+	}
+
+	agentVersion := t.agentVersion(upstream)
+	if agentVersion == "" {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "Node Agent version not detected. This task did not run.",
+		}
+	}
+
+	matrix, err := parseCompatibilityMatrix(t.matrix)
+	if err != nil {
+		return tasks.Result{
+			Status:  tasks.Error,
+			Summary: "Unable to read the Nodejs agent compatibility matrix: " + err.Error(),
+		}
+	}
+
+	nodeMajor := strconv.Itoa(nodeVersion.Major)
+	requirements, isNodeVersionSupported := matrix.NodeVersions[nodeMajor]
+	if !isNodeVersionSupported {
+		return tasks.Result{
+			Status:  tasks.Warning,
+			Summary: fmt.Sprintf("Nodejs %s is not supported by any version of the Node Agent. The supported Nodejs versions are %s.", nodeVersion.String(), strings.Join(matrix.supportedNodeVersions(), ", ")),
+			URL:     matrix.DocsURL,
+		}
+	}
+
+	isCompatible, err := tasks.VersionIsCompatible(agentVersion, requirements)
+	if err != nil {
+		return tasks.Result{
+			Status:  tasks.Error,
+			Summary: "There was an issue when checking the Node Agent version compatibility: " + err.Error(),
+		}
+	}
+	if !isCompatible {
+		return tasks.Result{
+			Status:  tasks.Warning,
+			Summary: fmt.Sprintf("Node Agent version %s does not support Nodejs %s. Nodejs %s is supported by the Node Agent versions %s.", agentVersion, nodeVersion.String(), nodeMajor, strings.Join(requirements, ", ")),
+			URL:     matrix.DocsURL,
+		}
+	}
+
+	return tasks.Result{
+		Status:  tasks.Success,
+		Summary: fmt.Sprintf("Node Agent version %s supports Nodejs %s", agentVersion, nodeVersion.String()),
+	}
+}
+
+// agentVersion - the version found by Node/Agent/Version, or else the one in the package.json of the newrelic module
+// installed in the working directory
+func (t NodeAgentVersionCompatibility) agentVersion(upstream map[string]tasks.Result) string {
+	if upstream["Node/Agent/Version"].Status == tasks.Info {
+		if version, ok := upstream["Node/Agent/Version"].Payload.(string); ok {
+			return version
+		}
+	}
+
+	wd, err := t.getwd()
+	if err != nil {
+		return ""
+	}
+	packageJSON, err := t.readFile(filepath.Join(wd, "node_modules", "newrelic", "package.json"))
+	if err != nil {
+		log.Debug("Unable to read the package.json of the newrelic module:", err)
+		return ""
+	}
+	var newrelicPackage struct {
+		Version string `json:"version"`
+	}
+	if err := json.Unmarshal(packageJSON, &newrelicPackage); err != nil {
+		log.Debug("Unable to parse the package.json of the newrelic module:", err)
+		return ""
+	}
+	return newrelicPackage.Version
+}
@@ -0,0 +1,143 @@
+package agent
+
+import (
+	"errors"
+	"os"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Node/Agent/VersionCompatibility", func() {
+	var (
+		p        NodeAgentVersionCompatibility
+		result   tasks.Result
+		upstream map[string]tasks.Result
+	)
+
+	BeforeEach(func() {
+		p = NodeAgentVersionCompatibility{
+			matrix: []byte(`{"docsURL": "https://docs.example.com/node", "nodeVersions": {"16": ["7.5.0-11.*"], "18": ["9.10.0+"], "8": ["2.0.0-6.*"]}}`),
+			getwd:  func() (string, error) { return "/app", nil },
+			readFile: func(string) ([]byte, error) {
+				return nil, os.ErrNotExist
+			},
+		}
+		upstream = map[string]tasks.Result{
+			"Node/Env/Version":   {Status: tasks.Info, Payload: tasks.Ver{Major: 18, Minor: 19, Patch: 0}},
+			"Node/Agent/Version": {Status: tasks.Info, Payload: "11.10.1"},
+		}
+	})
+
+	JustBeforeEach(func() {
+		result = p.Execute(tasks.Options{}, upstream)
+	})
+
+	Describe("Dependencies()", func() {
+		It("Should depend on the Nodejs and agent versions", func() {
+			Expect(p.Dependencies()).To(Equal([]string{"Node/Env/Version", "Node/Agent/Version"}))
+		})
+	})
+
+	Context("when Nodejs was not detected", func() {
+		BeforeEach(func() {
+			upstream["Node/Env/Version"] = tasks.Result{Status: tasks.None}
+		})
+		It("Should return a None result", func() {
+			Expect(result.Status).To(Equal(tasks.None))
+		})
+	})
+
+	Context("when the agent version supports the Nodejs version", func() {
+		It("Should return a Success result", func() {
+			Expect(result.Status).To(Equal(tasks.Success))
+			Expect(result.Summary).To(Equal("Node Agent version 11.10.1 supports Nodejs 18.19.0.0"))
+		})
+	})
+
+	Context("when the agent version is too old for the Nodejs version", func() {
+		BeforeEach(func() {
+			upstream["Node/Agent/Version"] = tasks.Result{Status: tasks.Info, Payload: "8.17.1"}
+		})
+		It("Should return a Warning result with the supported range", func() {
+			Expect(result.Status).To(Equal(tasks.Warning))
+			Expect(result.Summary).To(Equal("Node Agent version 8.17.1 does not support Nodejs 18.19.0.0. Nodejs 18 is supported by the Node Agent versions 9.10.0+."))
+			Expect(result.URL).To(Equal("https://docs.example.com/node"))
+		})
+	})
+
+	Context("when the agent version dropped the Nodejs version", func() {
+		BeforeEach(func() {
+			upstream["Node/Env/Version"] = tasks.Result{Status: tasks.Info, Payload: tasks.Ver{Major: 16, Minor: 20, Patch: 2}}
+			upstream["Node/Agent/Version"] = tasks.Result{Status: tasks.Info, Payload: "12.0.0"}
+		})
+		It("Should return a Warning result", func() {
+			Expect(result.Status).To(Equal(tasks.Warning))
+			Expect(result.Summary).To(ContainSubstring("7.5.0-11.*"))
+		})
+	})
+
+	Context("when the Nodejs version is not in the matrix", func() {
+		BeforeEach(func() {
+			upstream["Node/Env/Version"] = tasks.Result{Status: tasks.Info, Payload: tasks.Ver{Major: 21, Minor: 1}}
+		})
+		It("Should return a Warning result listing the supported Nodejs versions in order", func() {
+			Expect(result.Status).To(Equal(tasks.Warning))
+			Expect(result.Summary).To(Equal("Nodejs 21.1.0.0 is not supported by any version of the Node Agent. The supported Nodejs versions are 8, 16, 18."))
+		})
+	})
+
+	Context("when Node/Agent/Version did not find the agent", func() {
+		BeforeEach(func() {
+			upstream["Node/Agent/Version"] = tasks.Result{Status: tasks.Warning}
+		})
+		It("Should return a None result when the newrelic module is not installed", func() {
+			Expect(result.Status).To(Equal(tasks.None))
+		})
+
+		Context("and the newrelic module is in node_modules", func() {
+			BeforeEach(func() {
+				p.readFile = func(path string) ([]byte, error) {
+					if path != "/app/node_modules/newrelic/package.json" {
+						return nil, errors.New("unexpected path " + path)
+					}
+					return []byte(`{"name": "newrelic", "version": "9.0.0"}`), nil
+				}
+			})
+			It("Should check the version of its package.json", func() {
+				Expect(result.Status).To(Equal(tasks.Warning))
+				Expect(result.Summary).To(HavePrefix("Node Agent version 9.0.0 does not support Nodejs 18.19.0.0"))
+			})
+		})
+	})
+
+	Context("when the matrix can't be parsed", func() {
+		BeforeEach(func() {
+			p.matrix = []byte(`{"nodeVersions": [`)
+		})
+		It("Should return an Error result", func() {
+			Expect(result.Status).To(Equal(tasks.Error))
+		})
+	})
+
+	Describe("the embedded compatibility matrix", func() {
+		It("Should parse with valid requirements for every Nodejs version", func() {
+			matrix, err := parseCompatibilityMatrix(compatibilityJSON)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(matrix.DocsURL).NotTo(BeEmpty())
+			Expect(matrix.NodeVersions).NotTo(BeEmpty())
+			for nodeVersion, requirements := range matrix.NodeVersions {
+				_, err := tasks.VersionIsCompatible("1.0.0", requirements)
+				Expect(err).NotTo(HaveOccurred(), "Nodejs "+nodeVersion)
+			}
+		})
+
+		It("Should cover the current LTS lines of Nodejs", func() {
+			matrix, err := parseCompatibilityMatrix(compatibilityJSON)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(matrix.NodeVersions).To(HaveKey("22"))
+			Expect(matrix.NodeVersions).To(HaveKey("24"))
+		})
+	})
+})
@@ -12,5 +12,9 @@ func RegisterWith(registrationFunc func(tasks.Task, bool)) {
 	registrationFunc(PHPAgentVersion{
 		returnLastMatchInFile: tasks.ReturnLastStringSubmatchInFile,
 	}, true)
+	registrationFunc(PHPAgentExtension{
+		cmdExec:         tasks.CmdExecutor,
+		findFpmBinaries: findFpmBinaries,
+	}, true)
 
 }
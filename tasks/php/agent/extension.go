@@ -0,0 +1,240 @@
+package agent
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+)
+
+const (
+	sapiCLI = "cli"
+	sapiFPM = "fpm"
+)
+
+var (
+	htmlInfoCell = regexp.MustCompile(`</td>\s*<td[^>]*>`)
+	htmlTag      = regexp.MustCompile(`<[^>]+>`)
+)
+
+// PHPSapiExtension - whether the newrelic extension is loaded by a PHP SAPI, and the ini files it reads
+type PHPSapiExtension struct {
+	Sapi             string
+	Binary           string
+	Loaded           bool
+	LoadedConfigFile string
+	NewRelicIniFiles []string
+	Error            string `json:",omitempty"`
+}
+
+// PHPAgentExtension - checks the newrelic extension is loaded by the CLI and FPM SAPIs
+type PHPAgentExtension struct {
+	cmdExec         tasks.CmdExecFunc
+	findFpmBinaries func() []string
+}
+
+// Identifier - This returns the Category, Subcategory and Name of each task
+func (p PHPAgentExtension) Identifier() tasks.Identifier {
+	return tasks.IdentifierFromString("PHP/Agent/Extension")
+}
+
+// Explain - Returns the help text for this task
+func (p PHPAgentExtension) Explain() string {
+	return "Check the New Relic PHP extension is loaded by the PHP CLI and PHP-FPM"
+}
+
+// Dependencies - Returns the dependencies for this task
+func (p PHPAgentExtension) Dependencies() []string {
+	return []string{
+		"PHP/Config/Agent",
+	}
+}
+
+// Execute - The core work within each task
+func (p PHPAgentExtension) Execute(options tasks.Options, upstream map[string]tasks.Result) tasks.Result {
+	if upstream["PHP/Config/Agent"].Status != tasks.Success {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "PHP Agent was not detected on this host. This task did not run",
+		}
+	}
+
+	sapis := []PHPSapiExtension{p.checkSapi(sapiCLI, "php")}
+	for _, binary := range p.findFpmBinaries() {
+		sapis = append(sapis, p.checkSapi(sapiFPM, binary))
+	}
+
+	var cliLoaded, fpmRan bool
+	var ran, notLoaded, multipleIni, summaries []string
+	for _, sapi := range sapis {
+		if sapi.Error != "" {
+			log.Debug("Unable to run", sapi.Binary, ":", sapi.Error)
+			continue
+		}
+		ran = append(ran, sapi.Binary)
+		if sapi.Sapi == sapiFPM {
+			fpmRan = true
+		}
+		if !sapi.Loaded {
+			notLoaded = append(notLoaded, sapi.Binary)
+			summaries = append(summaries, fmt.Sprintf("The newrelic extension is not loaded by %s (%s).", sapi.Binary, sapi.Sapi))
+			continue
+		}
+		if sapi.Sapi == sapiCLI {
+			cliLoaded = true
+		}
+		summary := fmt.Sprintf("The newrelic extension is loaded by %s (%s)", sapi.Binary, sapi.Sapi)
+		if len(sapi.NewRelicIniFiles) > 0 {
+			summary += " from " + strings.Join(sapi.NewRelicIniFiles, ", ")
+		} else if sapi.LoadedConfigFile != "" {
+			summary += " from " + sapi.LoadedConfigFile
+		}
+		summaries = append(summaries, summary+".")
+		if len(sapi.NewRelicIniFiles) > 1 {
+			multipleIni = append(multipleIni, sapi.Binary)
+		}
+	}
+
+	if len(ran) == 0 {
+		return tasks.Result{
+			Status:  tasks.Error,
+			Summary: "Unable to run php or php-fpm to check the loaded extensions: " + sapis[0].Error,
+			Payload: sapis,
+		}
+	}
+
+	result := tasks.Result{
+		Status:  tasks.Success,
+		Summary: strings.Join(summaries, "\n"),
+		Payload: sapis,
+	}
+	switch {
+	case len(notLoaded) == len(ran):
+		result.Status = tasks.Failure
+		result.Summary += "\nThe New Relic PHP agent will not report until the newrelic extension is loaded. Check the extension and newrelic.ini were installed for this PHP version."
+		result.URL = "https://docs.newrelic.com/docs/apm/agents/php-agent/troubleshooting/no-data-appears-php/"
+	case cliLoaded && fpmRan && len(notLoaded) > 0:
+		result.Status = tasks.Warning
+		result.Summary += "\nThe extension is loaded by the PHP CLI but not by PHP-FPM, so web requests are not monitored. Install newrelic.ini in the conf.d directory of PHP-FPM and restart it."
+		result.URL = "https://docs.newrelic.com/docs/apm/agents/php-agent/troubleshooting/no-data-appears-php/"
+	case len(multipleIni) > 0:
+		result.Status = tasks.Warning
+		result.Summary += "\nMore than one newrelic.ini is parsed by " + strings.Join(multipleIni, ", ") + ", the settings of the last one override the others."
+	}
+	return result
+}
+
+// checkSapi - reads the modules and the ini files of a PHP binary with -m and -i, which the CLI and FPM both accept
+func (p PHPAgentExtension) checkSapi(sapi string, binary string) PHPSapiExtension {
+	extension := PHPSapiExtension{Sapi: sapi, Binary: binary, NewRelicIniFiles: []string{}}
+
+	modules, err := p.cmdExec(binary, "-m")
+	if err != nil {
+		extension.Error = err.Error()
+		return extension
+	}
+	extension.Loaded = hasModule(string(modules), "newrelic")
+
+	info, err := p.cmdExec(binary, "-i")
+	if err != nil {
+		log.Debug("Unable to run", binary, "-i:", err)
+		return extension
+	}
+	loadedConfig, additionalIni := parseIniFiles(string(info))
+	extension.LoadedConfigFile = loadedConfig
+	for _, iniFile := range additionalIni {
+		if strings.Contains(strings.ToLower(filepath.Base(iniFile)), "newrelic") {
+			extension.NewRelicIniFiles = append(extension.NewRelicIniFiles, iniFile)
+		}
+	}
+	return extension
+}
+
+func hasModule(modules string, name string) bool {
+	for _, line := range strings.Split(modules, "\n") {
+		if strings.EqualFold(strings.TrimSpace(line), name) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseIniFiles - returns the php.ini and the additional ini files listed by -i. php-fpm may print the info as HTML
+func parseIniFiles(info string) (string, []string) {
+	info = htmlInfoCell.ReplaceAllString(info, " => ")
+	info = htmlTag.ReplaceAllString(info, "")
+
+	var loadedConfig string
+	additional := []string{}
+	inAdditional := false
+	for _, line := range strings.Split(info, "\n") {
+		line = strings.TrimSpace(line)
+		if inAdditional {
+			// the list continues on the following lines until the next setting
+			if line == "" || strings.Contains(line, "=>") {
+				inAdditional = false
+			} else {
+				additional = append(additional, splitIniList(line)...)
+				continue
+			}
+		}
+		keyVal := strings.SplitN(line, "=>", 2)
+		if len(keyVal) != 2 {
+			continue
+		}
+		value := strings.TrimSpace(keyVal[1])
+		switch strings.TrimSpace(keyVal[0]) {
+		case "Loaded Configuration File":
+			if value != "(none)" {
+				loadedConfig = value
+			}
+		case "Additional .ini files parsed":
+			if value != "(none)" {
+				additional = append(additional, splitIniList(value)...)
+			}
+			inAdditional = true
+		}
+	}
+	return loadedConfig, additional
+}
+
+func splitIniList(list string) []string {
+	files := []string{}
+	for _, file := range strings.Split(list, ",") {
+		if file = strings.TrimSpace(file); file != "" {
+			files = append(files, file)
+		}
+	}
+	return files
+}
+
+// findFpmBinaries - returns the php-fpm binaries on the PATH and the versioned ones distributions install in sbin.
+// Symlinks are resolved so a php-fpm link to php-fpm8.2 is only checked once
+func findFpmBinaries() []string {
+	candidates := []string{}
+	if path, err := exec.LookPath("php-fpm"); err == nil {
+		candidates = append(candidates, path)
+	}
+	for _, dir := range []string{"/usr/sbin", "/usr/local/sbin"} {
+		matches, _ := filepath.Glob(filepath.Join(dir, "php-fpm*"))
+		candidates = append(candidates, matches...)
+	}
+	found := map[string]bool{}
+	for _, candidate := range candidates {
+		if resolved, err := filepath.EvalSymlinks(candidate); err == nil {
+			candidate = resolved
+		}
+		found[candidate] = true
+	}
+	binaries := []string{}
+	for binary := range found {
+		binaries = append(binaries, binary)
+	}
+	sort.Strings(binaries)
+	return binaries
+}
@@ -0,0 +1,120 @@
+package agent
+
+import (
+	"errors"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+const (
+	phpModulesWithNewRelic    = "[PHP Modules]\nCore\ncurl\nnewrelic\nopenssl\n\n[Zend Modules]\nZend OPcache\n"
+	phpModulesWithoutNewRelic = "[PHP Modules]\nCore\ncurl\nopenssl\n\n[Zend Modules]\n"
+	phpCLIInfo                = "phpinfo()\nPHP Version => 8.2.7\n\nServer API => Command Line Interface\nLoaded Configuration File => /etc/php/8.2/cli/php.ini\nScan this dir for additional .ini files => /etc/php/8.2/cli/conf.d\nAdditional .ini files parsed => /etc/php/8.2/cli/conf.d/10-opcache.ini,\n/etc/php/8.2/cli/conf.d/20-curl.ini,\n/etc/php/8.2/cli/conf.d/newrelic.ini\n\nPHP API => 20220829\n"
+	phpFPMInfo                = "<tr><td class=\"e\">Loaded Configuration File </td><td class=\"v\">/etc/php/8.2/fpm/php.ini </td></tr>\n<tr><td class=\"e\">Additional .ini files parsed </td><td class=\"v\">/etc/php/8.2/fpm/conf.d/10-opcache.ini,\n/etc/php/8.2/fpm/conf.d/20-curl.ini\n</td></tr>\n"
+)
+
+var _ = Describe("PHP/Agent/Extension", func() {
+	var (
+		p        PHPAgentExtension
+		outputs  map[string]string
+		upstream map[string]tasks.Result
+		fpm      []string
+		result   tasks.Result
+	)
+
+	BeforeEach(func() {
+		upstream = map[string]tasks.Result{
+			"PHP/Config/Agent": {Status: tasks.Success},
+		}
+		outputs = map[string]string{
+			"php -m": phpModulesWithNewRelic,
+			"php -i": phpCLIInfo,
+		}
+		fpm = []string{}
+	})
+
+	JustBeforeEach(func() {
+		p = PHPAgentExtension{
+			cmdExec: func(name string, arg ...string) ([]byte, error) {
+				output, ok := outputs[name+" "+arg[0]]
+				if !ok {
+					return nil, errors.New("exec: \"" + name + "\": executable file not found in $PATH")
+				}
+				return []byte(output), nil
+			},
+			findFpmBinaries: func() []string { return fpm },
+		}
+		result = p.Execute(tasks.Options{}, upstream)
+	})
+
+	Context("when the PHP agent was not detected", func() {
+		BeforeEach(func() {
+			upstream["PHP/Config/Agent"] = tasks.Result{Status: tasks.None}
+		})
+		It("should return a None result", func() {
+			Expect(result.Status).To(Equal(tasks.None))
+		})
+	})
+
+	Context("when the extension is loaded by the CLI and FPM", func() {
+		BeforeEach(func() {
+			fpm = []string{"/usr/sbin/php-fpm8.2"}
+			outputs["/usr/sbin/php-fpm8.2 -m"] = phpModulesWithNewRelic
+			outputs["/usr/sbin/php-fpm8.2 -i"] = phpFPMInfo
+		})
+		It("should return a Success result with the breakdown per SAPI", func() {
+			Expect(result.Status).To(Equal(tasks.Success))
+			Expect(result.Summary).To(Equal("The newrelic extension is loaded by php (cli) from /etc/php/8.2/cli/conf.d/newrelic.ini.\n" +
+				"The newrelic extension is loaded by /usr/sbin/php-fpm8.2 (fpm) from /etc/php/8.2/fpm/php.ini."))
+			Expect(result.Payload).To(Equal([]PHPSapiExtension{
+				{Sapi: "cli", Binary: "php", Loaded: true, LoadedConfigFile: "/etc/php/8.2/cli/php.ini", NewRelicIniFiles: []string{"/etc/php/8.2/cli/conf.d/newrelic.ini"}},
+				{Sapi: "fpm", Binary: "/usr/sbin/php-fpm8.2", Loaded: true, LoadedConfigFile: "/etc/php/8.2/fpm/php.ini", NewRelicIniFiles: []string{}},
+			}))
+		})
+	})
+
+	Context("when the extension is loaded by the CLI but not FPM", func() {
+		BeforeEach(func() {
+			fpm = []string{"/usr/sbin/php-fpm8.2"}
+			outputs["/usr/sbin/php-fpm8.2 -m"] = phpModulesWithoutNewRelic
+			outputs["/usr/sbin/php-fpm8.2 -i"] = phpFPMInfo
+		})
+		It("should return a Warning result", func() {
+			Expect(result.Status).To(Equal(tasks.Warning))
+			Expect(result.Summary).To(ContainSubstring("The newrelic extension is not loaded by /usr/sbin/php-fpm8.2 (fpm)."))
+			Expect(result.Summary).To(ContainSubstring("web requests are not monitored"))
+		})
+	})
+
+	Context("when the extension is not loaded at all", func() {
+		BeforeEach(func() {
+			outputs["php -m"] = phpModulesWithoutNewRelic
+		})
+		It("should return a Failure result", func() {
+			Expect(result.Status).To(Equal(tasks.Failure))
+			Expect(result.Summary).To(HavePrefix("The newrelic extension is not loaded by php (cli)."))
+		})
+	})
+
+	Context("when two newrelic.ini files are parsed", func() {
+		BeforeEach(func() {
+			outputs["php -i"] = "Additional .ini files parsed => /etc/php.d/newrelic.ini, /etc/php.d/zz-newrelic.ini\n"
+		})
+		It("should return a Warning result", func() {
+			Expect(result.Status).To(Equal(tasks.Warning))
+			Expect(result.Summary).To(ContainSubstring("More than one newrelic.ini is parsed by php"))
+		})
+	})
+
+	Context("when neither php nor php-fpm can be run", func() {
+		BeforeEach(func() {
+			outputs = map[string]string{}
+		})
+		It("should return an Error result", func() {
+			Expect(result.Status).To(Equal(tasks.Error))
+			Expect(result.Summary).To(ContainSubstring("executable file not found"))
+		})
+	})
+})
@@ -10,4 +10,7 @@ func RegisterWith(registrationFunc func(tasks.Task, bool)) {
 	log.Debug("Registering Python/Agent/*")
 
 	registrationFunc(PythonAgentVersion{}, true)
+	registrationFunc(PythonAgentStartup{
+		listPythonProcesses: listPythonProcesses,
+	}, true)
 }
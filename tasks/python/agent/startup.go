@@ -0,0 +1,189 @@
+package agent
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	"github.com/shirou/gopsutil/v3/process"
+)
+
+// The ways the Python agent can be bootstrapped that are visible from outside of the application code
+const (
+	startupAdminScript = "newrelic-admin run-program"
+	startupBootstrap   = "newrelic-admin bootstrap on PYTHONPATH"
+	startupConfigEnv   = "NEW_RELIC_CONFIG_FILE"
+	startupConfigArg   = "newrelic.ini argument"
+)
+
+// pythonProcessName matches the interpreters and the servers commonly launching Python applications
+var pythonProcessName = regexp.MustCompile(`^(python[0-9.]*|gunicorn|uwsgi|uvicorn|celery|daphne|hypercorn|newrelic-admin)$`)
+
+// PythonProcess - a running Python process and how the agent is bootstrapped in it
+type PythonProcess struct {
+	Pid         int32
+	Cmdline     []string
+	Startup     string
+	ConfigFile  string
+	Environment string
+	env         map[string]string
+}
+
+// PythonAgentStartup - This struct defines the task detecting how the Python agent is initialized
+type PythonAgentStartup struct {
+	listPythonProcesses func() ([]PythonProcess, error)
+}
+
+// Identifier - This returns the Category, Subcategory and Name of this task.
+func (t PythonAgentStartup) Identifier() tasks.Identifier {
+	return tasks.IdentifierFromString("Python/Agent/Startup")
+}
+
+// Explain - Returns the help text for the PythonAgentStartup task.
+func (t PythonAgentStartup) Explain() string {
+	return "Detect how the New Relic Python agent is initialized in the running Python processes"
+}
+
+// Dependencies - Returns the dependencies for this task.
+func (t PythonAgentStartup) Dependencies() []string {
+	return []string{
+		"Python/Config/Agent",
+		"Base/Env/CollectEnvVars",
+	}
+}
+
+// Execute - The core work within this task.
+func (t PythonAgentStartup) Execute(options tasks.Options, upstream map[string]tasks.Result) tasks.Result {
+	if upstream["Python/Config/Agent"].Status != tasks.Success {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "Python agent not detected",
+		}
+	}
+
+	procs, err := t.listPythonProcesses()
+	if err != nil {
+		return tasks.Result{
+			Status:  tasks.Error,
+			Summary: "We encountered an error while detecting the running Python processes: " + err.Error(),
+		}
+	}
+
+	if len(procs) == 0 {
+		envVars, _ := upstream["Base/Env/CollectEnvVars"].Payload.(map[string]string)
+		if configFile := envVars["NEW_RELIC_CONFIG_FILE"]; configFile != "" {
+			return tasks.Result{
+				Status:  tasks.Info,
+				Summary: "No running Python process was found. NEW_RELIC_CONFIG_FILE is set to " + configFile + " in this shell, an application started from it with newrelic-admin run-program or newrelic.agent.initialize() will load that configuration.",
+			}
+		}
+		return tasks.Result{
+			Status:  tasks.Warning,
+			Summary: "No running Python process was found and NEW_RELIC_CONFIG_FILE is not set in this shell. The Python agent only reports once the application is started with newrelic-admin run-program or calls newrelic.agent.initialize('newrelic.ini'). Please re-run " + tasks.ThisProgramFullName + " while your application is running.",
+			URL:     "https://docs.newrelic.com/docs/apm/agents/python-agent/installation/standard-python-agent-install/",
+		}
+	}
+
+	var detected, undetected []string
+	for i, proc := range procs {
+		procs[i] = detectPythonStartup(proc)
+		if procs[i].Startup == "" {
+			undetected = append(undetected, fmt.Sprint(proc.Pid))
+			continue
+		}
+		summary := fmt.Sprintf("Python process %d is initialized with %s", proc.Pid, procs[i].Startup)
+		if procs[i].Environment != "" {
+			summary += " using the [newrelic:" + procs[i].Environment + "] section of the configuration"
+		}
+		detected = append(detected, summary+".")
+	}
+	log.Debug("Python processes startup", procs)
+
+	if len(undetected) > 0 {
+		return tasks.Result{
+			Status: tasks.Warning,
+			Summary: "No New Relic agent initialization was detected for the Python process(es) " + strings.Join(undetected, ", ") + ". " +
+				"If the application does not call newrelic.agent.initialize() itself, start it with newrelic-admin run-program and NEW_RELIC_CONFIG_FILE set to the path of newrelic.ini.",
+			URL:     "https://docs.newrelic.com/docs/apm/agents/python-agent/installation/standard-python-agent-install/",
+			Payload: procs,
+		}
+	}
+	return tasks.Result{
+		Status:  tasks.Success,
+		Summary: strings.Join(detected, "\n"),
+		Payload: procs,
+	}
+}
+
+// detectPythonStartup - sets the startup mechanism of a process from its command line and environment. newrelic-admin
+// run-program execs the application, so it is mostly found in the environment it leaves behind
+func detectPythonStartup(proc PythonProcess) PythonProcess {
+	proc.ConfigFile = proc.env["NEW_RELIC_CONFIG_FILE"]
+	proc.Environment = proc.env["NEW_RELIC_ENVIRONMENT"]
+
+	for i, arg := range proc.Cmdline {
+		if filepath.Base(arg) == "newrelic-admin" && i+1 < len(proc.Cmdline) &&
+			(proc.Cmdline[i+1] == "run-program" || proc.Cmdline[i+1] == "run-python") {
+			proc.Startup = startupAdminScript
+			return proc
+		}
+	}
+	if proc.env["NEW_RELIC_ADMIN_COMMAND"] != "" {
+		proc.Startup = startupAdminScript
+		return proc
+	}
+	for _, path := range filepath.SplitList(proc.env["PYTHONPATH"]) {
+		if filepath.Base(path) == "bootstrap" && filepath.Base(filepath.Dir(path)) == "newrelic" {
+			proc.Startup = startupBootstrap
+			return proc
+		}
+	}
+	if proc.ConfigFile != "" {
+		proc.Startup = startupConfigEnv
+		return proc
+	}
+	for _, arg := range proc.Cmdline {
+		if strings.HasSuffix(arg, ".ini") && strings.Contains(strings.ToLower(filepath.Base(arg)), "newrelic") {
+			proc.Startup = startupConfigArg
+			proc.ConfigFile = arg
+			return proc
+		}
+	}
+	return proc
+}
+
+// listPythonProcesses - returns the running Python processes with the variables of their environment used to
+// bootstrap the agent. The environment of processes owned by other users can't always be read
+func listPythonProcesses() ([]PythonProcess, error) {
+	procs, err := process.Processes()
+	if err != nil {
+		return nil, err
+	}
+	pythonProcs := []PythonProcess{}
+	for _, proc := range procs {
+		name, err := proc.Name()
+		if err != nil || !pythonProcessName.MatchString(strings.TrimSuffix(name, ".exe")) {
+			continue
+		}
+		cmdline, err := proc.CmdlineSlice()
+		if err != nil {
+			log.Debug("Error getting the command line of Python process", proc.Pid, err)
+		}
+		environ, err := proc.Environ()
+		if err != nil {
+			log.Debug("Error getting the environment of Python process", proc.Pid, err)
+		}
+		env := map[string]string{}
+		for _, variable := range environ {
+			keyVal := strings.SplitN(variable, "=", 2)
+			if len(keyVal) == 2 && (strings.HasPrefix(keyVal[0], "NEW_RELIC_") || keyVal[0] == "PYTHONPATH") {
+				env[keyVal[0]] = keyVal[1]
+			}
+		}
+		pythonProcs = append(pythonProcs, PythonProcess{Pid: proc.Pid, Cmdline: cmdline, env: env})
+	}
+	return pythonProcs, nil
+}
@@ -0,0 +1,104 @@
+package agent
+
+import (
+	"errors"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Python/Agent/Startup", func() {
+	var (
+		p        PythonAgentStartup
+		upstream map[string]tasks.Result
+		procs    []PythonProcess
+		procsErr error
+		result   tasks.Result
+	)
+
+	BeforeEach(func() {
+		upstream = map[string]tasks.Result{
+			"Python/Config/Agent":     {Status: tasks.Success},
+			"Base/Env/CollectEnvVars": {Status: tasks.Info, Payload: map[string]string{}},
+		}
+		procs = []PythonProcess{}
+		procsErr = nil
+	})
+
+	JustBeforeEach(func() {
+		p = PythonAgentStartup{
+			listPythonProcesses: func() ([]PythonProcess, error) { return procs, procsErr },
+		}
+		result = p.Execute(tasks.Options{}, upstream)
+	})
+
+	Context("when the Python agent is not installed", func() {
+		BeforeEach(func() {
+			upstream["Python/Config/Agent"] = tasks.Result{Status: tasks.None}
+		})
+		It("should return a None result", func() {
+			Expect(result.Status).To(Equal(tasks.None))
+		})
+	})
+
+	Context("when the processes can't be listed", func() {
+		BeforeEach(func() {
+			procsErr = errors.New("permission denied")
+		})
+		It("should return an Error result", func() {
+			Expect(result.Status).To(Equal(tasks.Error))
+			Expect(result.Summary).To(Equal("We encountered an error while detecting the running Python processes: permission denied"))
+		})
+	})
+
+	Context("when no Python process is running", func() {
+		It("should return a Warning result without NEW_RELIC_CONFIG_FILE", func() {
+			Expect(result.Status).To(Equal(tasks.Warning))
+			Expect(result.Summary).To(ContainSubstring("NEW_RELIC_CONFIG_FILE is not set in this shell"))
+		})
+		Context("and NEW_RELIC_CONFIG_FILE is set in the shell", func() {
+			BeforeEach(func() {
+				upstream["Base/Env/CollectEnvVars"] = tasks.Result{Status: tasks.Info, Payload: map[string]string{"NEW_RELIC_CONFIG_FILE": "/etc/newrelic.ini"}}
+			})
+			It("should return an Info result", func() {
+				Expect(result.Status).To(Equal(tasks.Info))
+				Expect(result.Summary).To(ContainSubstring("NEW_RELIC_CONFIG_FILE is set to /etc/newrelic.ini"))
+			})
+		})
+	})
+
+	Context("when every Python process initializes the agent", func() {
+		BeforeEach(func() {
+			procs = []PythonProcess{
+				{Pid: 10, Cmdline: []string{"/usr/bin/python3", "/venv/bin/newrelic-admin", "run-program", "gunicorn", "app:app"}},
+				{Pid: 11, Cmdline: []string{"/venv/bin/python3", "/venv/bin/gunicorn", "app:app"}, env: map[string]string{"PYTHONPATH": "/venv/lib/python3.11/site-packages/newrelic/bootstrap", "NEW_RELIC_ENVIRONMENT": "staging"}},
+				{Pid: 12, Cmdline: []string{"python", "worker.py"}, env: map[string]string{"NEW_RELIC_CONFIG_FILE": "/app/newrelic.ini"}},
+				{Pid: 13, Cmdline: []string{"uwsgi", "--ini", "uwsgi.ini", "--pyargv", "/app/newrelic.ini"}},
+			}
+		})
+		It("should return a Success result with the startup of each process", func() {
+			Expect(result.Status).To(Equal(tasks.Success))
+			Expect(result.Summary).To(Equal("Python process 10 is initialized with newrelic-admin run-program.\n" +
+				"Python process 11 is initialized with newrelic-admin bootstrap on PYTHONPATH using the [newrelic:staging] section of the configuration.\n" +
+				"Python process 12 is initialized with NEW_RELIC_CONFIG_FILE.\n" +
+				"Python process 13 is initialized with newrelic.ini argument."))
+			payload := result.Payload.([]PythonProcess)
+			Expect(payload[2].ConfigFile).To(Equal("/app/newrelic.ini"))
+			Expect(payload[3].ConfigFile).To(Equal("/app/newrelic.ini"))
+		})
+	})
+
+	Context("when a Python process doesn't initialize the agent", func() {
+		BeforeEach(func() {
+			procs = []PythonProcess{
+				{Pid: 10, Cmdline: []string{"newrelic-admin", "run-program", "python", "app.py"}},
+				{Pid: 20, Cmdline: []string{"python3", "manage.py", "runserver"}},
+			}
+		})
+		It("should return a Warning result naming the process", func() {
+			Expect(result.Status).To(Equal(tasks.Warning))
+			Expect(result.Summary).To(HavePrefix("No New Relic agent initialization was detected for the Python process(es) 20."))
+		})
+	})
+})
@@ -0,0 +1,46 @@
+package tasks
+
+// Effort is how much work fixing the issue reported by a Warning or Failure result takes, from the least work to the most
+type Effort string
+
+// The effort levels of Remediation
+const (
+	// EffortConfigChange - a setting of the agent, its environment or nrdiag to change, e.g. the proxy or the license key
+	EffortConfigChange Effort = "config change"
+	// EffortUpgrade - the agent, the runtime or the OS to upgrade to a supported version
+	EffortUpgrade Effort = "upgrade"
+	// EffortNetworkChange - a firewall, routing or DNS change, usually made by the network team of the customer
+	EffortNetworkChange Effort = "network change"
+	// EffortEngineering - the investigation of an engineer, the cause being unknown or in New Relic itself
+	EffortEngineering Effort = "engineering"
+)
+
+// Remediation tags the issue reported by a result with what it takes to fix it, so it can be routed to the customer or
+// to an engineer without parsing the summary
+type Remediation struct {
+	Effort Effort
+	// CustomerServiceable is true when the customer can fix the issue on their own by following the summary and URL
+	CustomerServiceable bool
+	// AutomatedFix is true when a tool can apply the fix, e.g. the agent installer or a package manager
+	AutomatedFix bool
+}
+
+// ConfigChangeRemediation - the remediation of an issue the customer fixes by changing a setting
+func ConfigChangeRemediation() *Remediation {
+	return &Remediation{
+		Effort:              EffortConfigChange,
+		CustomerServiceable: true,
+	}
+}
+
+// String - the remediation as shown in the reports, e.g. "config change, customer-serviceable"
+func (r Remediation) String() string {
+	tags := string(r.Effort)
+	if r.CustomerServiceable {
+		tags += ", customer-serviceable"
+	}
+	if r.AutomatedFix {
+		tags += ", automated fix available"
+	}
+	return tags
+}
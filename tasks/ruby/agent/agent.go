@@ -1,6 +1,9 @@
 package agent
 
 import (
+	"os"
+	"path/filepath"
+
 	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
 	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
 )
@@ -9,5 +12,9 @@ import (
 func RegisterWith(registrationFunc func(tasks.Task, bool)) {
 	log.Debug("Registering Ruby/Agent/*")
 	registrationFunc(RubyAgentVersion{}, true)
+	registrationFunc(RubyAgentRequireCheck{
+		readFile: os.ReadFile,
+		glob:     filepath.Glob,
+	}, true)
 
 }
@@ -0,0 +1,300 @@
+package agent
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+)
+
+const (
+	frameworkRails   = "Rails"
+	frameworkSinatra = "Sinatra"
+	frameworkNone    = "none"
+)
+
+var (
+	agentGemRegex      = regexp.MustCompile(`^\s*gem\s+['"]` + agentGemName + `['"](.*)$`)
+	frameworkGemRegex  = regexp.MustCompile(`^\s*gem\s+['"](rails|sinatra)['"]`)
+	requireFalseRegex  = regexp.MustCompile(`(require:|:require\s*=>)\s*false`)
+	inlineGroupRegex   = regexp.MustCompile(`(group:|groups:|:groups?\s*=>)\s*\[?([:\w, ]+)\]?`)
+	groupBlockRegex    = regexp.MustCompile(`^\s*group\s+(.+?)\s+do\s*$`)
+	blockStartRegex    = regexp.MustCompile(`\bdo\s*(\|[^|]*\|)?\s*$`)
+	blockEndRegex      = regexp.MustCompile(`^\s*end\b`)
+	groupNameRegex     = regexp.MustCompile(`:(\w+)`)
+	agentRequireRegex  = regexp.MustCompile(`^\s*require\s*\(?\s*['"]` + agentGemName + `['"]`)
+	sinatraRequireRe   = regexp.MustCompile(`^\s*require\s*\(?\s*['"]sinatra(/base)?['"]`)
+	bundlerRequireRe   = regexp.MustCompile(`^\s*Bundler\.require\b`)
+	productionGroupSet = map[string]bool{"default": true, "production": true, "staging": true}
+)
+
+// RubyRequireCheck - how newrelic_rpm is declared in a Gemfile and loaded by the application next to it
+type RubyRequireCheck struct {
+	Gemfile          string
+	Framework        string
+	GemDeclared      bool
+	GemGroups        []string
+	RequireFalse     bool
+	BundlerRequire   []string
+	ExplicitRequires []string
+	Findings         []string
+
+	sinatraGemFirst bool
+}
+
+// RubyAgentRequireCheck - This task checks that newrelic_rpm is required early enough to instrument the application
+type RubyAgentRequireCheck struct {
+	readFile func(string) ([]byte, error)
+	glob     func(string) ([]string, error)
+}
+
+// Identifier - This returns the Category, Subcategory and Name of each task
+func (t RubyAgentRequireCheck) Identifier() tasks.Identifier {
+	return tasks.IdentifierFromString("Ruby/Agent/RequireCheck")
+}
+
+// Explain - Returns the help text for each individual task
+func (t RubyAgentRequireCheck) Explain() string {
+	return "Check that the New Relic Ruby agent gem is required early enough by the application"
+}
+
+// Dependencies - Returns the dependencies for each task.
+func (t RubyAgentRequireCheck) Dependencies() []string {
+	return []string{
+		"Ruby/Config/Collect",
+	}
+}
+
+// Execute - The core work within each task
+func (t RubyAgentRequireCheck) Execute(options tasks.Options, upstream map[string]tasks.Result) tasks.Result {
+	if upstream["Ruby/Config/Collect"].Status != tasks.Success {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "Either no Gemfile or newrelic.yml was found",
+		}
+	}
+
+	gemfiles, ok := upstream["Ruby/Config/Collect"].Payload.([]string)
+	if !ok {
+		return tasks.Result{
+			Status:  tasks.Error,
+			Summary: tasks.AssertionErrorSummary,
+		}
+	}
+
+	checks := []RubyRequireCheck{}
+	for _, gemfile := range gemfiles {
+		if filepath.Base(gemfile) != "Gemfile" {
+			continue
+		}
+		content, err := t.readFile(gemfile)
+		if err != nil {
+			log.Debug("Unable to read", gemfile, err)
+			continue
+		}
+		checks = append(checks, t.checkGemfile(gemfile, string(content)))
+	}
+	if len(checks) == 0 {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "No readable Gemfile was found",
+		}
+	}
+
+	status := tasks.Success
+	summaries := []string{}
+	for _, check := range checks {
+		if len(check.Findings) == 0 {
+			summaries = append(summaries, fmt.Sprintf("%s: %s is required early enough by the %s.", check.Gemfile, agentGemName, frameworkDescription(check.Framework)))
+			continue
+		}
+		status = tasks.Warning
+		summaries = append(summaries, fmt.Sprintf("%s: %s may not be loaded early enough by the %s:", check.Gemfile, agentGemName, frameworkDescription(check.Framework)))
+		for _, finding := range check.Findings {
+			summaries = append(summaries, " - "+finding)
+		}
+	}
+
+	result := tasks.Result{
+		Status:  status,
+		Summary: strings.Join(summaries, "\n"),
+		Payload: checks,
+	}
+	if status == tasks.Warning {
+		result.URL = "https://docs.newrelic.com/docs/apm/agents/ruby-agent/installation/install-new-relic-ruby-agent/"
+	}
+	return result
+}
+
+func frameworkDescription(framework string) string {
+	switch framework {
+	case frameworkRails:
+		return "Rails application"
+	case frameworkSinatra:
+		return "Sinatra application (no Rails)"
+	}
+	return "Ruby application (neither Rails nor Sinatra)"
+}
+
+// checkGemfile - looks at how the agent gem is declared in the Gemfile, then at where the application requires it
+func (t RubyAgentRequireCheck) checkGemfile(gemfile string, content string) RubyRequireCheck {
+	check := RubyRequireCheck{Gemfile: gemfile, Framework: frameworkNone}
+	appDir := filepath.Dir(gemfile)
+
+	var blockGroups [][]string
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.SplitN(line, "#", 2)[0]
+		if match := frameworkGemRegex.FindStringSubmatch(line); match != nil {
+			if match[1] == "rails" {
+				check.Framework = frameworkRails
+			} else if check.Framework == frameworkNone {
+				check.Framework = frameworkSinatra
+				check.sinatraGemFirst = !check.GemDeclared
+			}
+		}
+		if match := groupBlockRegex.FindStringSubmatch(line); match != nil {
+			blockGroups = append(blockGroups, groupNames(match[1]))
+			continue
+		}
+		if blockStartRegex.MatchString(line) {
+			blockGroups = append(blockGroups, nil)
+			continue
+		}
+		if blockEndRegex.MatchString(line) && len(blockGroups) > 0 {
+			blockGroups = blockGroups[:len(blockGroups)-1]
+			continue
+		}
+		if match := agentGemRegex.FindStringSubmatch(line); match != nil {
+			check.GemDeclared = true
+			check.RequireFalse = requireFalseRegex.MatchString(match[1])
+			for _, groups := range blockGroups {
+				check.GemGroups = append(check.GemGroups, groups...)
+			}
+			if inline := inlineGroupRegex.FindStringSubmatch(match[1]); inline != nil {
+				check.GemGroups = append(check.GemGroups, groupNames(inline[2])...)
+			}
+		}
+	}
+	// a Rails application directory has config/application.rb even when rails is pulled in by another gem
+	if _, err := t.readFile(filepath.Join(appDir, "config", "application.rb")); err == nil {
+		check.Framework = frameworkRails
+	}
+
+	if !check.GemDeclared {
+		check.Findings = append(check.Findings, agentGemName+" is not declared in the Gemfile. Add gem '"+agentGemName+"' so Bundler loads the agent.")
+		return check
+	}
+	if len(check.GemGroups) > 0 && !inProductionGroups(check.GemGroups) {
+		check.Findings = append(check.Findings, fmt.Sprintf("%s is only declared in the %s group(s), it is not loaded in production. Move it out of the group.", agentGemName, strings.Join(check.GemGroups, ", ")))
+	}
+
+	switch check.Framework {
+	case frameworkRails:
+		t.checkRailsRequire(appDir, &check)
+	case frameworkSinatra:
+		t.checkSinatraRequire(appDir, &check)
+	default:
+		t.checkRequire(appDir, []string{"config.ru", "*.rb", "lib/*.rb", "bin/*", "config/*.rb"}, &check)
+		if len(check.ExplicitRequires) == 0 && (check.RequireFalse || len(check.BundlerRequire) == 0) {
+			check.Findings = append(check.Findings, "neither require '"+agentGemName+"' nor Bundler.require was found. Require the agent once the application code is loaded.")
+		}
+	}
+	return check
+}
+
+// checkRailsRequire - Bundler.require(*Rails.groups) in config/application.rb loads the agent before Rails initializes,
+// an explicit require in an initializer comes too late to install the instrumentation
+func (t RubyAgentRequireCheck) checkRailsRequire(appDir string, check *RubyRequireCheck) {
+	t.checkRequire(appDir, []string{"config/application.rb", "config/boot.rb", "config/environment.rb"}, check)
+	earlyRequire := len(check.ExplicitRequires) > 0
+	t.checkRequire(appDir, []string{"config/initializers/*.rb"}, check)
+
+	if !check.RequireFalse && len(check.BundlerRequire) > 0 {
+		return
+	}
+	if earlyRequire {
+		return
+	}
+	if len(check.ExplicitRequires) > 0 {
+		check.Findings = append(check.Findings, agentGemName+" is only required from "+strings.Join(check.ExplicitRequires, ", ")+", initializers run too late for the agent to instrument Rails. Require it in config/application.rb after require 'rails/all'.")
+		return
+	}
+	if check.RequireFalse {
+		check.Findings = append(check.Findings, "the gem is declared with require: false and is not required in config/application.rb. Remove require: false from the Gemfile.")
+		return
+	}
+	check.Findings = append(check.Findings, "config/application.rb does not call Bundler.require(*Rails.groups). Add it, or require '"+agentGemName+"' in config/application.rb.")
+}
+
+// checkSinatraRequire - the agent only instruments Sinatra when it is required after sinatra
+func (t RubyAgentRequireCheck) checkSinatraRequire(appDir string, check *RubyRequireCheck) {
+	for _, pattern := range []string{"config.ru", "*.rb", "app/*.rb", "lib/*.rb"} {
+		files, _ := t.glob(filepath.Join(appDir, pattern))
+		for _, file := range files {
+			content, err := t.readFile(file)
+			if err != nil {
+				continue
+			}
+			sinatraRequired := false
+			for _, line := range strings.Split(string(content), "\n") {
+				if sinatraRequireRe.MatchString(line) {
+					sinatraRequired = true
+				}
+				if bundlerRequireRe.MatchString(line) {
+					check.BundlerRequire = append(check.BundlerRequire, file)
+				}
+				if agentRequireRegex.MatchString(line) {
+					check.ExplicitRequires = append(check.ExplicitRequires, file)
+					if !sinatraRequired {
+						check.Findings = append(check.Findings, file+" requires "+agentGemName+" before sinatra. Require it after require 'sinatra' so the agent detects the framework.")
+					}
+				}
+			}
+		}
+	}
+	// Bundler.require loads the gems in the Gemfile order
+	if len(check.ExplicitRequires) == 0 && (check.RequireFalse || len(check.BundlerRequire) == 0 || !check.sinatraGemFirst) {
+		check.Findings = append(check.Findings, "require '"+agentGemName+"' was not found after require 'sinatra'. Require the agent after Sinatra, or declare gem '"+agentGemName+"' after gem 'sinatra' when using Bundler.require.")
+	}
+}
+
+// checkRequire - records the files matching the patterns that require the agent or call Bundler.require
+func (t RubyAgentRequireCheck) checkRequire(appDir string, patterns []string, check *RubyRequireCheck) {
+	for _, pattern := range patterns {
+		files, _ := t.glob(filepath.Join(appDir, pattern))
+		for _, file := range files {
+			content, err := t.readFile(file)
+			if err != nil {
+				continue
+			}
+			for _, line := range strings.Split(string(content), "\n") {
+				if bundlerRequireRe.MatchString(line) {
+					check.BundlerRequire = append(check.BundlerRequire, file)
+				}
+				if agentRequireRegex.MatchString(line) {
+					check.ExplicitRequires = append(check.ExplicitRequires, file)
+				}
+			}
+		}
+	}
+}
+
+func groupNames(groups string) []string {
+	names := []string{}
+	for _, match := range groupNameRegex.FindAllStringSubmatch(groups, -1) {
+		names = append(names, match[1])
+	}
+	return names
+}
+
+func inProductionGroups(groups []string) bool {
+	for _, group := range groups {
+		if productionGroupSet[group] {
+			return true
+		}
+	}
+	return false
+}
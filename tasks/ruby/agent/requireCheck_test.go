@@ -0,0 +1,157 @@
+package agent
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestRubyAgent(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Ruby/Agent test suite")
+}
+
+var _ = Describe("Ruby/Agent/RequireCheck", func() {
+	var (
+		p        RubyAgentRequireCheck
+		files    map[string]string
+		upstream map[string]tasks.Result
+		result   tasks.Result
+	)
+
+	BeforeEach(func() {
+		files = map[string]string{}
+		upstream = map[string]tasks.Result{
+			"Ruby/Config/Collect": {Status: tasks.Success, Payload: []string{"/app/Gemfile", "/app/Gemfile.lock"}},
+		}
+	})
+
+	JustBeforeEach(func() {
+		p = RubyAgentRequireCheck{
+			readFile: func(path string) ([]byte, error) {
+				content, ok := files[path]
+				if !ok {
+					return nil, os.ErrNotExist
+				}
+				return []byte(content), nil
+			},
+			glob: func(pattern string) ([]string, error) {
+				matches := []string{}
+				for path := range files {
+					if ok, _ := filepath.Match(pattern, path); ok {
+						matches = append(matches, path)
+					}
+				}
+				sort.Strings(matches)
+				return matches, nil
+			},
+		}
+		result = p.Execute(tasks.Options{}, upstream)
+	})
+
+	Context("when no Gemfile was collected", func() {
+		BeforeEach(func() {
+			upstream["Ruby/Config/Collect"] = tasks.Result{Status: tasks.Warning}
+		})
+		It("should return a None result", func() {
+			Expect(result.Status).To(Equal(tasks.None))
+		})
+	})
+
+	Context("when a Rails application loads the gem with Bundler.require", func() {
+		BeforeEach(func() {
+			files["/app/Gemfile"] = "source 'https://rubygems.org'\ngem 'rails', '~> 7.0'\ngem 'newrelic_rpm'\ngroup :development, :test do\n  gem 'rspec-rails'\nend\n"
+			files["/app/config/application.rb"] = "require_relative 'boot'\nrequire 'rails/all'\nBundler.require(*Rails.groups)\n"
+		})
+		It("should return a Success result", func() {
+			Expect(result.Status).To(Equal(tasks.Success))
+			Expect(result.Summary).To(Equal("/app/Gemfile: newrelic_rpm is required early enough by the Rails application."))
+			checks := result.Payload.([]RubyRequireCheck)
+			Expect(checks[0].BundlerRequire).To(Equal([]string{"/app/config/application.rb"}))
+		})
+	})
+
+	Context("when the gem is only in the development group", func() {
+		BeforeEach(func() {
+			files["/app/Gemfile"] = "gem 'rails'\ngroup :development, :test do\n  gem 'newrelic_rpm'\nend\n"
+			files["/app/config/application.rb"] = "Bundler.require(*Rails.groups)\n"
+		})
+		It("should return a Warning result", func() {
+			Expect(result.Status).To(Equal(tasks.Warning))
+			Expect(result.Summary).To(ContainSubstring("newrelic_rpm is only declared in the development, test group(s)"))
+			Expect(result.URL).NotTo(BeEmpty())
+		})
+	})
+
+	Context("when a Rails application only requires the gem from an initializer", func() {
+		BeforeEach(func() {
+			files["/app/Gemfile"] = "gem 'rails'\ngem 'newrelic_rpm', require: false\n"
+			files["/app/config/application.rb"] = "Bundler.require(*Rails.groups)\n"
+			files["/app/config/initializers/newrelic.rb"] = "require 'newrelic_rpm'\n"
+		})
+		It("should return a Warning result about the late require", func() {
+			Expect(result.Status).To(Equal(tasks.Warning))
+			Expect(result.Summary).To(ContainSubstring("newrelic_rpm is only required from /app/config/initializers/newrelic.rb, initializers run too late"))
+		})
+	})
+
+	Context("when a Rails application declares the gem with require: false", func() {
+		BeforeEach(func() {
+			files["/app/Gemfile"] = "gem 'rails'\ngem 'newrelic_rpm', :require => false\n"
+			files["/app/config/application.rb"] = "Bundler.require(*Rails.groups)\n"
+		})
+		It("should return a Warning result", func() {
+			Expect(result.Status).To(Equal(tasks.Warning))
+			Expect(result.Summary).To(ContainSubstring("the gem is declared with require: false"))
+		})
+	})
+
+	Context("when the gem is not in the Gemfile", func() {
+		BeforeEach(func() {
+			files["/app/Gemfile"] = "gem 'rails'\n# gem 'newrelic_rpm'\n"
+		})
+		It("should return a Warning result", func() {
+			Expect(result.Status).To(Equal(tasks.Warning))
+			Expect(result.Summary).To(ContainSubstring("newrelic_rpm is not declared in the Gemfile"))
+		})
+	})
+
+	Context("when a Sinatra application requires the agent after sinatra", func() {
+		BeforeEach(func() {
+			files["/app/Gemfile"] = "gem 'sinatra'\ngem 'newrelic_rpm'\n"
+			files["/app/app.rb"] = "require 'sinatra'\nrequire 'newrelic_rpm'\n"
+		})
+		It("should return a Success result naming Sinatra", func() {
+			Expect(result.Status).To(Equal(tasks.Success))
+			Expect(result.Summary).To(Equal("/app/Gemfile: newrelic_rpm is required early enough by the Sinatra application (no Rails)."))
+		})
+	})
+
+	Context("when a Sinatra application requires the agent before sinatra", func() {
+		BeforeEach(func() {
+			files["/app/Gemfile"] = "gem 'newrelic_rpm'\ngem 'sinatra'\n"
+			files["/app/config.ru"] = "require 'newrelic_rpm'\nrequire 'sinatra'\nrun Sinatra::Application\n"
+		})
+		It("should return a Warning result about the order", func() {
+			Expect(result.Status).To(Equal(tasks.Warning))
+			Expect(result.Summary).To(ContainSubstring("Sinatra application (no Rails)"))
+			Expect(result.Summary).To(ContainSubstring("/app/config.ru requires newrelic_rpm before sinatra"))
+		})
+	})
+
+	Context("when a Sinatra application relies on Bundler.require with the agent declared first", func() {
+		BeforeEach(func() {
+			files["/app/Gemfile"] = "gem 'newrelic_rpm'\ngem 'sinatra'\n"
+			files["/app/config.ru"] = "require 'bundler'\nBundler.require\nrun Sinatra::Application\n"
+		})
+		It("should return a Warning result", func() {
+			Expect(result.Status).To(Equal(tasks.Warning))
+			Expect(result.Summary).To(ContainSubstring("declare gem 'newrelic_rpm' after gem 'sinatra'"))
+		})
+	})
+})
@@ -0,0 +1,177 @@
+package minion
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/helpers/httpHelper"
+	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	baseConfig "github.com/newrelic/newrelic-diagnostics-cli/tasks/base/config"
+)
+
+const privateLocationNetworksURL = "https://docs.newrelic.com/docs/new-relic-solutions/get-started/networks#synthetics-private"
+
+// minionEndpoint - the private location API host the minions of a region poll for jobs
+type minionEndpoint struct {
+	region string // region as reported by Base/Config/RegionDetect, e.g. eu01
+	host   string
+}
+
+var minionEndpoints = []minionEndpoint{
+	{region: "us01", host: "synthetics-horde.nr-data.net"},
+	{region: "eu01", host: "synthetics-horde.eu01.nr-data.net"},
+	{region: "gov01", host: "gov-synthetics-horde.nr-data.net"},
+}
+
+// MinionEndpointResult - whether a private location endpoint could be reached from this host
+type MinionEndpointResult struct {
+	Region     string
+	URL        string
+	Reachable  bool
+	StatusCode int    `json:",omitempty"`
+	LatencyMs  int64  `json:",omitempty"`
+	Error      string `json:",omitempty"`
+}
+
+// SyntheticsMinionConnect - This task checks the private location API endpoints can be reached from the minion host
+type SyntheticsMinionConnect struct {
+	httpGetter func(httpHelper.RequestWrapper) (*http.Response, error)
+}
+
+// Identifier - This returns the Category, Subcategory and Name of each task
+func (p SyntheticsMinionConnect) Identifier() tasks.Identifier {
+	return tasks.IdentifierFromString("Synthetics/Minion/Connect")
+}
+
+// Explain - Returns the help text for each individual task
+func (p SyntheticsMinionConnect) Explain() string {
+	return "Check network connection to the New Relic Synthetics private location endpoints (only runs when provided with -t)"
+}
+
+// Dependencies - This task depends on Base/Config/ProxyDetect and Base/Config/RegionDetect
+func (p SyntheticsMinionConnect) Dependencies() []string {
+	return []string{
+		"Base/Config/ProxyDetect", //we are not using the payload of this task, but we want to make sure that it was already detected and set before running any HTTP request
+		"Base/Config/RegionDetect",
+	}
+}
+
+// RequiresNetwork - This task connects to the private location endpoints, it is skipped with -offline
+func (p SyntheticsMinionConnect) RequiresNetwork() {}
+
+// Execute - Requests the private location endpoint of each detected region
+func (p SyntheticsMinionConnect) Execute(options tasks.Options, upstream map[string]tasks.Result) tasks.Result {
+	// Only private minion hosts need to reach these endpoints, so the check is opt-in
+	if !options.IsForced() {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "This task only runs when requested with -t " + p.Identifier().String(),
+		}
+	}
+
+	var results []MinionEndpointResult
+	var summaries []string
+	status := tasks.Success
+	for _, endpoint := range p.getEndpoints(upstream) {
+		result := p.checkEndpoint(endpoint.region, "https://"+endpoint.host+"/")
+		results = append(results, result)
+		if !result.Reachable {
+			status = tasks.Failure
+			summaries = append(summaries, "There was an error connecting to "+result.URL+" ("+endpoint.region+")\nError = "+result.Error)
+			continue
+		}
+		summaries = append(summaries, fmt.Sprintf("Successfully connected to %s (%s), Status = %d, Latency = %dms", result.URL, endpoint.region, result.StatusCode, result.LatencyMs))
+	}
+
+	summary := strings.Join(summaries, "\n")
+	if status == tasks.Failure {
+		summary += "\nPlease check network and proxy settings of the minion host, it must reach these endpoints to receive monitor jobs."
+	}
+	summary += proxySummary(upstream)
+	result := tasks.Result{
+		Status:  status,
+		Summary: summary,
+		Payload: results,
+	}
+	if status == tasks.Failure {
+		result.URL = privateLocationNetworksURL
+	}
+	return result
+}
+
+// getEndpoints - returns the endpoints of the detected regions, defaulting to US
+func (p SyntheticsMinionConnect) getEndpoints(upstream map[string]tasks.Result) []minionEndpoint {
+	detected, _ := upstream["Base/Config/RegionDetect"].Payload.([]string)
+	var endpoints []minionEndpoint
+	for _, endpoint := range minionEndpoints {
+		if tasks.StringInSlice(endpoint.region, detected) {
+			endpoints = append(endpoints, endpoint)
+		}
+	}
+	if len(endpoints) == 0 {
+		return minionEndpoints[:1]
+	}
+	return endpoints
+}
+
+// checkEndpoint - any HTTP response means the endpoint is reachable, the API answers requests without a location key
+// with an authorization error
+func (p SyntheticsMinionConnect) checkEndpoint(region string, url string) MinionEndpointResult {
+	endpoint := MinionEndpointResult{Region: region, URL: url}
+	wrapper := httpHelper.RequestWrapper{
+		Method: "GET",
+		URL:    url,
+		// Ctrl-C or -deadline stops the request and its retries instead of waiting out the timeout
+		Context: httpHelper.RunContext(),
+		// a single dropped connection should not be reported as a failure
+		RetryCount:          2,
+		RetryBackoffSeconds: 1,
+	}
+	start := time.Now()
+	resp, err := p.httpGetter(wrapper)
+	latency := time.Since(start)
+	if err != nil {
+		log.Debug("Error connecting to", url, ":", err)
+		endpoint.Error = err.Error() + attemptsSummary(err)
+		return endpoint
+	}
+	defer resp.Body.Close()
+
+	endpoint.Reachable = true
+	endpoint.StatusCode = resp.StatusCode
+	endpoint.LatencyMs = latency.Milliseconds()
+	return endpoint
+}
+
+// attemptsSummary - reports how many attempts were made when a request failed after being retried
+func attemptsSummary(e error) string {
+	var retryErr httpHelper.RetryError
+	if !errors.As(e, &retryErr) {
+		return ""
+	}
+	return "\nAttempts = " + strconv.Itoa(retryErr.Attempts)
+}
+
+// proxySummary - summary line reporting the proxy the requests went through, with any credentials redacted
+func proxySummary(upstream map[string]tasks.Result) string {
+	proxy := ""
+	if proxyConfig, ok := upstream["Base/Config/ProxyDetect"].Payload.(baseConfig.ProxyConfig); ok {
+		proxy = proxyConfig.URL()
+	}
+	for _, key := range []string{"HTTPS_PROXY", "https_proxy", "HTTP_PROXY", "http_proxy", "ALL_PROXY", "all_proxy"} {
+		if proxy != "" {
+			break
+		}
+		proxy = os.Getenv(key)
+	}
+	if proxy == "" {
+		return "\nProxy = no proxy"
+	}
+	return "\nProxy = " + httpHelper.RedactProxyURL(proxy)
+}
@@ -0,0 +1,99 @@
+package minion
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/helpers/httpHelper"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	baseConfig "github.com/newrelic/newrelic-diagnostics-cli/tasks/base/config"
+)
+
+func TestSyntheticsMinionConnect_Execute(t *testing.T) {
+	forbidden := func(wrapper httpHelper.RequestWrapper) (*http.Response, error) {
+		return &http.Response{StatusCode: 403, Body: ioutil.NopCloser(strings.NewReader("Forbidden"))}, nil
+	}
+	tests := []struct {
+		name        string
+		forced      bool
+		regions     []string
+		httpGetter  func(httpHelper.RequestWrapper) (*http.Response, error)
+		want        tasks.Status
+		wantURLs    []string
+		wantSummary string
+	}{
+		{
+			name:   "should not run unless provided with -t",
+			forced: false,
+			httpGetter: func(wrapper httpHelper.RequestWrapper) (*http.Response, error) {
+				t.Error("no request should have been made")
+				return nil, nil
+			},
+			want: tasks.None,
+		},
+		{
+			name:        "should default to the US endpoint and report it reachable on any response",
+			forced:      true,
+			httpGetter:  forbidden,
+			want:        tasks.Success,
+			wantURLs:    []string{"https://synthetics-horde.nr-data.net/"},
+			wantSummary: "Successfully connected to https://synthetics-horde.nr-data.net/ (us01), Status = 403",
+		},
+		{
+			name:       "should check the endpoint of each detected region",
+			forced:     true,
+			regions:    []string{"eu01", "gov01"},
+			httpGetter: forbidden,
+			want:       tasks.Success,
+			wantURLs:   []string{"https://synthetics-horde.eu01.nr-data.net/", "https://gov-synthetics-horde.nr-data.net/"},
+		},
+		{
+			name:    "should return a Failure result when an endpoint can't be reached",
+			forced:  true,
+			regions: []string{"us01", "eu01"},
+			httpGetter: func(wrapper httpHelper.RequestWrapper) (*http.Response, error) {
+				if strings.Contains(wrapper.URL, "eu01") {
+					return nil, httpHelper.RetryError{Attempts: 3, Err: errors.New("dial tcp: i/o timeout")}
+				}
+				return forbidden(wrapper)
+			},
+			want:        tasks.Failure,
+			wantURLs:    []string{"https://synthetics-horde.nr-data.net/", "https://synthetics-horde.eu01.nr-data.net/"},
+			wantSummary: "There was an error connecting to https://synthetics-horde.eu01.nr-data.net/ (eu01)\nError = dial tcp: i/o timeout\nAttempts = 3",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := tasks.Options{Options: map[string]string{}}
+			if tt.forced {
+				options.Options[tasks.ForcedOption] = "true"
+			}
+
+			var requested []string
+			p := SyntheticsMinionConnect{
+				httpGetter: func(wrapper httpHelper.RequestWrapper) (*http.Response, error) {
+					requested = append(requested, wrapper.URL)
+					return tt.httpGetter(wrapper)
+				},
+			}
+			upstream := map[string]tasks.Result{
+				"Base/Config/RegionDetect": {Status: tasks.Info, Payload: tt.regions},
+				"Base/Config/ProxyDetect":  {Status: tasks.Success, Payload: baseConfig.ProxyConfig{}},
+			}
+			got := p.Execute(options, upstream)
+			if got.Status != tt.want {
+				t.Errorf("Execute() = %v, want %v", got, tt.want)
+			}
+			if !reflect.DeepEqual(requested, tt.wantURLs) {
+				t.Errorf("Execute() requested %v, want %v", requested, tt.wantURLs)
+			}
+			if !strings.Contains(got.Summary, tt.wantSummary) {
+				t.Errorf("Execute() summary = %q, want it to contain %q", got.Summary, tt.wantSummary)
+			}
+		})
+	}
+}
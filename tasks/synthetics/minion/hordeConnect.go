@@ -38,6 +38,9 @@ func (p SyntheticsMinionHordeConnect) Dependencies() []string {
 	}
 }
 
+// RequiresNetwork - This task connects to the Synthetics horde endpoint, it is skipped with -offline
+func (p SyntheticsMinionHordeConnect) RequiresNetwork() {}
+
 // Execute - Uses parsed private location settings key to perform a simple HTTP request to horde
 func (p SyntheticsMinionHordeConnect) Execute(options tasks.Options, upstream map[string]tasks.Result) tasks.Result {
 	var result tasks.Result
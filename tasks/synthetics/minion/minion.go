@@ -1,6 +1,7 @@
 package minion
 
 import (
+	"github.com/newrelic/newrelic-diagnostics-cli/helpers/httpHelper"
 	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
 	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
 )
@@ -12,6 +13,7 @@ func RegisterWith(registrationFunc func(tasks.Task, bool)) {
 	registrationFunc(SyntheticsMinionDetect{}, false)
 	registrationFunc(SyntheticsMinionConfigValidate{}, false)
 	registrationFunc(SyntheticsMinionHordeConnect{}, false)
+	registrationFunc(SyntheticsMinionConnect{httpGetter: httpHelper.MakeHTTPRequest}, false)
 	registrationFunc(SyntheticsMinionDetectCPM{executeCommand: tasks.CmdExecutor}, true)
 	registrationFunc(SyntheticsMinionCollectLogs{executeCommand: tasks.BufferedCommandExec}, true)
 }
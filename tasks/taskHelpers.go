@@ -19,6 +19,7 @@ import (
 	"strings"
 	"sync"
 
+	"github.com/newrelic/newrelic-diagnostics-cli/config"
 	"github.com/newrelic/newrelic-diagnostics-cli/helpers/httpHelper"
 	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
 	"github.com/shirou/gopsutil/v3/process"
@@ -68,6 +69,35 @@ func FindFiles(patterns []string, paths []string) []string {
 	return uniqueFoundFiles
 }
 
+// ProcIdAndArgs - a Java process running the New Relic agent with its command line args, the payload of Java/Env/Process
+type ProcIdAndArgs struct {
+	Proc        process.Process
+	CmdLineArgs []string
+	Cwd         string
+	JarPath     string
+	EnvVars     map[string]string
+}
+
+// LogElement - a New Relic log file found by the Base/Log tasks, the payload of Base/Log/Copy. It is in this package so
+// Base/Config/Permissions can read it, tasks/base/log imports tasks/base/config
+type LogElement struct {
+	FileName           string
+	FilePath           string
+	Source             LogSourceData
+	IsSecureLocation   bool
+	CanCollect         bool
+	ReasonToNotCollect string
+	Truncated          bool   `json:",omitempty"`
+	TruncationNote     string `json:",omitempty"`
+}
+
+// LogSourceData - how a log file was found and its full path
+type LogSourceData struct {
+	FoundBy  string
+	KeyVals  map[string]string
+	FullPath string
+}
+
 // FindProcessByNameFunc - allows FindProcessByName to be dependency injected
 type FindProcessByNameFunc func(string) ([]process.Process, error)
 
@@ -127,19 +157,35 @@ func FileExists(name string) bool {
 	return true
 }
 
-// PromptUser - This takes the input string as the query to the end users and waits for a response
+// promptLock keeps the prompts of tasks running in parallel from interleaving
+var promptLock sync.Mutex
+
+// PromptUser - This takes the input string as the query to the end users and waits for a response. -y answers yes
+// without asking, whether or not it was passed in the options
 func PromptUser(msg string, options Options) bool {
-	if options.Options["YesToAll"] == "true" {
+	if options.Options["YesToAll"] == "true" || config.Flags.YesToAll {
 		return true
 	}
+	return AskUser(msg)
+}
+
+// AskUser - shows the question on the terminal and waits for a 'y' or 'n' answer. Every prompt of nrdiag goes
+// through it after checking -y, it is a variable so tests can check -y never lets it be called
+var AskUser = askUser
+
+func askUser(msg string) bool {
+	promptLock.Lock()
+	defer promptLock.Unlock()
 
 	prompt := "Choose 'y' or 'n', then press enter: "
 	yesResponses := []string{"y", "yes"}
 	noResponses := []string{"n", "no"}
 
+	// the prompts go with the log messages, to stderr when stdout carries the results of -stream or -single
+	out := log.Output()
 	scanner := bufio.NewScanner(os.Stdin)
-	fmt.Println(msg)
-	fmt.Print(prompt)
+	fmt.Fprintln(out, msg)
+	fmt.Fprint(out, prompt)
 
 	for scanner.Scan() {
 		userInput := strings.ToLower(scanner.Text())
@@ -153,7 +199,7 @@ func PromptUser(msg string, options Options) bool {
 		}
 
 		//Repeat prompt if invalid input is provided
-		fmt.Printf("%s", prompt)
+		fmt.Fprint(out, prompt)
 	}
 	return false
 }
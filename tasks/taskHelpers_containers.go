@@ -6,12 +6,33 @@ import (
 	"errors"
 	"fmt"
 	"os/exec"
+	"path/filepath"
 	"sort"
 	"strings"
 
 	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
 )
 
+// ContainerEnvironment - defines the payload of Base/Env/DetectContainer. It is in this package so the Base/Config tasks
+// reading it don't import tasks/base/env, which reads their payloads
+type ContainerEnvironment struct {
+	Runtime string
+	// HostRoot is where the host filesystem is mounted in the container, empty when it is not
+	HostRoot string `json:",omitempty"`
+}
+
+// HostPaths - returns paths as seen from the container through the mounted host filesystem, nil when it is not mounted
+func (c ContainerEnvironment) HostPaths(paths []string) []string {
+	if c.HostRoot == "" {
+		return nil
+	}
+	var hostPaths []string
+	for _, path := range paths {
+		hostPaths = append(hostPaths, filepath.Join(c.HostRoot, path)+string(filepath.Separator))
+	}
+	return hostPaths
+}
+
 type DockerInfo struct {
 	Driver        string
 	ServerVersion string
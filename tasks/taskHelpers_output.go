@@ -1,6 +1,16 @@
 package tasks
 
-import "github.com/newrelic/newrelic-diagnostics-cli/output/color"
+import (
+	"errors"
+	"strings"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/output/color"
+)
+
+// statusSeverity lists the statuses from least to most severe. The Status constants are not declared in that
+// order (Info came last), so comparisons between statuses have to go through this list rather than the values.
+// Error ranks above Failure: the state of the system could not be determined, so it can't be assumed to be any better
+var statusSeverity = []Status{None, Success, Info, Warning, Failure, Error}
 
 func (s Status) GetColor() color.Color {
 	switch s {
@@ -29,3 +39,28 @@ func (s Status) StatusToString() string {
 func (r Result) StatusToString() string {
 	return r.Status.StatusToString()
 }
+
+// Severity returns the rank of the status in statusSeverity, higher is more severe
+func (s Status) Severity() int {
+	for rank, status := range statusSeverity {
+		if status == s {
+			return rank
+		}
+	}
+	return -1
+}
+
+// IsAtLeast returns true if the status is as severe as, or more severe than, the minimum status
+func (s Status) IsAtLeast(minimum Status) bool {
+	return s.Severity() >= minimum.Severity()
+}
+
+// StatusFromString parses a status name such as "warning", ignoring case
+func StatusFromString(status string) (Status, error) {
+	for _, s := range statusSeverity {
+		if strings.EqualFold(strings.TrimSpace(status), s.StatusToString()) {
+			return s, nil
+		}
+	}
+	return None, errors.New("unknown status '" + status + "'. Accepted values: None, Success, Info, Warning, Failure, Error")
+}
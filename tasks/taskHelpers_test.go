@@ -1,13 +1,19 @@
 package tasks
 
 import (
+	"bufio"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"os"
 	"path/filepath"
 	"reflect"
 	"runtime"
 	"sort"
+	"strings"
 	"testing"
 
+	"github.com/newrelic/newrelic-diagnostics-cli/config"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 )
@@ -802,3 +808,77 @@ var _ = Describe("Task Helpers", func() {
 	})
 
 })
+
+func TestStatus_IsAtLeast(t *testing.T) {
+	ascending := []Status{None, Success, Info, Warning, Failure, Error}
+	for i, status := range ascending {
+		for j, minimum := range ascending {
+			if got := status.IsAtLeast(minimum); got != (i >= j) {
+				t.Errorf("%s.IsAtLeast(%s) = %v, want %v", status.StatusToString(), minimum.StatusToString(), got, i >= j)
+			}
+		}
+	}
+}
+
+func TestStatusFromString(t *testing.T) {
+	for _, name := range []string{"warning", "Warning", " WARNING "} {
+		status, err := StatusFromString(name)
+		if err != nil || status != Warning {
+			t.Errorf("StatusFromString(%q) = %v, %v, want Warning", name, status, err)
+		}
+	}
+	if _, err := StatusFromString("critical"); err == nil {
+		t.Error("StatusFromString(\"critical\") expected an error")
+	}
+}
+
+func Test_askUser_stream(t *testing.T) {
+	dir := t.TempDir()
+	openFile := func(name string, content string) *os.File {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		file, err := os.OpenFile(path, os.O_RDWR|os.O_APPEND, 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return file
+	}
+	stdin, stdout, stderr := openFile("stdin", "maybe\ny\n"), openFile("stdout", ""), openFile("stderr", "")
+	originalStdin, originalStdout, originalStderr := os.Stdin, os.Stdout, os.Stderr
+	os.Stdin, os.Stdout, os.Stderr = stdin, stdout, stderr
+	config.Flags.Stream = true
+	defer func() {
+		os.Stdin, os.Stdout, os.Stderr = originalStdin, originalStdout, originalStderr
+		config.Flags.Stream = false
+		stdin.Close()
+		stdout.Close()
+		stderr.Close()
+	}()
+
+	// a prompt between two streamed results
+	fmt.Fprintln(os.Stdout, `{"Identifier":"Test/Prompt/Before","Status":"Info"}`)
+	answer := askUser("Collect the file?")
+	fmt.Fprintln(os.Stdout, `{"Identifier":"Test/Prompt/After","Status":"Info"}`)
+	if !answer {
+		t.Error("askUser() = false, want the 'y' answered after the invalid input")
+	}
+
+	streamed, _ := os.ReadFile(stdout.Name())
+	scanner := bufio.NewScanner(strings.NewReader(string(streamed)))
+	lines := 0
+	for scanner.Scan() {
+		var line map[string]interface{}
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			t.Fatalf("Expected stdout to stay NDJSON, got %q: %s", scanner.Text(), err)
+		}
+		lines++
+	}
+	if lines != 2 {
+		t.Errorf("Expected the 2 streamed results on stdout, got:\n%s", streamed)
+	}
+	if prompted, _ := os.ReadFile(stderr.Name()); !strings.Contains(string(prompted), "Collect the file?") {
+		t.Errorf("Expected the prompt on stderr, got %q", prompted)
+	}
+}
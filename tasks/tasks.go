@@ -3,6 +3,7 @@ package tasks
 import (
 	"encoding/json"
 	"fmt"
+	"path/filepath"
 	"strings"
 
 	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
@@ -15,6 +16,12 @@ type Result struct {
 	URL         string             // a URL pointing to documention about this task; "required" on Warning or Failure, desireable on any status
 	FilesToCopy []FileCopyEnvelope // List of files identified by the task to be included in zip file
 	Payload     interface{}        // task defined list of returned data. This is what is used by downstream tasks so data format agreements are between tasks
+	// PayloadVersion is the schema version of the Payload in the output files, bumped by the task whenever the shape of
+	// its payload changes. Zero, and left out of the output, when the task does not version its payload
+	PayloadVersion int `json:",omitempty"`
+	// Remediation tags a Warning or Failure with the effort to fix it and who can, nil and left out of the output when
+	// the task doesn't tag its result
+	Remediation *Remediation `json:",omitempty"`
 }
 
 // Status statusEnum listing of valid values for status
@@ -78,6 +85,37 @@ type Task interface {
 	Execute(Options, map[string]Result) Result
 }
 
+// NetworkDependent is implemented by the tasks making outbound network requests, e.g. through httpHelper. With -offline
+// the runner reports them as skipped instead of executing them
+type NetworkDependent interface {
+	Task
+	// RequiresNetwork only marks the task, it is never called
+	RequiresNetwork()
+}
+
+// IsNetworkDependent returns true if the task makes outbound network requests
+func IsNetworkDependent(t Task) bool {
+	_, ok := t.(NetworkDependent)
+	return ok
+}
+
+// TimeoutExplanation is appended to the Explain() text of the tasks whose requests use the -http-timeout flag
+const TimeoutExplanation = " (requests time out after -http-timeout seconds, default 30)"
+
+// ElevationRequirer is implemented by the tasks that only work as root or Administrator. When RequiresElevation returns
+// true and the process is not elevated, the runner reports the task as a Warning instead of executing it
+type ElevationRequirer interface {
+	Task
+	RequiresElevation(options Options) bool
+}
+
+// RequiresElevation returns true if the task needs root or Administrator privileges for this run, with the options it
+// would run with
+func RequiresElevation(t Task, options Options) bool {
+	requirer, ok := t.(ElevationRequirer)
+	return ok && requirer.RequiresElevation(options)
+}
+
 //ByIdentifier is a sort helper to sort an array of tasks by their identifiers
 type ByIdentifier []Task
 
@@ -105,6 +143,33 @@ type Options struct { // what goes in here? maybe this also should be string to
 	Options map[string]string // Map of core and task specific options
 }
 
+// IncludePathsOption - the key of the -include-path directories in Options, joined with os.PathListSeparator
+const IncludePathsOption = "includePaths"
+
+// IncludePaths - the directories given with -include-path, searched for config and log files along with the default locations
+func (o Options) IncludePaths() []string {
+	if o.Options[IncludePathsOption] == "" {
+		return nil
+	}
+	return filepath.SplitList(o.Options[IncludePathsOption])
+}
+
+// ForcedOption - the key set in the Options of a task explicitly requested, with -t or -single on the command line
+const ForcedOption = "forced"
+
+// IsForced - whether the task was explicitly requested, for the tasks that only run when they are
+func (o Options) IsForced() bool {
+	return o.Options[ForcedOption] == "true"
+}
+
+// OfflineOption - the key set in Options when running without network access, as with -offline
+const OfflineOption = "offline"
+
+// IsOffline - whether the run is offline: the network tasks are skipped, and the others must not make requests either
+func (o Options) IsOffline() bool {
+	return o.Options[OfflineOption] == "true"
+}
+
 // Identifier contains the task's name, category and subcategory
 type Identifier struct {
 	Category    string